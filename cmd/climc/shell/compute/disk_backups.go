@@ -0,0 +1,115 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func init() {
+	type DiskBackupListOptions struct {
+		options.BaseListOptions
+
+		Disk       string `help:"disk id or name" json:"disk"`
+		ServerId   string `help:"server id" json:"server_id"`
+		ChainId    string `help:"list backups of chain" json:"chain_id"`
+		BackupMode string `help:"backup mode" choices:"full|incremental" json:"backup_mode"`
+	}
+	R(&DiskBackupListOptions{}, "disk-backup-list", "Show disk backups", func(s *mcclient.ClientSession, args *DiskBackupListOptions) error {
+		params, err := options.ListStructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.DiskBackups.List(s, params)
+		if err != nil {
+			return err
+		}
+		printList(result, modules.DiskBackups.GetColumns(s))
+		return nil
+	})
+
+	type DiskBackupShowOptions struct {
+		ID string `help:"ID or Name of disk backup"`
+	}
+	R(&DiskBackupShowOptions{}, "disk-backup-show", "Show disk backup details", func(s *mcclient.ClientSession, args *DiskBackupShowOptions) error {
+		result, err := modules.DiskBackups.Get(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type DiskBackupCreateOptions struct {
+		DISK string `help:"ID or Name of disk to backup"`
+		NAME string `help:"Name of disk backup"`
+		Mode string `help:"Backup mode, incremental backup falls back to full if there is no valid parent" choices:"full|incremental"`
+	}
+	R(&DiskBackupCreateOptions{}, "disk-backup-create", "Create a backup of disk", func(s *mcclient.ClientSession, args *DiskBackupCreateOptions) error {
+		params := jsonutils.NewDict()
+		params.Set("disk", jsonutils.NewString(args.DISK))
+		params.Set("name", jsonutils.NewString(args.NAME))
+		if len(args.Mode) > 0 {
+			params.Set("backup_mode", jsonutils.NewString(args.Mode))
+		}
+		result, err := modules.DiskBackups.Create(s, params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type DiskBackupDeleteOptions struct {
+		ID []string `help:"ID of disk backups to delete"`
+	}
+	R(&DiskBackupDeleteOptions{}, "disk-backup-delete", "Delete disk backups", func(s *mcclient.ClientSession, args *DiskBackupDeleteOptions) error {
+		ret := modules.DiskBackups.BatchDelete(s, args.ID, nil)
+		printBatchResults(ret, modules.DiskBackups.GetColumns(s))
+		return nil
+	})
+
+	type DiskBackupRestoreOptions struct {
+		ID string `help:"ID of disk backup to restore"`
+	}
+	R(&DiskBackupRestoreOptions{}, "disk-backup-restore", "Restore disk from backup, server must be stopped", func(s *mcclient.ClientSession, args *DiskBackupRestoreOptions) error {
+		result, err := modules.DiskBackups.PerformAction(s, args.ID, "restore", nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type DiskBackupPruneOptions struct {
+		DISK       string `help:"ID or Name of disk"`
+		KeepChains int    `help:"Number of latest backup chains to keep" default:"1"`
+	}
+	R(&DiskBackupPruneOptions{}, "disk-backup-prune", "Delete old backup chains of disk", func(s *mcclient.ClientSession, args *DiskBackupPruneOptions) error {
+		params := jsonutils.NewDict()
+		params.Set("disk", jsonutils.NewString(args.DISK))
+		params.Set("keep_chains", jsonutils.NewInt(int64(args.KeepChains)))
+		result, err := modules.DiskBackups.PerformClassAction(s, "prune", params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+}
@@ -0,0 +1,134 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func init() {
+	type DrsPolicyListOptions struct {
+		options.BaseListOptions
+
+		ZoneId     string `help:"zone id or name" json:"zone_id"`
+		SchedtagId string `help:"schedtag id or name" json:"schedtag_id"`
+		Mode       string `help:"drs mode" choices:"manual|auto" json:"mode"`
+	}
+	R(&DrsPolicyListOptions{}, "drs-policy-list", "List drs policies", func(s *mcclient.ClientSession, args *DrsPolicyListOptions) error {
+		params, err := options.ListStructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.DrsPolicies.List(s, params)
+		if err != nil {
+			return err
+		}
+		printList(result, modules.DrsPolicies.GetColumns(s))
+		return nil
+	})
+
+	type DrsPolicyShowOptions struct {
+		ID string `help:"ID or Name of drs policy"`
+	}
+	R(&DrsPolicyShowOptions{}, "drs-policy-show", "Show drs policy details", func(s *mcclient.ClientSession, args *DrsPolicyShowOptions) error {
+		result, err := modules.DrsPolicies.Get(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type DrsPolicyCreateOptions struct {
+		NAME          string `help:"Name of drs policy" json:"name"`
+		Zone          string `help:"Rebalance hosts in zone" json:"zone_id"`
+		Schedtag      string `help:"Rebalance hosts tagged by schedtag" json:"schedtag"`
+		Mode          string `help:"Propose plan only or execute it automatically" choices:"manual|auto" json:"mode"`
+		Threshold     int    `help:"Tolerated standard deviation of host load in percent" json:"threshold"`
+		MaxMigrations int    `help:"Max migrations planned each round" json:"max_migrations"`
+		WindowStart   string `help:"Start of maintenance window, HH:MM" json:"window_start"`
+		WindowEnd     string `help:"End of maintenance window, HH:MM" json:"window_end"`
+	}
+	R(&DrsPolicyCreateOptions{}, "drs-policy-create", "Create a drs policy", func(s *mcclient.ClientSession, args *DrsPolicyCreateOptions) error {
+		params := jsonutils.Marshal(args)
+		result, err := modules.DrsPolicies.Create(s, params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type DrsPolicyUpdateOptions struct {
+		ID            string `help:"ID or Name of drs policy" json:"-"`
+		Mode          string `help:"Propose plan only or execute it automatically" choices:"manual|auto" json:"mode"`
+		Threshold     *int   `help:"Tolerated standard deviation of host load in percent" json:"threshold"`
+		MaxMigrations *int   `help:"Max migrations planned each round" json:"max_migrations"`
+		WindowStart   string `help:"Start of maintenance window, HH:MM" json:"window_start"`
+		WindowEnd     string `help:"End of maintenance window, HH:MM" json:"window_end"`
+	}
+	R(&DrsPolicyUpdateOptions{}, "drs-policy-update", "Update a drs policy", func(s *mcclient.ClientSession, args *DrsPolicyUpdateOptions) error {
+		params := jsonutils.Marshal(args)
+		result, err := modules.DrsPolicies.Update(s, args.ID, params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type DrsPolicyIdOptions struct {
+		ID string `help:"ID or Name of drs policy"`
+	}
+	R(&DrsPolicyIdOptions{}, "drs-policy-enable", "Enable drs policy", func(s *mcclient.ClientSession, args *DrsPolicyIdOptions) error {
+		result, err := modules.DrsPolicies.PerformAction(s, args.ID, "enable", nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&DrsPolicyIdOptions{}, "drs-policy-disable", "Disable drs policy", func(s *mcclient.ClientSession, args *DrsPolicyIdOptions) error {
+		result, err := modules.DrsPolicies.PerformAction(s, args.ID, "disable", nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&DrsPolicyIdOptions{}, "drs-policy-apply", "Apply migration plan proposed by last evaluation of drs policy", func(s *mcclient.ClientSession, args *DrsPolicyIdOptions) error {
+		result, err := modules.DrsPolicies.PerformAction(s, args.ID, "apply", nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&DrsPolicyIdOptions{}, "drs-policy-delete", "Delete a drs policy", func(s *mcclient.ClientSession, args *DrsPolicyIdOptions) error {
+		result, err := modules.DrsPolicies.Delete(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+}
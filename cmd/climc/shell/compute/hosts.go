@@ -706,6 +706,99 @@ func init() {
 		return nil
 	})
 
+	type HostBiosOptions struct {
+		ID string `help:"ID or name of host"`
+	}
+	R(&HostBiosOptions{}, "host-bios", "Get BIOS attributes of baremetal via Redfish", func(s *mcclient.ClientSession, args *HostBiosOptions) error {
+		result, err := modules.Hosts.GetSpecific(s, args.ID, "bios", nil)
+		if err != nil {
+			return err
+		}
+		fmt.Println(result.PrettyString())
+		return nil
+	})
+
+	type HostBiosSettingsOptions struct {
+		ID     string `help:"ID or name of host" json:"-"`
+		ATTRS  string `help:"BIOS attributes in JSON, e.g. {\"BootMode\":\"Uefi\"}" json:"-"`
+		Reboot bool   `help:"Reboot baremetal to apply the settings" json:"reboot"`
+	}
+	R(&HostBiosSettingsOptions{}, "host-bios-settings", "Set pending BIOS attributes of baremetal via Redfish", func(s *mcclient.ClientSession, args *HostBiosSettingsOptions) error {
+		attrs, err := jsonutils.ParseString(args.ATTRS)
+		if err != nil {
+			return err
+		}
+		params := jsonutils.Marshal(args).(*jsonutils.JSONDict)
+		params.Add(attrs, "attributes")
+		result, err := modules.Hosts.PerformAction(s, args.ID, "bios-settings", params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type HostFirmwareUpdateOptions struct {
+		ID       string   `help:"ID or name of host" json:"-"`
+		IMAGEURI string   `help:"URI of firmware image accessible by BMC" json:"image_uri"`
+		Protocol string   `help:"Transfer protocol, e.g. HTTP" json:"transfer_protocol"`
+		Target   []string `help:"Update targets" json:"targets"`
+	}
+	R(&HostFirmwareUpdateOptions{}, "host-firmware-update", "Push firmware to baremetal via Redfish UpdateService", func(s *mcclient.ClientSession, args *HostFirmwareUpdateOptions) error {
+		params := jsonutils.Marshal(args)
+		result, err := modules.Hosts.PerformAction(s, args.ID, "firmware-update", params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type HostRaidStoragesOptions struct {
+		ID string `help:"ID or name of host"`
+	}
+	R(&HostRaidStoragesOptions{}, "host-raid-storages", "Get storage controllers, drives and volumes of baremetal via Redfish", func(s *mcclient.ClientSession, args *HostRaidStoragesOptions) error {
+		result, err := modules.Hosts.GetSpecific(s, args.ID, "raid-storages", nil)
+		if err != nil {
+			return err
+		}
+		fmt.Println(result.PrettyString())
+		return nil
+	})
+
+	type HostRaidVolumeCreateOptions struct {
+		ID       string   `help:"ID or name of host" json:"-"`
+		RAID     string   `help:"RAID type, e.g. RAID1" json:"raid_type"`
+		DRIVE    []string `help:"Drive id or location" json:"drives"`
+		Storage  string   `help:"Storage controller id, default the first one" json:"storage"`
+		Name     string   `help:"Volume name" json:"name"`
+		Capacity int64    `help:"Capacity in GB, default all space of drives" json:"capacity_gb"`
+	}
+	R(&HostRaidVolumeCreateOptions{}, "host-raid-volume-create", "Create RAID volume on baremetal via Redfish", func(s *mcclient.ClientSession, args *HostRaidVolumeCreateOptions) error {
+		params := jsonutils.Marshal(args)
+		result, err := modules.Hosts.PerformAction(s, args.ID, "raid-volume-create", params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type HostRaidVolumeDeleteOptions struct {
+		ID      string `help:"ID or name of host" json:"-"`
+		VOLUME  string `help:"Volume id or name" json:"volume"`
+		Storage string `help:"Storage controller id, default the first one" json:"storage"`
+	}
+	R(&HostRaidVolumeDeleteOptions{}, "host-raid-volume-delete", "Delete RAID volume on baremetal via Redfish", func(s *mcclient.ClientSession, args *HostRaidVolumeDeleteOptions) error {
+		params := jsonutils.Marshal(args)
+		result, err := modules.Hosts.PerformAction(s, args.ID, "raid-volume-delete", params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
 	type HostSSHLoginOptions struct {
 		ID   string `help:"ID or name of host"`
 		Port int    `help:"SSH service port" default:"22"`
@@ -38,4 +38,23 @@ func init() {
 			return nil
 		},
 	)
+
+	type ScalingInstanceRefreshListOptions struct {
+		options.BaseListOptions
+		ScalingGroup string `help:"ScalingGroup ID or Name"`
+	}
+	R(&ScalingInstanceRefreshListOptions{}, "scaling-instance-refresh-list", "List Scaling Instance Refresh",
+		func(s *mcclient.ClientSession, args *ScalingInstanceRefreshListOptions) error {
+			params, err := options.ListStructToParams(args)
+			if err != nil {
+				return err
+			}
+			list, err := modules.ScalingInstanceRefresh.List(s, params)
+			if err != nil {
+				return err
+			}
+			printList(list, modules.ScalingInstanceRefresh.GetColumns(s))
+			return nil
+		},
+	)
 }
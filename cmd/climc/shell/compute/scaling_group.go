@@ -17,6 +17,7 @@ package compute
 import (
 	"yunion.io/x/jsonutils"
 
+	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/mcclient/modules"
 	"yunion.io/x/onecloud/pkg/mcclient/options"
@@ -142,4 +143,111 @@ func init() {
 		printObject(ret)
 		return nil
 	})
+	type ScalingGroupScaleInProtectionOptions struct {
+		ID      string   `help:"ScalingGroup ID or Name"`
+		SERVER  []string `help:"Server ID or Name"`
+		Disable bool     `help:"Disable the protection"`
+	}
+	R(&ScalingGroupScaleInProtectionOptions{}, "scaling-group-set-scale-in-protection",
+		"Protect instances of ScalingGroup from being removed by scaling in",
+		func(s *mcclient.ClientSession, args *ScalingGroupScaleInProtectionOptions) error {
+			input := api.ScalingGroupPerformScaleInProtectionInput{
+				Guests:    args.SERVER,
+				Protected: !args.Disable,
+			}
+			ret, err := modules.ScalingGroup.PerformAction(s, args.ID, "set-scale-in-protection", jsonutils.Marshal(input))
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
+
+	type ScalingGroupInstanceRefreshOptions struct {
+		ID                   string `help:"ScalingGroup ID or Name"`
+		GuestTemplate        string `help:"GuestTemplate ID or Name, the current one of ScalingGroup by default"`
+		MinHealthyPercentage *int   `help:"Min percentage of healthy instances during refresh, default 90"`
+		BatchSize            int    `help:"Number of instances replaced in a batch, calculated from min healthy percentage if 0"`
+		Pause                int    `help:"Pause between two batches, unit: s"`
+	}
+	R(&ScalingGroupInstanceRefreshOptions{}, "scaling-group-instance-refresh",
+		"Replace the instances of ScalingGroup with the instances created from GuestTemplate in batches",
+		func(s *mcclient.ClientSession, args *ScalingGroupInstanceRefreshOptions) error {
+			input := api.ScalingGroupPerformInstanceRefreshInput{
+				GuestTemplate:        args.GuestTemplate,
+				MinHealthyPercentage: args.MinHealthyPercentage,
+				BatchSize:            args.BatchSize,
+				Pause:                args.Pause,
+			}
+			ret, err := modules.ScalingGroup.PerformAction(s, args.ID, "instance-refresh", jsonutils.Marshal(input))
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
+
+	type ScalingGroupRollbackInstanceRefreshOptions struct {
+		ID string `help:"ScalingGroup ID or Name"`
+	}
+	R(&ScalingGroupRollbackInstanceRefreshOptions{}, "scaling-group-rollback-instance-refresh",
+		"Roll back the latest instance refresh of ScalingGroup",
+		func(s *mcclient.ClientSession, args *ScalingGroupRollbackInstanceRefreshOptions) error {
+			ret, err := modules.ScalingGroup.PerformAction(s, args.ID, "rollback-instance-refresh", nil)
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
+
+	type ScalingGroupLifecycleActionOptions struct {
+		ID              string `help:"ScalingGroup ID or Name"`
+		LifecycleAction string `help:"ScalingLifecycleAction ID"`
+		LifecycleHook   string `help:"ScalingLifecycleHook ID or Name, used with guest if lifecycle action is empty"`
+		Guest           string `help:"Guest ID or Name waiting for the lifecycle hook"`
+	}
+	type ScalingGroupCompleteLifecycleActionOptions struct {
+		ScalingGroupLifecycleActionOptions
+		Result string `help:"Result of lifecycle action" choices:"continue|abandon" default:"continue"`
+	}
+	R(&ScalingGroupCompleteLifecycleActionOptions{}, "scaling-group-complete-lifecycle-action",
+		"Complete the lifecycle action so that the scaling activity of ScalingGroup proceeds",
+		func(s *mcclient.ClientSession, args *ScalingGroupCompleteLifecycleActionOptions) error {
+			input := api.ScalingGroupCompleteLifecycleActionInput{
+				ScalingGroupLifecycleActionInput: api.ScalingGroupLifecycleActionInput{
+					LifecycleAction: args.LifecycleAction,
+					LifecycleHook:   args.LifecycleHook,
+					Guest:           args.Guest,
+				},
+				Result: args.Result,
+			}
+			ret, err := modules.ScalingGroup.PerformAction(s, args.ID, "complete-lifecycle-action", jsonutils.Marshal(input))
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
+
+	R(&ScalingGroupLifecycleActionOptions{}, "scaling-group-lifecycle-action-heartbeat",
+		"Postpone the timeout of the lifecycle action of ScalingGroup",
+		func(s *mcclient.ClientSession, args *ScalingGroupLifecycleActionOptions) error {
+			input := api.ScalingGroupLifecycleActionInput{
+				LifecycleAction: args.LifecycleAction,
+				LifecycleHook:   args.LifecycleHook,
+				Guest:           args.Guest,
+			}
+			ret, err := modules.ScalingGroup.PerformAction(s, args.ID, "lifecycle-action-heartbeat", jsonutils.Marshal(input))
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
 }
@@ -0,0 +1,124 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func init() {
+	type ScalingLifecycleHookListOptions struct {
+		options.BaseListOptions
+		ScalingGroup string `help:"ScalingGroup ID or Name"`
+		Transition   string `help:"Transition of lifecycle hook" choices:"launch|terminate"`
+	}
+	R(&ScalingLifecycleHookListOptions{}, "scaling-lifecycle-hook-list", "List Scaling Lifecycle Hook",
+		func(s *mcclient.ClientSession, args *ScalingLifecycleHookListOptions) error {
+			params, err := options.ListStructToParams(args)
+			if err != nil {
+				return err
+			}
+			list, err := modules.ScalingLifecycleHook.List(s, params)
+			if err != nil {
+				return err
+			}
+			printList(list, modules.ScalingLifecycleHook.GetColumns(s))
+			return nil
+		},
+	)
+
+	type ScalingLifecycleHookShowOptions struct {
+		ID string `help:"ScalingLifecycleHook ID or Name"`
+	}
+	R(&ScalingLifecycleHookShowOptions{}, "scaling-lifecycle-hook-show", "Show Scaling Lifecycle Hook",
+		func(s *mcclient.ClientSession, args *ScalingLifecycleHookShowOptions) error {
+			params := jsonutils.NewDict()
+			params.Set("details", jsonutils.JSONTrue)
+			ret, err := modules.ScalingLifecycleHook.Get(s, args.ID, params)
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
+
+	type ScalingLifecycleHookCreateOptions struct {
+		NAME                 string   `help:"ScalingLifecycleHook Name"`
+		ScalingGroup         string   `help:"ScalingGroup ID or Name" required:"true"`
+		Transition           string   `help:"Transition the hook waits for" choices:"launch|terminate" required:"true"`
+		HeartbeatTimeout     int      `help:"Timeout to wait for the lifecycle action to be completed, unit: s, default 300"`
+		DefaultResult        string   `help:"Result after timeout" choices:"continue|abandon"`
+		Receiver             []string `help:"Receivers of the notification"`
+		NotificationMetadata string   `help:"Additional information in the notification"`
+	}
+	R(&ScalingLifecycleHookCreateOptions{}, "scaling-lifecycle-hook-create", "Create Scaling Lifecycle Hook",
+		func(s *mcclient.ClientSession, args *ScalingLifecycleHookCreateOptions) error {
+			input := api.ScalingLifecycleHookCreateInput{
+				ScalingGroup:         args.ScalingGroup,
+				Transition:           args.Transition,
+				HeartbeatTimeout:     args.HeartbeatTimeout,
+				DefaultResult:        args.DefaultResult,
+				Receivers:            args.Receiver,
+				NotificationMetadata: args.NotificationMetadata,
+			}
+			input.Name = args.NAME
+			ret, err := modules.ScalingLifecycleHook.Create(s, jsonutils.Marshal(input))
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
+
+	type ScalingLifecycleHookDeleteOptions struct {
+		ID string `help:"ScalingLifecycleHook ID or Name"`
+	}
+	R(&ScalingLifecycleHookDeleteOptions{}, "scaling-lifecycle-hook-delete", "Delete Scaling Lifecycle Hook",
+		func(s *mcclient.ClientSession, args *ScalingLifecycleHookDeleteOptions) error {
+			ret, err := modules.ScalingLifecycleHook.Delete(s, args.ID, jsonutils.NewDict())
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
+
+	type ScalingLifecycleActionListOptions struct {
+		options.BaseListOptions
+		ScalingGroup string `help:"ScalingGroup ID or Name"`
+	}
+	R(&ScalingLifecycleActionListOptions{}, "scaling-lifecycle-action-list", "List Scaling Lifecycle Action",
+		func(s *mcclient.ClientSession, args *ScalingLifecycleActionListOptions) error {
+			params, err := options.ListStructToParams(args)
+			if err != nil {
+				return err
+			}
+			list, err := modules.ScalingLifecycleAction.List(s, params)
+			if err != nil {
+				return err
+			}
+			printList(list, modules.ScalingLifecycleAction.GetColumns(s))
+			return nil
+		},
+	)
+}
@@ -44,7 +44,7 @@ func init() {
 	type ScalingPolicyListOptions struct {
 		options.BaseListOptions
 		ScalingGroup string `help:"ScalingGroup ID or Name"`
-		TriggerType  string `help:"Trigger type" choices:"alarm|timing|cycle"`
+		TriggerType  string `help:"Trigger type" choices:"alarm|timing|cycle|target_tracking"`
 	}
 	R(&ScalingPolicyListOptions{}, "scaling-policy-list", "List Scaling Policy", func(s *mcclient.ClientSession,
 		args *ScalingPolicyListOptions) error {
@@ -81,17 +81,26 @@ func init() {
 		AlarmValue     float64 `help:"Value of Indicator" json:"value"`
 	}
 
+	type ScalingTargetTracking struct {
+		TrackingIndicator      string  `help:"Indicator for 'target_tracking' trigger"`
+		TrackingTargetValue    float64 `help:"Target value of the average indicator, for 'target_tracking' trigger"`
+		TrackingCycle          int     `help:"Monitoring cycle for indicators, for 'target_tracking' trigger"`
+		TrackingDisableScaleIn bool    `help:"Only scale out, for 'target_tracking' trigger"`
+	}
+
 	type ScalingPolicyCreateOptions struct {
 		NAME         string `help:"ScalingPolicy Name" json:"name"`
 		ScalingGroup string `help:"ScalingGroup ID or Name" json:"scaling_group"`
-		TriggerType  string `help:"Trigger type" choices:"alarm|timing|cycle" json:"trigger_type"`
+		TriggerType  string `help:"Trigger type" choices:"alarm|timing|cycle|target_tracking" json:"trigger_type"`
 
 		Timer
 		CycleTimer
 		ScalingAlarm
+		ScalingTargetTracking
 
-		Action      string `help:"Action for scaling policy" choices:"add|remove|set" json:"action"`
+		Action      string `help:"Action for scaling policy" choices:"add|remove|set|step" json:"action"`
 		Number      int    `help:"Instance number for action" json:"number"`
+		Steps       string `help:"Steps for 'step' action in json, e.g. '[{\"lower_bound\":80,\"number\":2}]'" json:"-"`
 		Unit        string `help:"Unit for Number" choices:"s|%" json:"unit"`
 		CoolingTime int    `help:"Cooling time, unit: s" json:"cooling_time"`
 	}
@@ -134,12 +143,28 @@ func init() {
 					Operator:  args.AlarmOperator,
 					Value:     args.AlarmValue,
 				},
+				TargetTracking: api.ScalingTargetTrackingCreateInput{
+					Indicator:      args.TrackingIndicator,
+					TargetValue:    args.TrackingTargetValue,
+					Cycle:          args.TrackingCycle,
+					DisableScaleIn: args.TrackingDisableScaleIn,
+				},
 				Action:      args.Action,
 				Number:      args.Number,
 				Unit:        args.Unit,
 				CoolingTime: args.CoolingTime,
 			}
 			spCreateInput.Name = args.NAME
+			if len(args.Steps) > 0 {
+				steps, err := jsonutils.ParseString(args.Steps)
+				if err != nil {
+					return fmt.Errorf("invalid json for 'steps'")
+				}
+				spCreateInput.Steps = &api.SScalingSteps{}
+				if err := steps.Unmarshal(spCreateInput.Steps); err != nil {
+					return fmt.Errorf("invalid steps: %s", err)
+				}
+			}
 			ret, err := modules.ScalingPolicy.Create(s, jsonutils.Marshal(spCreateInput))
 			if err != nil {
 				return err
@@ -61,6 +61,8 @@ func init() {
 	cmd.BatchPerform("purge", new(options.ServerIdsOptions))
 	cmd.Perform("migrate", new(options.ServerMigrateOptions))
 	cmd.Perform("live-migrate", new(options.ServerLiveMigrateOptions))
+	cmd.Perform("start-rescue", new(options.ServerIdOptions))
+	cmd.Perform("stop-rescue", new(options.ServerStopRescueOptions))
 	cmd.Perform("modify-src-check", new(options.ServerModifySrcCheckOptions))
 	cmd.Perform("set-secgroup", new(options.ServerSecGroupsOptions))
 	cmd.Perform("add-secgroup", new(options.ServerSecGroupsOptions))
@@ -694,6 +696,7 @@ func init() {
 	type ServerCreateSnapshot struct {
 		ID       string `help:"ID or name of VM" json:"-"`
 		SNAPSHOT string `help:"Instance snapshot name" json:"name"`
+		Quiesce  bool   `help:"Freeze guest filesystems to take application consistent snapshot" json:"quiesce"`
 	}
 	R(&ServerCreateSnapshot{}, "instance-snapshot-create", "create instance snapshot", func(s *mcclient.ClientSession, opts *ServerCreateSnapshot) error {
 		params := jsonutils.Marshal(opts)
@@ -711,6 +714,7 @@ func init() {
 		AutoStart   bool   `help:"Auto start new guest"`
 		AllowDelete bool   `help:"Allow new guest delete" json:"-"`
 		Count       int    `help:"Guest count"`
+		Quiesce     bool   `help:"Freeze guest filesystems to take application consistent snapshot"`
 	}
 	R(&ServerSnapshotAndClone{}, "instance-snapshot-and-clone", "create instance snapshot and clone new instance", func(s *mcclient.ClientSession, opts *ServerSnapshotAndClone) error {
 		params := jsonutils.Marshal(opts)
@@ -70,4 +70,13 @@ func init() {
 		printObject(result)
 		return nil
 	})
+
+	R(&TaskShowOptions{}, "region-task-cancel", "Cancel a region task and its subtasks", func(s *mcclient.ClientSession, args *TaskShowOptions) error {
+		result, err := modules.ComputeTasks.PerformAction(s, args.ID, "cancel", nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
 }
@@ -44,6 +44,34 @@ func init() {
 			return nil
 		})
 
+	R(&options.DataSourceCreateOptions{}, dsN("create"), "Create monitor data source",
+		func(s *mcclient.ClientSession, args *options.DataSourceCreateOptions) error {
+			params, err := args.Params()
+			if err != nil {
+				return err
+			}
+			ret, err := monitor.DataSources.Create(s, params.JSON(params))
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		})
+
+	R(&options.DataSourceUpdateOptions{}, dsN("update"), "Update monitor data source",
+		func(s *mcclient.ClientSession, args *options.DataSourceUpdateOptions) error {
+			params, err := args.Params()
+			if err != nil {
+				return err
+			}
+			ret, err := monitor.DataSources.Update(s, args.ID, params.JSON(params))
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		})
+
 	R(&options.DataSourceDeleteOptions{}, dsN("delete"), "Delete monitor data source",
 		func(s *mcclient.ClientSession, args *options.DataSourceDeleteOptions) error {
 			ret, err := monitor.DataSources.Delete(s, args.ID, nil)
@@ -0,0 +1,128 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/util/redfish"
+	"yunion.io/x/onecloud/pkg/util/shellutils"
+)
+
+func init() {
+
+	type StorageListOptions struct {
+	}
+	shellutils.R(&StorageListOptions{}, "storage-list", "List storage controllers with drives and volumes", func(cli redfish.IRedfishDriver, args *StorageListOptions) error {
+		storages, err := cli.GetStorages(context.Background())
+		if err != nil {
+			return err
+		}
+		fmt.Println(jsonutils.Marshal(storages).PrettyString())
+		return nil
+	})
+
+	type VolumeCreateOptions struct {
+		RAID     string   `help:"raid type, e.g. RAID1"`
+		DRIVE    []string `help:"drive id or location"`
+		Storage  string   `help:"storage controller id, default the first one"`
+		Name     string   `help:"volume name"`
+		Capacity int64    `help:"capacity in GB, default all space of drives"`
+		Wait     bool     `help:"wait until the task completes"`
+	}
+	shellutils.R(&VolumeCreateOptions{}, "volume-create", "Create a RAID volume", func(cli redfish.IRedfishDriver, args *VolumeCreateOptions) error {
+		ctx := context.Background()
+		params := redfish.SVolumeCreateParams{
+			Storage:       args.Storage,
+			Name:          args.Name,
+			RAIDType:      args.RAID,
+			Drives:        args.DRIVE,
+			CapacityBytes: args.Capacity * 1000 * 1000 * 1000,
+		}
+		taskPath, err := cli.CreateVolume(ctx, params)
+		if err != nil {
+			return err
+		}
+		return printTask(ctx, cli, taskPath, args.Wait)
+	})
+
+	type VolumeDeleteOptions struct {
+		VOLUME  string `help:"volume id or name"`
+		Storage string `help:"storage controller id, default the first one"`
+		Wait    bool   `help:"wait until the task completes"`
+	}
+	shellutils.R(&VolumeDeleteOptions{}, "volume-delete", "Delete a RAID volume", func(cli redfish.IRedfishDriver, args *VolumeDeleteOptions) error {
+		ctx := context.Background()
+		taskPath, err := cli.DeleteVolume(ctx, args.Storage, args.VOLUME)
+		if err != nil {
+			return err
+		}
+		return printTask(ctx, cli, taskPath, args.Wait)
+	})
+
+	type FirmwareUpdateOptions struct {
+		URI      string   `help:"firmware image URI"`
+		Protocol string   `help:"transfer protocol, e.g. HTTP"`
+		Target   []string `help:"update targets"`
+		Wait     bool     `help:"wait until the task completes"`
+	}
+	shellutils.R(&FirmwareUpdateOptions{}, "firmware-update", "Push firmware via UpdateService SimpleUpdate", func(cli redfish.IRedfishDriver, args *FirmwareUpdateOptions) error {
+		ctx := context.Background()
+		params := redfish.SFirmwareUpdateParams{
+			ImageURI:         args.URI,
+			TransferProtocol: args.Protocol,
+			Targets:          args.Target,
+		}
+		taskPath, err := cli.SimpleUpdate(ctx, params)
+		if err != nil {
+			return err
+		}
+		return printTask(ctx, cli, taskPath, args.Wait)
+	})
+
+	type TaskGetOptions struct {
+		PATH string `help:"task path"`
+	}
+	shellutils.R(&TaskGetOptions{}, "task-get", "Get status of a task", func(cli redfish.IRedfishDriver, args *TaskGetOptions) error {
+		info, err := cli.GetTaskInfo(context.Background(), args.PATH)
+		if err != nil {
+			return err
+		}
+		fmt.Println(jsonutils.Marshal(info).PrettyString())
+		return nil
+	})
+
+}
+
+func printTask(ctx context.Context, cli redfish.IRedfishDriver, taskPath string, wait bool) error {
+	if len(taskPath) == 0 {
+		fmt.Println("Success!")
+		return nil
+	}
+	if !wait {
+		fmt.Println("Task:", taskPath)
+		return nil
+	}
+	info, err := redfish.WaitTask(ctx, cli, taskPath, 5*time.Second, time.Hour)
+	if err != nil {
+		return err
+	}
+	fmt.Println(jsonutils.Marshal(info).PrettyString())
+	return nil
+}
@@ -47,6 +47,23 @@ func init() {
 		return nil
 	})
 
+	type BiosSetOptions struct {
+		ATTRS  string `help:"attributes in JSON, e.g. {\"BootMode\":\"Uefi\"}"`
+		Reboot bool   `help:"reboot system to apply the pending attributes"`
+	}
+	shellutils.R(&BiosSetOptions{}, "bios-set", "Set pending attributes of a system Bios", func(cli redfish.IRedfishDriver, args *BiosSetOptions) error {
+		attrs, err := jsonutils.ParseString(args.ATTRS)
+		if err != nil {
+			return err
+		}
+		err = redfish.SetBiosAttributes(context.Background(), cli, attrs, args.Reboot)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Success!")
+		return nil
+	})
+
 	type SetNextBootOptions struct {
 		DEV string `help:"next boot device"`
 	}
@@ -55,9 +55,8 @@ type NetworkConfig struct {
 	// required: false
 	Address string `json:"address"`
 
-	// 子网内的IPv6地址
+	// 子网内的IPv6地址，子网需配置IPv6地址段
 	// required: false
-	// swagger:ignore
 	Address6 string `json:"address6"`
 
 	// 驱动方式
@@ -206,6 +205,14 @@ type DiskConfig struct {
 
 	//swagger:ignore
 	DiskId string `json:"disk_id"`
+
+	// 是否加密磁盘, 仅KVM本地盘和Ceph盘支持
+	// 加密密钥由keystone托管
+	// required: false
+	Encrypt bool `json:"encrypt"`
+
+	//swagger:ignore
+	EncryptKeyId string `json:"encrypt_key_id"`
 }
 
 type IsolatedDeviceConfig struct {
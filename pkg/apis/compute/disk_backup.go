@@ -0,0 +1,96 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import "yunion.io/x/onecloud/pkg/apis"
+
+const (
+	DISK_BACKUP_STATUS_CREATING      = "creating"
+	DISK_BACKUP_STATUS_CREATE_FAILED = "create_failed"
+	DISK_BACKUP_STATUS_READY         = "ready"
+	DISK_BACKUP_STATUS_RESTORING     = "restoring"
+	DISK_BACKUP_STATUS_DELETING      = "deleting"
+	DISK_BACKUP_STATUS_DELETE_FAILED = "delete_failed"
+
+	DISK_BACKUP_MODE_FULL        = "full"
+	DISK_BACKUP_MODE_INCREMENTAL = "incremental"
+
+	DISK_BACKUP_STORAGE_NFS = "nfs"
+	DISK_BACKUP_STORAGE_S3  = "s3"
+
+	// backup the dirty bitmap of disk was reset at,
+	// the only one next incremental backup can be based on
+	DISK_METADATA_LAST_BACKUP = "__last_backup"
+)
+
+type DiskBackupCreateInput struct {
+	apis.VirtualResourceCreateInput
+
+	// 磁盘名称或Id, 目前仅支持KVM虚拟机的本地磁盘
+	// required: true
+	Disk string `json:"disk"`
+	// swagger:ignore
+	DiskId string `json:"disk_id"`
+
+	// 备份模式, 增量备份在磁盘没有可用备份链时自动转为全量备份
+	// enum: full, incremental
+	// default: incremental
+	BackupMode string `json:"backup_mode"`
+
+	// swagger:ignore
+	GuestId string `json:"guest_id"`
+	// swagger:ignore
+	ParentId string `json:"parent_id"`
+	// swagger:ignore
+	ChainId string `json:"chain_id"`
+}
+
+type DiskBackupListInput struct {
+	apis.VirtualResourceListInput
+
+	DiskFilterListInput
+
+	// 以云主机过滤
+	ServerId string `json:"server_id"`
+	// 以备份链过滤, 即备份链中全量备份的Id
+	ChainId string `json:"chain_id"`
+	// 以备份模式过滤
+	BackupMode string `json:"backup_mode"`
+}
+
+type DiskBackupDetails struct {
+	apis.VirtualResourceDetails
+	DiskResourceInfo
+
+	SDiskBackup
+
+	// 云主机名称
+	Guest string `json:"guest"`
+	// 所在备份链的备份数量
+	ChainLength int `json:"chain_length"`
+}
+
+type DiskBackupRestoreInput struct {
+}
+
+type DiskBackupPruneInput struct {
+	// 磁盘名称或Id
+	// required: true
+	Disk string `json:"disk"`
+
+	// 保留最近的备份链的数量
+	// default: 1
+	KeepChains int `json:"keep_chains"`
+}
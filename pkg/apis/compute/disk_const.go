@@ -58,4 +58,7 @@ const (
 
 	DISK_NOT_EXIST = "not_exist"
 	DISK_EXIST     = "exist"
+
+	// DISK_META_ISCSI_LUN records the json of SIscsiLun the disk is allocated on
+	DISK_META_ISCSI_LUN = "__iscsi_lun"
 )
@@ -0,0 +1,109 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import "yunion.io/x/onecloud/pkg/apis"
+
+const (
+	// only propose migration plan, which could be applied by admin
+	DRS_MODE_MANUAL = "manual"
+	// execute migration plan automatically in maintenance window
+	DRS_MODE_AUTO = "auto"
+
+	DRS_POLICY_STATUS_READY      = "ready"
+	DRS_POLICY_STATUS_EVALUATING = "evaluating"
+)
+
+type DrsPolicyCreateInput struct {
+	apis.EnabledStatusStandaloneResourceCreateInput
+
+	// 平衡范围, 可用区和调度标签二选一
+	ZoneResourceInput
+	// 平衡范围内宿主机的调度标签
+	Schedtag string `json:"schedtag"`
+	// swagger:ignore
+	SchedtagId string `json:"schedtag_id"`
+
+	// 运行模式
+	// enum: manual, auto
+	// default: manual
+	Mode string `json:"mode"`
+	// 可以容忍的宿主机负载标准差, 单位%
+	// default: 10
+	Threshold int `json:"threshold"`
+	// 每轮最多迁移的虚拟机数量
+	// default: 2
+	MaxMigrations int `json:"max_migrations"`
+	// 维护窗口开始时间, 格式 HH:MM, 为空则不限制
+	// example: 01:00
+	WindowStart string `json:"window_start"`
+	// 维护窗口结束时间, 格式 HH:MM
+	// example: 05:00
+	WindowEnd string `json:"window_end"`
+}
+
+type DrsPolicyUpdateInput struct {
+	apis.EnabledStatusStandaloneResourceBaseUpdateInput
+
+	Mode          string `json:"mode"`
+	Threshold     *int   `json:"threshold"`
+	MaxMigrations *int   `json:"max_migrations"`
+	WindowStart   string `json:"window_start"`
+	WindowEnd     string `json:"window_end"`
+}
+
+type DrsPolicyListInput struct {
+	apis.EnabledStatusStandaloneResourceListInput
+
+	ZonalFilterListBase
+	// 以调度标签过滤
+	SchedtagId string `json:"schedtag_id"`
+	// 以运行模式过滤
+	Mode string `json:"mode"`
+}
+
+type DrsPolicyDetails struct {
+	apis.EnabledStatusStandaloneResourceDetails
+	ZoneResourceInfoBase
+
+	SDrsPolicy
+
+	// 调度标签名称
+	Schedtag string `json:"schedtag"`
+	// 平衡范围内的宿主机数量
+	HostCount int `json:"host_count"`
+}
+
+// DrsMigration is one step of migration plan
+type DrsMigration struct {
+	GuestId    string `json:"guest_id"`
+	Guest      string `json:"guest"`
+	SrcHostId  string `json:"src_host_id"`
+	SrcHost    string `json:"src_host"`
+	DestHostId string `json:"dest_host_id"`
+	DestHost   string `json:"dest_host"`
+}
+
+// DrsPlan is the migration plan proposed by last evaluation
+type DrsPlan struct {
+	// 迁移前宿主机负载标准差, 单位%
+	Deviation float64 `json:"deviation"`
+	// 迁移后预计的宿主机负载标准差, 单位%
+	ExpectedDeviation float64        `json:"expected_deviation"`
+	Migrations        []DrsMigration `json:"migrations"`
+}
+
+type DrsPolicyApplyInput struct {
+}
@@ -72,6 +72,11 @@ const (
 	VM_MIGRATING      = "migrating"
 	VM_MIGRATE_FAILED = "migrate_failed"
 
+	VM_START_RESCUE      = "start_rescue"
+	VM_START_RESCUE_FAIL = "start_rescue_fail"
+	VM_STOP_RESCUE       = "stop_rescue"
+	VM_STOP_RESCUE_FAIL  = "stop_rescue_fail"
+
 	VM_CHANGE_FLAVOR      = "change_flavor"
 	VM_CHANGE_FLAVOR_FAIL = "change_flavor_fail"
 	VM_REBUILD_ROOT       = "rebuild_root"
@@ -279,6 +284,13 @@ const (
 	VM_METADATA_OS_DISTRO           = "os_distribution"
 	VM_METADATA_OS_NAME             = "os_name"
 	VM_METADATA_OS_VERSION          = "os_version"
+
+	// guest boots from rescue image with its disks attached
+	VM_METADATA_RESCUE_MODE = "__rescue_mode"
+	// one-time root password of rescue system, encrypted by guest id
+	VM_METADATA_RESCUE_LOGIN_KEY = "__rescue_login_key"
+
+	VM_RESCUE_LOGIN_USER = "root"
 )
 
 func Hypervisors2HostTypes(hypervisors []string) []string {
@@ -0,0 +1,37 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+type ServerQgaPingInput struct {
+	// 等待guest agent响应的超时时间, 单位秒
+	Timeout int `json:"timeout"`
+}
+
+type ServerQgaSetPasswordInput struct {
+	// 用户名, 默认为虚拟机的登录用户
+	Username string `json:"username"`
+	// 新密码
+	Password string `json:"password"`
+}
+
+type ServerQgaCommandInput struct {
+	// 虚拟机内命令的绝对路径
+	// example: /bin/sh
+	Command string `json:"command"`
+	// 命令参数
+	Args []string `json:"args"`
+	// 等待命令结束的超时时间, 单位秒
+	Timeout int `json:"timeout"`
+}
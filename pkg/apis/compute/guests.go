@@ -378,6 +378,22 @@ type GuestLiveMigrateInput struct {
 	SkipCpuCheck *bool `json:"skip_cpu_check"`
 }
 
+type GuestStartRescueInput struct {
+}
+
+type GuestStartRescueOutput struct {
+	// 救援系统登录用户
+	Username string `json:"username"`
+	// 救援系统一次性登录密码, 仅在此返回一次
+	Password string `json:"password"`
+}
+
+type GuestStopRescueInput struct {
+	// 退出救援模式后是否正常启动
+	// default: true
+	AutoStart *bool `json:"auto_start"`
+}
+
 type GuestSetSecgroupInput struct {
 	// 安全组Id列表
 	// 实例必须处于运行,休眠或者关机状态
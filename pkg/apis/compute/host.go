@@ -428,3 +428,44 @@ type HostUpdateInput struct {
 	// 主机启动模式, 可能值位PXE和ISO
 	BootMode string `json:"boot_mode"`
 }
+
+type HostFirmwareUpdateInput struct {
+	// 固件镜像地址, 需要可被BMC访问
+	// required: true
+	ImageUri string `json:"image_uri"`
+	// 固件传输协议, 例如 HTTP, HTTPS, NFS
+	TransferProtocol string `json:"transfer_protocol"`
+	// 升级目标, 为空则由BMC根据固件自动选择
+	Targets []string `json:"targets"`
+}
+
+type HostBiosSettingsInput struct {
+	// 待设置的BIOS属性, 重启后生效
+	// required: true
+	Attributes *jsonutils.JSONDict `json:"attributes"`
+	// 是否立即重启使BIOS设置生效
+	Reboot bool `json:"reboot"`
+}
+
+type HostRaidVolumeCreateInput struct {
+	// 存储控制器ID, 为空则使用第一个控制器
+	Storage string `json:"storage"`
+	// 卷名称
+	Name string `json:"name"`
+	// RAID级别, 例如 RAID0, RAID1, RAID5, RAID10
+	// required: true
+	RaidType string `json:"raid_type"`
+	// 组成卷的物理盘ID或槽位
+	// required: true
+	Drives []string `json:"drives"`
+	// 卷容量(GB), 为空则使用物理盘的全部容量
+	CapacityGb int64 `json:"capacity_gb"`
+}
+
+type HostRaidVolumeDeleteInput struct {
+	// 存储控制器ID, 为空则使用第一个控制器
+	Storage string `json:"storage"`
+	// 卷ID或名称
+	// required: true
+	Volume string `json:"volume"`
+}
@@ -79,6 +79,18 @@ const (
 	BAREMETAL_EJECTING_ISO    = "ejecting_iso"
 	BAREMETAL_EJECT_FAIL      = "eject_fail"
 
+	BAREMETAL_START_FIRMWARE_UPDATE = "start_firmware_update"
+	BAREMETAL_FIRMWARE_UPDATING     = "firmware_updating"
+	BAREMETAL_FIRMWARE_UPDATE_FAIL  = "firmware_update_fail"
+
+	BAREMETAL_START_BIOS_SETTINGS = "start_bios_settings"
+	BAREMETAL_BIOS_SETTING        = "bios_setting"
+	BAREMETAL_BIOS_SETTINGS_FAIL  = "bios_settings_fail"
+
+	BAREMETAL_START_RAID_CONFIG = "start_raid_config"
+	BAREMETAL_RAID_CONFIGURING  = "raid_configuring"
+	BAREMETAL_RAID_CONFIG_FAIL  = "raid_config_fail"
+
 	HOST_STATUS_RUNNING = BAREMETAL_RUNNING
 	HOST_STATUS_READY   = BAREMETAL_READY
 	HOST_STATUS_UNKNOWN = BAREMETAL_UNKNOWN
@@ -89,6 +101,16 @@ const (
 	BAREMETAL_CDROM_ACTION_EJECT  = "eject"
 )
 
+const (
+	BAREMETAL_RAID_VOLUME_ACTION_CREATE = "create"
+	BAREMETAL_RAID_VOLUME_ACTION_DELETE = "delete"
+)
+
+const (
+	// 裸金属BMC推送的硬件告警通知事件
+	BAREMETAL_HARDWARE_ALERT = "baremetal_hardware_alert"
+)
+
 const (
 	HostResourceTypeShared         = "shared"
 	HostResourceTypeDefault        = HostResourceTypeShared
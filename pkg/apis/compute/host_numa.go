@@ -0,0 +1,74 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+const (
+	// numa node a kvm guest is bound to, set by host when guest starts
+	VM_METADATA_NUMA_NODE = "__numa_node"
+)
+
+type HostNumaHugepages struct {
+	// size of hugepage in KB, 2048 or 1048576
+	SizeKb int `json:"size_kb"`
+	Total  int `json:"total"`
+	Free   int `json:"free"`
+}
+
+type HostNumaNode struct {
+	NodeId    int                 `json:"node_id"`
+	Cpus      []int               `json:"cpus"`
+	MemSizeMb int                 `json:"mem_size_mb"`
+	MemFreeMb int                 `json:"mem_free_mb"`
+	Hugepages []HostNumaHugepages `json:"hugepages"`
+}
+
+// FreeHugepagesMb returns free memory in hugepages of sizeKb,
+// sizeKb 0 sums up hugepages of all sizes
+func (n HostNumaNode) FreeHugepagesMb(sizeKb int) int {
+	free := 0
+	for _, hp := range n.Hugepages {
+		if sizeKb == 0 || hp.SizeKb == sizeKb {
+			free += hp.Free * hp.SizeKb / 1024
+		}
+	}
+	return free
+}
+
+// TotalHugepagesMb returns memory in hugepages of sizeKb, sizeKb 0 sums
+// up hugepages of all sizes
+func (n HostNumaNode) TotalHugepagesMb(sizeKb int) int {
+	total := 0
+	for _, hp := range n.Hugepages {
+		if sizeKb == 0 || hp.SizeKb == sizeKb {
+			total += hp.Total * hp.SizeKb / 1024
+		}
+	}
+	return total
+}
+
+// HostNumaTopology is reported by host in sys_info and refreshed on ping
+type HostNumaTopology struct {
+	Nodes []HostNumaNode `json:"nodes"`
+	// size of hugepages backing guest memory, 0 if hugepages are not used
+	HugepageSizeKb int `json:"hugepage_size_kb"`
+}
+
+func (t *HostNumaTopology) FreeHugepagesMb(sizeKb int) int {
+	free := 0
+	for _, n := range t.Nodes {
+		free += n.FreeHugepagesMb(sizeKb)
+	}
+	return free
+}
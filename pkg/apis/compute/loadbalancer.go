@@ -133,6 +133,9 @@ type LoadbalancerBackendListInput struct {
 
 	SendProxy []string `json:"send_proxy"`
 	Ssl       []string `json:"ssl"`
+
+	// 以健康状态过滤
+	HealthStatus []string `json:"health_status"`
 }
 
 type LoadbalancerBackendGroupListInput struct {
@@ -152,6 +155,9 @@ type LoadbalancerClusterListInput struct {
 
 	ZonalFilterListInput
 	WireFilterListBase
+
+	// 以集群类型过滤
+	ClusterType []string `json:"cluster_type"`
 }
 
 type LoadbalancerAclListInput struct {
@@ -141,6 +141,18 @@ var LB_NETWORK_TYPES = choices.NewChoices(
 	LB_NETWORK_TYPE_VPC,
 )
 
+const (
+	// lbagent clusters run haproxy/gobetween on lbagent hosts
+	LB_CLUSTER_TYPE_LBAGENT = "lbagent"
+	// ovn clusters have vpc loadbalancers realized by vpcagent as ovn load balancers
+	LB_CLUSTER_TYPE_OVN = "ovn"
+)
+
+var LB_CLUSTER_TYPES = choices.NewChoices(
+	LB_CLUSTER_TYPE_LBAGENT,
+	LB_CLUSTER_TYPE_OVN,
+)
+
 // TODO https_direct sni
 const (
 	LB_LISTENER_TYPE_TCP              = "tcp"
@@ -398,6 +410,18 @@ var LB_BACKEND_ROLES = choices.NewChoices(
 	LB_BACKEND_ROLE_SLAVE,
 )
 
+const (
+	LB_BACKEND_HEALTH_STATUS_ONLINE  = "online"
+	LB_BACKEND_HEALTH_STATUS_OFFLINE = "offline"
+	LB_BACKEND_HEALTH_STATUS_UNKNOWN = "unknown"
+)
+
+var LB_BACKEND_HEALTH_STATUSES = choices.NewChoices(
+	LB_BACKEND_HEALTH_STATUS_ONLINE,
+	LB_BACKEND_HEALTH_STATUS_OFFLINE,
+	LB_BACKEND_HEALTH_STATUS_UNKNOWN,
+)
+
 const (
 	LB_CHARGE_TYPE_BY_TRAFFIC   = "traffic"
 	LB_CHARGE_TYPE_BY_BANDWIDTH = "bandwidth"
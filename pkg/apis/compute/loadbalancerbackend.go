@@ -22,3 +22,9 @@ type LoadbalancerBackendDetails struct {
 
 	SLoadbalancerBackend
 }
+
+type LoadbalancerBackendHealthStatusInput struct {
+	// 后端健康状态
+	// enum: online, offline, unknown
+	HealthStatus string `json:"health_status"`
+}
@@ -172,6 +172,37 @@ type NetworkCreateInput struct {
 	// example: 192.168.222.1,192.168.222.4
 	GuestDHCP string `json:"guest_dhcp"`
 
+	// description: ipv6 range of guest, optional, if not set, you can set guest_ip6_start,guest_ip6_end and guest_ip6_mask params
+	// example: 2001:db8:1:2::/64
+	GuestIp6Prefix string `json:"guest_ip6_prefix"`
+
+	// description: ipv6 range of guest ip start, if set guest_ip6_prefix, this parameter will be useless
+	// example: 2001:db8:1:2::1
+	GuestIp6Start string `json:"guest_ip6_start"`
+
+	// description: ipv6 range of guest ip end, if set guest_ip6_prefix, this parameter will be useless
+	// example: 2001:db8:1:2::ffff
+	GuestIp6End string `json:"guest_ip6_end"`
+
+	// description: ipv6 prefix length, if set guest_ip6_prefix, this parameter will be useless
+	// example: 64
+	// maximum: 126
+	// minimum: 8
+	GuestIp6Mask int8 `json:"guest_ip6_mask"`
+
+	// description: guest ipv6 gateway
+	// example: 2001:db8:1:2::1
+	GuestGateway6 string `json:"guest_gateway6"`
+
+	// description: guest ipv6 dns
+	// example: 2001:4860:4860::8888
+	GuestDns6 string `json:"guest_dns6"`
+
+	// description: ipv6 address allocation mode
+	// enum: slaac,dhcpv6
+	// default: dhcpv6
+	GuestIp6AllocMode string `json:"guest_ip6_alloc_mode"`
+
 	// swagger:ignore
 	WireId string `json:"wire_id"`
 
@@ -314,6 +345,20 @@ type NetworkUpdateInput struct {
 
 	GuestDomain string `json:"guest_domain"`
 
+	// IPv6起始地址
+	GuestIp6Start string `json:"guest_ip6_start"`
+	// IPv6结束地址
+	GuestIp6End string `json:"guest_ip6_end"`
+	// IPv6前缀长度
+	GuestIp6Mask *int8 `json:"guest_ip6_mask"`
+	// IPv6网关地址
+	GuestGateway6 string `json:"guest_gateway6"`
+	// IPv6 DNS
+	GuestDns6 string `json:"guest_dns6"`
+	// IPv6地址分配方式
+	// enum: slaac,dhcpv6
+	GuestIp6AllocMode string `json:"guest_ip6_alloc_mode"`
+
 	VlanId *int `json:"vlan_id"`
 
 	// 分配策略
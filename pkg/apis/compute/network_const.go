@@ -76,6 +76,18 @@ const (
 	IPAllocationDefault                        = ""
 )
 
+const (
+	// 无状态地址自动配置，地址由前缀和MAC地址按EUI-64生成，要求前缀长度为64
+	IPV6_ALLOC_MODE_SLAAC = "slaac"
+	// 有状态DHCPv6分配
+	IPV6_ALLOC_MODE_DHCPV6 = "dhcpv6"
+)
+
+var IPV6_ALLOC_MODES = []string{
+	IPV6_ALLOC_MODE_SLAAC,
+	IPV6_ALLOC_MODE_DHCPV6,
+}
+
 type SNetworkUsedAddress struct {
 	IpAddr        string
 	MacAddr       string
@@ -29,9 +29,12 @@ const (
 	TRIGGER_TIMING = "timing" // 定时
 	TRIGGER_CYCLE  = "cycle"  // 周期定时
 
+	TRIGGER_TARGET_TRACKING = "target_tracking" // 目标追踪
+
 	ACTION_ADD    = "add"    // 增加
 	ACTION_REMOVE = "remove" // 减少
 	ACTION_SET    = "set"    // 设置
+	ACTION_STEP   = "step"   // 按告警指标分段调整
 
 	UNIT_ONE     = "s" // 个
 	UNIT_PERCENT = "%" // 百分之
@@ -62,6 +65,9 @@ const (
 	SG_GUEST_STATUS_REMOVE_FAILED  = "remove_failed"  // 移除失败
 	SG_GUEST_STATUS_PENDING_REMOVE = "pending_remove" // 机器进入回收站
 
+	SG_GUEST_STATUS_PENDING_LAUNCH    = "pending_launch"    // 等待启动挂钩完成
+	SG_GUEST_STATUS_PENDING_TERMINATE = "pending_terminate" // 等待移除挂钩完成
+
 	// 只有ready状态是正常的
 	SG_STATUS_READY              = "ready"              // 正常
 	SG_STATUS_DELETING           = "deleting"           // 删除中
@@ -83,4 +89,21 @@ const (
 	SA_STATUS_PART_SUCCEED = "part_succeed" // 部分成功
 	SA_STATUS_FAILED       = "failed"       // 失败
 	SA_STATUS_REJECT       = "reject"       // 拒绝
+
+	SIR_STATUS_IN_PROGRESS      = "in_progress"      // 刷新中
+	SIR_STATUS_SUCCEED          = "succeed"          // 刷新成功
+	SIR_STATUS_FAILED           = "failed"           // 刷新失败
+	SIR_STATUS_ROLLING_BACK     = "rolling_back"     // 回滚中
+	SIR_STATUS_ROLLBACK_SUCCEED = "rollback_succeed" // 回滚成功
+	SIR_STATUS_ROLLBACK_FAILED  = "rollback_failed"  // 回滚失败
+
+	LIFECYCLE_TRANSITION_LAUNCH    = "launch"    // 加入伸缩组
+	LIFECYCLE_TRANSITION_TERMINATE = "terminate" // 移出伸缩组
+
+	LIFECYCLE_RESULT_CONTINUE = "continue" // 继续
+	LIFECYCLE_RESULT_ABANDON  = "abandon"  // 放弃
+
+	SLA_STATUS_PENDING   = "pending"   // 等待中
+	SLA_STATUS_COMPLETED = "completed" // 已完成
+	SLA_STATUS_TIMEOUT   = "timeout"   // 超时
 )
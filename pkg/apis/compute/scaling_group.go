@@ -174,3 +174,34 @@ type SGPerformDetachScalingGroupInput struct {
 	// example: true
 	Auto bool `json:"auto"`
 }
+
+type ScalingGroupPerformScaleInProtectionInput struct {
+	// description: 伸缩组内的机器 Id or Name
+	// example: ["gst-1234"]
+	Guests []string `json:"guests"`
+
+	// description: 是否开启缩容保护，开启后缩容时不会移除该机器
+	// example: true
+	Protected bool `json:"protected"`
+}
+
+type ScalingGroupPerformInstanceRefreshInput struct {
+	// description: 替换成的主机模板 id or name, 为空时使用伸缩组当前的主机模板
+	// example: gt-test-one
+	GuestTemplate string `json:"guest_template"`
+
+	// swagger: ignore
+	GuestTemplateId string `json:"guest_template_id"`
+
+	// description: 替换过程中健康实例数占期望实例数的最小百分比
+	// example: 90
+	MinHealthyPercentage *int `json:"min_healthy_percentage"`
+
+	// description: 每批替换的实例数, 为0时根据最小健康百分比计算
+	// example: 1
+	BatchSize int `json:"batch_size"`
+
+	// description: 两批替换之间的暂停时间, 单位s
+	// example: 60
+	Pause int `json:"pause"`
+}
@@ -0,0 +1,120 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import "yunion.io/x/onecloud/pkg/apis"
+
+type ScalingLifecycleHookCreateInput struct {
+	apis.VirtualResourceCreateInput
+
+	// description: scaling_group ID or Name
+	// example: sg-test-one
+	ScalingGroup string `json:"scaling_group"`
+
+	// swagger: ignore
+	ScalingGroupId string `json:"scaling_group_id"`
+
+	// description: 挂钩的伸缩行为, launch 在机器加入伸缩组前等待, terminate 在机器移出伸缩组前等待
+	// enum: launch,terminate
+	// example: launch
+	Transition string `json:"transition"`
+
+	// description: 等待完成的超时时间, 单位s, 范围30-7200, 默认300
+	// example: 300
+	HeartbeatTimeout int `json:"heartbeat_timeout"`
+
+	// description: 超时后的默认结果, 默认continue
+	// enum: continue,abandon
+	// example: continue
+	DefaultResult string `json:"default_result"`
+
+	// description: 通知接收人的 Id or Name
+	// example: ["u-test"]
+	Receivers []string `json:"receivers"`
+
+	// description: 附加在通知中的信息
+	// example: consul-service=web
+	NotificationMetadata string `json:"notification_metadata"`
+}
+
+type ScalingLifecycleHookUpdateInput struct {
+	apis.VirtualResourceBaseUpdateInput
+
+	// description: 等待完成的超时时间, 单位s, 范围30-7200
+	HeartbeatTimeout *int `json:"heartbeat_timeout"`
+
+	// description: 超时后的默认结果
+	// enum: continue,abandon
+	DefaultResult string `json:"default_result"`
+
+	// description: 附加在通知中的信息
+	NotificationMetadata *string `json:"notification_metadata"`
+}
+
+type ScalingLifecycleHookDetails struct {
+	apis.VirtualResourceDetails
+	ScalingGroupResourceInfo
+	SScalingLifecycleHook
+}
+
+type ScalingLifecycleHookListInput struct {
+	apis.VirtualResourceListInput
+	ScalingGroupFilterListInput
+
+	// description: 挂钩的伸缩行为
+	// example: launch
+	Transition string `json:"transition"`
+}
+
+type ScalingLifecycleActionDetails struct {
+	apis.StatusStandaloneResourceDetails
+	ScalingGroupResourceInfo
+	SScalingLifecycleAction
+
+	// 挂钩名称
+	LifecycleHook string `json:"lifecycle_hook"`
+	// 机器名称
+	Guest string `json:"guest"`
+	// 挂钩附加在通知中的信息
+	NotificationMetadata string `json:"notification_metadata"`
+}
+
+type ScalingLifecycleActionListInput struct {
+	apis.StatusStandaloneResourceListInput
+	ScalingGroupFilterListInput
+}
+
+type ScalingGroupLifecycleActionInput struct {
+	// description: 生命周期动作 Id, 为空时根据 lifecycle_hook 和 guest 查找
+	// example: 5c2ac5b8-6b66-4b53-8d2a-3a0c4d9a7b1e
+	LifecycleAction string `json:"lifecycle_action"`
+
+	// description: 生命周期挂钩 Id or Name
+	// example: slh-test
+	LifecycleHook string `json:"lifecycle_hook"`
+
+	// description: 伸缩组内的机器 Id or Name
+	// example: gst-1234
+	Guest string `json:"guest"`
+}
+
+type ScalingGroupCompleteLifecycleActionInput struct {
+	ScalingGroupLifecycleActionInput
+
+	// description: 生命周期动作的结果, abandon 会放弃加入伸缩组的机器
+	// enum: continue,abandon
+	// example: continue
+	Result string `json:"result"`
+}
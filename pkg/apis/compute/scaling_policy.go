@@ -14,7 +14,16 @@
 
 package compute
 
-import "yunion.io/x/onecloud/pkg/apis"
+import (
+	"reflect"
+	"sort"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
 
 type ScalingPolicyDetails struct {
 	apis.VirtualResourceDetails
@@ -26,6 +35,8 @@ type ScalingPolicyDetails struct {
 	CycleTimer CycleTimerDetails `json:"cycle_timer"`
 	//  告警方式触发
 	Alarm ScalingAlarmDetails `json:"alarm"`
+	// 目标追踪方式触发
+	TargetTracking ScalingTargetTrackingDetails `json:"target_tracking"`
 }
 
 type ScalingPolicyCreateInput struct {
@@ -40,18 +51,22 @@ type ScalingPolicyCreateInput struct {
 	ScalingGroupId string `json:"scaling_group_id"`
 
 	// description: trigger type
-	// enum: timing,cycle,alarm
+	// enum: timing,cycle,alarm,target_tracking
 	TriggerType string `json:"trigger_type"`
 
-	Timer      TimerCreateInput        `json:"timer"`
-	CycleTimer CycleTimerCreateInput   `json:"cycle_timer"`
-	Alarm      ScalingAlarmCreateInput `json:"alarm"`
+	Timer          TimerCreateInput                 `json:"timer"`
+	CycleTimer     CycleTimerCreateInput            `json:"cycle_timer"`
+	Alarm          ScalingAlarmCreateInput          `json:"alarm"`
+	TargetTracking ScalingTargetTrackingCreateInput `json:"target_tracking"`
 
-	// desciption: 伸缩策略的行为(增加还是删除或者调整为)
-	// enum: add,remove,set
+	// desciption: 伸缩策略的行为(增加还是删除或者调整为), step 只能用于告警方式触发，按告警时的指标值所在的区间调整
+	// enum: add,remove,set,step
 	// example: add
 	Action string `json:"action"`
 
+	// desciption: 分段调整的区间，action 为 step 时必填
+	Steps *SScalingSteps `json:"steps"`
+
 	// desciption: 实例的数量
 	// example: 2
 	Number int `json:"number"`
@@ -75,7 +90,95 @@ type ScalingPolicyListInput struct {
 	ScalingGroupFilterListInput
 
 	// description: trigger type
-	// enum: timing,cycel,alarm
+	// enum: timing,cycel,alarm,target_tracking
 	// example: alarm
 	TriggerType string `json:"trigger_type"`
 }
+
+type SScalingStep struct {
+	// description: 指标值区间的下限(包含)，为空表示无下限
+	// example: 80
+	LowerBound *float64 `json:"lower_bound"`
+
+	// description: 指标值区间的上限(不包含)，为空表示无上限
+	// example: 90
+	UpperBound *float64 `json:"upper_bound"`
+
+	// description: 实例数量的调整值，正数为增加，负数为减少，单位同伸缩策略的 unit
+	// example: 2
+	Number int `json:"number"`
+}
+
+func (step *SScalingStep) Contains(value float64) bool {
+	if step.LowerBound != nil && value < *step.LowerBound {
+		return false
+	}
+	if step.UpperBound != nil && value >= *step.UpperBound {
+		return false
+	}
+	return true
+}
+
+type SScalingSteps []*SScalingStep
+
+func (steps SScalingSteps) String() string {
+	return jsonutils.Marshal(steps).String()
+}
+
+func (steps SScalingSteps) IsZero() bool {
+	if len(steps) == 0 {
+		return true
+	}
+	return false
+}
+
+// Validate sorts the steps by lower bound and makes sure they don't overlap
+func (steps *SScalingSteps) Validate() error {
+	if steps == nil || len(*steps) == 0 {
+		return httperrors.NewMissingParameterError("steps")
+	}
+	for _, step := range *steps {
+		if step.LowerBound == nil && step.UpperBound == nil && len(*steps) > 1 {
+			return httperrors.NewInputParameterError("unbounded step overlaps the others")
+		}
+		if step.LowerBound != nil && step.UpperBound != nil && *step.LowerBound >= *step.UpperBound {
+			return httperrors.NewInputParameterError("lower bound %f of step is not less than upper bound %f",
+				*step.LowerBound, *step.UpperBound)
+		}
+		if step.Number == 0 {
+			return httperrors.NewInputParameterError("number of step should not be 0")
+		}
+	}
+	sort.Slice(*steps, func(i, j int) bool {
+		if (*steps)[i].LowerBound == nil {
+			return true
+		}
+		if (*steps)[j].LowerBound == nil {
+			return false
+		}
+		return *(*steps)[i].LowerBound < *(*steps)[j].LowerBound
+	})
+	for i := 1; i < len(*steps); i++ {
+		prev, cur := (*steps)[i-1], (*steps)[i]
+		if prev.UpperBound == nil || cur.LowerBound == nil || *prev.UpperBound > *cur.LowerBound {
+			return httperrors.NewInputParameterError("steps overlap with each other")
+		}
+	}
+	return nil
+}
+
+// Match returns the step whose bounds contain value, nil if there is none
+func (steps SScalingSteps) Match(value float64) *SScalingStep {
+	for _, step := range steps {
+		if step.Contains(value) {
+			return step
+		}
+	}
+	return nil
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SScalingSteps{}), func() gotypes.ISerializable {
+		return &SScalingSteps{}
+	})
+}
@@ -81,6 +81,26 @@ type ScalingAlarmCreateInput struct {
 	Value float64 `json:"value"`
 }
 
+type ScalingTargetTrackingCreateInput struct {
+
+	// description: 监控指标
+	// example: cpu
+	// enum: cpu,disk_read,disk_write,flow_into,flow_out
+	Indicator string `json:"indicator"`
+
+	// description: 伸缩组内实例的监控指标平均值的目标值
+	// example: 50
+	TargetValue float64 `json:"target_value"`
+
+	// description: 监控周期，单位s
+	// example: 300
+	Cycle int `json:"cycle"`
+
+	// description: 禁止缩容，开启后只会根据目标值扩容
+	// example: false
+	DisableScaleIn bool `json:"disable_scale_in"`
+}
+
 type TimerDetails struct {
 	// description: 执行时间
 	ExecTime time.Time `json:"exec_time"`
@@ -117,3 +137,14 @@ type ScalingAlarmDetails struct {
 	// description: 阈值
 	Value float64 `json:"value"`
 }
+
+type ScalingTargetTrackingDetails struct {
+	// description: 指标
+	Indicator string `json:"indicator"`
+	// description: 指标平均值的目标值
+	TargetValue float64 `json:"target_value"`
+	// description: 监控周期
+	Cycle int `json:"cycle"`
+	// description: 是否禁止缩容
+	DisableScaleIn bool `json:"disable_scale_in"`
+}
@@ -16,6 +16,7 @@ package compute
 
 import (
 	"fmt"
+	"strings"
 
 	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/util/regutils"
@@ -128,6 +129,10 @@ func (input *SSecgroupRuleResource) Check() error {
 	}
 
 	if len(input.CIDR) > 0 {
+		if strings.Contains(input.CIDR, ":") {
+			// rules reach hosts as text parsed by secrules, which reads ipv4 only
+			return fmt.Errorf("ipv6 address is not supported: %s", input.CIDR)
+		}
 		if !regutils.MatchCIDR(input.CIDR) && !regutils.MatchIPAddr(input.CIDR) {
 			return fmt.Errorf("invalid ip address: %s", input.CIDR)
 		}
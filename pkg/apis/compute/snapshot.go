@@ -55,6 +55,9 @@ type SSnapshotPolicyCreateInput struct {
 	RetentionDays  int   `json:"retention_days"`
 	RepeatWeekdays []int `json:"repeat_weekdays"`
 	TimePoints     []int `json:"time_points"`
+
+	// 自动快照前是否通过guest agent冻结虚拟机文件系统, 生成应用一致性快照
+	Quiesce bool `json:"quiesce"`
 }
 
 type SSnapshotPolicyCreateInternalInput struct {
@@ -67,6 +70,7 @@ type SSnapshotPolicyCreateInternalInput struct {
 	RetentionDays  int
 	RepeatWeekdays uint8
 	TimePoints     uint32
+	Quiesce        bool
 }
 
 type SnapshotListInput struct {
@@ -37,10 +37,15 @@ type StorageCreateInput struct {
 	// | rbd 			| rbd_client_mount_timeout	| 否 		|	120		|单位: 秒	|
 	// | nfs 			| nfs_host					| 是 		|			|网络文件系统主机	|
 	// | nfs 			| nfs_shared_dir			| 是 		|			|网络文件系统共享目录	|
+	// | iscsi 			| iscsi_portals				| 是 		|			|iSCSI target 门户地址	|
+	// | iscsi 			| iscsi_target				| 是 		|			|iSCSI target IQN	|
+	// | iscsi 			| iscsi_chap_username		| 否 		|			|CHAP认证用户名	|
+	// | iscsi 			| iscsi_chap_password		| 否 		|			|CHAP认证密码	|
 	// local: 本地存储
 	// rbd: ceph块存储, ceph存储创建时仅会检测是否重复创建，不会具体检测认证参数是否合法，只有挂载存储时
 	// 计算节点会验证参数，若挂载失败，宿主机和存储不会关联，可以通过查看存储日志查找挂载失败原因
-	// enum: local, rbd, nfs, gpfs
+	// iscsi: SAN iSCSI块存储, 存储的LUN需预先在阵列上划分, 每个磁盘独占一个LUN
+	// enum: local, rbd, nfs, gpfs, iscsi
 	// required: true
 	StorageType string `json:"storage_type"`
 
@@ -105,6 +110,30 @@ type StorageCreateInput struct {
 	// 网络文件系统共享目录, storage_type 为 nfs 时, 此参数必传
 	// example: /nfs_root/
 	NfsSharedDir string `json:"nfs_shared_dir"`
+
+	// iSCSI target门户地址, storage_type 为 iscsi 时, 此参数必传
+	// 以逗号分隔的多个门户地址会通过多路径访问同一个LUN
+	// example: 192.168.222.10:3260,192.168.223.10:3260
+	IscsiPortals string `json:"iscsi_portals"`
+
+	// iSCSI target IQN, storage_type 为 iscsi 时, 此参数必传
+	// example: iqn.2004-04.com.qnap:ts-831x:iscsi.target0.8b1a2c
+	IscsiTarget string `json:"iscsi_target"`
+
+	// iSCSI CHAP认证用户名
+	IscsiChapUsername string `json:"iscsi_chap_username"`
+
+	// iSCSI CHAP认证密码
+	IscsiChapPassword string `json:"iscsi_chap_password"`
+}
+
+// SIscsiLun is a logical unit exported by the iSCSI target of storage
+type SIscsiLun struct {
+	Target string `json:"target"`
+	Lun    int    `json:"lun"`
+	SizeMb int64  `json:"size_mb"`
+	// Wwid identifies the same LUN reached from different portals
+	Wwid string `json:"wwid"`
 }
 
 type SStorageCapacityInfo struct {
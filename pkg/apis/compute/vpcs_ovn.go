@@ -95,7 +95,15 @@ const (
 	VpcEipGatewayMac3 = "ee:ee:ee:ee:ee:f0"
 )
 
+const (
+	// [100.65.64.0, 100.65.127.255], a /30 link for each pair of peered vpcs
+	sVpcPeerCidr = "100.65.64.0/18"
+	VpcPeerMask  = 30
+)
+
 var (
+	vpcPeerCidr netutils.IPV4Prefix
+
 	vpcMappedCidr      netutils.IPV4Prefix
 	vpcMappedGatewayIP netutils.IPV4Addr
 
@@ -128,6 +136,8 @@ func init() {
 	vpcInterExtIP1 = mi(netutils.NewIPV4Addr(sVpcInterExtIP1))
 	vpcInterExtIP2 = mi(netutils.NewIPV4Addr(sVpcInterExtIP2))
 
+	vpcPeerCidr = mp(netutils.NewIPV4Prefix(sVpcPeerCidr))
+
 	vpcMappedCidr = mp(netutils.NewIPV4Prefix(sVpcMappedCidr))
 	vpcMappedGatewayIP = mi(netutils.NewIPV4Addr(sVpcMappedGatewayIP))
 
@@ -142,6 +152,10 @@ func init() {
 	vpcMappedIPEnd = mi(netutils.NewIPV4Addr(sVpcMappedIPEnd))
 }
 
+func VpcPeerCidr() netutils.IPV4Prefix {
+	return vpcPeerCidr
+}
+
 func VpcMappedCidr() netutils.IPV4Prefix {
 	return vpcMappedCidr
 }
@@ -535,6 +535,32 @@ type SDisk struct {
 	IsSsd bool `json:"is_ssd"`
 }
 
+// SDiskBackup is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SDiskBackup.
+type SDiskBackup struct {
+	apis.SVirtualResourceBase
+	SDiskResourceBase
+	// 云主机Id
+	GuestId string `json:"guest_id"`
+	// 执行备份的宿主机Id
+	HostId string `json:"host_id"`
+	// 备份存储类型
+	// enum: nfs, s3
+	BackupStorage string `json:"backup_storage"`
+	// 备份文件位置
+	Location string `json:"location"`
+	// 备份模式
+	// enum: full, incremental
+	BackupMode string `json:"backup_mode"`
+	// 所基于的上一个备份Id, 全量备份为空
+	ParentId string `json:"parent_id"`
+	// 备份链Id, 即备份链中全量备份的Id
+	ChainId string `json:"chain_id"`
+	// 备份文件大小, 单位Mb
+	SizeMb int `json:"size_mb"`
+	// 备份时磁盘大小, 单位Mb
+	DiskSizeMb int `json:"disk_size_mb"`
+}
+
 // SDiskResourceBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SDiskResourceBase.
 type SDiskResourceBase struct {
 	DiskId string `json:"disk_id"`
@@ -605,6 +631,28 @@ type SDnsZoneVpc struct {
 	VpcId string `json:"vpc_id"`
 }
 
+// SDrsPolicy is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SDrsPolicy.
+type SDrsPolicy struct {
+	apis.SEnabledStatusStandaloneResourceBase
+	SZoneResourceBase
+	// 平衡范围内宿主机的调度标签Id
+	SchedtagId string `json:"schedtag_id"`
+	// 运行模式
+	// enum: manual, auto
+	Mode string `json:"mode"`
+	// 可以容忍的宿主机负载标准差, 单位%
+	Threshold int `json:"threshold"`
+	// 每轮最多迁移的虚拟机数量
+	MaxMigrations int `json:"max_migrations"`
+	// 维护窗口, 格式 HH:MM
+	WindowStart string `json:"window_start"`
+	WindowEnd   string `json:"window_end"`
+	// 上次评估时间
+	LastEvaluatedAt time.Time `json:"last_evaluated_at"`
+	// 上次评估得出的迁移计划
+	LastPlan interface{} `json:"last_plan"`
+}
+
 // SDynamicschedtag is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SDynamicschedtag.
 type SDynamicschedtag struct {
 	apis.SStandaloneResourceBase
@@ -1237,6 +1285,8 @@ type SInstanceSnapshot struct {
 	InstanceType string `json:"instance_type"`
 	// 主机快照磁盘容量和
 	SizeMb int `json:"size_mb"`
+	// 创建快照时是否冻结了文件系统, 即是否为应用一致性快照
+	Quiesced bool `json:"quiesced"`
 }
 
 // SInstanceSnapshotJoint is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SInstanceSnapshotJoint.
@@ -1453,6 +1503,8 @@ type SLoadbalancerBackend struct {
 	Port        int    `json:"port"`
 	SendProxy   string `json:"send_proxy"`
 	Ssl         string `json:"ssl"`
+	// 健康检查状态 online|offline|unknown
+	HealthStatus string `json:"health_status"`
 }
 
 // SLoadbalancerBackendGroup is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SLoadbalancerBackendGroup.
@@ -1487,6 +1539,9 @@ type SLoadbalancerCluster struct {
 	apis.SStandaloneResourceBase
 	SZoneResourceBase
 	SWireResourceBase
+	// WireId string `width:"36" charset:"ascii" nullable:"true" list:"admin" create:"optional" update:"admin"`
+	// 集群类型 lbagent|ovn
+	ClusterType string `json:"cluster_type"`
 }
 
 // SLoadbalancerClusterResourceBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SLoadbalancerClusterResourceBase.
@@ -1723,15 +1778,22 @@ type SNetwork struct {
 	// DNS
 	GuestDns string `json:"guest_dns"`
 	// allow multiple dhcp, seperated by ","
-	GuestDhcp     string `json:"guest_dhcp"`
-	GuestDomain   string `json:"guest_domain"`
+	GuestDhcp   string `json:"guest_dhcp"`
+	GuestDomain string `json:"guest_domain"`
+	// IPv6起始地址
 	GuestIp6Start string `json:"guest_ip6_start"`
-	GuestIp6End   string `json:"guest_ip6_end"`
-	GuestIp6Mask  byte   `json:"guest_ip6_mask"`
+	// IPv6结束地址
+	GuestIp6End string `json:"guest_ip6_end"`
+	// IPv6前缀长度
+	GuestIp6Mask byte `json:"guest_ip6_mask"`
+	// IPv6网关地址
 	GuestGateway6 string `json:"guest_gateway6"`
-	GuestDns6     string `json:"guest_dns6"`
-	GuestDomain6  string `json:"guest_domain6"`
-	VlanId        int    `json:"vlan_id"`
+	// IPv6 DNS
+	GuestDns6    string `json:"guest_dns6"`
+	GuestDomain6 string `json:"guest_domain6"`
+	// IPv6地址分配方式，slaac或dhcpv6
+	GuestIp6AllocMode string `json:"guest_ip6_alloc_mode"`
+	VlanId            int    `json:"vlan_id"`
 	// 服务器类型
 	// example: server
 	ServerType string `json:"server_type"`
@@ -1989,6 +2051,10 @@ type SScalingGroupGuest struct {
 	ScalingGroupId string `json:"scaling_group_id"`
 	GuestStatus    string `json:"guest_status"`
 	Manual         *bool  `json:"manual,omitempty"`
+	// ScaleInProtected guests are never removed by scaling in
+	ScaleInProtected *bool `json:"scale_in_protected,omitempty"`
+	// InstanceRefreshId is the id of the instance refresh which created the guest
+	InstanceRefreshId string `json:"instance_refresh_id"`
 }
 
 // SScalingGroupNetwork is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingGroupNetwork.
@@ -2004,6 +2070,61 @@ type SScalingGroupResourceBase struct {
 	ScalingGroupId string `json:"scaling_group_id"`
 }
 
+// SScalingInstanceRefresh is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingInstanceRefresh.
+type SScalingInstanceRefresh struct {
+	apis.SStatusStandaloneResourceBase
+	SScalingGroupResourceBase
+	// 替换过程中健康实例数占期望实例数的最小百分比
+	MinHealthyPercentage int `json:"min_healthy_percentage"`
+	// 每批替换的实例数
+	BatchSize int `json:"batch_size"`
+	// 两批替换之间的暂停时间
+	Pause           int    `json:"pause"`
+	GuestTemplateId string `json:"guest_template_id"`
+	// Content of guest template when the refresh started
+	Content                 interface{} `json:"content"`
+	PreviousGuestTemplateId string      `json:"previous_guest_template_id"`
+	// Content which the refresh rolls back to, empty if unknown
+	PreviousContent interface{} `json:"previous_content"`
+	// 已替换的实例数
+	RefreshedNumber int       `json:"refreshed_number"`
+	StartTime       time.Time `json:"start_time"`
+	EndTime         time.Time `json:"end_time"`
+	Reason          string    `json:"reason"`
+}
+
+// SScalingLifecycleAction is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingLifecycleAction.
+type SScalingLifecycleAction struct {
+	apis.SStatusStandaloneResourceBase
+	SScalingGroupResourceBase
+	LifecycleHookId string `json:"lifecycle_hook_id"`
+	GuestId         string `json:"guest_id"`
+	// 挂钩的伸缩行为
+	Transition string `json:"transition"`
+	// 生命周期动作的结果
+	Result    string    `json:"result"`
+	StartTime time.Time `json:"start_time"`
+	// 超时时间, 超时后使用挂钩的默认结果
+	TimeoutAt time.Time `json:"timeout_at"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+// SScalingLifecycleHook is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingLifecycleHook.
+type SScalingLifecycleHook struct {
+	apis.SVirtualResourceBase
+	SScalingGroupResourceBase
+	// 挂钩的伸缩行为
+	Transition string `json:"transition"`
+	// 等待完成的超时时间
+	HeartbeatTimeout int `json:"heartbeat_timeout"`
+	// 超时后的默认结果
+	DefaultResult string `json:"default_result"`
+	// Receivers of the notification, separated by comma
+	Receivers string `json:"receivers"`
+	// 附加在通知中的信息
+	NotificationMetadata string `json:"notification_metadata"`
+}
+
 // SScalingPolicy is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingPolicy.
 type SScalingPolicy struct {
 	apis.SVirtualResourceBase
@@ -2016,8 +2137,12 @@ type SScalingPolicy struct {
 	Number int    `json:"number"`
 	// Unit of Number
 	Unit string `json:"unit"`
+	// Steps of action 'step', the step containing the value of alarm indicator is applied
+	Steps *SScalingSteps `json:"steps"`
 	// Scaling activity triggered by alarms will be rejected during this period about CoolingTime
 	CoolingTime int `json:"cooling_time"`
+	// AllowScaleTime is the end of cooling time of this policy
+	AllowScaleTime time.Time `json:"allow_scale_time"`
 }
 
 // SScalingPolicyBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingPolicyBase.
@@ -2025,6 +2150,20 @@ type SScalingPolicyBase struct {
 	ScalingPolicyId string `json:"scaling_policy_id"`
 }
 
+// SScalingTargetTracking is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingTargetTracking.
+type SScalingTargetTracking struct {
+	apis.SStandaloneResourceBase
+	SScalingPolicyBase
+	Indicator   string  `json:"indicator"`
+	TargetValue float64 `json:"target_value"`
+	Cycle       int     `json:"cycle"`
+	// Only scale out if DisableScaleIn is true
+	DisableScaleIn *bool `json:"disable_scale_in,omitempty"`
+	// ID of alarm configs in alarm service
+	ScaleOutAlarmId string `json:"scale_out_alarm_id"`
+	ScaleInAlarmId  string `json:"scale_in_alarm_id"`
+}
+
 // SScalingTimer is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SScalingTimer.
 type SScalingTimer struct {
 	apis.SStandaloneResourceBase
@@ -2195,6 +2334,8 @@ type SSnapshot struct {
 	RefCount      int       `json:"ref_count"`
 	BackingDiskId string    `json:"backing_disk_id"`
 	ExpiredAt     time.Time `json:"expired_at"`
+	// 创建快照时是否冻结了文件系统, 即是否为应用一致性快照
+	Quiesced bool `json:"quiesced"`
 }
 
 // SSnapshotPolicy is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SSnapshotPolicy.
@@ -2206,6 +2347,8 @@ type SSnapshotPolicy struct {
 	// 0~23
 	TimePoints  uint32 `json:"time_points"`
 	IsActivated *bool  `json:"is_activated,omitempty"`
+	// 是否冻结文件系统生成应用一致性快照
+	Quiesce bool `json:"quiesce"`
 }
 
 // SSnapshotPolicyCache is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SSnapshotPolicyCache.
@@ -0,0 +1,31 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+const (
+	ENCRYPT_ALG_AES256 = "aes-256"
+)
+
+// SEncryptInfo carries the data key of a resource encrypted at rest
+type SEncryptInfo struct {
+	// key id
+	Id string `json:"id"`
+	// key name
+	Name string `json:"name"`
+	// base64 encoded data key, used as the LUKS passphrase of encrypted disks
+	Key string `json:"key"`
+	// key algorithm
+	Alg string `json:"alg"`
+}
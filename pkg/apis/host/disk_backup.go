@@ -0,0 +1,52 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package host
+
+type DiskBackupRequest struct {
+	BackupId string `json:"backup_id"`
+	// backup this one is based on, required by incremental backup
+	ParentId string `json:"parent_id"`
+	// full or incremental
+	Mode string `json:"mode"`
+	// guest the disk is attached to, backup is taken online if it is running
+	ServerId string `json:"server_id"`
+}
+
+type DiskBackupResponse struct {
+	// mode actually used, incremental backup falls back to full
+	// if there is no usable dirty bitmap
+	Mode        string `json:"mode"`
+	StorageType string `json:"storage_type"`
+	Location    string `json:"location"`
+	// size of backup file
+	SizeMb int `json:"size_mb"`
+	// virtual size of the disk
+	DiskSizeMb int `json:"disk_size_mb"`
+}
+
+type DiskBackupInfo struct {
+	BackupId string `json:"backup_id"`
+	Location string `json:"location"`
+}
+
+type DiskBackupRestoreRequest struct {
+	// backups from the full one to the point restored to, in order
+	Backups []DiskBackupInfo `json:"backups"`
+}
+
+type DiskBackupDeleteRequest struct {
+	DiskId  string           `json:"disk_id"`
+	Backups []DiskBackupInfo `json:"backups"`
+}
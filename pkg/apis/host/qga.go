@@ -0,0 +1,104 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package host
+
+type GuestQgaPingRequest struct {
+	// seconds to wait guest agent response
+	Timeout int `json:"timeout"`
+}
+
+type GuestQgaSetPasswordRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Crypted  bool   `json:"crypted"`
+}
+
+type GuestQgaExecRequest struct {
+	Path  string   `json:"path"`
+	Args  []string `json:"args"`
+	Env   []string `json:"env"`
+	Input string   `json:"input"`
+	// seconds to wait the command exit
+	Timeout int `json:"timeout"`
+}
+
+type GuestQgaExecResponse struct {
+	Exited       bool   `json:"exited"`
+	ExitCode     int    `json:"exit_code"`
+	Signal       int    `json:"signal"`
+	Stdout       string `json:"stdout"`
+	Stderr       string `json:"stderr"`
+	OutTruncated bool   `json:"out_truncated"`
+	ErrTruncated bool   `json:"err_truncated"`
+}
+
+type GuestQgaIpAddress struct {
+	IpAddressType string `json:"ip_address_type"`
+	IpAddress     string `json:"ip_address"`
+	Prefix        int    `json:"prefix"`
+}
+
+type GuestQgaNetworkInterface struct {
+	Name            string              `json:"name"`
+	HardwareAddress string              `json:"hardware_address"`
+	IpAddresses     []GuestQgaIpAddress `json:"ip_addresses"`
+}
+
+type GuestQgaNetworkInterfacesResponse struct {
+	Interfaces []GuestQgaNetworkInterface `json:"interfaces"`
+}
+
+type GuestQgaOsInfoResponse struct {
+	Id            string `json:"id"`
+	Name          string `json:"name"`
+	PrettyName    string `json:"pretty_name"`
+	Version       string `json:"version"`
+	VersionId     string `json:"version_id"`
+	KernelRelease string `json:"kernel_release"`
+	KernelVersion string `json:"kernel_version"`
+	Machine       string `json:"machine"`
+}
+
+type GuestQgaFsfreezeRequest struct {
+	// seconds to wait freeze or thaw finish
+	Timeout int `json:"timeout"`
+	// seconds after which host thaws filesystems by itself,
+	// guards guest against callers never sending thaw
+	AutoThawTimeout int `json:"auto_thaw_timeout"`
+}
+
+type GuestQgaFsfreezeResponse struct {
+	// number of filesystems frozen or thawed
+	Count int `json:"count"`
+	// thawed or frozen
+	Status string `json:"status"`
+}
+
+type GuestQgaFileReadRequest struct {
+	Path    string `json:"path"`
+	MaxSize int    `json:"max_size"`
+}
+
+type GuestQgaFileResponse struct {
+	Path string `json:"path"`
+	// base64 encoded file content
+	Content string `json:"content"`
+}
+
+type GuestQgaFileWriteRequest struct {
+	Path string `json:"path"`
+	// base64 encoded file content
+	Content string `json:"content"`
+}
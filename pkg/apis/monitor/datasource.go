@@ -0,0 +1,42 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import "yunion.io/x/onecloud/pkg/apis"
+
+type DataSourceCreateInput struct {
+	apis.StandaloneResourceCreateInput
+
+	// 数据源类型, influxdb 或者 prometheus
+	Type string `json:"type"`
+	// 数据源地址, prometheus 为其 http api 根地址, 如 http://prometheus:9090
+	Url string `json:"url"`
+	// 认证用户名
+	User string `json:"user"`
+	// 认证密码
+	Password string `json:"password"`
+	// 默认数据库, 仅 influxdb 使用
+	Database string `json:"database"`
+}
+
+type DataSourceUpdateInput struct {
+	apis.StandaloneResourceBaseUpdateInput
+
+	Type     string `json:"type"`
+	Url      string `json:"url"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+	Database string `json:"database"`
+}
@@ -15,7 +15,15 @@
 package monitor
 
 const (
-	DataSourceTypeInfluxdb = "influxdb"
+	DataSourceTypeInfluxdb   = "influxdb"
+	DataSourceTypePrometheus = "prometheus"
+)
+
+var (
+	DataSourceTypes = []string{
+		DataSourceTypeInfluxdb,
+		DataSourceTypePrometheus,
+	}
 )
 
 type DataSourceConfig struct {
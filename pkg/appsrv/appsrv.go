@@ -216,16 +216,20 @@ func (app *Application) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		hi = &app.defHandlerInfo
 	}
 	var counter *handlerRequestCounter
+	code := "2XX"
 	if lrw.status < 400 {
 		counter = &hi.counter2XX
 	} else if lrw.status < 500 {
 		counter = &hi.counter4XX
+		code = "4XX"
 	} else {
 		counter = &hi.counter5XX
+		code = "5XX"
 	}
 	duration := float64(time.Since(start).Nanoseconds()) / 1000000
 	counter.hit += 1
 	counter.duration += duration
+	observeHandlerRequest(hi, code, duration/1000)
 	skipLog := false
 	if params != nil {
 		if params.SkipLog {
@@ -364,6 +368,7 @@ func (app *Application) addDefaultHandlers() {
 	app.AddDefaultHandler("POST", "/ping", PingHandler, "ping")
 	app.AddDefaultHandler("GET", "/ping", PingHandler, "ping")
 	app.AddDefaultHandler("GET", "/worker_stats", WorkerStatsHandler, "worker_stats")
+	app.AddDefaultHandler("GET", "/metrics", MetricsHandler, "metrics")
 }
 
 func timeoutHandle(h http.Handler) http.HandlerFunc {
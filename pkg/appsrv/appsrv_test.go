@@ -20,8 +20,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+
+	"yunion.io/x/onecloud/pkg/util/promutils"
 )
 
 func TestSplitPath(t *testing.T) {
@@ -77,6 +80,25 @@ func (suite *ApplicationTestSuit) TestHandler() {
 	assert.True(suite.T(), assert.HTTPBodyContains(suite.T(), app.ServeHTTP, "GET", "/delaypanic", nil, "the handler is delay panic"))
 }
 
+func (suite *ApplicationTestSuit) TestMetricsHandler() {
+	app := suite.app
+	app.AddDefaultHandler("GET", "/ping", PingHandler, "ping")
+	app.AddDefaultHandler("GET", "/metrics", MetricsHandler, "metrics")
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_metric"})
+	gauge.Set(1)
+	promutils.Register(gauge)
+	assert.True(suite.T(), assert.HTTPBodyContains(suite.T(), app.ServeHTTP, "GET", "/ping", nil, ""))
+	for _, s := range []string{
+		`appsrv_http_requests_total{code="2XX",method="GET",name="ping",path="/ping"}`,
+		`appsrv_http_request_duration_seconds_bucket{code="2XX",method="GET",name="ping",path="/ping",le="+Inf"}`,
+		`appsrv_worker_queue_size{worker=`,
+		"go_goroutines",
+		"test_metric 1",
+	} {
+		assert.True(suite.T(), assert.HTTPBodyContains(suite.T(), app.ServeHTTP, "GET", "/metrics", nil, s))
+	}
+}
+
 func TestApplicationTestSuite(t *testing.T) {
 	suite.Run(t, new(ApplicationTestSuit))
 }
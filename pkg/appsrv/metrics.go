@@ -0,0 +1,96 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appsrv
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"yunion.io/x/onecloud/pkg/util/promutils"
+)
+
+// latencyBuckets are the upper bounds in seconds of request latency histogram
+var latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+var (
+	requestLabels = []string{"method", "path", "name", "code"}
+
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "appsrv_http_requests_total",
+		Help: "Total number of http requests by handler and status class.",
+	}, requestLabels)
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "appsrv_http_request_duration_seconds",
+		Help:    "Latency of http requests by handler and status class.",
+		Buckets: latencyBuckets,
+	}, requestLabels)
+
+	workerLabels   = []string{"worker"}
+	workerQueue    = prometheus.NewDesc("appsrv_worker_queue_size", "Number of requests waiting in the worker queue.", workerLabels, nil)
+	workerActive   = prometheus.NewDesc("appsrv_worker_active_count", "Number of active workers.", workerLabels, nil)
+	workerDetached = prometheus.NewDesc("appsrv_worker_detached_count", "Number of detached workers.", workerLabels, nil)
+	workerMax      = prometheus.NewDesc("appsrv_worker_max_count", "Maximal number of workers.", workerLabels, nil)
+	workerBacklog  = prometheus.NewDesc("appsrv_worker_backlog", "Capacity of the worker queue.", workerLabels, nil)
+)
+
+func init() {
+	promutils.Register(httpRequests)
+	promutils.Register(httpRequestDuration)
+	promutils.Register(promutils.NewCollector(collectWorkerMetrics,
+		workerQueue, workerActive, workerDetached, workerMax, workerBacklog))
+}
+
+// ObserveRequest records the latency in seconds of a request, it is also
+// used by the services not served by appsrv
+func ObserveRequest(method, path, name, code string, seconds float64) {
+	httpRequests.WithLabelValues(method, path, name, code).Inc()
+	httpRequestDuration.WithLabelValues(method, path, name, code).Observe(seconds)
+}
+
+func observeHandlerRequest(hi *SHandlerInfo, code string, seconds float64) {
+	method, path := "*", "*"
+	if len(hi.method) > 0 {
+		method = hi.method
+		path = "/" + strings.Join(hi.path, "/")
+	}
+	ObserveRequest(method, path, hi.GetName(nil), code, seconds)
+}
+
+func collectWorkerMetrics(ch chan<- prometheus.Metric) {
+	for i := 0; i < len(workerManagers); i += 1 {
+		state := workerManagers[i].getState()
+		for _, m := range []struct {
+			desc  *prometheus.Desc
+			value int
+		}{
+			{workerQueue, state.QueueCnt},
+			{workerActive, state.ActiveWorkerCnt},
+			{workerDetached, state.DetachWorkerCnt},
+			{workerMax, state.MaxWorkerCnt},
+			{workerBacklog, state.Backlog},
+		} {
+			ch <- prometheus.MustNewConstMetric(m.desc, prometheus.GaugeValue, float64(m.value), state.Name)
+		}
+	}
+}
+
+// MetricsHandler exports the metrics of all the collectors registered to
+// promutils.Registry, including request latency and worker queue
+func MetricsHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	promutils.Handler().ServeHTTP(w, r)
+}
@@ -140,6 +140,7 @@ func (agent *SBaremetalAgent) StartService() error {
 
 	agent.startPXEServices(manager)
 	agent.startFileServer()
+	agent.startRedfishEventReceiver()
 
 	agent.DoOnline(agent.GetAdminSession())
 	return nil
@@ -199,6 +200,7 @@ func (agent *SBaremetalAgent) startFileServer() {
 	http.Handle("/images/", http.StripPrefix("/images/", cacheFs))
 	isoFs := http.FileServer(httputils.Dir(o.Options.BootIsoPath))
 	http.Handle("/bootiso/", http.StripPrefix("/bootiso/", isoFs))
+	http.Handle(pxe.IPXEScriptPrefix, pxe.NewIPXEHandler(agent.Manager))
 	go func() {
 		if err := http.ListenAndServe(fmt.Sprintf("%s:%d", dhcpListenIp, o.Options.Port+1000), nil); err != nil {
 			panic(fmt.Sprintf("start http file server: %v", err))
@@ -185,6 +185,11 @@ func (job *SLogFetchJob) Do(ctx context.Context, now time.Time) error {
 	if !job.baremetal.isRedfishCapable() {
 		return nil
 	}
+	if job.baremetal.isEventSubscribed() {
+		// system events are pushed by BMC
+		job.lastTime = now
+		return nil
+	}
 	err := fetchLogs(job.baremetal, ctx, redfish.EVENT_TYPE_SYSTEM)
 	if err != nil {
 		return errors.Wrap(err, "fetchLogs api.EVENT_TYPE_SYSTEM")
@@ -250,3 +255,36 @@ func (job *SSendMetricsJob) Do(ctx context.Context, now time.Time) error {
 	job.lastTime = now
 	return nil
 }
+
+type SEventSubscribeJob struct {
+	SBaseBaremetalCronJob
+}
+
+func NewEventSubscribeJob(baremetal *SBaremetalInstance, interval time.Duration) IBaremetalCronJob {
+	return &SEventSubscribeJob{
+		SBaseBaremetalCronJob: SBaseBaremetalCronJob{
+			baremetal: baremetal,
+			interval:  interval,
+		},
+	}
+}
+
+func (job *SEventSubscribeJob) Name() string {
+	return "EventSubscribeJob"
+}
+
+func (job *SEventSubscribeJob) Do(ctx context.Context, now time.Time) error {
+	if !o.Options.EnableRedfishEvents || len(redfishEventSecret) == 0 {
+		return nil
+	}
+	if !job.baremetal.isRedfishCapable() {
+		return nil
+	}
+	subscribed, err := job.baremetal.syncRedfishEventSubscription(ctx)
+	if err != nil {
+		return errors.Wrap(err, "syncRedfishEventSubscription")
+	}
+	job.baremetal.eventSubscribed = subscribed
+	job.lastTime = now
+	return nil
+}
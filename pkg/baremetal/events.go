@@ -0,0 +1,316 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baremetal
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	o "yunion.io/x/onecloud/pkg/baremetal/options"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/util/influxdb"
+	"yunion.io/x/onecloud/pkg/util/redfish"
+	"yunion.io/x/onecloud/pkg/util/seclib2"
+)
+
+const (
+	REDFISH_EVENTS_PREFIX = "/redfish-events/"
+
+	redfishEventSecretFile = ".redfish_event_secret"
+	redfishEventCertFile   = ".redfish_event.crt"
+	redfishEventKeyFile    = ".redfish_event.key"
+)
+
+var (
+	redfishEventSecret []byte
+)
+
+func loadRedfishEventSecret() ([]byte, error) {
+	path := filepath.Join(o.Options.BaremetalsPath, redfishEventSecretFile)
+	secret, err := ioutil.ReadFile(path)
+	if err == nil && len(secret) > 0 {
+		return secret, nil
+	}
+	buf := make([]byte, 32)
+	_, err = rand.Read(buf)
+	if err != nil {
+		return nil, errors.Wrap(err, "rand.Read")
+	}
+	secret = []byte(hex.EncodeToString(buf))
+	err = ioutil.WriteFile(path, secret, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "write %s", path)
+	}
+	return secret, nil
+}
+
+// redfishEventContext returns the token carried by every event BMC pushes for
+// the baremetal, used to authenticate the unauthenticated event receiver
+func redfishEventContext(bmId string) string {
+	mac := hmac.New(sha256.New, redfishEventSecret)
+	mac.Write([]byte(bmId))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+func getRedfishEventCert(accessIp net.IP) (string, string, error) {
+	if len(o.Options.RedfishEventCertfile) > 0 && len(o.Options.RedfishEventKeyfile) > 0 {
+		return o.Options.RedfishEventCertfile, o.Options.RedfishEventKeyfile, nil
+	}
+	if len(o.Options.SslCertfile) > 0 && len(o.Options.SslKeyfile) > 0 {
+		return o.Options.SslCertfile, o.Options.SslKeyfile, nil
+	}
+	certFile := filepath.Join(o.Options.BaremetalsPath, redfishEventCertFile)
+	keyFile := filepath.Join(o.Options.BaremetalsPath, redfishEventKeyFile)
+	if _, err := os.Stat(certFile); err == nil {
+		if _, err := os.Stat(keyFile); err == nil {
+			return certFile, keyFile, nil
+		}
+	}
+	certPEM, keyPEM, err := seclib2.GenerateSelfSignedCert("baremetal-agent", []string{accessIp.String()}, 10*365*24*time.Hour)
+	if err != nil {
+		return "", "", errors.Wrap(err, "GenerateSelfSignedCert")
+	}
+	err = ioutil.WriteFile(certFile, certPEM, 0644)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "write %s", certFile)
+	}
+	err = ioutil.WriteFile(keyFile, keyPEM, 0600)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "write %s", keyFile)
+	}
+	return certFile, keyFile, nil
+}
+
+func (agent *SBaremetalAgent) getRedfishEventDestination(bmId string) (string, error) {
+	accessIp, err := agent.GetAccessIP()
+	if err != nil {
+		return "", errors.Wrap(err, "GetAccessIP")
+	}
+	addr := net.JoinHostPort(accessIp.String(), strconv.Itoa(o.Options.RedfishEventListenPort))
+	return fmt.Sprintf("https://%s%s%s", addr, REDFISH_EVENTS_PREFIX, bmId), nil
+}
+
+func (agent *SBaremetalAgent) startRedfishEventReceiver() {
+	if !o.Options.EnableRedfishEvents {
+		return
+	}
+	accessIp, err := agent.GetAccessIP()
+	if err != nil {
+		log.Errorf("Get access ip for redfish event receiver error: %v", err)
+		return
+	}
+	redfishEventSecret, err = loadRedfishEventSecret()
+	if err != nil {
+		log.Errorf("load redfish event secret error: %v", err)
+		return
+	}
+	certFile, keyFile, err := getRedfishEventCert(accessIp)
+	if err != nil {
+		log.Errorf("prepare redfish event receiver certificate error: %v", err)
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(REDFISH_EVENTS_PREFIX, agent.handleRedfishEvents)
+	server := &http.Server{
+		Addr:         net.JoinHostPort("", strconv.Itoa(o.Options.RedfishEventListenPort)),
+		Handler:      mux,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+	go func() {
+		log.Infof("Start redfish event receiver on https://%s", server.Addr)
+		err := server.ListenAndServeTLS(certFile, keyFile)
+		if err != nil && err != http.ErrServerClosed {
+			log.Errorf("redfish event receiver: %v", err)
+		}
+	}()
+}
+
+func (agent *SBaremetalAgent) handleRedfishEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	bmId := strings.Trim(strings.TrimPrefix(r.URL.Path, REDFISH_EVENTS_PREFIX), "/")
+	if agent.Manager == nil || len(bmId) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	bm := agent.Manager.GetBaremetalById(bmId)
+	if bm == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	body, err := jsonutils.Parse(data)
+	if err != nil {
+		log.Errorf("baremetal %s invalid redfish event %s: %v", bm.GetName(), data, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	evCtx, _ := body.GetString("Context")
+	if !hmac.Equal([]byte(evCtx), []byte(redfishEventContext(bmId))) {
+		log.Warningf("baremetal %s redfish event from %s with mismatch context", bm.GetName(), r.RemoteAddr)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	events, err := redfish.ParseEvents(body)
+	if err != nil {
+		log.Errorf("baremetal %s parse redfish event error: %v", bm.GetName(), err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	// BMC expects a quick response, events are stored asynchronously
+	w.WriteHeader(http.StatusNoContent)
+	go func() {
+		err := bm.saveRedfishEvents(context.Background(), events)
+		if err != nil {
+			log.Errorf("baremetal %s save redfish events error: %v", bm.GetName(), err)
+		}
+	}()
+}
+
+func (b *SBaremetalInstance) saveRedfishEvents(ctx context.Context, events []redfish.SEvent) error {
+	s := auth.GetAdminSession(ctx, consts.GetRegion(), "")
+	metrics := make([]influxdb.SMetricData, 0)
+	// a failed event is not to drop the alerts of the others
+	errs := make([]error, 0)
+	for i := range events {
+		eventData := eventToJson(events[i])
+		eventData.Add(jsonutils.NewString(b.GetId()), "host_id")
+		eventData.Add(jsonutils.NewString(b.GetName()), "host_name")
+		eventData.Add(jsonutils.NewString(b.GetIPMINicIPAddr()), "ipmi_ip")
+		_, err := modules.BaremetalEvents.Create(s, eventData)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "modules.BaremetalEvents.Create %s", events[i].EventId))
+		}
+		reason := fmt.Sprintf("%s: %s", events[i].EventId, events[i].Message)
+		switch events[i].Severity {
+		case redfish.EVENT_SEVERITY_CRITICAL:
+			notifyclient.NotifySystemErrorWithCtx(ctx, b.GetId(), b.GetName(), api.BAREMETAL_HARDWARE_ALERT, reason)
+		case redfish.EVENT_SEVERITY_WARNING:
+			notifyclient.NotifySystemWarningWithCtx(ctx, b.GetId(), b.GetName(), api.BAREMETAL_HARDWARE_ALERT, reason)
+		}
+		tags := append(b.getTags(), influxdb.SKeyValue{Key: "severity", Value: events[i].Severity})
+		metrics = append(metrics, influxdb.SMetricData{
+			Name: "baremetal_hardware_event",
+			Tags: tags,
+			Metrics: []influxdb.SKeyValue{
+				{Key: "event_id", Value: events[i].EventId},
+				{Key: "count", Value: "1"},
+			},
+			Timestamp: events[i].Created,
+		})
+	}
+	// monitor alerts are evaluated on the hardware event measurement
+	urls, err := s.GetServiceURLs("influxdb", o.Options.SessionEndpointType)
+	if err == nil && len(urls) > 0 {
+		err = influxdb.SendMetrics(urls, "telegraf", metrics, false)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "influxdb.SendMetrics"))
+		}
+	}
+	return errors.NewAggregate(errs)
+}
+
+// syncRedfishEventSubscription makes sure the BMC pushes events to the agent,
+// returns false if the BMC has no usable EventService
+func (b *SBaremetalInstance) syncRedfishEventSubscription(ctx context.Context) (bool, error) {
+	redfishApi := b.GetRedfishCli(ctx)
+	if redfishApi == nil {
+		return false, nil
+	}
+	dest, err := b.manager.Agent.getRedfishEventDestination(b.GetId())
+	if err != nil {
+		return false, errors.Wrap(err, "getRedfishEventDestination")
+	}
+	evCtx := redfishEventContext(b.GetId())
+	subs, err := redfishApi.GetEventSubscriptions(ctx)
+	if err != nil {
+		log.Infof("baremetal %s EventService not available, fallback to polling: %v", b.GetName(), err)
+		return false, nil
+	}
+	for i := range subs {
+		if subs[i].Destination != dest {
+			continue
+		}
+		if subs[i].Context == evCtx {
+			return true, nil
+		}
+		// stale subscription, e.g. the secret was regenerated
+		err := redfishApi.UnsubscribeEvents(ctx, subs[i].Path)
+		if err != nil {
+			return false, errors.Wrap(err, "UnsubscribeEvents")
+		}
+	}
+	path, err := redfishApi.SubscribeEvents(ctx, dest, evCtx)
+	if err != nil {
+		log.Infof("baremetal %s subscribe events fail, fallback to polling: %v", b.GetName(), err)
+		return false, nil
+	}
+	log.Infof("baremetal %s subscribe redfish events at %s", b.GetName(), path)
+	return true, nil
+}
+
+func (b *SBaremetalInstance) unsubscribeRedfishEvents(ctx context.Context) error {
+	if !o.Options.EnableRedfishEvents || !b.isEventSubscribed() {
+		return nil
+	}
+	redfishApi := b.GetRedfishCli(ctx)
+	if redfishApi == nil {
+		return nil
+	}
+	dest, err := b.manager.Agent.getRedfishEventDestination(b.GetId())
+	if err != nil {
+		return errors.Wrap(err, "getRedfishEventDestination")
+	}
+	subs, err := redfishApi.GetEventSubscriptions(ctx)
+	if err != nil {
+		return errors.Wrap(err, "GetEventSubscriptions")
+	}
+	for i := range subs {
+		if subs[i].Destination == dest {
+			err := redfishApi.UnsubscribeEvents(ctx, subs[i].Path)
+			if err != nil {
+				return errors.Wrap(err, "UnsubscribeEvents")
+			}
+		}
+	}
+	return nil
+}
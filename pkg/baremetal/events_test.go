@@ -0,0 +1,126 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baremetal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+)
+
+func TestRedfishEventContext(t *testing.T) {
+	redfishEventSecret = []byte("0123456789abcdef")
+	ctx := redfishEventContext("bm-0")
+	if len(ctx) != 32 {
+		t.Errorf("want context of 32 chars, got %q", ctx)
+	}
+	if ctx != redfishEventContext("bm-0") {
+		t.Errorf("context of the same baremetal changes")
+	}
+	if ctx == redfishEventContext("bm-1") {
+		t.Errorf("baremetals share the same context")
+	}
+	redfishEventSecret = []byte("fedcba9876543210")
+	if ctx == redfishEventContext("bm-0") {
+		t.Errorf("context does not change with the secret")
+	}
+}
+
+func TestHandleRedfishEvents(t *testing.T) {
+	redfishEventSecret = []byte("0123456789abcdef")
+	man := &SBaremetalManager{
+		baremetals: newBaremetalMap(),
+	}
+	man.baremetals.Add(&SBaremetalInstance{
+		manager:  man,
+		desc:     jsonutils.Marshal(map[string]string{"id": "bm-0", "name": "bm0"}).(*jsonutils.JSONDict),
+		descLock: new(sync.Mutex),
+	})
+	agent := &SBaremetalAgent{
+		Manager: man,
+	}
+	eventsBody := func(evCtx string) string {
+		return `{"Context": "` + evCtx + `", "Events": [{"EventId": "2162", "Message": "The system inlet temperature is less than the lower warning threshold.", "Severity": "Warning"}]}`
+	}
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{
+			name:   "non-POST request",
+			method: http.MethodGet,
+			path:   REDFISH_EVENTS_PREFIX + "bm-0",
+			want:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "no baremetal id",
+			method: http.MethodPost,
+			path:   REDFISH_EVENTS_PREFIX,
+			body:   eventsBody(redfishEventContext("")),
+			want:   http.StatusNotFound,
+		},
+		{
+			name:   "unknown baremetal",
+			method: http.MethodPost,
+			path:   REDFISH_EVENTS_PREFIX + "bm-1",
+			body:   eventsBody(redfishEventContext("bm-1")),
+			want:   http.StatusNotFound,
+		},
+		{
+			name:   "invalid json",
+			method: http.MethodPost,
+			path:   REDFISH_EVENTS_PREFIX + "bm-0",
+			body:   `{"Events": [`,
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "no context",
+			method: http.MethodPost,
+			path:   REDFISH_EVENTS_PREFIX + "bm-0",
+			body:   `{"Events": [{"EventId": "2162", "Severity": "Warning"}]}`,
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "context of another baremetal",
+			method: http.MethodPost,
+			path:   REDFISH_EVENTS_PREFIX + "bm-0/",
+			body:   eventsBody(redfishEventContext("bm-1")),
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "no events",
+			method: http.MethodPost,
+			path:   REDFISH_EVENTS_PREFIX + "bm-0",
+			body:   `{"Context": "` + redfishEventContext("bm-0") + `"}`,
+			want:   http.StatusBadRequest,
+		},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		w := httptest.NewRecorder()
+		agent.handleRedfishEvents(w, req)
+		if w.Code != c.want {
+			t.Errorf("%s: want %d, got %d", c.name, c.want, w.Code)
+		}
+	}
+}
@@ -73,6 +73,11 @@ func initBaremetalsHandler(app *appsrv.Application) {
 	AddHandler(app, "POST", bmActionPrefix("ipmi-probe"), bmObjMiddleware(handleBaremetalIpmiProbe))
 	AddHandler(app, "POST", bmActionPrefix("cdrom"), bmObjMiddleware(handleBaremetalCdromTask))
 	AddHandler(app, "POST", bmActionPrefix("jnlp"), bmObjMiddleware(handleBaremetalJnlpTask))
+	AddHandler(app, "POST", bmActionPrefix("bios"), bmObjMiddleware(handleBaremetalBios))
+	AddHandler(app, "POST", bmActionPrefix("raid-storages"), bmObjMiddleware(handleBaremetalRaidStorages))
+	AddHandler(app, "POST", bmActionPrefix("firmware-update"), bmObjMiddleware(handleBaremetalFirmwareUpdate))
+	AddHandler(app, "POST", bmActionPrefix("bios-settings"), bmObjMiddleware(handleBaremetalBiosSettings))
+	AddHandler(app, "POST", bmActionPrefix("raid-volume"), bmObjMiddleware(handleBaremetalRaidVolume))
 
 	// server actions handler
 	AddHandler(app, "POST", srvActionPrefix("create"), srvClassMiddleware(handleServerCreate))
@@ -173,6 +178,41 @@ func handleBaremetalJnlpTask(ctx *Context, bm *baremetal.SBaremetalInstance) {
 	ctx.ResponseJson(result)
 }
 
+func handleBaremetalBios(ctx *Context, bm *baremetal.SBaremetalInstance) {
+	bios, err := bm.GetBiosInfo(ctx)
+	if err != nil {
+		ctx.ResponseError(errors.Wrap(err, "GetBiosInfo"))
+		return
+	}
+	ctx.ResponseJson(jsonutils.Marshal(bios))
+}
+
+func handleBaremetalRaidStorages(ctx *Context, bm *baremetal.SBaremetalInstance) {
+	storages, err := bm.GetRaidStorages(ctx)
+	if err != nil {
+		ctx.ResponseError(errors.Wrap(err, "GetRaidStorages"))
+		return
+	}
+	result := jsonutils.NewDict()
+	result.Add(jsonutils.Marshal(storages), "storages")
+	ctx.ResponseJson(result)
+}
+
+func handleBaremetalFirmwareUpdate(ctx *Context, bm *baremetal.SBaremetalInstance) {
+	bm.StartBaremetalFirmwareUpdateTask(ctx.UserCred(), ctx.TaskId(), ctx.Data())
+	ctx.ResponseOk()
+}
+
+func handleBaremetalBiosSettings(ctx *Context, bm *baremetal.SBaremetalInstance) {
+	bm.StartBaremetalBiosSettingsTask(ctx.UserCred(), ctx.TaskId(), ctx.Data())
+	ctx.ResponseOk()
+}
+
+func handleBaremetalRaidVolume(ctx *Context, bm *baremetal.SBaremetalInstance) {
+	bm.StartBaremetalRaidVolumeTask(ctx.UserCred(), ctx.TaskId(), ctx.Data())
+	ctx.ResponseOk()
+}
+
 func handleServerCreate(ctx *Context, bm *baremetal.SBaremetalInstance) {
 	err := bm.StartServerCreateTask(ctx.UserCred(), ctx.TaskId(), ctx.Data())
 	if err != nil {
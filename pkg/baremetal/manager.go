@@ -174,6 +174,9 @@ func (m *SBaremetalManager) InitBaremetal(bmId string, update bool) error {
 func (m *SBaremetalManager) CleanBaremetal(bmId string) {
 	bm := m.baremetals.Pop(bmId)
 	if bm != nil {
+		if err := bm.unsubscribeRedfishEvents(context.Background()); err != nil {
+			log.Warningf("baremetal %s unsubscribe redfish events: %v", bm.GetName(), err)
+		}
 		bm.Stop()
 	}
 	bm.clearBootIsoImage()
@@ -510,6 +513,9 @@ type SBaremetalInstance struct {
 	serverLock *sync.Mutex
 
 	cronJobs []IBaremetalCronJob
+
+	// BMC pushes events through redfish EventService, no need to poll logs
+	eventSubscribed bool
 }
 
 func newBaremetalInstance(man *SBaremetalManager, desc jsonutils.JSONObject) (*SBaremetalInstance, error) {
@@ -524,6 +530,7 @@ func newBaremetalInstance(man *SBaremetalManager, desc jsonutils.JSONObject) (*S
 		NewStatusProbeJob(bm, time.Duration(o.Options.StatusProbeIntervalSeconds)*time.Second),
 		NewLogFetchJob(bm, time.Duration(o.Options.LogFetchIntervalSeconds)*time.Second),
 		NewSendMetricsJob(bm, time.Duration(o.Options.SendMetricsIntervalSeconds)*time.Second),
+		NewEventSubscribeJob(bm, time.Duration(o.Options.RedfishEventSubscribeIntervalSeconds)*time.Second),
 	}
 	err := os.MkdirAll(bm.GetDir(), 0755)
 	if err != nil {
@@ -973,8 +980,22 @@ func (b *SBaremetalInstance) GetDHCPConfig(cliMac net.HardwareAddr) (*dhcp.Respo
 	return b.getDHCPConfig(nic, hostname, false, 0)
 }
 
-func (b *SBaremetalInstance) GetPXEDHCPConfig(arch uint16) (*dhcp.ResponseConfig, error) {
-	return b.getDHCPConfig(b.GetAdminNic(), "", true, arch)
+func (b *SBaremetalInstance) GetPXEDHCPConfig(arch uint16, client pxe.BootClient) (*dhcp.ResponseConfig, error) {
+	conf, err := b.getDHCPConfig(b.GetAdminNic(), "", true, arch)
+	if err != nil {
+		return nil, err
+	}
+	switch client {
+	case pxe.BootClientIPXE:
+		// iPXE loads the per-machine script instead of chainloading itself again
+		conf.BootFile = b.getIPXEScriptUrl()
+		conf.BootBlock = 0
+	case pxe.BootClientHTTP:
+		conf.BootFile = b.getTftpFileUrl(conf.BootFile)
+		conf.BootBlock = 0
+		conf.VendorClassId = dhcp.HTTPCLIENT
+	}
+	return conf, nil
 }
 
 func (b *SBaremetalInstance) getDHCPConfig(
@@ -1006,6 +1027,16 @@ func (b *SBaremetalInstance) getTftpFileUrl(filename string) string {
 	return fmt.Sprintf("http://%s:%d/tftp/%s", serverIP, o.Options.Port+1000, filename)
 }
 
+func (b *SBaremetalInstance) getIPXEScriptUrl() string {
+	serverIP, err := b.manager.Agent.GetDHCPServerIP()
+	if err != nil {
+		log.Errorf("Get http file server: %v", err)
+		return ""
+	}
+	mac := b.GetAdminNic().GetMac()
+	return fmt.Sprintf("http://%s:%d%s", serverIP, o.Options.Port+1000, pxe.GetIPXEScriptPath(mac))
+}
+
 func (b *SBaremetalInstance) GetImageCacheUrl() string {
 	serverIP, err := b.manager.Agent.GetDHCPServerIP()
 	if err != nil {
@@ -1030,6 +1061,28 @@ func (b *SBaremetalInstance) GetTFTPResponse() string {
 	return b.getSyslinuxConf(true)
 }
 
+func (b *SBaremetalInstance) GetIPXEScript() string {
+	resp := "#!ipxe\n"
+	if b.NeedPXEBoot() {
+		args := []string{
+			"initrd=initramfs",
+			fmt.Sprintf("token=%s", auth.GetTokenString()),
+			fmt.Sprintf("url=%s", b.GetNotifyUrl()),
+			fmt.Sprintf("bootmod=%s", api.BOOT_MODE_PXE),
+		}
+		resp += fmt.Sprintf("kernel %s %s\n", b.getTftpFileUrl("kernel"), strings.Join(args, " "))
+		resp += fmt.Sprintf("initrd --name initramfs %s\n", b.getTftpFileUrl("initramfs"))
+		resp += "boot\n"
+	} else {
+		// chainload the first local disk like chain.c32 in syslinux conf,
+		// uefi firmware continues with local disk when ipxe exits
+		resp += "iseq ${platform} efi && exit ||\n"
+		resp += "sanboot --no-describe --drive 0x80\n"
+		b.ClearSSHConfig()
+	}
+	return resp
+}
+
 func (b *SBaremetalInstance) getIsolinuxConf() string {
 	return b.getSyslinuxConf(false)
 }
@@ -1559,6 +1612,21 @@ func (b *SBaremetalInstance) StartBaremetalCdromTask(userCred mcclient.TokenCred
 	return nil
 }
 
+func (b *SBaremetalInstance) StartBaremetalFirmwareUpdateTask(userCred mcclient.TokenCredential, taskId string, data jsonutils.JSONObject) error {
+	b.StartNewTask(tasks.NewBaremetalFirmwareUpdateTask, userCred, taskId, data)
+	return nil
+}
+
+func (b *SBaremetalInstance) StartBaremetalBiosSettingsTask(userCred mcclient.TokenCredential, taskId string, data jsonutils.JSONObject) error {
+	b.StartNewTask(tasks.NewBaremetalBiosSettingsTask, userCred, taskId, data)
+	return nil
+}
+
+func (b *SBaremetalInstance) StartBaremetalRaidVolumeTask(userCred mcclient.TokenCredential, taskId string, data jsonutils.JSONObject) error {
+	b.StartNewTask(tasks.NewBaremetalRaidVolumeTask, userCred, taskId, data)
+	return nil
+}
+
 func (b *SBaremetalInstance) DelayedServerReset(_ jsonutils.JSONObject) (jsonutils.JSONObject, error) {
 	err := b.DoPXEBoot()
 	return nil, err
@@ -1913,6 +1981,10 @@ func (b *SBaremetalInstance) clearLogs(ctx context.Context, logType string) erro
 	return errors.Wrap(httperrors.ErrNotSupported, logType)
 }
 
+func (b *SBaremetalInstance) isEventSubscribed() bool {
+	return b.eventSubscribed
+}
+
 func (b *SBaremetalInstance) doCronJobs(ctx context.Context) {
 	for _, job := range b.cronJobs {
 		now := time.Now().UTC()
@@ -1951,6 +2023,22 @@ func (b *SBaremetalInstance) fetchPowerThermalMetrics(ctx context.Context) ([]in
 	return powerMetrics, thermalMetrics, nil
 }
 
+func (b *SBaremetalInstance) GetBiosInfo(ctx context.Context) (redfish.SBiosInfo, error) {
+	cli := b.GetRedfishCli(ctx)
+	if cli == nil {
+		return redfish.SBiosInfo{}, httperrors.NewNotSupportedError("redfish api not supported")
+	}
+	return cli.GetBiosInfo(ctx)
+}
+
+func (b *SBaremetalInstance) GetRaidStorages(ctx context.Context) ([]redfish.SStorageInfo, error) {
+	cli := b.GetRedfishCli(ctx)
+	if cli == nil {
+		return nil, httperrors.NewNotSupportedError("redfish api not supported")
+	}
+	return cli.GetStorages(ctx)
+}
+
 func (b *SBaremetalInstance) GetConsoleJNLP(ctx context.Context) (string, error) {
 	cli := b.GetRedfishCli(ctx)
 	if cli != nil {
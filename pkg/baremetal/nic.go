@@ -70,19 +70,7 @@ func GetNicDHCPConfig(
 
 	if isPxe {
 		conf.BootServer = serverIP
-		switch arch {
-		case 7, 9:
-			conf.BootFile = "bootx64.efi"
-		case 6:
-			conf.BootFile = "bootia32.efi"
-		default:
-			//if o.Options.EnableTftpHttpDownload {
-			// bootFile = "lpxelinux.0"
-			//}else {
-			// bootFile := "pxelinux.0"
-			//}
-			conf.BootFile = "lpxelinux.0"
-		}
+		conf.BootFile = getPXEBootFile(arch)
 		pxePath := filepath.Join(o.Options.TftpRoot, conf.BootFile)
 		if f, err := os.Open(pxePath); err != nil {
 			return nil, err
@@ -101,3 +89,30 @@ func GetNicDHCPConfig(
 	}
 	return conf, nil
 }
+
+// getPXEBootFile returns the bootloader loaded by client firmware, iPXE is
+// chainloaded when its binary is provided in TftpRoot
+func getPXEBootFile(arch uint16) string {
+	var bootFile, ipxeFile string
+	switch arch {
+	case 7, 9, 16:
+		bootFile = "bootx64.efi"
+		ipxeFile = "ipxe.efi"
+	case 6, 15:
+		bootFile = "bootia32.efi"
+	default:
+		//if o.Options.EnableTftpHttpDownload {
+		// bootFile = "lpxelinux.0"
+		//}else {
+		// bootFile := "pxelinux.0"
+		//}
+		bootFile = "lpxelinux.0"
+		ipxeFile = "undionly.kpxe"
+	}
+	if o.Options.EnableIpxeBoot && len(ipxeFile) > 0 {
+		if _, err := os.Stat(filepath.Join(o.Options.TftpRoot, ipxeFile)); err == nil {
+			return ipxeFile
+		}
+	}
+	return bootFile
+}
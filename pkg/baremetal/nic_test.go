@@ -0,0 +1,70 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baremetal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	o "yunion.io/x/onecloud/pkg/baremetal/options"
+)
+
+func TestGetPXEBootFile(t *testing.T) {
+	tftpRoot, err := ioutil.TempDir("", "tftpboot")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(tftpRoot)
+	for _, fn := range []string{"undionly.kpxe", "ipxe.efi"} {
+		if err := ioutil.WriteFile(filepath.Join(tftpRoot, fn), []byte{}, 0644); err != nil {
+			t.Fatalf("write %s: %v", fn, err)
+		}
+	}
+	oldRoot, oldIpxe := o.Options.TftpRoot, o.Options.EnableIpxeBoot
+	defer func() {
+		o.Options.TftpRoot, o.Options.EnableIpxeBoot = oldRoot, oldIpxe
+	}()
+	o.Options.TftpRoot = tftpRoot
+
+	cases := []struct {
+		name       string
+		arch       uint16
+		enableIpxe bool
+		want       string
+	}{
+		{name: "legacy PXE", arch: 0, want: "lpxelinux.0"},
+		{name: "legacy PXE chainloads iPXE", arch: 0, enableIpxe: true, want: "undionly.kpxe"},
+		{name: "UEFI PXE", arch: 7, want: "bootx64.efi"},
+		{name: "UEFI PXE chainloads iPXE", arch: 9, enableIpxe: true, want: "ipxe.efi"},
+		{name: "UEFI HTTP Boot", arch: 16, want: "bootx64.efi"},
+		{name: "UEFI HTTP Boot chainloads iPXE", arch: 16, enableIpxe: true, want: "ipxe.efi"},
+		{name: "UEFI IA32 has no iPXE", arch: 15, enableIpxe: true, want: "bootia32.efi"},
+	}
+	for _, c := range cases {
+		o.Options.EnableIpxeBoot = c.enableIpxe
+		if got := getPXEBootFile(c.arch); got != c.want {
+			t.Errorf("%s: want %s, got %s", c.name, c.want, got)
+		}
+	}
+
+	// fallback to the firmware bootloader without iPXE binaries
+	os.Remove(filepath.Join(tftpRoot, "undionly.kpxe"))
+	o.Options.EnableIpxeBoot = true
+	if got := getPXEBootFile(0); got != "lpxelinux.0" {
+		t.Errorf("missing undionly.kpxe: want lpxelinux.0, got %s", got)
+	}
+}
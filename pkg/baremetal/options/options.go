@@ -43,13 +43,20 @@ type BaremetalOptions struct {
 	WindowsDefaultAdminUser bool `default:"true" help:"Default account for Windows system is Administrator"`
 	// EnableTftpHttpDownload  bool `default:"true" help:"Pxelinux download file through http"`
 
-	CachePath     string `help:"local image cache directory"`
-	EnablePxeBoot bool   `help:"Enable DHCP PXE boot" default:"true"`
-	BootIsoPath   string `help:"iso boot image path"`
+	CachePath      string `help:"local image cache directory"`
+	EnablePxeBoot  bool   `help:"Enable DHCP PXE boot" default:"true"`
+	EnableIpxeBoot bool   `help:"Chainload iPXE (undionly.kpxe or ipxe.efi in tftp root) to load kernel and initramfs over http" default:"true"`
+	BootIsoPath    string `help:"iso boot image path"`
 
 	StatusProbeIntervalSeconds int `help:"interval to probe baremetal status, default is 60 seconds" default:"60"`
 	LogFetchIntervalSeconds    int `help:"interval to fetch baremetal log, default is 900 seconds" default:"900"`
 	SendMetricsIntervalSeconds int `help:"interval to send baremetal metrics, default is 300 seconds" default:"300"`
+
+	EnableRedfishEvents                  bool   `help:"Subscribe redfish EventService of BMC to receive pushed hardware alerts" default:"true"`
+	RedfishEventListenPort               int    `help:"https port to receive redfish events, default is 8879" default:"8879"`
+	RedfishEventCertfile                 string `help:"certificate file of redfish events receiver, use ssl certfile or self-signed cert if empty"`
+	RedfishEventKeyfile                  string `help:"private key file of redfish events receiver"`
+	RedfishEventSubscribeIntervalSeconds int    `help:"interval to check redfish event subscription, default is 3600 seconds" default:"3600"`
 }
 
 var (
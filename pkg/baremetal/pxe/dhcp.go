@@ -56,6 +56,7 @@ type dhcpRequest struct {
 	RelayAddr             net.IP           // IP address of DHCP relay agent
 	Options               dhcp.Options     // dhcp packet options
 	VendorClassId         string
+	UserClass             string
 	ClientArch            uint16
 	NetworkInterfaceIdent NetworkInterfaceIdent
 	ClientGuid            string
@@ -105,6 +106,7 @@ func (h *DHCPHandler) newRequest(pkt dhcp.Packet, man IBaremetalManager) (*dhcpR
 
 	var (
 		vendorClsId string
+		userCls     string
 		cliArch     uint16
 		err         error
 		netIfIdent  NetworkInterfaceIdent
@@ -115,6 +117,8 @@ func (h *DHCPHandler) newRequest(pkt dhcp.Packet, man IBaremetalManager) (*dhcpR
 		switch optCode {
 		case dhcp.OptionVendorClassIdentifier:
 			vendorClsId, err = req.Options.String(optCode)
+		case dhcp.OptionUserClass:
+			userCls, err = req.Options.String(optCode)
 		case dhcp.OptionClientArchitecture:
 			cliArch, err = req.Options.Uint16(optCode)
 		case dhcp.OptionClientNetworkInterfaceIdentifier:
@@ -152,6 +156,7 @@ func (h *DHCPHandler) newRequest(pkt dhcp.Packet, man IBaremetalManager) (*dhcpR
 		cliUUIDStr = formatUuidString([]byte(cliGuid)[1:])
 	}
 	req.VendorClassId = vendorClsId
+	req.UserClass = userCls
 	req.ClientArch = cliArch
 	req.NetworkInterfaceIdent = netIfIdent
 	req.ClientGuid = cliUUIDStr
@@ -208,7 +213,7 @@ func (req *dhcpRequest) fetchConfig(session *mcclient.ClientSession) (*dhcp.Resp
 		// always response PXE request
 		// let bootloader decide boot local or remote
 		// if req.baremetalInstance.NeedPXEBoot() {
-		conf, err := req.baremetalInstance.GetPXEDHCPConfig(req.ClientArch)
+		conf, err := req.baremetalInstance.GetPXEDHCPConfig(req.ClientArch, req.getBootClient())
 		if err != nil {
 			return nil, nil, errors.Wrap(err, "req.baremetalInstance.GetPXEDHCPConfig")
 		}
@@ -405,6 +410,17 @@ func (req *dhcpRequest) doInitBaremetalAdminNetif(desc jsonutils.JSONObject) err
 	return err
 }
 
+func (req *dhcpRequest) getBootClient() BootClient {
+	// iPXE identifies itself by user class, no matter how it was loaded
+	if strings.Contains(req.UserClass, "iPXE") {
+		return BootClientIPXE
+	}
+	if strings.HasPrefix(req.VendorClassId, dhcp.HTTPCLIENT) {
+		return BootClientHTTP
+	}
+	return BootClientPXE
+}
+
 func (req *dhcpRequest) isPXERequest() bool {
 	pkt := req.packet
 	return dhcp.IsPXERequest(pkt)
@@ -462,6 +478,14 @@ func (s *Server) validateDHCP(pkt dhcp.Packet) (Machine, Firmware, error) {
 		// EFI x86-64
 		mach.Arch = ArchX64
 		fwtype = FirmwareEFIBC
+	case 15:
+		// EFI IA32 HTTP boot
+		mach.Arch = ArchIA32
+		fwtype = FirmwareEFI32
+	case 16:
+		// EFI x86-64 HTTP boot
+		mach.Arch = ArchX64
+		fwtype = FirmwareEFI64
 	default:
 		return mach, 0, fmt.Errorf("unsupported client firmware type '%d'", fwtype)
 	}
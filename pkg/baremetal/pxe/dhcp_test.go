@@ -0,0 +1,129 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pxe
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"yunion.io/x/onecloud/pkg/util/dhcp"
+)
+
+func newPXERequestPacket(arch uint16, vendorClsId string, userCls string) dhcp.Packet {
+	mac, _ := net.ParseMAC("00:22:46:9f:1b:5e")
+	archBytes := make([]byte, 2)
+	binary.BigEndian.PutUint16(archBytes, arch)
+	opts := []dhcp.Option{
+		{Code: dhcp.OptionClientArchitecture, Value: archBytes},
+		{Code: dhcp.OptionClientNetworkInterfaceIdentifier, Value: []byte{1, 3, 16}},
+		{Code: dhcp.OptionVendorClassIdentifier, Value: []byte(vendorClsId)},
+	}
+	if len(userCls) > 0 {
+		opts = append(opts, dhcp.Option{Code: dhcp.OptionUserClass, Value: []byte(userCls)})
+	}
+	return dhcp.RequestPacket(dhcp.Discover, mac, nil, []byte{1, 2, 3, 4}, true, opts)
+}
+
+func TestDHCPRequestBootClient(t *testing.T) {
+	cases := []struct {
+		name        string
+		arch        uint16
+		vendorClsId string
+		userCls     string
+		wantClient  BootClient
+		wantArch    Architecture
+		wantFw      Firmware
+	}{
+		{
+			name:        "legacy PXE",
+			arch:        0,
+			vendorClsId: "PXEClient:Arch:00000:UNDI:002001",
+			wantClient:  BootClientPXE,
+			wantArch:    ArchIA32,
+			wantFw:      FirmwareX86PC,
+		},
+		{
+			name:        "UEFI PXE",
+			arch:        7,
+			vendorClsId: "PXEClient:Arch:00007:UNDI:003016",
+			wantClient:  BootClientPXE,
+			wantArch:    ArchX64,
+			wantFw:      FirmwareEFI64,
+		},
+		{
+			name:        "UEFI HTTP Boot",
+			arch:        16,
+			vendorClsId: "HTTPClient:Arch:00016:UNDI:003001",
+			wantClient:  BootClientHTTP,
+			wantArch:    ArchX64,
+			wantFw:      FirmwareEFI64,
+		},
+		{
+			name:        "UEFI IA32 HTTP Boot",
+			arch:        15,
+			vendorClsId: "HTTPClient:Arch:00015:UNDI:003001",
+			wantClient:  BootClientHTTP,
+			wantArch:    ArchIA32,
+			wantFw:      FirmwareEFI32,
+		},
+		{
+			name:        "iPXE chainloaded by BIOS",
+			arch:        0,
+			vendorClsId: "PXEClient:Arch:00000:UNDI:002001",
+			userCls:     "iPXE",
+			wantClient:  BootClientIPXE,
+			wantArch:    ArchIA32,
+			wantFw:      FirmwareX86PC,
+		},
+		{
+			name:        "iPXE chainloaded by UEFI HTTP Boot",
+			arch:        16,
+			vendorClsId: "HTTPClient:Arch:00016:UNDI:003001",
+			userCls:     "iPXE",
+			wantClient:  BootClientIPXE,
+			wantArch:    ArchX64,
+			wantFw:      FirmwareEFI64,
+		},
+	}
+	h := &DHCPHandler{}
+	s := &Server{}
+	for _, c := range cases {
+		pkt := newPXERequestPacket(c.arch, c.vendorClsId, c.userCls)
+		if !dhcp.IsPXERequest(pkt) {
+			t.Errorf("%s: not a PXE request", c.name)
+			continue
+		}
+		req, err := h.newRequest(pkt, nil)
+		if err != nil {
+			t.Errorf("%s: newRequest: %v", c.name, err)
+			continue
+		}
+		if req.ClientArch != c.arch {
+			t.Errorf("%s: want arch %d, got %d", c.name, c.arch, req.ClientArch)
+		}
+		if client := req.getBootClient(); client != c.wantClient {
+			t.Errorf("%s: want boot client %s, got %s", c.name, c.wantClient, client)
+		}
+		mach, fw, err := s.validateDHCP(pkt)
+		if err != nil {
+			t.Errorf("%s: validateDHCP: %v", c.name, err)
+			continue
+		}
+		if mach.Arch != c.wantArch || fw != c.wantFw {
+			t.Errorf("%s: want arch %d firmware %d, got %d %d", c.name, c.wantArch, c.wantFw, mach.Arch, fw)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pxe
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"yunion.io/x/log"
+)
+
+const (
+	IPXEScriptPrefix = "/ipxe/"
+)
+
+// IPXEHandler serves per-machine iPXE scripts at /ipxe/<mac>, the HTTP
+// counterpart of pxelinux.cfg/01-<mac> served by TFTPHandler
+type IPXEHandler struct {
+	BaremetalManager IBaremetalManager
+}
+
+func NewIPXEHandler(baremetalManager IBaremetalManager) *IPXEHandler {
+	return &IPXEHandler{
+		BaremetalManager: baremetalManager,
+	}
+}
+
+func GetIPXEScriptPath(mac net.HardwareAddr) string {
+	return fmt.Sprintf("%s%s", IPXEScriptPrefix, strings.Replace(mac.String(), ":", "-", -1))
+}
+
+func (h *IPXEHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	macStr := strings.TrimPrefix(r.URL.Path, IPXEScriptPrefix)
+	mac, err := net.ParseMAC(macStr)
+	if err != nil {
+		log.Errorf("[iPXE] parse mac string %q error: %v", macStr, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Debugf("[iPXE] client mac: %s", mac)
+	bmInstance := h.BaremetalManager.GetBaremetalByMac(mac)
+	if bmInstance == nil {
+		log.Errorf("[iPXE] not found baremetal instance by mac: %s", mac)
+		http.NotFound(w, r)
+		return
+	}
+	respStr := bmInstance.GetIPXEScript()
+	log.Debugf("[iPXE] get ipxe script: %s", respStr)
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(respStr))
+}
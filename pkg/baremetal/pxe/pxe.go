@@ -80,6 +80,28 @@ const (
 	FirmwareUnknown
 )
 
+// BootClient describes the network boot program sending the DHCP request
+type BootClient int
+
+const (
+	BootClientPXE  BootClient = iota // firmware PXE ROM, loads bootloader over TFTP
+	BootClientHTTP                   // UEFI HTTP Boot, loads bootloader from an HTTP URL
+	BootClientIPXE                   // chainloaded iPXE, loads per-machine script from an HTTP URL
+)
+
+func (c BootClient) String() string {
+	switch c {
+	case BootClientPXE:
+		return "PXE"
+	case BootClientHTTP:
+		return "HTTP"
+	case BootClientIPXE:
+		return "iPXE"
+	default:
+		return "Unknown boot client"
+	}
+}
+
 type IBaremetalManager interface {
 	GetZoneId() string
 	GetBaremetalByMac(mac net.HardwareAddr) IBaremetalInstance
@@ -90,11 +112,12 @@ type IBaremetalManager interface {
 type IBaremetalInstance interface {
 	NeedPXEBoot() bool
 	GetIPMINic(cliMac net.HardwareAddr) *types.SNic
-	GetPXEDHCPConfig(arch uint16) (*dhcp.ResponseConfig, error)
+	GetPXEDHCPConfig(arch uint16, client BootClient) (*dhcp.ResponseConfig, error)
 	GetDHCPConfig(cliMac net.HardwareAddr) (*dhcp.ResponseConfig, error)
 	InitAdminNetif(cliMac net.HardwareAddr, wireId, nicType, netType string, isDoImport bool, ipAddr string) error
 	RegisterNetif(cliMac net.HardwareAddr, wireId string) error
 	GetTFTPResponse() string
+	GetIPXEScript() string
 }
 
 type Server struct {
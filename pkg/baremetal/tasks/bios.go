@@ -0,0 +1,70 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/redfish"
+)
+
+type SBaremetalBiosSettingsTask struct {
+	SBaremetalTaskBase
+}
+
+func NewBaremetalBiosSettingsTask(
+	userCred mcclient.TokenCredential,
+	baremetal IBaremetal,
+	taskId string,
+	data jsonutils.JSONObject,
+) ITask {
+	task := &SBaremetalBiosSettingsTask{
+		SBaremetalTaskBase: newBaremetalTaskBase(userCred, baremetal, taskId, data),
+	}
+	task.SetVirtualObject(task)
+	task.SetStage(task.DoBiosSettings)
+	return task
+}
+
+func (self *SBaremetalBiosSettingsTask) GetName() string {
+	return "BaremetalBiosSettingsTask"
+}
+
+func (self *SBaremetalBiosSettingsTask) DoBiosSettings(ctx context.Context, args interface{}) error {
+	redfishCli, err := getRedfishCli(ctx, self.Baremetal)
+	if err != nil {
+		return errors.Wrap(err, "getRedfishCli")
+	}
+	input := api.HostBiosSettingsInput{}
+	err = self.GetData().Unmarshal(&input)
+	if err != nil {
+		return errors.Wrap(err, "Unmarshal HostBiosSettingsInput")
+	}
+	if input.Attributes == nil {
+		return errors.Error("empty bios attributes")
+	}
+	err = redfish.SetBiosAttributes(ctx, redfishCli, input.Attributes, input.Reboot)
+	if err != nil {
+		return errors.Wrap(err, "SetBiosAttributes")
+	}
+	self.Baremetal.AutoSyncStatus()
+	SetTaskComplete(self, nil)
+	return nil
+}
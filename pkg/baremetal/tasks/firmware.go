@@ -0,0 +1,87 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/redfish"
+)
+
+type SBaremetalFirmwareUpdateTask struct {
+	SBaremetalTaskBase
+}
+
+func NewBaremetalFirmwareUpdateTask(
+	userCred mcclient.TokenCredential,
+	baremetal IBaremetal,
+	taskId string,
+	data jsonutils.JSONObject,
+) ITask {
+	task := &SBaremetalFirmwareUpdateTask{
+		SBaremetalTaskBase: newBaremetalTaskBase(userCred, baremetal, taskId, data),
+	}
+	task.SetVirtualObject(task)
+	task.SetStage(task.DoFirmwareUpdate)
+	return task
+}
+
+func (self *SBaremetalFirmwareUpdateTask) GetName() string {
+	return "BaremetalFirmwareUpdateTask"
+}
+
+func getRedfishCli(ctx context.Context, bm IBaremetal) (redfish.IRedfishDriver, error) {
+	redfishCli := bm.GetRedfishCli(ctx)
+	if redfishCli == nil {
+		return nil, errors.Error("redfish api not supported")
+	}
+	return redfishCli, nil
+}
+
+func (self *SBaremetalFirmwareUpdateTask) DoFirmwareUpdate(ctx context.Context, args interface{}) error {
+	redfishCli, err := getRedfishCli(ctx, self.Baremetal)
+	if err != nil {
+		return errors.Wrap(err, "getRedfishCli")
+	}
+	input := api.HostFirmwareUpdateInput{}
+	err = self.GetData().Unmarshal(&input)
+	if err != nil {
+		return errors.Wrap(err, "Unmarshal HostFirmwareUpdateInput")
+	}
+	params := redfish.SFirmwareUpdateParams{
+		ImageURI:         input.ImageUri,
+		TransferProtocol: input.TransferProtocol,
+		Targets:          input.Targets,
+	}
+	taskPath, err := redfishCli.SimpleUpdate(ctx, params)
+	if err != nil {
+		return errors.Wrap(err, "SimpleUpdate")
+	}
+	log.Infof("baremetal %s firmware update task %s", self.Baremetal.GetName(), taskPath)
+	info, err := redfish.WaitTask(ctx, redfishCli, taskPath, 10*time.Second, 2*time.Hour)
+	if err != nil {
+		return errors.Wrap(err, "WaitTask")
+	}
+	self.Baremetal.AutoSyncStatus()
+	SetTaskComplete(self, jsonutils.Marshal(info))
+	return nil
+}
@@ -15,6 +15,7 @@
 package tasks
 
 import (
+	"context"
 	"net"
 
 	"yunion.io/x/jsonutils"
@@ -23,6 +24,7 @@ import (
 	baremetaltypes "yunion.io/x/onecloud/pkg/baremetal/types"
 	"yunion.io/x/onecloud/pkg/cloudcommon/types"
 	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/redfish"
 )
 
 type IBaremetal interface {
@@ -56,6 +58,7 @@ type IBaremetal interface {
 	// DoDiskBoot() error
 
 	DoRedfishPowerOn() error
+	GetRedfishCli(ctx context.Context) redfish.IRedfishDriver
 	GetAccessIp() string
 	EnablePxeBoot() bool
 	GenerateBootISO() error
@@ -0,0 +1,107 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/redfish"
+)
+
+type SBaremetalRaidVolumeTask struct {
+	SBaremetalTaskBase
+}
+
+func NewBaremetalRaidVolumeTask(
+	userCred mcclient.TokenCredential,
+	baremetal IBaremetal,
+	taskId string,
+	data jsonutils.JSONObject,
+) ITask {
+	task := &SBaremetalRaidVolumeTask{
+		SBaremetalTaskBase: newBaremetalTaskBase(userCred, baremetal, taskId, data),
+	}
+	task.SetVirtualObject(task)
+	action, _ := data.GetString("action")
+	if action == api.BAREMETAL_RAID_VOLUME_ACTION_DELETE {
+		task.SetStage(task.DoDeleteVolume)
+	} else {
+		task.SetStage(task.DoCreateVolume)
+	}
+	return task
+}
+
+func (self *SBaremetalRaidVolumeTask) GetName() string {
+	return "BaremetalRaidVolumeTask"
+}
+
+func (self *SBaremetalRaidVolumeTask) DoCreateVolume(ctx context.Context, args interface{}) error {
+	redfishCli, err := getRedfishCli(ctx, self.Baremetal)
+	if err != nil {
+		return errors.Wrap(err, "getRedfishCli")
+	}
+	input := api.HostRaidVolumeCreateInput{}
+	err = self.GetData().Unmarshal(&input)
+	if err != nil {
+		return errors.Wrap(err, "Unmarshal HostRaidVolumeCreateInput")
+	}
+	params := redfish.SVolumeCreateParams{
+		Storage:       input.Storage,
+		Name:          input.Name,
+		RAIDType:      input.RaidType,
+		Drives:        input.Drives,
+		CapacityBytes: input.CapacityGb * 1000 * 1000 * 1000,
+	}
+	taskPath, err := redfishCli.CreateVolume(ctx, params)
+	if err != nil {
+		return errors.Wrap(err, "CreateVolume")
+	}
+	return self.waitVolumeTask(ctx, redfishCli, taskPath)
+}
+
+func (self *SBaremetalRaidVolumeTask) DoDeleteVolume(ctx context.Context, args interface{}) error {
+	redfishCli, err := getRedfishCli(ctx, self.Baremetal)
+	if err != nil {
+		return errors.Wrap(err, "getRedfishCli")
+	}
+	input := api.HostRaidVolumeDeleteInput{}
+	err = self.GetData().Unmarshal(&input)
+	if err != nil {
+		return errors.Wrap(err, "Unmarshal HostRaidVolumeDeleteInput")
+	}
+	taskPath, err := redfishCli.DeleteVolume(ctx, input.Storage, input.Volume)
+	if err != nil {
+		return errors.Wrap(err, "DeleteVolume")
+	}
+	return self.waitVolumeTask(ctx, redfishCli, taskPath)
+}
+
+func (self *SBaremetalRaidVolumeTask) waitVolumeTask(ctx context.Context, redfishCli redfish.IRedfishDriver, taskPath string) error {
+	log.Infof("baremetal %s raid volume task %s", self.Baremetal.GetName(), taskPath)
+	info, err := redfish.WaitTask(ctx, redfishCli, taskPath, 5*time.Second, time.Hour)
+	if err != nil {
+		return errors.Wrap(err, "WaitTask")
+	}
+	self.Baremetal.AutoSyncStatus()
+	SetTaskComplete(self, jsonutils.Marshal(info))
+	return nil
+}
@@ -113,6 +113,8 @@ func ParseDiskConfig(diskStr string, idx int) (*compute.DiskConfig, error) {
 			diskConfig.Mountpoint = p
 		} else if p == "autoextend" {
 			diskConfig.SizeMb = -1
+		} else if p == "encrypt" {
+			diskConfig.Encrypt = true
 		} else if utils.IsInStringArray(p, compute.STORAGE_ALL_TYPES) {
 			diskConfig.Backend = p
 		} else if strings.HasPrefix(p, "snapshot-") {
@@ -254,7 +254,8 @@ func listItemQueryFiltersRaw(manager IModelManager,
 	doCheckRbac bool,
 	useRawQuery bool,
 ) (*sqlchemy.SQuery, error) {
-	ownerId, queryScope, err := FetchCheckQueryOwnerScope(ctx, userCred, query, manager, action, doCheckRbac)
+	// the query is filtered by the conditions of the allow rules below
+	ownerId, queryScope, err := fetchCheckQueryOwnerScope(ctx, userCred, query, manager, action, doCheckRbac, true)
 	if err != nil {
 		return nil, httperrors.NewGeneralError(err)
 	}
@@ -266,6 +267,9 @@ func listItemQueryFiltersRaw(manager IModelManager,
 		q = manager.FilterBySystemAttributes(q, userCred, query, queryScope)
 		q = manager.FilterByHiddenSystemAttributes(q, userCred, query, queryScope)
 	}
+	if doCheckRbac && consts.IsRbacEnabled() {
+		q = filterByRbacConditions(manager, q, userCred, queryScope, action)
+	}
 	q, err = ListItemFilter(manager, ctx, q, userCred, query)
 	if err != nil {
 		return nil, err
@@ -310,6 +310,12 @@ type IScopedResourceManager interface {
 }
 
 func FetchCheckQueryOwnerScope(ctx context.Context, userCred mcclient.TokenCredential, data jsonutils.JSONObject, manager IScopedResourceManager, action string, doCheckRbac bool) (mcclient.IIdentityProvider, rbacutils.TRbacScope, error) {
+	return fetchCheckQueryOwnerScope(ctx, userCred, data, manager, action, doCheckRbac, false)
+}
+
+// fetchCheckQueryOwnerScope allows the conditional rules if withConditions,
+// the caller must filter the query by their conditions then
+func fetchCheckQueryOwnerScope(ctx context.Context, userCred mcclient.TokenCredential, data jsonutils.JSONObject, manager IScopedResourceManager, action string, doCheckRbac bool, withConditions bool) (mcclient.IIdentityProvider, rbacutils.TRbacScope, error) {
 	var scope rbacutils.TRbacScope
 
 	var allowScope rbacutils.TRbacScope
@@ -319,7 +325,11 @@ func FetchCheckQueryOwnerScope(ctx context.Context, userCred mcclient.TokenCrede
 	resScope := manager.ResourceScope()
 
 	if consts.IsRbacEnabled() {
-		allowScope = policy.PolicyManager.AllowScope(userCred, consts.GetServiceType(), manager.KeywordPlural(), action)
+		if withConditions {
+			allowScope = policy.PolicyManager.AllowScopeWithConditions(userCred, consts.GetServiceType(), manager.KeywordPlural(), action)
+		} else {
+			allowScope = policy.PolicyManager.AllowScope(userCred, consts.GetServiceType(), manager.KeywordPlural(), action)
+		}
 	} else {
 		if userCred.HasSystemAdminPrivilege() {
 			allowScope = rbacutils.ScopeSystem
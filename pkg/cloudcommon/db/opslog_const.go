@@ -70,6 +70,14 @@ const (
 	ACT_MIGRATE      = "migrate"
 	ACT_MIGRATE_FAIL = "migrate_fail"
 
+	ACT_START_RESCUE      = "start_rescue"
+	ACT_START_RESCUE_FAIL = "start_rescue_fail"
+	ACT_STOP_RESCUE       = "stop_rescue"
+	ACT_STOP_RESCUE_FAIL  = "stop_rescue_fail"
+
+	ACT_DRS_PLAN    = "drs_plan"
+	ACT_DRS_MIGRATE = "drs_migrate"
+
 	ACT_VM_CONVERT      = "vm_convert"
 	ACT_VM_CONVERTING   = "vm_converting"
 	ACT_VM_CONVERT_FAIL = "vm_convert_fail"
@@ -105,6 +113,11 @@ const (
 	ACT_VM_SNAPSHOT_AND_CLONE         = "vm_snapshot_and_clone"
 	ACT_VM_SNAPSHOT_AND_CLONE_FAILED  = "vm_snapshot_and_clone_failed"
 
+	ACT_GUEST_FSFREEZE      = "guest_fsfreeze"
+	ACT_GUEST_FSFREEZE_FAIL = "guest_fsfreeze_fail"
+	ACT_GUEST_FSTHAW        = "guest_fsthaw"
+	ACT_GUEST_FSTHAW_FAIL   = "guest_fsthaw_fail"
+
 	ACT_VM_RESET_SNAPSHOT        = "instance_reset_snapshot"
 	ACT_VM_RESET_SNAPSHOT_FAILED = "instance_reset_snapshot_failed"
 
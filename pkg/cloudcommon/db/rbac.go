@@ -67,21 +67,23 @@ func isObjectRbacAllowed(model IModel, userCred mcclient.TokenCredential, action
 		}
 	}
 
-	scope := policy.PolicyManager.AllowScope(userCred, consts.GetServiceType(), manager.KeywordPlural(), action, extra...)
+	scope := policy.PolicyManager.AllowScopeWithConditions(userCred, consts.GetServiceType(), manager.KeywordPlural(), action, extra...)
 
-	if !requireScope.HigherThan(scope) {
-		return nil
+	if requireScope.HigherThan(scope) {
+		return httperrors.NewForbiddenError("not enough privilege (require:%s,allow:%s:resource:%s)", requireScope, scope, resScope)
 	}
-	return httperrors.NewForbiddenError("not enough privilege (require:%s,allow:%s:resource:%s)", requireScope, scope, resScope)
+	return checkRbacConditions(manager, model, userCred, requireScope, action, extra...)
 }
 
 func isJointObjectRbacAllowed(item IJointModel, userCred mcclient.TokenCredential, action string, extra ...string) error {
 	err1 := isObjectRbacAllowed(JointMaster(item), userCred, action, extra...)
 	err2 := isObjectRbacAllowed(JointSlave(item), userCred, action, extra...)
-	if err1 == nil || err2 == nil {
-		return nil
+	if err1 != nil && err2 != nil {
+		return err1
 	}
-	return err1
+	// the rules of joint resource may be conditional as well
+	manager := item.GetModelManager()
+	return checkRbacConditions(manager, item, userCred, manager.ResourceScope(), action, extra...)
 }
 
 func isClassRbacAllowed(manager IModelManager, userCred mcclient.TokenCredential, objOwnerId mcclient.IIdentityProvider, action string, extra ...string) error {
@@ -121,12 +123,12 @@ func isClassRbacAllowed(manager IModelManager, userCred mcclient.TokenCredential
 		}
 	}
 
-	allowScope := policy.PolicyManager.AllowScope(userCred, consts.GetServiceType(), manager.KeywordPlural(), action, extra...)
+	allowScope := policy.PolicyManager.AllowScopeWithConditions(userCred, consts.GetServiceType(), manager.KeywordPlural(), action, extra...)
 
-	if !requireScope.HigherThan(allowScope) {
-		return nil
+	if requireScope.HigherThan(allowScope) {
+		return httperrors.NewForbiddenError("not enough privilege (require:%s,allow:%s)", requireScope, allowScope)
 	}
-	return httperrors.NewForbiddenError("not enough privilege (require:%s,allow:%s)", requireScope, allowScope)
+	return checkRbacConditions(manager, nil, userCred, requireScope, action, extra...)
 }
 
 type IResource interface {
@@ -0,0 +1,251 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"strings"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+)
+
+// sRbacConditionResource provides the attributes of model for the
+// conditions of policy rules
+type sRbacConditionResource struct {
+	model    IModel
+	metadata map[string]string
+}
+
+// fetchRbacConditionMetadata fetches the user tags of model for tag conditions
+var fetchRbacConditionMetadata = func(model IModel) (map[string]string, error) {
+	return Metadata.GetAll(model, nil, USER_TAG_PREFIX, nil)
+}
+
+// rbacConditionTagKey maps the key of tag condition, e.g. tag:env, to the
+// metadata key of the user tag, e.g. user:env, which is case insensitive
+func rbacConditionTagKey(key string) string {
+	return USER_TAG_PREFIX + strings.ToLower(key[len(rbacutils.ConditionKeyTagPrefix):])
+}
+
+func hasRbacTagCondition(conds rbacutils.TRbacConditionSet) bool {
+	for i := range conds {
+		for j := range conds[i] {
+			if strings.HasPrefix(conds[i][j].Key, rbacutils.ConditionKeyTagPrefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (res *sRbacConditionResource) fetchMetadata() error {
+	if res.metadata != nil {
+		return nil
+	}
+	meta, err := fetchRbacConditionMetadata(res.model)
+	if err != nil {
+		return errors.Wrapf(err, "fetch tags of %s %s", res.model.Keyword(), res.model.GetId())
+	}
+	res.metadata = meta
+	return nil
+}
+
+func conditionAttribute(val string) ([]string, bool) {
+	if len(val) == 0 {
+		return nil, false
+	}
+	return []string{val}, true
+}
+
+func (res *sRbacConditionResource) GetConditionAttribute(key string) ([]string, bool) {
+	if strings.HasPrefix(key, rbacutils.ConditionKeyTagPrefix) {
+		if err := res.fetchMetadata(); err != nil {
+			log.Errorf("GetConditionAttribute %s: %s", key, err)
+			return nil, false
+		}
+		val, ok := res.metadata[rbacConditionTagKey(key)]
+		if !ok {
+			return nil, false
+		}
+		return []string{val}, true
+	}
+	owner := res.model.GetOwnerId()
+	if owner == nil {
+		return nil, false
+	}
+	switch key {
+	case rbacutils.ConditionKeyOwnerProjectId:
+		return conditionAttribute(owner.GetProjectId())
+	case rbacutils.ConditionKeyOwnerDomainId:
+		return conditionAttribute(owner.GetProjectDomainId())
+	case rbacutils.ConditionKeyOwnerUserId:
+		return conditionAttribute(owner.GetUserId())
+	}
+	return nil, false
+}
+
+func newRbacConditionContext(userCred mcclient.TokenCredential, model IModel) *rbacutils.SRbacConditionContext {
+	ctx := &rbacutils.SRbacConditionContext{
+		Time: time.Now(),
+	}
+	if userCred != nil {
+		ctx.Caller = userCred
+	}
+	if model != nil {
+		ctx.Resource = &sRbacConditionResource{model: model}
+	}
+	return ctx
+}
+
+// checkRbacConditions checks the conditions of the allow rules, model is nil
+// for class actions, where the conditions on resource attributes never match
+func checkRbacConditions(manager IModelManager, model IModel, userCred mcclient.TokenCredential, requireScope rbacutils.TRbacScope, action string, extra ...string) error {
+	_, conds := policy.PolicyManager.AllowConditions(requireScope, userCred, consts.GetServiceType(), manager.KeywordPlural(), action, extra...)
+	if len(conds) == 0 {
+		return nil
+	}
+	ctx := newRbacConditionContext(userCred, model)
+	if res, ok := ctx.Resource.(*sRbacConditionResource); ok && hasRbacTagCondition(conds) {
+		// deny rather than take the tags as absent, which satisfies not_in
+		if err := res.fetchMetadata(); err != nil {
+			return httperrors.NewGeneralError(err)
+		}
+	}
+	if conds.Match(ctx) {
+		return nil
+	}
+	return httperrors.NewForbiddenError("%s %s not allowed by policy conditions", manager.Keyword(), strings.Join(append([]string{action}, extra...), " "))
+}
+
+func rbacBetweenFilter(field sqlchemy.IQueryField, values []string) sqlchemy.ICondition {
+	if len(values) != 2 {
+		return &sqlchemy.SFalseCondition{}
+	}
+	if values[0] <= values[1] {
+		return sqlchemy.AND(sqlchemy.GE(field, values[0]), sqlchemy.LT(field, values[1]))
+	}
+	return sqlchemy.OR(sqlchemy.GE(field, values[0]), sqlchemy.LT(field, values[1]))
+}
+
+func rbacTagConditionFilter(manager IModelManager, q *sqlchemy.SQuery, cond rbacutils.SRbacCondition) sqlchemy.ICondition {
+	idField := q.Field("id")
+	if idField == nil {
+		return &sqlchemy.SFalseCondition{}
+	}
+	tagKey := rbacConditionTagKey(cond.Key)
+	metaQ := Metadata.Query("obj_id").Equals("obj_type", manager.Keyword()).Equals("key", tagKey)
+	switch cond.Operator {
+	case rbacutils.ConditionIn:
+		if len(cond.Values) == 0 {
+			return &sqlchemy.SFalseCondition{}
+		}
+		return sqlchemy.In(idField, metaQ.In("value", cond.Values).SubQuery())
+	case rbacutils.ConditionNotIn:
+		if len(cond.Values) == 0 {
+			return &sqlchemy.STrueCondition{}
+		}
+		return sqlchemy.NotIn(idField, metaQ.In("value", cond.Values).SubQuery())
+	case rbacutils.ConditionExists:
+		return sqlchemy.In(idField, metaQ.SubQuery())
+	case rbacutils.ConditionNotExists:
+		return sqlchemy.NotIn(idField, metaQ.SubQuery())
+	case rbacutils.ConditionBetween:
+		metaQ = metaQ.Filter(rbacBetweenFilter(metaQ.Field("value"), cond.Values))
+		return sqlchemy.In(idField, metaQ.SubQuery())
+	}
+	return &sqlchemy.SFalseCondition{}
+}
+
+func rbacConditionFilter(manager IModelManager, q *sqlchemy.SQuery, cond rbacutils.SRbacCondition) sqlchemy.ICondition {
+	if strings.HasPrefix(cond.Key, rbacutils.ConditionKeyTagPrefix) {
+		return rbacTagConditionFilter(manager, q, cond)
+	}
+	var field sqlchemy.IQueryField
+	switch cond.Key {
+	case rbacutils.ConditionKeyOwnerProjectId:
+		field = q.Field("tenant_id")
+	case rbacutils.ConditionKeyOwnerDomainId:
+		field = q.Field("domain_id")
+	case rbacutils.ConditionKeyOwnerUserId:
+		field = q.Field("owner_id")
+	}
+	if field == nil {
+		// the resource has no such attribute
+		switch cond.Operator {
+		case rbacutils.ConditionNotIn, rbacutils.ConditionNotExists:
+			return &sqlchemy.STrueCondition{}
+		default:
+			return &sqlchemy.SFalseCondition{}
+		}
+	}
+	switch cond.Operator {
+	case rbacutils.ConditionIn:
+		if len(cond.Values) == 0 {
+			return &sqlchemy.SFalseCondition{}
+		}
+		return sqlchemy.In(field, cond.Values)
+	case rbacutils.ConditionNotIn:
+		if len(cond.Values) == 0 {
+			return &sqlchemy.STrueCondition{}
+		}
+		return sqlchemy.OR(sqlchemy.IsNullOrEmpty(field), sqlchemy.NotIn(field, cond.Values))
+	case rbacutils.ConditionExists:
+		return sqlchemy.IsNotEmpty(field)
+	case rbacutils.ConditionNotExists:
+		return sqlchemy.IsNullOrEmpty(field)
+	case rbacutils.ConditionBetween:
+		return rbacBetweenFilter(field, cond.Values)
+	}
+	return &sqlchemy.SFalseCondition{}
+}
+
+// filterByRbacConditions filters the list query by the conditions of the
+// allow rules, the conditions on request time and caller are evaluated
+// directly and the conditions on resource attributes become query filters
+func filterByRbacConditions(manager IModelManager, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, scope rbacutils.TRbacScope, action string) *sqlchemy.SQuery {
+	_, conds := policy.PolicyManager.AllowConditions(scope, userCred, consts.GetServiceType(), manager.KeywordPlural(), action)
+	if len(conds) == 0 {
+		return q
+	}
+	ctx := newRbacConditionContext(userCred, nil)
+	filters := make([]sqlchemy.ICondition, 0)
+	for i := range conds {
+		resConds, ok := conds[i].ResolveRequest(ctx)
+		if !ok {
+			continue
+		}
+		if len(resConds) == 0 {
+			// satisfied regardless of resource
+			return q
+		}
+		andFilters := make([]sqlchemy.ICondition, len(resConds))
+		for j := range resConds {
+			andFilters[j] = rbacConditionFilter(manager, q, resConds[j])
+		}
+		filters = append(filters, sqlchemy.AND(andFilters...))
+	}
+	if len(filters) == 0 {
+		return q.FilterByFalse()
+	}
+	return q.Filter(sqlchemy.OR(filters...))
+}
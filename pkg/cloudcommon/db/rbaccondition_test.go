@@ -0,0 +1,340 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+)
+
+type sRbacTestResourceManager struct {
+	SVirtualResourceBaseManager
+}
+
+type sRbacTestResource struct {
+	SVirtualResourceBase
+}
+
+var rbacTestResourceManager *sRbacTestResourceManager
+
+func init() {
+	rbacTestResourceManager = &sRbacTestResourceManager{
+		SVirtualResourceBaseManager: NewVirtualResourceBaseManager(
+			sRbacTestResource{},
+			"rbac_test_resources_tbl",
+			"rbac_test_resource",
+			"rbac_test_resources",
+		),
+	}
+	rbacTestResourceManager.SetVirtualObject(rbacTestResourceManager)
+}
+
+const rbacTestService = "rbactest"
+
+// rbacTestPolicies are the project policies of the test roles, keyed by role id
+var rbacTestPolicies = map[string]string{
+	"tag":       `{"get":{"result":"allow","conditions":[{"key":"tag:env","op":"not_in","values":["prod"]}]},"list":{"result":"allow","conditions":[{"key":"tag:env","op":"not_in","values":["prod"]}]},"perform":{"stop":{"result":"allow","conditions":[{"key":"tag:env","op":"not_in","values":["prod"]}]}}}`,
+	"owner":     `{"get":{"result":"allow","conditions":[{"key":"owner:project_id","op":"in","values":["$caller:project_id"]}]},"list":{"result":"allow","conditions":[{"key":"owner:project_id","op":"in","values":["$caller:project_id"]}]}}`,
+	"today":     `{"*":{"result":"allow","conditions":[{"key":"time:weekday","op":"in","values":["%s"]}]}}`,
+	"not-today": `{"*":{"result":"allow","conditions":[{"key":"time:weekday","op":"not_in","values":["%s"]}]}}`,
+	"plain":     `{"*":"allow"}`,
+}
+
+// setupRbacConditionTest installs the test policies and tags, the returned
+// function restores the original ones
+func setupRbacConditionTest() func() {
+	serviceType := consts.GetServiceType()
+	fetcher := policy.DefaultPolicyFetcher
+	fetchMetadata := fetchRbacConditionMetadata
+
+	consts.SetServiceType(rbacTestService)
+	today := strings.ToLower(time.Now().Weekday().String()[:3])
+	policy.DefaultPolicyFetcher = func(ctx context.Context, token mcclient.TokenCredential) (*mcclient.SFetchMatchPoliciesOutput, error) {
+		rules := rbacTestPolicies[token.GetRoleIds()[0]]
+		if strings.Contains(rules, "%s") {
+			rules = fmt.Sprintf(rules, today)
+		}
+		policyJson, err := jsonutils.ParseString(fmt.Sprintf(`{"%s":{"%s":%s}}`, rbacTestService, rbacTestResourceManager.KeywordPlural(), rules))
+		if err != nil {
+			return nil, err
+		}
+		p, err := rbacutils.DecodePolicy(policyJson)
+		if err != nil {
+			return nil, err
+		}
+		return &mcclient.SFetchMatchPoliciesOutput{
+			Policies: rbacutils.TPolicyGroup{
+				rbacutils.ScopeProject: rbacutils.TPolicySet{p},
+			},
+		}, nil
+	}
+	policy.EnableGlobalRbac(time.Minute, false)
+
+	fetchRbacConditionMetadata = func(model IModel) (map[string]string, error) {
+		res := model.(*sRbacTestResource)
+		if res.Id == "prod-resource" {
+			return map[string]string{"user:env": "prod"}, nil
+		}
+		return map[string]string{}, nil
+	}
+
+	return func() {
+		consts.SetServiceType(serviceType)
+		policy.DefaultPolicyFetcher = fetcher
+		fetchRbacConditionMetadata = fetchMetadata
+	}
+}
+
+func newRbacTestResource(id string, projectId string) *sRbacTestResource {
+	res := &sRbacTestResource{}
+	res.SetModelManager(rbacTestResourceManager, res)
+	res.Id = id
+	res.ProjectId = projectId
+	res.DomainId = "default"
+	return res
+}
+
+func newRbacTestUser(roleId string) mcclient.TokenCredential {
+	return &mcclient.SSimpleToken{
+		UserId:    "u1",
+		User:      "user1",
+		Roles:     roleId,
+		RoleIds:   roleId,
+		ProjectId: "p1",
+		Project:   "project1",
+		DomainId:  "default",
+		Domain:    "Default",
+	}
+}
+
+func TestCheckRbacConditions(t *testing.T) {
+	defer setupRbacConditionTest()()
+
+	devRes := newRbacTestResource("dev-resource", "p1")
+	prodRes := newRbacTestResource("prod-resource", "p1")
+	otherRes := newRbacTestResource("other-resource", "p2")
+
+	tests := []struct {
+		name    string
+		role    string
+		model   IModel
+		action  string
+		extra   []string
+		allowed bool
+	}{
+		{"get untagged by tag condition", "tag", devRes, policy.PolicyActionGet, nil, true},
+		{"get prod by tag condition", "tag", prodRes, policy.PolicyActionGet, nil, false},
+		{"perform on untagged by tag condition", "tag", devRes, policy.PolicyActionPerform, []string{"stop"}, true},
+		{"perform on prod by tag condition", "tag", prodRes, policy.PolicyActionPerform, []string{"stop"}, false},
+		{"perform not granted", "tag", devRes, policy.PolicyActionPerform, []string{"start"}, true},
+		{"class action by tag condition", "tag", nil, policy.PolicyActionGet, nil, false},
+		{"get own project by owner condition", "owner", devRes, policy.PolicyActionGet, nil, true},
+		{"get other project by owner condition", "owner", otherRes, policy.PolicyActionGet, nil, false},
+		{"get in time", "today", devRes, policy.PolicyActionGet, nil, true},
+		{"perform in time", "today", devRes, policy.PolicyActionPerform, []string{"stop"}, true},
+		{"get out of time", "not-today", devRes, policy.PolicyActionGet, nil, false},
+		{"perform out of time", "not-today", devRes, policy.PolicyActionPerform, []string{"stop"}, false},
+		{"unconditional", "plain", prodRes, policy.PolicyActionGet, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkRbacConditions(rbacTestResourceManager, tt.model, newRbacTestUser(tt.role), rbacutils.ScopeProject, tt.action, tt.extra...)
+			if (err == nil) != tt.allowed {
+				t.Errorf("checkRbacConditions() = %v, want allowed %v", err, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestAllowIgnoresConditionalRules(t *testing.T) {
+	defer setupRbacConditionTest()()
+
+	result := policy.PolicyManager.Allow(rbacutils.ScopeProject, newRbacTestUser("tag"), rbacTestService, rbacTestResourceManager.KeywordPlural(), policy.PolicyActionGet)
+	if result != rbacutils.Deny {
+		t.Errorf("Allow() with conditional rule = %s, want deny", result)
+	}
+	result = policy.PolicyManager.Allow(rbacutils.ScopeProject, newRbacTestUser("plain"), rbacTestService, rbacTestResourceManager.KeywordPlural(), policy.PolicyActionGet)
+	if result != rbacutils.Allow {
+		t.Errorf("Allow() with unconditional rule = %s, want allow", result)
+	}
+}
+
+func TestRbacConditionFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		cond rbacutils.SRbacCondition
+		want string
+	}{
+		{
+			name: "owner in",
+			cond: rbacutils.SRbacCondition{Key: rbacutils.ConditionKeyOwnerProjectId, Operator: rbacutils.ConditionIn, Values: []string{"p1"}},
+			want: "`tenant_id` IN (",
+		},
+		{
+			name: "owner not in",
+			cond: rbacutils.SRbacCondition{Key: rbacutils.ConditionKeyOwnerProjectId, Operator: rbacutils.ConditionNotIn, Values: []string{"p1"}},
+			want: "`tenant_id` NOT IN (",
+		},
+		{
+			name: "missing field in",
+			cond: rbacutils.SRbacCondition{Key: rbacutils.ConditionKeyOwnerUserId, Operator: rbacutils.ConditionIn, Values: []string{"u1"}},
+			want: "0",
+		},
+		{
+			name: "missing field not in",
+			cond: rbacutils.SRbacCondition{Key: rbacutils.ConditionKeyOwnerUserId, Operator: rbacutils.ConditionNotIn, Values: []string{"u1"}},
+			want: "1",
+		},
+		{
+			name: "missing field exists",
+			cond: rbacutils.SRbacCondition{Key: rbacutils.ConditionKeyOwnerUserId, Operator: rbacutils.ConditionExists},
+			want: "0",
+		},
+		{
+			name: "missing field not exists",
+			cond: rbacutils.SRbacCondition{Key: rbacutils.ConditionKeyOwnerUserId, Operator: rbacutils.ConditionNotExists},
+			want: "1",
+		},
+		{
+			name: "tag not in",
+			cond: rbacutils.SRbacCondition{Key: "tag:env", Operator: rbacutils.ConditionNotIn, Values: []string{"prod"}},
+			want: "NOT IN (SELECT",
+		},
+		{
+			name: "tag not exists",
+			cond: rbacutils.SRbacCondition{Key: "tag:env", Operator: rbacutils.ConditionNotExists},
+			want: "NOT IN (SELECT",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := rbacTestResourceManager.Query()
+			got := rbacConditionFilter(rbacTestResourceManager, q, tt.cond).WhereClause()
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("rbacConditionFilter() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterByRbacConditions(t *testing.T) {
+	defer setupRbacConditionTest()()
+
+	tests := []struct {
+		name       string
+		role       string
+		unfiltered bool
+		want       []string
+	}{
+		{
+			name: "tag condition",
+			role: "tag",
+			want: []string{"`metadata_tbl`", "NOT IN"},
+		},
+		{
+			name: "owner condition",
+			role: "owner",
+			want: []string{"`tenant_id` IN ("},
+		},
+		{
+			name:       "in time",
+			role:       "today",
+			unfiltered: true,
+		},
+		{
+			name: "out of time",
+			role: "not-today",
+			want: []string{"AND (0)"},
+		},
+		{
+			name:       "unconditional",
+			role:       "plain",
+			unfiltered: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := rbacTestResourceManager.Query()
+			base := q.String()
+			q = filterByRbacConditions(rbacTestResourceManager, q, newRbacTestUser(tt.role), rbacutils.ScopeProject, policy.PolicyActionList)
+			sql := q.String()
+			if tt.unfiltered && sql != base {
+				t.Errorf("filterByRbacConditions() = %s, want %s", sql, base)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(sql, w) {
+					t.Errorf("filterByRbacConditions() = %s, want %s", sql, w)
+				}
+			}
+		})
+	}
+}
+
+func TestRbacTagConditionsWithUserTags(t *testing.T) {
+	defer setupRbacConditionTest()()
+
+	userCred := newRbacTestUser("tag")
+
+	t.Run("list", func(t *testing.T) {
+		q := rbacTestResourceManager.Query("id")
+		q = filterByRbacConditions(rbacTestResourceManager, q, userCred, rbacutils.ScopeProject, policy.PolicyActionList)
+		sql := q.String()
+		for _, w := range []string{
+			"`id` NOT IN (SELECT",
+			"`metadata_tbl`",
+			"`obj_type` = ( ? )",
+			"`key` = ( ? )",
+			"`value` IN ( ? )",
+		} {
+			if !strings.Contains(sql, w) {
+				t.Errorf("list = %s, want %s", sql, w)
+			}
+		}
+		vars := fmt.Sprintf("%v", q.Variables())
+		want := fmt.Sprintf("[%s %senv prod]", rbacTestResourceManager.Keyword(), USER_TAG_PREFIX)
+		if vars != want {
+			t.Errorf("list variables = %s, want %s", vars, want)
+		}
+	})
+
+	t.Run("missing tag", func(t *testing.T) {
+		cond := rbacutils.SRbacCondition{Key: "tag:owner", Operator: rbacutils.ConditionIn, Values: []string{"alice"}}
+		ctx := newRbacConditionContext(userCred, newRbacTestResource("dev-resource", "p1"))
+		if cond.Match(ctx) {
+			t.Errorf("%s should not match resource without the tag", cond)
+		}
+	})
+}
+
+func TestCheckRbacConditionsMetadataError(t *testing.T) {
+	defer setupRbacConditionTest()()
+	fetchRbacConditionMetadata = func(model IModel) (map[string]string, error) {
+		return nil, fmt.Errorf("database unavailable")
+	}
+	err := checkRbacConditions(rbacTestResourceManager, newRbacTestResource("dev-resource", "p1"), newRbacTestUser("tag"), rbacutils.ScopeProject, policy.PolicyActionGet)
+	if err == nil {
+		t.Errorf("checkRbacConditions should deny when the tags are unavailable")
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskman
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+const (
+	TASK_CANCELLED_KEY = "__cancelled"
+)
+
+// AllowPerformCancel allows admins to cancel any task, other users may only
+// cancel the tasks they started on objects of their project
+func (self *STask) AllowPerformCancel(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	if db.IsAdminAllowPerform(userCred, self, "cancel") {
+		return true
+	}
+	if !db.IsProjectAllowPerform(userCred, self, "cancel") || !self.isStartedBy(userCred) {
+		return false
+	}
+	obj := self.fetchObject()
+	if obj == nil {
+		return false
+	}
+	owner := obj.GetOwnerId()
+	return owner != nil && owner.GetProjectId() == userCred.GetProjectId()
+}
+
+func (self *STask) isStartedBy(userCred mcclient.TokenCredential) bool {
+	return self.UserCred != nil && len(self.UserCred.GetUserId()) > 0 && self.UserCred.GetUserId() == userCred.GetUserId()
+}
+
+// fetchObject fetches the object of a single object task
+func (self *STask) fetchObject() db.IModel {
+	if self.ObjId == MULTI_OBJECTS_ID {
+		return nil
+	}
+	manager := db.GetModelManager(self.ObjName)
+	if manager == nil {
+		return nil
+	}
+	obj, err := manager.FetchById(self.ObjId)
+	if err != nil {
+		log.Errorf("fetch %s %s of task %s fail %s", self.ObjName, self.ObjId, self.Id, err)
+		return nil
+	}
+	return obj
+}
+
+// PerformCancel fails the task at its current stage through the
+// <Stage>Failed handler, open subtasks are cancelled first and the
+// task then fails on their failure notification
+func (self *STask) PerformCancel(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if !self.isOpen() {
+		return nil, httperrors.NewInvalidStatusError("task %s is at stage %s", self.Id, self.Stage)
+	}
+	if self.Params == nil {
+		self.Params = jsonutils.NewDict()
+	}
+	err := self.cancel(userCred, fmt.Sprintf("task cancelled by %s", userCred.GetUserName()))
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return nil, nil
+}
+
+func (self *STask) cancel(userCred mcclient.TokenCredential, reason string) error {
+	cancelled := jsonutils.NewDict()
+	cancelled.Add(jsonutils.NewString(userCred.GetUserId()), "user_id")
+	cancelled.Add(jsonutils.NewString(userCred.GetUserName()), "user")
+	cancelled.Add(jsonutils.NewString(reason), "reason")
+	cancelled.Add(jsonutils.NewString(self.Stage), "stage")
+	cancelled.Add(jsonutils.NewTimeString(time.Now().UTC()), "at")
+	data := jsonutils.NewDict()
+	data.Add(cancelled, TASK_CANCELLED_KEY)
+	err := self.SaveParams(data)
+	if err != nil {
+		return err
+	}
+	log.Infof("cancel task %s(%s) at stage %s: %s", self.TaskName, self.Id, self.Stage, reason)
+
+	pending := 0
+	subtasks := SubTaskManager.GetInitSubtasks(self.Id, self.Stage)
+	for i := range subtasks {
+		subtask := TaskManager.fetchTask(subtasks[i].SubtaskId)
+		if subtask == nil || !subtask.isOpen() {
+			continue
+		}
+		err := subtask.cancel(userCred, reason)
+		if err != nil {
+			log.Errorf("cancel subtask %s(%s) fail %s", subtask.TaskName, subtask.Id, err)
+			continue
+		}
+		pending += 1
+	}
+	if pending > 0 {
+		// the failure of the last subtask schedules this task
+		return nil
+	}
+	return self.scheduleFailure(reason)
+}
+
+// isCancelledStage tells whether the task stays at the stage it was
+// cancelled at, the stages entered later by its failure handler, e.g. to
+// roll back or release resources, run as usual
+func (self *STask) isCancelledStage() bool {
+	if self.Params == nil || !self.Params.Contains(TASK_CANCELLED_KEY) {
+		return false
+	}
+	stage, _ := self.Params.GetString(TASK_CANCELLED_KEY, "stage")
+	return stage == self.Stage
+}
+
+// cancelledTaskData turns the callbacks of the cancelled stage into a
+// failure so that a late reply from a host cannot resume it
+func (self *STask) cancelledTaskData(odata jsonutils.JSONObject) jsonutils.JSONObject {
+	if odata != nil {
+		taskStatus, _ := odata.GetString("__status__")
+		if len(taskStatus) > 0 && taskStatus != "OK" {
+			return odata
+		}
+	}
+	reason, _ := self.Params.GetString(TASK_CANCELLED_KEY, "reason")
+	body := jsonutils.NewDict()
+	body.Add(jsonutils.NewString("error"), "__status__")
+	body.Add(jsonutils.NewString(reason), "__reason__")
+	return body
+}
@@ -0,0 +1,151 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskman
+
+import (
+	"testing"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+func TestCancelledTaskData(t *testing.T) {
+	cancelled := jsonutils.NewDict()
+	cancelled.Add(jsonutils.NewString("cancelled by admin"), "reason")
+	cancelled.Add(jsonutils.NewString("OnDeployComplete"), "stage")
+	params := jsonutils.NewDict()
+	params.Add(cancelled, TASK_CANCELLED_KEY)
+	task := &STask{Params: params, Stage: "OnDeployComplete"}
+
+	failed := jsonutils.NewDict()
+	failed.Add(jsonutils.NewString("error"), "__status__")
+	failed.Add(jsonutils.NewString("host error"), "__reason__")
+	ok := jsonutils.NewDict()
+	ok.Add(jsonutils.NewString("OK"), "__status__")
+
+	tests := []struct {
+		name       string
+		data       jsonutils.JSONObject
+		wantReason string
+	}{
+		{
+			name:       "nil data",
+			data:       nil,
+			wantReason: "cancelled by admin",
+		},
+		{
+			name:       "success callback",
+			data:       ok,
+			wantReason: "cancelled by admin",
+		},
+		{
+			name:       "failure callback is kept",
+			data:       failed,
+			wantReason: "host error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !task.isCancelledStage() {
+				t.Fatalf("task should be at the cancelled stage")
+			}
+			got := task.cancelledTaskData(tt.data)
+			status, _ := got.GetString("__status__")
+			if status != "error" {
+				t.Errorf("cancelledTaskData() status = %q, want error", status)
+			}
+			reason, _ := got.GetString("__reason__")
+			if reason != tt.wantReason {
+				t.Errorf("cancelledTaskData() reason = %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestIsCancelledStage(t *testing.T) {
+	cancelled := jsonutils.NewDict()
+	cancelled.Add(jsonutils.NewString("OnDeployComplete"), "stage")
+	params := jsonutils.NewDict()
+	params.Add(cancelled, TASK_CANCELLED_KEY)
+
+	tests := []struct {
+		name string
+		task *STask
+		want bool
+	}{
+		{
+			name: "not cancelled",
+			task: &STask{Params: jsonutils.NewDict(), Stage: "OnDeployComplete"},
+			want: false,
+		},
+		{
+			name: "at cancelled stage",
+			task: &STask{Params: params, Stage: "OnDeployComplete"},
+			want: true,
+		},
+		{
+			name: "cleanup stage after cancel",
+			task: &STask{Params: params, Stage: "OnCleanupComplete"},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.task.isCancelledStage(); got != tt.want {
+				t.Errorf("isCancelledStage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsStartedBy(t *testing.T) {
+	admin := &mcclient.SSimpleToken{UserId: "admin-id", ProjectId: "project-id"}
+	member := &mcclient.SSimpleToken{UserId: "member-id", ProjectId: "project-id"}
+
+	tests := []struct {
+		name     string
+		taskCred mcclient.TokenCredential
+		userCred mcclient.TokenCredential
+		want     bool
+	}{
+		{
+			name:     "initiator",
+			taskCred: member,
+			userCred: member,
+			want:     true,
+		},
+		{
+			name:     "another member of the project",
+			taskCred: admin,
+			userCred: member,
+			want:     false,
+		},
+		{
+			name:     "task without user",
+			taskCred: &mcclient.SSimpleToken{ProjectId: "project-id"},
+			userCred: &mcclient.SSimpleToken{ProjectId: "project-id"},
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &STask{UserCred: tt.taskCred}
+			if got := task.isStartedBy(tt.userCred); got != tt.want {
+				t.Errorf("isStartedBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
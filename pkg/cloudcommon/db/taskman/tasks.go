@@ -206,6 +206,7 @@ func fetchTaskParams(
 	if taskData != nil {
 		excludeKeys := []string{
 			PARENT_TASK_ID_KEY, PARENT_TASK_NOTIFY_KEY, PENDING_USAGE_KEY,
+			TASK_WORKER_KEY, TASK_INIT_STARTED_KEY, TASK_TIMEOUT_KEY, TASK_CANCELLED_KEY,
 		}
 		for i := 1; taskData.Contains(pendingUsageKey(i)); i += 1 {
 			excludeKeys = append(excludeKeys, pendingUsageKey(i))
@@ -237,6 +238,8 @@ func fetchTaskParams(
 			}
 		}
 	}
+	startTaskWorkerHeartbeat()
+	data.Add(taskWorkerMarker(), TASK_WORKER_KEY)
 	if len(pendingUsages) > 0 {
 		for i := range pendingUsages {
 			pendingUsage := pendingUsages[i]
@@ -406,6 +409,10 @@ func execITask(taskValue reflect.Value, task *STask, odata jsonutils.JSONObject,
 
 	taskFailed := false
 
+	if task.isCancelledStage() && task.isOpen() {
+		odata = task.cancelledTaskData(odata)
+	}
+
 	var data jsonutils.JSONObject
 	if odata != nil {
 		switch dictdata := odata.(type) {
@@ -526,6 +533,21 @@ func execITask(taskValue reflect.Value, task *STask, odata jsonutils.JSONObject,
 
 	params[2] = reflect.ValueOf(data)
 
+	if task.Stage == TASK_INIT_STAGE && !taskFailed && !task.Params.Contains(TASK_INIT_STARTED_KEY) {
+		// tells the orphan task sweep not to rerun a partial OnInit
+		started := jsonutils.NewDict()
+		started.Add(jsonutils.NewTimeString(time.Now().UTC()), TASK_INIT_STARTED_KEY)
+		err := task.SaveParams(started)
+		if err != nil {
+			// without the marker the orphan sweep would run OnInit again
+			msg := fmt.Sprintf("fail to mark %s init started: %s", task.TaskName, err)
+			log.Errorf(msg)
+			task.SetStageFailed(ctx, jsonutils.NewString(msg))
+			task.SaveRequestContext(&ctxData)
+			return
+		}
+	}
+
 	filled := reflectutils.FillEmbededStructValue(taskValue.Elem(), reflect.Indirect(reflect.ValueOf(task)))
 	if !filled {
 		log.Errorf("Cannot locate baseTask embedded struct, give up...")
@@ -607,6 +629,9 @@ func (self *STask) SaveParams(data *jsonutils.JSONDict) error {
 }
 
 func (self *STask) SetStage(stageName string, data *jsonutils.JSONDict) error {
+	if len(stageName) > 0 {
+		startTaskWorkerHeartbeat()
+	}
 	_, err := db.Update(self, func() error {
 		params := jsonutils.NewDict()
 		params.Update(self.Params)
@@ -625,6 +650,8 @@ func (self *STask) SetStage(stageName string, data *jsonutils.JSONDict) error {
 			stageData.Add(jsonutils.NewTimeString(time.Now()), "complete_at")
 			stageList.Add(stageData)
 			self.Stage = stageName
+			// the process entering the stage drives the task from now on
+			params.Set(TASK_WORKER_KEY, taskWorkerMarker())
 		}
 		self.Params = params
 		return nil
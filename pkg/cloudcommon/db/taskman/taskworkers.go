@@ -0,0 +1,106 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskman
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+)
+
+// STaskWorkerManager keeps the heartbeat of every service process running
+// tasks, a task whose worker stopped heartbeating is left without process
+type STaskWorkerManager struct {
+	db.SModelBaseManager
+}
+
+var TaskWorkerManager *STaskWorkerManager
+
+var (
+	taskWorkerHeartbeatInterval = 30 * time.Second
+	// a worker not heartbeating within the lease is considered dead
+	taskWorkerLease = 3 * time.Minute
+
+	taskWorkerHeartbeatOnce = &sync.Once{}
+)
+
+func init() {
+	TaskWorkerManager = &STaskWorkerManager{SModelBaseManager: db.NewModelBaseManager(STaskWorker{}, "taskworkers_tbl", "taskworker", "taskworkers")}
+}
+
+type STaskWorker struct {
+	db.SModelBase
+
+	// instance id of the service process
+	Id          string    `width:"36" charset:"ascii" primary:"true"`
+	Service     string    `width:"64" charset:"ascii" nullable:"false" index:"true"`
+	Host        string    `width:"128" charset:"utf8" nullable:"false"`
+	HeartbeatAt time.Time `nullable:"false"`
+}
+
+func (manager *STaskWorkerManager) heartbeat(ctx context.Context) error {
+	worker := &STaskWorker{
+		Id:          taskWorkerInstance,
+		Service:     consts.GetServiceType(),
+		Host:        taskWorkerHost,
+		HeartbeatAt: time.Now().UTC(),
+	}
+	worker.SetModelManager(manager, worker)
+	return manager.TableSpec().InsertOrUpdate(ctx, worker)
+}
+
+// startTaskWorkerHeartbeat records this process as a live worker before it
+// creates its first task, then keeps the heartbeat every interval
+func startTaskWorkerHeartbeat() {
+	taskWorkerHeartbeatOnce.Do(func() {
+		ctx := context.Background()
+		err := TaskWorkerManager.heartbeat(ctx)
+		if err != nil {
+			log.Errorf("task worker heartbeat fail %s", err)
+		}
+		go func() {
+			for range time.Tick(taskWorkerHeartbeatInterval) {
+				err := TaskWorkerManager.heartbeat(ctx)
+				if err != nil {
+					log.Errorf("task worker heartbeat fail %s", err)
+				}
+			}
+		}()
+	})
+}
+
+// fetchAliveWorkers returns the instance ids of the processes of the
+// service heartbeating within the lease
+func (manager *STaskWorkerManager) fetchAliveWorkers(now time.Time) (map[string]bool, error) {
+	q := manager.Query().Equals("service", consts.GetServiceType()).GE("heartbeat_at", now.Add(-taskWorkerLease))
+	workers := make([]STaskWorker, 0)
+	err := db.FetchModelObjects(manager, q, &workers)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch task workers")
+	}
+	alive := make(map[string]bool, len(workers))
+	for i := range workers {
+		alive[workers[i].Id] = true
+	}
+	// this process is alive even if its heartbeat failed
+	alive[taskWorkerInstance] = true
+	return alive, nil
+}
@@ -0,0 +1,425 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskman
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/gotypes"
+	"yunion.io/x/pkg/util/stringutils"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/appctx"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/cronman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/elect"
+	common_options "yunion.io/x/onecloud/pkg/cloudcommon/options"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+const (
+	TASK_TIMEOUT_KEY = "__stage_timeout"
+
+	// the service process creating the task
+	TASK_WORKER_KEY = "__worker"
+	// set when OnInit of the task starts running
+	TASK_INIT_STARTED_KEY = "__init_started"
+
+	// stage name used to register a timeout covering every stage of a task
+	TASK_ANY_STAGE = "*"
+)
+
+var (
+	taskTimeoutTable = make(map[string]map[string]time.Duration)
+
+	// open tasks found by the orphan task sweep, keyed by task id, valued by
+	// the start time of the stage they were waiting in
+	orphanTasks     = make(map[string]time.Time)
+	orphanTasksLock = &sync.Mutex{}
+
+	orphanTaskTimeout    = 6 * time.Hour
+	orphanTaskScanWindow = 3 * 24 * time.Hour
+
+	orphanTaskSweepOnce = &sync.Once{}
+	// whether this process is elected to sweep orphan tasks
+	orphanTaskSweeper     = false
+	orphanTaskSweeperLock = &sync.Mutex{}
+
+	// identity of this service process, a task last driven by a process of
+	// the service not heartbeating any longer is orphaned
+	taskWorkerHost, _  = os.Hostname()
+	taskWorkerInstance = stringutils.UUID4()
+)
+
+// RegisterTaskTimeout sets a deadline for every stage of the task, stages
+// running longer than timeout are failed through the <Stage>Failed handler
+func RegisterTaskTimeout(task interface{}, timeout time.Duration) {
+	RegisterTaskStageTimeout(task, TASK_ANY_STAGE, timeout)
+}
+
+// RegisterTaskStageTimeout sets a deadline for the given stage of the task,
+// it takes precedence over the timeout registered for the whole task
+func RegisterTaskStageTimeout(task interface{}, stage string, timeout time.Duration) {
+	taskName := gotypes.GetInstanceTypeName(task)
+	if !isTaskExist(taskName) {
+		log.Fatalf("Task %s not registered!", taskName)
+	}
+	if _, ok := taskTimeoutTable[taskName]; !ok {
+		taskTimeoutTable[taskName] = make(map[string]time.Duration)
+	}
+	taskTimeoutTable[taskName][stage] = timeout
+}
+
+// SetOrphanTaskOptions sets how long a task left open by a dead process
+// may stay in its stage before being failed, and how far back the sweep
+// looks for such tasks
+func SetOrphanTaskOptions(timeout time.Duration, scanWindow time.Duration) {
+	if timeout > 0 {
+		orphanTaskTimeout = timeout
+	}
+	if scanWindow > 0 {
+		orphanTaskScanWindow = scanWindow
+	}
+}
+
+// InitTaskTimeoutCheck applies the orphan task options, starts the worker
+// heartbeat and adds the job failing timeout and orphan tasks to cron, every
+// service running tasks should call it. Orphan tasks are swept periodically
+// by the process elected by electObj, services without election skip the
+// sweep since every replica of them would sweep otherwise
+func InitTaskTimeoutCheck(cron *cronman.SCronJobManager, opts *common_options.DBOptions, electObj *elect.Elect) {
+	SetOrphanTaskOptions(time.Duration(opts.OrphanTaskTimeoutHours)*time.Hour, time.Duration(opts.OrphanTaskScanDays)*24*time.Hour)
+	startTaskWorkerHeartbeat()
+	cron.AddJobAtIntervalsWithStartRun("TaskTimeoutCheck", time.Duration(opts.TaskTimeoutCheckIntervalSeconds)*time.Second, TaskManager.CheckTaskTimeouts, true)
+	if electObj != nil {
+		electObj.SubscribeWithAction(context.Background(), func() { setOrphanTaskSweeper(true) }, func() { setOrphanTaskSweeper(false) })
+	}
+}
+
+func setOrphanTaskSweeper(sweeper bool) {
+	orphanTaskSweeperLock.Lock()
+	orphanTaskSweeper = sweeper
+	orphanTaskSweeperLock.Unlock()
+	if sweeper {
+		orphanTaskSweepOnce.Do(func() {
+			ctx := context.WithValue(context.Background(), appctx.APP_CONTEXT_KEY_APPNAME, "OrphanTaskSweep")
+			go TaskManager.runOrphanTaskSweep(ctx)
+		})
+	}
+}
+
+func isOrphanTaskSweeper() bool {
+	orphanTaskSweeperLock.Lock()
+	defer orphanTaskSweeperLock.Unlock()
+	return orphanTaskSweeper
+}
+
+// runOrphanTaskSweep sweeps orphan tasks every worker lease while this
+// process stays elected, so that the tasks of a process dying at any time
+// are picked up once its lease expires
+func (manager *STaskManager) runOrphanTaskSweep(ctx context.Context) {
+	for {
+		if isOrphanTaskSweeper() {
+			manager.sweepOrphanTasks(ctx)
+		}
+		time.Sleep(taskWorkerLease)
+	}
+}
+
+func taskWorkerMarker() *jsonutils.JSONDict {
+	marker := jsonutils.NewDict()
+	marker.Add(jsonutils.NewString(taskWorkerHost), "host")
+	marker.Add(jsonutils.NewString(consts.GetServiceType()), "service")
+	marker.Add(jsonutils.NewString(taskWorkerInstance), "instance")
+	return marker
+}
+
+// isOrphaned tells whether the process of the service last driving the
+// task is not among the alive workers, no process runs it then
+func (self *STask) isOrphaned(aliveWorkers map[string]bool) bool {
+	if self.Params == nil || !self.Params.Contains(TASK_WORKER_KEY) {
+		return false
+	}
+	service, _ := self.Params.GetString(TASK_WORKER_KEY, "service")
+	instance, _ := self.Params.GetString(TASK_WORKER_KEY, "instance")
+	return service == consts.GetServiceType() && !aliveWorkers[instance]
+}
+
+// claim takes over the task as this process, it is not orphaned any
+// longer unless this process dies too
+func (self *STask) claim() error {
+	data := jsonutils.NewDict()
+	data.Add(taskWorkerMarker(), TASK_WORKER_KEY)
+	return self.SaveParams(data)
+}
+
+func getTaskStageTimeout(taskName string, stage string) time.Duration {
+	stages, ok := taskTimeoutTable[taskName]
+	if !ok {
+		return 0
+	}
+	if timeout, ok := stages[stage]; ok {
+		return timeout
+	}
+	return stages[TASK_ANY_STAGE]
+}
+
+// taskTimeoutScanWindow is how far back open tasks are looked for timeout,
+// a stage of the longest registered timeout is still found within the scan
+// window after its deadline
+func taskTimeoutScanWindow() time.Duration {
+	var longest time.Duration
+	for _, stages := range taskTimeoutTable {
+		for _, timeout := range stages {
+			if timeout > longest {
+				longest = timeout
+			}
+		}
+	}
+	return orphanTaskScanWindow + longest
+}
+
+func (self *STask) isOpen() bool {
+	return self.Stage != TASK_STAGE_COMPLETE && self.Stage != TASK_STAGE_FAILED
+}
+
+// GetStageStartTime returns the time the task entered its current stage
+func (self *STask) GetStageStartTime() time.Time {
+	if self.Params != nil {
+		stages, _ := self.Params.GetArray("__stages")
+		if len(stages) > 0 {
+			at, err := stages[len(stages)-1].GetTime("complete_at")
+			if err == nil {
+				return at
+			}
+		}
+	}
+	return self.CreatedAt
+}
+
+func (self *STask) isStageTimedOut(stageStart time.Time) bool {
+	if self.Params == nil || !self.Params.Contains(TASK_TIMEOUT_KEY) {
+		return false
+	}
+	stage, _ := self.Params.GetString(TASK_TIMEOUT_KEY, "stage")
+	at, _ := self.Params.GetTime(TASK_TIMEOUT_KEY, "at")
+	return stage == self.Stage && !at.Before(stageStart)
+}
+
+func (self *STask) scheduleFailure(reason string) error {
+	body := jsonutils.NewDict()
+	body.Add(jsonutils.NewString("error"), "__status__")
+	body.Add(jsonutils.NewString(reason), "__reason__")
+	return self.ScheduleRun(body)
+}
+
+func (self *STask) failOnTimeout(timeout time.Duration) error {
+	marker := jsonutils.NewDict()
+	marker.Add(jsonutils.NewString(self.Stage), "stage")
+	marker.Add(jsonutils.NewTimeString(time.Now().UTC()), "at")
+	data := jsonutils.NewDict()
+	data.Add(marker, TASK_TIMEOUT_KEY)
+	err := self.SaveParams(data)
+	if err != nil {
+		return err
+	}
+	log.Warningf("task %s(%s) timeout at stage %s after %s", self.TaskName, self.Id, self.Stage, timeout)
+	return self.scheduleFailure(fmt.Sprintf("stage %s timeout after %s", self.Stage, timeout))
+}
+
+// hasOpenSubtasks tells whether the current stage still waits for local subtasks,
+// such a task is driven by its subtasks rather than by an external callback
+func (self *STask) hasOpenSubtasks() bool {
+	subtasks := SubTaskManager.GetInitSubtasks(self.Id, self.Stage)
+	for i := range subtasks {
+		subtask := TaskManager.fetchTask(subtasks[i].SubtaskId)
+		if subtask != nil && subtask.isOpen() {
+			return true
+		}
+	}
+	return false
+}
+
+func (manager *STaskManager) openTasksQuery() *sqlchemy.SQuery {
+	q := manager.Query()
+	return q.Filter(sqlchemy.NOT(sqlchemy.In(q.Field("stage"), []string{TASK_STAGE_COMPLETE, TASK_STAGE_FAILED})))
+}
+
+func (manager *STaskManager) fetchTasks(q *sqlchemy.SQuery) ([]STask, error) {
+	tasks := make([]STask, 0)
+	err := db.FetchModelObjects(manager, q, &tasks)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tasks {
+		if tasks[i].Params == nil {
+			tasks[i].Params = jsonutils.NewDict()
+		}
+	}
+	return tasks, nil
+}
+
+// fetchOpenTasks returns the open tasks updated since, updated_at moves on
+// every stage change so that long running tasks entering a stage recently
+// are included no matter when they were created
+func (manager *STaskManager) fetchOpenTasks(since time.Time, taskNames []string) ([]STask, error) {
+	q := manager.openTasksQuery().GE("updated_at", since)
+	if taskNames != nil {
+		q = q.In("task_name", taskNames)
+	}
+	return manager.fetchTasks(q)
+}
+
+// CheckTaskTimeouts fails the open tasks whose current stage passed its
+// deadline and the orphan tasks found by the sweep still waiting
+// for their callback
+func (manager *STaskManager) CheckTaskTimeouts(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	now := time.Now().UTC()
+	since := now.Add(-taskTimeoutScanWindow())
+
+	taskNames := make([]string, 0, len(taskTimeoutTable))
+	for taskName := range taskTimeoutTable {
+		taskNames = append(taskNames, taskName)
+	}
+	if len(taskNames) > 0 {
+		tasks, err := manager.fetchOpenTasks(since, taskNames)
+		if err != nil {
+			log.Errorf("fetch open tasks fail %s", err)
+			return
+		}
+		for i := range tasks {
+			task := &tasks[i]
+			timeout := getTaskStageTimeout(task.TaskName, task.Stage)
+			if timeout <= 0 {
+				continue
+			}
+			stageStart := task.GetStageStartTime()
+			if now.Sub(stageStart) < timeout || task.isStageTimedOut(stageStart) {
+				continue
+			}
+			err := task.failOnTimeout(timeout)
+			if err != nil {
+				log.Errorf("fail timeout task %s(%s): %s", task.TaskName, task.Id, err)
+			}
+		}
+	}
+
+	manager.checkOrphanTasks(now)
+}
+
+// sweepOrphanTasks resumes or fails the tasks left open by the processes
+// of the service which stopped heartbeating, e.g. restarted or replaced.
+// The tasks are claimed by this process first:
+//   - tasks queued but never run are resumed by running OnInit
+//   - tasks interrupted in OnInit are failed, since rerunning OnInit could
+//     repeat side effects of the partial run
+//   - tasks whose subtasks of the current stage all finished missed their
+//     notification and are resumed with the subtask results
+//   - tasks waiting for callback are given orphanTaskTimeout unless their
+//     stage has a registered timeout
+func (manager *STaskManager) sweepOrphanTasks(ctx context.Context) {
+	now := time.Now().UTC()
+	since := now.Add(-orphanTaskScanWindow)
+	aliveWorkers, err := TaskWorkerManager.fetchAliveWorkers(now)
+	if err != nil {
+		log.Errorf("fetch alive task workers fail %s", err)
+		return
+	}
+	tasks, err := manager.fetchOpenTasks(since, nil)
+	if err != nil {
+		log.Errorf("fetch open tasks fail %s", err)
+		return
+	}
+	for i := range tasks {
+		task := &tasks[i]
+		if !task.isOrphaned(aliveWorkers) || !isTaskExist(task.TaskName) {
+			continue
+		}
+		err := manager.resumeOrphanTask(task)
+		if err != nil {
+			log.Errorf("resume orphan task %s(%s): %s", task.TaskName, task.Id, err)
+		}
+	}
+}
+
+func (manager *STaskManager) resumeOrphanTask(task *STask) error {
+	if err := task.claim(); err != nil {
+		return err
+	}
+	if task.Stage == TASK_INIT_STAGE {
+		if task.Params.Contains(TASK_INIT_STARTED_KEY) {
+			log.Warningf("orphan task %s(%s) interrupted at %s", task.TaskName, task.Id, task.Stage)
+			return task.scheduleFailure("task interrupted at on_init by death of its service process")
+		}
+		log.Infof("resume orphan task %s(%s) lost in worker queue", task.TaskName, task.Id)
+		return task.ScheduleRun(nil)
+	}
+	if task.IsCurrentStageComplete() {
+		log.Infof("resume orphan task %s(%s) at stage %s with subtasks finished", task.TaskName, task.Id, task.Stage)
+		return task.ScheduleRun(task.subtaskResults())
+	}
+	if getTaskStageTimeout(task.TaskName, task.Stage) > 0 || task.hasOpenSubtasks() {
+		return nil
+	}
+	orphanTasksLock.Lock()
+	defer orphanTasksLock.Unlock()
+	orphanTasks[task.Id] = task.GetStageStartTime()
+	return nil
+}
+
+// subtaskResults returns the notification of the failed subtask of the
+// current stage if any, the task proceeds as on the last notification
+func (self *STask) subtaskResults() jsonutils.JSONObject {
+	failed := SubTaskManager.GetTotalSubtasks(self.Id, self.Stage, SUBTASK_FAIL)
+	if len(failed) == 0 {
+		return nil
+	}
+	body := jsonutils.NewDict()
+	result, _ := jsonutils.ParseString(failed[0].Result)
+	if dict, ok := result.(*jsonutils.JSONDict); ok {
+		body = dict
+	}
+	body.Set("__status__", jsonutils.NewString("error"))
+	return body
+}
+
+func (manager *STaskManager) checkOrphanTasks(now time.Time) {
+	orphanTasksLock.Lock()
+	defer orphanTasksLock.Unlock()
+
+	for taskId, stageStart := range orphanTasks {
+		if now.Sub(stageStart) < orphanTaskTimeout {
+			continue
+		}
+		delete(orphanTasks, taskId)
+		task := manager.fetchTask(taskId)
+		if task == nil || !task.isOpen() || !task.GetStageStartTime().Equal(stageStart) {
+			// the task moved on since the sweep
+			continue
+		}
+		log.Warningf("orphan task %s(%s) stays at stage %s since %s", task.TaskName, task.Id, task.Stage, stageStart)
+		err := task.scheduleFailure(fmt.Sprintf("stage %s orphaned by death of its service process", task.Stage))
+		if err != nil {
+			log.Errorf("fail orphan task %s(%s): %s", task.TaskName, task.Id, err)
+		}
+	}
+}
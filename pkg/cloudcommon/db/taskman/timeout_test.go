@@ -0,0 +1,224 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taskman
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+)
+
+func TestGetTaskStageTimeout(t *testing.T) {
+	taskTimeoutTable["TimeoutTestTask"] = map[string]time.Duration{
+		TASK_ANY_STAGE: time.Hour,
+		"OnSlowStage":  3 * time.Hour,
+	}
+	defer delete(taskTimeoutTable, "TimeoutTestTask")
+
+	tests := []struct {
+		name     string
+		taskName string
+		stage    string
+		want     time.Duration
+	}{
+		{
+			name:     "stage timeout takes precedence",
+			taskName: "TimeoutTestTask",
+			stage:    "OnSlowStage",
+			want:     3 * time.Hour,
+		},
+		{
+			name:     "fallback to task timeout",
+			taskName: "TimeoutTestTask",
+			stage:    "OnOtherStage",
+			want:     time.Hour,
+		},
+		{
+			name:     "task without timeout",
+			taskName: "NoTimeoutTestTask",
+			stage:    "OnSlowStage",
+			want:     0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getTaskStageTimeout(tt.taskName, tt.stage); got != tt.want {
+				t.Errorf("getTaskStageTimeout() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskTimeoutScanWindow(t *testing.T) {
+	window := orphanTaskScanWindow
+	taskTimeoutTable["TimeoutTestTask"] = map[string]time.Duration{
+		TASK_ANY_STAGE: time.Hour,
+		"OnSlowStage":  7 * 24 * time.Hour,
+	}
+	defer delete(taskTimeoutTable, "TimeoutTestTask")
+
+	if got, want := taskTimeoutScanWindow(), window+7*24*time.Hour; got < want {
+		t.Errorf("taskTimeoutScanWindow() = %s, want at least %s", got, want)
+	}
+}
+
+func TestGetStageStartTime(t *testing.T) {
+	createdAt := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	completeAt := time.Date(2020, 6, 1, 10, 30, 0, 0, time.UTC)
+
+	stage := jsonutils.NewDict()
+	stage.Add(jsonutils.NewString("on_init"), "name")
+	stage.Add(jsonutils.NewTimeString(completeAt), "complete_at")
+	stages := jsonutils.NewArray(stage)
+	params := jsonutils.NewDict()
+	params.Add(stages, "__stages")
+
+	tests := []struct {
+		name   string
+		params *jsonutils.JSONDict
+		want   time.Time
+	}{
+		{
+			name:   "nil params",
+			params: nil,
+			want:   createdAt,
+		},
+		{
+			name:   "no completed stage",
+			params: jsonutils.NewDict(),
+			want:   createdAt,
+		},
+		{
+			name:   "last completed stage",
+			params: params,
+			want:   completeAt,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &STask{Params: tt.params}
+			task.CreatedAt = createdAt
+			if got := task.GetStageStartTime(); !got.Equal(tt.want) {
+				t.Errorf("GetStageStartTime() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsStageTimedOut(t *testing.T) {
+	stageStart := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	timeoutParams := func(stage string, at time.Time) *jsonutils.JSONDict {
+		marker := jsonutils.NewDict()
+		marker.Add(jsonutils.NewString(stage), "stage")
+		marker.Add(jsonutils.NewTimeString(at), "at")
+		params := jsonutils.NewDict()
+		params.Add(marker, TASK_TIMEOUT_KEY)
+		return params
+	}
+
+	tests := []struct {
+		name   string
+		params *jsonutils.JSONDict
+		want   bool
+	}{
+		{
+			name:   "not timed out",
+			params: jsonutils.NewDict(),
+			want:   false,
+		},
+		{
+			name:   "timed out in current stage",
+			params: timeoutParams("OnSlowStage", stageStart.Add(time.Hour)),
+			want:   true,
+		},
+		{
+			name:   "timed out in other stage",
+			params: timeoutParams("OnOtherStage", stageStart.Add(time.Hour)),
+			want:   false,
+		},
+		{
+			name:   "timed out before entering the stage again",
+			params: timeoutParams("OnSlowStage", stageStart.Add(-time.Hour)),
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &STask{Stage: "OnSlowStage", Params: tt.params}
+			if got := task.isStageTimedOut(stageStart); got != tt.want {
+				t.Errorf("isStageTimedOut() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsOrphaned(t *testing.T) {
+	workerParams := func(service, instance string) *jsonutils.JSONDict {
+		marker := taskWorkerMarker()
+		marker.Set("service", jsonutils.NewString(service))
+		marker.Set("instance", jsonutils.NewString(instance))
+		params := jsonutils.NewDict()
+		params.Add(marker, TASK_WORKER_KEY)
+		return params
+	}
+	service := consts.GetServiceType()
+	aliveWorkers := map[string]bool{
+		taskWorkerInstance: true,
+		"alive-instance":   true,
+	}
+
+	tests := []struct {
+		name   string
+		params *jsonutils.JSONDict
+		want   bool
+	}{
+		{
+			name:   "task without worker",
+			params: jsonutils.NewDict(),
+			want:   false,
+		},
+		{
+			name:   "task of this process",
+			params: workerParams(service, taskWorkerInstance),
+			want:   false,
+		},
+		{
+			name:   "task of another alive process",
+			params: workerParams(service, "alive-instance"),
+			want:   false,
+		},
+		{
+			name:   "task of dead process",
+			params: workerParams(service, "dead-instance"),
+			want:   true,
+		},
+		{
+			name:   "task of another service",
+			params: workerParams("another-service", "dead-instance"),
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &STask{Params: tt.params}
+			if got := task.isOrphaned(aliveWorkers); got != tt.want {
+				t.Errorf("isOrphaned() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
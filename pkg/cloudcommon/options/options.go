@@ -147,6 +147,10 @@ type DBOptions struct {
 
 	LockmanMethod string `help:"method for lock synchronization" choices:"inmemory|etcd" default:"inmemory"`
 
+	TaskTimeoutCheckIntervalSeconds int `help:"interval to check timeout and orphan tasks" default:"60"`
+	OrphanTaskTimeoutHours          int `help:"hours a task left open by a restart may wait for its callback before being failed" default:"6"`
+	OrphanTaskScanDays              int `help:"days back to look for open tasks by the time they entered their stage" default:"3"`
+
 	// SplitableMaxKeepSegments  int `help:"maximal segements of splitable to keep, default 6 segments" default:"6"`
 	// SplitableMaxDurationHours int `help:"maximal number of hours that a splitable segement lasts, default 30 days" default:"720"`
 
@@ -174,22 +174,36 @@ func permissionKey(scope rbacutils.TRbacScope, userCred mcclient.TokenCredential
 	return strings.Join(queryKeys, "-")
 }
 
+// AllowScope returns the highest scope the request is allowed in
+// unconditionally, the conditional allow rules are ignored as the callers
+// never evaluate their conditions
 func (manager *SPolicyManager) AllowScope(userCred mcclient.TokenCredential, service string, resource string, action string, extra ...string) rbacutils.TRbacScope {
+	return manager.allowScope(false, userCred, service, resource, action, extra...)
+}
+
+// AllowScopeWithConditions returns the highest scope the request is allowed
+// in, the allow rules may be conditional, the caller must check their
+// conditions by AllowConditions in the scope required
+func (manager *SPolicyManager) AllowScopeWithConditions(userCred mcclient.TokenCredential, service string, resource string, action string, extra ...string) rbacutils.TRbacScope {
+	return manager.allowScope(true, userCred, service, resource, action, extra...)
+}
+
+func (manager *SPolicyManager) allowScope(withConditions bool, userCred mcclient.TokenCredential, service string, resource string, action string, extra ...string) rbacutils.TRbacScope {
 	for _, scope := range []rbacutils.TRbacScope{
 		rbacutils.ScopeSystem,
 		rbacutils.ScopeDomain,
 		rbacutils.ScopeProject,
 		rbacutils.ScopeUser,
 	} {
-		result := manager.allow(scope, userCred, service, resource, action, extra...)
-		if result == rbacutils.Allow {
+		perm := manager.check(scope, userCred, service, resource, action, extra...)
+		if perm.result == rbacutils.Allow && (withConditions || len(perm.conditions) == 0) {
 			return scope
 		}
 	}
 	return rbacutils.ScopeNone
 }
 
-func (manager *SPolicyManager) Allow(targetScope rbacutils.TRbacScope, userCred mcclient.TokenCredential, service string, resource string, action string, extra ...string) rbacutils.TRbacResult {
+func getRetryScopes(targetScope rbacutils.TRbacScope) []rbacutils.TRbacScope {
 	var retryScopes []rbacutils.TRbacScope
 	switch targetScope {
 	case rbacutils.ScopeSystem:
@@ -213,15 +227,42 @@ func (manager *SPolicyManager) Allow(targetScope rbacutils.TRbacScope, userCred
 			rbacutils.ScopeUser,
 		}
 	}
-	for _, scope := range retryScopes {
-		result := manager.allow(scope, userCred, service, resource, action, extra...)
-		if result == rbacutils.Allow {
+	return retryScopes
+}
+
+// Allow returns Allow only if the request is allowed unconditionally, the
+// conditional allow rules are denied here as their conditions are never
+// evaluated, callers able to check them should use AllowConditions
+func (manager *SPolicyManager) Allow(targetScope rbacutils.TRbacScope, userCred mcclient.TokenCredential, service string, resource string, action string, extra ...string) rbacutils.TRbacResult {
+	for _, scope := range getRetryScopes(targetScope) {
+		perm := manager.check(scope, userCred, service, resource, action, extra...)
+		if perm.result == rbacutils.Allow && len(perm.conditions) == 0 {
 			return rbacutils.Allow
 		}
 	}
 	return rbacutils.Deny
 }
 
+// AllowConditions returns the conditions of the allow rules matching the request
+// in targetScope, nil conditions means the request is allowed unconditionally
+func (manager *SPolicyManager) AllowConditions(targetScope rbacutils.TRbacScope, userCred mcclient.TokenCredential, service string, resource string, action string, extra ...string) (rbacutils.TRbacResult, rbacutils.TRbacConditionSet) {
+	var conds rbacutils.TRbacConditionSet
+	for _, scope := range getRetryScopes(targetScope) {
+		perm := manager.check(scope, userCred, service, resource, action, extra...)
+		if perm.result != rbacutils.Allow {
+			continue
+		}
+		if len(perm.conditions) == 0 {
+			return rbacutils.Allow, nil
+		}
+		conds = append(conds, perm.conditions...)
+	}
+	if len(conds) > 0 {
+		return rbacutils.Allow, conds
+	}
+	return rbacutils.Deny, nil
+}
+
 func (manager *SPolicyManager) fetchMatchedPolicies(userCred mcclient.TokenCredential) (*mcclient.SFetchMatchPoliciesOutput, error) {
 	key := policyKey(userCred)
 
@@ -251,30 +292,38 @@ func (manager *SPolicyManager) fetchMatchedPolicies(userCred mcclient.TokenCrede
 	return res.output, res.err
 }
 
-func (manager *SPolicyManager) allow(scope rbacutils.TRbacScope, userCred mcclient.TokenCredential, service string, resource string, action string, extra ...string) rbacutils.TRbacResult {
+// sPermission is the result of a permission check, the allow result
+// holds only if any of the conditions is satisfied when conditions is not empty
+type sPermission struct {
+	result     rbacutils.TRbacResult
+	conditions rbacutils.TRbacConditionSet
+}
+
+func (manager *SPolicyManager) check(scope rbacutils.TRbacScope, userCred mcclient.TokenCredential, service string, resource string, action string, extra ...string) *sPermission {
 	// first download userCred policy
 	policies, err := manager.fetchMatchedPolicies(userCred)
 	if err != nil {
 		log.Errorf("fetchMatchedPolicyGroup fail %s", err)
-		return rbacutils.Deny
+		return &sPermission{result: rbacutils.Deny}
 	}
 	// check permission
 	key := permissionKey(scope, userCred, service, resource, action, extra...)
 	val := manager.permissionCache.AtomicGet(key)
 	if !gotypes.IsNil(val) {
 		if consts.IsRbacDebug() {
-			log.Debugf("query %s:%s:%s:%s from cache %s", service, resource, action, extra, val)
+			log.Debugf("query %s:%s:%s:%s from cache %s", service, resource, action, extra, val.(*sPermission).result)
 		}
-		return val.(rbacutils.TRbacResult)
+		return val.(*sPermission)
 	}
 
 	policySet, ok := policies.Policies[scope]
 	if !ok {
 		policySet = rbacutils.TPolicySet{}
 	}
-	result := manager.allowWithoutCache(policySet, scope, userCred, service, resource, action, extra...)
-	manager.permissionCache.Set(key, result)
-	return result
+	result, conds := manager.allowWithoutCache(policySet, scope, userCred, service, resource, action, extra...)
+	perm := &sPermission{result: result, conditions: conds}
+	manager.permissionCache.Set(key, perm)
+	return perm
 }
 
 /*
@@ -303,7 +352,7 @@ func getMatchedPolicyRules(policies []rbacutils.SPolicyInfo, userCred rbacutils.
 }
 */
 
-func (manager *SPolicyManager) allowWithoutCache(policies rbacutils.TPolicySet, scope rbacutils.TRbacScope, userCred mcclient.TokenCredential, service string, resource string, action string, extra ...string) rbacutils.TRbacResult {
+func (manager *SPolicyManager) allowWithoutCache(policies rbacutils.TPolicySet, scope rbacutils.TRbacScope, userCred mcclient.TokenCredential, service string, resource string, action string, extra ...string) (rbacutils.TRbacResult, rbacutils.TRbacConditionSet) {
 	matchRules := make([]rbacutils.SRbacRule, 0)
 	findMatchPolicy := false
 	if len(policies) == 0 {
@@ -354,12 +403,18 @@ func (manager *SPolicyManager) allowWithoutCache(policies rbacutils.TPolicySet,
 	}
 
 	var result rbacutils.TRbacResult
+	var conds rbacutils.TRbacConditionSet
 	if len(matchRules) > 0 {
 		result = rbacutils.Deny
 		for _, rule := range matchRules {
 			if rule.Result == rbacutils.Allow {
 				result = rbacutils.Allow
-				break
+				if len(rule.Conditions) == 0 {
+					// any unconditional allow wins
+					conds = nil
+					break
+				}
+				conds = append(conds, rule.Conditions)
 			}
 		}
 		// rule := rbacutils.GetMatchRule(matchRules, service, resource, action, extra...)
@@ -374,7 +429,7 @@ func (manager *SPolicyManager) allowWithoutCache(policies rbacutils.TPolicySet,
 	if consts.IsRbacDebug() {
 		log.Debugf("[RBAC: %s] %s %s %s %#v permission %s userCred: %s MatchRules: %d(%s)", scope, service, resource, action, extra, result, userCred, len(matchRules), jsonutils.Marshal(matchRules))
 	}
-	return result
+	return result, conds
 }
 
 //
@@ -0,0 +1,67 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+)
+
+func TestAllowConditionalRules(t *testing.T) {
+	policyJson, _ := jsonutils.ParseString(`{"compute":{"servers":{"get":"allow","perform":{"stop":{"result":"allow","conditions":[{"key":"tag:env","op":"not_in","values":["prod"]}]}}}}}`)
+	projectPolicy, err := rbacutils.DecodePolicy(policyJson)
+	if err != nil {
+		t.Fatalf("DecodePolicy: %s", err)
+	}
+	fetcher := DefaultPolicyFetcher
+	defer func() { DefaultPolicyFetcher = fetcher }()
+	DefaultPolicyFetcher = func(ctx context.Context, token mcclient.TokenCredential) (*mcclient.SFetchMatchPoliciesOutput, error) {
+		return &mcclient.SFetchMatchPoliciesOutput{
+			Policies: rbacutils.TPolicyGroup{
+				rbacutils.ScopeProject: rbacutils.TPolicySet{projectPolicy},
+			},
+		}, nil
+	}
+	manager := &SPolicyManager{lock: &sync.Mutex{}}
+	manager.init(time.Minute)
+	userCred := &mcclient.SSimpleToken{UserId: "u1", ProjectId: "p1"}
+
+	if result := manager.Allow(rbacutils.ScopeProject, userCred, "compute", "servers", "get"); result != rbacutils.Allow {
+		t.Errorf("get should be allowed, got %s", result)
+	}
+	if result := manager.Allow(rbacutils.ScopeProject, userCred, "compute", "servers", "perform", "stop"); result != rbacutils.Deny {
+		t.Errorf("conditional stop should be denied by Allow, got %s", result)
+	}
+	result, conds := manager.AllowConditions(rbacutils.ScopeProject, userCred, "compute", "servers", "perform", "stop")
+	if result != rbacutils.Allow || len(conds) != 1 || conds[0][0].Key != "tag:env" {
+		t.Errorf("conditional stop should be allowed with conditions, got %s %#v", result, conds)
+	}
+	if scope := manager.AllowScope(userCred, "compute", "servers", "perform", "stop"); scope != rbacutils.ScopeNone {
+		t.Errorf("conditional stop should be ignored by AllowScope, got %s", scope)
+	}
+	if scope := manager.AllowScopeWithConditions(userCred, "compute", "servers", "perform", "stop"); scope != rbacutils.ScopeProject {
+		t.Errorf("conditional stop should be scoped to project, got %s", scope)
+	}
+	if scope := manager.AllowScope(userCred, "compute", "servers", "get"); scope != rbacutils.ScopeProject {
+		t.Errorf("get should be scoped to project, got %s", scope)
+	}
+}
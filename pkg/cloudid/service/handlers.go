@@ -47,6 +47,7 @@ func InitHandlers(app *appsrv.Application) {
 		taskman.TaskManager,
 		taskman.SubTaskManager,
 		taskman.TaskObjectManager,
+		taskman.TaskWorkerManager,
 		db.UserCacheManager,
 		db.TenantCacheManager,
 		db.SharedResourceManager,
@@ -334,7 +334,8 @@ type ICloudVM interface {
 
 	GetError() error
 
-	CreateInstanceSnapshot(ctx context.Context, name string, desc string) (ICloudInstanceSnapshot, error)
+	// quiesce asks hypervisor to flush and freeze guest filesystems while taking snapshot
+	CreateInstanceSnapshot(ctx context.Context, name string, desc string, quiesce bool) (ICloudInstanceSnapshot, error)
 	GetInstanceSnapshot(idStr string) (ICloudInstanceSnapshot, error)
 	GetInstanceSnapshots() ([]ICloudInstanceSnapshot, error)
 	ResetToInstanceSnapshot(ctx context.Context, idStr string) error
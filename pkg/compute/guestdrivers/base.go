@@ -24,6 +24,7 @@ import (
 	"yunion.io/x/pkg/errors"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
+	host_api "yunion.io/x/onecloud/pkg/apis/host"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/cloudprovider"
@@ -128,6 +129,34 @@ func (self *SBaseGuestDriver) RequestCloseForward(ctx context.Context, userCred
 	return nil, cloudprovider.ErrNotImplemented
 }
 
+func (self *SBaseGuestDriver) RequestQgaPing(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, req *host_api.GuestQgaPingRequest) error {
+	return cloudprovider.ErrNotImplemented
+}
+
+func (self *SBaseGuestDriver) RequestQgaSetPassword(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, req *host_api.GuestQgaSetPasswordRequest) error {
+	return cloudprovider.ErrNotImplemented
+}
+
+func (self *SBaseGuestDriver) RequestQgaExec(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, req *host_api.GuestQgaExecRequest) (*host_api.GuestQgaExecResponse, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (self *SBaseGuestDriver) RequestQgaGetNetworkInterfaces(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest) (*host_api.GuestQgaNetworkInterfacesResponse, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (self *SBaseGuestDriver) RequestQgaGetOsInfo(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest) (*host_api.GuestQgaOsInfoResponse, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (self *SBaseGuestDriver) RequestQgaFsfreeze(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, req *host_api.GuestQgaFsfreezeRequest) (*host_api.GuestQgaFsfreezeResponse, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (self *SBaseGuestDriver) RequestQgaFsthaw(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, req *host_api.GuestQgaFsfreezeRequest) (*host_api.GuestQgaFsfreezeResponse, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
 func (self *SBaseGuestDriver) RequestSaveImage(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, task taskman.ITask) error {
 	return errors.Wrapf(cloudprovider.ErrNotImplemented, "RequestSaveImage")
 }
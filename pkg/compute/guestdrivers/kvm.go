@@ -702,10 +702,25 @@ func checkAssignHost(userCred mcclient.TokenCredential, preferHost string) error
 	return nil
 }
 
+// checkMigrateDisks rejects migrating guest with disks hosts can't copy to
+// the destination, lvm volumes are not created on destination hosts
+func checkMigrateDisks(guest *models.SGuest) error {
+	for _, guestDisk := range guest.GetDisks() {
+		storage := guestDisk.GetDisk().GetStorage()
+		if storage != nil && storage.StorageType == api.STORAGE_LVM {
+			return httperrors.NewBadRequestError("Cannot migrate disks of %s storage", storage.StorageType)
+		}
+	}
+	return nil
+}
+
 func (self *SKVMGuestDriver) CheckMigrate(guest *models.SGuest, userCred mcclient.TokenCredential, input api.GuestMigrateInput) error {
 	if len(guest.BackupHostId) > 0 {
 		return httperrors.NewBadRequestError("Guest have backup, can't migrate")
 	}
+	if err := checkMigrateDisks(guest); err != nil {
+		return err
+	}
 	if !input.IsRescueMode && guest.Status != api.VM_READY {
 		return httperrors.NewServerStatusError("Cannot normal migrate guest in status %s, try rescue mode or server-live-migrate?", guest.Status)
 	}
@@ -747,6 +762,9 @@ func (self *SKVMGuestDriver) CheckLiveMigrate(guest *models.SGuest, userCred mcc
 		if !guest.CheckQemuVersion(guest.GetQemuVersion(userCred), "1.1.2") {
 			return httperrors.NewBadRequestError("Cannot do live migrate, too low qemu version")
 		}
+		if err := checkMigrateDisks(guest); err != nil {
+			return err
+		}
 		if len(input.PreferHost) > 0 {
 			err := checkAssignHost(userCred, input.PreferHost)
 			if err != nil {
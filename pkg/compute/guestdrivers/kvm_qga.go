@@ -0,0 +1,112 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestdrivers
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	host_api "yunion.io/x/onecloud/pkg/apis/host"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+)
+
+// requestQga calls guest agent action of guest on its host, and unmarshal
+// host response to resp if it is not nil
+func (self *SKVMGuestDriver) requestQga(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, action string, req interface{}, resp interface{}) error {
+	host := guest.GetHost()
+	if host == nil {
+		return errors.Wrapf(errors.ErrNotFound, "host of guest %s", guest.Name)
+	}
+	var (
+		url        = fmt.Sprintf("%s/servers/%s/%s", host.ManagerUri, guest.Id, action)
+		httpClient = httputils.GetDefaultClient()
+		header     = mcclient.GetTokenHeaders(userCred)
+		body       = jsonutils.NewDict()
+	)
+	if req != nil {
+		body = jsonutils.Marshal(req).(*jsonutils.JSONDict)
+	}
+	_, respBody, err := httputils.JSONRequest(httpClient, ctx, "POST", url, header, body, false)
+	if err != nil {
+		return errors.Wrapf(err, "host request %s", action)
+	}
+	if resp != nil {
+		if respBody == nil {
+			return errors.Wrapf(errors.ErrServer, "empty host response of %s", action)
+		}
+		if err := respBody.Unmarshal(resp); err != nil {
+			return errors.Wrap(err, "unmarshal host response")
+		}
+	}
+	return nil
+}
+
+func (self *SKVMGuestDriver) RequestQgaPing(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, req *host_api.GuestQgaPingRequest) error {
+	return self.requestQga(ctx, userCred, guest, "qga-ping", req, nil)
+}
+
+func (self *SKVMGuestDriver) RequestQgaSetPassword(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, req *host_api.GuestQgaSetPasswordRequest) error {
+	return self.requestQga(ctx, userCred, guest, "qga-set-password", req, nil)
+}
+
+func (self *SKVMGuestDriver) RequestQgaExec(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, req *host_api.GuestQgaExecRequest) (*host_api.GuestQgaExecResponse, error) {
+	resp := &host_api.GuestQgaExecResponse{}
+	err := self.requestQga(ctx, userCred, guest, "qga-exec", req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (self *SKVMGuestDriver) RequestQgaGetNetworkInterfaces(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest) (*host_api.GuestQgaNetworkInterfacesResponse, error) {
+	resp := &host_api.GuestQgaNetworkInterfacesResponse{}
+	err := self.requestQga(ctx, userCred, guest, "qga-get-network-interfaces", nil, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (self *SKVMGuestDriver) RequestQgaGetOsInfo(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest) (*host_api.GuestQgaOsInfoResponse, error) {
+	resp := &host_api.GuestQgaOsInfoResponse{}
+	err := self.requestQga(ctx, userCred, guest, "qga-get-os-info", nil, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (self *SKVMGuestDriver) RequestQgaFsfreeze(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, req *host_api.GuestQgaFsfreezeRequest) (*host_api.GuestQgaFsfreezeResponse, error) {
+	resp := &host_api.GuestQgaFsfreezeResponse{}
+	err := self.requestQga(ctx, userCred, guest, "qga-fsfreeze", req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (self *SKVMGuestDriver) RequestQgaFsthaw(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, req *host_api.GuestQgaFsfreezeRequest) (*host_api.GuestQgaFsfreezeResponse, error) {
+	resp := &host_api.GuestQgaFsfreezeResponse{}
+	err := self.requestQga(ctx, userCred, guest, "qga-fsthaw", req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
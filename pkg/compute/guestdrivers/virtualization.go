@@ -170,6 +170,7 @@ func (self *SVirtualizedGuestDriver) Attach2RandomNetwork(guest *models.SGuest,
 		Network:             selNet,
 		PendingUsage:        pendingUsage,
 		IpAddr:              netConfig.Address,
+		IpAddr6:             netConfig.Address6,
 		NicDriver:           netConfig.Driver,
 		BwLimit:             netConfig.BwLimit,
 		Virtual:             netConfig.Vip,
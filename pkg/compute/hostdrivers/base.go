@@ -70,6 +70,18 @@ func (self *SBaseHostDriver) RequestCleanUpDiskSnapshots(ctx context.Context, ho
 	return fmt.Errorf("Not Implement")
 }
 
+func (self *SBaseHostDriver) RequestDiskBackup(ctx context.Context, host *models.SHost, disk *models.SDisk, backup *models.SDiskBackup, task taskman.ITask) error {
+	return fmt.Errorf("Not Implement")
+}
+
+func (self *SBaseHostDriver) RequestRestoreDiskBackup(ctx context.Context, host *models.SHost, disk *models.SDisk, chain []models.SDiskBackup, task taskman.ITask) error {
+	return fmt.Errorf("Not Implement")
+}
+
+func (self *SBaseHostDriver) RequestDeleteDiskBackups(ctx context.Context, host *models.SHost, diskId string, backups []models.SDiskBackup, task taskman.ITask) error {
+	return fmt.Errorf("Not Implement")
+}
+
 func (self *SBaseHostDriver) PrepareConvert(host *models.SHost, image, raid string, data jsonutils.JSONObject) (*api.ServerCreateInput, error) {
 	params := &api.ServerCreateInput{
 		ServerConfigs: &api.ServerConfigs{
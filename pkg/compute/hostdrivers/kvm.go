@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"path"
 	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -29,6 +30,7 @@ import (
 	"yunion.io/x/pkg/utils"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
+	hostapi "yunion.io/x/onecloud/pkg/apis/host"
 	"yunion.io/x/onecloud/pkg/cloudcommon/cmdline"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/cloudprovider"
@@ -59,7 +61,7 @@ func (self *SKVMHostDriver) GetHypervisor() string {
 }
 
 func (self *SKVMHostDriver) ValidateAttachStorage(ctx context.Context, userCred mcclient.TokenCredential, host *models.SHost, storage *models.SStorage, data *jsonutils.JSONDict) error {
-	if !utils.IsInStringArray(storage.StorageType, append([]string{api.STORAGE_LOCAL}, api.SHARED_STORAGE...)) {
+	if !utils.IsInStringArray(storage.StorageType, append([]string{api.STORAGE_LOCAL, api.STORAGE_LVM}, api.SHARED_STORAGE...)) {
 		return httperrors.NewUnsupportOperationError("Unsupport attach %s storage for %s host", storage.StorageType, host.HostType)
 	}
 	if storage.StorageType == api.STORAGE_RBD {
@@ -68,6 +70,12 @@ func (self *SKVMHostDriver) ValidateAttachStorage(ctx context.Context, userCred
 		}
 		pool, _ := storage.StorageConf.GetString("pool")
 		data.Set("mount_point", jsonutils.NewString(fmt.Sprintf("rbd:%s", pool)))
+	} else if storage.StorageType == api.STORAGE_ISCSI {
+		if host.HostStatus != api.HOST_ONLINE {
+			return httperrors.NewInvalidStatusError("Attach iscsi storage require host status is online")
+		}
+		// disks are linked to the luns under the directory with the same path on all hosts
+		data.Set("mount_point", jsonutils.NewString(path.Join(api.STORAGE_ISCSI_DISK_DIR, storage.Id)))
 	} else if utils.IsInStringArray(storage.StorageType, api.SHARED_FILE_STORAGE) {
 		mountPoint, err := data.GetString("mount_point")
 		if err != nil {
@@ -243,6 +251,13 @@ func (self *SKVMHostDriver) RequestUncacheImage(ctx context.Context, host *model
 
 func (self *SKVMHostDriver) RequestAllocateDiskOnStorage(ctx context.Context, userCred mcclient.TokenCredential, host *models.SHost, storage *models.SStorage, disk *models.SDisk, task taskman.ITask, content *jsonutils.JSONDict) error {
 	header := task.GetTaskRequestHeader()
+	if storage.StorageType == api.STORAGE_ISCSI {
+		lun, err := storage.AllocateIscsiLun(ctx, userCred, disk)
+		if err != nil {
+			return errors.Wrap(err, "AllocateIscsiLun")
+		}
+		content.Set("iscsi_lun", jsonutils.Marshal(lun))
+	}
 	if snapshotId, err := content.GetString("snapshot"); err == nil {
 		iSnapshot, _ := models.SnapshotManager.FetchById(snapshotId)
 		snapshot := iSnapshot.(*models.SSnapshot)
@@ -264,6 +279,9 @@ func (self *SKVMHostDriver) RequestAllocateDiskOnStorage(ctx context.Context, us
 			content.Set("snapshot_url", jsonutils.NewString(snapshot.Id))
 			content.Set("src_disk_id", jsonutils.NewString(snapshot.DiskId))
 			content.Set("src_pool", jsonutils.NewString(pool))
+		} else if snapshotStorage.StorageType == api.STORAGE_LVM {
+			content.Set("snapshot_url", jsonutils.NewString(snapshot.Id))
+			content.Set("src_disk_id", jsonutils.NewString(snapshot.DiskId))
 		} else {
 			content.Set("snapshot_url", jsonutils.NewString(snapshot.Location))
 		}
@@ -396,6 +414,46 @@ func (self *SKVMHostDriver) RequestCleanUpDiskSnapshots(ctx context.Context, hos
 	return err
 }
 
+func (self *SKVMHostDriver) RequestDiskBackup(ctx context.Context, host *models.SHost, disk *models.SDisk, backup *models.SDiskBackup, task taskman.ITask) error {
+	url := fmt.Sprintf("/disks/%s/backup/%s", disk.StorageId, disk.Id)
+	req := &hostapi.DiskBackupRequest{
+		BackupId: backup.Id,
+		ParentId: backup.ParentId,
+		Mode:     backup.BackupMode,
+		ServerId: backup.GuestId,
+	}
+	header := task.GetTaskRequestHeader()
+	_, err := host.Request(ctx, task.GetUserCred(), "POST", url, header, jsonutils.Marshal(req))
+	return err
+}
+
+func (self *SKVMHostDriver) RequestRestoreDiskBackup(ctx context.Context, host *models.SHost, disk *models.SDisk, chain []models.SDiskBackup, task taskman.ITask) error {
+	url := fmt.Sprintf("/disks/%s/restore-backup/%s", disk.StorageId, disk.Id)
+	req := &hostapi.DiskBackupRestoreRequest{}
+	for i := range chain {
+		req.Backups = append(req.Backups, hostapi.DiskBackupInfo{
+			BackupId: chain[i].Id,
+			Location: chain[i].Location,
+		})
+	}
+	header := task.GetTaskRequestHeader()
+	_, err := host.Request(ctx, task.GetUserCred(), "POST", url, header, jsonutils.Marshal(req))
+	return err
+}
+
+func (self *SKVMHostDriver) RequestDeleteDiskBackups(ctx context.Context, host *models.SHost, diskId string, backups []models.SDiskBackup, task taskman.ITask) error {
+	req := &hostapi.DiskBackupDeleteRequest{DiskId: diskId}
+	for i := range backups {
+		req.Backups = append(req.Backups, hostapi.DiskBackupInfo{
+			BackupId: backups[i].Id,
+			Location: backups[i].Location,
+		})
+	}
+	header := task.GetTaskRequestHeader()
+	_, err := host.Request(ctx, task.GetUserCred(), "POST", "/disk_backups/delete", header, jsonutils.Marshal(req))
+	return err
+}
+
 func (self *SKVMHostDriver) PrepareConvert(host *models.SHost, image, raid string, data jsonutils.JSONObject) (*api.ServerCreateInput, error) {
 	params, err := self.SBaseHostDriver.PrepareConvert(host, image, raid, data)
 	if err != nil {
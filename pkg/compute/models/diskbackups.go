@@ -0,0 +1,635 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+func init() {
+	DiskBackupManager = &SDiskBackupManager{
+		SVirtualResourceBaseManager: db.NewVirtualResourceBaseManager(
+			SDiskBackup{},
+			"disk_backups_tbl",
+			"disk_backup",
+			"disk_backups",
+		),
+	}
+	DiskBackupManager.SetVirtualObject(DiskBackupManager)
+}
+
+// SDiskBackup is a backup of kvm disk kept outside of its storage. A full
+// backup starts a chain, each incremental backup after it holds clusters
+// changed since its parent, tracked by dirty bitmap of qemu.
+type SDiskBackup struct {
+	db.SVirtualResourceBase
+	SDiskResourceBase
+
+	// 云主机Id
+	GuestId string `width:"36" charset:"ascii" nullable:"true" list:"user"`
+	// 执行备份的宿主机Id
+	HostId string `width:"36" charset:"ascii" nullable:"true" list:"admin"`
+	// 备份存储类型
+	// enum: nfs, s3
+	BackupStorage string `width:"16" charset:"ascii" nullable:"true" list:"user"`
+	// 备份文件位置
+	Location string `width:"256" charset:"utf8" nullable:"true" list:"admin"`
+	// 备份模式
+	// enum: full, incremental
+	BackupMode string `width:"16" charset:"ascii" nullable:"false" list:"user" create:"optional"`
+	// 所基于的上一个备份Id, 全量备份为空
+	ParentId string `width:"36" charset:"ascii" nullable:"true" list:"user"`
+	// 备份链Id, 即备份链中全量备份的Id
+	ChainId string `width:"36" charset:"ascii" nullable:"true" list:"user" index:"true"`
+	// 备份文件大小, 单位Mb
+	SizeMb int `nullable:"false" default:"0" list:"user"`
+	// 备份时磁盘大小, 单位Mb
+	DiskSizeMb int `nullable:"false" default:"0" list:"user"`
+}
+
+type SDiskBackupManager struct {
+	db.SVirtualResourceBaseManager
+	SDiskResourceBaseManager
+}
+
+var DiskBackupManager *SDiskBackupManager
+
+// 磁盘备份列表
+func (manager *SDiskBackupManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.DiskBackupListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SVirtualResourceBaseManager.ListItemFilter(ctx, q, userCred, query.VirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SVirtualResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SDiskResourceBaseManager.ListItemFilter(ctx, q, userCred, query.DiskFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SDiskResourceBaseManager.ListItemFilter")
+	}
+	if len(query.ServerId) > 0 {
+		guestObj, err := GuestManager.FetchByIdOrName(userCred, query.ServerId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(GuestManager.Keyword(), query.ServerId)
+			}
+			return nil, httperrors.NewGeneralError(err)
+		}
+		q = q.Equals("guest_id", guestObj.GetId())
+	}
+	if len(query.ChainId) > 0 {
+		q = q.Equals("chain_id", query.ChainId)
+	}
+	if len(query.BackupMode) > 0 {
+		q = q.Equals("backup_mode", query.BackupMode)
+	}
+	return q, nil
+}
+
+func (manager *SDiskBackupManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.DiskBackupListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SVirtualResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.VirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SVirtualResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SDiskResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.DiskFilterListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SDiskResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SDiskBackupManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SVirtualResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	q, err = manager.SDiskResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SDiskBackupManager) ListItemExportKeys(ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	keys stringutils2.SSortedStrings,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SVirtualResourceBaseManager.ListItemExportKeys(ctx, q, userCred, keys)
+	if err != nil {
+		return nil, errors.Wrap(err, "SVirtualResourceBaseManager.ListItemExportKeys")
+	}
+	if keys.ContainsAny(manager.SDiskResourceBaseManager.GetExportKeys()...) {
+		q, err = manager.SDiskResourceBaseManager.ListItemExportKeys(ctx, q, userCred, keys)
+		if err != nil {
+			return nil, errors.Wrap(err, "SDiskResourceBaseManager.ListItemExportKeys")
+		}
+	}
+	return q, nil
+}
+
+func (manager *SDiskBackupManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.DiskBackupDetails {
+	rows := make([]api.DiskBackupDetails, len(objs))
+
+	virtRows := manager.SVirtualResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	diskRows := manager.SDiskResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+
+	guestIds := make([]string, len(objs))
+	chainIds := make([]string, len(objs))
+	for i := range rows {
+		rows[i] = api.DiskBackupDetails{
+			VirtualResourceDetails: virtRows[i],
+			DiskResourceInfo:       diskRows[i],
+		}
+		backup := objs[i].(*SDiskBackup)
+		guestIds[i] = backup.GuestId
+		chainIds[i] = backup.ChainId
+	}
+
+	guests := make(map[string]SGuest)
+	err := db.FetchStandaloneObjectsByIds(GuestManager, guestIds, &guests)
+	if err != nil {
+		log.Errorf("FetchStandaloneObjectsByIds fail %s", err)
+		return rows
+	}
+	chainLength, err := manager.getChainLength(chainIds)
+	if err != nil {
+		log.Errorf("getChainLength fail %s", err)
+		return rows
+	}
+	for i := range rows {
+		if guest, ok := guests[guestIds[i]]; ok {
+			rows[i].Guest = guest.Name
+		}
+		rows[i].ChainLength = chainLength[chainIds[i]]
+	}
+	return rows
+}
+
+func (manager *SDiskBackupManager) getChainLength(chainIds []string) (map[string]int, error) {
+	q := manager.Query("chain_id")
+	q = q.In("chain_id", chainIds)
+	q = q.AppendField(sqlchemy.COUNT("count"))
+	q = q.GroupBy(q.Field("chain_id"))
+	rows := []struct {
+		ChainId string
+		Count   int
+	}{}
+	if err := q.All(&rows); err != nil {
+		return nil, errors.Wrap(err, "query chain length")
+	}
+	ret := make(map[string]int)
+	for _, row := range rows {
+		ret[row.ChainId] = row.Count
+	}
+	return ret, nil
+}
+
+func (self *SDiskBackup) GetExtraDetails(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	isList bool,
+) (api.DiskBackupDetails, error) {
+	return api.DiskBackupDetails{}, nil
+}
+
+func (manager *SDiskBackupManager) ValidateCreateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	query jsonutils.JSONObject,
+	input api.DiskBackupCreateInput,
+) (*jsonutils.JSONDict, error) {
+	if len(input.Disk) == 0 {
+		input.Disk = input.DiskId
+	}
+	if len(input.Disk) == 0 {
+		return nil, httperrors.NewMissingParameterError("disk")
+	}
+	disk, diskInput, err := ValidateDiskResourceInput(userCred, api.DiskResourceInput{DiskId: input.Disk})
+	if err != nil {
+		return nil, err
+	}
+	input.DiskId = diskInput.DiskId
+	if disk.Status != api.DISK_READY {
+		return nil, httperrors.NewInvalidStatusError("cannot backup disk in status %s", disk.Status)
+	}
+	storage := disk.GetStorage()
+	if storage == nil || !utils.IsInStringArray(storage.StorageType, api.FIEL_STORAGE) {
+		return nil, httperrors.NewNotSupportedError("only disks on file based storages of kvm hosts support backup")
+	}
+
+	guests := disk.GetGuests()
+	if len(guests) > 1 {
+		return nil, httperrors.NewBadRequestError("disk %s is attached to multiple guests", disk.Name)
+	}
+	if len(guests) == 1 {
+		guest := guests[0]
+		if guest.Hypervisor != api.HYPERVISOR_KVM {
+			return nil, httperrors.NewNotSupportedError("disk backup is not supported by %s", guest.Hypervisor)
+		}
+		if !utils.IsInStringArray(guest.Status, []string{api.VM_READY, api.VM_RUNNING}) {
+			return nil, httperrors.NewInvalidStatusError("cannot backup disk of guest in status %s", guest.Status)
+		}
+		input.GuestId = guest.Id
+	} else if host := storage.GetMasterHost(); host == nil || host.HostType != api.HOST_TYPE_HYPERVISOR {
+		return nil, httperrors.NewNotSupportedError("only disks on file based storages of kvm hosts support backup")
+	}
+
+	switch input.BackupMode {
+	case "":
+		input.BackupMode = api.DISK_BACKUP_MODE_INCREMENTAL
+	case api.DISK_BACKUP_MODE_FULL, api.DISK_BACKUP_MODE_INCREMENTAL:
+	default:
+		return nil, httperrors.NewInputParameterError("invalid backup_mode %s", input.BackupMode)
+	}
+
+	cnt, err := manager.Query().Equals("disk_id", disk.Id).Equals("status", api.DISK_BACKUP_STATUS_CREATING).CountWithError()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	if cnt > 0 {
+		return nil, httperrors.NewConflictError("disk %s is being backed up", disk.Name)
+	}
+	if input.BackupMode == api.DISK_BACKUP_MODE_INCREMENTAL {
+		parent, err := manager.getParentBackup(disk)
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+		if parent != nil {
+			input.ParentId = parent.Id
+			input.ChainId = parent.ChainId
+		} else {
+			input.BackupMode = api.DISK_BACKUP_MODE_FULL
+		}
+	}
+
+	input.VirtualResourceCreateInput, err = manager.SVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.VirtualResourceCreateInput)
+	if err != nil {
+		return nil, err
+	}
+	return input.JSON(input), nil
+}
+
+// getParentBackup returns backup next incremental backup of disk can be
+// based on, nil means a new chain has to be started
+func (manager *SDiskBackupManager) getParentBackup(disk *SDisk) (*SDiskBackup, error) {
+	backupId := disk.GetMetadata(api.DISK_METADATA_LAST_BACKUP, nil)
+	if len(backupId) == 0 {
+		return nil, nil
+	}
+	obj, err := manager.FetchById(backupId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "fetch backup %s", backupId)
+	}
+	backup := obj.(*SDiskBackup)
+	if !isValidParentBackup(disk.Id, backup) {
+		return nil, nil
+	}
+	return backup, nil
+}
+
+// isValidParentBackup checks whether incremental backup of disk can be
+// based on backup
+func isValidParentBackup(diskId string, backup *SDiskBackup) bool {
+	return backup.DiskId == diskId && backup.Status == api.DISK_BACKUP_STATUS_READY && len(backup.ChainId) > 0
+}
+
+func (self *SDiskBackup) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	// backups belong to owner of the disk
+	disk := self.GetDisk()
+	if disk == nil {
+		return errors.Wrapf(errors.ErrNotFound, "disk %s", self.DiskId)
+	}
+	self.GuestId, _ = data.GetString("guest_id")
+	self.ParentId, _ = data.GetString("parent_id")
+	self.ChainId, _ = data.GetString("chain_id")
+	if host := self.getBackupHost(disk); host != nil {
+		self.HostId = host.Id
+	}
+	self.Status = api.DISK_BACKUP_STATUS_CREATING
+	return self.SVirtualResourceBase.CustomizeCreate(ctx, userCred, disk.GetOwnerId(), query, data)
+}
+
+// getBackupHost returns host the disk is in use on, or the host
+// owning its storage
+func (self *SDiskBackup) getBackupHost(disk *SDisk) *SHost {
+	if guest := disk.GetGuest(); guest != nil && len(guest.HostId) > 0 {
+		return guest.GetHost()
+	}
+	if storage := disk.GetStorage(); storage != nil {
+		return storage.GetMasterHost()
+	}
+	return nil
+}
+
+func (self *SDiskBackup) GetHost() *SHost {
+	if len(self.HostId) > 0 {
+		host := HostManager.FetchHostById(self.HostId)
+		if host != nil {
+			return host
+		}
+	}
+	if disk := self.GetDisk(); disk != nil {
+		return self.getBackupHost(disk)
+	}
+	return nil
+}
+
+func (self *SDiskBackup) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	self.SVirtualResourceBase.PostCreate(ctx, userCred, ownerId, query, data)
+	if self.BackupMode == api.DISK_BACKUP_MODE_FULL {
+		// full backup starts a new chain
+		if err := self.startNewChain(); err != nil {
+			log.Errorf("backup %s start new chain fail %s", self.Name, err)
+		}
+	}
+}
+
+func (self *SDiskBackup) startNewChain() error {
+	_, err := db.Update(self, func() error {
+		self.BackupMode = api.DISK_BACKUP_MODE_FULL
+		self.ParentId = ""
+		self.ChainId = self.Id
+		return nil
+	})
+	return err
+}
+
+func (manager *SDiskBackupManager) OnCreateComplete(ctx context.Context, items []db.IModel, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	backup := items[0].(*SDiskBackup)
+	err := backup.StartDiskBackupCreateTask(ctx, userCred, "")
+	if err != nil {
+		backup.SetStatus(userCred, api.DISK_BACKUP_STATUS_CREATE_FAILED, err.Error())
+	}
+}
+
+func (self *SDiskBackup) StartDiskBackupCreateTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
+	task, err := taskman.TaskManager.NewTask(ctx, "DiskBackupCreateTask", self, userCred, nil, parentTaskId, "", nil)
+	if err != nil {
+		return err
+	}
+	task.ScheduleRun(nil)
+	return nil
+}
+
+func (self *SDiskBackup) AllowUpdateItem(ctx context.Context, userCred mcclient.TokenCredential) bool {
+	return false
+}
+
+// GetChain returns backups from the full one to self
+func (self *SDiskBackup) GetChain() ([]SDiskBackup, error) {
+	return buildBackupChain(self, func(id string) (*SDiskBackup, error) {
+		obj, err := DiskBackupManager.FetchById(id)
+		if err != nil {
+			return nil, err
+		}
+		return obj.(*SDiskBackup), nil
+	})
+}
+
+// buildBackupChain walks up parents of backup with fetchBackup and checks
+// they form a complete chain of the same disk starting from a full backup
+func buildBackupChain(backup *SDiskBackup, fetchBackup func(id string) (*SDiskBackup, error)) ([]SDiskBackup, error) {
+	chain := []SDiskBackup{*backup}
+	visited := map[string]bool{backup.Id: true}
+	for cur := backup; len(cur.ParentId) > 0; {
+		if visited[cur.ParentId] {
+			return nil, fmt.Errorf("backup %s has circular parent %s", cur.Name, cur.ParentId)
+		}
+		visited[cur.ParentId] = true
+		parent, err := fetchBackup(cur.ParentId)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fetch parent backup %s", cur.ParentId)
+		}
+		if parent.Status != api.DISK_BACKUP_STATUS_READY {
+			return nil, fmt.Errorf("parent backup %s in status %s", parent.Name, parent.Status)
+		}
+		if parent.DiskId != backup.DiskId || parent.ChainId != backup.ChainId {
+			return nil, fmt.Errorf("parent backup %s not in chain %s of disk %s", parent.Name, backup.ChainId, backup.DiskId)
+		}
+		chain = append([]SDiskBackup{*parent}, chain...)
+		cur = parent
+	}
+	if root := chain[0]; root.BackupMode != api.DISK_BACKUP_MODE_FULL || root.Id != root.ChainId {
+		return nil, fmt.Errorf("chain of backup %s does not start with a full backup", backup.Name)
+	}
+	return chain, nil
+}
+
+func (self *SDiskBackup) GetChildrenCount() (int, error) {
+	return DiskBackupManager.Query().Equals("parent_id", self.Id).CountWithError()
+}
+
+func (self *SDiskBackup) AllowPerformRestore(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, self, "restore")
+}
+
+// 将磁盘恢复到该备份的时间点, 磁盘所在虚拟机需处于关机状态
+func (self *SDiskBackup) PerformRestore(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.DiskBackupRestoreInput) (jsonutils.JSONObject, error) {
+	if self.Status != api.DISK_BACKUP_STATUS_READY {
+		return nil, httperrors.NewInvalidStatusError("cannot restore backup in status %s", self.Status)
+	}
+	disk := self.GetDisk()
+	if disk == nil {
+		return nil, httperrors.NewResourceNotFoundError2(DiskManager.Keyword(), self.DiskId)
+	}
+	if disk.Status != api.DISK_READY {
+		return nil, httperrors.NewInvalidStatusError("cannot restore disk in status %s", disk.Status)
+	}
+	guests := disk.GetGuests()
+	if len(guests) > 1 {
+		return nil, httperrors.NewBadRequestError("disk %s is attached to multiple guests", disk.Name)
+	}
+	if len(guests) == 1 && guests[0].Status != api.VM_READY {
+		return nil, httperrors.NewInvalidStatusError("guest %s must be stopped before restoring disk", guests[0].Name)
+	}
+	if _, err := self.GetChain(); err != nil {
+		return nil, httperrors.NewBadRequestError("backup chain broken: %v", err)
+	}
+	return nil, self.StartDiskBackupRestoreTask(ctx, userCred, "")
+}
+
+func (self *SDiskBackup) StartDiskBackupRestoreTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
+	task, err := taskman.TaskManager.NewTask(ctx, "DiskBackupRestoreTask", self, userCred, nil, parentTaskId, "", nil)
+	if err != nil {
+		return err
+	}
+	self.SetStatus(userCred, api.DISK_BACKUP_STATUS_RESTORING, "")
+	task.ScheduleRun(nil)
+	return nil
+}
+
+func (self *SDiskBackup) ValidateDeleteCondition(ctx context.Context) error {
+	if isDiskBackupBusy(self.Status) {
+		return httperrors.NewInvalidStatusError("cannot delete backup in status %s", self.Status)
+	}
+	cnt, err := self.GetChildrenCount()
+	if err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("backup %s has %d incremental backups based on it", self.Name, cnt)
+	}
+	return self.SVirtualResourceBase.ValidateDeleteCondition(ctx)
+}
+
+func (self *SDiskBackup) CustomizeDelete(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	return self.StartDiskBackupDeleteTask(ctx, userCred, []SDiskBackup{*self}, "")
+}
+
+// StartDiskBackupDeleteTask deletes backups given, they are supposed to
+// be backups of the same disk and none of them has children not deleted
+func (self *SDiskBackup) StartDiskBackupDeleteTask(ctx context.Context, userCred mcclient.TokenCredential, backups []SDiskBackup, parentTaskId string) error {
+	backupIds := make([]string, len(backups))
+	for i := range backups {
+		backupIds[i] = backups[i].Id
+	}
+	params := jsonutils.NewDict()
+	params.Set("backup_ids", jsonutils.NewStringArray(backupIds))
+	task, err := taskman.TaskManager.NewTask(ctx, "DiskBackupDeleteTask", self, userCred, params, parentTaskId, "", nil)
+	if err != nil {
+		return err
+	}
+	for i := range backups {
+		backups[i].SetStatus(userCred, api.DISK_BACKUP_STATUS_DELETING, "")
+	}
+	task.ScheduleRun(nil)
+	return nil
+}
+
+func (self *SDiskBackup) Delete(ctx context.Context, userCred mcclient.TokenCredential) error {
+	return nil
+}
+
+func (self *SDiskBackup) RealDelete(ctx context.Context, userCred mcclient.TokenCredential) error {
+	return db.DeleteModel(ctx, userCred, self)
+}
+
+func (manager *SDiskBackupManager) AllowPerformPrune(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsDomainAllowClassPerform(userCred, manager, "prune")
+}
+
+// 清理磁盘的旧备份链, 仅保留最近的若干条备份链
+func (manager *SDiskBackupManager) PerformPrune(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.DiskBackupPruneInput) (jsonutils.JSONObject, error) {
+	if len(input.Disk) == 0 {
+		return nil, httperrors.NewMissingParameterError("disk")
+	}
+	disk, _, err := ValidateDiskResourceInput(userCred, api.DiskResourceInput{DiskId: input.Disk})
+	if err != nil {
+		return nil, err
+	}
+	if input.KeepChains < 0 {
+		return nil, httperrors.NewInputParameterError("keep_chains must not be negative")
+	}
+	if input.KeepChains == 0 {
+		input.KeepChains = 1
+	}
+
+	lockman.LockClass(ctx, manager, db.GetLockClassKey(manager, disk.GetOwnerId()))
+	defer lockman.ReleaseClass(ctx, manager, db.GetLockClassKey(manager, disk.GetOwnerId()))
+
+	fulls := make([]SDiskBackup, 0)
+	q := manager.Query().Equals("disk_id", disk.Id).Equals("backup_mode", api.DISK_BACKUP_MODE_FULL).
+		Equals("status", api.DISK_BACKUP_STATUS_READY).Desc("created_at")
+	if err := db.FetchModelObjects(manager, q, &fulls); err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+
+	chains := make(map[string][]SDiskBackup)
+	for i := input.KeepChains; i < len(fulls); i++ {
+		chain := make([]SDiskBackup, 0)
+		q := manager.Query().Equals("chain_id", fulls[i].Id)
+		if err := db.FetchModelObjects(manager, q, &chain); err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+		chains[fulls[i].Id] = chain
+	}
+	prunes := choosePruneChains(fulls, chains, input.KeepChains)
+	for _, chain := range prunes {
+		if err := chain[0].StartDiskBackupDeleteTask(ctx, userCred, chain, ""); err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+	}
+	pruned := len(prunes)
+	ret := jsonutils.NewDict()
+	ret.Set("pruned_chains", jsonutils.NewInt(int64(pruned)))
+	return ret, nil
+}
+
+func isDiskBackupBusy(status string) bool {
+	return utils.IsInStringArray(status, []string{api.DISK_BACKUP_STATUS_CREATING, api.DISK_BACKUP_STATUS_RESTORING, api.DISK_BACKUP_STATUS_DELETING})
+}
+
+// choosePruneChains returns chains to delete other than the latest
+// keepChains ones, fulls are full backups ordered by created_at desc and
+// chains are backups of each chain by chain id.  Chains with backups in
+// progress are skipped, the full backup comes first in a returned chain
+func choosePruneChains(fulls []SDiskBackup, chains map[string][]SDiskBackup, keepChains int) [][]SDiskBackup {
+	ret := make([][]SDiskBackup, 0)
+	for i := keepChains; i < len(fulls); i++ {
+		chain := []SDiskBackup{fulls[i]}
+		busy := false
+		for _, backup := range chains[fulls[i].Id] {
+			if isDiskBackupBusy(backup.Status) {
+				busy = true
+				break
+			}
+			if backup.Id != fulls[i].Id {
+				chain = append(chain, backup)
+			}
+		}
+		if busy {
+			log.Infof("backup chain %s of disk %s is busy, skip pruning", fulls[i].Id, fulls[i].DiskId)
+			continue
+		}
+		ret = append(ret, chain)
+	}
+	return ret
+}
@@ -0,0 +1,231 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"database/sql"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func newTestDiskBackup(id, parentId, chainId, status string) SDiskBackup {
+	backup := SDiskBackup{}
+	backup.Id = id
+	backup.Name = id
+	backup.DiskId = "disk1"
+	backup.ParentId = parentId
+	backup.ChainId = chainId
+	backup.Status = status
+	backup.BackupMode = api.DISK_BACKUP_MODE_INCREMENTAL
+	if len(parentId) == 0 {
+		backup.BackupMode = api.DISK_BACKUP_MODE_FULL
+	}
+	return backup
+}
+
+func TestIsValidParentBackup(t *testing.T) {
+	ready := newTestDiskBackup("b1", "", "b1", api.DISK_BACKUP_STATUS_READY)
+	otherDisk := ready
+	otherDisk.DiskId = "disk2"
+	failed := newTestDiskBackup("b2", "b1", "b1", api.DISK_BACKUP_STATUS_CREATE_FAILED)
+	noChain := newTestDiskBackup("b3", "", "", api.DISK_BACKUP_STATUS_READY)
+	cases := []struct {
+		name   string
+		backup SDiskBackup
+		want   bool
+	}{
+		{"ready", ready, true},
+		{"other disk", otherDisk, false},
+		{"failed", failed, false},
+		{"chain not started", noChain, false},
+	}
+	for _, c := range cases {
+		if got := isValidParentBackup("disk1", &c.backup); got != c.want {
+			t.Errorf("%s: want %v got %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestBuildBackupChain(t *testing.T) {
+	type backups []SDiskBackup
+	incrementalRoot := newTestDiskBackup("f1", "", "f1", api.DISK_BACKUP_STATUS_READY)
+	incrementalRoot.BackupMode = api.DISK_BACKUP_MODE_INCREMENTAL
+	cases := []struct {
+		name    string
+		backups backups
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "full only",
+			backups: backups{
+				newTestDiskBackup("f1", "", "f1", api.DISK_BACKUP_STATUS_READY),
+			},
+			want: []string{"f1"},
+		},
+		{
+			name: "incremental chain",
+			backups: backups{
+				newTestDiskBackup("i2", "i1", "f1", api.DISK_BACKUP_STATUS_READY),
+				newTestDiskBackup("i1", "f1", "f1", api.DISK_BACKUP_STATUS_READY),
+				newTestDiskBackup("f1", "", "f1", api.DISK_BACKUP_STATUS_READY),
+			},
+			want: []string{"f1", "i1", "i2"},
+		},
+		{
+			name: "missing parent",
+			backups: backups{
+				newTestDiskBackup("i2", "i1", "f1", api.DISK_BACKUP_STATUS_READY),
+				newTestDiskBackup("f1", "", "f1", api.DISK_BACKUP_STATUS_READY),
+			},
+			wantErr: true,
+		},
+		{
+			name: "parent not ready",
+			backups: backups{
+				newTestDiskBackup("i1", "f1", "f1", api.DISK_BACKUP_STATUS_READY),
+				newTestDiskBackup("f1", "", "f1", api.DISK_BACKUP_STATUS_DELETE_FAILED),
+			},
+			wantErr: true,
+		},
+		{
+			name: "parent in other chain",
+			backups: backups{
+				newTestDiskBackup("i1", "f2", "f1", api.DISK_BACKUP_STATUS_READY),
+				newTestDiskBackup("f2", "", "f2", api.DISK_BACKUP_STATUS_READY),
+			},
+			wantErr: true,
+		},
+		{
+			name: "circular parents",
+			backups: backups{
+				newTestDiskBackup("i2", "i1", "f1", api.DISK_BACKUP_STATUS_READY),
+				newTestDiskBackup("i1", "i2", "f1", api.DISK_BACKUP_STATUS_READY),
+			},
+			wantErr: true,
+		},
+		{
+			name: "root not chain head",
+			backups: backups{
+				newTestDiskBackup("i1", "", "f1", api.DISK_BACKUP_STATUS_READY),
+			},
+			wantErr: true,
+		},
+		{
+			name: "root not full",
+			backups: backups{
+				newTestDiskBackup("i1", "f1", "f1", api.DISK_BACKUP_STATUS_READY),
+				incrementalRoot,
+			},
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		byId := make(map[string]*SDiskBackup)
+		for i := range c.backups {
+			byId[c.backups[i].Id] = &c.backups[i]
+		}
+		fetch := func(id string) (*SDiskBackup, error) {
+			if backup, ok := byId[id]; ok {
+				return backup, nil
+			}
+			return nil, sql.ErrNoRows
+		}
+		chain, err := buildBackupChain(&c.backups[0], fetch)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: want error, got chain of %d", c.name, len(chain))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		got := make([]string, len(chain))
+		for i := range chain {
+			got[i] = chain[i].Id
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("%s: want %v got %v", c.name, c.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("%s: want %v got %v", c.name, c.want, got)
+				break
+			}
+		}
+	}
+}
+
+func TestChoosePruneChains(t *testing.T) {
+	// full backups ordered by created_at desc
+	fulls := []SDiskBackup{
+		newTestDiskBackup("f3", "", "f3", api.DISK_BACKUP_STATUS_READY),
+		newTestDiskBackup("f2", "", "f2", api.DISK_BACKUP_STATUS_READY),
+		newTestDiskBackup("f1", "", "f1", api.DISK_BACKUP_STATUS_READY),
+	}
+	chains := map[string][]SDiskBackup{
+		"f3": {
+			fulls[0],
+			newTestDiskBackup("i31", "f3", "f3", api.DISK_BACKUP_STATUS_CREATING),
+		},
+		"f2": {
+			newTestDiskBackup("i21", "f2", "f2", api.DISK_BACKUP_STATUS_READY),
+			fulls[1],
+			newTestDiskBackup("i22", "i21", "f2", api.DISK_BACKUP_STATUS_READY),
+		},
+		"f1": {
+			fulls[2],
+			newTestDiskBackup("i11", "f1", "f1", api.DISK_BACKUP_STATUS_RESTORING),
+		},
+	}
+	cases := []struct {
+		keep int
+		want [][]string
+	}{
+		{keep: 3, want: [][]string{}},
+		{keep: 2, want: [][]string{}},
+		{keep: 1, want: [][]string{{"f2", "i21", "i22"}}},
+		{keep: 0, want: [][]string{{"f2", "i21", "i22"}}},
+	}
+	for _, c := range cases {
+		prunes := choosePruneChains(fulls, chains, c.keep)
+		got := make([][]string, len(prunes))
+		for i := range prunes {
+			for _, backup := range prunes[i] {
+				got[i] = append(got[i], backup.Id)
+			}
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("keep %d: want %v got %v", c.keep, c.want, got)
+			continue
+		}
+		for i := range got {
+			if len(got[i]) != len(c.want[i]) {
+				t.Errorf("keep %d: want %v got %v", c.keep, c.want, got)
+				break
+			}
+			for j := range got[i] {
+				if got[i][j] != c.want[i][j] {
+					t.Errorf("keep %d: want %v got %v", c.keep, c.want, got)
+					break
+				}
+			}
+		}
+	}
+}
@@ -121,6 +121,9 @@ type SDisk struct {
 
 	// 是否标记为SSD磁盘
 	IsSsd bool `nullable:"false" default:"false" list:"user" update:"user" create:"optional"`
+
+	// 加密密钥ID, 密钥由keystone托管
+	EncryptKeyId string `width:"128" charset:"ascii" nullable:"true" list:"user" json:"encrypt_key_id"`
 }
 
 func (manager *SDiskManager) GetContextManagers() [][]db.IModelManager {
@@ -379,7 +382,11 @@ func (self *SDisk) CustomizeCreate(ctx context.Context, userCred mcclient.TokenC
 		return err
 	}
 	self.fetchDiskInfo(input.DiskConfig)
-	return self.SVirtualResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
+	if err := self.SVirtualResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data); err != nil {
+		return err
+	}
+	// the key is generated last, nothing fails after it before insertion
+	return self.prepareEncryptKey(ctx, input.DiskConfig)
 }
 
 func (self *SDisk) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.DiskUpdateInput) (api.DiskUpdateInput, error) {
@@ -482,6 +489,9 @@ func (manager *SDiskManager) ValidateCreateData(ctx context.Context, userCred mc
 		if err != nil {
 			return input, err
 		}
+		if err := validateDiskEncrypt(diskConfig, input.Hypervisor); err != nil {
+			return input, err
+		}
 		input.Storage = storage.Id
 
 		quotaKey = fetchComputeQuotaKeys(
@@ -514,6 +524,9 @@ func (manager *SDiskManager) ValidateCreateData(ctx context.Context, userCred mc
 			return input, err
 		}
 		input = *serverInput.ToDiskCreateInput()
+		if err := validateDiskEncrypt(input.DiskConfig, input.Hypervisor); err != nil {
+			return input, err
+		}
 		quotaKey = diskCreateInput2ComputeQuotaKeys(input, ownerId)
 	}
 
@@ -707,15 +720,9 @@ func (self *SDisk) StartAllocate(ctx context.Context, host *SHost, storage *SSto
 	}
 	if len(fsFormat) > 0 {
 		content.Add(jsonutils.NewString(fsFormat), "fs_format")
-		if fsFormat == "ext4" {
-			name := strings.ToLower(self.GetName())
-			for _, key := range []string{"encrypt", "secret", "cipher", "private"} {
-				if strings.Index(key, name) > 0 {
-					content.Add(jsonutils.JSONTrue, "encryption")
-					break
-				}
-			}
-		}
+	}
+	if len(self.EncryptKeyId) > 0 {
+		content.Add(jsonutils.NewString(self.EncryptKeyId), "encrypt_key_id")
 	}
 	if rebuild {
 		return host.GetHostDriver().RequestRebuildDiskOnStorage(ctx, host, storage, self, task, content)
@@ -985,6 +992,10 @@ func (self *SDisk) GetZone() *SZone {
 }
 
 func (self *SDisk) PrepareSaveImage(ctx context.Context, userCred mcclient.TokenCredential, input api.ServerSaveImageInput) (string, error) {
+	if self.IsEncrypted() {
+		// the image would be sealed by a key other users can't get
+		return "", httperrors.NewNotSupportedError("Cannot save encrypted disk %s as image", self.Name)
+	}
 	zone := self.GetZone()
 	if zone == nil {
 		return "", httperrors.NewResourceNotFoundError("No zone for this disk")
@@ -1673,6 +1684,8 @@ func totalDiskSize(
 }
 
 func parseDiskInfo(ctx context.Context, userCred mcclient.TokenCredential, info *api.DiskConfig) (*api.DiskConfig, error) {
+	// encrypt key is only inherited from snapshot or generated on creation
+	info.EncryptKeyId = ""
 	if info.SnapshotId != "" {
 		if err := fillDiskConfigBySnapshot(userCred, info, info.SnapshotId); err != nil {
 			return nil, err
@@ -1719,6 +1732,25 @@ func fillDiskConfigBySnapshot(userCred mcclient.TokenCredential, diskConfig *api
 		diskConfig.Fs = ""
 		diskConfig.Mountpoint = ""
 		diskConfig.OsArch = snapshot.OsArch
+		if len(snapshot.EncryptKeyId) > 0 {
+			diskConfig.Encrypt = true
+			diskConfig.EncryptKeyId = snapshot.EncryptKeyId
+		}
+	}
+	return nil
+}
+
+// validateDiskEncrypt checks the disk could be encrypted, only kvm guests
+// are able to open LUKS encrypted images of local and rbd storages
+func validateDiskEncrypt(diskConfig *api.DiskConfig, hypervisor string) error {
+	if !diskConfig.Encrypt {
+		return nil
+	}
+	if len(hypervisor) > 0 && hypervisor != api.HYPERVISOR_KVM {
+		return httperrors.NewNotSupportedError("hypervisor %s does not support disk encryption", hypervisor)
+	}
+	if !utils.IsInStringArray(diskConfig.Backend, []string{api.STORAGE_LOCAL, api.STORAGE_RBD}) {
+		return httperrors.NewNotSupportedError("storage %s does not support disk encryption", diskConfig.Backend)
 	}
 	return nil
 }
@@ -1803,6 +1835,52 @@ func (self *SDisk) fetchDiskInfo(diskConfig *api.DiskConfig) {
 	self.DiskFormat = diskConfig.Format
 	self.DiskSize = diskConfig.SizeMb
 	self.OsArch = diskConfig.OsArch
+	self.EncryptKeyId = diskConfig.EncryptKeyId
+}
+
+// prepareEncryptKey generates the data key of a new encrypted disk, the key
+// is held by keystone and fetched by host when the disk is opened
+func (self *SDisk) prepareEncryptKey(ctx context.Context, diskConfig *api.DiskConfig) error {
+	if !diskConfig.Encrypt || len(self.EncryptKeyId) > 0 {
+		return nil
+	}
+	s := auth.GetAdminSession(ctx, options.Options.Region, "")
+	key, err := modules.Credentials.CreateEncryptKey(s, self.Name)
+	if err != nil {
+		return errors.Wrap(err, "create encrypt key")
+	}
+	self.EncryptKeyId = key.KeyId
+	return nil
+}
+
+// releaseEncryptKey deletes the data key from keystone once no disk or
+// snapshot refers to it any longer
+func releaseEncryptKey(ctx context.Context, keyId string) {
+	if len(keyId) == 0 {
+		return
+	}
+	for _, q := range []*sqlchemy.SQuery{
+		DiskManager.Query().Equals("encrypt_key_id", keyId),
+		SnapshotManager.Query().Equals("encrypt_key_id", keyId),
+	} {
+		cnt, err := q.CountWithError()
+		if err != nil {
+			log.Errorf("count references of encrypt key %s fail %s", keyId, err)
+			return
+		}
+		if cnt > 0 {
+			return
+		}
+	}
+	s := auth.GetAdminSession(ctx, options.Options.Region, "")
+	_, err := modules.Credentials.Delete(s, keyId, nil)
+	if err != nil {
+		log.Errorf("delete encrypt key %s fail %s", keyId, err)
+	}
+}
+
+func (self *SDisk) IsEncrypted() bool {
+	return len(self.EncryptKeyId) > 0
 }
 
 type DiskInfo struct {
@@ -1870,7 +1948,12 @@ func (self *SDisk) RealDelete(ctx context.Context, userCred mcclient.TokenCreden
 	if err != nil {
 		log.Errorf("unable to DetachAllSnapshotpolicies: %v", err)
 	}
-	return self.SVirtualResourceBase.Delete(ctx, userCred)
+	err = self.SVirtualResourceBase.Delete(ctx, userCred)
+	if err != nil {
+		return err
+	}
+	releaseEncryptKey(ctx, self.EncryptKeyId)
+	return nil
 }
 
 func (self *SDisk) AllowPerformSyncstatus(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
@@ -2343,7 +2426,11 @@ func (self *SDisk) CreateSnapshotAuto(
 	}
 
 	db.OpsLog.LogEvent(snap, db.ACT_CREATE, "disk create snapshot auto", userCred)
-	err = snap.StartSnapshotCreateTask(ctx, userCred, nil, "")
+	params := jsonutils.NewDict()
+	if snapshotPolicy.Quiesce {
+		params.Set("quiesce", jsonutils.JSONTrue)
+	}
+	err = snap.StartSnapshotCreateTask(ctx, userCred, params, "")
 	if err != nil {
 		return errors.Wrap(err, "disk auto snapshot start snapshot task")
 	}
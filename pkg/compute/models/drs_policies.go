@@ -0,0 +1,522 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SDrsPolicyManager struct {
+	db.SEnabledStatusStandaloneResourceBaseManager
+	SZoneResourceBaseManager
+}
+
+var DrsPolicyManager *SDrsPolicyManager
+
+func init() {
+	DrsPolicyManager = &SDrsPolicyManager{
+		SEnabledStatusStandaloneResourceBaseManager: db.NewEnabledStatusStandaloneResourceBaseManager(
+			SDrsPolicy{},
+			"drs_policies_tbl",
+			"drs_policy",
+			"drs_policies",
+		),
+	}
+	DrsPolicyManager.SetVirtualObject(DrsPolicyManager)
+}
+
+// SDrsPolicy describes a group of kvm hosts, either in a zone or tagged by
+// a schedtag, whose load is periodically rebalanced by live migrating guests
+type SDrsPolicy struct {
+	db.SEnabledStatusStandaloneResourceBase
+	SZoneResourceBase
+
+	// 平衡范围内宿主机的调度标签Id
+	SchedtagId string `width:"36" charset:"ascii" nullable:"true" list:"admin" create:"admin_optional"`
+	// 运行模式
+	// enum: manual, auto
+	Mode string `width:"16" charset:"ascii" nullable:"false" default:"manual" list:"admin" create:"admin_optional" update:"admin"`
+	// 可以容忍的宿主机负载标准差, 单位%
+	Threshold int `nullable:"false" default:"10" list:"admin" create:"admin_optional" update:"admin"`
+	// 每轮最多迁移的虚拟机数量
+	MaxMigrations int `nullable:"false" default:"2" list:"admin" create:"admin_optional" update:"admin"`
+	// 维护窗口, 格式 HH:MM
+	WindowStart string `width:"5" charset:"ascii" nullable:"true" list:"admin" create:"admin_optional" update:"admin"`
+	WindowEnd   string `width:"5" charset:"ascii" nullable:"true" list:"admin" create:"admin_optional" update:"admin"`
+
+	// 上次评估时间
+	LastEvaluatedAt time.Time `nullable:"true" list:"admin"`
+	// 上次评估得出的迁移计划
+	LastPlan jsonutils.JSONObject `nullable:"true" list:"admin"`
+}
+
+func parseWindowTime(str string) (int, error) {
+	t, err := time.Parse("15:04", str)
+	if err != nil {
+		return 0, httperrors.NewInputParameterError("invalid time %s, should be HH:MM", str)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func validateDrsWindow(start, end string) error {
+	if len(start) == 0 && len(end) == 0 {
+		return nil
+	}
+	if len(start) == 0 || len(end) == 0 {
+		return httperrors.NewInputParameterError("window_start and window_end should be set together")
+	}
+	if _, err := parseWindowTime(start); err != nil {
+		return err
+	}
+	if _, err := parseWindowTime(end); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateDrsMode(mode string) error {
+	if !utils.IsInStringArray(mode, []string{api.DRS_MODE_MANUAL, api.DRS_MODE_AUTO}) {
+		return httperrors.NewInputParameterError("invalid mode %s", mode)
+	}
+	return nil
+}
+
+func (manager *SDrsPolicyManager) ValidateCreateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	query jsonutils.JSONObject,
+	input api.DrsPolicyCreateInput,
+) (api.DrsPolicyCreateInput, error) {
+	var err error
+	if len(input.Schedtag) > 0 {
+		input.SchedtagId = input.Schedtag
+	}
+	if len(input.ZoneId) > 0 && len(input.SchedtagId) > 0 {
+		return input, httperrors.NewConflictError("zone_id and schedtag are mutually exclusive")
+	}
+	if len(input.ZoneId) > 0 {
+		_, input.ZoneResourceInput, err = ValidateZoneResourceInput(userCred, input.ZoneResourceInput)
+		if err != nil {
+			return input, err
+		}
+	} else if len(input.SchedtagId) > 0 {
+		tag, _, err := ValidateSchedtagResourceInput(userCred, api.SchedtagResourceInput{SchedtagId: input.SchedtagId})
+		if err != nil {
+			return input, err
+		}
+		if tag.ResourceType != HostManager.KeywordPlural() {
+			return input, httperrors.NewInputParameterError("schedtag %s is not for hosts", tag.Name)
+		}
+		input.SchedtagId = tag.Id
+	} else {
+		return input, httperrors.NewMissingParameterError("zone_id or schedtag")
+	}
+
+	if len(input.Mode) == 0 {
+		input.Mode = api.DRS_MODE_MANUAL
+	}
+	if err := validateDrsMode(input.Mode); err != nil {
+		return input, err
+	}
+	if input.Threshold == 0 {
+		input.Threshold = 10
+	}
+	if input.Threshold < 0 || input.Threshold > 100 {
+		return input, httperrors.NewOutOfRangeError("threshold should be in range 0-100")
+	}
+	if input.MaxMigrations == 0 {
+		input.MaxMigrations = 2
+	}
+	if input.MaxMigrations < 0 {
+		return input, httperrors.NewOutOfRangeError("max_migrations should be positive")
+	}
+	if err := validateDrsWindow(input.WindowStart, input.WindowEnd); err != nil {
+		return input, err
+	}
+
+	input.Status = api.DRS_POLICY_STATUS_READY
+	input.EnabledStatusStandaloneResourceCreateInput, err = manager.SEnabledStatusStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.EnabledStatusStandaloneResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (self *SDrsPolicy) ValidateUpdateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.DrsPolicyUpdateInput,
+) (api.DrsPolicyUpdateInput, error) {
+	var err error
+	if len(input.Mode) > 0 {
+		if err := validateDrsMode(input.Mode); err != nil {
+			return input, err
+		}
+	}
+	if input.Threshold != nil && (*input.Threshold <= 0 || *input.Threshold > 100) {
+		return input, httperrors.NewOutOfRangeError("threshold should be in range 1-100")
+	}
+	if input.MaxMigrations != nil && *input.MaxMigrations <= 0 {
+		return input, httperrors.NewOutOfRangeError("max_migrations should be positive")
+	}
+	if len(input.WindowStart) > 0 || len(input.WindowEnd) > 0 {
+		start, end := input.WindowStart, input.WindowEnd
+		if len(start) == 0 {
+			start = self.WindowStart
+		}
+		if len(end) == 0 {
+			end = self.WindowEnd
+		}
+		if err := validateDrsWindow(start, end); err != nil {
+			return input, err
+		}
+	}
+	input.EnabledStatusStandaloneResourceBaseUpdateInput, err = self.SEnabledStatusStandaloneResourceBase.ValidateUpdateData(ctx, userCred, query, input.EnabledStatusStandaloneResourceBaseUpdateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SEnabledStatusStandaloneResourceBase.ValidateUpdateData")
+	}
+	return input, nil
+}
+
+// DRS策略列表
+func (manager *SDrsPolicyManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.DrsPolicyListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, query.EnabledStatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusStandaloneResourceBaseManager.ListItemFilter")
+	}
+	if len(query.ZoneId) > 0 {
+		zone, _, err := ValidateZoneResourceInput(userCred, query.ZoneResourceInput)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Equals("zone_id", zone.Id)
+	}
+	if len(query.SchedtagId) > 0 {
+		tag, _, err := ValidateSchedtagResourceInput(userCred, api.SchedtagResourceInput{SchedtagId: query.SchedtagId})
+		if err != nil {
+			return nil, err
+		}
+		q = q.Equals("schedtag_id", tag.Id)
+	}
+	if len(query.Mode) > 0 {
+		q = q.Equals("mode", query.Mode)
+	}
+	return q, nil
+}
+
+func (manager *SDrsPolicyManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.DrsPolicyListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.EnabledStatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusStandaloneResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SDrsPolicyManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusStandaloneResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SDrsPolicyManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.DrsPolicyDetails {
+	rows := make([]api.DrsPolicyDetails, len(objs))
+
+	stdRows := manager.SEnabledStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	zoneRows := manager.SZoneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+
+	tagIds := make([]string, len(objs))
+	for i := range rows {
+		rows[i] = api.DrsPolicyDetails{
+			EnabledStatusStandaloneResourceDetails: stdRows[i],
+			ZoneResourceInfoBase:                   zoneRows[i].ZoneResourceInfoBase,
+		}
+		policy := objs[i].(*SDrsPolicy)
+		tagIds[i] = policy.SchedtagId
+		rows[i].HostCount, _ = policy.GetHostsQuery().CountWithError()
+	}
+
+	tags := make(map[string]SSchedtag)
+	err := db.FetchStandaloneObjectsByIds(SchedtagManager, tagIds, &tags)
+	if err != nil {
+		log.Errorf("FetchStandaloneObjectsByIds fail %s", err)
+		return rows
+	}
+	for i := range rows {
+		if tag, ok := tags[tagIds[i]]; ok {
+			rows[i].Schedtag = tag.Name
+		}
+	}
+	return rows
+}
+
+func (self *SDrsPolicy) GetExtraDetails(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	isList bool,
+) (api.DrsPolicyDetails, error) {
+	return api.DrsPolicyDetails{}, nil
+}
+
+// GetHostsQuery returns online and enabled kvm hosts within the policy
+func (self *SDrsPolicy) GetHostsQuery() *sqlchemy.SQuery {
+	q := HostManager.Query().Equals("host_type", api.HOST_TYPE_HYPERVISOR).
+		Equals("host_status", api.HOST_ONLINE).IsTrue("enabled")
+	if len(self.ZoneId) > 0 {
+		q = q.Equals("zone_id", self.ZoneId)
+	} else {
+		sq := HostschedtagManager.Query("host_id").Equals("schedtag_id", self.SchedtagId).SubQuery()
+		q = q.In("id", sq)
+	}
+	return q
+}
+
+func (self *SDrsPolicy) GetHosts() ([]SHost, error) {
+	hosts := []SHost{}
+	err := db.FetchModelObjects(HostManager, self.GetHostsQuery(), &hosts)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	return hosts, nil
+}
+
+// InMaintenanceWindow tells whether migrations could be executed at t,
+// window crossing midnight is supported
+func (self *SDrsPolicy) InMaintenanceWindow(t time.Time) bool {
+	if len(self.WindowStart) == 0 || len(self.WindowEnd) == 0 {
+		return true
+	}
+	start, err := parseWindowTime(self.WindowStart)
+	if err != nil {
+		return false
+	}
+	end, err := parseWindowTime(self.WindowEnd)
+	if err != nil {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+	if start <= end {
+		return now >= start && now < end
+	}
+	return now >= start || now < end
+}
+
+func (self *SDrsPolicy) GetPlan() (*api.DrsPlan, error) {
+	if self.LastPlan == nil {
+		return nil, nil
+	}
+	plan := &api.DrsPlan{}
+	if err := self.LastPlan.Unmarshal(plan); err != nil {
+		return nil, errors.Wrap(err, "unmarshal plan")
+	}
+	return plan, nil
+}
+
+// SavePlan records plan of an evaluation, plans with migrations are
+// kept in opslog as audit trail
+func (self *SDrsPolicy) SavePlan(ctx context.Context, userCred mcclient.TokenCredential, plan *api.DrsPlan) error {
+	_, err := db.Update(self, func() error {
+		self.LastEvaluatedAt = time.Now()
+		if plan != nil {
+			self.LastPlan = jsonutils.Marshal(plan)
+		} else {
+			self.LastPlan = nil
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "db.Update")
+	}
+	if plan != nil && len(plan.Migrations) > 0 {
+		db.OpsLog.LogEvent(self, db.ACT_DRS_PLAN, plan, userCred)
+	}
+	return nil
+}
+
+// drsHostHasRoom tells whether a host with capacity cpu/mem, of which used
+// is committed already, could take another guest of ncpu/memMb
+func drsHostHasRoom(cpu, mem float32, used SHostGuestResourceUsage, ncpu, memMb int) bool {
+	if cpu > 0 && float32(used.GuestVcpuCount+ncpu) > cpu {
+		return false
+	}
+	if mem > 0 && float32(used.GuestVmemSize+memMb) > mem {
+		return false
+	}
+	return true
+}
+
+// ApplyPlan starts live migrations of plan, steps whose guest has left
+// source host or is no longer running, or whose destination host has no
+// room any more, are skipped. Steps not reached due to limit are kept as
+// the plan of policy, the plan is cleared once all steps are consumed.
+func (self *SDrsPolicy) ApplyPlan(ctx context.Context, userCred mcclient.TokenCredential, plan *api.DrsPlan, limit int) (int, error) {
+	started := 0
+	// resources moving into destination hosts by this apply
+	incoming := make(map[string]*SHostGuestResourceUsage)
+	i := 0
+	for ; i < len(plan.Migrations); i++ {
+		if limit >= 0 && started >= limit {
+			break
+		}
+		m := plan.Migrations[i]
+		obj, err := GuestManager.FetchById(m.GuestId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				continue
+			}
+			return started, errors.Wrapf(err, "fetch guest %s", m.GuestId)
+		}
+		guest := obj.(*SGuest)
+		if guest.HostId != m.SrcHostId || guest.Status != api.VM_RUNNING {
+			log.Infof("drs policy %s skip migrating guest %s in status %s on host %s", self.Name, guest.Name, guest.Status, guest.HostId)
+			continue
+		}
+		dest, err := self.checkDestHost(m.DestHostId, guest, incoming[m.DestHostId])
+		if err != nil {
+			db.OpsLog.LogEvent(self, db.ACT_DRS_MIGRATE, fmt.Sprintf("skip migrating guest %s to %s: %v", guest.Name, m.DestHost, err), userCred)
+			continue
+		}
+		err = guest.startLiveMigrate(ctx, userCred, api.GuestLiveMigrateInput{PreferHost: m.DestHostId})
+		if err != nil {
+			db.OpsLog.LogEvent(self, db.ACT_DRS_MIGRATE, fmt.Sprintf("migrate guest %s to %s: %v", guest.Name, m.DestHost, err), userCred)
+			continue
+		}
+		if _, ok := incoming[dest.Id]; !ok {
+			incoming[dest.Id] = &SHostGuestResourceUsage{}
+		}
+		incoming[dest.Id].GuestCount++
+		incoming[dest.Id].GuestVcpuCount += guest.VcpuCount
+		incoming[dest.Id].GuestVmemSize += guest.VmemSize
+		notes := fmt.Sprintf("migrate guest %s from %s to %s", guest.Name, m.SrcHost, m.DestHost)
+		db.OpsLog.LogEvent(self, db.ACT_DRS_MIGRATE, notes, userCred)
+		db.OpsLog.LogEvent(guest, db.ACT_DRS_MIGRATE, fmt.Sprintf("drs policy %s: %s", self.Name, notes), userCred)
+		started++
+	}
+	_, err := db.Update(self, func() error {
+		if i < len(plan.Migrations) {
+			rest := *plan
+			rest.Migrations = plan.Migrations[i:]
+			self.LastPlan = jsonutils.Marshal(&rest)
+		} else {
+			self.LastPlan = nil
+		}
+		return nil
+	})
+	if err != nil {
+		return started, errors.Wrap(err, "update plan")
+	}
+	return started, nil
+}
+
+// checkDestHost makes sure destination host of a step is still enabled and
+// has room for guest, as a manually applied plan may be evaluated long ago
+func (self *SDrsPolicy) checkDestHost(hostId string, guest *SGuest, incoming *SHostGuestResourceUsage) (*SHost, error) {
+	obj, err := HostManager.FetchById(hostId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch host %s", hostId)
+	}
+	host := obj.(*SHost)
+	if !host.GetEnabled() || host.HostStatus != api.HOST_ONLINE {
+		return nil, fmt.Errorf("host %s is not enabled or online", host.Name)
+	}
+	used := host.getGuestsResource(api.VM_RUNNING)
+	if used == nil {
+		return nil, fmt.Errorf("query resource usage of host %s failed", host.Name)
+	}
+	if incoming != nil {
+		used.GuestVcpuCount += incoming.GuestVcpuCount
+		used.GuestVmemSize += incoming.GuestVmemSize
+	}
+	if !drsHostHasRoom(host.GetVirtualCPUCount(), host.GetVirtualMemorySize(), *used, guest.VcpuCount, guest.VmemSize) {
+		return nil, fmt.Errorf("host %s has no room for %d cpu %dMB memory", host.Name, guest.VcpuCount, guest.VmemSize)
+	}
+	return host, nil
+}
+
+// GetInflightMigrationCount returns count of guests being migrated on the
+// hosts of policy
+func (self *SDrsPolicy) GetInflightMigrationCount() (int, error) {
+	hosts := self.GetHostsQuery().SubQuery()
+	q := GuestManager.Query().In("host_id", hosts.Query(hosts.Field("id")).SubQuery()).
+		In("status", []string{api.VM_START_MIGRATE, api.VM_MIGRATING})
+	return q.CountWithError()
+}
+
+func (self *SDrsPolicy) AllowPerformApply(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.DrsPolicyApplyInput) bool {
+	return db.IsAdminAllowPerform(userCred, self, "apply")
+}
+
+// 执行上次评估得出的迁移计划
+func (self *SDrsPolicy) PerformApply(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.DrsPolicyApplyInput) (jsonutils.JSONObject, error) {
+	plan, err := self.GetPlan()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	if plan == nil || len(plan.Migrations) == 0 {
+		return nil, httperrors.NewBadRequestError("no migration plan to apply")
+	}
+	inflight, err := self.GetInflightMigrationCount()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	limit := options.Options.DrsMaxConcurrentMigrations - inflight
+	if limit <= 0 {
+		return nil, httperrors.NewConflictError("%d migrations in progress, exceeds limit %d", inflight, options.Options.DrsMaxConcurrentMigrations)
+	}
+	started, err := self.ApplyPlan(ctx, userCred, plan, limit)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	ret := jsonutils.NewDict()
+	ret.Set("migrations", jsonutils.NewInt(int64(started)))
+	return ret, nil
+}
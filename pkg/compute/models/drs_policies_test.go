@@ -0,0 +1,66 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDrsPolicyInMaintenanceWindow(t *testing.T) {
+	at := func(hhmm string) time.Time {
+		tm, _ := time.Parse("15:04", hhmm)
+		return tm
+	}
+	cases := []struct {
+		start, end string
+		now        string
+		want       bool
+	}{
+		{"", "", "12:00", true},
+		{"01:00", "05:00", "03:30", true},
+		{"01:00", "05:00", "05:00", false},
+		{"01:00", "05:00", "00:59", false},
+		{"22:00", "02:00", "23:00", true},
+		{"22:00", "02:00", "01:59", true},
+		{"22:00", "02:00", "12:00", false},
+	}
+	for _, c := range cases {
+		p := &SDrsPolicy{WindowStart: c.start, WindowEnd: c.end}
+		if got := p.InMaintenanceWindow(at(c.now)); got != c.want {
+			t.Errorf("window %s-%s at %s: want %v, got %v", c.start, c.end, c.now, c.want, got)
+		}
+	}
+}
+
+func TestDrsHostHasRoom(t *testing.T) {
+	cases := []struct {
+		cpu, mem    float32
+		used        SHostGuestResourceUsage
+		ncpu, memMb int
+		want        bool
+	}{
+		{16, 32768, SHostGuestResourceUsage{GuestVcpuCount: 8, GuestVmemSize: 16384}, 4, 8192, true},
+		{16, 32768, SHostGuestResourceUsage{GuestVcpuCount: 12, GuestVmemSize: 16384}, 4, 8192, true},
+		{16, 32768, SHostGuestResourceUsage{GuestVcpuCount: 14, GuestVmemSize: 16384}, 4, 8192, false},
+		{16, 32768, SHostGuestResourceUsage{GuestVcpuCount: 8, GuestVmemSize: 30720}, 4, 8192, false},
+		{0, 0, SHostGuestResourceUsage{GuestVcpuCount: 64, GuestVmemSize: 65536}, 4, 8192, true},
+	}
+	for i, c := range cases {
+		if got := drsHostHasRoom(c.cpu, c.mem, c.used, c.ncpu, c.memMb); got != c.want {
+			t.Errorf("case %d: want %v, got %v", i, c.want, got)
+		}
+	}
+}
@@ -85,7 +85,7 @@ type SElasticip struct {
 	Mode string `width:"32" charset:"ascii" get:"user" list:"user" create:"optional"`
 
 	// IP地址
-	IpAddr string `width:"17" charset:"ascii" list:"user"`
+	IpAddr string `width:"64" charset:"ascii" list:"user"`
 
 	// 绑定资源类型
 	AssociateType string `width:"32" charset:"ascii" list:"user"`
@@ -1285,23 +1285,34 @@ func (manager *SElasticipManager) NewEipForVMOnHost(ctx context.Context, userCre
 		eip.Name = fmt.Sprintf("eip-for-%s", nat.GetName())
 	}
 
-	if host != nil && host.ManagerId == "" { // kvm
+	if (host != nil || nat != nil) && eip.ManagerId == "" { // kvm
 
-		hostq := HostManager.Query().SubQuery()
-		wireq := WireManager.Query().SubQuery()
-		hostwireq := HostwireManager.Query().SubQuery()
 		scope := policy.PolicyManager.AllowScope(userCred, consts.GetServiceType(), NetworkManager.KeywordPlural(), policy.PolicyActionList)
 		q := NetworkManager.Query()
 		q = NetworkManager.FilterByOwner(q, userCred, scope)
+		wireq := WireManager.Query().SubQuery()
 		q = q.Join(wireq, sqlchemy.Equals(wireq.Field("id"), q.Field("wire_id")))
-		q = q.Join(hostwireq, sqlchemy.Equals(hostwireq.Field("wire_id"), wireq.Field("id")))
-		q = q.Join(hostq, sqlchemy.Equals(hostq.Field("id"), host.Id))
+		if host != nil {
+			hostq := HostManager.Query().SubQuery()
+			hostwireq := HostwireManager.Query().SubQuery()
+			q = q.Join(hostwireq, sqlchemy.Equals(hostwireq.Field("wire_id"), wireq.Field("id")))
+			q = q.Join(hostq, sqlchemy.Equals(hostq.Field("id"), host.Id))
+		} else {
+			// eip of nat gateway is not bound to any host, any eip network
+			// of the region will do
+			vpcq := VpcManager.Query().SubQuery()
+			q = q.Join(vpcq, sqlchemy.Equals(vpcq.Field("id"), wireq.Field("vpc_id")))
+			q = q.Filter(sqlchemy.Equals(vpcq.Field("cloudregion_id"), region.Id))
+		}
 		q = q.Equals("server_type", api.NETWORK_TYPE_EIP)
 		q = q.Equals("bgp_type", bgpType)
 		var nets []SNetwork
 		if err := db.FetchModelObjects(NetworkManager, q, &nets); err != nil {
-			return nil, errors.Wrapf(err, "fetch eip networks usable in host %s(%s)",
-				host.Name, host.Id)
+			if host != nil {
+				return nil, errors.Wrapf(err, "fetch eip networks usable in host %s(%s)",
+					host.Name, host.Id)
+			}
+			return nil, errors.Wrap(err, "fetch eip networks")
 		}
 		var net *SNetwork
 		for i := range nets {
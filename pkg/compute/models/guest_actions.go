@@ -255,6 +255,9 @@ func (self *SGuest) PerformSaveGuestImage(ctx context.Context, userCred mcclient
 	images := jsonutils.NewArray()
 	diskList := append(disks.Data, disks.Root)
 	for _, disk := range diskList {
+		if disk.IsEncrypted() {
+			return nil, httperrors.NewNotSupportedError("Cannot save encrypted disk %s as image", disk.Name)
+		}
 		params := jsonutils.NewDict()
 		params.Add(jsonutils.NewString(disk.DiskFormat), "disk_format")
 		params.Add(jsonutils.NewInt(int64(disk.DiskSize)), "virtual_size")
@@ -418,26 +421,31 @@ func (self *SGuest) AllowPerformLiveMigrate(ctx context.Context, userCred mcclie
 }
 
 func (self *SGuest) PerformLiveMigrate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.GuestLiveMigrateInput) (jsonutils.JSONObject, error) {
+	return nil, self.startLiveMigrate(ctx, userCred, input)
+}
+
+// startLiveMigrate validates input against driver and guest status before
+// starting the live migrate task
+func (self *SGuest) startLiveMigrate(ctx context.Context, userCred mcclient.TokenCredential, input api.GuestLiveMigrateInput) error {
 	if !self.GetDriver().IsSupportLiveMigrate() {
-		return nil, httperrors.NewNotAcceptableError("Not allow for hypervisor %s", self.GetHypervisor())
+		return httperrors.NewNotAcceptableError("Not allow for hypervisor %s", self.GetHypervisor())
 	}
 	if err := self.GetDriver().CheckLiveMigrate(self, userCred, input); err != nil {
-		return nil, err
+		return err
 	}
 	if utils.IsInStringArray(self.Status, []string{api.VM_RUNNING, api.VM_SUSPEND}) {
 		var preferHostId string
 		if len(input.PreferHost) > 0 {
 			iHost, _ := HostManager.FetchByIdOrName(userCred, input.PreferHost)
 			if iHost == nil {
-				return nil, httperrors.NewBadRequestError("Host %s not found", input.PreferHost)
+				return httperrors.NewBadRequestError("Host %s not found", input.PreferHost)
 			}
 			host := iHost.(*SHost)
 			preferHostId = host.Id
 		}
-		err := self.StartGuestLiveMigrateTask(ctx, userCred, self.Status, preferHostId, input.SkipCpuCheck, "")
-		return nil, err
+		return self.StartGuestLiveMigrateTask(ctx, userCred, self.Status, preferHostId, input.SkipCpuCheck, "")
 	}
-	return nil, httperrors.NewBadRequestError("Cannot live migrate in status %s", self.Status)
+	return httperrors.NewBadRequestError("Cannot live migrate in status %s", self.Status)
 }
 
 func (self *SGuest) StartGuestLiveMigrateTask(ctx context.Context, userCred mcclient.TokenCredential, guestStatus, preferHostId string, skipCpuCheck *bool, parentTaskId string) error {
@@ -632,6 +640,11 @@ func (self *SGuest) PerformDeploy(ctx context.Context, userCred mcclient.TokenCr
 		kwargs.Set("reset_password", jsonutils.JSONFalse)
 	}
 
+	// 运行中的虚拟机优先通过qemu-guest-agent在线重置密码, 无需重启
+	if resetPasswd && self.tryResetPasswordByQga(ctx, userCred, kwargs) {
+		return nil, nil
+	}
+
 	// 变更密码/密钥时需要Restart才能生效。更新普通字段不需要Restart, Azure需要在运行状态下操作
 	doRestart := false
 	if resetPasswd {
@@ -1664,6 +1677,10 @@ func (self *SGuest) PerformCreatedisk(ctx context.Context, userCred mcclient.Tok
 		if len(diskInfo.Backend) == 0 {
 			diskInfo.Backend = self.getDefaultStorageType()
 		}
+		if err := validateDiskEncrypt(diskInfo, self.Hypervisor); err != nil {
+			logclient.AddActionLogWithContext(ctx, self, logclient.ACT_CREATE, err.Error(), userCred, false)
+			return nil, err
+		}
 		disksConf = append(disksConf, diskInfo)
 		if _, ok := diskSizes[diskInfo.Backend]; !ok {
 			diskSizes[diskInfo.Backend] = diskInfo.SizeMb
@@ -4669,6 +4686,12 @@ func (self *SGuest) validateCreateInstanceSnapshot(
 		return nil, httperrors.NewInvalidStatusError("guest can't do snapshot in status %s", self.Status)
 	}
 
+	for _, guestDisk := range self.GetDisks() {
+		if storage := guestDisk.GetDisk().GetStorage(); storage != nil && storage.IsLvmThick() {
+			return nil, httperrors.NewBadRequestError("Can't do snapshot of disks on lvm storage %s without thin pool", storage.Name)
+		}
+	}
+
 	var name string
 	ownerId := self.GetOwnerId()
 	dataDict := data.(*jsonutils.JSONDict)
@@ -4737,7 +4760,8 @@ func (self *SGuest) PerformInstanceSnapshot(
 			ctx, userCred, pendingUsage, pendingUsage, false)
 		return nil, httperrors.NewInternalServerError("create instance snapshot failed: %s", err)
 	}
-	err = self.InstaceCreateSnapshot(ctx, userCred, instanceSnapshot, pendingUsage)
+	quiesce := jsonutils.QueryBoolean(data, "quiesce", false)
+	err = self.InstaceCreateSnapshot(ctx, userCred, instanceSnapshot, pendingUsage, quiesce)
 	if err != nil {
 		quotas.CancelPendingUsage(
 			ctx, userCred, pendingUsage, pendingUsage, false)
@@ -4751,9 +4775,11 @@ func (self *SGuest) InstaceCreateSnapshot(
 	userCred mcclient.TokenCredential,
 	instanceSnapshot *SInstanceSnapshot,
 	pendingUsage *SRegionQuota,
+	quiesce bool,
 ) error {
+	quiesce = quiesce && self.isQuiesceSnapshotNeeded()
 	self.SetStatus(userCred, api.VM_START_INSTANCE_SNAPSHOT, "instance snapshot")
-	return instanceSnapshot.StartCreateInstanceSnapshotTask(ctx, userCred, pendingUsage, "")
+	return instanceSnapshot.StartCreateInstanceSnapshotTask(ctx, userCred, pendingUsage, quiesce, "")
 }
 
 func (self *SGuest) AllowPerformInstanceSnapshotReset(ctx context.Context,
@@ -4898,6 +4924,9 @@ func (self *SGuest) StartInstanceSnapshotAndCloneTask(
 
 	params := jsonutils.NewDict()
 	params.Set("guest_params", data)
+	if jsonutils.QueryBoolean(data, "quiesce", false) && self.isQuiesceSnapshotNeeded() {
+		params.Set("quiesce", jsonutils.JSONTrue)
+	}
 	if task, err := taskman.TaskManager.NewTask(
 		ctx, "InstanceSnapshotAndCloneTask", instanceSnapshot, userCred, params, "", "", pendingUsage, pendingRegionUsage); err != nil {
 		return err
@@ -0,0 +1,275 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	hostapi "yunion.io/x/onecloud/pkg/apis/host"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/seclib2"
+)
+
+const (
+	// seconds to wait guest agent before falling back to offline operations
+	QGA_PING_TIMEOUT = 3
+)
+
+func (self *SGuest) validateQgaStatus() error {
+	if self.Hypervisor != api.HYPERVISOR_KVM {
+		return httperrors.NewNotSupportedError("guest agent is not supported by %s", self.Hypervisor)
+	}
+	if self.Status != api.VM_RUNNING {
+		return httperrors.NewInvalidStatusError("cannot talk to guest agent in status %s", self.Status)
+	}
+	return nil
+}
+
+// IsGuestAgentResponsive returns true if qemu-ga inside guest is running
+// and answers in time
+func (self *SGuest) IsGuestAgentResponsive(ctx context.Context, userCred mcclient.TokenCredential) bool {
+	if err := self.validateQgaStatus(); err != nil {
+		return false
+	}
+	err := self.GetDriver().RequestQgaPing(ctx, userCred, self, &hostapi.GuestQgaPingRequest{Timeout: QGA_PING_TIMEOUT})
+	if err != nil {
+		log.Debugf("guest %s agent not responsive: %v", self.Name, err)
+		return false
+	}
+	return true
+}
+
+func (self *SGuest) AllowPerformQgaPing(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, self, "qga-ping")
+}
+
+func (self *SGuest) PerformQgaPing(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerQgaPingInput) (jsonutils.JSONObject, error) {
+	if err := self.validateQgaStatus(); err != nil {
+		return nil, err
+	}
+	err := self.GetDriver().RequestQgaPing(ctx, userCred, self, &hostapi.GuestQgaPingRequest{Timeout: input.Timeout})
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return nil, nil
+}
+
+func (self *SGuest) AllowPerformQgaSetPassword(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, self, "qga-set-password")
+}
+
+func (self *SGuest) PerformQgaSetPassword(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerQgaSetPasswordInput) (jsonutils.JSONObject, error) {
+	if err := self.validateQgaStatus(); err != nil {
+		return nil, err
+	}
+	if len(input.Password) == 0 {
+		return nil, httperrors.NewMissingParameterError("password")
+	}
+	if err := seclib2.ValidatePassword(input.Password); err != nil {
+		return nil, err
+	}
+	if len(input.Username) == 0 {
+		input.Username = self.GetMetadata(api.VM_METADATA_LOGIN_ACCOUNT, userCred)
+	}
+	if len(input.Username) == 0 {
+		return nil, httperrors.NewMissingParameterError("username")
+	}
+	err := self.setPasswordByQga(ctx, userCred, input.Username, input.Password)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return nil, nil
+}
+
+// setPasswordByQga changes password of account inside running guest and
+// records it as login info, the same as deploy does
+func (self *SGuest) setPasswordByQga(ctx context.Context, userCred mcclient.TokenCredential, account, password string) error {
+	self.saveOldPassword(ctx, userCred)
+	req := &hostapi.GuestQgaSetPasswordRequest{
+		Username: account,
+		Password: password,
+	}
+	err := self.GetDriver().RequestQgaSetPassword(ctx, userCred, self, req)
+	if err != nil {
+		logclient.AddActionLogWithContext(ctx, self, logclient.ACT_RESET_PASSWORD, err, userCred, false)
+		return err
+	}
+	key, err := utils.EncryptAESBase64(self.Id, password)
+	if err != nil {
+		return err
+	}
+	info := jsonutils.NewDict()
+	info.Set("account", jsonutils.NewString(account))
+	info.Set("key", jsonutils.NewString(key))
+	self.SaveDeployInfo(ctx, userCred, info)
+	db.OpsLog.LogEvent(self, db.ACT_UPDATE, "reset password by guest agent", userCred)
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_RESET_PASSWORD, "guest agent", userCred, true)
+	return nil
+}
+
+// isPasswordOnlyDeploy tells whether the deploy request resets password
+// only, other deploy params like deploy configs or login account need an
+// offline deploy to take effect
+func isPasswordOnlyDeploy(kwargs *jsonutils.JSONDict) bool {
+	for _, key := range kwargs.SortedKeys() {
+		if !utils.IsInStringArray(key, []string{"password", "reset_password"}) {
+			return false
+		}
+	}
+	return true
+}
+
+// tryResetPasswordByQga resets login password of running guest through
+// guest agent, it returns false when deploy task must be used instead
+func (self *SGuest) tryResetPasswordByQga(ctx context.Context, userCred mcclient.TokenCredential, kwargs *jsonutils.JSONDict) bool {
+	if !isPasswordOnlyDeploy(kwargs) {
+		return false
+	}
+	if len(self.KeypairId) > 0 {
+		// public key injection requires offline deploy
+		return false
+	}
+	account := self.GetMetadata(api.VM_METADATA_LOGIN_ACCOUNT, userCred)
+	if len(account) == 0 {
+		return false
+	}
+	if !self.IsGuestAgentResponsive(ctx, userCred) {
+		return false
+	}
+	password, _ := kwargs.GetString("password")
+	if len(password) == 0 {
+		password = seclib2.RandomPassword2(12)
+	}
+	err := self.setPasswordByQga(ctx, userCred, account, password)
+	if err != nil {
+		log.Errorf("reset password of %s by guest agent fail %s, fallback to deploy", self.Name, err)
+		return false
+	}
+	return true
+}
+
+func (self *SGuest) AllowPerformQgaCommand(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, self, "qga-command")
+}
+
+func (self *SGuest) PerformQgaCommand(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerQgaCommandInput) (jsonutils.JSONObject, error) {
+	if err := self.validateQgaStatus(); err != nil {
+		return nil, err
+	}
+	if len(input.Command) == 0 {
+		return nil, httperrors.NewMissingParameterError("command")
+	}
+	req := &hostapi.GuestQgaExecRequest{
+		Path:    input.Command,
+		Args:    input.Args,
+		Timeout: input.Timeout,
+	}
+	resp, err := self.GetDriver().RequestQgaExec(ctx, userCred, self, req)
+	// arguments may carry secrets, only the command is logged
+	notes := jsonutils.NewDict()
+	notes.Set("command", jsonutils.NewString(req.Path))
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_QGA_COMMAND, notes, userCred, err == nil)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return jsonutils.Marshal(resp), nil
+}
+
+func (self *SGuest) AllowGetDetailsQgaNetworkInterfaces(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowGetSpec(userCred, self, "qga-network-interfaces")
+}
+
+// GetDetailsQgaNetworkInterfaces reports interfaces and addresses seen inside guest
+func (self *SGuest) GetDetailsQgaNetworkInterfaces(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if err := self.validateQgaStatus(); err != nil {
+		return nil, err
+	}
+	resp, err := self.GetDriver().RequestQgaGetNetworkInterfaces(ctx, userCred, self)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return jsonutils.Marshal(resp), nil
+}
+
+func (self *SGuest) AllowGetDetailsQgaOsInfo(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowGetSpec(userCred, self, "qga-os-info")
+}
+
+func (self *SGuest) GetDetailsQgaOsInfo(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if err := self.validateQgaStatus(); err != nil {
+		return nil, err
+	}
+	resp, err := self.GetDriver().RequestQgaGetOsInfo(ctx, userCred, self)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return jsonutils.Marshal(resp), nil
+}
+
+// GuestFsfreeze freezes filesystems of guest before taking a quiesced
+// snapshot, host thaws them by itself if GuestFsthaw does not come in time
+func (self *SGuest) GuestFsfreeze(ctx context.Context, userCred mcclient.TokenCredential) error {
+	req := &hostapi.GuestQgaFsfreezeRequest{
+		Timeout:         options.Options.GuestFsfreezeTimeoutSeconds,
+		AutoThawTimeout: options.Options.GuestFsfreezeAutoThawSeconds,
+	}
+	resp, err := self.GetDriver().RequestQgaFsfreeze(ctx, userCred, self, req)
+	if err != nil {
+		db.OpsLog.LogEvent(self, db.ACT_GUEST_FSFREEZE_FAIL, err.Error(), userCred)
+		return errors.Wrapf(err, "freeze guest %s filesystems", self.Name)
+	}
+	db.OpsLog.LogEvent(self, db.ACT_GUEST_FSFREEZE, fmt.Sprintf("%d filesystems frozen", resp.Count), userCred)
+	return nil
+}
+
+// GuestFsthaw thaws guest filesystems and returns how many of them were
+// still frozen, 0 means they had been thawed already, e.g. by host auto thaw
+func (self *SGuest) GuestFsthaw(ctx context.Context, userCred mcclient.TokenCredential) (int, error) {
+	req := &hostapi.GuestQgaFsfreezeRequest{
+		Timeout: options.Options.GuestFsfreezeTimeoutSeconds,
+	}
+	resp, err := self.GetDriver().RequestQgaFsthaw(ctx, userCred, self, req)
+	if err != nil {
+		db.OpsLog.LogEvent(self, db.ACT_GUEST_FSTHAW_FAIL, err.Error(), userCred)
+		return 0, errors.Wrapf(err, "thaw guest %s filesystems", self.Name)
+	}
+	db.OpsLog.LogEvent(self, db.ACT_GUEST_FSTHAW, fmt.Sprintf("%d filesystems thawed", resp.Count), userCred)
+	return resp.Count, nil
+}
+
+// isQuiesceSnapshotNeeded tells whether snapshot of guest should be taken
+// quiesced, filesystems of stopped kvm guest are consistent already while
+// esxi quiesces guest by vmware tools
+func (self *SGuest) isQuiesceSnapshotNeeded() bool {
+	return utils.IsInStringArray(self.Hypervisor, []string{api.HYPERVISOR_KVM, api.HYPERVISOR_ESXI}) &&
+		self.Status == api.VM_RUNNING
+}
+
+// IsFsfreezeNeeded tells whether quiesced snapshot of guest has to be taken
+// between GuestFsfreeze and GuestFsthaw
+func (self *SGuest) IsFsfreezeNeeded() bool {
+	return self.Hypervisor == api.HYPERVISOR_KVM && self.Status == api.VM_RUNNING
+}
@@ -0,0 +1,46 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	"yunion.io/x/jsonutils"
+)
+
+func TestIsPasswordOnlyDeploy(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{"password", `{"password":"Passw0rd!","reset_password":true}`, true},
+		{"reset password", `{"reset_password":true}`, true},
+		{"deploy configs", `{"reset_password":true,"deploy.0.path":"/etc/motd","deploy.0.action":"create","deploy.0.content":"hi"}`, false},
+		{"login account", `{"password":"Passw0rd!","reset_password":true,"login_account":"admin"}`, false},
+		{"delete public key", `{"reset_password":true,"delete_public_key":"ssh-rsa AAAA"}`, false},
+		{"restart", `{"reset_password":true,"restart":true}`, false},
+	}
+	for _, c := range cases {
+		body, err := jsonutils.ParseString(c.body)
+		if err != nil {
+			t.Fatalf("%s: parse body: %v", c.name, err)
+		}
+		got := isPasswordOnlyDeploy(body.(*jsonutils.JSONDict))
+		if got != c.want {
+			t.Errorf("%s: want %v, got %v", c.name, c.want, got)
+		}
+	}
+}
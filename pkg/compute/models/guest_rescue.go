@@ -0,0 +1,139 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/seclib"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+// IsRescueMode tells whether guest is booted, or going to be booted,
+// from rescue image instead of its own system disk
+func (self *SGuest) IsRescueMode() bool {
+	return self.GetMetadata(api.VM_METADATA_RESCUE_MODE, nil) == "true"
+}
+
+// SetRescueMode records the rescue mode and its one-time password,
+// which is passed to host through guest desc
+func (self *SGuest) SetRescueMode(ctx context.Context, userCred mcclient.TokenCredential, password string) error {
+	meta := map[string]interface{}{
+		api.VM_METADATA_RESCUE_MODE:      "",
+		api.VM_METADATA_RESCUE_LOGIN_KEY: "",
+	}
+	if len(password) > 0 {
+		key, err := utils.EncryptAESBase64(self.Id, password)
+		if err != nil {
+			return errors.Wrap(err, "EncryptAESBase64")
+		}
+		meta[api.VM_METADATA_RESCUE_MODE] = "true"
+		meta[api.VM_METADATA_RESCUE_LOGIN_KEY] = key
+	}
+	return self.SetAllMetadata(ctx, meta, userCred)
+}
+
+// validateStartRescue checks whether guest could enter rescue mode,
+// start-rescue failed could be retried
+func (self *SGuest) validateStartRescue(rescueMode bool) error {
+	if self.GetHypervisor() != api.HYPERVISOR_KVM {
+		return httperrors.NewNotAcceptableError("Not allow for hypervisor %s", self.GetHypervisor())
+	}
+	if len(self.BackupHostId) > 0 {
+		return httperrors.NewBadRequestError("Guest with backup guest can't be rescued")
+	}
+	if !utils.IsInStringArray(self.Status, []string{api.VM_READY, api.VM_RUNNING, api.VM_START_RESCUE_FAIL}) {
+		return httperrors.NewInvalidStatusError("Cannot rescue guest in status %s", self.Status)
+	}
+	if rescueMode && self.Status != api.VM_START_RESCUE_FAIL {
+		return httperrors.NewConflictError("Guest is already in rescue mode")
+	}
+	return nil
+}
+
+// validateStopRescue checks whether guest could leave rescue mode, it is
+// allowed after start-rescue or stop-rescue failed
+func (self *SGuest) validateStopRescue(rescueMode bool) error {
+	if !rescueMode {
+		return httperrors.NewBadRequestError("Guest is not in rescue mode")
+	}
+	if !utils.IsInStringArray(self.Status, []string{api.VM_READY, api.VM_RUNNING, api.VM_START_RESCUE_FAIL, api.VM_STOP_RESCUE_FAIL}) {
+		return httperrors.NewInvalidStatusError("Cannot stop rescue guest in status %s", self.Status)
+	}
+	return nil
+}
+
+func (self *SGuest) AllowPerformStartRescue(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.GuestStartRescueInput) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, self, "start-rescue")
+}
+
+// 以救援模式启动虚拟机, 虚拟机从救援镜像启动, 原有磁盘作为数据盘挂载
+func (self *SGuest) PerformStartRescue(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.GuestStartRescueInput) (jsonutils.JSONObject, error) {
+	if err := self.validateStartRescue(self.IsRescueMode()); err != nil {
+		return nil, err
+	}
+
+	password := seclib.RandomPassword(12)
+	if err := self.SetRescueMode(ctx, userCred, password); err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	if err := self.StartGuestRescueTask(ctx, userCred, "GuestStartRescueTask", nil, ""); err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return jsonutils.Marshal(api.GuestStartRescueOutput{
+		Username: api.VM_RESCUE_LOGIN_USER,
+		Password: password,
+	}), nil
+}
+
+func (self *SGuest) AllowPerformStopRescue(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.GuestStopRescueInput) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, self, "stop-rescue")
+}
+
+// 退出救援模式, 恢复从原有系统盘启动
+func (self *SGuest) PerformStopRescue(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.GuestStopRescueInput) (jsonutils.JSONObject, error) {
+	if err := self.validateStopRescue(self.IsRescueMode()); err != nil {
+		return nil, err
+	}
+	params := jsonutils.NewDict()
+	params.Set("auto_start", jsonutils.NewBool(input.AutoStart == nil || *input.AutoStart))
+	if err := self.StartGuestRescueTask(ctx, userCred, "GuestStopRescueTask", params, ""); err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return nil, nil
+}
+
+func (self *SGuest) StartGuestRescueTask(ctx context.Context, userCred mcclient.TokenCredential, taskName string, params *jsonutils.JSONDict, parentTaskId string) error {
+	if params == nil {
+		params = jsonutils.NewDict()
+	}
+	params.Set("guest_status", jsonutils.NewString(self.Status))
+	task, err := taskman.TaskManager.NewTask(ctx, taskName, self, userCred, params, parentTaskId, "", nil)
+	if err != nil {
+		log.Errorf("%s newTask error %s", taskName, err)
+		return err
+	}
+	task.ScheduleRun(nil)
+	return nil
+}
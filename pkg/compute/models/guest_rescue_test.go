@@ -0,0 +1,82 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func newTestRescueGuest(status string) *SGuest {
+	guest := &SGuest{}
+	guest.Hypervisor = api.HYPERVISOR_KVM
+	guest.Status = status
+	return guest
+}
+
+func TestValidateStartRescue(t *testing.T) {
+	withBackup := newTestRescueGuest(api.VM_RUNNING)
+	withBackup.BackupHostId = "host2"
+	defaultHypervisor := newTestRescueGuest(api.VM_READY)
+	defaultHypervisor.Hypervisor = ""
+	esxi := newTestRescueGuest(api.VM_RUNNING)
+	esxi.Hypervisor = api.HYPERVISOR_ESXI
+	cases := []struct {
+		name       string
+		guest      *SGuest
+		rescueMode bool
+		ok         bool
+	}{
+		{"ready", newTestRescueGuest(api.VM_READY), false, true},
+		{"running", newTestRescueGuest(api.VM_RUNNING), false, true},
+		{"default hypervisor", defaultHypervisor, false, true},
+		{"other hypervisor", esxi, false, false},
+		{"with backup", withBackup, false, false},
+		{"starting", newTestRescueGuest(api.VM_STARTING), false, false},
+		{"already rescued", newTestRescueGuest(api.VM_RUNNING), true, false},
+		{"retry failed", newTestRescueGuest(api.VM_START_RESCUE_FAIL), true, true},
+		{"stop rescue failed", newTestRescueGuest(api.VM_STOP_RESCUE_FAIL), true, false},
+	}
+	for _, c := range cases {
+		err := c.guest.validateStartRescue(c.rescueMode)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: want ok %v, got %v", c.name, c.ok, err)
+		}
+	}
+}
+
+func TestValidateStopRescue(t *testing.T) {
+	cases := []struct {
+		name       string
+		status     string
+		rescueMode bool
+		ok         bool
+	}{
+		{"running", api.VM_RUNNING, true, true},
+		{"ready", api.VM_READY, true, true},
+		{"start rescue failed", api.VM_START_RESCUE_FAIL, true, true},
+		{"stop rescue failed", api.VM_STOP_RESCUE_FAIL, true, true},
+		{"not rescued", api.VM_RUNNING, false, false},
+		{"starting rescue", api.VM_START_RESCUE, true, false},
+		{"stopping rescue", api.VM_STOP_RESCUE, true, false},
+	}
+	for _, c := range cases {
+		err := newTestRescueGuest(c.status).validateStopRescue(c.rescueMode)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: want ok %v, got %v", c.name, c.ok, err)
+		}
+	}
+}
@@ -217,6 +217,9 @@ func (self *SGuestdisk) GetJsonDescAtHost(host *SHost) jsonutils.JSONObject {
 	}
 	desc.Add(jsonutils.NewString(disk.DiskFormat), "format")
 	desc.Add(jsonutils.NewInt(int64(self.Index)), "index")
+	if len(disk.EncryptKeyId) > 0 {
+		desc.Add(jsonutils.NewString(disk.EncryptKeyId), "encrypt_key_id")
+	}
 
 	tid := disk.GetTemplateId()
 	if len(tid) > 0 {
@@ -22,6 +22,7 @@ import (
 	"yunion.io/x/jsonutils"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
+	hostapi "yunion.io/x/onecloud/pkg/apis/host"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/cloudprovider"
@@ -211,6 +212,14 @@ type IGuestDriver interface {
 	RequestOpenForward(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest, req *guestdriver_types.OpenForwardRequest) (*guestdriver_types.OpenForwardResponse, error)
 	RequestListForward(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest, req *guestdriver_types.ListForwardRequest) (*guestdriver_types.ListForwardResponse, error)
 	RequestCloseForward(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest, req *guestdriver_types.CloseForwardRequest) (*guestdriver_types.CloseForwardResponse, error)
+
+	RequestQgaPing(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest, req *hostapi.GuestQgaPingRequest) error
+	RequestQgaSetPassword(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest, req *hostapi.GuestQgaSetPasswordRequest) error
+	RequestQgaExec(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest, req *hostapi.GuestQgaExecRequest) (*hostapi.GuestQgaExecResponse, error)
+	RequestQgaGetNetworkInterfaces(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest) (*hostapi.GuestQgaNetworkInterfacesResponse, error)
+	RequestQgaGetOsInfo(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest) (*hostapi.GuestQgaOsInfoResponse, error)
+	RequestQgaFsfreeze(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest, req *hostapi.GuestQgaFsfreezeRequest) (*hostapi.GuestQgaFsfreezeResponse, error)
+	RequestQgaFsthaw(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest, req *hostapi.GuestQgaFsfreezeRequest) (*hostapi.GuestQgaFsfreezeResponse, error)
 }
 
 var guestDrivers map[string]IGuestDriver
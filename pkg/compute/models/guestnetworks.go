@@ -242,6 +242,7 @@ type newGuestNetworkArgs struct {
 	index int8
 
 	ipAddr              string
+	ip6Addr             string
 	allocDir            api.IPAllocationDirection
 	tryReserved         bool
 	requireDesignatedIP bool
@@ -269,6 +270,7 @@ func (manager *SGuestnetworkManager) newGuestNetwork(
 		network              = args.network
 		index                = args.index
 		address              = args.ipAddr
+		address6             = args.ip6Addr
 		mac                  = args.macAddr
 		driver               = args.nicDriver
 		bwLimit              = args.bwLimit
@@ -330,6 +332,21 @@ func (manager *SGuestnetworkManager) newGuestNetwork(
 			gn.IpAddr = ipAddr
 		}
 
+		if network.HasIPv6() {
+			if len(address6) > 0 && reUseAddr {
+				gn.Ip6Addr = address6
+			} else {
+				ip6Addr, err := network.GetFreeIP6(ctx, userCred, nil, address6, macAddr, allocDir)
+				if err != nil {
+					return nil, errors.Wrap(err, "GetFreeIP6")
+				}
+				if len(address6) > 0 && ip6Addr != address6 && requiredDesignatedIp {
+					return nil, fmt.Errorf("candidate ipv6 %s is occupied!", address6)
+				}
+				gn.Ip6Addr = ip6Addr
+			}
+		}
+
 		if vpc := network.GetVpc(); vpc == nil {
 			return nil, fmt.Errorf("cannot find vpc of network %s(%s)", network.Id, network.Name)
 		} else if vpc.Id != api.DEFAULT_VPC_ID && vpc.GetProviderName() == api.CLOUD_PROVIDER_ONECLOUD {
@@ -578,6 +595,17 @@ func (self *SGuestnetwork) getJsonDesc(network *SNetwork) *jsonutils.JSONDict {
 	desc.Add(jsonutils.NewInt(int64(self.getBandwidth())), "bw")
 	desc.Add(jsonutils.NewInt(int64(self.getMtu(network))), "mtu")
 	desc.Add(jsonutils.NewInt(int64(self.Index)), "index")
+	if len(self.Ip6Addr) > 0 && network.HasIPv6() {
+		desc.Add(jsonutils.NewString(self.Ip6Addr), "ip6")
+		desc.Add(jsonutils.NewInt(int64(network.GuestIp6Mask)), "masklen6")
+		if len(network.GuestGateway6) > 0 {
+			desc.Add(jsonutils.NewString(network.GuestGateway6), "gateway6")
+		}
+		if len(network.GuestDns6) > 0 {
+			desc.Add(jsonutils.NewString(network.GuestDns6), "dns6")
+		}
+		desc.Add(jsonutils.NewString(network.GetIp6AllocMode()), "ip6_alloc_mode")
+	}
 	vips := self.GetVirtualIPs()
 	if len(vips) > 0 {
 		desc.Add(jsonutils.NewStringArray(vips), "virtual_ips")
@@ -962,18 +990,19 @@ func (self *SGuestnetwork) ToNetworkConfig() *api.NetworkConfig {
 		return nil
 	}
 	ret := &api.NetworkConfig{
-		Index:   int(self.Index),
-		Network: net.Id,
-		Wire:    net.GetWire().Id,
-		Mac:     self.MacAddr,
-		Address: self.IpAddr,
-		Driver:  self.Driver,
-		BwLimit: self.BwLimit,
-		Project: net.ProjectId,
-		Domain:  net.DomainId,
-		Ifname:  self.Ifname,
-		NetType: net.ServerType,
-		Exit:    net.IsExitNetwork(),
+		Index:    int(self.Index),
+		Network:  net.Id,
+		Wire:     net.GetWire().Id,
+		Mac:      self.MacAddr,
+		Address:  self.IpAddr,
+		Address6: self.Ip6Addr,
+		Driver:   self.Driver,
+		BwLimit:  self.BwLimit,
+		Project:  net.ProjectId,
+		Domain:   net.DomainId,
+		Ifname:   self.Ifname,
+		NetType:  net.ServerType,
+		Exit:     net.IsExitNetwork(),
 	}
 	return ret
 }
@@ -1322,6 +1322,9 @@ func (manager *SGuestManager) validateCreateData(
 		if len(rootDiskConfig.Driver) == 0 {
 			rootDiskConfig.Driver = osProf.DiskDriver
 		}
+		if err := validateDiskEncrypt(rootDiskConfig, hypervisor); err != nil {
+			return nil, err
+		}
 		log.Debugf("ROOT DISK: %#v", rootDiskConfig)
 		input.Disks[0] = rootDiskConfig
 		if sku != nil {
@@ -1347,6 +1350,9 @@ func (manager *SGuestManager) validateCreateData(
 			if len(diskConfig.Driver) == 0 {
 				diskConfig.Driver = osProf.DiskDriver
 			}
+			if err := validateDiskEncrypt(diskConfig, hypervisor); err != nil {
+				return nil, err
+			}
 			input.Disks[i+1] = diskConfig
 		}
 
@@ -2741,6 +2747,7 @@ type Attach2NetworkArgs struct {
 	Network *SNetwork
 
 	IpAddr              string
+	IpAddr6             string
 	AllocDir            api.IPAllocationDirection
 	TryReserved         bool
 	RequireDesignatedIP bool
@@ -2763,6 +2770,7 @@ func (args *Attach2NetworkArgs) onceArgs(i int) attach2NetworkOnceArgs {
 		network: args.Network,
 
 		ipAddr:              args.IpAddr,
+		ip6Addr:             args.IpAddr6,
 		allocDir:            args.AllocDir,
 		tryReserved:         args.TryReserved,
 		requireDesignatedIP: args.RequireDesignatedIP,
@@ -2778,6 +2786,7 @@ func (args *Attach2NetworkArgs) onceArgs(i int) attach2NetworkOnceArgs {
 	}
 	if i > 0 {
 		r.ipAddr = ""
+		r.ip6Addr = ""
 		r.bwLimit = 0
 		r.virtual = true
 		r.tryReserved = false
@@ -2793,6 +2802,7 @@ type attach2NetworkOnceArgs struct {
 	network *SNetwork
 
 	ipAddr              string
+	ip6Addr             string
 	allocDir            api.IPAllocationDirection
 	tryReserved         bool
 	requireDesignatedIP bool
@@ -2861,6 +2871,7 @@ func (self *SGuest) attach2NetworkOnce(
 		index: index,
 
 		ipAddr:              args.ipAddr,
+		ip6Addr:             args.ip6Addr,
 		allocDir:            args.allocDir,
 		tryReserved:         args.tryReserved,
 		requireDesignatedIP: args.requireDesignatedIP,
@@ -3471,6 +3482,7 @@ func (self *SGuest) attach2NamedNetworkDesc(ctx context.Context, userCred mcclie
 			Network:             net,
 			PendingUsage:        pendingUsage,
 			IpAddr:              netConfig.Address,
+			IpAddr6:             netConfig.Address6,
 			NicDriver:           netConfig.Driver,
 			BwLimit:             netConfig.BwLimit,
 			Virtual:             netConfig.Vip,
@@ -4577,6 +4589,37 @@ func (manager *SGuestManager) GetIpInProjectWithName(projectId, name string, isE
 	return manager.getIpsByExit(ips, isExitOnly)
 }
 
+// GetIp6InProjectWithName returns the ipv6 addresses of guests with name, used for AAAA records
+func (manager *SGuestManager) GetIp6InProjectWithName(projectId, name string) []string {
+	guestnics := GuestnetworkManager.Query().SubQuery()
+	guests := manager.Query().SubQuery()
+	q := guestnics.Query(guestnics.Field("ip6_addr")).Join(guests,
+		sqlchemy.AND(
+			sqlchemy.Equals(guests.Field("id"), guestnics.Field("guest_id")),
+			sqlchemy.OR(sqlchemy.IsNull(guests.Field("pending_deleted")),
+				sqlchemy.IsFalse(guests.Field("pending_deleted"))))).
+		Filter(sqlchemy.Equals(guests.Field("name"), name)).
+		Filter(sqlchemy.NotEquals(guestnics.Field("ip6_addr"), "")).
+		Filter(sqlchemy.IsNotNull(guestnics.Field("ip6_addr")))
+	ips := make([]string, 0)
+	rows, err := q.Rows()
+	if err != nil {
+		log.Errorf("Get guest ip6 with name query err: %v", err)
+		return ips
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ip string
+		err = rows.Scan(&ip)
+		if err != nil {
+			log.Errorf("Get guest ip6 with name scan err: %v", err)
+			return ips
+		}
+		ips = append(ips, ip)
+	}
+	return ips
+}
+
 func (manager *SGuestManager) getIpsByExit(ips []string, isExitOnly bool) []string {
 	intRet := make([]string, 0)
 	extRet := make([]string, 0)
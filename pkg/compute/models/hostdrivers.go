@@ -49,6 +49,9 @@ type IHostDriver interface {
 	RequestDeleteSnapshotsWithStorage(ctx context.Context, host *SHost, snapshot *SSnapshot, task taskman.ITask) error
 	RequestResetDisk(ctx context.Context, host *SHost, disk *SDisk, params *jsonutils.JSONDict, task taskman.ITask) error
 	RequestCleanUpDiskSnapshots(ctx context.Context, host *SHost, disk *SDisk, params *jsonutils.JSONDict, task taskman.ITask) error
+	RequestDiskBackup(ctx context.Context, host *SHost, disk *SDisk, backup *SDiskBackup, task taskman.ITask) error
+	RequestRestoreDiskBackup(ctx context.Context, host *SHost, disk *SDisk, chain []SDiskBackup, task taskman.ITask) error
+	RequestDeleteDiskBackups(ctx context.Context, host *SHost, diskId string, backups []SDiskBackup, task taskman.ITask) error
 	PrepareConvert(host *SHost, image, raid string, data jsonutils.JSONObject) (*api.ServerCreateInput, error)
 	PrepareUnconvert(host *SHost) error
 	FinishUnconvert(ctx context.Context, userCred mcclient.TokenCredential, host *SHost) error
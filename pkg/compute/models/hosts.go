@@ -590,6 +590,19 @@ func (self *SHost) GetVirtualMemorySize() float32 {
 	return float32(self.GetMemSize()) * self.GetMemoryOvercommitBound()
 }
 
+// GetNumaTopology returns numa nodes reported by kvm host, nil if unknown
+func (self *SHost) GetNumaTopology() *api.HostNumaTopology {
+	if self.SysInfo == nil || !self.SysInfo.Contains("numa") {
+		return nil
+	}
+	topo := &api.HostNumaTopology{}
+	if err := self.SysInfo.Unmarshal(topo, "numa"); err != nil {
+		log.Errorf("unmarshal numa topology of host %s: %v", self.Name, err)
+		return nil
+	}
+	return topo
+}
+
 func (self *SHost) GetCPUOvercommitBound() float32 {
 	if self.CpuCmtbound > 0 {
 		return self.CpuCmtbound
@@ -3890,8 +3903,24 @@ func (self *SHost) PerformPing(ctx context.Context, userCred mcclient.TokenCrede
 	if self.HostStatus != api.HOST_ONLINE {
 		self.PerformOnline(ctx, userCred, query, data)
 	} else {
+		// kvm host reports free memory of numa nodes on every ping,
+		// sys_info is only rewritten when it changes
+		numa, _ := data.Get("numa")
+		if numa != nil && self.SysInfo != nil {
+			if oldNuma, _ := self.SysInfo.Get("numa"); oldNuma != nil && oldNuma.Equals(numa) {
+				numa = nil
+			}
+		}
 		self.SaveUpdates(func() error {
 			self.LastPingAt = time.Now()
+			if numa != nil {
+				sysInfo := jsonutils.NewDict()
+				if self.SysInfo != nil {
+					sysInfo.Update(self.SysInfo)
+				}
+				sysInfo.Set("numa", numa)
+				self.SysInfo = sysInfo
+			}
 			return nil
 		})
 	}
@@ -5690,6 +5719,187 @@ func (self *SHost) StartEjectIsoTask(ctx context.Context, userCred mcclient.Toke
 	}
 }
 
+func (self *SHost) isRedfishOutOfBandAllowed() error {
+	if !self.IsBaremetal {
+		return httperrors.NewBadRequestError("Cannot do out-of-band management on a non-baremetal host")
+	}
+	if !utils.IsInStringArray(self.Status, []string{api.BAREMETAL_READY, api.BAREMETAL_RUNNING}) {
+		return httperrors.NewInvalidStatusError("Cannot do out-of-band management in status %s", self.Status)
+	}
+	ipmiInfo, err := self.GetIpmiInfo()
+	if err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	if !ipmiInfo.RedfishApi {
+		return httperrors.NewNotSupportedError("Redfish API not supported by BMC")
+	}
+	return nil
+}
+
+func (self *SHost) AllowGetDetailsBios(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowGetSpec(userCred, self, "bios")
+}
+
+func (self *SHost) GetDetailsBios(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if !self.IsBaremetal {
+		return nil, httperrors.NewBadRequestError("Cannot get bios of a non-baremetal host")
+	}
+	url := fmt.Sprintf("/baremetals/%s/bios", self.Id)
+	header := mcclient.GetTokenHeaders(userCred)
+	resp, err := self.BaremetalSyncRequest(ctx, "POST", url, header, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "BaremetalSyncRequest")
+	}
+	return resp, nil
+}
+
+func (self *SHost) AllowGetDetailsRaidStorages(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowGetSpec(userCred, self, "raid-storages")
+}
+
+func (self *SHost) GetDetailsRaidStorages(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if !self.IsBaremetal {
+		return nil, httperrors.NewBadRequestError("Cannot get raid storages of a non-baremetal host")
+	}
+	url := fmt.Sprintf("/baremetals/%s/raid-storages", self.Id)
+	header := mcclient.GetTokenHeaders(userCred)
+	resp, err := self.BaremetalSyncRequest(ctx, "POST", url, header, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "BaremetalSyncRequest")
+	}
+	return resp, nil
+}
+
+func (self *SHost) AllowPerformFirmwareUpdate(ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, self, "firmware-update")
+}
+
+func (self *SHost) PerformFirmwareUpdate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.HostFirmwareUpdateInput) (jsonutils.JSONObject, error) {
+	err := self.isRedfishOutOfBandAllowed()
+	if err != nil {
+		return nil, err
+	}
+	if len(input.ImageUri) == 0 {
+		return nil, httperrors.NewMissingParameterError("image_uri")
+	}
+	return nil, self.StartFirmwareUpdateTask(ctx, userCred, input, "")
+}
+
+func (self *SHost) StartFirmwareUpdateTask(ctx context.Context, userCred mcclient.TokenCredential, input api.HostFirmwareUpdateInput, parentTaskId string) error {
+	data := jsonutils.Marshal(input).(*jsonutils.JSONDict)
+	self.SetStatus(userCred, api.BAREMETAL_START_FIRMWARE_UPDATE, "start firmware update task")
+	task, err := taskman.TaskManager.NewTask(ctx, "BaremetalFirmwareUpdateTask", self, userCred, data, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrap(err, "NewTask")
+	}
+	task.ScheduleRun(nil)
+	return nil
+}
+
+func (self *SHost) AllowPerformBiosSettings(ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, self, "bios-settings")
+}
+
+func (self *SHost) PerformBiosSettings(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.HostBiosSettingsInput) (jsonutils.JSONObject, error) {
+	err := self.isRedfishOutOfBandAllowed()
+	if err != nil {
+		return nil, err
+	}
+	if input.Attributes == nil || input.Attributes.Length() == 0 {
+		return nil, httperrors.NewMissingParameterError("attributes")
+	}
+	if input.Reboot && self.Status == api.BAREMETAL_RUNNING {
+		guest := self.GetBaremetalServer()
+		if guest != nil && guest.Status == api.VM_RUNNING {
+			return nil, httperrors.NewInvalidStatusError("Cannot reboot while server %s is running", guest.Name)
+		}
+	}
+	return nil, self.StartBiosSettingsTask(ctx, userCred, input, "")
+}
+
+func (self *SHost) StartBiosSettingsTask(ctx context.Context, userCred mcclient.TokenCredential, input api.HostBiosSettingsInput, parentTaskId string) error {
+	data := jsonutils.Marshal(input).(*jsonutils.JSONDict)
+	self.SetStatus(userCred, api.BAREMETAL_START_BIOS_SETTINGS, "start bios settings task")
+	task, err := taskman.TaskManager.NewTask(ctx, "BaremetalBiosSettingsTask", self, userCred, data, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrap(err, "NewTask")
+	}
+	task.ScheduleRun(nil)
+	return nil
+}
+
+func (self *SHost) AllowPerformRaidVolumeCreate(ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, self, "raid-volume-create")
+}
+
+func (self *SHost) PerformRaidVolumeCreate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.HostRaidVolumeCreateInput) (jsonutils.JSONObject, error) {
+	err := self.isRedfishOutOfBandAllowed()
+	if err != nil {
+		return nil, err
+	}
+	if self.GetBaremetalServer() != nil {
+		return nil, httperrors.NewInvalidStatusError("Cannot change raid volumes of an occupied baremetal")
+	}
+	if len(input.RaidType) == 0 {
+		return nil, httperrors.NewMissingParameterError("raid_type")
+	}
+	input.RaidType = strings.ToUpper(input.RaidType)
+	if !strings.HasPrefix(input.RaidType, "RAID") {
+		return nil, httperrors.NewInputParameterError("invalid raid_type %s", input.RaidType)
+	}
+	if len(input.Drives) == 0 {
+		return nil, httperrors.NewMissingParameterError("drives")
+	}
+	if input.CapacityGb < 0 {
+		return nil, httperrors.NewInputParameterError("invalid capacity_gb %d", input.CapacityGb)
+	}
+	data := jsonutils.Marshal(input).(*jsonutils.JSONDict)
+	data.Set("action", jsonutils.NewString(api.BAREMETAL_RAID_VOLUME_ACTION_CREATE))
+	return nil, self.StartRaidVolumeTask(ctx, userCred, data, "")
+}
+
+func (self *SHost) AllowPerformRaidVolumeDelete(ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, self, "raid-volume-delete")
+}
+
+func (self *SHost) PerformRaidVolumeDelete(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.HostRaidVolumeDeleteInput) (jsonutils.JSONObject, error) {
+	err := self.isRedfishOutOfBandAllowed()
+	if err != nil {
+		return nil, err
+	}
+	if self.GetBaremetalServer() != nil {
+		return nil, httperrors.NewInvalidStatusError("Cannot change raid volumes of an occupied baremetal")
+	}
+	if len(input.Volume) == 0 {
+		return nil, httperrors.NewMissingParameterError("volume")
+	}
+	data := jsonutils.Marshal(input).(*jsonutils.JSONDict)
+	data.Set("action", jsonutils.NewString(api.BAREMETAL_RAID_VOLUME_ACTION_DELETE))
+	return nil, self.StartRaidVolumeTask(ctx, userCred, data, "")
+}
+
+func (self *SHost) StartRaidVolumeTask(ctx context.Context, userCred mcclient.TokenCredential, data *jsonutils.JSONDict, parentTaskId string) error {
+	self.SetStatus(userCred, api.BAREMETAL_START_RAID_CONFIG, "start raid volume task")
+	task, err := taskman.TaskManager.NewTask(ctx, "BaremetalRaidVolumeTask", self, userCred, data, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrap(err, "NewTask")
+	}
+	task.ScheduleRun(nil)
+	return nil
+}
+
 func (self *SHost) AllowPerformSyncConfig(ctx context.Context,
 	userCred mcclient.TokenCredential,
 	query jsonutils.JSONObject,
@@ -77,6 +77,8 @@ type SInstanceSnapshot struct {
 	InstanceType string `width:"64" charset:"utf8" nullable:"true" list:"user" create:"optional"`
 	// 主机快照磁盘容量和
 	SizeMb int `nullable:"false"`
+	// 创建快照时是否冻结了文件系统, 即是否为应用一致性快照
+	Quiesced bool `nullable:"false" default:"false" list:"user"`
 }
 
 type SInstanceSnapshotManager struct {
@@ -289,10 +291,15 @@ func (self *SInstanceSnapshot) StartCreateInstanceSnapshotTask(
 	ctx context.Context,
 	userCred mcclient.TokenCredential,
 	pendingUsage quotas.IQuota,
+	quiesce bool,
 	parentTaskId string,
 ) error {
+	params := jsonutils.NewDict()
+	if quiesce {
+		params.Set("quiesce", jsonutils.JSONTrue)
+	}
 	if task, err := taskman.TaskManager.NewTask(
-		ctx, "InstanceSnapshotCreateTask", self, userCred, nil, parentTaskId, "", pendingUsage); err != nil {
+		ctx, "InstanceSnapshotCreateTask", self, userCred, params, parentTaskId, "", pendingUsage); err != nil {
 		return err
 	} else {
 		task.ScheduleRun(nil)
@@ -461,6 +468,27 @@ func (self *SInstanceSnapshot) GetSnapshots() ([]SSnapshot, error) {
 	}
 }
 
+// SetQuiesced records whether guest filesystems were frozen while snapshots were taken
+func (self *SInstanceSnapshot) SetQuiesced(quiesced bool) error {
+	_, err := db.Update(self, func() error {
+		self.Quiesced = quiesced
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "update instance snapshot")
+	}
+	snapshots, err := self.GetSnapshots()
+	if err != nil {
+		return errors.Wrap(err, "GetSnapshots")
+	}
+	for i := range snapshots {
+		if err := snapshots[i].SetQuiesced(quiesced); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (self *SInstanceSnapshot) GetQuotaKeys() quotas.IQuotaKeys {
 	return fetchRegionalQuotaKeys(
 		rbacutils.ScopeProject,
@@ -420,6 +420,10 @@ func (man *SLoadbalancerAgentManager) ValidateCreateData(ctx context.Context, us
 	}
 	{
 		cluster := clusterV.Model.(*SLoadbalancerCluster)
+		if cluster.IsOvn() {
+			return nil, httperrors.NewInputParameterError("lbcluster %s(%s) is of type %s and takes no lbagent",
+				cluster.Name, cluster.Id, cluster.ClusterType)
+		}
 		lbagents, err := LoadbalancerClusterManager.getLoadbalancerAgents(cluster.Id)
 		if err != nil {
 			return nil, httperrors.NewGeneralError(err)
@@ -77,6 +77,9 @@ type SLoadbalancerBackend struct {
 
 	SendProxy string `width:"16" charset:"ascii" nullable:"false" list:"user" create:"optional" update:"user" default:"off"`
 	Ssl       string `width:"16" charset:"ascii" nullable:"true" list:"user" create:"optional" update:"user" default:"off"`
+
+	// 健康检查状态 online|offline|unknown
+	HealthStatus string `width:"16" charset:"ascii" nullable:"true" list:"user"`
 }
 
 func (man *SLoadbalancerBackendManager) pendingDeleteSubs(ctx context.Context, userCred mcclient.TokenCredential, q *sqlchemy.SQuery) {
@@ -134,6 +137,9 @@ func (man *SLoadbalancerBackendManager) ListItemFilter(
 	if len(query.Ssl) > 0 {
 		q = q.In("ssl", query.Ssl)
 	}
+	if len(query.HealthStatus) > 0 {
+		q = q.In("health_status", query.HealthStatus)
+	}
 
 	return q, nil
 }
@@ -431,6 +437,30 @@ func (lbb *SLoadbalancerBackend) Delete(ctx context.Context, userCred mcclient.T
 	return nil
 }
 
+func (lbb *SLoadbalancerBackend) AllowPerformHealthStatus(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, lbb, "health-status")
+}
+
+// 上报后端健康状态
+func (lbb *SLoadbalancerBackend) PerformHealthStatus(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.LoadbalancerBackendHealthStatusInput) (jsonutils.JSONObject, error) {
+	if !api.LB_BACKEND_HEALTH_STATUSES.Has(input.HealthStatus) {
+		return nil, httperrors.NewInputParameterError("invalid health_status %q", input.HealthStatus)
+	}
+	if lbb.HealthStatus == input.HealthStatus {
+		return nil, nil
+	}
+	oldStatus := lbb.HealthStatus
+	_, err := db.Update(lbb, func() error {
+		lbb.HealthStatus = input.HealthStatus
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "update health_status")
+	}
+	db.OpsLog.LogEvent(lbb, db.ACT_UPDATE_STATUS, fmt.Sprintf("health status %s -> %s", oldStatus, input.HealthStatus), userCred)
+	return nil, nil
+}
+
 func (lbb *SLoadbalancerBackend) AllowPerformPurge(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
 	return db.IsAdminAllowPerform(userCred, lbb, "purge")
 }
@@ -56,6 +56,9 @@ type SLoadbalancerCluster struct {
 	SZoneResourceBase
 	SWireResourceBase `width:"36" charset:"ascii" nullable:"true" list:"admin" create:"optional" update:"admin"`
 	//WireId string `width:"36" charset:"ascii" nullable:"true" list:"admin" create:"optional" update:"admin"`
+
+	// 集群类型 lbagent|ovn
+	ClusterType string `width:"16" charset:"ascii" nullable:"false" default:"lbagent" list:"user" create:"optional"`
 }
 
 // 负载均衡集群列表
@@ -83,6 +86,10 @@ func (man *SLoadbalancerClusterManager) ListItemFilter(
 		return nil, errors.Wrap(err, "SWireResourceBaseManager.ListItemFilter")
 	}
 
+	if len(query.ClusterType) > 0 {
+		q = q.In("cluster_type", query.ClusterType)
+	}
+
 	return q, nil
 }
 
@@ -135,9 +142,11 @@ func (man *SLoadbalancerClusterManager) QueryDistinctExtraField(q *sqlchemy.SQue
 func (man *SLoadbalancerClusterManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data *jsonutils.JSONDict) (*jsonutils.JSONDict, error) {
 	zoneV := validators.NewModelIdOrNameValidator("zone", "zone", ownerId)
 	wireV := validators.NewModelIdOrNameValidator("wire", "wire", ownerId)
+	clusterTypeV := validators.NewStringChoicesValidator("cluster_type", api.LB_CLUSTER_TYPES)
 	vs := []validators.IValidator{
 		zoneV,
 		wireV.Optional(true),
+		clusterTypeV.Default(api.LB_CLUSTER_TYPE_LBAGENT),
 	}
 	for _, v := range vs {
 		if err := v.Validate(data); err != nil {
@@ -148,6 +157,9 @@ func (man *SLoadbalancerClusterManager) ValidateCreateData(ctx context.Context,
 	if zone.ExternalId != "" {
 		return nil, httperrors.NewInputParameterError("allow only internal zone, got %s(%s)", zone.Name, zone.Id)
 	}
+	if clusterTypeV.Value == api.LB_CLUSTER_TYPE_OVN && wireV.Model != nil {
+		return nil, httperrors.NewInputParameterError("ovn lbcluster serves vpc networks and can not be bound to wire")
+	}
 	if wireV.Model != nil {
 		wire := wireV.Model.(*SWire)
 		if wire.ZoneId != zone.Id {
@@ -176,6 +188,9 @@ func (lbc *SLoadbalancerCluster) ValidateUpdateData(ctx context.Context, userCre
 		return nil, err
 	}
 	if wireV.Model != nil {
+		if lbc.IsOvn() {
+			return nil, httperrors.NewInputParameterError("ovn lbcluster serves vpc networks and can not be bound to wire")
+		}
 		wire := wireV.Model.(*SWire)
 		if wire.ZoneId != lbc.ZoneId {
 			return nil, httperrors.NewInputParameterError("zone of wire must be %s, got %s", lbc.ZoneId, wire.ZoneId)
@@ -202,6 +217,12 @@ func (lbc *SLoadbalancerCluster) ValidateUpdateData(ctx context.Context, userCre
 	return data, nil
 }
 
+// IsOvn returns true if loadbalancers of the cluster are realized by vpcagent
+// instead of lbagents
+func (lbc *SLoadbalancerCluster) IsOvn() bool {
+	return lbc.ClusterType == api.LB_CLUSTER_TYPE_OVN
+}
+
 func (lbc *SLoadbalancerCluster) ValidateDeleteCondition(ctx context.Context) error {
 	men := []db.IModelManager{
 		LoadbalancerManager,
@@ -436,6 +436,15 @@ func (lb *SLoadbalancer) GetVpc() *SVpc {
 	return lb.SVpcResourceBase.GetVpc()
 }
 
+// IsOvn returns true if the loadbalancer belongs to an ovn lbcluster
+func (lb *SLoadbalancer) IsOvn() bool {
+	if lb.ClusterId == "" {
+		return false
+	}
+	cluster := lb.GetLoadbalancerCluster()
+	return cluster != nil && cluster.IsOvn()
+}
+
 func (lb *SLoadbalancer) GetNetworks() ([]SNetwork, error) {
 	networks := []SNetwork{}
 	networkIds := strings.Split(lb.NetworkId, ",")
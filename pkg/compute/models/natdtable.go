@@ -346,11 +346,7 @@ func (self *SNatDEntry) PostCreate(ctx context.Context, userCred mcclient.TokenC
 }
 
 func (self *SNatDEntry) CustomizeDelete(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
-	if len(self.ExternalId) > 0 {
-		return self.StartDeleteDNatTask(ctx, userCred)
-	} else {
-		return self.RealDelete(ctx, userCred)
-	}
+	return self.StartDeleteDNatTask(ctx, userCred)
 }
 
 func (self *SNatDEntry) StartDeleteDNatTask(ctx context.Context, userCred mcclient.TokenCredential) error {
@@ -164,23 +164,24 @@ func (man *SNatSEntryManager) ValidateCreateData(ctx context.Context, userCred m
 		return nil, httperrors.NewInputParameterError("Only one of that sourceCIDR and netword_id is needed")
 	}
 
+	// get natgateway
+	_natgateway, err := NatGatewayManager.FetchById(input.NatgatewayId)
+	if err != nil {
+		return nil, err
+	}
+	natgateway := _natgateway.(*SNatGateway)
+	// get vpc
+	vpc, err := natgateway.GetVpc()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetVpc")
+	}
+
 	if len(input.SourceCidr) != 0 {
 		//check sourceCidr and convert to netutils.IPV4Range
 		sourceIPV4Range, err := newIPv4RangeFromCIDR(input.SourceCidr)
 		if err != nil {
 			return nil, httperrors.NewInputParameterError("%v", err)
 		}
-		// get natgateway
-		model, err := man.FetchById(input.NatgatewayId)
-		if err != nil {
-			return nil, err
-		}
-		natgateway := model.(*SNatGateway)
-		// get vpc
-		vpc, err := natgateway.GetVpc()
-		if err != nil {
-			return nil, errors.Wrapf(err, "GetVpc")
-		}
 
 		vpcIPV4Range, err := newIPv4RangeFromCIDR(vpc.CidrBlock)
 		if err != nil {
@@ -196,6 +197,9 @@ func (man *SNatSEntryManager) ValidateCreateData(ctx context.Context, userCred m
 		if err != nil {
 			return nil, httperrors.NewInputParameterError("%v", err)
 		}
+		if netVpc := network.GetVpc(); netVpc == nil || netVpc.Id != vpc.Id {
+			return nil, httperrors.NewInputParameterError("network %s is not in vpc %s", network.Name, vpc.Name)
+		}
 		data.Add(jsonutils.NewString(network.GetExternalId()), "network_ext_id")
 	}
 
@@ -442,11 +446,7 @@ func (self *SNatSEntry) PostCreate(ctx context.Context, userCred mcclient.TokenC
 }
 
 func (self *SNatSEntry) CustomizeDelete(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
-	if len(self.ExternalId) > 0 {
-		return self.StartDeleteSNatTask(ctx, userCred)
-	} else {
-		return self.RealDelete(ctx, userCred)
-	}
+	return self.StartDeleteSNatTask(ctx, userCred)
 }
 
 func (self *SNatSEntry) StartDeleteSNatTask(ctx context.Context, userCred mcclient.TokenCredential) error {
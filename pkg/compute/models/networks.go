@@ -47,6 +47,7 @@ import (
 	"yunion.io/x/onecloud/pkg/mcclient/auth"
 	"yunion.io/x/onecloud/pkg/util/billing"
 	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/netutils2"
 	"yunion.io/x/onecloud/pkg/util/rand"
 	"yunion.io/x/onecloud/pkg/util/rbacutils"
 	"yunion.io/x/onecloud/pkg/util/stringutils2"
@@ -94,14 +95,22 @@ type SNetwork struct {
 
 	GuestDomain string `width:"128" charset:"ascii" nullable:"true" get:"user" update:"user"`
 
-	GuestIp6Start string `width:"64" charset:"ascii" nullable:"true"`
-	GuestIp6End   string `width:"64" charset:"ascii" nullable:"true"`
-	GuestIp6Mask  int8   `nullable:"true"`
-	GuestGateway6 string `width:"64" charset:"ascii" nullable:"true"`
-	GuestDns6     string `width:"64" charset:"ascii" nullable:"true"`
+	// IPv6起始地址
+	GuestIp6Start string `width:"64" charset:"ascii" nullable:"true" list:"user" update:"user" create:"optional"`
+	// IPv6结束地址
+	GuestIp6End string `width:"64" charset:"ascii" nullable:"true" list:"user" update:"user" create:"optional"`
+	// IPv6前缀长度
+	GuestIp6Mask int8 `nullable:"true" list:"user" update:"user" create:"optional"`
+	// IPv6网关地址
+	GuestGateway6 string `width:"64" charset:"ascii" nullable:"true" list:"user" update:"user" create:"optional"`
+	// IPv6 DNS
+	GuestDns6 string `width:"64" charset:"ascii" nullable:"true" list:"user" update:"user" create:"optional"`
 
 	GuestDomain6 string `width:"128" charset:"ascii" nullable:"true"`
 
+	// IPv6地址分配方式，slaac或dhcpv6
+	GuestIp6AllocMode string `width:"16" charset:"ascii" nullable:"true" list:"user" update:"user" create:"optional"`
+
 	VlanId int `nullable:"false" default:"1" list:"user" update:"user" create:"optional"`
 
 	// 二层网络Id
@@ -1033,6 +1042,18 @@ func isValidNetworkInfo(userCred mcclient.TokenCredential, netConfig *api.Networ
 				}
 			}
 		}
+		if len(netConfig.Address6) > 0 {
+			ip6Addr, err := netutils2.NewIPV6Addr(netConfig.Address6)
+			if err != nil {
+				return httperrors.NewInputParameterError("%v", err)
+			}
+			if !net.IsAddress6InRange(ip6Addr) {
+				return httperrors.NewInputParameterError("Address %s not in range", netConfig.Address6)
+			}
+			if net.GetUsedAddresses6()[ip6Addr.String()] {
+				return httperrors.NewInputParameterError("Address %s has been used", netConfig.Address6)
+			}
+		}
 		if netConfig.BwLimit > api.MAX_BANDWIDTH {
 			return httperrors.NewInputParameterError("Bandwidth limit cannot exceed %dMbps", api.MAX_BANDWIDTH)
 		}
@@ -1554,6 +1575,40 @@ func (manager *SNetworkManager) ValidateCreateData(ctx context.Context, userCred
 		}
 	}
 
+	if len(input.GuestIp6Prefix) > 0 || len(input.GuestIp6Start) > 0 || len(input.GuestIp6End) > 0 {
+		if region.Provider == api.CLOUD_PROVIDER_ONECLOUD && vpc.Id != api.DEFAULT_VPC_ID {
+			return input, httperrors.NewNotSupportedError("ipv6 is not supported in vpc %q", vpc.GetName())
+		}
+		ip6Range, masklen6, err := parseNetworkIp6Range(input.GuestIp6Prefix, input.GuestIp6Start, input.GuestIp6End, input.GuestIp6Mask)
+		if err != nil {
+			return input, err
+		}
+		if len(input.GuestIp6AllocMode) == 0 {
+			input.GuestIp6AllocMode = api.IPV6_ALLOC_MODE_DHCPV6
+		}
+		err = validateNetworkIp6Options(ip6Range, masklen6, input.GuestGateway6, input.GuestDns6, input.GuestIp6AllocMode)
+		if err != nil {
+			return input, err
+		}
+		nets, err := vpc.GetNetworks()
+		if err != nil {
+			return input, httperrors.NewInternalServerError("fail to GetNetworks of vpc: %v", err)
+		}
+		if isOverlapNetworks6(nets, ip6Range) {
+			return input, httperrors.NewInputParameterError("Conflict ipv6 address space with existing networks in vpc %q", vpc.GetName())
+		}
+		input.GuestIp6Start = ip6Range.StartIp().String()
+		input.GuestIp6End = ip6Range.EndIp().String()
+		input.GuestIp6Mask = masklen6
+	} else {
+		input.GuestIp6Start = ""
+		input.GuestIp6End = ""
+		input.GuestIp6Mask = 0
+		input.GuestGateway6 = ""
+		input.GuestDns6 = ""
+		input.GuestIp6AllocMode = ""
+	}
+
 	input.GuestIpStart = ipStart.String()
 	input.GuestIpEnd = ipEnd.String()
 	input.SharableVirtualResourceCreateInput, err = manager.SSharableVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.SharableVirtualResourceCreateInput)
@@ -1666,6 +1721,14 @@ func (self *SNetwork) validateUpdateData(ctx context.Context, userCred mcclient.
 		}
 	}
 
+	if len(input.GuestIp6Start) > 0 || len(input.GuestIp6End) > 0 || input.GuestIp6Mask != nil ||
+		len(input.GuestGateway6) > 0 || len(input.GuestDns6) > 0 || len(input.GuestIp6AllocMode) > 0 {
+		input, err = self.validateUpdateIp6Data(input)
+		if err != nil {
+			return input, err
+		}
+	}
+
 	if input.IsAutoAlloc != nil && *input.IsAutoAlloc {
 		if self.ServerType != api.NETWORK_TYPE_GUEST {
 			return input, httperrors.NewInputParameterError("network server_type %s not support auto alloc", self.ServerType)
@@ -1690,6 +1753,12 @@ func (self *SNetwork) ValidateUpdateData(ctx context.Context, userCred mcclient.
 		input.GuestDns = ""
 		input.GuestDomain = ""
 		input.GuestDhcp = ""
+		input.GuestIp6Start = ""
+		input.GuestIp6End = ""
+		input.GuestIp6Mask = nil
+		input.GuestGateway6 = ""
+		input.GuestDns6 = ""
+		input.GuestIp6AllocMode = ""
 	}
 
 	var err error
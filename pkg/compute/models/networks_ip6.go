@@ -0,0 +1,312 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/util/regutils"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/netutils2"
+)
+
+const (
+	minIp6MaskLen = 8
+	maxIp6MaskLen = 126
+)
+
+func isValidIp6MaskLen(maskLen int8) bool {
+	return maskLen >= minIp6MaskLen && maskLen <= maxIp6MaskLen
+}
+
+// parseNetworkIp6Range returns the ipv6 address range either from a prefix,
+// e.g. 2001:db8:1:2::/64, or from start, end address and prefix length
+func parseNetworkIp6Range(prefix, start, end string, maskLen int8) (netutils2.IPV6AddrRange, int8, error) {
+	if len(prefix) > 0 {
+		ip, ipnet, err := net.ParseCIDR(prefix)
+		if err != nil || ip.To4() != nil {
+			return netutils2.IPV6AddrRange{}, 0, httperrors.NewInputParameterError("invalid ipv6 prefix %s", prefix)
+		}
+		ones, _ := ipnet.Mask.Size()
+		maskLen = int8(ones)
+		if !isValidIp6MaskLen(maskLen) {
+			return netutils2.IPV6AddrRange{}, 0, httperrors.NewInputParameterError("invalid ipv6 prefix length %d", maskLen)
+		}
+		netAddr, _ := netutils2.NewIPV6Addr(ipnet.IP.String())
+		// skip the subnet-router anycast address
+		return netutils2.NewIPV6AddrRange(netAddr.StepUp(), netAddr.BroadcastAddr(maskLen)), maskLen, nil
+	}
+	if !isValidIp6MaskLen(maskLen) {
+		return netutils2.IPV6AddrRange{}, 0, httperrors.NewInputParameterError("invalid ipv6 prefix length %d", maskLen)
+	}
+	startIp, err := netutils2.NewIPV6Addr(start)
+	if err != nil {
+		return netutils2.IPV6AddrRange{}, 0, httperrors.NewInputParameterError("invalid ipv6 start ip %s", start)
+	}
+	endIp, err := netutils2.NewIPV6Addr(end)
+	if err != nil {
+		return netutils2.IPV6AddrRange{}, 0, httperrors.NewInputParameterError("invalid ipv6 end ip %s", end)
+	}
+	if startIp.NetAddr(maskLen) != endIp.NetAddr(maskLen) {
+		return netutils2.IPV6AddrRange{}, 0, httperrors.NewInputParameterError("ipv6 start and end ip not in the same subnet")
+	}
+	return netutils2.NewIPV6AddrRange(startIp, endIp), maskLen, nil
+}
+
+func validateNetworkIp6Options(ipRange netutils2.IPV6AddrRange, maskLen int8, gateway6, dns6, allocMode string) error {
+	if !utils.IsInStringArray(allocMode, api.IPV6_ALLOC_MODES) {
+		return httperrors.NewInputParameterError("invalid ipv6 alloc mode %s", allocMode)
+	}
+	if allocMode == api.IPV6_ALLOC_MODE_SLAAC && maskLen != 64 {
+		return httperrors.NewInputParameterError("slaac requires ipv6 prefix length 64, got %d", maskLen)
+	}
+	if len(gateway6) > 0 {
+		gw, err := netutils2.NewIPV6Addr(gateway6)
+		if err != nil {
+			return httperrors.NewInputParameterError("invalid ipv6 gateway %s", gateway6)
+		}
+		if gw.NetAddr(maskLen) != ipRange.StartIp().NetAddr(maskLen) {
+			return httperrors.NewInputParameterError("ipv6 gateway must be in the same subnet as start, end ip")
+		}
+	}
+	if len(dns6) > 0 {
+		for _, dns := range strings.Split(dns6, ",") {
+			if !regutils.MatchIP6Addr(dns) {
+				return httperrors.NewInputParameterError("guest_dns6: Invalid IPv6 address %s", dns)
+			}
+		}
+	}
+	return nil
+}
+
+func isOverlapNetworks6(nets []SNetwork, ipRange netutils2.IPV6AddrRange) bool {
+	for i := range nets {
+		if !nets[i].HasIPv6() {
+			continue
+		}
+		if nets[i].getIP6Range().IsOverlap(ipRange) {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *SNetwork) validateUpdateIp6Data(input api.NetworkUpdateInput) (api.NetworkUpdateInput, error) {
+	start, end, maskLen := self.GuestIp6Start, self.GuestIp6End, self.GuestIp6Mask
+	if len(input.GuestIp6Start) > 0 {
+		start = input.GuestIp6Start
+	}
+	if len(input.GuestIp6End) > 0 {
+		end = input.GuestIp6End
+	}
+	if input.GuestIp6Mask != nil {
+		maskLen = *input.GuestIp6Mask
+	}
+	if len(start) == 0 || len(end) == 0 {
+		return input, httperrors.NewMissingParameterError("guest_ip6_start")
+	}
+	ipRange, maskLen, err := parseNetworkIp6Range("", start, end, maskLen)
+	if err != nil {
+		return input, err
+	}
+
+	gateway6, dns6, allocMode := self.GuestGateway6, self.GuestDns6, self.GetIp6AllocMode()
+	if len(input.GuestGateway6) > 0 {
+		gateway6 = input.GuestGateway6
+	}
+	if len(input.GuestDns6) > 0 {
+		dns6 = input.GuestDns6
+	}
+	if len(input.GuestIp6AllocMode) > 0 {
+		allocMode = input.GuestIp6AllocMode
+	}
+	err = validateNetworkIp6Options(ipRange, maskLen, gateway6, dns6, allocMode)
+	if err != nil {
+		return input, err
+	}
+
+	nets := NetworkManager.getAllNetworks(self.WireId, self.Id)
+	if nets == nil {
+		return input, httperrors.NewInternalServerError("query all networks fail")
+	}
+	if isOverlapNetworks6(nets, ipRange) {
+		return input, httperrors.NewInputParameterError("Conflict ipv6 address space with existing networks")
+	}
+
+	usedMap := self.GetUsedAddresses6()
+	if len(usedMap) > 0 && self.HasIPv6() && allocMode != self.GetIp6AllocMode() {
+		return input, httperrors.NewInputParameterError("cannot change ipv6 alloc mode when ipv6 addresses been assigned")
+	}
+	for usedIpStr := range usedMap {
+		usedIp, _ := netutils2.NewIPV6Addr(usedIpStr)
+		if !ipRange.Contains(usedIp) {
+			return input, httperrors.NewInputParameterError("IPv6 address been assigned out of new range")
+		}
+	}
+
+	input.GuestIp6Start = ipRange.StartIp().String()
+	input.GuestIp6End = ipRange.EndIp().String()
+	input.GuestIp6Mask = &maskLen
+	input.GuestIp6AllocMode = allocMode
+	return input, nil
+}
+
+func (self *SNetwork) HasIPv6() bool {
+	return len(self.GuestIp6Start) > 0 && len(self.GuestIp6End) > 0 && self.GuestIp6Mask > 0
+}
+
+func (self *SNetwork) GetIP6Range() netutils2.IPV6AddrRange {
+	return self.getIP6Range()
+}
+
+func (self *SNetwork) getIP6Range() netutils2.IPV6AddrRange {
+	start, _ := netutils2.NewIPV6Addr(self.GuestIp6Start)
+	end, _ := netutils2.NewIPV6Addr(self.GuestIp6End)
+	return netutils2.NewIPV6AddrRange(start, end)
+}
+
+func (self *SNetwork) GetNetAddr6() netutils2.IPV6Addr {
+	startIp, _ := netutils2.NewIPV6Addr(self.GuestIp6Start)
+	return startIp.NetAddr(self.GuestIp6Mask)
+}
+
+func (self *SNetwork) GetIp6AllocMode() string {
+	if len(self.GuestIp6AllocMode) > 0 {
+		return self.GuestIp6AllocMode
+	}
+	return api.IPV6_ALLOC_MODE_DHCPV6
+}
+
+func (self *SNetwork) IsAddress6InRange(address netutils2.IPV6Addr) bool {
+	return self.HasIPv6() && self.getIP6Range().Contains(address)
+}
+
+// GetUsedAddresses6 returns the ipv6 addresses assigned to guest nics and
+// eips of the network, in canonical form
+func (self *SNetwork) GetUsedAddresses6() map[string]bool {
+	used := make(map[string]bool)
+	addAddr := func(addrStr string) {
+		addr, err := netutils2.NewIPV6Addr(addrStr)
+		if err == nil {
+			used[addr.String()] = true
+		}
+	}
+	for addrStr := range self.GetUsedAddresses() {
+		addAddr(addrStr)
+	}
+
+	q := GuestnetworkManager.Query("ip6_addr").Equals("network_id", self.Id).IsNotEmpty("ip6_addr")
+	results, err := q.AllStringMap()
+	if err != nil {
+		log.Errorf("GetUsedAddresses6 fail %s", err)
+		return used
+	}
+	for _, result := range results {
+		addAddr(result["ip6_addr"])
+	}
+	return used
+}
+
+func (self *SNetwork) getFreeIP6(addrTable map[string]bool, candidate string, mac string, allocDir api.IPAllocationDirection) (string, error) {
+	iprange := self.getIP6Range()
+
+	var slaacAddr string
+	if self.GetIp6AllocMode() == api.IPV6_ALLOC_MODE_SLAAC {
+		hwAddr, err := net.ParseMAC(mac)
+		if err != nil {
+			return "", httperrors.NewInputParameterError("invalid mac address %s", mac)
+		}
+		addr, err := netutils2.SlaacAddr6(self.GetNetAddr6(), hwAddr)
+		if err != nil {
+			return "", httperrors.NewInputParameterError("%v", err)
+		}
+		if !iprange.Contains(addr) {
+			return "", httperrors.NewOutOfRangeError("slaac address %s out of range %s", addr, iprange)
+		}
+		slaacAddr = addr.String()
+	}
+	// Try candidate first
+	if len(candidate) > 0 {
+		candIP, err := netutils2.NewIPV6Addr(candidate)
+		if err != nil {
+			return "", httperrors.NewInputParameterError("%v", err)
+		}
+		if !iprange.Contains(candIP) {
+			return "", httperrors.NewInputParameterError("candidate %s out of range", candidate)
+		}
+		if len(slaacAddr) > 0 && candIP.String() != slaacAddr {
+			return "", httperrors.NewInputParameterError("candidate %s mismatch slaac address %s of mac %s", candidate, slaacAddr, mac)
+		}
+		if !addrTable[candIP.String()] {
+			return candIP.String(), nil
+		}
+	}
+	if len(slaacAddr) > 0 {
+		if addrTable[slaacAddr] {
+			return "", httperrors.NewConflictError("slaac address %s of mac %s has been used", slaacAddr, mac)
+		}
+		return slaacAddr, nil
+	}
+	if len(self.AllocPolicy) > 0 && api.IPAllocationDirection(self.AllocPolicy) != api.IPAllocationNone {
+		allocDir = api.IPAllocationDirection(self.AllocPolicy)
+	}
+	if len(allocDir) == 0 || allocDir == api.IPAllocationStepdown {
+		ip := iprange.EndIp()
+		for iprange.Contains(ip) {
+			if !addrTable[ip.String()] {
+				return ip.String(), nil
+			}
+			ip = ip.StepDown()
+		}
+	} else {
+		if allocDir == api.IPAllocationRadnom {
+			const MAX_TRIES = 5
+			for i := 0; i < MAX_TRIES; i += 1 {
+				ip := iprange.Random()
+				if !addrTable[ip.String()] {
+					return ip.String(), nil
+				}
+			}
+			// failed, fallback to IPAllocationStepup
+		}
+		ip := iprange.StartIp()
+		for iprange.Contains(ip) {
+			if !addrTable[ip.String()] {
+				return ip.String(), nil
+			}
+			ip = ip.StepUp()
+		}
+	}
+	return "", httperrors.NewInsufficientResourceError("Out of IPv6 address")
+}
+
+// GetFreeIP6 allocates an ipv6 address for the nic with mac. In slaac mode
+// the address is always derived from the mac address
+func (self *SNetwork) GetFreeIP6(ctx context.Context, userCred mcclient.TokenCredential, addrTable map[string]bool, candidate string, mac string, allocDir api.IPAllocationDirection) (string, error) {
+	if !self.HasIPv6() {
+		return "", httperrors.NewInputParameterError("network %s has no ipv6 address range", self.Name)
+	}
+	if addrTable == nil {
+		addrTable = self.GetUsedAddresses6()
+	}
+	return self.getFreeIP6(addrTable, candidate, mac, allocDir)
+}
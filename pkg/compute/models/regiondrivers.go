@@ -122,6 +122,12 @@ type IRegionDriver interface {
 	RequestResetToInstanceSnapshot(ctx context.Context, guest *SGuest, isp *SInstanceSnapshot, task taskman.ITask, params *jsonutils.JSONDict) error
 
 	//Nat gateway
+	RequestCreateNatGateway(ctx context.Context, userCred mcclient.TokenCredential, natgateway *SNatGateway, task taskman.ITask) error
+	RequestDeleteNatGateway(ctx context.Context, userCred mcclient.TokenCredential, natgateway *SNatGateway, task taskman.ITask) error
+	RequestCreateNatSEntry(ctx context.Context, userCred mcclient.TokenCredential, snatEntry *SNatSEntry, task taskman.ITask) error
+	RequestDeleteNatSEntry(ctx context.Context, userCred mcclient.TokenCredential, snatEntry *SNatSEntry, task taskman.ITask) error
+	RequestCreateNatDEntry(ctx context.Context, userCred mcclient.TokenCredential, dnatEntry *SNatDEntry, task taskman.ITask) error
+	RequestDeleteNatDEntry(ctx context.Context, userCred mcclient.TokenCredential, dnatEntry *SNatDEntry, task taskman.ITask) error
 	RequestBindIPToNatgateway(ctx context.Context, task taskman.ITask, natgateway *SNatGateway, eipID string) error
 	RequestUnBindIPFromNatgateway(ctx context.Context, task taskman.ITask, nat INatHelper, natgateway *SNatGateway) error
 	BindIPToNatgatewayRollback(ctx context.Context, eipId string) error
@@ -400,6 +400,10 @@ func (sg *SScalingGroup) GuestNumber() (int, error) {
 	return q.CountWithError()
 }
 
+func (sg *SScalingGroup) ScaleInProtectedNumber() (int, error) {
+	return ScalingGroupGuestManager.Query().Equals("scaling_group_id", sg.Id).IsTrue("scale_in_protected").CountWithError()
+}
+
 func (sg *SScalingGroup) ScalingPolicyNumber() (int, error) {
 	q := ScalingPolicyManager.Query().Equals("scaling_group_id", sg.Id)
 	return q.CountWithError()
@@ -498,6 +502,31 @@ func (sg *SScalingGroup) exec(ctx context.Context, action IScalingAction) (ret s
 		)
 		targetNum = sg.MinInstanceNumber
 	}
+	if targetNum < sg.DesireInstanceNumber {
+		// instances protected from scaling in can't be removed
+		protected, err := sg.ScaleInProtectedNumber()
+		if err != nil {
+			ret.code = 3
+			ret.reason = fmt.Sprintf("fail to count instances protected from scaling in: %s", err.Error())
+			return
+		}
+		if targetNum < protected {
+			if sg.DesireInstanceNumber <= protected {
+				ret.code = 2
+				ret.reason = fmt.Sprintf(
+					`Want to change the Desired Instance Number from "%d" to "%d", but "%d" instances are protected from scaling in`,
+					sg.DesireInstanceNumber, targetNum, protected,
+				)
+				return
+			}
+			ret.code = 1
+			ret.reason = fmt.Sprintf(
+				`Want to change the Desired Instance Number from "%d" to "%d", but "%d" instances are protected from scaling in`,
+				sg.DesireInstanceNumber, targetNum, protected,
+			)
+			targetNum = protected
+		}
+	}
 	ret.actionStr = fmt.Sprintf(`Change the Desired Instance Number from "%d" to "%d"`, sg.DesireInstanceNumber, targetNum)
 	_, err = db.Update(sg, func() error {
 		sg.DesireInstanceNumber = targetNum
@@ -518,10 +547,19 @@ func (sg *SScalingGroup) Scale(ctx context.Context, triggerDesc IScalingTriggerD
 	coolingTime int) error {
 	lockman.LockObject(ctx, sg)
 	defer lockman.ReleaseObject(ctx, sg)
+	// every scaling cools down the scaling group, so that opposite policies can't fire back to back;
+	// a policy also keeps its own cooling time
+	cooldowns := []IScalingCooldown{sg}
+	if c, ok := action.(IScalingCooldown); ok {
+		cooldowns = append(cooldowns, c)
+	}
 	isExec := false
 	defer func() {
 		if isExec && coolingTime > 0 {
-			sg.SetAllowScaleTime(time.Now().Add(time.Duration(coolingTime) * time.Second))
+			allowScaleTime := time.Now().Add(time.Duration(coolingTime) * time.Second)
+			for _, cooldown := range cooldowns {
+				cooldown.SetAllowScaleTime(allowScaleTime)
+			}
 		}
 	}()
 	if sg.Enabled.IsFalse() {
@@ -531,10 +569,10 @@ func (sg *SScalingGroup) Scale(ctx context.Context, triggerDesc IScalingTriggerD
 	if err != nil {
 		return errors.Wrapf(err, "create ScalingActivity whose ScalingGroup is %s error", sg.Id)
 	}
-	if action.CheckCoolTime() && !sg.AllowScale() {
+	if cooldown := coolingDown(cooldowns); action.CheckCoolTime() && cooldown != nil {
 		err = scalingActivity.SetReject("",
 			fmt.Sprintf("The Cooling Time limit the execution time of the policy to at least: %s",
-				sg.AllowScaleTime.In(CoolingTimeLocation).Format("2006-01-02 15:04:05 -0700")))
+				cooldown.GetAllowScaleTime().In(CoolingTimeLocation).Format("2006-01-02 15:04:05 -0700")))
 		return nil
 	}
 
@@ -641,10 +679,28 @@ func (sg *SScalingGroup) PostCreate(ctx context.Context, userCred mcclient.Token
 	})
 }
 
+// coolingDown returns the cooldown which forbids scaling for the longest time, nil if none forbids
+func coolingDown(cooldowns []IScalingCooldown) IScalingCooldown {
+	var ret IScalingCooldown
+	for _, cooldown := range cooldowns {
+		if cooldown.AllowScale() {
+			continue
+		}
+		if ret == nil || cooldown.GetAllowScaleTime().After(ret.GetAllowScaleTime()) {
+			ret = cooldown
+		}
+	}
+	return ret
+}
+
 func (sg *SScalingGroup) AllowScale() bool {
 	return sg.AllowScaleTime.Before(time.Now())
 }
 
+func (sg *SScalingGroup) GetAllowScaleTime() time.Time {
+	return sg.AllowScaleTime
+}
+
 func (sg *SScalingGroup) SetAllowScaleTime(t time.Time) {
 	if sg.AllowScaleTime.After(t) {
 		return
@@ -686,6 +742,176 @@ func (sg *SScalingGroup) PerformDisable(ctx context.Context, userCred mcclient.T
 	return nil, nil
 }
 
+func (sg *SScalingGroup) AllowPerformSetScaleInProtection(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, input api.ScalingGroupPerformScaleInProtectionInput) bool {
+	return sg.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, sg, "set-scale-in-protection")
+}
+
+// PerformSetScaleInProtection protects instances of scaling group from being removed when scaling in
+func (sg *SScalingGroup) PerformSetScaleInProtection(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, input api.ScalingGroupPerformScaleInProtectionInput) (jsonutils.JSONObject, error) {
+	if len(input.Guests) == 0 {
+		return nil, httperrors.NewMissingParameterError("guests")
+	}
+	guestIds := make([]string, 0, len(input.Guests))
+	for _, idOrName := range input.Guests {
+		guest, err := GuestManager.FetchByIdOrName(userCred, idOrName)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(GuestManager.Keyword(), idOrName)
+			}
+			return nil, errors.Wrap(err, "GuestManager.FetchByIdOrName")
+		}
+		guestIds = append(guestIds, guest.GetId())
+	}
+	sggs, err := sg.ScalingGroupGuests(guestIds)
+	if err != nil {
+		return nil, errors.Wrap(err, "ScalingGroup.ScalingGroupGuests")
+	}
+	if len(sggs) != len(guestIds) {
+		return nil, httperrors.NewInputParameterError("some guests don't belong to ScalingGroup '%s'", sg.Id)
+	}
+	for i := range sggs {
+		if err := sggs[i].SetScaleInProtected(input.Protected); err != nil {
+			return nil, errors.Wrapf(err, "set scale-in protection of guest %s", sggs[i].GuestId)
+		}
+	}
+	logclient.AddActionLogWithContext(ctx, sg, logclient.ACT_UPDATE, input, userCred, true)
+	return nil, nil
+}
+
+// ActiveInstanceRefresh returns the instance refresh in progress or rolling back, nil if none.
+func (sg *SScalingGroup) ActiveInstanceRefresh() (*SScalingInstanceRefresh, error) {
+	return ScalingInstanceRefreshManager.fetchLatest(sg.Id, activeInstanceRefreshStatus...)
+}
+
+func (sg *SScalingGroup) AllowPerformInstanceRefresh(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, input api.ScalingGroupPerformInstanceRefreshInput) bool {
+	return sg.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, sg, "instance-refresh")
+}
+
+// PerformInstanceRefresh replaces the instances of scaling group in batches with the instances created from
+// the guest template. The scaling controller creates the new instances of a batch and waits for them to pass
+// the health check before removing the old ones.
+func (sg *SScalingGroup) PerformInstanceRefresh(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, input api.ScalingGroupPerformInstanceRefreshInput) (jsonutils.JSONObject, error) {
+	if sg.Enabled.IsFalse() {
+		return nil, httperrors.NewForbiddenError("ScalingGroup '%s' is disabled", sg.Id)
+	}
+	if input.MinHealthyPercentage == nil {
+		defaultPercentage := 90
+		input.MinHealthyPercentage = &defaultPercentage
+	}
+	if *input.MinHealthyPercentage < 0 || *input.MinHealthyPercentage > 100 {
+		return nil, httperrors.NewInputParameterError("min_healthy_percentage should between 0 and 100")
+	}
+	if input.BatchSize < 0 {
+		return nil, httperrors.NewInputParameterError("batch_size should not be smaller than 0")
+	}
+	if input.Pause < 0 {
+		return nil, httperrors.NewInputParameterError("pause should not be smaller than 0")
+	}
+
+	lockman.LockObject(ctx, sg)
+	defer lockman.ReleaseObject(ctx, sg)
+
+	active, err := sg.ActiveInstanceRefresh()
+	if err != nil {
+		return nil, errors.Wrap(err, "ScalingGroup.ActiveInstanceRefresh")
+	}
+	if active != nil {
+		return nil, httperrors.NewConflictError("instance refresh '%s' of ScalingGroup '%s' is %s", active.Id, sg.Id, active.Status)
+	}
+
+	previous := sg.GetGuestTemplate()
+	gt := previous
+	idOrName := input.GuestTemplate
+	if len(input.GuestTemplateId) != 0 {
+		idOrName = input.GuestTemplateId
+	}
+	if len(idOrName) != 0 {
+		model, err := GuestTemplateManager.FetchByIdOrName(userCred, idOrName)
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, httperrors.NewInputParameterError("no such guest template %s", idOrName)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "GuestTempalteManager.FetchByIdOrName")
+		}
+		gt = model.(*SGuestTemplate)
+	}
+	if gt == nil {
+		return nil, httperrors.NewInputParameterError("ScalingGroup '%s' has no guest template", sg.Id)
+	}
+	nets, err := sg.NetworkIds()
+	if err != nil {
+		return nil, errors.Wrap(err, "ScalingGroup.NetworkIds")
+	}
+	if ok, reason := gt.Validate(ctx, userCred, sg.GetOwnerId(),
+		SGuestTemplateValidate{sg.Hypervisor, sg.CloudregionId, sg.VpcId, nets}); !ok {
+		return nil, httperrors.NewInputParameterError("the guest template %s is not valid in cloudregion %s, "+
+			"reason: %s", gt.Id, sg.CloudregionId, reason)
+	}
+	input.GuestTemplateId = gt.Id
+
+	sir, err := ScalingInstanceRefreshManager.CreateInstanceRefresh(ctx, sg, input, gt, previous)
+	if err != nil {
+		return nil, errors.Wrap(err, "ScalingInstanceRefreshManager.CreateInstanceRefresh")
+	}
+	if sg.GuestTemplateId != gt.Id {
+		_, err = db.Update(sg, func() error {
+			sg.GuestTemplateId = gt.Id
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "update GuestTemplateId of ScalingGroup")
+		}
+	}
+	logclient.AddActionLogWithContext(ctx, sg, logclient.ACT_UPDATE, input, userCred, true)
+	return jsonutils.Marshal(sir), nil
+}
+
+func (sg *SScalingGroup) AllowPerformRollbackInstanceRefresh(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return sg.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, sg, "rollback-instance-refresh")
+}
+
+// PerformRollbackInstanceRefresh stops the latest instance refresh and replaces the instances created by it
+// with the instances created from the previous guest template.
+func (sg *SScalingGroup) PerformRollbackInstanceRefresh(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	lockman.LockObject(ctx, sg)
+	defer lockman.ReleaseObject(ctx, sg)
+
+	sir, err := ScalingInstanceRefreshManager.fetchLatest(sg.Id, api.SIR_STATUS_IN_PROGRESS, api.SIR_STATUS_FAILED,
+		api.SIR_STATUS_ROLLING_BACK, api.SIR_STATUS_SUCCEED, api.SIR_STATUS_ROLLBACK_SUCCEED, api.SIR_STATUS_ROLLBACK_FAILED)
+	if err != nil {
+		return nil, errors.Wrap(err, "ScalingInstanceRefreshManager.fetchLatest")
+	}
+	if sir == nil {
+		return nil, httperrors.NewNotFoundError("ScalingGroup '%s' has no instance refresh", sg.Id)
+	}
+	if !utils.IsInStringArray(sir.Status, []string{api.SIR_STATUS_IN_PROGRESS, api.SIR_STATUS_FAILED}) {
+		return nil, httperrors.NewInvalidStatusError("can't roll back instance refresh '%s' in status %s", sir.Id, sir.Status)
+	}
+	if sir.PreviousContent == nil {
+		return nil, httperrors.NewUnsupportOperationError("the content of guest template before instance refresh '%s' is unknown", sir.Id)
+	}
+	if err := sir.StartRollback(); err != nil {
+		return nil, errors.Wrap(err, "ScalingInstanceRefresh.StartRollback")
+	}
+	if len(sir.PreviousGuestTemplateId) != 0 && sg.GuestTemplateId != sir.PreviousGuestTemplateId {
+		_, err = db.Update(sg, func() error {
+			sg.GuestTemplateId = sir.PreviousGuestTemplateId
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "update GuestTemplateId of ScalingGroup")
+		}
+	}
+	logclient.AddActionLogWithContext(ctx, sg, logclient.ACT_UPDATE, jsonutils.Marshal(sir), userCred, true)
+	return nil, nil
+}
+
 func (s *SGuest) AllowPerformDetachScalingGroup(ctx context.Context, userCred mcclient.TokenCredential,
 	query jsonutils.JSONObject, input api.SGPerformDetachScalingGroupInput) bool {
 	return s.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, s, "detach-scaling-group")
@@ -0,0 +1,359 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SScalingInstanceRefreshManager struct {
+	db.SStatusStandaloneResourceBaseManager
+	SScalingGroupResourceBaseManager
+}
+
+// SScalingInstanceRefresh replaces the instances of scaling group in batches with the instances created from
+// the snapshot of guest template, so that the existing instances pick up the changes of guest template.
+// The instances created by the refresh are marked with its id in SScalingGroupGuest, rolling back replaces
+// them with the instances created from PreviousContent.
+type SScalingInstanceRefresh struct {
+	db.SStatusStandaloneResourceBase
+	SScalingGroupResourceBase
+
+	// 替换过程中健康实例数占期望实例数的最小百分比
+	MinHealthyPercentage int `nullable:"false" default:"90" list:"user" get:"user"`
+	// 每批替换的实例数
+	BatchSize int `nullable:"false" default:"0" list:"user" get:"user"`
+	// 两批替换之间的暂停时间
+	Pause int `nullable:"false" default:"0" list:"user" get:"user"`
+
+	GuestTemplateId string `width:"36" charset:"ascii" list:"user" get:"user"`
+	// Content of guest template when the refresh started
+	Content jsonutils.JSONObject `nullable:"true"`
+
+	PreviousGuestTemplateId string `width:"36" charset:"ascii" list:"user" get:"user"`
+	// Content which the refresh rolls back to, empty if unknown
+	PreviousContent jsonutils.JSONObject `nullable:"true"`
+
+	// 已替换的实例数
+	RefreshedNumber int       `nullable:"false" default:"0" list:"user" get:"user"`
+	StartTime       time.Time `list:"user" get:"user"`
+	EndTime         time.Time `list:"user" get:"user"`
+	Reason          string    `width:"1024" charset:"ascii" get:"user" list:"user"`
+
+	// NextBatchTime is the earliest time to start the next batch
+	NextBatchTime time.Time
+}
+
+var ScalingInstanceRefreshManager *SScalingInstanceRefreshManager
+
+func init() {
+	ScalingInstanceRefreshManager = &SScalingInstanceRefreshManager{
+		SStatusStandaloneResourceBaseManager: db.NewStatusStandaloneResourceBaseManager(
+			SScalingInstanceRefresh{},
+			"scalinginstancerefreshes_tbl",
+			"scalinginstancerefresh",
+			"scalinginstancerefreshes",
+		),
+	}
+	ScalingInstanceRefreshManager.SetVirtualObject(ScalingInstanceRefreshManager)
+}
+
+var activeInstanceRefreshStatus = []string{api.SIR_STATUS_IN_PROGRESS, api.SIR_STATUS_ROLLING_BACK}
+
+// FetchDue fetches all the refreshes which should start the next batch
+func (sirm *SScalingInstanceRefreshManager) FetchDue() ([]SScalingInstanceRefresh, error) {
+	q := sirm.Query().In("status", activeInstanceRefreshStatus).LE("next_batch_time", time.Now())
+	sirs := make([]SScalingInstanceRefresh, 0, 1)
+	err := db.FetchModelObjects(sirm, q, &sirs)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	return sirs, nil
+}
+
+func (sirm *SScalingInstanceRefreshManager) fetchLatest(sgId string, status ...string) (*SScalingInstanceRefresh, error) {
+	q := sirm.Query().Equals("scaling_group_id", sgId).In("status", status).Desc("start_time").Limit(1)
+	sirs := make([]SScalingInstanceRefresh, 0, 1)
+	err := db.FetchModelObjects(sirm, q, &sirs)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	if len(sirs) == 0 {
+		return nil, nil
+	}
+	return &sirs[0], nil
+}
+
+func (sirm *SScalingInstanceRefreshManager) CreateInstanceRefresh(ctx context.Context, sg *SScalingGroup,
+	input api.ScalingGroupPerformInstanceRefreshInput, gt, previous *SGuestTemplate) (*SScalingInstanceRefresh, error) {
+	sir := &SScalingInstanceRefresh{
+		MinHealthyPercentage: *input.MinHealthyPercentage,
+		BatchSize:            input.BatchSize,
+		Pause:                input.Pause,
+		GuestTemplateId:      gt.Id,
+		Content:              gt.Content,
+		StartTime:            time.Now(),
+	}
+	sir.ScalingGroupId = sg.Id
+	sir.Status = api.SIR_STATUS_IN_PROGRESS
+	sir.Name = fmt.Sprintf("%s-refresh-%s", sg.Name, sir.StartTime.Format("20060102150405"))
+	if previous != nil {
+		sir.PreviousGuestTemplateId = previous.Id
+		sir.PreviousContent = previous.Content
+	}
+	if previous == nil || previous.Id == gt.Id {
+		// the guest template was modified in place, the content that the instances were
+		// created from is only known if they were refreshed to it
+		sir.PreviousGuestTemplateId = gt.Id
+		sir.PreviousContent = nil
+		last, err := sirm.fetchLatest(sg.Id, api.SIR_STATUS_SUCCEED)
+		if err != nil {
+			return nil, err
+		}
+		if last != nil && last.GuestTemplateId == gt.Id {
+			sir.PreviousContent = last.Content
+		}
+	}
+	sir.SetModelManager(sirm, sir)
+	return sir, sirm.TableSpec().Insert(ctx, sir)
+}
+
+// MinHealthyNumber returns the number of healthy instances that the scaling group keeps during refresh
+func (sir *SScalingInstanceRefresh) MinHealthyNumber(desire int) int {
+	return int(math.Ceil(float64(desire*sir.MinHealthyPercentage) / 100))
+}
+
+// BatchNumber returns the number of instances replaced in the next batch, remain is the number of
+// instances waiting to be replaced
+func (sir *SScalingInstanceRefresh) BatchNumber(desire, remain int) int {
+	batch := sir.BatchSize
+	if batch <= 0 {
+		batch = desire - sir.MinHealthyNumber(desire)
+	}
+	if batch < 1 {
+		batch = 1
+	}
+	if batch > remain {
+		batch = remain
+	}
+	return batch
+}
+
+func (sir *SScalingInstanceRefresh) IsRollback() bool {
+	return sir.Status == api.SIR_STATUS_ROLLING_BACK
+}
+
+// BatchContent returns the guest template content and the refresh id marked on the new instances of next batch
+func (sir *SScalingInstanceRefresh) BatchContent() (jsonutils.JSONObject, string) {
+	if sir.IsRollback() {
+		return sir.PreviousContent, ""
+	}
+	return sir.Content, sir.Id
+}
+
+// InstancesToReplace returns the instances that haven't been replaced, the instances protected from
+// scaling in are left alone.
+func (sir *SScalingInstanceRefresh) InstancesToReplace() ([]SGuest, error) {
+	sggQ := ScalingGroupGuestManager.Query("guest_id").Equals("scaling_group_id", sir.ScalingGroupId).
+		Equals("guest_status", api.SG_GUEST_STATUS_READY).IsFalse("scale_in_protected")
+	if sir.IsRollback() {
+		sggQ = sggQ.Equals("instance_refresh_id", sir.Id)
+	} else {
+		sggQ = sggQ.Filter(sqlchemy.OR(sqlchemy.IsNullOrEmpty(sggQ.Field("instance_refresh_id")),
+			sqlchemy.NotEquals(sggQ.Field("instance_refresh_id"), sir.Id)))
+	}
+	q := GuestManager.Query().In("id", sggQ.SubQuery()).IsFalse("pending_deleted").Asc("created_at")
+	guests := make([]SGuest, 0, 1)
+	err := db.FetchModelObjects(GuestManager, q, &guests)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	return guests, nil
+}
+
+func (sir *SScalingInstanceRefresh) TriggerDescription() string {
+	if sir.IsRollback() {
+		return fmt.Sprintf(`Instance refresh "%s" rolls back the instances to the previous guest template "%s"`,
+			sir.Name, sir.PreviousGuestTemplateId)
+	}
+	return fmt.Sprintf(`Instance refresh "%s" replaces the instances with the guest template "%s"`,
+		sir.Name, sir.GuestTemplateId)
+}
+
+// latest fetches the refresh again, it returns nil if the refresh has left the status during a batch,
+// e.g. it was rolled back.
+func (sir *SScalingInstanceRefresh) latest() (*SScalingInstanceRefresh, error) {
+	model, err := ScalingInstanceRefreshManager.FetchById(sir.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, "ScalingInstanceRefreshManager.FetchById")
+	}
+	latest := model.(*SScalingInstanceRefresh)
+	if latest.Status != sir.Status {
+		return nil, nil
+	}
+	return latest, nil
+}
+
+// FinishBatch records the replaced instances and schedules the next batch
+func (sir *SScalingInstanceRefresh) FinishBatch(replaced int) error {
+	latest, err := sir.latest()
+	if err != nil || latest == nil {
+		return err
+	}
+	_, err = db.Update(latest, func() error {
+		latest.RefreshedNumber += replaced
+		latest.NextBatchTime = time.Now().Add(time.Duration(latest.Pause) * time.Second)
+		return nil
+	})
+	return err
+}
+
+func (sir *SScalingInstanceRefresh) setResult(status, reason string) error {
+	latest, err := sir.latest()
+	if err != nil || latest == nil {
+		return err
+	}
+	_, err = db.Update(latest, func() error {
+		latest.Status = status
+		latest.EndTime = time.Now()
+		if len(reason) != 0 {
+			latest.Reason = reason
+		}
+		return nil
+	})
+	return err
+}
+
+func (sir *SScalingInstanceRefresh) SetSucceed() error {
+	if sir.IsRollback() {
+		return sir.setResult(api.SIR_STATUS_ROLLBACK_SUCCEED, "")
+	}
+	return sir.setResult(api.SIR_STATUS_SUCCEED, "")
+}
+
+func (sir *SScalingInstanceRefresh) SetFailed(reason string) error {
+	if sir.IsRollback() {
+		return sir.setResult(api.SIR_STATUS_ROLLBACK_FAILED, reason)
+	}
+	return sir.setResult(api.SIR_STATUS_FAILED, reason)
+}
+
+// StartRollback replaces the instances created by the refresh with the previous content from the next batch
+func (sir *SScalingInstanceRefresh) StartRollback() error {
+	_, err := db.Update(sir, func() error {
+		sir.Status = api.SIR_STATUS_ROLLING_BACK
+		sir.RefreshedNumber = 0
+		sir.NextBatchTime = time.Now()
+		sir.EndTime = time.Time{}
+		return nil
+	})
+	return err
+}
+
+func (sirm *SScalingInstanceRefreshManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := sirm.SStatusStandaloneResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return sirm.SScalingGroupResourceBaseManager.QueryDistinctExtraField(q, field)
+}
+
+func (sirm *SScalingInstanceRefreshManager) OrderByExtraFields(ctx context.Context, q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential, query api.ScalingInstanceRefreshListInput) (*sqlchemy.SQuery, error) {
+	return sirm.SStatusStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+}
+
+func (sirm *SScalingInstanceRefreshManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.ScalingInstanceRefreshDetails {
+	rows := make([]api.ScalingInstanceRefreshDetails, len(objs))
+	statusRows := sirm.SStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	sgRows := sirm.SScalingGroupResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i].StatusStandaloneResourceDetails = statusRows[i]
+		rows[i].ScalingGroupResourceInfo = sgRows[i]
+	}
+	return rows
+}
+
+func (sir *SScalingInstanceRefresh) GetExtraDetails(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, isList bool) (api.ScalingInstanceRefreshDetails, error) {
+	return api.ScalingInstanceRefreshDetails{}, nil
+}
+
+func (sirm *SScalingInstanceRefreshManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential, input api.ScalingInstanceRefreshListInput) (*sqlchemy.SQuery, error) {
+
+	q, err := sirm.SStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, input.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, err
+	}
+	q, err = sirm.SScalingGroupResourceBaseManager.ListItemFilter(ctx, q, userCred, input.ScalingGroupFilterListInput)
+	if err != nil {
+		return nil, err
+	}
+	q = q.Desc("start_time")
+	return q, nil
+}
+
+func (sirm *SScalingInstanceRefreshManager) NamespaceScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeProject
+}
+
+func (sirm *SScalingInstanceRefreshManager) ResourceScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeProject
+}
+
+func (sirm *SScalingInstanceRefreshManager) FilterByOwner(q *sqlchemy.SQuery, owner mcclient.IIdentityProvider, scope rbacutils.TRbacScope) *sqlchemy.SQuery {
+	if owner != nil {
+		switch scope {
+		case rbacutils.ScopeProject, rbacutils.ScopeDomain:
+			scalingGroupQ := ScalingGroupManager.Query("id", "domain_id").SubQuery()
+			q = q.Join(scalingGroupQ, sqlchemy.Equals(q.Field("scaling_group_id"), scalingGroupQ.Field("id")))
+			q = q.Filter(sqlchemy.Equals(scalingGroupQ.Field("domain_id"), owner.GetProjectDomainId()))
+		}
+	}
+	return q
+}
+
+func (sirm *SScalingInstanceRefreshManager) FetchOwnerId(ctx context.Context, data jsonutils.JSONObject) (mcclient.IIdentityProvider, error) {
+	return db.FetchDomainInfo(ctx, data)
+}
+
+func (sir *SScalingInstanceRefresh) GetOwnerId() mcclient.IIdentityProvider {
+	scalingGroup := sir.GetScalingGroup()
+	if scalingGroup != nil {
+		return scalingGroup.GetOwnerId()
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "testing"
+
+func TestScalingInstanceRefreshBatchNumber(t *testing.T) {
+	cases := []struct {
+		percentage int
+		batchSize  int
+		desire     int
+		remain     int
+		minHealthy int
+		want       int
+	}{
+		{90, 0, 10, 10, 9, 1},
+		{50, 0, 10, 10, 5, 5},
+		{50, 0, 10, 3, 5, 3},
+		{100, 0, 4, 4, 4, 1},
+		{0, 0, 4, 4, 0, 4},
+		{90, 3, 10, 10, 9, 3},
+		{90, 3, 10, 2, 9, 2},
+		{75, 0, 3, 3, 3, 1},
+		{90, 0, 0, 2, 0, 1},
+	}
+	for _, c := range cases {
+		sir := &SScalingInstanceRefresh{MinHealthyPercentage: c.percentage, BatchSize: c.batchSize}
+		if got := sir.MinHealthyNumber(c.desire); got != c.minHealthy {
+			t.Errorf("percentage %d desire %d: want min healthy %d, got %d", c.percentage, c.desire, c.minHealthy, got)
+		}
+		if got := sir.BatchNumber(c.desire, c.remain); got != c.want {
+			t.Errorf("percentage %d batch size %d desire %d remain %d: want %d, got %d",
+				c.percentage, c.batchSize, c.desire, c.remain, c.want, got)
+		}
+	}
+}
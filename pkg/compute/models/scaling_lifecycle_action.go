@@ -0,0 +1,522 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// The heartbeat can't postpone the lifecycle action beyond this
+const lifecycleActionMaxWait = 48 * time.Hour
+
+type SScalingLifecycleActionManager struct {
+	db.SStatusStandaloneResourceBaseManager
+	SScalingGroupResourceBaseManager
+}
+
+// SScalingLifecycleAction records that a guest waits for a lifecycle hook, its id is the token to complete it
+type SScalingLifecycleAction struct {
+	db.SStatusStandaloneResourceBase
+	SScalingGroupResourceBase
+
+	LifecycleHookId string `width:"36" charset:"ascii" nullable:"false" index:"true" list:"user" get:"user"`
+	GuestId         string `width:"36" charset:"ascii" nullable:"false" index:"true" list:"user" get:"user"`
+	// 挂钩的伸缩行为
+	Transition string `width:"16" charset:"ascii" nullable:"false" list:"user" get:"user"`
+	// 生命周期动作的结果
+	Result string `width:"16" charset:"ascii" list:"user" get:"user"`
+
+	StartTime time.Time `list:"user" get:"user"`
+	// 超时时间, 超时后使用挂钩的默认结果
+	TimeoutAt time.Time `list:"user" get:"user"`
+	EndTime   time.Time `list:"user" get:"user"`
+}
+
+var ScalingLifecycleActionManager *SScalingLifecycleActionManager
+
+func init() {
+	ScalingLifecycleActionManager = &SScalingLifecycleActionManager{
+		SStatusStandaloneResourceBaseManager: db.NewStatusStandaloneResourceBaseManager(
+			SScalingLifecycleAction{},
+			"scalinglifecycleactions_tbl",
+			"scalinglifecycleaction",
+			"scalinglifecycleactions",
+		),
+	}
+	ScalingLifecycleActionManager.SetVirtualObject(ScalingLifecycleActionManager)
+}
+
+// StartLifecycleActions puts the guests in pending status and notifies the lifecycle hooks of transition,
+// nothing is returned if there is no hook.
+func (slam *SScalingLifecycleActionManager) StartLifecycleActions(ctx context.Context, userCred mcclient.TokenCredential,
+	sg *SScalingGroup, transition string, guestIds []string) ([]SScalingLifecycleAction, error) {
+	hooks, err := sg.LifecycleHooks(transition)
+	if err != nil {
+		return nil, errors.Wrap(err, "ScalingGroup.LifecycleHooks")
+	}
+	if len(hooks) == 0 || len(guestIds) == 0 {
+		return nil, nil
+	}
+	sggs, err := sg.ScalingGroupGuests(guestIds)
+	if err != nil {
+		return nil, errors.Wrap(err, "ScalingGroup.ScalingGroupGuests")
+	}
+	guestStatus := api.SG_GUEST_STATUS_PENDING_LAUNCH
+	if transition == api.LIFECYCLE_TRANSITION_TERMINATE {
+		guestStatus = api.SG_GUEST_STATUS_PENDING_TERMINATE
+	}
+	for i := range sggs {
+		err := sggs[i].SetGuestStatus(guestStatus)
+		if err != nil {
+			return nil, errors.Wrapf(err, "set status of ScalingGroupGuest '%s'", sggs[i].GuestId)
+		}
+	}
+	now := time.Now()
+	actions := make([]SScalingLifecycleAction, 0, len(hooks)*len(guestIds))
+	for _, guestId := range guestIds {
+		for i := range hooks {
+			action := SScalingLifecycleAction{
+				LifecycleHookId: hooks[i].Id,
+				GuestId:         guestId,
+				Transition:      transition,
+				StartTime:       now,
+				TimeoutAt:       now.Add(time.Duration(hooks[i].HeartbeatTimeout) * time.Second),
+			}
+			action.ScalingGroupId = sg.Id
+			action.Status = api.SLA_STATUS_PENDING
+			action.Name = hooks[i].Name
+			action.SetModelManager(slam, &action)
+			err := slam.TableSpec().Insert(ctx, &action)
+			if err != nil {
+				return nil, errors.Wrap(err, "insert ScalingLifecycleAction")
+			}
+			action.notify(ctx, userCred, &hooks[i])
+			actions = append(actions, action)
+		}
+	}
+	return actions, nil
+}
+
+// notify fires the webhook and sends the notification to the receivers of hook
+func (sla *SScalingLifecycleAction) notify(ctx context.Context, userCred mcclient.TokenCredential, hook *SScalingLifecycleHook) {
+	notifyclient.NotifyWebhook(ctx, userCred, sla, notifyclient.ActionExecute)
+	receivers := hook.GetReceivers()
+	if len(receivers) == 0 {
+		return
+	}
+	ret, err := db.FetchCustomizeColumns(ScalingLifecycleActionManager, ctx, userCred, jsonutils.NewDict(),
+		[]interface{}{sla}, stringutils2.SSortedStrings{}, false)
+	if err != nil || len(ret) == 0 {
+		log.Errorf("unable to fetch details of ScalingLifecycleAction '%s': %v", sla.Id, err)
+		return
+	}
+	event := notifyclient.Event.WithAction(notifyclient.ActionExecute).WithResourceType(ScalingLifecycleActionManager)
+	notifyclient.NotifyNormalWithCtx(ctx, receivers, false, event.String(), ret[0])
+}
+
+// CheckLifecycleActions takes the default result of hook for the actions reached the timeout, and returns
+// the latest actions.
+func (slam *SScalingLifecycleActionManager) CheckLifecycleActions(ctx context.Context, ids []string) ([]SScalingLifecycleAction, error) {
+	actions := make([]SScalingLifecycleAction, 0, len(ids))
+	err := db.FetchModelObjects(slam, slam.Query().In("id", ids), &actions)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	now := time.Now()
+	for i := range actions {
+		action := &actions[i]
+		if action.Status != api.SLA_STATUS_PENDING || action.TimeoutAt.After(now) {
+			continue
+		}
+		err := action.timeout(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "time out ScalingLifecycleAction '%s'", action.Id)
+		}
+	}
+	return actions, nil
+}
+
+// lifecycleDefaultResult is the result taken by the lifecycle actions of hook reached the timeout
+func lifecycleDefaultResult(hook *SScalingLifecycleHook) string {
+	if hook == nil || len(hook.DefaultResult) == 0 {
+		return api.LIFECYCLE_RESULT_CONTINUE
+	}
+	return hook.DefaultResult
+}
+
+// timeout sets the default result of hook if the action is still pending, the action is reloaded
+// under lock so that a concurrent complete is never overwritten.
+func (sla *SScalingLifecycleAction) timeout(ctx context.Context) error {
+	lockman.LockObject(ctx, sla)
+	defer lockman.ReleaseObject(ctx, sla)
+	err := sla.reload()
+	if err != nil {
+		return err
+	}
+	if sla.Status != api.SLA_STATUS_PENDING {
+		return nil
+	}
+	var hook *SScalingLifecycleHook
+	model, err := ScalingLifecycleHookManager.FetchById(sla.LifecycleHookId)
+	if err == nil {
+		hook = model.(*SScalingLifecycleHook)
+	}
+	return sla.setResult(api.SLA_STATUS_TIMEOUT, lifecycleDefaultResult(hook))
+}
+
+func (sla *SScalingLifecycleAction) reload() error {
+	model, err := ScalingLifecycleActionManager.FetchById(sla.Id)
+	if err != nil {
+		return errors.Wrapf(err, "fetch ScalingLifecycleAction '%s'", sla.Id)
+	}
+	*sla = *model.(*SScalingLifecycleAction)
+	sla.SetModelManager(ScalingLifecycleActionManager, sla)
+	return nil
+}
+
+// LifecycleResults returns the results of the guests whose lifecycle actions are all finished, a guest is
+// abandoned if any of its actions is abandoned.
+func LifecycleResults(actions []SScalingLifecycleAction) map[string]string {
+	results := make(map[string]string)
+	pending := make(map[string]bool)
+	for i := range actions {
+		action := &actions[i]
+		if action.Status == api.SLA_STATUS_PENDING {
+			pending[action.GuestId] = true
+			continue
+		}
+		if results[action.GuestId] != api.LIFECYCLE_RESULT_ABANDON {
+			results[action.GuestId] = action.Result
+		}
+	}
+	for guestId := range pending {
+		delete(results, guestId)
+	}
+	return results
+}
+
+func (sla *SScalingLifecycleAction) setResult(status, result string) error {
+	_, err := db.Update(sla, func() error {
+		sla.Status = status
+		sla.Result = result
+		sla.EndTime = time.Now()
+		return nil
+	})
+	return err
+}
+
+// lifecycleHeartbeatTimeoutAt returns the new timeout of lifecycle action started at start, which is
+// postponed by the heartbeat timeout of hook but never beyond lifecycleActionMaxWait
+func lifecycleHeartbeatTimeoutAt(start, now time.Time, hook *SScalingLifecycleHook) time.Time {
+	timeoutAt := now.Add(time.Duration(hook.HeartbeatTimeout) * time.Second)
+	if deadline := start.Add(lifecycleActionMaxWait); timeoutAt.After(deadline) {
+		return deadline
+	}
+	return timeoutAt
+}
+
+// Heartbeat postpones the timeout of lifecycle action by the heartbeat timeout of hook
+func (sla *SScalingLifecycleAction) Heartbeat(hook *SScalingLifecycleHook) error {
+	timeoutAt := lifecycleHeartbeatTimeoutAt(sla.StartTime, time.Now(), hook)
+	_, err := db.Update(sla, func() error {
+		sla.TimeoutAt = timeoutAt
+		return nil
+	})
+	return err
+}
+
+// LifecycleGuestKept tells whether the guest stays in scaling group once its lifecycle actions of transition
+// finished with result, the others are removed from scaling group.
+func LifecycleGuestKept(transition, result string) bool {
+	return transition == api.LIFECYCLE_TRANSITION_LAUNCH && result != api.LIFECYCLE_RESULT_ABANDON
+}
+
+// TimeoutPendingActions takes the default result of hook for all the pending lifecycle actions, which
+// nobody waits for after the service restarts. The lifecycle actions of the same transition of the
+// affected guests are returned, including those finished before.
+func (slam *SScalingLifecycleActionManager) TimeoutPendingActions(ctx context.Context) ([]SScalingLifecycleAction, error) {
+	pendings := make([]SScalingLifecycleAction, 0)
+	err := db.FetchModelObjects(slam, slam.Query().Equals("status", api.SLA_STATUS_PENDING), &pendings)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	if len(pendings) == 0 {
+		return nil, nil
+	}
+	// the lifecycle actions started together share the start time
+	keyOf := func(action *SScalingLifecycleAction) string {
+		return action.GuestId + "/" + action.Transition + "/" + action.StartTime.String()
+	}
+	keys := make(map[string]bool, len(pendings))
+	guestIds := make([]string, 0, len(pendings))
+	for i := range pendings {
+		err := pendings[i].timeout(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "time out ScalingLifecycleAction '%s'", pendings[i].Id)
+		}
+		keys[keyOf(&pendings[i])] = true
+		guestIds = append(guestIds, pendings[i].GuestId)
+	}
+	actions := make([]SScalingLifecycleAction, 0, len(pendings))
+	err = db.FetchModelObjects(slam, slam.Query().In("guest_id", guestIds), &actions)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	ret := make([]SScalingLifecycleAction, 0, len(actions))
+	for i := range actions {
+		if keys[keyOf(&actions[i])] {
+			ret = append(ret, actions[i])
+		}
+	}
+	return ret, nil
+}
+
+func (sg *SScalingGroup) fetchLifecycleAction(userCred mcclient.TokenCredential,
+	input api.ScalingGroupLifecycleActionInput) (*SScalingLifecycleAction, *SScalingLifecycleHook, error) {
+	q := ScalingLifecycleActionManager.Query().Equals("scaling_group_id", sg.Id).Equals("status", api.SLA_STATUS_PENDING)
+	if len(input.LifecycleAction) != 0 {
+		q = q.Equals("id", input.LifecycleAction)
+	} else {
+		if len(input.LifecycleHook) == 0 || len(input.Guest) == 0 {
+			return nil, nil, httperrors.NewMissingParameterError("lifecycle_action")
+		}
+		hookQ := ScalingLifecycleHookManager.Query("id").Equals("scaling_group_id", sg.Id)
+		hookQ = hookQ.Filter(sqlchemy.OR(sqlchemy.Equals(hookQ.Field("id"), input.LifecycleHook),
+			sqlchemy.Equals(hookQ.Field("name"), input.LifecycleHook)))
+		guest, err := GuestManager.FetchByIdOrName(userCred, input.Guest)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, nil, httperrors.NewResourceNotFoundError2(GuestManager.Keyword(), input.Guest)
+			}
+			return nil, nil, errors.Wrap(err, "GuestManager.FetchByIdOrName")
+		}
+		q = q.In("lifecycle_hook_id", hookQ.SubQuery()).Equals("guest_id", guest.GetId())
+	}
+	actions := make([]SScalingLifecycleAction, 0, 1)
+	err := db.FetchModelObjects(ScalingLifecycleActionManager, q, &actions)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	if len(actions) == 0 {
+		return nil, nil, httperrors.NewNotFoundError("no pending lifecycle action in ScalingGroup '%s'", sg.Id)
+	}
+	model, err := ScalingLifecycleHookManager.FetchById(actions[0].LifecycleHookId)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "ScalingLifecycleHookManager.FetchById")
+	}
+	return &actions[0], model.(*SScalingLifecycleHook), nil
+}
+
+func (sg *SScalingGroup) AllowPerformCompleteLifecycleAction(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, input api.ScalingGroupCompleteLifecycleActionInput) bool {
+	return sg.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, sg, "complete-lifecycle-action")
+}
+
+// PerformCompleteLifecycleAction finishes the pending lifecycle action, the scaling activity proceeds after
+// all the lifecycle actions of the guest are finished.
+func (sg *SScalingGroup) PerformCompleteLifecycleAction(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, input api.ScalingGroupCompleteLifecycleActionInput) (jsonutils.JSONObject, error) {
+	if err := validateLifecycleResult(input.Result); err != nil {
+		return nil, err
+	}
+	action, _, err := sg.fetchLifecycleAction(userCred, input.ScalingGroupLifecycleActionInput)
+	if err != nil {
+		return nil, err
+	}
+	lockman.LockObject(ctx, action)
+	defer lockman.ReleaseObject(ctx, action)
+	// the action may time out between the fetch and the lock
+	err = action.reload()
+	if err != nil {
+		return nil, err
+	}
+	if action.Status != api.SLA_STATUS_PENDING {
+		return nil, httperrors.NewInvalidStatusError("lifecycle action '%s' is %s", action.Id, action.Status)
+	}
+	err = action.setResult(api.SLA_STATUS_COMPLETED, input.Result)
+	if err != nil {
+		return nil, errors.Wrap(err, "ScalingLifecycleAction.setResult")
+	}
+	logclient.AddActionLogWithContext(ctx, sg, logclient.ACT_UPDATE, input, userCred, true)
+	return nil, nil
+}
+
+func (sg *SScalingGroup) AllowPerformLifecycleActionHeartbeat(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, input api.ScalingGroupLifecycleActionInput) bool {
+	return sg.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, sg, "lifecycle-action-heartbeat")
+}
+
+// PerformLifecycleActionHeartbeat postpones the timeout of the pending lifecycle action
+func (sg *SScalingGroup) PerformLifecycleActionHeartbeat(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, input api.ScalingGroupLifecycleActionInput) (jsonutils.JSONObject, error) {
+	action, hook, err := sg.fetchLifecycleAction(userCred, input)
+	if err != nil {
+		return nil, err
+	}
+	err = action.Heartbeat(hook)
+	if err != nil {
+		return nil, errors.Wrap(err, "ScalingLifecycleAction.Heartbeat")
+	}
+	return nil, nil
+}
+
+// DeleteLifecycleHooks deletes the lifecycle hooks of scaling group and their lifecycle actions
+func (sg *SScalingGroup) DeleteLifecycleHooks(ctx context.Context, userCred mcclient.TokenCredential) error {
+	actions := make([]SScalingLifecycleAction, 0)
+	q := ScalingLifecycleActionManager.Query().Equals("scaling_group_id", sg.Id)
+	err := db.FetchModelObjects(ScalingLifecycleActionManager, q, &actions)
+	if err != nil {
+		return errors.Wrap(err, "fetch ScalingLifecycleActions")
+	}
+	for i := range actions {
+		err := actions[i].Delete(ctx, userCred)
+		if err != nil {
+			return errors.Wrapf(err, "delete ScalingLifecycleAction '%s'", actions[i].Id)
+		}
+	}
+	hooks := make([]SScalingLifecycleHook, 0)
+	err = db.FetchModelObjects(ScalingLifecycleHookManager, ScalingLifecycleHookManager.Query().Equals("scaling_group_id", sg.Id), &hooks)
+	if err != nil {
+		return errors.Wrap(err, "fetch ScalingLifecycleHooks")
+	}
+	for i := range hooks {
+		err := db.DeleteModel(ctx, userCred, &hooks[i])
+		if err != nil {
+			return errors.Wrapf(err, "delete ScalingLifecycleHook '%s'", hooks[i].Id)
+		}
+	}
+	return nil
+}
+
+func (slam *SScalingLifecycleActionManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := slam.SStatusStandaloneResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return slam.SScalingGroupResourceBaseManager.QueryDistinctExtraField(q, field)
+}
+
+func (slam *SScalingLifecycleActionManager) OrderByExtraFields(ctx context.Context, q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential, query api.ScalingLifecycleActionListInput) (*sqlchemy.SQuery, error) {
+	return slam.SStatusStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+}
+
+func (slam *SScalingLifecycleActionManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.ScalingLifecycleActionDetails {
+	rows := make([]api.ScalingLifecycleActionDetails, len(objs))
+	statusRows := slam.SStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	sgRows := slam.SScalingGroupResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	hookIds := make([]string, len(objs))
+	guestIds := make([]string, len(objs))
+	for i := range rows {
+		rows[i].StatusStandaloneResourceDetails = statusRows[i]
+		rows[i].ScalingGroupResourceInfo = sgRows[i]
+		action := objs[i].(*SScalingLifecycleAction)
+		hookIds[i] = action.LifecycleHookId
+		guestIds[i] = action.GuestId
+	}
+	hooks := make(map[string]SScalingLifecycleHook)
+	err := db.FetchStandaloneObjectsByIds(ScalingLifecycleHookManager, hookIds, &hooks)
+	if err != nil {
+		log.Errorf("FetchStandaloneObjectsByIds fail %s", err)
+	}
+	guestNames, err := db.FetchIdNameMap2(GuestManager, guestIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 fail %s", err)
+	}
+	for i := range rows {
+		if hook, ok := hooks[hookIds[i]]; ok {
+			rows[i].LifecycleHook = hook.Name
+			rows[i].NotificationMetadata = hook.NotificationMetadata
+		}
+		rows[i].Guest = guestNames[guestIds[i]]
+	}
+	return rows
+}
+
+func (sla *SScalingLifecycleAction) GetExtraDetails(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, isList bool) (api.ScalingLifecycleActionDetails, error) {
+	return api.ScalingLifecycleActionDetails{}, nil
+}
+
+func (slam *SScalingLifecycleActionManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential, input api.ScalingLifecycleActionListInput) (*sqlchemy.SQuery, error) {
+
+	q, err := slam.SStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, input.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, err
+	}
+	q, err = slam.SScalingGroupResourceBaseManager.ListItemFilter(ctx, q, userCred, input.ScalingGroupFilterListInput)
+	if err != nil {
+		return nil, err
+	}
+	q = q.Desc("start_time")
+	return q, nil
+}
+
+func (slam *SScalingLifecycleActionManager) NamespaceScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeProject
+}
+
+func (slam *SScalingLifecycleActionManager) ResourceScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeProject
+}
+
+func (slam *SScalingLifecycleActionManager) FilterByOwner(q *sqlchemy.SQuery, owner mcclient.IIdentityProvider, scope rbacutils.TRbacScope) *sqlchemy.SQuery {
+	if owner != nil {
+		switch scope {
+		case rbacutils.ScopeProject, rbacutils.ScopeDomain:
+			scalingGroupQ := ScalingGroupManager.Query("id", "domain_id").SubQuery()
+			q = q.Join(scalingGroupQ, sqlchemy.Equals(q.Field("scaling_group_id"), scalingGroupQ.Field("id")))
+			q = q.Filter(sqlchemy.Equals(scalingGroupQ.Field("domain_id"), owner.GetProjectDomainId()))
+		}
+	}
+	return q
+}
+
+func (slam *SScalingLifecycleActionManager) FetchOwnerId(ctx context.Context, data jsonutils.JSONObject) (mcclient.IIdentityProvider, error) {
+	return db.FetchDomainInfo(ctx, data)
+}
+
+func (sla *SScalingLifecycleAction) GetOwnerId() mcclient.IIdentityProvider {
+	scalingGroup := sla.GetScalingGroup()
+	if scalingGroup != nil {
+		return scalingGroup.GetOwnerId()
+	}
+	return nil
+}
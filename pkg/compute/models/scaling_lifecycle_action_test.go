@@ -0,0 +1,104 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestLifecycleResults(t *testing.T) {
+	action := func(guestId, status, result string) SScalingLifecycleAction {
+		a := SScalingLifecycleAction{GuestId: guestId, Result: result}
+		a.Status = status
+		return a
+	}
+	actions := []SScalingLifecycleAction{
+		action("g1", api.SLA_STATUS_COMPLETED, api.LIFECYCLE_RESULT_CONTINUE),
+		action("g1", api.SLA_STATUS_TIMEOUT, api.LIFECYCLE_RESULT_CONTINUE),
+		action("g2", api.SLA_STATUS_TIMEOUT, api.LIFECYCLE_RESULT_ABANDON),
+		action("g2", api.SLA_STATUS_COMPLETED, api.LIFECYCLE_RESULT_CONTINUE),
+		action("g3", api.SLA_STATUS_COMPLETED, api.LIFECYCLE_RESULT_CONTINUE),
+		action("g3", api.SLA_STATUS_PENDING, ""),
+	}
+	want := map[string]string{
+		"g1": api.LIFECYCLE_RESULT_CONTINUE,
+		"g2": api.LIFECYCLE_RESULT_ABANDON,
+	}
+	if got := LifecycleResults(actions); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestLifecycleDefaultResult(t *testing.T) {
+	hook := func(result string) *SScalingLifecycleHook {
+		return &SScalingLifecycleHook{DefaultResult: result}
+	}
+	cases := []struct {
+		name string
+		hook *SScalingLifecycleHook
+		want string
+	}{
+		{"hook deleted", nil, api.LIFECYCLE_RESULT_CONTINUE},
+		{"no default result", hook(""), api.LIFECYCLE_RESULT_CONTINUE},
+		{"continue", hook(api.LIFECYCLE_RESULT_CONTINUE), api.LIFECYCLE_RESULT_CONTINUE},
+		{"abandon", hook(api.LIFECYCLE_RESULT_ABANDON), api.LIFECYCLE_RESULT_ABANDON},
+	}
+	for _, c := range cases {
+		if got := lifecycleDefaultResult(c.hook); got != c.want {
+			t.Errorf("%s: want %s, got %s", c.name, c.want, got)
+		}
+	}
+}
+
+func TestLifecycleHeartbeatTimeoutAt(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	hook := &SScalingLifecycleHook{HeartbeatTimeout: 600}
+	cases := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"postponed", start.Add(time.Hour), start.Add(time.Hour + 10*time.Minute)},
+		{"capped by max wait", start.Add(lifecycleActionMaxWait - time.Minute), start.Add(lifecycleActionMaxWait)},
+		{"after max wait", start.Add(lifecycleActionMaxWait + time.Hour), start.Add(lifecycleActionMaxWait)},
+	}
+	for _, c := range cases {
+		if got := lifecycleHeartbeatTimeoutAt(start, c.now, hook); !got.Equal(c.want) {
+			t.Errorf("%s: want %s, got %s", c.name, c.want, got)
+		}
+	}
+}
+
+func TestLifecycleGuestKept(t *testing.T) {
+	cases := []struct {
+		transition string
+		result     string
+		want       bool
+	}{
+		{api.LIFECYCLE_TRANSITION_LAUNCH, api.LIFECYCLE_RESULT_CONTINUE, true},
+		{api.LIFECYCLE_TRANSITION_LAUNCH, api.LIFECYCLE_RESULT_ABANDON, false},
+		{api.LIFECYCLE_TRANSITION_TERMINATE, api.LIFECYCLE_RESULT_CONTINUE, false},
+		{api.LIFECYCLE_TRANSITION_TERMINATE, api.LIFECYCLE_RESULT_ABANDON, false},
+	}
+	for _, c := range cases {
+		if got := LifecycleGuestKept(c.transition, c.result); got != c.want {
+			t.Errorf("%s with %s: want %v, got %v", c.transition, c.result, c.want, got)
+		}
+	}
+}
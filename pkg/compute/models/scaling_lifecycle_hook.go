@@ -0,0 +1,253 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+const (
+	lifecycleHeartbeatTimeoutMin = 30
+	lifecycleHeartbeatTimeoutMax = 7200
+)
+
+type SScalingLifecycleHookManager struct {
+	db.SVirtualResourceBaseManager
+	SScalingGroupResourceBaseManager
+}
+
+// SScalingLifecycleHook makes the scaling controller wait before an instance joins in or is removed from
+// the scaling group. The instance stays in a pending status until the lifecycle action is completed or the
+// heartbeat timeout is reached.
+type SScalingLifecycleHook struct {
+	db.SVirtualResourceBase
+	SScalingGroupResourceBase
+
+	// 挂钩的伸缩行为
+	Transition string `width:"16" charset:"ascii" nullable:"false" create:"required" list:"user" get:"user"`
+	// 等待完成的超时时间
+	HeartbeatTimeout int `nullable:"false" default:"300" create:"optional" list:"user" get:"user" update:"user"`
+	// 超时后的默认结果
+	DefaultResult string `width:"16" charset:"ascii" nullable:"false" default:"continue" create:"optional" list:"user" get:"user" update:"user"`
+
+	// Receivers of the notification, separated by comma
+	Receivers string `width:"1024" charset:"ascii" list:"user" get:"user"`
+	// 附加在通知中的信息
+	NotificationMetadata string `width:"1024" charset:"utf8" create:"optional" list:"user" get:"user" update:"user"`
+}
+
+var ScalingLifecycleHookManager *SScalingLifecycleHookManager
+
+func init() {
+	ScalingLifecycleHookManager = &SScalingLifecycleHookManager{
+		SVirtualResourceBaseManager: db.NewVirtualResourceBaseManager(
+			SScalingLifecycleHook{},
+			"scalinglifecyclehooks_tbl",
+			"scalinglifecyclehook",
+			"scalinglifecyclehooks",
+		),
+	}
+	ScalingLifecycleHookManager.SetVirtualObject(ScalingLifecycleHookManager)
+}
+
+func validateLifecycleHeartbeatTimeout(timeout int) error {
+	if timeout < lifecycleHeartbeatTimeoutMin || timeout > lifecycleHeartbeatTimeoutMax {
+		return httperrors.NewInputParameterError("heartbeat_timeout should between %d and %d",
+			lifecycleHeartbeatTimeoutMin, lifecycleHeartbeatTimeoutMax)
+	}
+	return nil
+}
+
+func validateLifecycleResult(result string) error {
+	if !utils.IsInStringArray(result, []string{api.LIFECYCLE_RESULT_CONTINUE, api.LIFECYCLE_RESULT_ABANDON}) {
+		return httperrors.NewInputParameterError("unkown lifecycle result %s", result)
+	}
+	return nil
+}
+
+func (slhm *SScalingLifecycleHookManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.ScalingLifecycleHookCreateInput) (
+	api.ScalingLifecycleHookCreateInput, error) {
+	var err error
+	input.VirtualResourceCreateInput, err = slhm.SVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query,
+		input.VirtualResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+
+	// check scaling group
+	idOrName := input.ScalingGroup
+	if len(input.ScalingGroupId) != 0 {
+		idOrName = input.ScalingGroupId
+	}
+	model, err := ScalingGroupManager.FetchByIdOrName(userCred, idOrName)
+	if errors.Cause(err) == sql.ErrNoRows {
+		return input, httperrors.NewInputParameterError("no such scaling group %s", idOrName)
+	}
+	if err != nil {
+		return input, errors.Wrap(err, "ScalingGroupManager.FetchByIdOrName")
+	}
+	input.ScalingGroupId = model.GetId()
+
+	if !utils.IsInStringArray(input.Transition, []string{api.LIFECYCLE_TRANSITION_LAUNCH, api.LIFECYCLE_TRANSITION_TERMINATE}) {
+		return input, httperrors.NewInputParameterError("unkown lifecycle transition %s", input.Transition)
+	}
+	if input.HeartbeatTimeout == 0 {
+		input.HeartbeatTimeout = 300
+	}
+	if err := validateLifecycleHeartbeatTimeout(input.HeartbeatTimeout); err != nil {
+		return input, err
+	}
+	if len(input.DefaultResult) == 0 {
+		input.DefaultResult = api.LIFECYCLE_RESULT_CONTINUE
+	}
+	if err := validateLifecycleResult(input.DefaultResult); err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (slh *SScalingLifecycleHook) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	input := api.ScalingLifecycleHookCreateInput{}
+	err := data.Unmarshal(&input)
+	if err != nil {
+		return httperrors.NewInputParameterError("Unmarshal input failed %s", err)
+	}
+	slh.Receivers = strings.Join(input.Receivers, ",")
+	// slh.Project must be same with slh.ScalingGroup
+	sg := slh.GetScalingGroup()
+	if sg == nil {
+		return httperrors.NewResourceNotFoundError2(ScalingGroupManager.Keyword(), slh.ScalingGroupId)
+	}
+	return slh.SVirtualResourceBase.CustomizeCreate(ctx, userCred, sg.GetOwnerId(), query, data)
+}
+
+func (slh *SScalingLifecycleHook) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, input api.ScalingLifecycleHookUpdateInput) (api.ScalingLifecycleHookUpdateInput, error) {
+	var err error
+	input.VirtualResourceBaseUpdateInput, err = slh.SVirtualResourceBase.ValidateUpdateData(ctx, userCred, query,
+		input.VirtualResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	if input.HeartbeatTimeout != nil {
+		if err := validateLifecycleHeartbeatTimeout(*input.HeartbeatTimeout); err != nil {
+			return input, err
+		}
+	}
+	if len(input.DefaultResult) != 0 {
+		if err := validateLifecycleResult(input.DefaultResult); err != nil {
+			return input, err
+		}
+	}
+	return input, nil
+}
+
+func (slh *SScalingLifecycleHook) GetReceivers() []string {
+	if len(slh.Receivers) == 0 {
+		return nil
+	}
+	return strings.Split(slh.Receivers, ",")
+}
+
+func (slhm *SScalingLifecycleHookManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential, input api.ScalingLifecycleHookListInput) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = slhm.SVirtualResourceBaseManager.ListItemFilter(ctx, q, userCred, input.VirtualResourceListInput)
+	if err != nil {
+		return q, err
+	}
+	q, err = slhm.SScalingGroupResourceBaseManager.ListItemFilter(ctx, q, userCred, input.ScalingGroupFilterListInput)
+	if err != nil {
+		return q, err
+	}
+	if len(input.Transition) != 0 {
+		q = q.Equals("transition", input.Transition)
+	}
+	return q, nil
+}
+
+func (slhm *SScalingLifecycleHookManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := slhm.SVirtualResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return slhm.SScalingGroupResourceBaseManager.QueryDistinctExtraField(q, field)
+}
+
+func (slh *SScalingLifecycleHook) GetUniqValues() jsonutils.JSONObject {
+	return jsonutils.Marshal(map[string]string{"scaling_group_id": slh.ScalingGroupId})
+}
+
+func (slhm *SScalingLifecycleHookManager) FetchUniqValues(ctx context.Context, data jsonutils.JSONObject) jsonutils.JSONObject {
+	return slhm.SScalingGroupResourceBaseManager.FetchUniqValues(ctx, data)
+}
+
+func (slhm *SScalingLifecycleHookManager) FilterByUniqValues(q *sqlchemy.SQuery, values jsonutils.JSONObject) *sqlchemy.SQuery {
+	return slhm.SScalingGroupResourceBaseManager.FilterByUniqValues(q, values)
+}
+
+func (slhm *SScalingLifecycleHookManager) OrderByExtraFields(ctx context.Context, q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential, query api.ScalingLifecycleHookListInput) (*sqlchemy.SQuery, error) {
+	return slhm.SVirtualResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.VirtualResourceListInput)
+}
+
+func (slhm *SScalingLifecycleHookManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.ScalingLifecycleHookDetails {
+	rows := make([]api.ScalingLifecycleHookDetails, len(objs))
+	virtRows := slhm.SVirtualResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	sgRows := slhm.SScalingGroupResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i].VirtualResourceDetails = virtRows[i]
+		rows[i].ScalingGroupResourceInfo = sgRows[i]
+	}
+	return rows
+}
+
+func (slh *SScalingLifecycleHook) GetExtraDetails(ctx context.Context, userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject, isList bool) (api.ScalingLifecycleHookDetails, error) {
+	return api.ScalingLifecycleHookDetails{}, nil
+}
+
+// LifecycleHooks returns the lifecycle hooks of transition
+func (sg *SScalingGroup) LifecycleHooks(transition string) ([]SScalingLifecycleHook, error) {
+	q := ScalingLifecycleHookManager.Query().Equals("scaling_group_id", sg.Id).Equals("transition", transition)
+	hooks := make([]SScalingLifecycleHook, 0, 1)
+	err := db.FetchModelObjects(ScalingLifecycleHookManager, q, &hooks)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.FetchModelObjects")
+	}
+	return hooks, nil
+}
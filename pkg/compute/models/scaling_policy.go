@@ -23,6 +23,7 @@ import (
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/tristate"
 	"yunion.io/x/pkg/utils"
 	"yunion.io/x/sqlchemy"
 
@@ -57,8 +58,14 @@ type SScalingPolicy struct {
 	// Unit of Number
 	Unit string `width:"4" charset:"ascii" create:"required" list:"user"`
 
+	// Steps of action 'step', the step containing the value of alarm indicator is applied
+	Steps *api.SScalingSteps `list:"user" create:"optional"`
+
 	// Scaling activity triggered by alarms will be rejected during this period about CoolingTime
 	CoolingTime int `nullable:"false" default:"300" create:"required" list:"user"`
+
+	// AllowScaleTime is the end of cooling time of this policy
+	AllowScaleTime time.Time `list:"user"`
 }
 
 var ScalingPolicyManager *SScalingPolicyManager
@@ -194,6 +201,15 @@ func (sp *SScalingPolicy) getMoreDetails(ctx context.Context, userCred mcclient.
 			return out, errors.Wrap(err, "ScalingTimerManager.FetchById")
 		}
 		out.CycleTimer = model.(*SScalingTimer).CycleTimerDetails()
+	case api.TRIGGER_TARGET_TRACKING:
+		model, err := ScalingTargetTrackingManager.FetchById(sp.TriggerId)
+		if errors.Cause(err) == sql.ErrNoRows {
+			return out, nil
+		}
+		if err != nil {
+			return out, errors.Wrap(err, "ScalingTargetTrackingManager.FetchById")
+		}
+		out.TargetTracking = model.(*SScalingTargetTracking).TargetTrackingDetails()
 	}
 
 	return out, nil
@@ -224,15 +240,10 @@ func (spm *SScalingPolicyManager) ValidateCreateData(ctx context.Context, userCr
 	}
 	input.ScalingGroupId = model.GetId()
 
-	if !utils.IsInStringArray(input.TriggerType, []string{api.TRIGGER_TIMING, api.TRIGGER_CYCLE, api.TRIGGER_ALARM}) {
+	if !utils.IsInStringArray(input.TriggerType, []string{api.TRIGGER_TIMING, api.TRIGGER_CYCLE, api.TRIGGER_ALARM,
+		api.TRIGGER_TARGET_TRACKING}) {
 		return input, httperrors.NewInputParameterError("unkown trigger type %s", input.TriggerType)
 	}
-	if !utils.IsInStringArray(input.Action, []string{api.ACTION_ADD, api.ACTION_REMOVE, api.ACTION_SET}) {
-		return input, httperrors.NewInputParameterError("unkown scaling policy action %s", input.Action)
-	}
-	if !utils.IsInStringArray(input.Unit, []string{api.UNIT_ONE, api.UNIT_PERCENT}) {
-		return input, httperrors.NewInputParameterError("unkown scaling policy unit %s", input.Unit)
-	}
 	trigger, err := ScalingPolicyManager.Trigger(&input)
 	if err != nil {
 		return input, errors.Wrap(err, "ScalingPolicyManager.Trigger")
@@ -241,6 +252,23 @@ func (spm *SScalingPolicyManager) ValidateCreateData(ctx context.Context, userCr
 	if err != nil {
 		return input, httperrors.NewInputParameterError("%v", err)
 	}
+	if !utils.IsInStringArray(input.Action, []string{api.ACTION_ADD, api.ACTION_REMOVE, api.ACTION_SET, api.ACTION_STEP}) {
+		return input, httperrors.NewInputParameterError("unkown scaling policy action %s", input.Action)
+	}
+	if !utils.IsInStringArray(input.Unit, []string{api.UNIT_ONE, api.UNIT_PERCENT}) {
+		return input, httperrors.NewInputParameterError("unkown scaling policy unit %s", input.Unit)
+	}
+	if input.Action == api.ACTION_STEP {
+		if input.TriggerType != api.TRIGGER_ALARM {
+			return input, httperrors.NewInputParameterError("action %s is only supported by trigger type %s",
+				api.ACTION_STEP, api.TRIGGER_ALARM)
+		}
+		if err := input.Steps.Validate(); err != nil {
+			return input, err
+		}
+	} else {
+		input.Steps = nil
+	}
 	return input, err
 }
 
@@ -346,6 +374,14 @@ func (sp *SScalingPolicy) Trigger(input *api.ScalingPolicyCreateInput) (IScaling
 				RealCumulate:       0,
 				LastTriggerTime:    time.Now(),
 			}, nil
+		case api.TRIGGER_TARGET_TRACKING:
+			return &SScalingTargetTracking{
+				SScalingPolicyBase: SScalingPolicyBase{sp.GetId()},
+				Indicator:          input.TargetTracking.Indicator,
+				TargetValue:        input.TargetTracking.TargetValue,
+				Cycle:              input.TargetTracking.Cycle,
+				DisableScaleIn:     tristate.NewFromBool(input.TargetTracking.DisableScaleIn),
+			}, nil
 		default:
 			return nil, fmt.Errorf("unkown trigger type %s", sp.TriggerType)
 		}
@@ -366,6 +402,12 @@ func (sp *SScalingPolicy) Trigger(input *api.ScalingPolicyCreateInput) (IScaling
 			return nil, errors.Wrap(err, "SScalingAlarmManager.FetchById")
 		}
 		return model.(*SScalingAlarm), nil
+	case api.TRIGGER_TARGET_TRACKING:
+		model, err := ScalingTargetTrackingManager.FetchById(sp.TriggerId)
+		if err != nil {
+			return nil, errors.Wrap(err, "SScalingTargetTrackingManager.FetchById")
+		}
+		return model.(*SScalingTargetTracking), nil
 	default:
 		return nil, fmt.Errorf("unkown trigger type %s", sp.TriggerType)
 	}
@@ -401,6 +443,7 @@ func (sp *SScalingPolicy) PerformTrigger(ctx context.Context, userCred mcclient.
 		return nil, nil
 	}
 
+	action := &sScalingPolicyAction{SScalingPolicy: sp}
 	manual, _ := data.Bool("manual")
 	if manual {
 		triggerDesc = SScalingManual{SScalingPolicyBase{sp.Id}}
@@ -411,7 +454,8 @@ func (sp *SScalingPolicy) PerformTrigger(ctx context.Context, userCred mcclient.
 		}
 		if data.Contains("alarm_id") {
 			alarmId, _ := data.GetString("alarm_id")
-			if alarmId != trigger.(*SScalingAlarm).AlarmId {
+			alarmTrigger, ok := trigger.(IScalingAlarmTrigger)
+			if !ok || !alarmTrigger.HasAlarm(alarmId) {
 				return nil, httperrors.NewInputParameterError("mismatched alarm id")
 			}
 		}
@@ -419,8 +463,16 @@ func (sp *SScalingPolicy) PerformTrigger(ctx context.Context, userCred mcclient.
 			return nil, nil
 		}
 		triggerDesc = trigger
+		action.trigger = trigger
+	}
+	if data.Contains("metric_value") {
+		value, err := data.Float("metric_value")
+		if err != nil {
+			return nil, httperrors.NewInputParameterError("invalid metric_value")
+		}
+		action.metricValue = &value
 	}
-	err = sg.Scale(ctx, triggerDesc, sp, sp.CoolingTime)
+	err = sg.Scale(ctx, triggerDesc, action, sp.CoolingTime)
 	if err != nil {
 		return nil, errors.Wrap(err, "ScalingPolicy.Scale")
 	}
@@ -448,8 +500,20 @@ type IScalingAction interface {
 	CheckCoolTime() bool
 }
 
+// IScalingCooldown is where the cooling time of scaling is kept, actions
+// implementing it cool down by themselves in addition to the scaling group
+type IScalingCooldown interface {
+	AllowScale() bool
+	GetAllowScaleTime() time.Time
+	SetAllowScaleTime(t time.Time)
+}
+
 func (sp *SScalingPolicy) Exec(from int) int {
-	diff := sp.Number
+	return sp.execNumber(from, sp.Number)
+}
+
+func (sp *SScalingPolicy) execNumber(from, number int) int {
+	diff := number
 	if sp.Unit == api.UNIT_PERCENT {
 		diff = diff * from / 100
 	}
@@ -460,18 +524,70 @@ func (sp *SScalingPolicy) Exec(from int) int {
 		return from - diff
 	case api.ACTION_SET:
 		return diff
+	case api.ACTION_STEP:
+		// the sign of number tells adding or removing
+		return from + diff
 	default:
 		return from
 	}
 }
 
 func (sp *SScalingPolicy) CheckCoolTime() bool {
-	if sp.TriggerType == api.TRIGGER_ALARM {
+	if sp.TriggerType == api.TRIGGER_ALARM || sp.TriggerType == api.TRIGGER_TARGET_TRACKING {
 		return true
 	}
 	return false
 }
 
+func (sp *SScalingPolicy) AllowScale() bool {
+	return sp.AllowScaleTime.Before(time.Now())
+}
+
+func (sp *SScalingPolicy) GetAllowScaleTime() time.Time {
+	return sp.AllowScaleTime
+}
+
+func (sp *SScalingPolicy) SetAllowScaleTime(t time.Time) {
+	if sp.AllowScaleTime.After(t) {
+		return
+	}
+	_, err := db.Update(sp, func() error {
+		sp.AllowScaleTime = t
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Set AllowScaleTime of ScalingPolicy error: %s", err)
+	}
+}
+
+// sScalingPolicyAction executes the policy with the value of indicator
+// reported by the alert which triggers it
+type sScalingPolicyAction struct {
+	*SScalingPolicy
+	trigger     IScalingTrigger
+	metricValue *float64
+}
+
+func (action *sScalingPolicyAction) Exec(from int) int {
+	if tracking, ok := action.trigger.(*SScalingTargetTracking); ok {
+		if action.metricValue == nil {
+			return from
+		}
+		return tracking.DesireInstanceNumber(from, *action.metricValue)
+	}
+	if action.Action == api.ACTION_STEP {
+		if action.metricValue == nil || action.Steps == nil {
+			return from
+		}
+		step := action.Steps.Match(*action.metricValue)
+		if step == nil {
+			return from
+		}
+		return action.execNumber(from, step.Number)
+	}
+	return action.SScalingPolicy.Exec(from)
+}
+
 func (sp *SScalingPolicy) AllowPerformEnable(ctx context.Context, userCred mcclient.TokenCredential,
 	query jsonutils.JSONObject, input apis.PerformEnableInput) bool {
 	return true
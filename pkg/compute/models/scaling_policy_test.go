@@ -0,0 +1,109 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/pkg/tristate"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestScalingPolicyActionStep(t *testing.T) {
+	bound := func(v float64) *float64 { return &v }
+	steps := api.SScalingSteps{
+		{LowerBound: bound(90), Number: 3},
+		{UpperBound: bound(20), Number: -1},
+		{LowerBound: bound(70), UpperBound: bound(90), Number: 1},
+	}
+	if err := steps.Validate(); err != nil {
+		t.Fatalf("validate steps: %v", err)
+	}
+	sp := &SScalingPolicy{Action: api.ACTION_STEP, Unit: api.UNIT_ONE, Steps: &steps}
+	cases := []struct {
+		value *float64
+		want  int
+	}{
+		{bound(95), 7},
+		{bound(90), 7},
+		{bound(75), 5},
+		{bound(50), 4},
+		{bound(10), 3},
+		{nil, 4},
+	}
+	for _, c := range cases {
+		action := &sScalingPolicyAction{SScalingPolicy: sp, metricValue: c.value}
+		if got := action.Exec(4); got != c.want {
+			t.Errorf("value %v: want %d, got %d", c.value, c.want, got)
+		}
+	}
+
+	overlapped := api.SScalingSteps{
+		{LowerBound: bound(70), Number: 1},
+		{LowerBound: bound(80), UpperBound: bound(90), Number: 2},
+	}
+	if err := overlapped.Validate(); err == nil {
+		t.Errorf("overlapped steps should fail")
+	}
+}
+
+func TestScalingPolicyActionTargetTracking(t *testing.T) {
+	value := func(v float64) *float64 { return &v }
+	sp := &SScalingPolicy{TriggerType: api.TRIGGER_TARGET_TRACKING, Action: api.ACTION_SET, Unit: api.UNIT_ONE}
+	cases := []struct {
+		disableScaleIn bool
+		current        int
+		value          *float64
+		want           int
+	}{
+		{false, 4, value(75), 6},
+		{false, 4, value(51), 5},
+		{false, 4, value(20), 2},
+		{true, 4, value(20), 4},
+		{false, 4, nil, 4},
+		{false, 0, value(80), 0},
+	}
+	for _, c := range cases {
+		tracking := &SScalingTargetTracking{TargetValue: 50, DisableScaleIn: tristate.NewFromBool(c.disableScaleIn)}
+		action := &sScalingPolicyAction{SScalingPolicy: sp, trigger: tracking, metricValue: c.value}
+		if got := action.Exec(c.current); got != c.want {
+			t.Errorf("current %d value %v disable scale-in %v: want %d, got %d",
+				c.current, c.value, c.disableScaleIn, c.want, got)
+		}
+	}
+}
+
+func TestScalingCoolingDown(t *testing.T) {
+	now := time.Now()
+	group := &SScalingGroup{AllowScaleTime: now.Add(time.Minute)}
+	cooled := &SScalingPolicy{AllowScaleTime: now.Add(-time.Minute)}
+	policy := &SScalingPolicy{AllowScaleTime: now.Add(time.Hour)}
+	cases := []struct {
+		name      string
+		cooldowns []IScalingCooldown
+		want      IScalingCooldown
+	}{
+		{"policy cooled down but group not", []IScalingCooldown{group, cooled}, group},
+		{"policy cools down longer", []IScalingCooldown{group, policy}, policy},
+		{"all cooled down", []IScalingCooldown{&SScalingGroup{}, cooled}, nil},
+	}
+	for _, c := range cases {
+		if got := coolingDown(c.cooldowns); got != c.want {
+			t.Errorf("%s: want %v, got %v", c.name, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/tristate"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/mcclient/modules/monitor"
+)
+
+// The scale-in alert fires when the average is below this ratio of the
+// target value, so that the group doesn't flap around the target
+const targetTrackingScaleInRatio = 0.9
+
+type SScalingTargetTrackingManager struct {
+	db.SStandaloneResourceBaseManager
+}
+
+// SScalingTargetTracking keeps the average indicator of the instances in
+// scaling group around TargetValue. It registers a scale-out alert above the
+// target and a scale-in alert below it, the desire instance number is
+// calculated from the average reported by the alert.
+type SScalingTargetTracking struct {
+	db.SStandaloneResourceBase
+
+	SScalingPolicyBase
+
+	Indicator   string `width:"32" charset:"ascii"`
+	TargetValue float64
+	Cycle       int
+
+	// Only scale out if DisableScaleIn is true
+	DisableScaleIn tristate.TriState `nullable:"false" default:"false"`
+
+	// ID of alarm configs in alarm service
+	ScaleOutAlarmId string `width:"128" charset:"ascii"`
+	ScaleInAlarmId  string `width:"128" charset:"ascii"`
+}
+
+var ScalingTargetTrackingManager *SScalingTargetTrackingManager
+
+func init() {
+	ScalingTargetTrackingManager = &SScalingTargetTrackingManager{
+		SStandaloneResourceBaseManager: db.NewStandaloneResourceBaseManager(
+			SScalingTargetTracking{},
+			"scalingtargettrackings_tbl",
+			"scalingtargettracking",
+			"scalingtargettrackings",
+		),
+	}
+	ScalingTargetTrackingManager.SetVirtualObject(ScalingTargetTrackingManager)
+}
+
+func (stt *SScalingTargetTracking) TargetTrackingDetails() api.ScalingTargetTrackingDetails {
+	return api.ScalingTargetTrackingDetails{
+		Indicator:      stt.Indicator,
+		TargetValue:    stt.TargetValue,
+		Cycle:          stt.Cycle,
+		DisableScaleIn: stt.DisableScaleIn.Bool(),
+	}
+}
+
+func (stt *SScalingTargetTracking) ValidateCreateData(input api.ScalingPolicyCreateInput) (api.ScalingPolicyCreateInput, error) {
+	if input.TargetTracking.Cycle == 0 {
+		input.TargetTracking.Cycle = 300
+	}
+	if !utils.IsInStringArray(input.TargetTracking.Indicator, []string{api.INDICATOR_CPU, api.INDICATOR_DISK_READ,
+		api.INDICATOR_DISK_WRITE, api.INDICATOR_FLOW_INTO, api.INDICATOR_FLOW_OUT}) {
+		return input, httperrors.NewInputParameterError("unkown indicator in target tracking %s", input.TargetTracking.Indicator)
+	}
+	if input.TargetTracking.TargetValue <= 0 {
+		return input, httperrors.NewInputParameterError("target value of target tracking should be greater than 0")
+	}
+	if input.TargetTracking.Cycle < 300 {
+		return input, httperrors.NewInputParameterError("the min value of cycle in target tracking is 300")
+	}
+	// the instance number is calculated from the indicator
+	input.Action = api.ACTION_SET
+	input.Unit = api.UNIT_ONE
+	return input, nil
+}
+
+func (stt *SScalingTargetTracking) generateAlertConfig(sp *SScalingPolicy, scaleOut bool) (*monitor.AlertConfig, error) {
+	suffix := "in"
+	if scaleOut {
+		suffix = "out"
+	}
+	config, err := monitor.NewAlertConfig(fmt.Sprintf("sp-%s-%s", sp.Id, suffix), fmt.Sprintf("%ds", stt.Cycle), true)
+	if err != nil {
+		return nil, err
+	}
+	config.UsedBy = alertConfigUsedBy
+	cond := config.Condition("telegraf", indicatorMap[stt.Indicator].Table).Avg()
+	if scaleOut {
+		cond = cond.GT(stt.TargetValue)
+	} else {
+		cond = cond.LT(stt.TargetValue * targetTrackingScaleInRatio)
+	}
+	q := cond.Query().From(fmt.Sprintf("%ds", stt.Cycle))
+	q.Selects().Select(indicatorMap[stt.Indicator].Field).MEAN()
+	q.Where().Equal("vm_scaling_group_id", sp.ScalingGroupId)
+	// no group by tag, the average is over all instances of scaling group
+	q.GroupBy().FILL_NULL()
+	return config, nil
+}
+
+func (stt *SScalingTargetTracking) Register(ctx context.Context, userCred mcclient.TokenCredential) error {
+	sp, err := stt.ScalingPolicy()
+	if err != nil {
+		return err
+	}
+	session := auth.GetSession(ctx, userCred, "", "")
+	notificationID, err := ScalingPolicyManager.NotificationID(session)
+	if err != nil {
+		return errors.Wrap(err, "ScalingPolicyManager.NotificationID")
+	}
+	config, err := stt.generateAlertConfig(sp, true)
+	if err != nil {
+		return errors.Wrap(err, "generate scale-out alert config")
+	}
+	stt.ScaleOutAlarmId, err = stt.createAlert(session, config, notificationID)
+	if err != nil {
+		return errors.Wrap(err, "create scale-out alert")
+	}
+	if stt.DisableScaleIn.IsFalse() {
+		config, err = stt.generateAlertConfig(sp, false)
+		if err != nil {
+			stt.deleteAlerts(session)
+			return errors.Wrap(err, "generate scale-in alert config")
+		}
+		stt.ScaleInAlarmId, err = stt.createAlert(session, config, notificationID)
+		if err != nil {
+			stt.deleteAlerts(session)
+			return errors.Wrap(err, "create scale-in alert")
+		}
+	}
+
+	err = ScalingTargetTrackingManager.TableSpec().Insert(ctx, stt)
+	if err != nil {
+		stt.deleteAlerts(session)
+		return errors.Wrap(err, "STableSpec.Insert")
+	}
+	return nil
+}
+
+func (stt *SScalingTargetTracking) deleteAlerts(session *mcclient.ClientSession) error {
+	for _, alarmId := range []string{stt.ScaleOutAlarmId, stt.ScaleInAlarmId} {
+		if len(alarmId) == 0 {
+			continue
+		}
+		_, err := monitor.Alerts.Delete(session, alarmId, jsonutils.NewDict())
+		if err != nil {
+			return errors.Wrapf(err, "Alerts.Delete %s", alarmId)
+		}
+	}
+	return nil
+}
+
+func (stt *SScalingTargetTracking) UnRegister(ctx context.Context, userCred mcclient.TokenCredential) error {
+	session := auth.GetSession(ctx, userCred, "", "")
+	if err := stt.deleteAlerts(session); err != nil {
+		return err
+	}
+	err := stt.Delete(ctx, userCred)
+	if err != nil {
+		return errors.Wrap(err, "SScalingTargetTracking.Delete")
+	}
+	return nil
+}
+
+func (stt *SScalingTargetTracking) TriggerId() string {
+	return stt.GetId()
+}
+
+func (stt *SScalingTargetTracking) TriggerDescription() string {
+	name := stt.ScalingPolicyId
+	sp, _ := stt.ScalingPolicy()
+	if sp != nil {
+		name = sp.Name
+	}
+	return fmt.Sprintf(
+		`Target tracking task(keep the average %s of the instances at %f%s) execute scaling policy "%s"`,
+		descs[stt.Indicator], stt.TargetValue, units[stt.Indicator], name,
+	)
+}
+
+func (stt *SScalingTargetTracking) IsTrigger() bool {
+	return true
+}
+
+// HasAlarm tells whether the alert in alarm service belongs to the trigger
+func (stt *SScalingTargetTracking) HasAlarm(alarmId string) bool {
+	return len(alarmId) > 0 && (alarmId == stt.ScaleOutAlarmId || alarmId == stt.ScaleInAlarmId)
+}
+
+// DesireInstanceNumber returns the instance number which brings the average
+// indicator of the scaling group back to the target value
+func (stt *SScalingTargetTracking) DesireInstanceNumber(current int, value float64) int {
+	if current <= 0 || stt.TargetValue <= 0 {
+		return current
+	}
+	desire := int(math.Ceil(float64(current) * value / stt.TargetValue))
+	if desire < current && stt.DisableScaleIn.IsTrue() {
+		return current
+	}
+	return desire
+}
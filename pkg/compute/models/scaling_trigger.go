@@ -51,6 +51,11 @@ type IScalingTrigger interface {
 	IsTrigger() bool
 }
 
+// IScalingAlarmTrigger is implemented by triggers fired by alerts of alarm service
+type IScalingAlarmTrigger interface {
+	HasAlarm(alarmId string) bool
+}
+
 type SScalingManual struct {
 	SScalingPolicyBase
 }
@@ -285,30 +290,41 @@ func (sa *SScalingAlarm) Register(ctx context.Context, userCred mcclient.TokenCr
 	if err != nil {
 		return errors.Wrap(err, "ScalingAlarm.generateAlertConfig")
 	}
+	alarmId, err := sa.createAlert(session, config, notificationID)
+	if err != nil {
+		return err
+	}
+	sa.AlarmId = alarmId
+
+	// insert
+	err = ScalingAlarmManager.TableSpec().Insert(ctx, sa)
+	if err != nil {
+		return errors.Wrap(err, "STableSpec.Insert")
+	}
+
+	return nil
+}
+
+// createAlert creates the alert in alarm service and attaches it to the
+// autoscaling notification which triggers the scaling policy
+func (spb *SScalingPolicyBase) createAlert(session *mcclient.ClientSession, config *monitor.AlertConfig,
+	notificationID string) (string, error) {
 	alert, err := monitor.Alerts.DoCreate(session, config)
 	if err != nil {
-		return errors.Wrap(err, "create Alert failed")
+		return "", errors.Wrap(err, "create Alert failed")
 	}
 	alarmId, _ := alert.GetString("id")
 	// detach
 	params := jsonutils.NewDict()
-	params.Set("scaling_policy_id", jsonutils.NewString(sa.ScalingPolicyId))
+	params.Set("scaling_policy_id", jsonutils.NewString(spb.ScalingPolicyId))
 	detachParams := jsonutils.NewDict()
 	detachParams.Set("params", params)
 	_, err = monitor.Alertnotification.Attach(session, alarmId, notificationID, detachParams)
 	if err != nil {
 		monitor.Alerts.Delete(session, alarmId, jsonutils.NewDict())
-		return errors.Wrap(err, "attach alert with notification")
+		return "", errors.Wrap(err, "attach alert with notification")
 	}
-	sa.AlarmId = alarmId
-
-	// insert
-	err = ScalingAlarmManager.TableSpec().Insert(ctx, sa)
-	if err != nil {
-		return errors.Wrap(err, "STableSpec.Insert")
-	}
-
-	return nil
+	return alarmId, nil
 }
 
 type sTableField struct {
@@ -369,6 +385,10 @@ func (sa *SScalingAlarm) UnRegister(ctx context.Context, userCred mcclient.Token
 	return nil
 }
 
+func (sa *SScalingAlarm) HasAlarm(alarmId string) bool {
+	return alarmId == sa.AlarmId
+}
+
 func (sa *SScalingAlarm) TriggerId() string {
 	return sa.GetId()
 }
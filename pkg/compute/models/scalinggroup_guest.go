@@ -53,19 +53,27 @@ type SScalingGroupGuest struct {
 	ScalingGroupId string            `width:"36" charset:"ascii" nullable:"false"`
 	GuestStatus    string            `width:"36" charset:"ascii" nullable:"false" index:"true"`
 	Manual         tristate.TriState `nullable:"false" default:"false"`
+
+	// ScaleInProtected guests are never removed by scaling in
+	ScaleInProtected tristate.TriState `nullable:"false" default:"false" list:"user"`
+
+	// InstanceRefreshId is the id of the instance refresh which created the guest
+	InstanceRefreshId string `width:"36" charset:"ascii" nullable:"true" list:"user"`
 }
 
 func (sggm *SScalingGroupGuestManager) GetSlaveFieldName() string {
 	return "scaling_group_id"
 }
 
-func (sggm *SScalingGroupGuestManager) Attach(ctx context.Context, scaligGroupId, guestId string, manual bool) error {
+func (sggm *SScalingGroupGuestManager) Attach(ctx context.Context, scaligGroupId, guestId string, manual bool,
+	instanceRefreshId string) error {
 	sgg := &SScalingGroupGuest{
 		SGuestJointsBase: SGuestJointsBase{
 			GuestId: guestId,
 		},
-		ScalingGroupId: scaligGroupId,
-		GuestStatus:    compute.SG_GUEST_STATUS_JOINING,
+		ScalingGroupId:    scaligGroupId,
+		GuestStatus:       compute.SG_GUEST_STATUS_JOINING,
+		InstanceRefreshId: instanceRefreshId,
 	}
 	if manual {
 		sgg.Manual = tristate.True
@@ -110,3 +118,14 @@ func (sggm *SScalingGroupGuestManager) Query(fields ...string) *sqlchemy.SQuery
 	return sggm.SVirtualJointResourceBaseManager.Query(fields...).NotEquals("guest_status",
 		compute.SG_GUEST_STATUS_PENDING_REMOVE)
 }
+
+func (sgg *SScalingGroupGuest) SetScaleInProtected(protected bool) error {
+	if sgg.ScaleInProtected.Bool() == protected {
+		return nil
+	}
+	_, err := db.Update(sgg, func() error {
+		sgg.ScaleInProtected = tristate.NewFromBool(protected)
+		return nil
+	})
+	return err
+}
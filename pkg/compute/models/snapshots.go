@@ -83,6 +83,12 @@ type SSnapshot struct {
 
 	BackingDiskId string    `width:"36" charset:"ascii" nullable:"true" default:""`
 	ExpiredAt     time.Time `nullable:"true" list:"user" create:"optional"`
+
+	// 创建快照时是否冻结了文件系统, 即是否为应用一致性快照
+	Quiesced bool `nullable:"false" default:"false" list:"user"`
+
+	// 加密密钥ID, 继承自磁盘, 由快照创建的磁盘使用同一密钥
+	EncryptKeyId string `width:"128" charset:"ascii" nullable:"true" list:"user" json:"encrypt_key_id"`
 }
 
 var SnapshotManager *SSnapshotManager
@@ -413,6 +419,7 @@ func (self *SSnapshot) CustomizeCreate(ctx context.Context, userCred mcclient.To
 		return errors.Wrap(err, "DiskManager.FetchById")
 	}
 	ownerId = diskObj.(*SDisk).GetOwnerId()
+	self.EncryptKeyId = diskObj.(*SDisk).EncryptKeyId
 	return self.SVirtualResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
 }
 
@@ -442,6 +449,17 @@ func (self *SSnapshot) StartSnapshotCreateTask(ctx context.Context, userCred mcc
 	return nil
 }
 
+func (self *SSnapshot) SetQuiesced(quiesced bool) error {
+	_, err := db.Update(self, func() error {
+		self.Quiesced = quiesced
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "update snapshot %s", self.Name)
+	}
+	return nil
+}
+
 func (self *SSnapshot) AllowGetDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
 	return true
 }
@@ -581,6 +599,7 @@ func (self *SSnapshotManager) CreateSnapshot(ctx context.Context, owner mcclient
 	snapshot.OutOfChain = driver.SnapshotIsOutOfChain(disk)
 	snapshot.Size = disk.DiskSize
 	snapshot.DiskType = disk.DiskType
+	snapshot.EncryptKeyId = disk.EncryptKeyId
 	snapshot.Location = location
 	snapshot.CreatedBy = createdBy
 	snapshot.ManagerId = storage.ManagerId
@@ -789,7 +808,12 @@ func (self *SSnapshot) RealDelete(ctx context.Context, userCred mcclient.TokenCr
 			}
 		}
 	}
-	return db.DeleteModel(ctx, userCred, self)
+	err := db.DeleteModel(ctx, userCred, self)
+	if err != nil {
+		return err
+	}
+	releaseEncryptKey(ctx, self.EncryptKeyId)
+	return nil
 }
 
 func (self *SSnapshot) GetBackingDisks() ([]string, error) {
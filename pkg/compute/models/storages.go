@@ -397,8 +397,21 @@ func (self *SStorage) IsLocal() bool {
 	return utils.IsInStringArray(self.StorageType, api.HOST_STORAGE_LOCAL_TYPES)
 }
 
+// IsLvmThick tells whether the storage carves disks from a volume group
+// without thin pool, such volumes can't take snapshots
+func (self *SStorage) IsLvmThick() bool {
+	if self.StorageType != api.STORAGE_LVM {
+		return false
+	}
+	if self.StorageConf == nil {
+		return true
+	}
+	thinPool, _ := self.StorageConf.GetString("thin_pool")
+	return len(thinPool) == 0
+}
+
 func (self *SStorage) GetStorageCachePath(mountPoint, imageCachePath string) string {
-	if utils.IsInStringArray(self.StorageType, api.SHARED_FILE_STORAGE) {
+	if utils.IsInStringArray(self.StorageType, api.SHARED_FILE_STORAGE) || self.StorageType == api.STORAGE_ISCSI {
 		return path.Join(mountPoint, imageCachePath)
 	} else {
 		return imageCachePath
@@ -588,6 +601,9 @@ func (self *SStorage) GetCapacity() int64 {
 }
 
 func (self *SStorage) GetFreeCapacity() int64 {
+	if self.StorageType == api.STORAGE_ISCSI {
+		return self.GetIscsiFreeCapacity()
+	}
 	return int64(float32(self.GetCapacity())*self.GetOvercommitBound()) - self.GetUsedCapacity(tristate.None)
 }
 
@@ -1184,6 +1200,9 @@ func (self *SStorage) createDisk(ctx context.Context, name string, diskConfig *a
 
 	disk.Name = name
 	disk.fetchDiskInfo(diskConfig)
+	if err := disk.prepareEncryptKey(ctx, diskConfig); err != nil {
+		return nil, err
+	}
 
 	disk.StorageId = self.Id
 	disk.AutoDelete = autoDelete
@@ -1197,6 +1216,7 @@ func (self *SStorage) createDisk(ctx context.Context, name string, diskConfig *a
 
 	err := disk.GetModelManager().TableSpec().Insert(ctx, &disk)
 	if err != nil {
+		releaseEncryptKey(ctx, disk.EncryptKeyId)
 		return nil, err
 	}
 	db.OpsLog.LogEvent(&disk, db.ACT_CREATE, nil, userCred)
@@ -0,0 +1,126 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+// GetIscsiLuns returns the luns of the target reported by attached hosts
+func (self *SStorage) GetIscsiLuns() []api.SIscsiLun {
+	luns := []api.SIscsiLun{}
+	if self.StorageConf != nil && self.StorageConf.Contains("luns") {
+		self.StorageConf.Unmarshal(&luns, "luns")
+	}
+	return luns
+}
+
+// GetIscsiLun returns the lun the disk is allocated on, nil if the disk isn't allocated yet
+func (self *SDisk) GetIscsiLun() *api.SIscsiLun {
+	val := self.GetMetadata(api.DISK_META_ISCSI_LUN, nil)
+	if len(val) == 0 {
+		return nil
+	}
+	obj, err := jsonutils.ParseString(val)
+	if err != nil {
+		return nil
+	}
+	lun := &api.SIscsiLun{}
+	if err := obj.Unmarshal(lun); err != nil {
+		return nil
+	}
+	return lun
+}
+
+func iscsiLunKey(target string, lun int) string {
+	return fmt.Sprintf("%s:%d", target, lun)
+}
+
+// chooseIscsiLun picks the smallest free lun which is large enough for sizeMb
+func chooseIscsiLun(luns []api.SIscsiLun, used map[string]bool, sizeMb int64) *api.SIscsiLun {
+	sort.Slice(luns, func(i, j int) bool {
+		if luns[i].SizeMb != luns[j].SizeMb {
+			return luns[i].SizeMb < luns[j].SizeMb
+		}
+		return luns[i].Lun < luns[j].Lun
+	})
+	for i := range luns {
+		if used[iscsiLunKey(luns[i].Target, luns[i].Lun)] {
+			continue
+		}
+		if luns[i].SizeMb >= sizeMb {
+			return &luns[i]
+		}
+	}
+	return nil
+}
+
+// freeIscsiLunsSizeMb sums the size of the luns not allocated to any disk,
+// each disk takes a whole lun whatever its size
+func freeIscsiLunsSizeMb(luns []api.SIscsiLun, used map[string]bool) int64 {
+	var free int64
+	for i := range luns {
+		if !used[iscsiLunKey(luns[i].Target, luns[i].Lun)] {
+			free += luns[i].SizeMb
+		}
+	}
+	return free
+}
+
+func (self *SStorage) getUsedIscsiLuns() map[string]bool {
+	used := map[string]bool{}
+	for _, d := range self.GetDisks() {
+		if lun := d.GetIscsiLun(); lun != nil {
+			used[iscsiLunKey(lun.Target, lun.Lun)] = true
+		}
+	}
+	return used
+}
+
+// GetIscsiFreeCapacity returns the size of luns still available for new disks
+func (self *SStorage) GetIscsiFreeCapacity() int64 {
+	return freeIscsiLunsSizeMb(self.GetIscsiLuns(), self.getUsedIscsiLuns())
+}
+
+// AllocateIscsiLun assigns a free lun of the storage to the disk, luns
+// are exported by the array beforehand and each disk takes one of them
+func (self *SStorage) AllocateIscsiLun(ctx context.Context, userCred mcclient.TokenCredential, disk *SDisk) (*api.SIscsiLun, error) {
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	if lun := disk.GetIscsiLun(); lun != nil {
+		return lun, nil
+	}
+
+	lun := chooseIscsiLun(self.GetIscsiLuns(), self.getUsedIscsiLuns(), int64(disk.DiskSize))
+	if lun == nil {
+		return nil, httperrors.NewInsufficientResourceError("no free lun of storage %s is larger than %dMB", self.Name, disk.DiskSize)
+	}
+	err := disk.SetMetadata(ctx, api.DISK_META_ISCSI_LUN, jsonutils.Marshal(lun).String(), userCred)
+	if err != nil {
+		return nil, errors.Wrap(err, "disk.SetMetadata")
+	}
+	return lun, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestChooseIscsiLun(t *testing.T) {
+	const target = "iqn.2004-04.com.qnap:target0"
+	luns := []api.SIscsiLun{
+		{Target: target, Lun: 0, SizeMb: 102400},
+		{Target: target, Lun: 1, SizeMb: 20480},
+		{Target: target, Lun: 2, SizeMb: 51200},
+		{Target: target, Lun: 3, SizeMb: 20480},
+	}
+	cases := []struct {
+		sizeMb int64
+		used   []int
+		want   int
+	}{
+		{10240, nil, 1},
+		{10240, []int{1}, 3},
+		{30720, nil, 2},
+		{30720, []int{2}, 0},
+		{20480, []int{1, 3}, 2},
+		{204800, nil, -1},
+		{10240, []int{0, 1, 2, 3}, -1},
+	}
+	for _, c := range cases {
+		used := map[string]bool{}
+		for _, lun := range c.used {
+			used[iscsiLunKey(target, lun)] = true
+		}
+		got := -1
+		if lun := chooseIscsiLun(append([]api.SIscsiLun{}, luns...), used, c.sizeMb); lun != nil {
+			got = lun.Lun
+		}
+		if got != c.want {
+			t.Errorf("size %d used %v: want lun %d, got %d", c.sizeMb, c.used, c.want, got)
+		}
+	}
+}
+
+func TestFreeIscsiLunsSizeMb(t *testing.T) {
+	const target = "iqn.2004-04.com.qnap:target0"
+	luns := []api.SIscsiLun{
+		{Target: target, Lun: 0, SizeMb: 102400},
+		{Target: target, Lun: 1, SizeMb: 20480},
+		{Target: target, Lun: 2, SizeMb: 51200},
+	}
+	cases := []struct {
+		used []int
+		want int64
+	}{
+		{nil, 174080},
+		{[]int{1}, 153600},
+		{[]int{0, 2}, 20480},
+		{[]int{0, 1, 2}, 0},
+	}
+	for _, c := range cases {
+		used := map[string]bool{}
+		for _, lun := range c.used {
+			used[iscsiLunKey(target, lun)] = true
+		}
+		if got := freeIscsiLunsSizeMb(luns, used); got != c.want {
+			t.Errorf("used %v: want %d, got %d", c.used, c.want, got)
+		}
+	}
+}
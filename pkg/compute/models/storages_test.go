@@ -0,0 +1,43 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestStorageIsLvmThick(t *testing.T) {
+	cases := []struct {
+		name        string
+		storageType string
+		conf        jsonutils.JSONObject
+		want        bool
+	}{
+		{"local", api.STORAGE_LOCAL, nil, false},
+		{"lvm without conf", api.STORAGE_LVM, nil, true},
+		{"lvm thick", api.STORAGE_LVM, jsonutils.Marshal(map[string]string{"vg": "vg0"}), true},
+		{"lvm thin", api.STORAGE_LVM, jsonutils.Marshal(map[string]string{"vg": "vg0", "thin_pool": "pool0"}), false},
+	}
+	for _, c := range cases {
+		storage := &SStorage{StorageType: c.storageType, StorageConf: c.conf}
+		if got := storage.IsLvmThick(); got != c.want {
+			t.Errorf("%s: want %v, got %v", c.name, c.want, got)
+		}
+	}
+}
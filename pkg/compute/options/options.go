@@ -97,6 +97,9 @@ type ComputeOptions struct {
 	DefaultMaxSnapshotCount       int `default:"9" help:"Per Disk max snapshot count, default 9"`
 	DefaultMaxManualSnapshotCount int `default:"2" help:"Per Disk max manual snapshot count, default 2"`
 
+	GuestFsfreezeTimeoutSeconds  int `default:"30" help:"Seconds to wait guest agent freezing filesystems before quiesced snapshot, default 30"`
+	GuestFsfreezeAutoThawSeconds int `default:"300" help:"Seconds after which host thaws guest filesystems if snapshot does not finish, default 300"`
+
 	//snapshot policy options
 	RetentionDaysLimit  int `default:"49" help:"Days of snapshot retention, default 49 days"`
 	TimePointsLimit     int `default:"1" help:"time point of every days, default 1 point"`
@@ -158,6 +161,7 @@ type ComputeOptions struct {
 
 	SCapabilityOptions
 	SASControllerOptions
+	SDrsControllerOptions
 	common_options.CommonOptions
 	common_options.DBOptions
 
@@ -186,6 +190,13 @@ type SASControllerOptions struct {
 	ConcurrentUpper     int `help:"This represents the upper limit of concurrent sacling sctivities" default:"500"`
 	CheckScaleInterval  int `help:"The interval between the two checks about scaling, unit: s" default:"60"`
 	CheckHealthInterval int `help:"The interval bewteen the two check about instance's health unit: m" default:"1"`
+
+	CheckInstanceRefreshInterval int `help:"The interval between the two checks about instance refresh, unit: s" default:"30"`
+}
+
+type SDrsControllerOptions struct {
+	DrsCheckInterval           int `help:"The interval between two evaluations of drs policies, unit: m" default:"10"`
+	DrsMaxConcurrentMigrations int `help:"The upper limit of concurrent live migrations started by a drs policy" default:"2"`
 }
 
 var (
@@ -212,6 +212,30 @@ func (self *SBaseRegionDriver) RequestBingToNatgateway(ctx context.Context, task
 	return fmt.Errorf("Not implement RequestBindIPToNatgateway")
 }
 
+func (self *SBaseRegionDriver) RequestCreateNatGateway(ctx context.Context, userCred mcclient.TokenCredential, natgateway *models.SNatGateway, task taskman.ITask) error {
+	return errors.Wrapf(cloudprovider.ErrNotImplemented, "RequestCreateNatGateway")
+}
+
+func (self *SBaseRegionDriver) RequestDeleteNatGateway(ctx context.Context, userCred mcclient.TokenCredential, natgateway *models.SNatGateway, task taskman.ITask) error {
+	return errors.Wrapf(cloudprovider.ErrNotImplemented, "RequestDeleteNatGateway")
+}
+
+func (self *SBaseRegionDriver) RequestCreateNatSEntry(ctx context.Context, userCred mcclient.TokenCredential, snatEntry *models.SNatSEntry, task taskman.ITask) error {
+	return errors.Wrapf(cloudprovider.ErrNotImplemented, "RequestCreateNatSEntry")
+}
+
+func (self *SBaseRegionDriver) RequestDeleteNatSEntry(ctx context.Context, userCred mcclient.TokenCredential, snatEntry *models.SNatSEntry, task taskman.ITask) error {
+	return errors.Wrapf(cloudprovider.ErrNotImplemented, "RequestDeleteNatSEntry")
+}
+
+func (self *SBaseRegionDriver) RequestCreateNatDEntry(ctx context.Context, userCred mcclient.TokenCredential, dnatEntry *models.SNatDEntry, task taskman.ITask) error {
+	return errors.Wrapf(cloudprovider.ErrNotImplemented, "RequestCreateNatDEntry")
+}
+
+func (self *SBaseRegionDriver) RequestDeleteNatDEntry(ctx context.Context, userCred mcclient.TokenCredential, dnatEntry *models.SNatDEntry, task taskman.ITask) error {
+	return errors.Wrapf(cloudprovider.ErrNotImplemented, "RequestDeleteNatDEntry")
+}
+
 func (self *SBaseRegionDriver) IsVpcCreateNeedInputCidr() bool {
 	return true
 }
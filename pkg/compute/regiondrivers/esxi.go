@@ -64,12 +64,14 @@ func (self *SEsxiRegionDriver) RequestCreateInstanceSnapshot(ctx context.Context
 		if err != nil {
 			return nil, errors.Wrap(err, "unable to GetIVM")
 		}
-		cloudSP, err := ivm.CreateInstanceSnapshot(ctx, isp.GetName(), isp.Description)
+		quiesce := jsonutils.QueryBoolean(params, "quiesce", false)
+		cloudSP, err := ivm.CreateInstanceSnapshot(ctx, isp.GetName(), isp.Description, quiesce)
 		if err != nil {
 			return nil, errors.Wrap(err, "unable to CreateInstanceSnapshot")
 		}
 		_, err = db.Update(isp, func() error {
 			isp.SetExternalId(cloudSP.GetGlobalId())
+			isp.Quiesced = quiesce
 			return nil
 		})
 		return nil, err
@@ -24,6 +24,7 @@ import (
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/regutils"
 	"yunion.io/x/pkg/utils"
 	"yunion.io/x/sqlchemy"
 
@@ -39,6 +40,7 @@ import (
 	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/util/choices"
 	"yunion.io/x/onecloud/pkg/util/httputils"
+	"yunion.io/x/onecloud/pkg/util/netutils2"
 	"yunion.io/x/onecloud/pkg/util/rand"
 )
 
@@ -94,9 +96,9 @@ func (self *SKVMRegionDriver) ValidateCreateLoadbalancerData(ctx context.Context
 	if zone == nil {
 		return nil, httperrors.NewInputParameterError("zone info missing")
 	}
-	if vpc.Id != api.DEFAULT_VPC_ID {
-		return nil, httperrors.NewInputParameterError("vpc lb is not allowed for now")
-	}
+	// loadbalancers in vpc are served by ovn lbclusters, those in
+	// default vpc by lbagent lbclusters
+	isVpcLb := vpc.Id != api.DEFAULT_VPC_ID
 
 	if clusterV.Model == nil {
 		clusters := models.LoadbalancerClusterManager.FindByZoneId(zone.Id)
@@ -109,6 +111,9 @@ func (self *SKVMRegionDriver) ValidateCreateLoadbalancerData(ctx context.Context
 		)
 		for i := range clusters {
 			c := &clusters[i]
+			if c.IsOvn() != isVpcLb {
+				continue
+			}
 			if c.WireId != "" {
 				if c.WireId == network.WireId {
 					wireMatched = append(wireMatched, c)
@@ -137,12 +142,24 @@ func (self *SKVMRegionDriver) ValidateCreateLoadbalancerData(ctx context.Context
 			return nil, httperrors.NewInputParameterError("cluster wire affiliation does not match network's: %s != %s",
 				cluster.WireId, network.WireId)
 		}
+		if isVpcLb && !cluster.IsOvn() {
+			return nil, httperrors.NewInputParameterError("vpc lb requires lbcluster of type %s, got %s(%s) of type %s",
+				api.LB_CLUSTER_TYPE_OVN, cluster.Name, cluster.Id, cluster.ClusterType)
+		}
+		if !isVpcLb && cluster.IsOvn() {
+			return nil, httperrors.NewInputParameterError("lbcluster %s(%s) of type %s serves only vpc lb",
+				cluster.Name, cluster.Id, cluster.ClusterType)
+		}
 	}
 
+	networkType := api.LB_NETWORK_TYPE_CLASSIC
+	if isVpcLb {
+		networkType = api.LB_NETWORK_TYPE_VPC
+	}
 	data.Set("cloudregion_id", jsonutils.NewString(region.GetId()))
 	data.Set("zone_id", jsonutils.NewString(zone.GetId()))
 	data.Set("vpc_id", jsonutils.NewString(vpc.GetId()))
-	data.Set("network_type", jsonutils.NewString(api.LB_NETWORK_TYPE_CLASSIC))
+	data.Set("network_type", jsonutils.NewString(networkType))
 	data.Set("address_type", jsonutils.NewString(api.LB_ADDR_TYPE_INTRANET))
 	return data, nil
 }
@@ -179,6 +196,11 @@ func (self *SKVMRegionDriver) ValidateCreateLoadbalancerBackendData(ctx context.
 		return nil, err
 	}
 
+	if lb.IsOvn() && backendType != api.LB_BACKEND_GUEST {
+		return nil, httperrors.NewInputParameterError("ovn lb supports only backend of type %s, got %s",
+			api.LB_BACKEND_GUEST, backendType)
+	}
+
 	var basename string
 	switch backendType {
 	case api.LB_BACKEND_GUEST:
@@ -559,6 +581,24 @@ func (self *SKVMRegionDriver) ValidateCreateLoadbalancerListenerData(ctx context
 		return nil, err
 	}
 
+	if lb.IsOvn() {
+		getString := func(k string) string {
+			v, _ := data.GetString(k)
+			return v
+		}
+		err := validateOvnLoadbalancerListener(
+			listenerType,
+			getString("scheduler"),
+			getString("sticky_session"),
+			getString("health_check"),
+			getString("health_check_type"),
+			getString("acl_status"),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	data.Set("manager_id", jsonutils.NewString(lb.GetCloudproviderId()))
 	data.Set("cloudregion_id", jsonutils.NewString(lb.GetRegionId()))
 	return data, nil
@@ -677,6 +717,26 @@ func (self *SKVMRegionDriver) ValidateUpdateLoadbalancerListenerData(ctx context
 		return nil, err
 	}
 
+	if lb := lblis.GetLoadbalancer(); lb != nil && lb.IsOvn() {
+		getString := func(k, def string) string {
+			if v, err := data.GetString(k); err == nil {
+				return v
+			}
+			return def
+		}
+		err := validateOvnLoadbalancerListener(
+			lblis.ListenerType,
+			getString("scheduler", lblis.Scheduler),
+			getString("sticky_session", lblis.StickySession),
+			getString("health_check", lblis.HealthCheck),
+			getString("health_check_type", lblis.HealthCheckType),
+			getString("acl_status", lblis.AclStatus),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	{
 		if backendGroup == nil {
 			if lblis.ListenerType != api.LB_LISTENER_TYPE_HTTP &&
@@ -692,6 +752,38 @@ func (self *SKVMRegionDriver) ValidateUpdateLoadbalancerListenerData(ctx context
 	return data, nil
 }
 
+// validateOvnLoadbalancerListener checks that the listener can be realized
+// as ovn load balancer, which works at layer 4 only.  Scheduler sch is
+// realized as source ip affinity
+func validateOvnLoadbalancerListener(listenerType, scheduler, stickySession, healthCheck, healthCheckType, aclStatus string) error {
+	switch listenerType {
+	case api.LB_LISTENER_TYPE_TCP, api.LB_LISTENER_TYPE_UDP:
+	default:
+		return httperrors.NewInputParameterError("ovn lb supports only tcp and udp listener, got %s", listenerType)
+	}
+	switch scheduler {
+	case api.LB_SCHEDULER_RR, api.LB_SCHEDULER_SCH:
+	default:
+		return httperrors.NewInputParameterError("ovn lb supports only scheduler %s and %s, got %s",
+			api.LB_SCHEDULER_RR, api.LB_SCHEDULER_SCH, scheduler)
+	}
+	if stickySession == api.LB_BOOL_ON {
+		return httperrors.NewInputParameterError("ovn lb does not support sticky session, use scheduler %s for source ip affinity",
+			api.LB_SCHEDULER_SCH)
+	}
+	if healthCheck == api.LB_BOOL_ON {
+		switch healthCheckType {
+		case api.LB_HEALTH_CHECK_TCP, api.LB_HEALTH_CHECK_UDP:
+		default:
+			return httperrors.NewInputParameterError("ovn lb supports only tcp and udp health check, got %s", healthCheckType)
+		}
+	}
+	if aclStatus == api.LB_BOOL_ON {
+		return httperrors.NewInputParameterError("ovn lb does not support acl")
+	}
+	return nil
+}
+
 func (self *SKVMRegionDriver) RequestCreateLoadbalancer(ctx context.Context, userCred mcclient.TokenCredential, lb *models.SLoadbalancer, task taskman.ITask) error {
 	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
 		_, err := db.Update(lb, func() error {
@@ -714,7 +806,21 @@ func (self *SKVMRegionDriver) RequestCreateLoadbalancer(ctx context.Context, use
 			}
 			return nil
 		})
-		return nil, err
+		if err != nil {
+			return nil, err
+		}
+		if lb.Address != "" && lb.IsOvn() {
+			// ovn service monitor needs a source address on the
+			// loadbalancer network to probe backends with
+			req := &models.SLoadbalancerNetworkRequestData{
+				Loadbalancer: lb,
+				NetworkId:    lb.NetworkId,
+			}
+			if _, err := models.LoadbalancernetworkManager.NewLoadbalancerNetwork(ctx, userCred, req); err != nil {
+				log.Errorf("allocating ovn lb health check source address failed: %v", err)
+			}
+		}
+		return nil, nil
 	})
 	return nil
 }
@@ -919,7 +1025,10 @@ func (self *SKVMRegionDriver) ValidateCreateEipData(ctx context.Context, userCre
 	if err := self.ValidateEipChargeType(input.ChargeType); err != nil {
 		return err
 	}
-	var network *models.SNetwork
+	var (
+		network *models.SNetwork
+		isIp6   = regutils.MatchIP6Addr(input.IpAddr)
+	)
 	if input.NetworkId != "" {
 		_network, err := models.NetworkManager.FetchByIdOrName(userCred, input.NetworkId)
 		if err != nil {
@@ -940,6 +1049,15 @@ func (self *SKVMRegionDriver) ValidateCreateEipData(ctx context.Context, userCre
 		}
 		for i := range nets {
 			net := &nets[i]
+			if isIp6 {
+				addr, _ := netutils2.NewIPV6Addr(input.IpAddr)
+				if net.IsAddress6InRange(addr) {
+					network = net
+					input.NetworkId = net.Id
+					break
+				}
+				continue
+			}
 			cnt, _ := net.GetFreeAddressCount()
 			if cnt > 0 {
 				network = net
@@ -956,6 +1074,21 @@ func (self *SKVMRegionDriver) ValidateCreateEipData(ctx context.Context, userCre
 	}
 	input.NetworkId = network.Id
 
+	if isIp6 {
+		if !network.HasIPv6() {
+			return httperrors.NewInputParameterError("network %s(%s) has no ipv6 address range", network.Name, network.Id)
+		}
+		// eip has no mac address to derive slaac address from
+		if network.GetIp6AllocMode() != api.IPV6_ALLOC_MODE_DHCPV6 {
+			return httperrors.NewInputParameterError("ipv6 eip requires network %s(%s) in %s mode", network.Name, network.Id, api.IPV6_ALLOC_MODE_DHCPV6)
+		}
+		addr, _ := netutils2.NewIPV6Addr(input.IpAddr)
+		if !network.IsAddress6InRange(addr) {
+			return httperrors.NewInputParameterError("address %s not in range of network %s(%s)", input.IpAddr, network.Name, network.Id)
+		}
+		input.IpAddr = addr.String()
+	}
+
 	vpc := network.GetVpc()
 	if vpc == nil {
 		return httperrors.NewInputParameterError("failed to found vpc for network %s(%s)", network.Name, network.Id)
@@ -1234,16 +1367,142 @@ func (self *SKVMRegionDriver) RequestSyncSnapshotStatus(ctx context.Context, use
 	return nil
 }
 
+func (self *SKVMRegionDriver) IsSupportedNatGateway() bool {
+	return true
+}
+
+func (self *SKVMRegionDriver) IsSupportedNatAutoRenew() bool {
+	return false
+}
+
+func (self *SKVMRegionDriver) ValidateCreateNatGateway(ctx context.Context, userCred mcclient.TokenCredential, input api.NatgatewayCreateInput) (api.NatgatewayCreateInput, error) {
+	if input.VpcId == api.DEFAULT_VPC_ID {
+		return input, httperrors.NewInputParameterError("nat gateway is not supported in default vpc")
+	}
+	_vpc, err := models.VpcManager.FetchById(input.VpcId)
+	if err != nil {
+		return input, httperrors.NewGeneralError(errors.Wrapf(err, "VpcManager.FetchById(%s)", input.VpcId))
+	}
+	vpc := _vpc.(*models.SVpc)
+	// nat gateway egresses from the eip gateway of vpc
+	if !utils.IsInStringArray(vpc.ExternalAccessMode, []string{
+		api.VPC_EXTERNAL_ACCESS_MODE_EIP,
+		api.VPC_EXTERNAL_ACCESS_MODE_EIP_DISTGW,
+	}) {
+		return input, httperrors.NewInputParameterError("vpc %s external access mode %s does not support nat gateway", vpc.Name, vpc.ExternalAccessMode)
+	}
+	return input, nil
+}
+
+// nat gateway and its rules are realized by vpcagent, there is nothing to
+// do with them remotely
+func (self *SKVMRegionDriver) RequestCreateNatGateway(ctx context.Context, userCred mcclient.TokenCredential, natgateway *models.SNatGateway, task taskman.ITask) error {
+	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
+		return nil, nil
+	})
+	return nil
+}
+
+func (self *SKVMRegionDriver) RequestDeleteNatGateway(ctx context.Context, userCred mcclient.TokenCredential, natgateway *models.SNatGateway, task taskman.ITask) error {
+	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
+		return nil, nil
+	})
+	return nil
+}
+
+func (self *SKVMRegionDriver) RequestCreateNatSEntry(ctx context.Context, userCred mcclient.TokenCredential, snatEntry *models.SNatSEntry, task taskman.ITask) error {
+	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
+		return nil, nil
+	})
+	return nil
+}
+
+func (self *SKVMRegionDriver) RequestDeleteNatSEntry(ctx context.Context, userCred mcclient.TokenCredential, snatEntry *models.SNatSEntry, task taskman.ITask) error {
+	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
+		return nil, nil
+	})
+	return nil
+}
+
+func (self *SKVMRegionDriver) RequestCreateNatDEntry(ctx context.Context, userCred mcclient.TokenCredential, dnatEntry *models.SNatDEntry, task taskman.ITask) error {
+	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
+		return nil, nil
+	})
+	return nil
+}
+
+func (self *SKVMRegionDriver) RequestDeleteNatDEntry(ctx context.Context, userCred mcclient.TokenCredential, dnatEntry *models.SNatDEntry, task taskman.ITask) error {
+	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
+		return nil, nil
+	})
+	return nil
+}
+
+func (self *SKVMRegionDriver) RequestSyncNatGatewayStatus(ctx context.Context, userCred mcclient.TokenCredential, natgateway *models.SNatGateway, task taskman.ITask) error {
+	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
+		return nil, natgateway.SetStatus(userCred, api.NAT_STAUTS_AVAILABLE, "syncstatus")
+	})
+	return nil
+}
+
 func (self *SKVMRegionDriver) RequestBindIPToNatgateway(ctx context.Context, task taskman.ITask, natgateway *models.SNatGateway,
 	eipId string) error {
 
+	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
+		model, err := models.ElasticipManager.FetchById(eipId)
+		if err != nil {
+			return nil, errors.Wrapf(err, "ElasticipManager.FetchById(%s)", eipId)
+		}
+		lockman.LockObject(ctx, model)
+		defer lockman.ReleaseObject(ctx, model)
+		eip := model.(*models.SElasticip)
+		err = eip.AssociateNatGateway(ctx, task.GetUserCred(), natgateway)
+		if err != nil {
+			return nil, errors.Wrapf(err, "associate eip %s to natgateway %s", eip.Id, natgateway.Id)
+		}
+		return nil, nil
+	})
 	return nil
 }
 
+// RequestUnBindIPFromNatgateway dissociates the eip from natgateway when it
+// is no longer used by any nat rule of the natgateway
 func (self *SKVMRegionDriver) RequestUnBindIPFromNatgateway(ctx context.Context, task taskman.ITask,
 	nat models.INatHelper, natgateway *models.SNatGateway) error {
 
-	return nil
+	var ipAddr string
+	switch entry := nat.(type) {
+	case *models.SNatSEntry:
+		ipAddr = entry.IP
+	case *models.SNatDEntry:
+		ipAddr = entry.ExternalIP
+	default:
+		return errors.Wrapf(cloudprovider.ErrNotSupported, "nat entry %s", nat.Keyword())
+	}
+	cnt := 0
+	for _, q := range []*sqlchemy.SQuery{
+		models.NatSEntryManager.Query().Equals("natgateway_id", natgateway.Id).Equals("ip", ipAddr),
+		models.NatDEntryManager.Query().Equals("natgateway_id", natgateway.Id).Equals("external_ip", ipAddr),
+	} {
+		n, err := q.CountWithError()
+		if err != nil {
+			return errors.Wrapf(err, "count nat entries using %s", ipAddr)
+		}
+		cnt += n
+	}
+	if cnt > 0 {
+		return nil
+	}
+
+	eip := &models.SElasticip{}
+	err := models.ElasticipManager.Query().Equals("associate_id", natgateway.Id).Equals("ip_addr", ipAddr).First(eip)
+	if err != nil {
+		return errors.Wrapf(err, "fail to fetch eip %s associate with natgateway %s", ipAddr, natgateway.Id)
+	}
+	eip.SetModelManager(models.ElasticipManager, eip)
+	lockman.LockObject(ctx, eip)
+	defer lockman.ReleaseObject(ctx, eip)
+	return eip.Dissociate(ctx, task.GetUserCred())
 }
 
 func (self *SKVMRegionDriver) RequestPreSnapshotPolicyApply(ctx context.Context, userCred mcclient.
@@ -1257,6 +1516,24 @@ func (self *SKVMRegionDriver) RequestPreSnapshotPolicyApply(ctx context.Context,
 }
 
 func (self *SKVMRegionDriver) BindIPToNatgatewayRollback(ctx context.Context, eipId string) error {
+	model, err := models.ElasticipManager.FetchById(eipId)
+	if err != nil {
+		return errors.Wrapf(err, "ElasticipManager.FetchById(%s)", eipId)
+	}
+	lockman.LockObject(ctx, model)
+	defer lockman.ReleaseObject(ctx, model)
+	eip := model.(*models.SElasticip)
+	if eip.AssociateType != api.EIP_ASSOCIATE_TYPE_NAT_GATEWAY {
+		return nil
+	}
+	_, err = db.Update(eip, func() error {
+		eip.AssociateId = ""
+		eip.AssociateType = ""
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "rollback about binding eip %s failed", eip.Id)
+	}
 	return nil
 }
 
@@ -1406,6 +1683,16 @@ func (self *SKVMRegionDriver) GetMaxElasticcacheSecurityGroupCount() int {
 
 func (self *SKVMRegionDriver) RequestAssociatEip(ctx context.Context, userCred mcclient.TokenCredential, eip *models.SElasticip, input api.ElasticipAssociateInput, obj db.IStatusStandaloneModel, task taskman.ITask) error {
 	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
+		if input.InstanceType == api.EIP_ASSOCIATE_TYPE_NAT_GATEWAY {
+			nat := obj.(*models.SNatGateway)
+			if err := eip.AssociateNatGateway(ctx, userCred, nat); err != nil {
+				return nil, errors.Wrapf(err, "associate eip %s(%s) to natgateway %s(%s)", eip.Name, eip.Id, nat.Name, nat.Id)
+			}
+			if err := eip.SetStatus(userCred, api.EIP_STATUS_READY, api.EIP_STATUS_ASSOCIATE); err != nil {
+				return nil, errors.Wrapf(err, "set eip status to %s", api.EIP_STATUS_READY)
+			}
+			return nil, nil
+		}
 		if input.InstanceType != api.EIP_ASSOCIATE_TYPE_SERVER {
 			return nil, errors.Wrapf(cloudprovider.ErrNotSupported, "instance type %s", input.InstanceType)
 		}
@@ -1485,6 +1485,240 @@ func (self *SManagedVirtualizationRegionDriver) OnSnapshotDelete(ctx context.Con
 	return nil
 }
 
+func (self *SManagedVirtualizationRegionDriver) RequestCreateNatGateway(ctx context.Context, userCred mcclient.TokenCredential, nat *models.SNatGateway, task taskman.ITask) error {
+	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
+		opts := cloudprovider.NatGatewayCreateOptions{
+			Name:    nat.Name,
+			Desc:    nat.Description,
+			NatSpec: nat.NatSpec,
+		}
+
+		vpc, err := nat.GetVpc()
+		if err != nil {
+			return nil, errors.Wrapf(err, "nat.GetVpc")
+		}
+		opts.VpcId = vpc.ExternalId
+
+		if len(nat.NetworkId) > 0 {
+			_network, err := models.NetworkManager.FetchById(nat.NetworkId)
+			if err != nil {
+				return nil, errors.Wrapf(err, "NetworkManager.FetchById(%s)", nat.NetworkId)
+			}
+			network := _network.(*models.SNetwork)
+			opts.NetworkId = network.ExternalId
+		}
+
+		if nat.BillingType == billing_api.BILLING_TYPE_PREPAID {
+			bc, err := billing.ParseBillingCycle(nat.BillingCycle)
+			if err != nil {
+				return nil, errors.Wrapf(err, "ParseBillingCycle(%s)", nat.BillingCycle)
+			}
+			bc.AutoRenew = nat.AutoRenew
+			opts.BillingCycle = &bc
+		}
+
+		iVpc, err := vpc.GetIVpc()
+		if err != nil {
+			return nil, errors.Wrapf(err, "vpc.GetIVpc")
+		}
+
+		iNat, err := iVpc.CreateINatGateway(&opts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "iVpc.CreateINatGateway")
+		}
+		err = db.SetExternalId(nat, userCred, iNat.GetGlobalId())
+		if err != nil {
+			return nil, errors.Wrapf(err, "db.SetExternalId")
+		}
+
+		err = cloudprovider.WaitStatus(iNat, api.NAT_STAUTS_AVAILABLE, time.Second*5, time.Minute*10)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cloudprovider.WaitStatus")
+		}
+
+		nat.SyncWithCloudNatGateway(ctx, userCred, nat.GetCloudprovider(), iNat)
+		return nil, nil
+	})
+	return nil
+}
+
+func (self *SManagedVirtualizationRegionDriver) RequestDeleteNatGateway(ctx context.Context, userCred mcclient.TokenCredential, nat *models.SNatGateway, task taskman.ITask) error {
+	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
+		iNat, err := nat.GetINatGateway()
+		if err != nil {
+			if errors.Cause(err) == cloudprovider.ErrNotFound {
+				return nil, nil
+			}
+			return nil, errors.Wrapf(err, "nat.GetINatGateway")
+		}
+
+		dnat, err := iNat.GetINatDTable()
+		if err != nil {
+			return nil, errors.Wrapf(err, "iNat.GetINatDTable")
+		}
+		for i := range dnat {
+			err = dnat[i].Delete()
+			if err != nil {
+				return nil, errors.Wrapf(err, "delete d entry %v", dnat[i])
+			}
+		}
+		snat, err := iNat.GetINatSTable()
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetINatSTable")
+		}
+		for i := range snat {
+			err = snat[i].Delete()
+			if err != nil {
+				return nil, errors.Wrapf(err, "delete s entry %v", snat[i])
+			}
+		}
+
+		err = iNat.Delete()
+		if err != nil {
+			return nil, errors.Wrapf(err, "iNat.Delete")
+		}
+
+		err = cloudprovider.WaitDeleted(iNat, time.Second*5, time.Minute*3)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cloudprovider.WaitDeleted")
+		}
+		return nil, nil
+	})
+	return nil
+}
+
+func (self *SManagedVirtualizationRegionDriver) RequestCreateNatSEntry(ctx context.Context, userCred mcclient.TokenCredential, snatEntry *models.SNatSEntry, task taskman.ITask) error {
+	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
+		cloudNatGateway, err := snatEntry.GetINatGateway()
+		if err != nil {
+			return nil, errors.Wrapf(err, "Get NatGateway failed")
+		}
+
+		externalIPID, _ := task.GetParams().GetString("eip_external_id")
+		// construct a SNat RUle
+		snatRule := cloudprovider.SNatSRule{
+			ExternalIP:   snatEntry.IP,
+			ExternalIPID: externalIPID,
+		}
+		if task.GetParams().Contains("network_ext_id") {
+			extID, _ := task.GetParams().GetString("network_ext_id")
+			snatRule.NetworkID = extID
+		} else {
+			snatRule.SourceCIDR = snatEntry.SourceCIDR
+		}
+		extSnat, err := cloudNatGateway.CreateINatSEntry(snatRule)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Create SNat Entry '%s' failed", snatEntry.ExternalId)
+		}
+
+		err = cloudprovider.WaitStatus(extSnat, api.NAT_STAUTS_AVAILABLE, 10*time.Second, 300*time.Second)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cloudprovider.WaitStatus")
+		}
+
+		err = db.SetExternalId(snatEntry, userCred, extSnat.GetGlobalId())
+		if err != nil {
+			return nil, errors.Wrapf(err, "set external id failed")
+		}
+		return nil, nil
+	})
+	return nil
+}
+
+func (self *SManagedVirtualizationRegionDriver) RequestDeleteNatSEntry(ctx context.Context, userCred mcclient.TokenCredential, snatEntry *models.SNatSEntry, task taskman.ITask) error {
+	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
+		if len(snatEntry.ExternalId) == 0 {
+			return nil, nil
+		}
+		cloudNatGateway, err := snatEntry.GetINatGateway()
+		if err != nil {
+			return nil, errors.Wrapf(err, "Get NatGateway failed")
+		}
+		cloudNatSEntry, err := cloudNatGateway.GetINatSEntryByID(snatEntry.ExternalId)
+		if err != nil {
+			if errors.Cause(err) == cloudprovider.ErrNotFound {
+				return nil, nil
+			}
+			return nil, errors.Wrapf(err, "Get SNat Entry by ID '%s' failed", snatEntry.ExternalId)
+		}
+		err = cloudNatSEntry.Delete()
+		if err != nil {
+			return nil, errors.Wrapf(err, "Delete SNat Entry '%s' failed", snatEntry.ExternalId)
+		}
+		err = cloudprovider.WaitDeleted(cloudNatSEntry, 10*time.Second, 300*time.Second)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cloudprovider.WaitDeleted")
+		}
+		return nil, nil
+	})
+	return nil
+}
+
+func (self *SManagedVirtualizationRegionDriver) RequestCreateNatDEntry(ctx context.Context, userCred mcclient.TokenCredential, dnatEntry *models.SNatDEntry, task taskman.ITask) error {
+	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
+		cloudNatGateway, err := dnatEntry.GetINatGateway()
+		if err != nil {
+			return nil, errors.Wrapf(err, "Get NatGateway failed")
+		}
+
+		externalIPID, _ := task.GetParams().GetString("eip_external_id")
+		// construct a DNat RUle
+		dnatRule := cloudprovider.SNatDRule{
+			Protocol:     dnatEntry.IpProtocol,
+			InternalIP:   dnatEntry.InternalIP,
+			InternalPort: dnatEntry.InternalPort,
+			ExternalIP:   dnatEntry.ExternalIP,
+			ExternalIPID: externalIPID,
+			ExternalPort: dnatEntry.ExternalPort,
+		}
+		extDnat, err := cloudNatGateway.CreateINatDEntry(dnatRule)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Create DNat Entry '%s' failed", dnatEntry.ExternalId)
+		}
+
+		err = cloudprovider.WaitStatus(extDnat, api.NAT_STAUTS_AVAILABLE, 10*time.Second, 300*time.Second)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cloudprovider.WaitStatus")
+		}
+
+		err = db.SetExternalId(dnatEntry, userCred, extDnat.GetGlobalId())
+		if err != nil {
+			return nil, errors.Wrapf(err, "set external id failed")
+		}
+		return nil, nil
+	})
+	return nil
+}
+
+func (self *SManagedVirtualizationRegionDriver) RequestDeleteNatDEntry(ctx context.Context, userCred mcclient.TokenCredential, dnatEntry *models.SNatDEntry, task taskman.ITask) error {
+	taskman.LocalTaskRun(task, func() (jsonutils.JSONObject, error) {
+		if len(dnatEntry.ExternalId) == 0 {
+			return nil, nil
+		}
+		cloudNatGateway, err := dnatEntry.GetINatGateway()
+		if err != nil {
+			return nil, errors.Wrapf(err, "Get NatGateway failed")
+		}
+		cloudNatDEntry, err := cloudNatGateway.GetINatDEntryByID(dnatEntry.ExternalId)
+		if err != nil {
+			if errors.Cause(err) == cloudprovider.ErrNotFound {
+				return nil, nil
+			}
+			return nil, errors.Wrapf(err, "Get DNat Entry by ID '%s' failed", dnatEntry.ExternalId)
+		}
+		err = cloudNatDEntry.Delete()
+		if err != nil {
+			return nil, errors.Wrapf(err, "Delete DNat Entry '%s' failed", dnatEntry.ExternalId)
+		}
+		err = cloudprovider.WaitDeleted(cloudNatDEntry, 10*time.Second, 300*time.Second)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cloudprovider.WaitDeleted")
+		}
+		return nil, nil
+	})
+	return nil
+}
+
 func (self *SManagedVirtualizationRegionDriver) RequestBindIPToNatgateway(ctx context.Context, task taskman.ITask,
 	natgateway *models.SNatGateway, eipId string) error {
 
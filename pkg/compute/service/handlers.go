@@ -54,6 +54,7 @@ func InitHandlers(app *appsrv.Application) {
 		taskman.TaskManager,
 		taskman.SubTaskManager,
 		taskman.TaskObjectManager,
+		taskman.TaskWorkerManager,
 		db.UserCacheManager,
 		db.TenantCacheManager,
 		db.SharedResourceManager,
@@ -87,6 +88,7 @@ func InitHandlers(app *appsrv.Application) {
 
 		models.ScalingTimerManager,
 		models.ScalingAlarmManager,
+		models.ScalingTargetTrackingManager,
 		models.ScalingGroupGuestManager,
 		models.ScalingGroupNetworkManager,
 
@@ -133,6 +135,8 @@ func InitHandlers(app *appsrv.Application) {
 		models.NatDEntryManager,
 		models.NatSEntryManager,
 		models.InstanceSnapshotManager,
+		models.DiskBackupManager,
+		models.DrsPolicyManager,
 		models.SnapshotManager,
 		models.SnapshotPolicyManager,
 		models.SnapshotPolicyCacheManager,
@@ -190,6 +194,9 @@ func InitHandlers(app *appsrv.Application) {
 		models.ScalingGroupManager,
 		models.ScalingPolicyManager,
 		models.ScalingActivityManager,
+		models.ScalingInstanceRefreshManager,
+		models.ScalingLifecycleHookManager,
+		models.ScalingLifecycleActionManager,
 		models.PolicyDefinitionManager,
 		models.PolicyAssignmentManager,
 
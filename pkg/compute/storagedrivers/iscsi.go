@@ -0,0 +1,189 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagedrivers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+const iscsiDefaultPort = "3260"
+
+type SIscsiStorageDriver struct {
+	SBaseStorageDriver
+}
+
+func init() {
+	driver := SIscsiStorageDriver{}
+	models.RegisterStorageDriver(&driver)
+}
+
+func (self *SIscsiStorageDriver) GetStorageType() string {
+	return api.STORAGE_ISCSI
+}
+
+func normalizeIscsiPortals(portals string) ([]string, error) {
+	ret := []string{}
+	for _, portal := range strings.Split(portals, ",") {
+		portal = strings.TrimSpace(portal)
+		if len(portal) == 0 {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(portal); err != nil {
+			portal = net.JoinHostPort(portal, iscsiDefaultPort)
+		}
+		host, _, err := net.SplitHostPort(portal)
+		if err != nil || net.ParseIP(host) == nil {
+			return nil, httperrors.NewInputParameterError("invalid iscsi portal %s", portal)
+		}
+		ret = append(ret, portal)
+	}
+	if len(ret) == 0 {
+		return nil, httperrors.NewMissingParameterError("iscsi_portals")
+	}
+	return ret, nil
+}
+
+func (self *SIscsiStorageDriver) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, input *api.StorageCreateInput) error {
+	input.StorageConf = jsonutils.NewDict()
+	if len(input.IscsiPortals) == 0 {
+		return httperrors.NewMissingParameterError("iscsi_portals")
+	}
+	portals, err := normalizeIscsiPortals(input.IscsiPortals)
+	if err != nil {
+		return err
+	}
+	if len(input.IscsiTarget) == 0 {
+		return httperrors.NewMissingParameterError("iscsi_target")
+	}
+	if !strings.HasPrefix(input.IscsiTarget, "iqn.") && !strings.HasPrefix(input.IscsiTarget, "eui.") && !strings.HasPrefix(input.IscsiTarget, "naa.") {
+		return httperrors.NewInputParameterError("invalid iscsi target name %s", input.IscsiTarget)
+	}
+	if len(input.IscsiChapUsername) > 0 && len(input.IscsiChapPassword) == 0 {
+		return httperrors.NewMissingParameterError("iscsi_chap_password")
+	}
+
+	storages := []models.SStorage{}
+	q := models.StorageManager.Query().Equals("storage_type", api.STORAGE_ISCSI).IsNullOrEmpty("manager_id")
+	if err := db.FetchModelObjects(models.StorageManager, q, &storages); err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	for i := 0; i < len(storages); i++ {
+		target, _ := storages[i].StorageConf.GetString("target")
+		if target == input.IscsiTarget {
+			return httperrors.NewDuplicateResourceError("This iSCSI Storage[%s/%s] has already exist", storages[i].Name, target)
+		}
+	}
+
+	input.StorageConf.Update(jsonutils.Marshal(map[string]interface{}{
+		"portals":       portals,
+		"target":        input.IscsiTarget,
+		"chap_username": input.IscsiChapUsername,
+		"chap_password": input.IscsiChapPassword,
+	}))
+	return nil
+}
+
+// ValidateUpdateData takes the luns reported by hosts logged in to the target
+func (self *SIscsiStorageDriver) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, data *jsonutils.JSONDict, storage *models.SStorage) (*jsonutils.JSONDict, error) {
+	conf, ok := storage.StorageConf.(*jsonutils.JSONDict)
+	if !ok {
+		conf = jsonutils.NewDict()
+	}
+	var changed bool
+	if data.Contains("iscsi_luns") {
+		luns := []api.SIscsiLun{}
+		if err := data.Unmarshal(&luns, "iscsi_luns"); err != nil {
+			return nil, httperrors.NewInputParameterError("invalid iscsi_luns: %s", err)
+		}
+		conf.Set("luns", jsonutils.Marshal(luns))
+		data.Remove("iscsi_luns")
+		changed = true
+	}
+	data.Set("update_storage_conf", jsonutils.JSONFalse)
+	if portalsStr, _ := data.GetString("iscsi_portals"); len(portalsStr) > 0 {
+		portals, err := normalizeIscsiPortals(portalsStr)
+		if err != nil {
+			return nil, err
+		}
+		conf.Set("portals", jsonutils.Marshal(portals))
+		data.Set("update_storage_conf", jsonutils.JSONTrue)
+	}
+	for _, k := range []string{"iscsi_chap_username", "iscsi_chap_password"} {
+		if data.Contains(k) {
+			v, _ := data.GetString(k)
+			conf.Set(strings.TrimPrefix(k, "iscsi_"), jsonutils.NewString(v))
+			data.Set("update_storage_conf", jsonutils.JSONTrue)
+		}
+	}
+
+	if update, _ := data.Bool("update_storage_conf"); update || changed {
+		_, err := storage.GetModelManager().TableSpec().Update(ctx, storage, func() error {
+			storage.StorageConf = conf
+			return nil
+		})
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+	}
+	return data, nil
+}
+
+func (self *SIscsiStorageDriver) DoStorageUpdateTask(ctx context.Context, userCred mcclient.TokenCredential, storage *models.SStorage, task taskman.ITask) error {
+	subtask, err := taskman.TaskManager.NewTask(ctx, "SharedStorageUpdateTask", storage, task.GetUserCred(), task.GetParams(), task.GetTaskId(), "", nil)
+	if err != nil {
+		return err
+	}
+	subtask.ScheduleRun(nil)
+	return nil
+}
+
+// PostCreate creates the storagecache, images are cached on hosts
+// and written to the lun of disk when the disk is allocated
+func (self *SIscsiStorageDriver) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, storage *models.SStorage, data jsonutils.JSONObject) {
+	sc := &models.SStoragecache{}
+	sc.SetModelManager(models.StoragecacheManager, sc)
+	sc.Path = options.Options.DefaultImageCacheDir
+	sc.ExternalId = storage.Id
+	sc.Name = fmt.Sprintf("iscsi-%s", storage.Id)
+	if err := models.StoragecacheManager.TableSpec().Insert(ctx, sc); err != nil {
+		log.Errorf("insert storagecache for storage %s error: %v", storage.Name, err)
+		return
+	}
+	_, err := db.Update(storage, func() error {
+		storage.StoragecacheId = sc.Id
+		return nil
+	})
+	if err != nil {
+		log.Errorf("update storagecache info for storage %s error: %v", storage.Name, err)
+	}
+}
+
+func (self *SIscsiStorageDriver) ValidateCreateSnapshotData(ctx context.Context, userCred mcclient.TokenCredential, disk *models.SDisk, input *api.SnapshotCreateInput) error {
+	return httperrors.NewUnsupportOperationError("Not support create snapshot for disk of %s storage", api.STORAGE_ISCSI)
+}
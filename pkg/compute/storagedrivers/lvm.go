@@ -0,0 +1,109 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagedrivers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+)
+
+type SLVMStorageDriver struct {
+	SBaseStorageDriver
+}
+
+func init() {
+	driver := SLVMStorageDriver{}
+	models.RegisterStorageDriver(&driver)
+}
+
+func (self *SLVMStorageDriver) GetStorageType() string {
+	return api.STORAGE_LVM
+}
+
+func (self *SLVMStorageDriver) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, input *api.StorageCreateInput) error {
+	return nil
+}
+
+func (self *SLVMStorageDriver) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, storage *models.SStorage, data jsonutils.JSONObject) {
+}
+
+func (self *SLVMStorageDriver) ValidateCreateSnapshotData(ctx context.Context, userCred mcclient.TokenCredential, disk *models.SDisk, input *api.SnapshotCreateInput) error {
+	if storage := disk.GetStorage(); storage != nil && storage.IsLvmThick() {
+		return httperrors.NewBadRequestError("Can't do snapshot on lvm storage %s without thin pool", storage.Name)
+	}
+	return self.SBaseStorageDriver.ValidateCreateSnapshotData(ctx, userCred, disk, input)
+}
+
+// thin snapshots don't depend on each other or the disk
+func (self *SLVMStorageDriver) ValidateSnapshotDelete(ctx context.Context, snapshot *models.SSnapshot) error {
+	return nil
+}
+
+func (self *SLVMStorageDriver) RequestCreateSnapshot(ctx context.Context, snapshot *models.SSnapshot, task taskman.ITask) error {
+	disk, err := snapshot.GetDisk()
+	if err != nil {
+		return errors.Wrap(err, "snapshot get disk")
+	}
+	storage := snapshot.GetStorage()
+	host := storage.GetMasterHost()
+	if host == nil {
+		return errors.Errorf("storage %s can't get master host", storage.Id)
+	}
+	url := fmt.Sprintf("%s/disks/%s/snapshot/%s", host.ManagerUri, storage.Id, disk.Id)
+	header := task.GetTaskRequestHeader()
+	params := jsonutils.NewDict()
+	params.Set("snapshot_id", jsonutils.NewString(snapshot.Id))
+	_, _, err = httputils.JSONRequest(httputils.GetDefaultClient(), ctx, "POST", url, header, params, false)
+	if err != nil {
+		return errors.Wrap(err, "request create snapshot")
+	}
+	return nil
+}
+
+func (self *SLVMStorageDriver) RequestDeleteSnapshot(ctx context.Context, snapshot *models.SSnapshot, task taskman.ITask) error {
+	storage := snapshot.GetStorage()
+	host := storage.GetMasterHost()
+	if host == nil {
+		return errors.Errorf("storage %s can't get master host", storage.Id)
+	}
+	url := fmt.Sprintf("%s/disks/%s/delete-snapshot/%s", host.ManagerUri, storage.Id, snapshot.DiskId)
+	header := task.GetTaskRequestHeader()
+	params := jsonutils.NewDict()
+	params.Set("snapshot_id", jsonutils.NewString(snapshot.Id))
+	_, _, err := httputils.JSONRequest(httputils.GetDefaultClient(), ctx, "POST", url, header, params, false)
+	if err != nil {
+		return errors.Wrap(err, "request delete snapshot")
+	}
+	return nil
+}
+
+func (self *SLVMStorageDriver) SnapshotIsOutOfChain(disk *models.SDisk) bool {
+	return true
+}
+
+func (self *SLVMStorageDriver) OnDiskReset(ctx context.Context, userCred mcclient.TokenCredential, disk *models.SDisk, snapshot *models.SSnapshot, data jsonutils.JSONObject) error {
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type BaremetalRaidVolumeTask struct {
+	SBaremetalBaseTask
+}
+
+func init() {
+	taskman.RegisterTask(BaremetalRaidVolumeTask{})
+}
+
+func (self *BaremetalRaidVolumeTask) getAction() string {
+	action, _ := self.Params.GetString("action")
+	if action == api.BAREMETAL_RAID_VOLUME_ACTION_DELETE {
+		return logclient.ACT_RAID_VOLUME_DELETE
+	}
+	return logclient.ACT_RAID_VOLUME_CREATE
+}
+
+func (self *BaremetalRaidVolumeTask) OnInit(ctx context.Context, obj db.IStandaloneModel, body jsonutils.JSONObject) {
+	baremetal := obj.(*models.SHost)
+	baremetal.SetStatus(self.UserCred, api.BAREMETAL_RAID_CONFIGURING, "")
+	url := fmt.Sprintf("/baremetals/%s/raid-volume", baremetal.Id)
+	headers := self.GetTaskRequestHeader()
+	self.SetStage("OnRaidVolumeComplete", nil)
+	_, err := baremetal.BaremetalSyncRequest(ctx, "POST", url, headers, self.Params)
+	if err != nil {
+		self.OnFailure(ctx, baremetal, jsonutils.NewString(err.Error()))
+	}
+}
+
+func (self *BaremetalRaidVolumeTask) OnFailure(ctx context.Context, baremetal *models.SHost, reason jsonutils.JSONObject) {
+	baremetal.SetStatus(self.UserCred, api.BAREMETAL_RAID_CONFIG_FAIL, reason.String())
+	logclient.AddActionLogWithStartable(self, baremetal, self.getAction(), reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *BaremetalRaidVolumeTask) OnRaidVolumeComplete(ctx context.Context, baremetal *models.SHost, body jsonutils.JSONObject) {
+	logclient.AddActionLogWithStartable(self, baremetal, self.getAction(), self.Params, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *BaremetalRaidVolumeTask) OnRaidVolumeCompleteFailed(ctx context.Context, baremetal *models.SHost, body jsonutils.JSONObject) {
+	self.OnFailure(ctx, baremetal, body)
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	hostapi "yunion.io/x/onecloud/pkg/apis/host"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type DiskBackupCreateTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(DiskBackupCreateTask{})
+}
+
+func (self *DiskBackupCreateTask) taskFailed(ctx context.Context, backup *models.SDiskBackup, reason jsonutils.JSONObject) {
+	backup.SetStatus(self.UserCred, api.DISK_BACKUP_STATUS_CREATE_FAILED, reason.String())
+	// qemu may have reset dirty bitmap already, next backup can not be
+	// based on the last successful one any more
+	if disk := backup.GetDisk(); disk != nil {
+		disk.SetMetadata(ctx, api.DISK_METADATA_LAST_BACKUP, "", self.UserCred)
+	}
+	db.OpsLog.LogEvent(backup, db.ACT_CREATE_BACKUP_FAILED, reason, self.UserCred)
+	logclient.AddActionLogWithStartable(self, backup, logclient.ACT_CREATE_BACKUP, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *DiskBackupCreateTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	backup := obj.(*models.SDiskBackup)
+	disk := backup.GetDisk()
+	if disk == nil {
+		self.taskFailed(ctx, backup, jsonutils.NewString(fmt.Sprintf("disk %s not found", backup.DiskId)))
+		return
+	}
+	host := backup.GetHost()
+	if host == nil {
+		self.taskFailed(ctx, backup, jsonutils.NewString("backup host not found"))
+		return
+	}
+	self.SetStage("OnDiskBackupComplete", nil)
+	err := host.GetHostDriver().RequestDiskBackup(ctx, host, disk, backup, self)
+	if err != nil {
+		self.taskFailed(ctx, backup, jsonutils.NewString(err.Error()))
+	}
+}
+
+func (self *DiskBackupCreateTask) OnDiskBackupComplete(ctx context.Context, backup *models.SDiskBackup, data jsonutils.JSONObject) {
+	resp := &hostapi.DiskBackupResponse{}
+	if err := data.Unmarshal(resp); err != nil {
+		self.taskFailed(ctx, backup, jsonutils.NewString(fmt.Sprintf("unmarshal backup response: %v", err)))
+		return
+	}
+	_, err := db.Update(backup, func() error {
+		if resp.Mode == api.DISK_BACKUP_MODE_FULL {
+			// host starts a new chain if changes since parent are unknown
+			backup.BackupMode = api.DISK_BACKUP_MODE_FULL
+			backup.ParentId = ""
+			backup.ChainId = backup.Id
+		}
+		backup.BackupStorage = resp.StorageType
+		backup.Location = resp.Location
+		backup.SizeMb = resp.SizeMb
+		backup.DiskSizeMb = resp.DiskSizeMb
+		backup.Status = api.DISK_BACKUP_STATUS_READY
+		return nil
+	})
+	if err != nil {
+		self.taskFailed(ctx, backup, jsonutils.NewString(fmt.Sprintf("update backup: %v", err)))
+		return
+	}
+	if disk := backup.GetDisk(); disk != nil {
+		disk.SetMetadata(ctx, api.DISK_METADATA_LAST_BACKUP, backup.Id, self.UserCred)
+	}
+	db.OpsLog.LogEvent(backup, db.ACT_CREATE_BACKUP, backup.GetShortDesc(ctx), self.UserCred)
+	logclient.AddActionLogWithStartable(self, backup, logclient.ACT_CREATE_BACKUP, nil, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *DiskBackupCreateTask) OnDiskBackupCompleteFailed(ctx context.Context, backup *models.SDiskBackup, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, backup, data)
+}
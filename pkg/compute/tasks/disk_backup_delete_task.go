@@ -0,0 +1,113 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+// DiskBackupDeleteTask deletes backups listed in backup_ids, either a
+// single one or a whole chain pruned
+type DiskBackupDeleteTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(DiskBackupDeleteTask{})
+}
+
+func (self *DiskBackupDeleteTask) getBackups() ([]models.SDiskBackup, error) {
+	backupIds := jsonutils.GetQueryStringArray(self.Params, "backup_ids")
+	backups := make([]models.SDiskBackup, 0)
+	q := models.DiskBackupManager.Query().In("id", backupIds)
+	err := db.FetchModelObjects(models.DiskBackupManager, q, &backups)
+	if err != nil {
+		return nil, err
+	}
+	return backups, nil
+}
+
+func (self *DiskBackupDeleteTask) taskFailed(ctx context.Context, backup *models.SDiskBackup, reason jsonutils.JSONObject) {
+	backups, err := self.getBackups()
+	if err != nil {
+		log.Errorf("fetch backups fail %s", err)
+	}
+	for i := range backups {
+		backups[i].SetStatus(self.UserCred, api.DISK_BACKUP_STATUS_DELETE_FAILED, reason.String())
+	}
+	db.OpsLog.LogEvent(backup, db.ACT_DELETE_BACKUP_FAILED, reason, self.UserCred)
+	logclient.AddActionLogWithStartable(self, backup, logclient.ACT_DELETE_BACKUP, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *DiskBackupDeleteTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	backup := obj.(*models.SDiskBackup)
+	backups, err := self.getBackups()
+	if err != nil {
+		self.taskFailed(ctx, backup, jsonutils.NewString(err.Error()))
+		return
+	}
+	var created []models.SDiskBackup
+	for i := range backups {
+		// nothing was saved for backups failed
+		if len(backups[i].Location) > 0 {
+			created = append(created, backups[i])
+		}
+	}
+	if len(created) == 0 {
+		self.OnDeleteComplete(ctx, backup, nil)
+		return
+	}
+	host := backup.GetHost()
+	if host == nil {
+		self.taskFailed(ctx, backup, jsonutils.NewString("backup host not found"))
+		return
+	}
+	self.SetStage("OnDeleteComplete", nil)
+	err = host.GetHostDriver().RequestDeleteDiskBackups(ctx, host, backup.DiskId, created, self)
+	if err != nil {
+		self.taskFailed(ctx, backup, jsonutils.NewString(err.Error()))
+	}
+}
+
+func (self *DiskBackupDeleteTask) OnDeleteComplete(ctx context.Context, backup *models.SDiskBackup, data jsonutils.JSONObject) {
+	backups, err := self.getBackups()
+	if err != nil {
+		self.taskFailed(ctx, backup, jsonutils.NewString(err.Error()))
+		return
+	}
+	for i := range backups {
+		if err := backups[i].RealDelete(ctx, self.UserCred); err != nil {
+			self.taskFailed(ctx, backup, jsonutils.NewString(err.Error()))
+			return
+		}
+		db.OpsLog.LogEvent(&backups[i], db.ACT_DELETE_BACKUP, backups[i].GetShortDesc(ctx), self.UserCred)
+		logclient.AddActionLogWithStartable(self, &backups[i], logclient.ACT_DELETE_BACKUP, nil, self.UserCred, true)
+	}
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *DiskBackupDeleteTask) OnDeleteCompleteFailed(ctx context.Context, backup *models.SDiskBackup, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, backup, data)
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type DiskBackupRestoreTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(DiskBackupRestoreTask{})
+}
+
+func (self *DiskBackupRestoreTask) taskFailed(ctx context.Context, backup *models.SDiskBackup, reason jsonutils.JSONObject) {
+	backup.SetStatus(self.UserCred, api.DISK_BACKUP_STATUS_READY, "")
+	if disk := backup.GetDisk(); disk != nil {
+		disk.SetStatus(self.UserCred, api.DISK_READY, "")
+		if guest := disk.GetGuest(); guest != nil {
+			guest.SetStatus(self.UserCred, api.VM_DISK_RESET_FAIL, reason.String())
+		}
+		logclient.AddActionLogWithStartable(self, disk, logclient.ACT_RESTORE, reason, self.UserCred, false)
+	}
+	db.OpsLog.LogEvent(backup, db.ACT_RESTORE, reason, self.UserCred)
+	logclient.AddActionLogWithStartable(self, backup, logclient.ACT_RESTORE, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *DiskBackupRestoreTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	backup := obj.(*models.SDiskBackup)
+	disk := backup.GetDisk()
+	if disk == nil {
+		self.taskFailed(ctx, backup, jsonutils.NewString(fmt.Sprintf("disk %s not found", backup.DiskId)))
+		return
+	}
+	chain, err := backup.GetChain()
+	if err != nil {
+		self.taskFailed(ctx, backup, jsonutils.NewString(err.Error()))
+		return
+	}
+	storage := disk.GetStorage()
+	if storage == nil {
+		self.taskFailed(ctx, backup, jsonutils.NewString("disk storage not found"))
+		return
+	}
+	host := storage.GetMasterHost()
+	if host == nil {
+		self.taskFailed(ctx, backup, jsonutils.NewString("storage master host not found"))
+		return
+	}
+	disk.SetStatus(self.UserCred, api.DISK_RESET, "restore from backup")
+	if guest := disk.GetGuest(); guest != nil {
+		guest.SetStatus(self.UserCred, api.VM_DISK_RESET, "restore from backup")
+	}
+	self.SetStage("OnRestoreComplete", nil)
+	err = host.GetHostDriver().RequestRestoreDiskBackup(ctx, host, disk, chain, self)
+	if err != nil {
+		self.taskFailed(ctx, backup, jsonutils.NewString(err.Error()))
+	}
+}
+
+func (self *DiskBackupRestoreTask) OnRestoreComplete(ctx context.Context, backup *models.SDiskBackup, data jsonutils.JSONObject) {
+	backup.SetStatus(self.UserCred, api.DISK_BACKUP_STATUS_READY, "")
+	disk := backup.GetDisk()
+	if disk != nil {
+		// content of disk changed, changes tracked by dirty bitmap are lost
+		disk.SetMetadata(ctx, api.DISK_METADATA_LAST_BACKUP, "", self.UserCred)
+		disk.SetStatus(self.UserCred, api.DISK_READY, "")
+		if guest := disk.GetGuest(); guest != nil {
+			guest.SetStatus(self.UserCred, api.VM_READY, "")
+		}
+		db.OpsLog.LogEvent(disk, db.ACT_RESTORE, backup.GetShortDesc(ctx), self.UserCred)
+		logclient.AddActionLogWithStartable(self, disk, logclient.ACT_RESTORE, backup.GetShortDesc(ctx), self.UserCred, true)
+	}
+	logclient.AddActionLogWithStartable(self, backup, logclient.ACT_RESTORE, nil, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *DiskBackupRestoreTask) OnRestoreCompleteFailed(ctx context.Context, backup *models.SDiskBackup, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, backup, data)
+}
@@ -17,6 +17,7 @@ package tasks
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
@@ -36,6 +37,7 @@ type DiskResizeTask struct {
 
 func init() {
 	taskman.RegisterTask(DiskResizeTask{})
+	taskman.RegisterTaskStageTimeout(DiskResizeTask{}, "OnDiskResizeComplete", time.Hour)
 }
 
 func (self *DiskResizeTask) SetDiskReady(ctx context.Context, disk *models.SDisk, userCred mcclient.TokenCredential, reason string) {
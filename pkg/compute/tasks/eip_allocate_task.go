@@ -20,6 +20,7 @@ import (
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
+	"yunion.io/x/pkg/util/regutils"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
@@ -89,7 +90,12 @@ func (self *EipAllocateTask) OnInit(ctx context.Context, obj db.IStandaloneModel
 			lockman.LockObject(ctx, network)
 			defer lockman.ReleaseObject(ctx, network)
 
-			ipAddr, err := network.GetFreeIP(ctx, self.UserCred, nil, nil, reqIp, api.IPAllocationNone, false)
+			var ipAddr string
+			if regutils.MatchIP6Addr(reqIp) {
+				ipAddr, err = network.GetFreeIP6(ctx, self.UserCred, nil, reqIp, "", api.IPAllocationNone)
+			} else {
+				ipAddr, err = network.GetFreeIP(ctx, self.UserCred, nil, nil, reqIp, api.IPAllocationNone, false)
+			}
 			if err != nil {
 				self.onFailed(ctx, eip, jsonutils.NewString(err.Error()))
 				return
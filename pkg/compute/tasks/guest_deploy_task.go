@@ -17,6 +17,7 @@ package tasks
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
@@ -162,4 +163,5 @@ func (self *GuestDeployTask) OnDeployGuestSyncstatusCompleteFailed(ctx context.C
 
 func init() {
 	taskman.RegisterTask(GuestDeployTask{})
+	taskman.RegisterTaskStageTimeout(GuestDeployTask{}, "OnDeployGuestComplete", time.Hour)
 }
@@ -0,0 +1,142 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+func init() {
+	taskman.RegisterTask(GuestStartRescueTask{})
+	taskman.RegisterTask(GuestStopRescueTask{})
+}
+
+type GuestStartRescueTask struct {
+	SGuestBaseTask
+}
+
+func (self *GuestStartRescueTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	guest := obj.(*models.SGuest)
+	guest.SetStatus(self.UserCred, api.VM_START_RESCUE, "")
+	guestStatus, _ := self.Params.GetString("guest_status")
+	if guestStatus == api.VM_READY {
+		self.OnGuestStopComplete(ctx, guest, nil)
+		return
+	}
+	self.SetStage("OnGuestStopComplete", nil)
+	err := guest.StartGuestStopTask(ctx, self.UserCred, false, false, self.GetTaskId())
+	if err != nil {
+		self.taskFailed(ctx, guest, jsonutils.NewString(err.Error()))
+	}
+}
+
+func (self *GuestStartRescueTask) OnGuestStopComplete(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	// guest desc carries rescue mode to host, so plain start boots rescue image
+	self.SetStage("OnRescueStartComplete", nil)
+	err := guest.StartGueststartTask(ctx, self.UserCred, nil, self.GetTaskId())
+	if err != nil {
+		self.taskFailed(ctx, guest, jsonutils.NewString(err.Error()))
+	}
+}
+
+func (self *GuestStartRescueTask) OnGuestStopCompleteFailed(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, guest, data)
+}
+
+func (self *GuestStartRescueTask) OnRescueStartComplete(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	db.OpsLog.LogEvent(guest, db.ACT_START_RESCUE, "", self.UserCred)
+	logclient.AddActionLogWithStartable(self, guest, logclient.ACT_START_RESCUE, nil, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *GuestStartRescueTask) OnRescueStartCompleteFailed(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, guest, data)
+}
+
+// rescue mode is kept on failure, so that start-rescue could be retried
+// or stop-rescue could restore the normal boot configuration
+func (self *GuestStartRescueTask) taskFailed(ctx context.Context, guest *models.SGuest, reason jsonutils.JSONObject) {
+	guest.SetStatus(self.UserCred, api.VM_START_RESCUE_FAIL, reason.String())
+	db.OpsLog.LogEvent(guest, db.ACT_START_RESCUE_FAIL, reason, self.UserCred)
+	logclient.AddActionLogWithStartable(self, guest, logclient.ACT_START_RESCUE, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
+
+type GuestStopRescueTask struct {
+	SGuestBaseTask
+}
+
+func (self *GuestStopRescueTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	guest := obj.(*models.SGuest)
+	guest.SetStatus(self.UserCred, api.VM_STOP_RESCUE, "")
+	guestStatus, _ := self.Params.GetString("guest_status")
+	if guestStatus == api.VM_READY {
+		self.OnGuestStopComplete(ctx, guest, nil)
+		return
+	}
+	self.SetStage("OnGuestStopComplete", nil)
+	err := guest.StartGuestStopTask(ctx, self.UserCred, false, false, self.GetTaskId())
+	if err != nil {
+		self.taskFailed(ctx, guest, jsonutils.NewString(err.Error()))
+	}
+}
+
+func (self *GuestStopRescueTask) OnGuestStopComplete(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	err := guest.SetRescueMode(ctx, self.UserCred, "")
+	if err != nil {
+		self.taskFailed(ctx, guest, jsonutils.NewString(err.Error()))
+		return
+	}
+	db.OpsLog.LogEvent(guest, db.ACT_STOP_RESCUE, "", self.UserCred)
+	logclient.AddActionLogWithStartable(self, guest, logclient.ACT_STOP_RESCUE, nil, self.UserCred, true)
+	if !jsonutils.QueryBoolean(self.Params, "auto_start", true) {
+		guest.SetStatus(self.UserCred, api.VM_READY, "")
+		self.SetStageComplete(ctx, nil)
+		return
+	}
+	self.SetStage("OnGuestStartComplete", nil)
+	err = guest.StartGueststartTask(ctx, self.UserCred, nil, self.GetTaskId())
+	if err != nil {
+		self.SetStageFailed(ctx, jsonutils.NewString(err.Error()))
+	}
+}
+
+func (self *GuestStopRescueTask) OnGuestStopCompleteFailed(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, guest, data)
+}
+
+func (self *GuestStopRescueTask) OnGuestStartComplete(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *GuestStopRescueTask) OnGuestStartCompleteFailed(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	// rescue mode is already off, the guest start task has marked the failure
+	self.SetStageFailed(ctx, data)
+}
+
+func (self *GuestStopRescueTask) taskFailed(ctx context.Context, guest *models.SGuest, reason jsonutils.JSONObject) {
+	guest.SetStatus(self.UserCred, api.VM_STOP_RESCUE_FAIL, reason.String())
+	db.OpsLog.LogEvent(guest, db.ACT_STOP_RESCUE_FAIL, reason, self.UserCred)
+	logclient.AddActionLogWithStartable(self, guest, logclient.ACT_STOP_RESCUE, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
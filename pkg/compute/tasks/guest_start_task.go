@@ -16,6 +16,7 @@ package tasks
 
 import (
 	"context"
+	"time"
 
 	"yunion.io/x/jsonutils"
 
@@ -33,6 +34,7 @@ type GuestStartTask struct {
 func init() {
 	taskman.RegisterTask(GuestStartTask{})
 	taskman.RegisterTask(GuestSchedStartTask{})
+	taskman.RegisterTaskStageTimeout(GuestStartTask{}, "OnStartComplete", 30*time.Minute)
 }
 
 func (self *GuestStartTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
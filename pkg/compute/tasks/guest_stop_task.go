@@ -16,6 +16,7 @@ package tasks
 
 import (
 	"context"
+	"time"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
@@ -35,6 +36,7 @@ type GuestStopTask struct {
 func init() {
 	taskman.RegisterTask(GuestStopTask{})
 	taskman.RegisterTask(GuestStopAndFreezeTask{})
+	taskman.RegisterTaskStageTimeout(GuestStopTask{}, "OnGuestStopTaskComplete", 30*time.Minute)
 }
 
 func (self *GuestStopTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
@@ -16,6 +16,7 @@ package tasks
 
 import (
 	"context"
+	"time"
 
 	"yunion.io/x/jsonutils"
 
@@ -31,6 +32,7 @@ type GuestSuspendTask struct {
 
 func init() {
 	taskman.RegisterTask(GuestSuspendTask{})
+	taskman.RegisterTaskStageTimeout(GuestSuspendTask{}, "on_suspend_complete", 30*time.Minute)
 }
 
 func (self *GuestSuspendTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
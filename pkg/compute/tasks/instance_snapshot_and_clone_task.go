@@ -83,7 +83,8 @@ func (self *InstanceSnapshotAndCloneTask) OnInit(
 
 	isp := obj.(*models.SInstanceSnapshot)
 	self.SetStage("OnCreateInstanceSnapshot", nil)
-	err := isp.StartCreateInstanceSnapshotTask(ctx, self.UserCred, nil, self.Id)
+	quiesce := jsonutils.QueryBoolean(self.Params, "quiesce", false)
+	err := isp.StartCreateInstanceSnapshotTask(ctx, self.UserCred, nil, quiesce, self.Id)
 	if err != nil {
 		self.taskFailed(ctx, isp, jsonutils.NewString(err.Error()))
 		return
@@ -18,6 +18,7 @@ import (
 	"context"
 
 	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
 
 	"yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
@@ -55,6 +56,7 @@ func (self *InstanceSnapshotCreateTask) taskFail(
 	if guest == nil {
 		guest = models.GuestManager.FetchGuestById(isp.GuestId)
 	}
+	self.thawGuest(ctx, guest)
 	isp.SetStatus(self.UserCred, compute.INSTANCE_SNAPSHOT_FAILED, reason.String())
 	guest.SetStatus(self.UserCred, compute.VM_INSTANCE_SNAPSHOT_FAILED, reason.String())
 
@@ -71,6 +73,11 @@ func (self *InstanceSnapshotCreateTask) taskComplete(
 	if guest == nil {
 		guest = models.GuestManager.FetchGuestById(isp.GuestId)
 	}
+	if self.thawGuest(ctx, guest) {
+		if err := isp.SetQuiesced(true); err != nil {
+			log.Errorf("instance snapshot %s set quiesced: %s", isp.Name, err)
+		}
+	}
 	isp.SetStatus(self.UserCred, compute.INSTANCE_SNAPSHOT_READY, "")
 	guest.StartSyncstatus(ctx, self.UserCred, "")
 
@@ -88,15 +95,70 @@ func (self *InstanceSnapshotCreateTask) OnInit(
 
 	isp := obj.(*models.SInstanceSnapshot)
 	guest := models.GuestManager.FetchGuestById(isp.GuestId)
+	if jsonutils.QueryBoolean(self.Params, "quiesce", false) && guest.Hypervisor == compute.HYPERVISOR_KVM {
+		self.SetStage("OnGuestFsfreeze", nil)
+		taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+			return nil, guest.GuestFsfreeze(ctx, self.UserCred)
+		})
+		return
+	}
+	self.doInstanceSnapshot(ctx, isp, guest)
+}
+
+func (self *InstanceSnapshotCreateTask) OnGuestFsfreeze(
+	ctx context.Context, isp *models.SInstanceSnapshot, data jsonutils.JSONObject) {
+
+	params := jsonutils.NewDict()
+	params.Set("fsfrozen", jsonutils.JSONTrue)
+	self.SaveParams(params)
+	self.doInstanceSnapshot(ctx, isp, nil)
+}
+
+func (self *InstanceSnapshotCreateTask) OnGuestFsfreezeFailed(
+	ctx context.Context, isp *models.SInstanceSnapshot, data jsonutils.JSONObject) {
+
+	// guest agent may be absent, fallback to crash consistent snapshot
+	log.Warningf("instance snapshot %s freeze guest filesystems failed: %s, snapshot is not quiesced", isp.Name, data)
+	self.doInstanceSnapshot(ctx, isp, nil)
+}
+
+func (self *InstanceSnapshotCreateTask) doInstanceSnapshot(
+	ctx context.Context, isp *models.SInstanceSnapshot, guest *models.SGuest) {
+
+	if guest == nil {
+		guest = models.GuestManager.FetchGuestById(isp.GuestId)
+	}
 	self.SetStage("OnInstanceSnapshot", nil)
 	params := jsonutils.NewDict()
 	params.Set("disk_index", jsonutils.NewInt(0))
+	if jsonutils.QueryBoolean(self.Params, "quiesce", false) {
+		params.Set("quiesce", jsonutils.JSONTrue)
+	}
 	if err := isp.GetRegionDriver().RequestCreateInstanceSnapshot(ctx, guest, isp, self, params); err != nil {
 		self.taskFail(ctx, isp, guest, jsonutils.NewString(err.Error()))
 		return
 	}
 }
 
+// thawGuest thaws guest filesystems frozen by this task, it returns true
+// if filesystems stayed frozen until thaw, i.e. snapshots were taken quiesced
+func (self *InstanceSnapshotCreateTask) thawGuest(ctx context.Context, guest *models.SGuest) bool {
+	if !jsonutils.QueryBoolean(self.Params, "fsfrozen", false) {
+		return false
+	}
+	count, err := guest.GuestFsthaw(ctx, self.UserCred)
+	if err != nil {
+		// host thaws guest by itself after auto thaw timeout
+		log.Warningf("thaw guest %s: %s, snapshots may not be quiesced", guest.Name, err)
+		return false
+	}
+	if count == 0 {
+		log.Warningf("guest %s filesystems were thawed before snapshots finished, snapshots may not be quiesced", guest.Name)
+		return false
+	}
+	return true
+}
+
 func (self *InstanceSnapshotCreateTask) OnKvmDiskSnapshot(
 	ctx context.Context, isp *models.SInstanceSnapshot, data jsonutils.JSONObject) {
 
@@ -17,7 +17,6 @@ package tasks
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
@@ -25,7 +24,6 @@ import (
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
-	"yunion.io/x/onecloud/pkg/cloudprovider"
 	"yunion.io/x/onecloud/pkg/compute/models"
 	"yunion.io/x/onecloud/pkg/util/logclient"
 )
@@ -64,46 +62,39 @@ func (self *SNatDEntryCreateTask) OnBindIPCompleteFailed(ctx context.Context, dn
 func (self *SNatDEntryCreateTask) OnBindIPComplete(ctx context.Context, dnatEntry *models.SNatDEntry,
 	body jsonutils.JSONObject) {
 
-	cloudNatGateway, err := dnatEntry.GetINatGateway()
-	if err != nil {
-		self.TaskFailed(ctx, dnatEntry, jsonutils.NewString(fmt.Sprintf("Get NatGateway failed: %s", err)))
-		return
-	}
-
-	externalIPID, err := self.Params.GetString("eip_external_id")
-	// construct a DNat RUle
-	dnatRule := cloudprovider.SNatDRule{
-		Protocol:     dnatEntry.IpProtocol,
-		InternalIP:   dnatEntry.InternalIP,
-		InternalPort: dnatEntry.InternalPort,
-		ExternalIP:   dnatEntry.ExternalIP,
-		ExternalIPID: externalIPID,
-		ExternalPort: dnatEntry.ExternalPort,
-	}
-	extDnat, err := cloudNatGateway.CreateINatDEntry(dnatRule)
+	natgateway, err := dnatEntry.GetNatgateway()
 	if err != nil {
-		if self.Params.Contains("need_bind") {
-			err1 := CreateINatFailedRollback(ctx, self, dnatEntry)
-			if err1 != nil {
-				eip_id, _ := self.Params.GetString("eip_id")
-				log.Errorf("roll back after failing to create dnat in cloud so that eip %s need to sync with cloud", eip_id)
-			}
-		}
-		self.TaskFailed(ctx, dnatEntry, jsonutils.NewString(fmt.Sprintf("Create DNat Entry '%s' failed: %s", dnatEntry.ExternalId, err)))
+		self.TaskFailed(ctx, dnatEntry, jsonutils.NewString(fmt.Sprintf("fetch natgateway failed: %s", err)))
 		return
 	}
-
-	err = cloudprovider.WaitStatus(extDnat, api.NAT_STAUTS_AVAILABLE, 10*time.Second, 300*time.Second)
+	self.SetStage("OnCreateDNatEntryComplete", nil)
+	err = natgateway.GetRegion().GetDriver().RequestCreateNatDEntry(ctx, self.UserCred, dnatEntry, self)
 	if err != nil {
+		self.rollback(ctx, dnatEntry)
 		self.TaskFailed(ctx, dnatEntry, jsonutils.NewString(err.Error()))
 		return
 	}
+}
 
-	err = db.SetExternalId(dnatEntry, self.UserCred, extDnat.GetGlobalId())
-	if err != nil {
-		self.TaskFailed(ctx, dnatEntry, jsonutils.NewString(fmt.Sprintf("set external id failed: %s", err)))
-		return
+func (self *SNatDEntryCreateTask) rollback(ctx context.Context, dnatEntry *models.SNatDEntry) {
+	if self.Params.Contains("need_bind") {
+		err := CreateINatFailedRollback(ctx, self, dnatEntry)
+		if err != nil {
+			eip_id, _ := self.Params.GetString("eip_id")
+			log.Errorf("roll back after failing to create dnat so that eip %s need to sync with cloud", eip_id)
+		}
 	}
+}
+
+func (self *SNatDEntryCreateTask) OnCreateDNatEntryCompleteFailed(ctx context.Context, dnatEntry *models.SNatDEntry,
+	reason jsonutils.JSONObject) {
+
+	self.rollback(ctx, dnatEntry)
+	self.TaskFailed(ctx, dnatEntry, reason)
+}
+
+func (self *SNatDEntryCreateTask) OnCreateDNatEntryComplete(ctx context.Context, dnatEntry *models.SNatDEntry,
+	body jsonutils.JSONObject) {
 
 	dnatEntry.SetStatus(self.UserCred, api.NAT_STAUTS_AVAILABLE, "")
 	db.OpsLog.LogEvent(dnatEntry, db.ACT_ALLOCATE, dnatEntry.GetShortDesc(ctx), self.UserCred)
@@ -16,17 +16,13 @@ package tasks
 
 import (
 	"context"
-	"fmt"
-	"time"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
-	"yunion.io/x/pkg/errors"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
-	"yunion.io/x/onecloud/pkg/cloudprovider"
 	"yunion.io/x/onecloud/pkg/compute/models"
 	"yunion.io/x/onecloud/pkg/util/logclient"
 )
@@ -57,31 +53,29 @@ func (self *SNatDEntryDeleteTask) OnInit(ctx context.Context, obj db.IStandalone
 	natgateway, err := dnatEntry.GetNatgateway()
 	if err != nil {
 		self.TaskFailed(ctx, dnatEntry, jsonutils.NewString(err.Error()))
+		return
 	}
-	cloudNatGateway, err := dnatEntry.GetINatGateway()
+	self.SetStage("OnDeleteDNatEntryComplete", nil)
+	err = natgateway.GetRegion().GetDriver().RequestDeleteNatDEntry(ctx, self.UserCred, dnatEntry, self)
 	if err != nil {
-		self.TaskFailed(ctx, dnatEntry, jsonutils.NewString(fmt.Sprintf("Get NatGateway failed: %s", err)))
+		self.TaskFailed(ctx, dnatEntry, jsonutils.NewString(err.Error()))
 		return
 	}
+}
 
-	cloudNatDEntry, err := cloudNatGateway.GetINatDEntryByID(dnatEntry.ExternalId)
-	if errors.Cause(err) == cloudprovider.ErrNotFound {
-		// already delete
-	} else if err != nil {
-		self.TaskFailed(ctx, dnatEntry, jsonutils.NewString(fmt.Sprintf("Get DNat Entry by ID '%s' failed", dnatEntry.ExternalId)))
-		return
-	} else if cloudNatDEntry != nil {
-		err = cloudNatDEntry.Delete()
-		if err != nil {
-			self.TaskFailed(ctx, dnatEntry, jsonutils.NewString(fmt.Sprintf("Delete DNat Entry '%s' failed: %s", dnatEntry.ExternalId, err)))
-			return
-		}
+func (self *SNatDEntryDeleteTask) OnDeleteDNatEntryCompleteFailed(ctx context.Context, dnatEntry *models.SNatDEntry,
+	reason jsonutils.JSONObject) {
+
+	self.TaskFailed(ctx, dnatEntry, reason)
+}
 
-		err = cloudprovider.WaitDeleted(cloudNatDEntry, 10*time.Second, 300*time.Second)
-		if err != nil {
-			self.TaskFailed(ctx, dnatEntry, jsonutils.NewString(err.Error()))
-			return
-		}
+func (self *SNatDEntryDeleteTask) OnDeleteDNatEntryComplete(ctx context.Context, dnatEntry *models.SNatDEntry,
+	body jsonutils.JSONObject) {
+
+	natgateway, err := dnatEntry.GetNatgateway()
+	if err != nil {
+		self.TaskFailed(ctx, dnatEntry, jsonutils.NewString(err.Error()))
+		return
 	}
 
 	err = dnatEntry.Purge(ctx, self.UserCred)
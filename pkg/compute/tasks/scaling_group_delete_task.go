@@ -141,6 +141,13 @@ func (self *ScalingGroupDeleteTask) OnInit(ctx context.Context, obj db.IStandalo
 		}
 	}
 
+	// delete SScalingLifecycleHooks and their actions
+	err = sg.DeleteLifecycleHooks(ctx, self.UserCred)
+	if err != nil {
+		self.taskFailed(ctx, sg, jsonutils.NewString(fmt.Sprintf("ScalingGroup.DeleteLifecycleHooks: %s", err.Error())))
+		return
+	}
+
 	err = sg.RealDelete(ctx, self.UserCred)
 	if err != nil {
 		self.taskFailed(ctx, sg, jsonutils.NewString(fmt.Sprintf("ScalingGroup.RealDelete: %s", err.Error())))
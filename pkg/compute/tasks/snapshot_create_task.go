@@ -16,8 +16,10 @@ package tasks
 
 import (
 	"context"
+	"time"
 
 	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
@@ -33,14 +35,77 @@ type SnapshotCreateTask struct {
 
 func init() {
 	taskman.RegisterTask(SnapshotCreateTask{})
+	taskman.RegisterTaskStageTimeout(SnapshotCreateTask{}, "OnGuestFsfreeze", 10*time.Minute)
+	taskman.RegisterTaskStageTimeout(SnapshotCreateTask{}, "OnCreateSnapshot", 2*time.Hour)
 }
 
 func (self *SnapshotCreateTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
 	snapshot := obj.(*models.SSnapshot)
+	if jsonutils.QueryBoolean(self.Params, "quiesce", false) {
+		if guest := self.getGuest(snapshot); guest != nil && guest.IsFsfreezeNeeded() {
+			params := jsonutils.NewDict()
+			params.Set("guest_id", jsonutils.NewString(guest.Id))
+			self.SetStage("OnGuestFsfreeze", params)
+			taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+				return nil, guest.GuestFsfreeze(ctx, self.UserCred)
+			})
+			return
+		}
+	}
+	self.DoDiskSnapshot(ctx, snapshot)
+}
+
+func (self *SnapshotCreateTask) getGuest(snapshot *models.SSnapshot) *models.SGuest {
+	disk, err := snapshot.GetDisk()
+	if err != nil {
+		return nil
+	}
+	guests := disk.GetGuests()
+	if len(guests) != 1 {
+		return nil
+	}
+	return &guests[0]
+}
+
+func (self *SnapshotCreateTask) OnGuestFsfreeze(ctx context.Context, snapshot *models.SSnapshot, data jsonutils.JSONObject) {
+	params := jsonutils.NewDict()
+	params.Set("fsfrozen", jsonutils.JSONTrue)
+	self.SaveParams(params)
+	self.DoDiskSnapshot(ctx, snapshot)
+}
+
+func (self *SnapshotCreateTask) OnGuestFsfreezeFailed(ctx context.Context, snapshot *models.SSnapshot, data jsonutils.JSONObject) {
+	// guest agent may be absent, fallback to crash consistent snapshot
+	log.Warningf("snapshot %s freeze guest filesystems failed: %s, snapshot is not quiesced", snapshot.Name, data)
 	self.DoDiskSnapshot(ctx, snapshot)
 }
 
+// thawGuest thaws guest filesystems frozen by this task, it returns true
+// if filesystems stayed frozen until thaw, i.e. snapshot was taken quiesced
+func (self *SnapshotCreateTask) thawGuest(ctx context.Context) bool {
+	if !jsonutils.QueryBoolean(self.Params, "fsfrozen", false) {
+		return false
+	}
+	guestId, _ := self.Params.GetString("guest_id")
+	guest := models.GuestManager.FetchGuestById(guestId)
+	if guest == nil {
+		return false
+	}
+	count, err := guest.GuestFsthaw(ctx, self.UserCred)
+	if err != nil {
+		// host thaws guest by itself after auto thaw timeout
+		log.Warningf("thaw guest %s: %s, snapshot may not be quiesced", guest.Name, err)
+		return false
+	}
+	if count == 0 {
+		log.Warningf("guest %s filesystems were thawed before snapshot finished, snapshot may not be quiesced", guest.Name)
+		return false
+	}
+	return true
+}
+
 func (self *SnapshotCreateTask) TaskFailed(ctx context.Context, snapshot *models.SSnapshot, reason jsonutils.JSONObject) {
+	self.thawGuest(ctx)
 	snapshot.SetStatus(self.UserCred, api.SNAPSHOT_FAILED, reason.String())
 	db.OpsLog.LogEvent(snapshot, db.ACT_SNAPSHOT_FAIL, reason, self.UserCred)
 	logclient.AddActionLogWithStartable(self, snapshot, logclient.ACT_CREATE, reason, self.UserCred, false)
@@ -48,6 +113,11 @@ func (self *SnapshotCreateTask) TaskFailed(ctx context.Context, snapshot *models
 }
 
 func (self *SnapshotCreateTask) TaskComplete(ctx context.Context, snapshot *models.SSnapshot, data jsonutils.JSONObject) {
+	if self.thawGuest(ctx) {
+		if err := snapshot.SetQuiesced(true); err != nil {
+			log.Errorf("snapshot %s set quiesced: %s", snapshot.Name, err)
+		}
+	}
 	snapshot.SetStatus(self.UserCred, api.SNAPSHOT_READY, "")
 	db.OpsLog.LogEvent(snapshot, db.ACT_SNAPSHOT_DONE, snapshot.GetShortDesc(ctx), self.UserCred)
 	logclient.AddActionLogWithStartable(self, snapshot, logclient.ACT_CREATE, snapshot.GetShortDesc(ctx), self.UserCred, true)
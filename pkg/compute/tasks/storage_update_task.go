@@ -31,6 +31,7 @@ import (
 func init() {
 	taskman.RegisterTask(StorageUpdateTask{})
 	taskman.RegisterTask(RbdStorageUpdateTask{})
+	taskman.RegisterTask(SharedStorageUpdateTask{})
 }
 
 type StorageUpdateTask struct {
@@ -58,16 +59,18 @@ func (self *StorageUpdateTask) OnStorageUpdateFailed(ctx context.Context, obj db
 	self.SetStageFailed(ctx, data)
 }
 
-type RbdStorageUpdateTask struct {
+// SharedStorageUpdateTask pushes the storage conf of a shared storage to
+// all attached hosts
+type SharedStorageUpdateTask struct {
 	taskman.STask
 }
 
-func (self *RbdStorageUpdateTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+func (self *SharedStorageUpdateTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
 	storage := obj.(*models.SStorage)
 	hosts := storage.GetAllAttachingHosts()
 
 	for _, host := range hosts {
-		log.Infof("Updata rbd Storage [%s] on host %s ...", storage.Name, host.Name)
+		log.Infof("Update %s storage [%s] on host %s ...", storage.StorageType, storage.Name, host.Name)
 		url := fmt.Sprintf("%s/storages/update", host.ManagerUri)
 		headers := mcclient.GetTokenHeaders(self.GetUserCred())
 		body := jsonutils.Marshal(map[string]interface{}{
@@ -76,7 +79,14 @@ func (self *RbdStorageUpdateTask) OnInit(ctx context.Context, obj db.IStandalone
 		})
 		_, _, err := httputils.JSONRequest(httputils.GetDefaultClient(), ctx, "POST", url, headers, body, false)
 		//这里尽可能的更新所有在线的hoststorage信息,仅打印warning信息
-		log.Warningf("update rbd storage info for host %s(%s) error: %v", host.Name, host.Id, err)
+		if err != nil {
+			log.Warningf("update %s storage info for host %s(%s) error: %v", storage.StorageType, host.Name, host.Id, err)
+		}
 	}
 	self.SetStageComplete(ctx, nil)
 }
+
+// RbdStorageUpdateTask is kept for the tasks created before SharedStorageUpdateTask
+type RbdStorageUpdateTask struct {
+	SharedStorageUpdateTask
+}
@@ -89,6 +89,7 @@ func (asc *SASController) Init(options options.SASControllerOptions, cronm *cron
 	cronm.AddJobAtIntervalsWithStartRun("CheckTimer", time.Duration(options.TimerInterval)*time.Second, asc.Timer, true)
 	cronm.AddJobAtIntervalsWithStartRun("CheckScale", time.Duration(options.CheckScaleInterval)*time.Second, asc.CheckScale, true)
 	cronm.AddJobAtIntervalsWithStartRun("CheckInstanceHealth", time.Duration(options.CheckHealthInterval)*time.Minute, asc.CheckInstanceHealth, true)
+	cronm.AddJobAtIntervalsWithStartRun("CheckInstanceRefresh", time.Duration(options.CheckInstanceRefreshInterval)*time.Second, asc.CheckInstanceRefresh, true)
 	asc.timerQueue = make(chan struct{}, 20)
 	asc.scalingQueue = make(chan struct{}, options.ConcurrentUpper)
 	asc.scalingGroupSet = &SLockedSet{set: sets.NewString()}
@@ -117,6 +118,9 @@ func (asc *SASController) Init(options options.SASControllerOptions, cronm *cron
 		sas[i].SetFailed("", "As the service restarts, the status becomes unknown")
 	}
 	log.Infof("check and update scalngactivities complete")
+
+	// the pending lifecycle actions can't be waited for any more
+	asc.recoverLifecycleActions(context.Background(), auth.AdminCredential())
 }
 
 func (asc *SASController) PreScale(group *models.SScalingGroup, userCred mcclient.TokenCredential) bool {
@@ -207,6 +211,11 @@ func (asc *SASController) Scale(ctx context.Context, userCred mcclient.TokenCred
 		success = true
 		return
 	}
+	// the instance refresh keeps the extra instances until the new ones are healthy
+	refresh, err := sg.ActiveInstanceRefresh()
+	if err != nil || refresh != nil {
+		return
+	}
 	total, err := sg.GuestNumber()
 	if err != nil {
 		return
@@ -319,11 +328,26 @@ func (asc *SASController) DetachInstances(ctx context.Context, userCred mcclient
 	if err != nil {
 		return nil, errors.Wrap(err, "find suitable instances failed")
 	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("no instance can be removed, the others are protected from scaling in")
+	}
+	return asc.RemoveInstances(ctx, userCred, sg, instances)
+}
+
+// RemoveInstances detaches the instances from scaling group and deletes them
+func (asc *SASController) RemoveInstances(ctx context.Context, userCred mcclient.TokenCredential,
+	sg *models.SScalingGroup, instances []models.SGuest) ([]SInstance, error) {
 	removeParams := jsonutils.NewDict()
 	removeParams.Set("scaling_group", jsonutils.NewString(sg.Id))
 	removeParams.Set("delete_server", jsonutils.JSONTrue)
 	removeParams.Set("auto", jsonutils.JSONTrue)
 	session := auth.GetSession(ctx, userCred, "", "")
+	// wait for the terminate lifecycle hooks, the instances are removed whatever the result is
+	ids := make([]string, len(instances))
+	for i := range instances {
+		ids[i] = instances[i].Id
+	}
+	asc.waitLifecycleActions(ctx, userCred, sg, compute.LIFECYCLE_TRANSITION_TERMINATE, ids)
 	failedList := make([]string, 0)
 	waitList := make([]string, 0, len(instances))
 	instanceMap := make(map[string]SInstance, len(instances))
@@ -373,12 +397,13 @@ Loop:
 				failedList = append(failedList, fmt.Sprintf("remove instance '%s' timeout", id))
 				succeedList.Delete(id)
 			}
+			break Loop
 		}
 	}
 	ticker.Stop()
 	timer.Stop()
 	log.Debugf("finish all check jobs when removing servers")
-	err = nil
+	var err error
 	if len(failedList) != 0 {
 		err = fmt.Errorf(strings.Join(failedList, "; "))
 	}
@@ -390,7 +415,9 @@ Loop:
 }
 
 func (asc *SASController) findSuitableInstance(sg *models.SScalingGroup, num int) ([]models.SGuest, error) {
-	ggSubQ := models.ScalingGroupGuestManager.Query("guest_id").Equals("scaling_group_id", sg.Id).SubQuery()
+	// instances protected from scaling in are left alone
+	ggSubQ := models.ScalingGroupGuestManager.Query("guest_id").Equals("scaling_group_id", sg.Id).
+		IsFalse("scale_in_protected").SubQuery()
 	guestQ := models.GuestManager.Query().In("id", ggSubQ)
 	switch sg.ShrinkPrinciple {
 	case compute.SHRINK_EARLIEST_CREATION_FIRST:
@@ -458,10 +485,27 @@ func (asc *SASController) CreateInstances(
 	defaultNet string,
 	num int,
 ) ([]SInstance, error) {
-	// build the create request data
-	content := gt.Content.(*jsonutils.JSONDict)
 	// no network, add a default
-	if len(gt.VpcId) == 0 {
+	if len(gt.VpcId) != 0 {
+		defaultNet = ""
+	}
+	return asc.LaunchInstances(ctx, userCred, ownerId, sg, gt.Content.(*jsonutils.JSONDict), defaultNet, num, "")
+}
+
+// LaunchInstances creates num instances from the content of guest template and joins them in scaling group,
+// the default network is added if it isn't empty. The instances are marked with instanceRefreshId.
+func (asc *SASController) LaunchInstances(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	sg *models.SScalingGroup,
+	content *jsonutils.JSONDict,
+	defaultNet string,
+	num int,
+	instanceRefreshId string,
+) ([]SInstance, error) {
+	// build the create request data
+	if len(defaultNet) != 0 {
 		net := jsonutils.NewDict()
 		net.Set("network", jsonutils.NewString(defaultNet))
 		content.Set("nets", jsonutils.NewArray(net))
@@ -499,7 +543,7 @@ func (asc *SASController) CreateInstances(
 
 	// second stage: joining scaling group
 	for _, instance := range succeedList {
-		err := models.ScalingGroupGuestManager.Attach(ctx, sg.Id, instance.ID, false)
+		err := models.ScalingGroupGuestManager.Attach(ctx, sg.Id, instance.ID, false, instanceRefreshId)
 		if err != nil {
 			log.Errorf("Attach ScalingGroup '%s' with Guest '%s' failed", sg.Id, instance.ID)
 		}
@@ -699,6 +743,12 @@ func (asc *SASController) actionAfterCreate(
 		}
 		return
 	}
+	// wait for the launch lifecycle hooks
+	results := asc.waitLifecycleActions(ctx, userCred, sg, compute.LIFECYCLE_TRANSITION_LAUNCH, []string{ret.Id})
+	if results[ret.Id] == compute.LIFECYCLE_RESULT_ABANDON {
+		rollback(fmt.Sprintf("instance '%s' was abandoned by the launch lifecycle hook", ret.Id))
+		return
+	}
 	// bind lb
 	if len(sg.BackendGroupId) != 0 {
 		params := jsonutils.NewDict()
@@ -0,0 +1,270 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+// CheckInstanceRefresh starts the next batch of the instance refreshes whose pause is over
+func (asc *SASController) CheckInstanceRefresh(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	sirs, err := models.ScalingInstanceRefreshManager.FetchDue()
+	if err != nil {
+		log.Errorf("ScalingInstanceRefreshManager.FetchDue: %s", err.Error())
+		return
+	}
+	for i := range sirs {
+		sir := &sirs[i]
+		insert := asc.scalingGroupSet.CheckAndInsert(sir.ScalingGroupId)
+		if !insert {
+			log.Infof("A scaling activity of ScalingGroup %s is in progress, so the batch of instance refresh was delayed.", sir.ScalingGroupId)
+			continue
+		}
+		asc.scalingQueue <- struct{}{}
+		go asc.RefreshInstances(ctx, userCred, sir)
+	}
+}
+
+// RefreshInstances replaces a batch of instances of the scaling group. The new instances are created first,
+// the old ones are removed after the new ones pass the health check of scaling group.
+func (asc *SASController) RefreshInstances(ctx context.Context, userCred mcclient.TokenCredential, sir *models.SScalingInstanceRefresh) {
+	defer func() {
+		asc.scalingGroupSet.Delete(sir.ScalingGroupId)
+		<-asc.scalingQueue
+		log.Debugf("Instance refresh '%s' for ScalingGroup '%s' finished a batch", sir.Id, sir.ScalingGroupId)
+	}()
+	sg := sir.GetScalingGroup()
+	if sg == nil {
+		sir.SetFailed(fmt.Sprintf("fetch ScalingGroup '%s' error", sir.ScalingGroupId))
+		return
+	}
+	// continue after the scaling group is enabled
+	if sg.Enabled.IsFalse() {
+		return
+	}
+	olds, err := sir.InstancesToReplace()
+	if err != nil {
+		log.Errorf("InstancesToReplace of instance refresh '%s': %s", sir.Id, err.Error())
+		return
+	}
+	if len(olds) == 0 {
+		err = sir.SetSucceed()
+		if err != nil {
+			log.Errorf("set result of instance refresh '%s' failed: %s", sir.Id, err.Error())
+		}
+		return
+	}
+
+	scalingActivity, err := models.ScalingActivityManager.CreateScalingActivity(ctx, sg.Id, sir.TriggerDescription(),
+		compute.SA_STATUS_EXEC)
+	if err != nil {
+		log.Errorf("create ScalingActivity for instance refresh '%s' failed: %s", sir.Id, err.Error())
+		return
+	}
+	setFail := func(actionDesc, reason string) {
+		scalingActivity.SetFailed(actionDesc, reason)
+		sir.SetFailed(reason)
+	}
+	content, instanceRefreshId := sir.BatchContent()
+	dict, ok := content.(*jsonutils.JSONDict)
+	if !ok {
+		setFail("", "invalid content of guest template")
+		return
+	}
+	nets, err := sg.NetworkIds()
+	if err != nil || len(nets) == 0 {
+		setFail("", fmt.Sprintf("fetch Networks of ScalingGroup '%s' error", sg.Id))
+		return
+	}
+	defaultNet := ""
+	if !dict.Contains("nets") {
+		defaultNet = nets[0]
+	}
+
+	// first stage: create new instances
+	num := sir.BatchNumber(sg.DesireInstanceNumber, len(olds))
+	created, err := asc.LaunchInstances(ctx, userCred, sg.GetOwnerId(), sg, dict, defaultNet, num, instanceRefreshId)
+	if len(created) == 0 {
+		setFail("", failedReason("instances create", num, num, err))
+		return
+	}
+	reasons := make([]string, 0, 2)
+	if len(created) < num {
+		reasons = append(reasons, failedReason("instances create", num-len(created), num, err))
+	}
+	actions := []string{fmt.Sprintf("Instances %s are created", instanceNames(created))}
+
+	// second stage: wait for the new instances to pass the health check
+	healthy, unhealthy := asc.waitInstancesHealthy(sg, created)
+	if len(unhealthy) > 0 {
+		reasons = append(reasons, fmt.Sprintf("Instances %s didn't pass the health check", instanceNames(unhealthy)))
+		guests, err := fetchGuests(unhealthy)
+		if err == nil {
+			_, err = asc.RemoveInstances(ctx, userCred, sg, guests)
+		}
+		if err != nil {
+			log.Errorf("remove unhealthy instances of instance refresh '%s' failed: %s", sir.Id, err.Error())
+		}
+	}
+	if len(healthy) == 0 {
+		setFail(strings.Join(actions, ", "), strings.Join(reasons, "; "))
+		return
+	}
+
+	// third stage: remove the old instances, keep the healthy instances no less than the min healthy number
+	remove := len(healthy)
+	if remove > len(olds) {
+		remove = len(olds)
+	}
+	healthyNumber, err := asc.healthyInstanceNumber(sg)
+	if err != nil {
+		setFail(strings.Join(actions, ", "), fmt.Sprintf("count healthy instances error: %s", err.Error()))
+		return
+	}
+	if limit := healthyNumber - sir.MinHealthyNumber(sg.DesireInstanceNumber); remove > limit {
+		remove = limit
+	}
+	if remove <= 0 {
+		setFail(strings.Join(actions, ", "), fmt.Sprintf(
+			"Only %d instances are healthy, removing the old instances breaks the min healthy percentage %d%%",
+			healthyNumber, sir.MinHealthyPercentage))
+		return
+	}
+	removed, err := asc.RemoveInstances(ctx, userCred, sg, olds[:remove])
+	if len(removed) == 0 {
+		setFail(strings.Join(actions, ", "), failedReason("old instances remove", remove, remove, err))
+		return
+	}
+	actions = append(actions, fmt.Sprintf("instances %s are deleted", instanceNames(removed)))
+	if len(removed) < remove {
+		reasons = append(reasons, failedReason("old instances remove", remove-len(removed), remove, err))
+	}
+
+	total, _ := sg.GuestNumber()
+	status := compute.SA_STATUS_SUCCEED
+	if len(reasons) > 0 {
+		status = compute.SA_STATUS_PART_SUCCEED
+	}
+	err = scalingActivity.SetResult(strings.Join(actions, ", "), status, strings.Join(reasons, "; "), total)
+	if err != nil {
+		log.Errorf("ScalingActivity set result failed: %s", err.Error())
+	}
+	err = sir.FinishBatch(len(removed))
+	if err != nil {
+		log.Errorf("finish batch of instance refresh '%s' failed: %s", sir.Id, err.Error())
+	}
+}
+
+// waitInstancesHealthy waits for the instances to pass the health check of scaling group, the instances that
+// are still unhealthy after HealthCheckGov are returned as unhealthy.
+func (asc *SASController) waitInstancesHealthy(sg *models.SScalingGroup, instances []SInstance) (healthy, unhealthy []SInstance) {
+	pending := make(map[string]SInstance, len(instances))
+	for _, instance := range instances {
+		pending[instance.ID] = instance
+	}
+	deadline := time.Now().Add(time.Duration(sg.HealthCheckGov) * time.Second)
+	for {
+		for id, instance := range pending {
+			ok, err := asc.isInstanceHealthy(sg, id)
+			if err != nil {
+				log.Errorf("check health of instance '%s' error: %s", id, err.Error())
+				continue
+			}
+			if ok {
+				healthy = append(healthy, instance)
+				delete(pending, id)
+			}
+		}
+		if len(pending) == 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Second)
+	}
+	for _, instance := range pending {
+		unhealthy = append(unhealthy, instance)
+	}
+	return
+}
+
+func (asc *SASController) isInstanceHealthy(sg *models.SScalingGroup, guestId string) (bool, error) {
+	q := asc.healthyInstanceQuery(sg).Equals("id", guestId)
+	count, err := q.CountWithError()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (asc *SASController) healthyInstanceNumber(sg *models.SScalingGroup) (int, error) {
+	return asc.healthyInstanceQuery(sg).CountWithError()
+}
+
+// healthyInstanceQuery queries the running instances of the scaling group, in loadbalancer health check
+// mode the instances also need to be backends reported online by the health check of loadbalancer,
+// backends not checked yet are not healthy so the refresh waits for them.
+func (asc *SASController) healthyInstanceQuery(sg *models.SScalingGroup) *sqlchemy.SQuery {
+	sggSubQ := models.ScalingGroupGuestManager.Query("guest_id").Equals("scaling_group_id", sg.Id).
+		Equals("guest_status", compute.SG_GUEST_STATUS_READY).SubQuery()
+	q := models.GuestManager.Query().In("id", sggSubQ).Equals("status", compute.VM_RUNNING)
+	if sg.HealthCheckMode == compute.HEALTH_CHECK_MODE_LOADBALANCER && len(sg.BackendGroupId) != 0 {
+		lbbSubQ := models.LoadbalancerBackendManager.Query("backend_id").Equals("backend_group_id", sg.BackendGroupId).
+			Equals("health_status", compute.LB_BACKEND_HEALTH_STATUS_ONLINE).SubQuery()
+		q = q.In("id", lbbSubQ)
+	}
+	return q
+}
+
+func fetchGuests(instances []SInstance) ([]models.SGuest, error) {
+	ids := make([]string, len(instances))
+	for i := range instances {
+		ids[i] = instances[i].ID
+	}
+	guests := make([]models.SGuest, 0, len(ids))
+	err := db.FetchModelObjects(models.GuestManager, models.GuestManager.Query().In("id", ids), &guests)
+	if err != nil {
+		return nil, err
+	}
+	return guests, nil
+}
+
+func instanceNames(instances []SInstance) string {
+	names := make([]string, len(instances))
+	for i := range instances {
+		names[i] = fmt.Sprintf("'%s'", instances[i].Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// failedReason builds the reason from the counts, err is nil when the
+// instances were abandoned by lifecycle hooks rather than failed
+func failedReason(what string, failed, total int, err error) string {
+	reason := fmt.Sprintf("%d of %d %s failed", failed, total, what)
+	if err != nil {
+		reason = fmt.Sprintf("%s: %s", reason, err.Error())
+	}
+	return reason
+}
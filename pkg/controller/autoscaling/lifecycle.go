@@ -0,0 +1,116 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscaling
+
+import (
+	"context"
+	"time"
+
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+// waitLifecycleActions notifies the lifecycle hooks of transition and waits for all the lifecycle actions of
+// the guests to be completed or timed out. The result of every guest is returned, the guests go on with
+// 'continue' if there is no hook or the lifecycle actions can't be started.
+func (asc *SASController) waitLifecycleActions(ctx context.Context, userCred mcclient.TokenCredential,
+	sg *models.SScalingGroup, transition string, guestIds []string) map[string]string {
+	results := make(map[string]string, len(guestIds))
+	for _, id := range guestIds {
+		results[id] = compute.LIFECYCLE_RESULT_CONTINUE
+	}
+	actions, err := models.ScalingLifecycleActionManager.StartLifecycleActions(ctx, userCred, sg, transition, guestIds)
+	if err != nil {
+		log.Errorf("start %s lifecycle actions of ScalingGroup '%s' failed: %s", transition, sg.Id, err.Error())
+		return results
+	}
+	if len(actions) == 0 {
+		return results
+	}
+	ids := make([]string, len(actions))
+	for i := range actions {
+		ids[i] = actions[i].Id
+	}
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		actions, err := models.ScalingLifecycleActionManager.CheckLifecycleActions(ctx, ids)
+		if err != nil {
+			log.Errorf("check lifecycle actions of ScalingGroup '%s' failed: %s", sg.Id, err.Error())
+		} else {
+			pending := false
+			for i := range actions {
+				if actions[i].Status == compute.SLA_STATUS_PENDING {
+					pending = true
+					break
+				}
+			}
+			if !pending {
+				for id, result := range models.LifecycleResults(actions) {
+					results[id] = result
+				}
+				return results
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// recoverLifecycleActions finishes the lifecycle actions nobody waits for after the service restarts with
+// the default results of their hooks, the guests go back to ready or are removed from the scaling group.
+func (asc *SASController) recoverLifecycleActions(ctx context.Context, userCred mcclient.TokenCredential) {
+	actions, err := models.ScalingLifecycleActionManager.TimeoutPendingActions(ctx)
+	if err != nil {
+		log.Errorf("unable to time out pending lifecycle actions: %s", err.Error())
+		return
+	}
+	type sGuestTransition struct {
+		scalingGroupId string
+		transition     string
+	}
+	transitions := make(map[string]sGuestTransition)
+	for i := range actions {
+		transitions[actions[i].GuestId] = sGuestTransition{actions[i].ScalingGroupId, actions[i].Transition}
+	}
+	for guestId, result := range models.LifecycleResults(actions) {
+		gt := transitions[guestId]
+		if models.LifecycleGuestKept(gt.transition, result) {
+			sggs, err := models.ScalingGroupGuestManager.Fetch(gt.scalingGroupId, guestId)
+			if err != nil || len(sggs) == 0 {
+				log.Errorf("ScalingGroupGuestManager.Fetch failed; ScalingGroup '%s', Guest '%s'", gt.scalingGroupId, guestId)
+				continue
+			}
+			sggs[0].SetGuestStatus(compute.SG_GUEST_STATUS_READY)
+			continue
+		}
+		guest := models.GuestManager.FetchGuestById(guestId)
+		if guest == nil {
+			log.Errorf("unable to fetch guest '%s' of ScalingGroup '%s'", guestId, gt.scalingGroupId)
+			continue
+		}
+		input := compute.SGPerformDetachScalingGroupInput{
+			ScalingGroup: gt.scalingGroupId,
+			DeleteServer: true,
+			Auto:         true,
+		}
+		_, err := guest.PerformDetachScalingGroup(ctx, userCred, nil, input)
+		if err != nil {
+			log.Errorf("remove guest '%s' from ScalingGroup '%s' failed: %s", guestId, gt.scalingGroupId, err.Error())
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drs
+
+import (
+	"context"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/cronman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+)
+
+type SDrsController struct {
+	options options.SDrsControllerOptions
+}
+
+var DrsController = new(SDrsController)
+
+func (drs *SDrsController) Init(options options.SDrsControllerOptions, cronm *cronman.SCronJobManager) {
+	drs.options = options
+	if options.DrsCheckInterval <= 0 {
+		log.Infof("drs controller disabled")
+		return
+	}
+	cronm.AddJobAtIntervalsWithStartRun("CheckDrsPolicies", time.Duration(options.DrsCheckInterval)*time.Minute, drs.CheckPolicies, false)
+}
+
+func (drs *SDrsController) CheckPolicies(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	policies := make([]models.SDrsPolicy, 0)
+	q := models.DrsPolicyManager.Query().IsTrue("enabled").Equals("status", api.DRS_POLICY_STATUS_READY)
+	err := db.FetchModelObjects(models.DrsPolicyManager, q, &policies)
+	if err != nil {
+		log.Errorf("fetch drs policies: %v", err)
+		return
+	}
+	for i := range policies {
+		policy := &policies[i]
+		policy.SetStatus(userCred, api.DRS_POLICY_STATUS_EVALUATING, "")
+		err := drs.Evaluate(ctx, userCred, policy)
+		if err != nil {
+			log.Errorf("evaluate drs policy %s: %v", policy.Name, err)
+		}
+		policy.SetStatus(userCred, api.DRS_POLICY_STATUS_READY, "")
+	}
+}
+
+// Evaluate computes migration plan of policy, and executes it in
+// maintenance window if policy is in auto mode
+func (drs *SDrsController) Evaluate(ctx context.Context, userCred mcclient.TokenCredential, policy *models.SDrsPolicy) error {
+	hosts, err := policy.GetHosts()
+	if err != nil {
+		return errors.Wrap(err, "GetHosts")
+	}
+	if len(hosts) < 2 {
+		return policy.SavePlan(ctx, userCred, nil)
+	}
+	hostIds := make([]string, len(hosts))
+	hostLoads := make([]*SHostLoad, len(hosts))
+	hostLoadMap := make(map[string]*SHostLoad, len(hosts))
+	for i := range hosts {
+		hostIds[i] = hosts[i].Id
+		hostLoads[i] = &SHostLoad{
+			Id:          hosts[i].Id,
+			Name:        hosts[i].Name,
+			CpuCapacity: float64(hosts[i].GetVirtualCPUCount()),
+			MemCapacity: float64(hosts[i].GetVirtualMemorySize()),
+		}
+		hostLoadMap[hosts[i].Id] = hostLoads[i]
+	}
+
+	guests := make([]models.SGuest, 0)
+	q := models.GuestManager.Query().In("host_id", hostIds)
+	err = db.FetchModelObjects(models.GuestManager, q, &guests)
+	if err != nil {
+		return errors.Wrap(err, "fetch guests")
+	}
+	inflight := 0
+	guestMap := make(map[string]*models.SGuest)
+	guestLoads := make([]*SGuestLoad, 0)
+	for i := range guests {
+		guest := &guests[i]
+		switch guest.Status {
+		case api.VM_START_MIGRATE, api.VM_MIGRATING:
+			inflight++
+		case api.VM_RUNNING:
+		default:
+			continue
+		}
+		host := hostLoadMap[guest.HostId]
+		host.CpuUsed += float64(guest.VcpuCount)
+		host.MemUsed += float64(guest.VmemSize)
+		if guest.Status != api.VM_RUNNING || guest.Hypervisor != api.HYPERVISOR_KVM || len(guest.BackupHostId) > 0 {
+			continue
+		}
+		if err := guest.GetDriver().CheckLiveMigrate(guest, userCred, api.GuestLiveMigrateInput{}); err != nil {
+			log.Debugf("drs policy %s skip guest %s: %v", policy.Name, guest.Name, err)
+			continue
+		}
+		guestMap[guest.Id] = guest
+		guestLoads = append(guestLoads, &SGuestLoad{
+			Id:     guest.Id,
+			Name:   guest.Name,
+			HostId: guest.HostId,
+			Ncpu:   float64(guest.VcpuCount),
+			MemMb:  float64(guest.VmemSize),
+		})
+	}
+
+	session := auth.GetAdminSession(ctx, options.Options.Region, "")
+	finder := func(g *SGuestLoad) ([]SMigrateTarget, error) {
+		return drs.findTargets(session, guestMap[g.Id], len(hosts))
+	}
+	plan, err := Plan(hostLoads, guestLoads, float64(policy.Threshold), policy.MaxMigrations, finder)
+	if err != nil {
+		return errors.Wrap(err, "Plan")
+	}
+	if len(plan.Migrations) == 0 {
+		plan = nil
+	}
+	err = policy.SavePlan(ctx, userCred, plan)
+	if err != nil {
+		return errors.Wrap(err, "SavePlan")
+	}
+	if plan == nil || policy.Mode != api.DRS_MODE_AUTO {
+		return nil
+	}
+	if !policy.InMaintenanceWindow(time.Now()) {
+		log.Infof("drs policy %s out of maintenance window, plan is not executed", policy.Name)
+		return nil
+	}
+	limit := drs.options.DrsMaxConcurrentMigrations - inflight
+	if limit <= 0 {
+		log.Infof("drs policy %s has %d migrations in progress, plan is not executed", policy.Name, inflight)
+		return nil
+	}
+	started, err := policy.ApplyPlan(ctx, userCred, plan, limit)
+	if err != nil {
+		return errors.Wrap(err, "ApplyPlan")
+	}
+	log.Infof("drs policy %s started %d migrations", policy.Name, started)
+	return nil
+}
+
+// findTargets asks scheduler which hosts the guest could be live migrated
+// to, so that schedtags, instance groups and other constraints are respected
+func (drs *SDrsController) findTargets(session *mcclient.ClientSession, guest *models.SGuest, limit int) ([]SMigrateTarget, error) {
+	input := guest.ToSchedDesc()
+	input.LiveMigrate = true
+	if guest.GetMetadata("__cpu_mode", nil) != api.CPU_MODE_QEMU {
+		host := guest.GetHost()
+		input.CpuDesc = host.CpuDesc
+		input.CpuMicrocode = host.CpuMicrocode
+		input.CpuMode = api.CPU_MODE_HOST
+	} else {
+		input.CpuMode = api.CPU_MODE_QEMU
+	}
+	input.ReuseNetwork = true
+	input.SuggestionLimit = int64(limit)
+	ret, err := modules.SchedManager.Test(session, input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "scheduler test for guest %s", guest.Name)
+	}
+	items, err := ret.GetArray("data")
+	if err != nil {
+		return nil, nil
+	}
+	targets := make([]SMigrateTarget, 0, len(items))
+	for _, item := range items {
+		id, _ := item.GetString("id")
+		capacity, _ := item.Int("capacity")
+		if len(id) > 0 && id != guest.HostId && capacity > 0 {
+			targets = append(targets, SMigrateTarget{HostId: id, Capacity: capacity})
+		}
+	}
+	return targets, nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drs
+
+import (
+	"math"
+	"sort"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+// SHostLoad is the resource commitment of a host taken into account by drs,
+// load of a host is the higher one of its cpu and memory commit rate
+type SHostLoad struct {
+	Id   string
+	Name string
+
+	CpuCapacity float64
+	MemCapacity float64
+	CpuUsed     float64
+	MemUsed     float64
+}
+
+func (h *SHostLoad) Load() float64 {
+	var cpu, mem float64
+	if h.CpuCapacity > 0 {
+		cpu = h.CpuUsed / h.CpuCapacity
+	}
+	if h.MemCapacity > 0 {
+		mem = h.MemUsed / h.MemCapacity
+	}
+	return math.Max(cpu, mem)
+}
+
+type SGuestLoad struct {
+	Id     string
+	Name   string
+	HostId string
+	Ncpu   float64
+	MemMb  float64
+}
+
+// SMigrateTarget is a host the guest could be live migrated to, Capacity
+// is how many guests of the same spec the host could take at the moment
+type SMigrateTarget struct {
+	HostId   string
+	Capacity int64
+}
+
+// TargetFinder returns the hosts the guest could be live migrated to
+type TargetFinder func(guest *SGuestLoad) ([]SMigrateTarget, error)
+
+// Deviation returns standard deviation of host loads in percent
+func Deviation(hosts []*SHostLoad) float64 {
+	if len(hosts) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, h := range hosts {
+		sum += h.Load()
+	}
+	avg := sum / float64(len(hosts))
+	var variance float64
+	for _, h := range hosts {
+		d := h.Load() - avg
+		variance += d * d
+	}
+	return math.Sqrt(variance/float64(len(hosts))) * 100
+}
+
+func moveGuest(guest *SGuestLoad, src, dest *SHostLoad) {
+	src.CpuUsed -= guest.Ncpu
+	src.MemUsed -= guest.MemMb
+	dest.CpuUsed += guest.Ncpu
+	dest.MemUsed += guest.MemMb
+}
+
+// canTake tells whether dest still has room for guest after the moves
+// planned into it, the targets are found before planning so that both the
+// capacity reported for the target and the projected usage of dest count
+func canTake(dest *SHostLoad, target SMigrateTarget, plannedIn int, guest *SGuestLoad) bool {
+	if target.Capacity <= int64(plannedIn) {
+		return false
+	}
+	if dest.CpuCapacity > 0 && dest.CpuUsed+guest.Ncpu > dest.CpuCapacity {
+		return false
+	}
+	if dest.MemCapacity > 0 && dest.MemUsed+guest.MemMb > dest.MemCapacity {
+		return false
+	}
+	return true
+}
+
+// Plan greedily moves a guest off the most loaded host each round, to the
+// target which reduces the deviation most, until the deviation falls within
+// threshold, no move helps or maxMigrations is reached. A target takes no
+// more guests than it has room for. Hosts and guests are updated in place
+// to reflect the planned state.
+func Plan(hosts []*SHostLoad, guests []*SGuestLoad, threshold float64, maxMigrations int, findTargets TargetFinder) (*api.DrsPlan, error) {
+	hostMap := make(map[string]*SHostLoad, len(hosts))
+	for _, h := range hosts {
+		hostMap[h.Id] = h
+	}
+	plan := &api.DrsPlan{
+		Deviation: Deviation(hosts),
+	}
+	plan.ExpectedDeviation = plan.Deviation
+
+	// smaller guests are cheaper to migrate
+	sort.SliceStable(guests, func(i, j int) bool {
+		return guests[i].MemMb < guests[j].MemMb
+	})
+	moved := make(map[string]bool)
+	targetsCache := make(map[string][]SMigrateTarget)
+	// count of guests planned to move into each host
+	plannedIn := make(map[string]int)
+	for len(plan.Migrations) < maxMigrations && plan.ExpectedDeviation > threshold {
+		var src *SHostLoad
+		for _, h := range hosts {
+			if src == nil || h.Load() > src.Load() {
+				src = h
+			}
+		}
+		var (
+			bestGuest *SGuestLoad
+			bestDest  *SHostLoad
+			bestDev   = plan.ExpectedDeviation
+		)
+		for _, g := range guests {
+			if g.HostId != src.Id || moved[g.Id] {
+				continue
+			}
+			targets, ok := targetsCache[g.Id]
+			if !ok {
+				var err error
+				targets, err = findTargets(g)
+				if err != nil {
+					return nil, err
+				}
+				targetsCache[g.Id] = targets
+			}
+			for _, target := range targets {
+				dest, ok := hostMap[target.HostId]
+				if !ok || dest == src || !canTake(dest, target, plannedIn[dest.Id], g) {
+					continue
+				}
+				moveGuest(g, src, dest)
+				dev := Deviation(hosts)
+				moveGuest(g, dest, src)
+				if dev < bestDev {
+					bestGuest, bestDest, bestDev = g, dest, dev
+				}
+			}
+		}
+		if bestGuest == nil {
+			break
+		}
+		moveGuest(bestGuest, src, bestDest)
+		bestGuest.HostId = bestDest.Id
+		moved[bestGuest.Id] = true
+		plannedIn[bestDest.Id]++
+		plan.ExpectedDeviation = bestDev
+		plan.Migrations = append(plan.Migrations, api.DrsMigration{
+			GuestId:    bestGuest.Id,
+			Guest:      bestGuest.Name,
+			SrcHostId:  src.Id,
+			SrcHost:    src.Name,
+			DestHostId: bestDest.Id,
+			DestHost:   bestDest.Name,
+		})
+	}
+	return plan, nil
+}
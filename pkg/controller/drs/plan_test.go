@@ -0,0 +1,134 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drs
+
+import (
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	newHosts := func() []*SHostLoad {
+		return []*SHostLoad{
+			{Id: "h1", Name: "host1", CpuCapacity: 32, MemCapacity: 65536, CpuUsed: 24, MemUsed: 49152},
+			{Id: "h2", Name: "host2", CpuCapacity: 32, MemCapacity: 65536, CpuUsed: 4, MemUsed: 8192},
+			{Id: "h3", Name: "host3", CpuCapacity: 32, MemCapacity: 65536, CpuUsed: 8, MemUsed: 16384},
+		}
+	}
+	newGuests := func() []*SGuestLoad {
+		return []*SGuestLoad{
+			{Id: "g1", HostId: "h1", Ncpu: 8, MemMb: 16384},
+			{Id: "g2", HostId: "h1", Ncpu: 8, MemMb: 16384},
+			{Id: "g3", HostId: "h1", Ncpu: 8, MemMb: 16384},
+			{Id: "g4", HostId: "h2", Ncpu: 4, MemMb: 8192},
+		}
+	}
+	targets := func(capacity int64, ids ...string) []SMigrateTarget {
+		ret := make([]SMigrateTarget, len(ids))
+		for i := range ids {
+			ret[i] = SMigrateTarget{HostId: ids[i], Capacity: capacity}
+		}
+		return ret
+	}
+	anyHost := func(g *SGuestLoad) ([]SMigrateTarget, error) {
+		return targets(10, "h1", "h2", "h3"), nil
+	}
+
+	t.Run("rebalance", func(t *testing.T) {
+		hosts := newHosts()
+		plan, err := Plan(hosts, newGuests(), 5, 2, anyHost)
+		if err != nil {
+			t.Fatalf("plan: %v", err)
+		}
+		if len(plan.Migrations) != 1 {
+			t.Fatalf("want 1 migration, got %#v", plan.Migrations)
+		}
+		m := plan.Migrations[0]
+		if m.SrcHostId != "h1" || m.DestHostId != "h2" {
+			t.Errorf("want migrate from h1 to h2, got %#v", m)
+		}
+		if plan.ExpectedDeviation >= plan.Deviation {
+			t.Errorf("deviation not reduced: %f -> %f", plan.Deviation, plan.ExpectedDeviation)
+		}
+		if hosts[0].CpuUsed != 16 || hosts[1].CpuUsed != 12 {
+			t.Errorf("hosts not updated: %#v %#v", hosts[0], hosts[1])
+		}
+	})
+
+	t.Run("within threshold", func(t *testing.T) {
+		plan, err := Plan(newHosts(), newGuests(), 50, 2, anyHost)
+		if err != nil {
+			t.Fatalf("plan: %v", err)
+		}
+		if len(plan.Migrations) != 0 {
+			t.Errorf("want no migration, got %#v", plan.Migrations)
+		}
+	})
+
+	t.Run("constrained", func(t *testing.T) {
+		onlyH3 := func(g *SGuestLoad) ([]SMigrateTarget, error) {
+			return targets(10, "h3"), nil
+		}
+		plan, err := Plan(newHosts(), newGuests(), 0, 1, onlyH3)
+		if err != nil {
+			t.Fatalf("plan: %v", err)
+		}
+		if len(plan.Migrations) != 1 || plan.Migrations[0].DestHostId != "h3" {
+			t.Errorf("want migrate to h3, got %#v", plan.Migrations)
+		}
+	})
+
+	t.Run("no target", func(t *testing.T) {
+		none := func(g *SGuestLoad) ([]SMigrateTarget, error) {
+			return nil, nil
+		}
+		plan, err := Plan(newHosts(), newGuests(), 0, 2, none)
+		if err != nil {
+			t.Fatalf("plan: %v", err)
+		}
+		if len(plan.Migrations) != 0 {
+			t.Errorf("want no migration, got %#v", plan.Migrations)
+		}
+	})
+
+	t.Run("target capacity", func(t *testing.T) {
+		// h2 takes a single guest of the spec, though it looks best for both
+		onlyH2 := func(g *SGuestLoad) ([]SMigrateTarget, error) {
+			return targets(1, "h2"), nil
+		}
+		plan, err := Plan(newHosts(), newGuests(), 0, 2, onlyH2)
+		if err != nil {
+			t.Fatalf("plan: %v", err)
+		}
+		if len(plan.Migrations) != 1 {
+			t.Errorf("want 1 migration to h2, got %#v", plan.Migrations)
+		}
+	})
+
+	t.Run("projected usage", func(t *testing.T) {
+		hosts := newHosts()
+		// h2 has room for a single 8 cpus guest
+		hosts[1].CpuCapacity = 16
+		onlyH2 := func(g *SGuestLoad) ([]SMigrateTarget, error) {
+			return targets(10, "h2"), nil
+		}
+		plan, err := Plan(hosts, newGuests(), 0, 2, onlyH2)
+		if err != nil {
+			t.Fatalf("plan: %v", err)
+		}
+		if len(plan.Migrations) != 1 || hosts[1].CpuUsed > hosts[1].CpuCapacity {
+			t.Errorf("want 1 migration within h2 capacity, got %#v %#v", plan.Migrations, hosts[1])
+		}
+	})
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/coredns/coredns/plugin"
@@ -87,12 +88,27 @@ type SRegionDNS struct {
 	Region        string
 	K8sSkip       bool
 
+	// TransferTo lists secondaries allowed to transfer primary zone, as
+	// ip, ip:port, cidr or "*".  Single addresses also receive NOTIFY
+	TransferTo          []string
+	ZoneRefreshInterval int
+	// ZoneSerialFile keeps the last issued zone serial across restarts
+	ZoneSerialFile string
+
 	K8sManager            *k8s.SKubeClusterManager
 	primaryZoneLabelCount int
+
+	zone            sZone
+	zoneChecksum    string
+	zoneRefreshLock sync.Mutex
+	stopCh          chan struct{}
 }
 
 func New() *SRegionDNS {
-	r := &SRegionDNS{}
+	r := &SRegionDNS{
+		ZoneSerialFile: defaultZoneSerialFile,
+		stopCh:         make(chan struct{}),
+	}
 	return r
 }
 
@@ -160,6 +176,8 @@ func (r *SRegionDNS) ServeDNS(ctx context.Context, w dns.ResponseWriter, rmsg *d
 		records, extra, err = plugin.SRV(r, zone, state, opt)
 	case dns.TypeSOA:
 		records, err = plugin.SOA(r, zone, state, opt)
+	case dns.TypeAXFR, dns.TypeIXFR:
+		return r.Transfer(ctx, state)
 	case dns.TypeNS:
 		if state.Name() == zone {
 			records, extra, err = plugin.NS(r, zone, state, opt)
@@ -273,6 +291,9 @@ func (r *SRegionDNS) getGuestIpWithName(req *recordRequest) []string {
 	projectId := req.ProjectId()
 	wantOnlyExit := false
 	ips = models.GuestManager.GetIpInProjectWithName(projectId, name, wantOnlyExit)
+	if req.Type() == "AAAA" {
+		ips = append(ips, models.GuestManager.GetIp6InProjectWithName(projectId, name)...)
+	}
 	return ips
 }
 
@@ -0,0 +1,108 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+
+	ylog "yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+)
+
+const (
+	defaultZoneRefreshInterval = 30
+	notifyRetries              = 3
+)
+
+// notifyAddrs returns transfer_to entries that denote a single secondary
+func (r *SRegionDNS) notifyAddrs() []string {
+	addrs := []string{}
+	for _, to := range r.TransferTo {
+		if to == "*" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(to); err == nil {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(to); err != nil {
+			to = net.JoinHostPort(to, "53")
+		}
+		addrs = append(addrs, to)
+	}
+	return addrs
+}
+
+// startZoneWatcher periodically checks the zone serial and sends NOTIFY to
+// secondaries when records changed
+func (r *SRegionDNS) startZoneWatcher() {
+	interval := time.Duration(r.ZoneRefreshInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultZoneRefreshInterval * time.Second
+	}
+	if _, err := r.refreshZone(); err != nil {
+		ylog.Errorf("refresh zone %s: %v", r.PrimaryZone, err)
+	}
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				changed, err := r.refreshZone()
+				if err != nil {
+					ylog.Errorf("refresh zone %s: %v", r.PrimaryZone, err)
+					continue
+				}
+				if changed {
+					r.notify()
+				}
+			case <-r.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (r *SRegionDNS) notify() {
+	m := new(dns.Msg)
+	m.SetNotify(r.PrimaryZone)
+	c := new(dns.Client)
+	for _, addr := range r.notifyAddrs() {
+		if err := notifyAddr(c, m, addr); err != nil {
+			ylog.Errorf("notify zone %s to %s: %v", r.PrimaryZone, addr, err)
+		} else {
+			ylog.Infof("Sent notify for zone %s serial %d to %s", r.PrimaryZone, r.zone.getSerial(), addr)
+		}
+	}
+}
+
+func notifyAddr(c *dns.Client, m *dns.Msg, addr string) error {
+	var err error
+	for i := 0; i < notifyRetries; i++ {
+		var ret *dns.Msg
+		ret, _, err = c.Exchange(m, addr)
+		if err != nil {
+			continue
+		}
+		if ret.Rcode == dns.RcodeSuccess {
+			return nil
+		}
+		err = errors.Errorf("rcode %s", dns.RcodeToString[ret.Rcode])
+	}
+	return err
+}
@@ -16,6 +16,7 @@ package dns
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
@@ -59,6 +60,17 @@ func setup(c *caddy.Controller) error {
 		go rDNS.initK8s()
 	}
 
+	if len(rDNS.TransferTo) > 0 {
+		c.OnStartup(func() error {
+			rDNS.startZoneWatcher()
+			return nil
+		})
+		c.OnShutdown(func() error {
+			close(rDNS.stopCh)
+			return nil
+		})
+	}
+
 	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
 		rDNS.Next = next
 		return rDNS
@@ -132,6 +144,26 @@ func parseConfig(c *caddy.Controller) (*SRegionDNS, error) {
 					rDNS.Upstream = u
 				case "k8s_skip":
 					rDNS.K8sSkip = true
+				case "transfer_to":
+					args := c.RemainingArgs()
+					if len(args) == 0 {
+						return nil, c.ArgErr()
+					}
+					rDNS.TransferTo = append(rDNS.TransferTo, args...)
+				case "zone_refresh_interval":
+					if !c.NextArg() {
+						return nil, c.ArgErr()
+					}
+					interval, err := strconv.Atoi(c.Val())
+					if err != nil || interval <= 0 {
+						return nil, c.Errf("invalid zone_refresh_interval %q", c.Val())
+					}
+					rDNS.ZoneRefreshInterval = interval
+				case "zone_serial_file":
+					if !c.NextArg() {
+						return nil, c.ArgErr()
+					}
+					rDNS.ZoneSerialFile = c.Val()
 				default:
 					if c.Val() != "}" {
 						return nil, c.Errf("unknown property %q", c.Val())
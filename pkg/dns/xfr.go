@@ -16,15 +16,32 @@ package dns
 
 import (
 	"context"
+	"net"
+	"sync"
 	"time"
 
+	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/request"
 	"github.com/miekg/dns"
+
+	ylog "yunion.io/x/log"
 )
 
+// Start a new envelope after message reaches this size in bytes
+const transferLength = 32 * 1024
+
 // Serial implements the Transferer interface
 func (r *SRegionDNS) Serial(state request.Request) uint32 {
-	return uint32(time.Now().Unix())
+	if serial := r.zone.getSerial(); serial > 0 {
+		return serial
+	}
+	// zone watcher not running, derive it from database directly
+	serial, err := fetchZoneSerial()
+	if err != nil {
+		ylog.Errorf("fetch zone serial: %v", err)
+		return uint32(time.Now().Unix())
+	}
+	return serial
 }
 
 // MinTTL implements the Transferer interface
@@ -32,7 +49,189 @@ func (r *SRegionDNS) MinTTL(state request.Request) uint32 {
 	return 30
 }
 
+// refreshZone reloads zone records and issues a new serial when they
+// changed.  Records are always compared by content as the timestamps have
+// only second resolution.  It returns true if a new zone version was recorded
+func (r *SRegionDNS) refreshZone() (bool, error) {
+	r.zoneRefreshLock.Lock()
+	defer r.zoneRefreshLock.Unlock()
+
+	records, err := r.fetchZoneRecords()
+	if err != nil {
+		return false, err
+	}
+	checksum := zoneChecksum(records)
+
+	var prev *sZoneSerialState
+	if cur := r.zone.current(); cur != nil {
+		prev = &sZoneSerialState{Serial: cur.serial, Checksum: r.zoneChecksum}
+	} else if len(r.ZoneSerialFile) > 0 {
+		// serial issued before restart
+		prev, err = loadZoneSerialState(r.ZoneSerialFile)
+		if err != nil {
+			ylog.Errorf("load zone serial from %s: %v", r.ZoneSerialFile, err)
+		}
+	}
+	if prev != nil && prev.Checksum == checksum {
+		if r.zone.current() != nil {
+			return false, nil
+		}
+		r.zoneChecksum = checksum
+		return r.zone.update(prev.Serial, records), nil
+	}
+
+	dbSerial, err := fetchZoneSerial()
+	if err != nil {
+		ylog.Warningf("fetch zone serial: %v", err)
+	}
+	serial := nextZoneSerial(prev, dbSerial)
+	if len(r.ZoneSerialFile) > 0 {
+		// persist before answering with it so that the serial never
+		// goes backwards after restart
+		err := saveZoneSerialState(r.ZoneSerialFile, sZoneSerialState{Serial: serial, Checksum: checksum})
+		if err != nil {
+			ylog.Errorf("save zone serial to %s: %v", r.ZoneSerialFile, err)
+		}
+	}
+	r.zoneChecksum = checksum
+	return r.zone.update(serial, records), nil
+}
+
+// transferAllowed checks source address of state against transfer_to acl
+func (r *SRegionDNS) transferAllowed(state request.Request) bool {
+	ip := net.ParseIP(state.IP())
+	if ip == nil {
+		return false
+	}
+	for _, to := range r.TransferTo {
+		if to == "*" {
+			return true
+		}
+		if _, ipnet, err := net.ParseCIDR(to); err == nil {
+			if ipnet.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		host := to
+		if h, _, err := net.SplitHostPort(to); err == nil {
+			host = h
+		}
+		if addr := net.ParseIP(host); addr != nil && addr.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *SRegionDNS) zoneSOA(state request.Request, serial uint32) *dns.SOA {
+	rrs, _ := plugin.SOA(r, r.PrimaryZone, state, plugin.Options{})
+	soa := rrs[0].(*dns.SOA)
+	soa.Serial = serial
+	return soa
+}
+
+// ixfrClientSerial returns the serial carried in authority section of an
+// IXFR request
+func ixfrClientSerial(req *dns.Msg) (uint32, bool) {
+	for _, rr := range req.Ns {
+		if soa, ok := rr.(*dns.SOA); ok {
+			return soa.Serial, true
+		}
+	}
+	return 0, false
+}
+
+// transferRecords builds the answer of a zone transfer request for zone
+// version cur
+func (r *SRegionDNS) transferRecords(state request.Request, cur *sZoneVersion) []dns.RR {
+	soa := r.zoneSOA(state, cur.serial)
+	var records []dns.RR
+	if state.QType() == dns.TypeIXFR {
+		if clientSerial, ok := ixfrClientSerial(state.Req); ok {
+			if !serialLess(clientSerial, cur.serial) {
+				// client is up to date
+				records = []dns.RR{soa}
+			} else if old := r.zone.version(clientSerial); old != nil {
+				deleted, added := diffRecords(old.records, cur.records)
+				records = append(records, soa, r.zoneSOA(state, old.serial))
+				records = append(records, deleted...)
+				records = append(records, soa)
+				records = append(records, added...)
+				records = append(records, soa)
+			}
+		}
+	}
+	if records == nil {
+		// AXFR, or IXFR falling back to full transfer as allowed by rfc1995
+		records = append(records, soa)
+		records = append(records, cur.records...)
+		records = append(records, soa)
+	}
+	if state.Proto() != "tcp" && len(records) > 1 {
+		// rfc1995 section 2: an IXFR answer not fitting in udp is replaced
+		// by the current SOA so that the secondary retries over tcp
+		records = []dns.RR{soa}
+	}
+	return records
+}
+
 // Transferer implements the Transferer interface
 func (r *SRegionDNS) Transfer(ctx context.Context, state request.Request) (int, error) {
-	return dns.RcodeServerFailure, nil
+	if !r.transferAllowed(state) {
+		ylog.Warningf("Refused zone transfer of %s to %s", state.Name(), state.IP())
+		return dns.RcodeRefused, nil
+	}
+	if state.Name() != r.PrimaryZone {
+		return dns.RcodeNotAuth, nil
+	}
+	if state.QType() == dns.TypeAXFR && state.Proto() != "tcp" {
+		// rfc5936 allows AXFR over tcp only
+		return dns.RcodeRefused, nil
+	}
+	if changed, err := r.refreshZone(); err != nil {
+		ylog.Errorf("refresh zone %s: %v", r.PrimaryZone, err)
+	} else if changed {
+		// the zone watcher won't see this change again
+		go r.notify()
+	}
+	cur := r.zone.current()
+	if cur == nil {
+		return dns.RcodeServerFailure, nil
+	}
+
+	records := r.transferRecords(state, cur)
+
+	ch := make(chan *dns.Envelope)
+	tr := new(dns.Transfer)
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := tr.Out(state.W, state.Req, ch); err != nil {
+			ylog.Errorf("Outgoing transfer of zone %s to %s: %v", r.PrimaryZone, state.IP(), err)
+			// unblock the remaining envelopes
+			for range ch {
+			}
+		}
+	}()
+
+	ylog.Infof("Outgoing transfer of %d records of zone %s serial %d to %s started", len(records), r.PrimaryZone, cur.serial, state.IP())
+	j, l := 0, 0
+	for i, rr := range records {
+		l += dns.Len(rr)
+		if l > transferLength {
+			ch <- &dns.Envelope{RR: records[j:i]}
+			l = dns.Len(rr)
+			j = i
+		}
+	}
+	if j < len(records) {
+		ch <- &dns.Envelope{RR: records[j:]}
+	}
+	close(ch)
+	wg.Wait()
+
+	state.W.Hijack()
+	return dns.RcodeSuccess, nil
 }
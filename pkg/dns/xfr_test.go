@@ -0,0 +1,93 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"testing"
+
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
+	"github.com/miekg/dns"
+)
+
+func TestTransferAllowed(t *testing.T) {
+	// test.ResponseWriter always reports 10.240.0.1 as client address
+	cases := []struct {
+		name       string
+		transferTo []string
+		want       bool
+	}{
+		{"empty", nil, false},
+		{"any", []string{"*"}, true},
+		{"ip", []string{"10.240.0.1"}, true},
+		{"ip with port", []string{"10.240.0.1:53"}, true},
+		{"other ip", []string{"10.240.0.2", "10.240.0.2:53"}, false},
+		{"cidr", []string{"10.240.0.0/24"}, true},
+		{"other cidr", []string{"10.241.0.0/16"}, false},
+		{"second entry", []string{"192.168.0.1", "10.0.0.0/8"}, true},
+		{"invalid", []string{"secondary.example.com"}, false},
+	}
+	for _, c := range cases {
+		r := &SRegionDNS{TransferTo: c.transferTo}
+		req := new(dns.Msg)
+		req.SetAxfr("example.com.")
+		state := request.Request{W: &test.ResponseWriter{TCP: true}, Req: req}
+		if got := r.transferAllowed(state); got != c.want {
+			t.Errorf("%s: want %v got %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestTransferRecords(t *testing.T) {
+	a1, _ := dns.NewRR("a.example.com. 30 IN A 10.0.0.1")
+	a2, _ := dns.NewRR("b.example.com. 30 IN A 10.0.0.2")
+	r := &SRegionDNS{PrimaryZone: "example.com."}
+	r.zone.update(1, []dns.RR{a1})
+	r.zone.update(2, []dns.RR{a1, a2})
+	cur := r.zone.current()
+
+	ixfr := func(serial uint32) *dns.Msg {
+		req := new(dns.Msg)
+		req.SetIxfr("example.com.", serial, "ns.dns.example.com.", "hostmaster.example.com.")
+		return req
+	}
+	axfr := new(dns.Msg)
+	axfr.SetAxfr("example.com.")
+	cases := []struct {
+		name string
+		req  *dns.Msg
+		tcp  bool
+		want int
+	}{
+		{"axfr", axfr, true, 4},
+		{"ixfr up to date", ixfr(2), true, 1},
+		{"ixfr incremental", ixfr(1), true, 5},
+		{"ixfr unknown serial falls back to full zone", ixfr(0), true, 4},
+		{"ixfr over udp up to date", ixfr(2), false, 1},
+		{"ixfr over udp incremental", ixfr(1), false, 1},
+		{"ixfr over udp unknown serial", ixfr(0), false, 1},
+	}
+	for _, c := range cases {
+		state := request.Request{W: &test.ResponseWriter{TCP: c.tcp}, Req: c.req}
+		records := r.transferRecords(state, cur)
+		if len(records) != c.want {
+			t.Errorf("%s: want %d records got %d", c.name, c.want, len(records))
+			continue
+		}
+		if soa, ok := records[0].(*dns.SOA); !ok || soa.Serial != 2 {
+			t.Errorf("%s: want leading soa of serial 2 got %s", c.name, records[0])
+		}
+	}
+}
@@ -0,0 +1,438 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	ylog "yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/compute/models"
+)
+
+const (
+	// maxZoneHistory is the number of zone versions kept in memory to
+	// answer IXFR requests incrementally
+	maxZoneHistory = 16
+
+	defaultZoneSerialFile = "/opt/cloud/workspace/data/region-dns/zone_serial"
+)
+
+// sZoneVersion is a snapshot of the primary zone at a given serial
+type sZoneVersion struct {
+	serial  uint32
+	records []dns.RR
+}
+
+type sZone struct {
+	lock    sync.Mutex
+	serial  uint32
+	history []*sZoneVersion
+}
+
+// serialLess compares zone serials with the sequence space arithmetic of
+// rfc1982, so that the order holds after the serial wraps
+func serialLess(a, b uint32) bool {
+	const half = 1 << 31
+	return (a < b && b-a < half) || (a > b && a-b > half)
+}
+
+func (z *sZone) getSerial() uint32 {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	return z.serial
+}
+
+// update records serial as the current zone version.  It returns false when
+// serial is not newer than the known one
+func (z *sZone) update(serial uint32, records []dns.RR) bool {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	if !serialLess(z.serial, serial) && len(z.history) > 0 {
+		return false
+	}
+	z.serial = serial
+	z.history = append(z.history, &sZoneVersion{serial: serial, records: records})
+	if len(z.history) > maxZoneHistory {
+		z.history = z.history[len(z.history)-maxZoneHistory:]
+	}
+	return true
+}
+
+func (z *sZone) current() *sZoneVersion {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	if len(z.history) == 0 {
+		return nil
+	}
+	return z.history[len(z.history)-1]
+}
+
+func (z *sZone) version(serial uint32) *sZoneVersion {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	for _, v := range z.history {
+		if v.serial == serial {
+			return v
+		}
+	}
+	return nil
+}
+
+// fetchZoneSerial derives the zone serial from the latest modification time
+// of tables contributing records to the zone.  Deleted rows are taken into
+// account so that removals also bump the serial.
+func fetchZoneSerial() (uint32, error) {
+	var serial uint32
+	for _, man := range []db.IModelManager{
+		models.GuestManager,
+		models.GuestnetworkManager,
+		models.HostManager,
+		models.DnsRecordManager,
+	} {
+		t := man.TableSpec().Instance()
+		q := t.Query(sqlchemy.MAX("last_update", t.Field("updated_at")))
+		var lastUpdate time.Time
+		err := q.Row().Scan(&lastUpdate)
+		if err != nil {
+			// empty table yields NULL
+			continue
+		}
+		if s := uint32(lastUpdate.Unix()); s > serial {
+			serial = s
+		}
+	}
+	if serial == 0 {
+		return 0, errors.Error("no zone serial available")
+	}
+	return serial, nil
+}
+
+// sZoneSerialState is the last issued serial and the checksum of records
+// it was issued for
+type sZoneSerialState struct {
+	Serial   uint32
+	Checksum string
+}
+
+// zoneChecksum digests records sorted as done by uniqRecords
+func zoneChecksum(records []dns.RR) string {
+	h := sha256.New()
+	for _, rr := range records {
+		fmt.Fprintln(h, rr.String())
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// nextZoneSerial returns the serial for changed records.  It follows the
+// modification time of database when possible and always advances prev
+func nextZoneSerial(prev *sZoneSerialState, dbSerial uint32) uint32 {
+	serial := dbSerial
+	if prev != nil && !serialLess(prev.Serial, serial) {
+		serial = prev.Serial + 1
+	}
+	if serial == 0 {
+		serial = 1
+	}
+	return serial
+}
+
+// loadZoneSerialState returns nil if the serial was never saved
+func loadZoneSerialState(path string) (*sZoneSerialState, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "ReadFile")
+	}
+	state := &sZoneSerialState{}
+	_, err = fmt.Sscanf(string(content), "%d %s", &state.Serial, &state.Checksum)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid zone serial %q", string(content))
+	}
+	return state, nil
+}
+
+func saveZoneSerialState(path string, state sZoneSerialState) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return errors.Wrap(err, "MkdirAll")
+	}
+	tmp := path + ".tmp"
+	err = ioutil.WriteFile(tmp, []byte(fmt.Sprintf("%d %s\n", state.Serial, state.Checksum)), 0644)
+	if err != nil {
+		return errors.Wrap(err, "WriteFile")
+	}
+	return os.Rename(tmp, path)
+}
+
+// fetchZoneRecords collects all records in the primary zone, excluding SOA
+func (r *SRegionDNS) fetchZoneRecords() ([]dns.RR, error) {
+	records := []dns.RR{}
+	hostRecs, err := r.fetchHostRecords()
+	if err != nil {
+		return nil, errors.Wrap(err, "fetchHostRecords")
+	}
+	records = append(records, hostRecs...)
+	guestRecs, err := r.fetchGuestRecords()
+	if err != nil {
+		return nil, errors.Wrap(err, "fetchGuestRecords")
+	}
+	records = append(records, guestRecs...)
+	dnsRecs, err := r.fetchDnsRecords()
+	if err != nil {
+		return nil, errors.Wrap(err, "fetchDnsRecords")
+	}
+	records = append(records, dnsRecs...)
+	return uniqRecords(records), nil
+}
+
+// zoneName returns the fully qualified name of name in primary zone, or
+// empty string if it cannot be represented as a single label
+func (r *SRegionDNS) zoneName(name string) string {
+	name = strings.ToLower(name)
+	if len(name) == 0 || strings.Contains(name, ".") {
+		return ""
+	}
+	fqdn := r.joinDomain(name)
+	if _, ok := dns.IsDomainName(fqdn); !ok {
+		return ""
+	}
+	return fqdn
+}
+
+func (r *SRegionDNS) fetchHostRecords() ([]dns.RR, error) {
+	hosts := models.HostManager.Query().SubQuery()
+	q := hosts.Query(hosts.Field("name"), hosts.Field("access_ip")).
+		Filter(sqlchemy.IsNotEmpty(hosts.Field("access_ip")))
+	rows, err := q.Rows()
+	if err != nil {
+		return nil, errors.Wrap(err, "query hosts")
+	}
+	defer rows.Close()
+	records := []dns.RR{}
+	for rows.Next() {
+		var name, ip string
+		if err := rows.Scan(&name, &ip); err != nil {
+			return nil, errors.Wrap(err, "scan hosts")
+		}
+		if rr := newAddrRecord(r.zoneName(name), ip, defaultTTL); rr != nil {
+			records = append(records, rr)
+		}
+	}
+	return records, nil
+}
+
+func (r *SRegionDNS) fetchGuestRecords() ([]dns.RR, error) {
+	guestnics := models.GuestnetworkManager.Query().SubQuery()
+	guests := models.GuestManager.Query().SubQuery()
+	networks := models.NetworkManager.Query().SubQuery()
+	q := guestnics.Query(guests.Field("name"), guestnics.Field("ip_addr"), guestnics.Field("ip6_addr")).
+		Join(guests, sqlchemy.AND(
+			sqlchemy.Equals(guests.Field("id"), guestnics.Field("guest_id")),
+			sqlchemy.OR(sqlchemy.IsNull(guests.Field("pending_deleted")),
+				sqlchemy.IsFalse(guests.Field("pending_deleted"))))).
+		Join(networks, sqlchemy.Equals(networks.Field("id"), guestnics.Field("network_id"))).
+		Filter(sqlchemy.IsNotEmpty(guestnics.Field("ip_addr"))).
+		Filter(sqlchemy.IsNotNull(networks.Field("guest_gateway")))
+	rows, err := q.Rows()
+	if err != nil {
+		return nil, errors.Wrap(err, "query guests")
+	}
+	defer rows.Close()
+	records := []dns.RR{}
+	for rows.Next() {
+		var (
+			name, ip string
+			ip6      sql.NullString
+		)
+		if err := rows.Scan(&name, &ip, &ip6); err != nil {
+			return nil, errors.Wrap(err, "scan guests")
+		}
+		records = append(records, guestAddrRecords(r.zoneName(name), ip, ip6.String)...)
+	}
+	return records, nil
+}
+
+// guestAddrRecords returns A record of ip and, for dual stack nics, AAAA
+// record of ip6
+func guestAddrRecords(name, ip, ip6 string) []dns.RR {
+	records := []dns.RR{}
+	for _, addr := range []string{ip, ip6} {
+		if rr := newAddrRecord(name, addr, defaultTTL); rr != nil {
+			records = append(records, rr)
+		}
+	}
+	return records
+}
+
+// fetchDnsRecords returns public dnsrecords whose name falls in primary zone
+func (r *SRegionDNS) fetchDnsRecords() ([]dns.RR, error) {
+	q := models.DnsRecordManager.Query().IsTrue("enabled").IsTrue("is_public")
+	recs := []models.SDnsRecord{}
+	err := db.FetchModelObjects(models.DnsRecordManager, q, &recs)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	records := []dns.RR{}
+	for i := range recs {
+		rec := &recs[i]
+		name := dns.Fqdn(strings.ToLower(rec.Name))
+		if !dns.IsSubDomain(r.PrimaryZone, name) || name == r.PrimaryZone {
+			continue
+		}
+		ttl := uint32(defaultTTL)
+		if rec.Ttl > 0 {
+			ttl = uint32(rec.Ttl)
+		}
+		for _, info := range rec.GetInfo() {
+			rr, err := dnsRecordInfoToRR(name, info, ttl)
+			if err != nil {
+				ylog.Warningf("dnsrecord %s(%s): %v", rec.Name, rec.Id, err)
+				continue
+			}
+			if rr != nil {
+				records = append(records, rr)
+			}
+		}
+	}
+	return records, nil
+}
+
+func newAddrRecord(name string, ip string, ttl uint32) dns.RR {
+	if len(name) == 0 {
+		return nil
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil
+	}
+	if addr4 := addr.To4(); addr4 != nil {
+		return &dns.A{
+			Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: ttl},
+			A:   addr4,
+		}
+	}
+	return &dns.AAAA{
+		Hdr:  dns.RR_Header{Name: name, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: ttl},
+		AAAA: addr,
+	}
+}
+
+// dnsRecordInfoToRR converts one "TYPE:value" entry of SDnsRecord.Records
+// to resource record.  PTR records belong to reverse zones and are skipped
+func dnsRecordInfoToRR(name, info string, ttl uint32) (dns.RR, error) {
+	i := strings.Index(info, ":")
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid record %q", info)
+	}
+	typ, val := info[:i], info[i+1:]
+	switch typ {
+	case "A", "AAAA":
+		rr := newAddrRecord(name, val, ttl)
+		if rr == nil {
+			return nil, fmt.Errorf("invalid address %q", val)
+		}
+		return rr, nil
+	case "CNAME":
+		return &dns.CNAME{
+			Hdr:    dns.RR_Header{Name: name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: ttl},
+			Target: dns.Fqdn(val),
+		}, nil
+	case "SRV":
+		parts := strings.SplitN(val, ":", 4)
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid SRV record %q", val)
+		}
+		port, err := strconv.ParseUint(parts[1], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SRV port %q", val)
+		}
+		weight, priority := uint64(100), uint64(0)
+		if len(parts) >= 3 {
+			if weight, err = strconv.ParseUint(parts[2], 10, 16); err != nil {
+				return nil, fmt.Errorf("invalid SRV weight %q", val)
+			}
+		}
+		if len(parts) >= 4 {
+			if priority, err = strconv.ParseUint(parts[3], 10, 16); err != nil {
+				return nil, fmt.Errorf("invalid SRV priority %q", val)
+			}
+		}
+		return &dns.SRV{
+			Hdr:      dns.RR_Header{Name: name, Rrtype: dns.TypeSRV, Class: dns.ClassINET, Ttl: ttl},
+			Target:   dns.Fqdn(parts[0]),
+			Port:     uint16(port),
+			Weight:   uint16(weight),
+			Priority: uint16(priority),
+		}, nil
+	}
+	return nil, nil
+}
+
+// uniqRecords sorts records and removes duplicates
+func uniqRecords(records []dns.RR) []dns.RR {
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].String() < records[j].String()
+	})
+	ret := make([]dns.RR, 0, len(records))
+	for i := range records {
+		if i > 0 && records[i].String() == records[i-1].String() {
+			continue
+		}
+		ret = append(ret, records[i])
+	}
+	return ret
+}
+
+// diffRecords returns records removed from and added to oldRecs, both of
+// which must be sorted as done by uniqRecords
+func diffRecords(oldRecs, newRecs []dns.RR) (deleted []dns.RR, added []dns.RR) {
+	i, j := 0, 0
+	for i < len(oldRecs) && j < len(newRecs) {
+		o, n := oldRecs[i].String(), newRecs[j].String()
+		switch {
+		case o == n:
+			i++
+			j++
+		case o < n:
+			deleted = append(deleted, oldRecs[i])
+			i++
+		default:
+			added = append(added, newRecs[j])
+			j++
+		}
+	}
+	deleted = append(deleted, oldRecs[i:]...)
+	added = append(added, newRecs[j:]...)
+	return
+}
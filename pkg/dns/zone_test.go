@@ -0,0 +1,230 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func mustRR(t *testing.T, s string) dns.RR {
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		t.Fatalf("NewRR %q: %v", s, err)
+	}
+	return rr
+}
+
+func rrStrings(rrs []dns.RR) []string {
+	ret := make([]string, len(rrs))
+	for i := range rrs {
+		ret[i] = rrs[i].String()
+	}
+	return ret
+}
+
+func TestUniqRecords(t *testing.T) {
+	a1 := mustRR(t, "a.example.com. 10 IN A 10.0.0.1")
+	a2 := mustRR(t, "b.example.com. 10 IN A 10.0.0.2")
+	a3 := mustRR(t, "a.example.com. 10 IN A 10.0.0.1")
+	ret := uniqRecords([]dns.RR{a2, a1, a3})
+	if len(ret) != 2 {
+		t.Fatalf("want 2 records, got %v", rrStrings(ret))
+	}
+	if ret[0].String() != a1.String() || ret[1].String() != a2.String() {
+		t.Errorf("records not sorted: %v", rrStrings(ret))
+	}
+	if ret := uniqRecords(nil); len(ret) != 0 {
+		t.Errorf("want empty, got %v", rrStrings(ret))
+	}
+}
+
+func TestDiffRecords(t *testing.T) {
+	a := mustRR(t, "a.example.com. 10 IN A 10.0.0.1")
+	b := mustRR(t, "b.example.com. 10 IN A 10.0.0.2")
+	c := mustRR(t, "c.example.com. 10 IN A 10.0.0.3")
+	d := mustRR(t, "d.example.com. 10 IN A 10.0.0.4")
+
+	cases := []struct {
+		name    string
+		old     []dns.RR
+		new     []dns.RR
+		deleted []dns.RR
+		added   []dns.RR
+	}{
+		{"same", []dns.RR{a, b}, []dns.RR{a, b}, nil, nil},
+		{"from empty", nil, []dns.RR{a, b}, nil, []dns.RR{a, b}},
+		{"to empty", []dns.RR{a, b}, nil, []dns.RR{a, b}, nil},
+		{"interleaved", []dns.RR{a, c}, []dns.RR{b, c, d}, []dns.RR{a}, []dns.RR{b, d}},
+		{"replaced", []dns.RR{a, b, c}, []dns.RR{a, d}, []dns.RR{b, c}, []dns.RR{d}},
+	}
+	for _, c := range cases {
+		deleted, added := diffRecords(uniqRecords(c.old), uniqRecords(c.new))
+		if got, want := rrStrings(deleted), rrStrings(c.deleted); !equalStrings(got, want) {
+			t.Errorf("%s: deleted want %v got %v", c.name, want, got)
+		}
+		if got, want := rrStrings(added), rrStrings(c.added); !equalStrings(got, want) {
+			t.Errorf("%s: added want %v got %v", c.name, want, got)
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDnsRecordInfoToRR(t *testing.T) {
+	name := "www.example.com."
+	cases := []struct {
+		info    string
+		want    string
+		wantErr bool
+	}{
+		{info: "A:10.0.0.1", want: "www.example.com.\t300\tIN\tA\t10.0.0.1"},
+		{info: "AAAA:fd00::1", want: "www.example.com.\t300\tIN\tAAAA\tfd00::1"},
+		{info: "CNAME:web.example.com", want: "www.example.com.\t300\tIN\tCNAME\tweb.example.com."},
+		{info: "SRV:srv.example.com:8080", want: "www.example.com.\t300\tIN\tSRV\t0 100 8080 srv.example.com."},
+		{info: "SRV:srv.example.com:8080:10:5", want: "www.example.com.\t300\tIN\tSRV\t5 10 8080 srv.example.com."},
+		{info: "PTR:www.example.com"},
+		{info: "A:10.0.0", wantErr: true},
+		{info: "A:fd00::1:", wantErr: true},
+		{info: "SRV:srv.example.com", wantErr: true},
+		{info: "SRV:srv.example.com:port", wantErr: true},
+		{info: "SRV:srv.example.com:80:weight", wantErr: true},
+		{info: "10.0.0.1", wantErr: true},
+		{info: ":10.0.0.1", wantErr: true},
+	}
+	for _, c := range cases {
+		rr, err := dnsRecordInfoToRR(name, c.info, 300)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: want error, got %v", c.info, rr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.info, err)
+			continue
+		}
+		got := ""
+		if rr != nil {
+			got = rr.String()
+		}
+		if got != c.want {
+			t.Errorf("%s: want %q got %q", c.info, c.want, got)
+		}
+	}
+}
+
+func TestGuestAddrRecords(t *testing.T) {
+	name := "vm1.example.com."
+	cases := []struct {
+		ip, ip6 string
+		want    []string
+	}{
+		{ip: "10.0.0.1", want: []string{"A\t10.0.0.1"}},
+		{ip: "10.0.0.1", ip6: "fd00::1", want: []string{"A\t10.0.0.1", "AAAA\tfd00::1"}},
+		{ip: "10.0.0.1", ip6: "fd00::1:", want: []string{"A\t10.0.0.1"}},
+	}
+	for _, c := range cases {
+		rrs := guestAddrRecords(name, c.ip, c.ip6)
+		if len(rrs) != len(c.want) {
+			t.Errorf("%s/%s: want %d records, got %v", c.ip, c.ip6, len(c.want), rrs)
+			continue
+		}
+		for i, rr := range rrs {
+			if !strings.HasSuffix(rr.String(), c.want[i]) {
+				t.Errorf("%s/%s: want %q, got %q", c.ip, c.ip6, c.want[i], rr.String())
+			}
+		}
+	}
+}
+
+func TestNextZoneSerial(t *testing.T) {
+	cases := []struct {
+		name     string
+		prev     *sZoneSerialState
+		dbSerial uint32
+		want     uint32
+	}{
+		{"initial", nil, 1000, 1000},
+		{"initial without db serial", nil, 0, 1},
+		{"db newer", &sZoneSerialState{Serial: 1000}, 1001, 1001},
+		// changes committed within the same second
+		{"db same", &sZoneSerialState{Serial: 1000}, 1000, 1001},
+		{"db older", &sZoneSerialState{Serial: 1000}, 900, 1001},
+		{"wrapped", &sZoneSerialState{Serial: 0xffffffff}, 5, 5},
+	}
+	for _, c := range cases {
+		if got := nextZoneSerial(c.prev, c.dbSerial); got != c.want {
+			t.Errorf("%s: want %d got %d", c.name, c.want, got)
+		}
+	}
+}
+
+func TestSerialLess(t *testing.T) {
+	cases := []struct {
+		name string
+		a    uint32
+		b    uint32
+		want bool
+	}{
+		{"less", 1, 2, true},
+		{"greater", 2, 1, false},
+		{"equal", 7, 7, false},
+		{"wrapped less", 0xfffffff0, 3, true},
+		{"wrapped greater", 3, 0xfffffff0, false},
+		{"far apart", 1, 0x90000000, false},
+	}
+	for _, c := range cases {
+		if got := serialLess(c.a, c.b); got != c.want {
+			t.Errorf("%s: want %v got %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestZoneSerialState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "region-dns", "zone_serial")
+	state, err := loadZoneSerialState(path)
+	if err != nil || state != nil {
+		t.Fatalf("want nil state for missing file, got %v, %v", state, err)
+	}
+	records := uniqRecords([]dns.RR{mustRR(t, "a.example.com. 10 IN A 10.0.0.1")})
+	want := sZoneSerialState{Serial: 1234, Checksum: zoneChecksum(records)}
+	if err := saveZoneSerialState(path, want); err != nil {
+		t.Fatalf("saveZoneSerialState: %v", err)
+	}
+	state, err = loadZoneSerialState(path)
+	if err != nil {
+		t.Fatalf("loadZoneSerialState: %v", err)
+	}
+	if state == nil || *state != want {
+		t.Errorf("want %v got %v", want, state)
+	}
+	if zoneChecksum(records) == zoneChecksum(nil) {
+		t.Errorf("checksum should differ for different records")
+	}
+}
@@ -32,17 +32,19 @@ type DiskParams struct {
 	Hypervisor string
 	DiskPath   string
 	VddkInfo   *apis.VDDKConInfo
+
+	EncryptInfo *apis.EncryptInfo
 }
 
 func GetIDisk(params DiskParams, driver string) IDisk {
 	hypervisor := params.Hypervisor
 	switch hypervisor {
 	case comapi.HYPERVISOR_KVM:
-		return NewKVMGuestDisk(params.DiskPath, driver)
+		return NewKVMGuestDisk(params.DiskPath, driver, params.EncryptInfo)
 	case comapi.HYPERVISOR_ESXI:
 		return NewVDDKDisk(params.VddkInfo, params.DiskPath, driver)
 	default:
-		return NewKVMGuestDisk(params.DiskPath, driver)
+		return NewKVMGuestDisk(params.DiskPath, driver, params.EncryptInfo)
 	}
 }
 
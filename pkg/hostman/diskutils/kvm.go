@@ -21,6 +21,7 @@ import (
 	"yunion.io/x/onecloud/pkg/hostman/diskutils/nbd"
 	"yunion.io/x/onecloud/pkg/hostman/guestfs"
 	"yunion.io/x/onecloud/pkg/hostman/guestfs/fsdriver"
+	"yunion.io/x/onecloud/pkg/hostman/hostdeployer/apis"
 	"yunion.io/x/onecloud/pkg/hostman/hostdeployer/consts"
 )
 
@@ -28,20 +29,20 @@ type SKVMGuestDisk struct {
 	deployer IDeployer
 }
 
-func NewKVMGuestDisk(imagePath, driver string) *SKVMGuestDisk {
+func NewKVMGuestDisk(imagePath, driver string, encryptInfo *apis.EncryptInfo) *SKVMGuestDisk {
 	return &SKVMGuestDisk{
-		deployer: newDeployer(imagePath, driver),
+		deployer: newDeployer(imagePath, driver, encryptInfo),
 	}
 }
 
-func newDeployer(imagePath, driver string) IDeployer {
+func newDeployer(imagePath, driver string, encryptInfo *apis.EncryptInfo) IDeployer {
 	switch driver {
 	case consts.DEPLOY_DRIVER_NBD:
-		return nbd.NewNBDDriver(imagePath)
+		return nbd.NewNBDDriver(imagePath, encryptInfo)
 	case consts.DEPLOY_DRIVER_LIBGUESTFS:
-		return libguestfs.NewLibguestfsDriver(imagePath)
+		return libguestfs.NewLibguestfsDriver(imagePath, encryptInfo)
 	default:
-		return nbd.NewNBDDriver(imagePath)
+		return nbd.NewNBDDriver(imagePath, encryptInfo)
 	}
 
 }
@@ -33,6 +33,7 @@ import (
 	"yunion.io/x/onecloud/pkg/hostman/guestfs/fsdriver"
 	"yunion.io/x/onecloud/pkg/hostman/guestfs/guestfishpart"
 	"yunion.io/x/onecloud/pkg/hostman/guestfs/kvmpart"
+	"yunion.io/x/onecloud/pkg/hostman/hostdeployer/apis"
 	"yunion.io/x/onecloud/pkg/util/fileutils2"
 )
 
@@ -54,20 +55,22 @@ func init() {
 }
 
 type SLibguestfsDriver struct {
-	imagePath string
-	nbddev    string
-	diskLabel string
-	lvmParts  []string
-	fsmap     *sortedmap.SSortedMap
-	fish      *guestfish.Guestfish
-	device    string
+	imagePath   string
+	encryptInfo *apis.EncryptInfo
+	nbddev      string
+	diskLabel   string
+	lvmParts    []string
+	fsmap       *sortedmap.SSortedMap
+	fish        *guestfish.Guestfish
+	device      string
 
 	parts []fsdriver.IDiskPartition
 }
 
-func NewLibguestfsDriver(imagePath string) *SLibguestfsDriver {
+func NewLibguestfsDriver(imagePath string, encryptInfo *apis.EncryptInfo) *SLibguestfsDriver {
 	return &SLibguestfsDriver{
-		imagePath: imagePath,
+		imagePath:   imagePath,
+		encryptInfo: encryptInfo,
 	}
 }
 
@@ -84,7 +87,7 @@ func (d *SLibguestfsDriver) Connect() error {
 	}
 	log.Debugf("acquired device %s", d.nbddev)
 
-	err = nbd.QemuNbdConnect(d.imagePath, d.nbddev)
+	err = nbd.QemuNbdConnect(d.imagePath, d.nbddev, d.encryptInfo)
 	if err != nil {
 		return err
 	}
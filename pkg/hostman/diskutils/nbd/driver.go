@@ -29,6 +29,7 @@ import (
 	"yunion.io/x/onecloud/pkg/hostman/diskutils/fsutils"
 	"yunion.io/x/onecloud/pkg/hostman/guestfs/fsdriver"
 	"yunion.io/x/onecloud/pkg/hostman/guestfs/kvmpart"
+	"yunion.io/x/onecloud/pkg/hostman/hostdeployer/apis"
 	"yunion.io/x/onecloud/pkg/util/procutils"
 	"yunion.io/x/onecloud/pkg/util/qemuimg"
 	"yunion.io/x/onecloud/pkg/util/qemutils"
@@ -41,14 +42,16 @@ type NBDDriver struct {
 	lvms                  []*SKVMGuestLVMPartition
 	imageRootBackFilePath string
 	imagePath             string
+	encryptInfo           *apis.EncryptInfo
 	acquiredLvm           bool
 	nbdDev                string
 }
 
-func NewNBDDriver(imagePath string) *NBDDriver {
+func NewNBDDriver(imagePath string, encryptInfo *apis.EncryptInfo) *NBDDriver {
 	return &NBDDriver{
-		imagePath:  imagePath,
-		partitions: make([]fsdriver.IDiskPartition, 0),
+		imagePath:   imagePath,
+		encryptInfo: encryptInfo,
+		partitions:  make([]fsdriver.IDiskPartition, 0),
 	}
 }
 
@@ -69,7 +72,7 @@ func (d *NBDDriver) Connect() error {
 	if len(d.nbdDev) == 0 {
 		return errors.Errorf("Cannot get nbd device")
 	}
-	if err := QemuNbdConnect(d.imagePath, d.nbdDev); err != nil {
+	if err := QemuNbdConnect(d.imagePath, d.nbdDev, d.encryptInfo); err != nil {
 		return err
 	}
 
@@ -256,25 +259,17 @@ func (d *NBDDriver) IsLVMPartition() bool {
 	return len(d.lvms) > 0
 }
 
-func QemuNbdConnect(imagePath, nbddev string) error {
+func QemuNbdConnect(imagePath, nbddev string, encryptInfo *apis.EncryptInfo) error {
+	if strings.HasPrefix(imagePath, "rbd:") {
+		if err := ensureCephConf(); err != nil {
+			return err
+		}
+	}
+	if encryptInfo != nil && len(encryptInfo.Key) > 0 {
+		return qemuNbdConnectEncrypted(imagePath, nbddev, encryptInfo.Key)
+	}
 	var cmd []string
 	if strings.HasPrefix(imagePath, "rbd:") || getImageFormat(imagePath) == "raw" {
-		//qemu-nbd 连接ceph时 /etc/ceph/ceph.conf 必须存在
-		if strings.HasPrefix(imagePath, "rbd:") {
-			err := procutils.NewRemoteCommandAsFarAsPossible("mkdir", "-p", "/etc/ceph").Run()
-			if err != nil {
-				log.Errorf("Failed to mkdir /etc/ceph: %s", err)
-				return errors.Wrap(err, "Failed to mkdir /etc/ceph: %s")
-			}
-			err = procutils.NewRemoteCommandAsFarAsPossible("test", "-f", "/etc/ceph/ceph.conf").Run()
-			if err != nil {
-				err = procutils.NewRemoteCommandAsFarAsPossible("touch", "/etc/ceph/ceph.conf").Run()
-				if err != nil {
-					log.Errorf("failed to create /etc/ceph/ceph.conf: %s", err)
-					return errors.Wrap(err, "failed to create /etc/ceph/ceph.conf")
-				}
-			}
-		}
 		cmd = []string{qemutils.GetQemuNbd(), "-c", nbddev, "-f", "raw", imagePath}
 	} else {
 		cmd = []string{qemutils.GetQemuNbd(), "-c", nbddev, imagePath}
@@ -287,6 +282,46 @@ func QemuNbdConnect(imagePath, nbddev string) error {
 	return nil
 }
 
+// qemu-nbd 连接ceph时 /etc/ceph/ceph.conf 必须存在
+func ensureCephConf() error {
+	err := procutils.NewRemoteCommandAsFarAsPossible("mkdir", "-p", "/etc/ceph").Run()
+	if err != nil {
+		log.Errorf("Failed to mkdir /etc/ceph: %s", err)
+		return errors.Wrap(err, "Failed to mkdir /etc/ceph: %s")
+	}
+	err = procutils.NewRemoteCommandAsFarAsPossible("test", "-f", "/etc/ceph/ceph.conf").Run()
+	if err != nil {
+		err = procutils.NewRemoteCommandAsFarAsPossible("touch", "/etc/ceph/ceph.conf").Run()
+		if err != nil {
+			log.Errorf("failed to create /etc/ceph/ceph.conf: %s", err)
+			return errors.Wrap(err, "failed to create /etc/ceph/ceph.conf")
+		}
+	}
+	return nil
+}
+
+// qemuNbdConnectEncrypted exports a LUKS encrypted image, the key is
+// passed to qemu-nbd through stdin
+func qemuNbdConnectEncrypted(imagePath, nbddev, key string) error {
+	img, err := qemuimg.NewQemuImage(imagePath)
+	if err != nil {
+		return errors.Wrapf(err, "open image %s", imagePath)
+	}
+	img.SetEncryptKey(key)
+	args, err := img.EncryptImageArgs()
+	if err != nil {
+		return errors.Wrap(err, "encrypt image args")
+	}
+	args = append(args, "-c", nbddev)
+	cmd := procutils.NewRemoteCommandAsFarAsPossible(qemutils.GetQemuNbd(), args...)
+	output, err := qemuimg.RunWithSecret(cmd, key)
+	if err != nil {
+		log.Errorf("qemu-nbd connect encrypted image failed %s %s", output, err.Error())
+		return errors.Wrapf(err, "qemu-nbd connect encrypted image failed %s", output)
+	}
+	return nil
+}
+
 func getImageFormat(imagePath string) string {
 	lines, err := procutils.NewRemoteCommandAsFarAsPossible(qemutils.GetQemuImg(), "info", imagePath).Output()
 	if err != nil {
@@ -142,7 +142,7 @@ func (vd *VDDKDisk) Connect() error {
 	if err != nil {
 		return err
 	}
-	vd.kvmDisk = NewKVMGuestDisk(flatFile, vd.deployDriver)
+	vd.kvmDisk = NewKVMGuestDisk(flatFile, vd.deployDriver, nil)
 	return vd.kvmDisk.Connect()
 }
 
@@ -0,0 +1,254 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	hostapi "yunion.io/x/onecloud/pkg/apis/host"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/hostman/monitor"
+	"yunion.io/x/onecloud/pkg/hostman/storageman"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+	"yunion.io/x/onecloud/pkg/util/qemuimg"
+	"yunion.io/x/onecloud/pkg/util/qemutils"
+)
+
+const (
+	// persistent dirty bitmap tracking writes since last backup
+	DISK_BACKUP_BITMAP = "onecloud-backup"
+)
+
+// DiskBackup takes backup of a disk, backup of disk of running guest is
+// taken online and reported when the block job completes
+func (m *SGuestManager) DiskBackup(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	backupParams, ok := params.(*storageman.SDiskBackup)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	guest, ok := m.GetServer(backupParams.Request.ServerId)
+	if ok && guest.IsRunning() {
+		if guest.Monitor == nil {
+			return nil, fmt.Errorf("guest %s monitor not ready", guest.Id)
+		}
+		NewGuestDiskBackupTask(ctx, guest, backupParams.Disk, backupParams.Request).Start()
+		return nil, nil
+	}
+	res, err := storageman.BackupDiskOffline(ctx, backupParams)
+	if err != nil {
+		return nil, err
+	}
+	hostutils.TaskComplete(ctx, res)
+	return nil, nil
+}
+
+func getBlockJobEventDevice(event *monitor.Event) string {
+	device, _ := event.Data["device"].(string)
+	return device
+}
+
+func (s *SKVMGuestInstance) isBackupJobEvent(event *monitor.Event) bool {
+	_, ok := s.backupJobs.Load(getBlockJobEventDevice(event))
+	return ok
+}
+
+func (s *SKVMGuestInstance) onBackupJobFinished(event *monitor.Event) {
+	if jobType, _ := event.Data["type"].(string); jobType != "backup" {
+		return
+	}
+	device := getBlockJobEventDevice(event)
+	callback, ok := s.backupJobs.Load(device)
+	if !ok {
+		return
+	}
+	s.backupJobs.Delete(device)
+	reason, _ := event.Data["error"].(string)
+	if event.Event == `"BLOCK_JOB_CANCELLED"` {
+		reason = "backup job cancelled"
+	}
+	go callback.(func(string))(reason)
+}
+
+/**
+ *  GuestDiskBackupTask
+**/
+
+type SGuestDiskBackupTask struct {
+	*SKVMGuestInstance
+
+	ctx  context.Context
+	disk storageman.IDisk
+	req  *hostapi.DiskBackupRequest
+
+	device     string
+	mode       string
+	target     string
+	diskSizeMb int
+}
+
+func NewGuestDiskBackupTask(
+	ctx context.Context, s *SKVMGuestInstance, disk storageman.IDisk, req *hostapi.DiskBackupRequest,
+) *SGuestDiskBackupTask {
+	return &SGuestDiskBackupTask{
+		SKVMGuestInstance: s,
+		ctx:               ctx,
+		disk:              disk,
+		req:               req,
+	}
+}
+
+func (s *SGuestDiskBackupTask) Start() {
+	s.Monitor.GetBlocks(s.onGetBlocks)
+}
+
+func (s *SGuestDiskBackupTask) onGetBlocks(blocks *jsonutils.JSONArray) {
+	if blocks == nil {
+		s.taskFailed("query blocks failed")
+		return
+	}
+	var block jsonutils.JSONObject
+	devs, _ := blocks.GetArray()
+	for _, d := range devs {
+		file, _ := d.GetString("inserted", "file")
+		if file == s.disk.GetPath() {
+			block = d
+			break
+		}
+	}
+	if block == nil {
+		s.taskFailed("Device not found")
+		return
+	}
+	s.device, _ = block.GetString("device")
+	sizeBytes, _ := block.Int("inserted", "image", "virtual-size")
+	s.diskSizeMb = int(sizeBytes / 1024 / 1024)
+
+	var bitmap *monitor.BlockDirtyBitmap
+	bitmaps := monitor.GetBlockDirtyBitmaps(block)
+	for i := range bitmaps {
+		if bitmaps[i].Name == DISK_BACKUP_BITMAP {
+			bitmap = &bitmaps[i]
+			break
+		}
+	}
+
+	s.mode = s.req.Mode
+	if len(s.req.ParentId) == 0 {
+		s.mode = api.DISK_BACKUP_MODE_FULL
+	}
+	switch {
+	case bitmap == nil:
+		// changes since last backup are unknown, e.g. guest migrated
+		// or disk snapshotted, a new chain has to be started
+		s.mode = api.DISK_BACKUP_MODE_FULL
+		s.Monitor.BlockDirtyBitmapAdd(s.device, DISK_BACKUP_BITMAP, true, s.onBitmapReady)
+	case bitmap.Inconsistent:
+		log.Warningf("guest %s disk %s dirty bitmap inconsistent, take full backup", s.Id, s.disk.GetId())
+		s.mode = api.DISK_BACKUP_MODE_FULL
+		s.Monitor.BlockDirtyBitmapRemove(s.device, DISK_BACKUP_BITMAP, func(res string) {
+			if len(res) > 0 {
+				s.taskFailed(fmt.Sprintf("remove dirty bitmap: %s", res))
+				return
+			}
+			s.Monitor.BlockDirtyBitmapAdd(s.device, DISK_BACKUP_BITMAP, true, s.onBitmapReady)
+		})
+	default:
+		s.onBitmapReady("")
+	}
+}
+
+func (s *SGuestDiskBackupTask) onBitmapReady(res string) {
+	if len(res) > 0 {
+		s.taskFailed(fmt.Sprintf("add dirty bitmap: %s", res))
+		return
+	}
+	bs, err := storageman.GetBackupStorage()
+	if err != nil {
+		s.taskFailed(err.Error())
+		return
+	}
+	dir, err := bs.GetBackupDir(s.disk.GetId())
+	if err != nil {
+		s.taskFailed(err.Error())
+		return
+	}
+	s.target = path.Join(dir, storageman.GetBackupFileName(s.req.BackupId))
+	img, err := qemuimg.NewQemuImage(s.target)
+	if err != nil {
+		s.taskFailed(err.Error())
+		return
+	}
+	if err := img.CreateQcow2(s.diskSizeMb, true, ""); err != nil {
+		s.taskFailed(fmt.Sprintf("create backup image: %s", err))
+		return
+	}
+
+	s.backupJobs.Store(s.device, s.onBackupJobDone)
+	if s.mode == api.DISK_BACKUP_MODE_FULL {
+		s.Monitor.DriveBackup(s.device, s.target, "full", DISK_BACKUP_BITMAP, true, s.onBackupStarted)
+	} else {
+		// bitmap is cleared by qemu once incremental backup succeeds
+		s.Monitor.DriveBackup(s.device, s.target, "incremental", DISK_BACKUP_BITMAP, false, s.onBackupStarted)
+	}
+}
+
+func (s *SGuestDiskBackupTask) onBackupStarted(res string) {
+	if len(res) > 0 {
+		s.backupJobs.Delete(s.device)
+		s.removeTarget()
+		s.taskFailed(fmt.Sprintf("start backup job: %s", res))
+	}
+}
+
+func (s *SGuestDiskBackupTask) onBackupJobDone(reason string) {
+	if len(reason) > 0 {
+		s.removeTarget()
+		s.taskFailed(reason)
+		return
+	}
+	if s.mode == api.DISK_BACKUP_MODE_INCREMENTAL {
+		// chain backup to its parent, parent is referred relatively
+		// and may not be present locally, so rebase in unsafe mode
+		output, err := procutils.NewRemoteCommandAsFarAsPossible(qemutils.GetQemuImg(),
+			"rebase", "-u", "-b", storageman.GetBackupFileName(s.req.ParentId), s.target).Output()
+		if err != nil {
+			s.removeTarget()
+			s.taskFailed(fmt.Sprintf("rebase backup image: %s %s", output, err))
+			return
+		}
+	}
+	resp, err := storageman.FinishDiskBackup(s.disk.GetId(), s.req.BackupId, s.mode, s.diskSizeMb)
+	if err != nil {
+		s.removeTarget()
+		s.taskFailed(err.Error())
+		return
+	}
+	hostutils.TaskComplete(s.ctx, jsonutils.Marshal(resp))
+}
+
+func (s *SGuestDiskBackupTask) removeTarget() {
+	procutils.NewCommand("rm", "-f", s.target).Run()
+}
+
+func (s *SGuestDiskBackupTask) taskFailed(reason string) {
+	log.Errorf("SGuestDiskBackupTask error: %s", reason)
+	hostutils.TaskFailed(s.ctx, reason)
+}
@@ -80,6 +80,17 @@ func AddGuestTaskHandler(prefix string, app *appsrv.Application) {
 			"open-forward":         guestOpenForward,
 			"list-forward":         guestListForward,
 			"close-forward":        guestCloseForward,
+
+			"qga-ping":                   guestQgaPing,
+			"qga-set-password":           guestQgaSetPassword,
+			"qga-exec":                   guestQgaExec,
+			"qga-get-network-interfaces": guestQgaGetNetworkInterfaces,
+			"qga-get-os-info":            guestQgaGetOsInfo,
+			"qga-fsfreeze":               guestQgaFsfreeze,
+			"qga-fsthaw":                 guestQgaFsthaw,
+			"qga-fsfreeze-status":        guestQgaFsfreezeStatus,
+			"qga-file-read":              guestQgaFileRead,
+			"qga-file-write":             guestQgaFileWrite,
 		} {
 			app.AddHandler("POST",
 				fmt.Sprintf("%s/%s/<sid>/%s", prefix, keyWord, action),
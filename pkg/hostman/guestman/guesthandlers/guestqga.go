@@ -0,0 +1,148 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guesthandlers
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+
+	hostapis "yunion.io/x/onecloud/pkg/apis/host"
+	"yunion.io/x/onecloud/pkg/hostman/guestman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+func guestQgaPing(ctx context.Context, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	req := &hostapis.GuestQgaPingRequest{}
+	if err := body.Unmarshal(req); err != nil {
+		return nil, httperrors.NewInputParameterError("unmarshal: %v", err)
+	}
+	err := guestman.GetGuestManager().QgaPing(ctx, sid, req)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return nil, nil
+}
+
+func guestQgaSetPassword(ctx context.Context, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	req := &hostapis.GuestQgaSetPasswordRequest{}
+	if err := body.Unmarshal(req); err != nil {
+		return nil, httperrors.NewInputParameterError("unmarshal: %v", err)
+	}
+	if len(req.Username) == 0 {
+		return nil, httperrors.NewMissingParameterError("username")
+	}
+	if len(req.Password) == 0 {
+		return nil, httperrors.NewMissingParameterError("password")
+	}
+	err := guestman.GetGuestManager().QgaSetPassword(ctx, sid, req)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return nil, nil
+}
+
+func guestQgaExec(ctx context.Context, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	req := &hostapis.GuestQgaExecRequest{}
+	if err := body.Unmarshal(req); err != nil {
+		return nil, httperrors.NewInputParameterError("unmarshal: %v", err)
+	}
+	if len(req.Path) == 0 {
+		return nil, httperrors.NewMissingParameterError("path")
+	}
+	resp, err := guestman.GetGuestManager().QgaExec(ctx, sid, req)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return jsonutils.Marshal(resp), nil
+}
+
+func guestQgaGetNetworkInterfaces(ctx context.Context, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	resp, err := guestman.GetGuestManager().QgaGetNetworkInterfaces(ctx, sid)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return jsonutils.Marshal(resp), nil
+}
+
+func guestQgaGetOsInfo(ctx context.Context, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	resp, err := guestman.GetGuestManager().QgaGetOsInfo(ctx, sid)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return jsonutils.Marshal(resp), nil
+}
+
+func guestQgaFsfreeze(ctx context.Context, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	req := &hostapis.GuestQgaFsfreezeRequest{}
+	if err := body.Unmarshal(req); err != nil {
+		return nil, httperrors.NewInputParameterError("unmarshal: %v", err)
+	}
+	resp, err := guestman.GetGuestManager().QgaFsfreeze(ctx, sid, req)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return jsonutils.Marshal(resp), nil
+}
+
+func guestQgaFsthaw(ctx context.Context, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	req := &hostapis.GuestQgaFsfreezeRequest{}
+	if err := body.Unmarshal(req); err != nil {
+		return nil, httperrors.NewInputParameterError("unmarshal: %v", err)
+	}
+	resp, err := guestman.GetGuestManager().QgaFsthaw(ctx, sid, req)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return jsonutils.Marshal(resp), nil
+}
+
+func guestQgaFsfreezeStatus(ctx context.Context, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	resp, err := guestman.GetGuestManager().QgaFsfreezeStatus(ctx, sid)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return jsonutils.Marshal(resp), nil
+}
+
+func guestQgaFileRead(ctx context.Context, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	req := &hostapis.GuestQgaFileReadRequest{}
+	if err := body.Unmarshal(req); err != nil {
+		return nil, httperrors.NewInputParameterError("unmarshal: %v", err)
+	}
+	if len(req.Path) == 0 {
+		return nil, httperrors.NewMissingParameterError("path")
+	}
+	resp, err := guestman.GetGuestManager().QgaFileRead(ctx, sid, req)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return jsonutils.Marshal(resp), nil
+}
+
+func guestQgaFileWrite(ctx context.Context, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	req := &hostapis.GuestQgaFileWriteRequest{}
+	if err := body.Unmarshal(req); err != nil {
+		return nil, httperrors.NewInputParameterError("unmarshal: %v", err)
+	}
+	if len(req.Path) == 0 {
+		return nil, httperrors.NewMissingParameterError("path")
+	}
+	err := guestman.GetGuestManager().QgaFileWrite(ctx, sid, req)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return nil, nil
+}
@@ -25,6 +25,7 @@ import (
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/utils"
 
 	"yunion.io/x/onecloud/pkg/appctx"
@@ -33,7 +34,6 @@ import (
 	"yunion.io/x/onecloud/pkg/hostman/storageman"
 	"yunion.io/x/onecloud/pkg/util/fileutils2"
 	"yunion.io/x/onecloud/pkg/util/procutils"
-	"yunion.io/x/onecloud/pkg/util/qemuimg"
 	"yunion.io/x/onecloud/pkg/util/timeutils2"
 )
 
@@ -224,11 +224,18 @@ func (d *SGuestDiskSyncTask) removeDisk(disk jsonutils.JSONObject) {
 	index, _ := disk.Int("index")
 	devId := fmt.Sprintf("drive_%d", index)
 	d.guest.Monitor.DriveDel(devId,
-		func(results string) { d.onRemoveDriveSucc(devId, results) })
+		func(results string) { d.onRemoveDriveSucc(disk, devId, results) })
 }
 
-func (d *SGuestDiskSyncTask) onRemoveDriveSucc(devId, results string) {
-	d.guest.Monitor.DeviceDel(devId, d.onRemoveDiskSucc)
+func (d *SGuestDiskSyncTask) onRemoveDriveSucc(disk jsonutils.JSONObject, devId, results string) {
+	if !disk.Contains("encrypt_key_id") {
+		d.guest.Monitor.DeviceDel(devId, d.onRemoveDiskSucc)
+		return
+	}
+	d.guest.Monitor.DeviceDel(devId, func(results string) {
+		index, _ := disk.Int("index")
+		d.guest.Monitor.ObjectDel(getDiskSecretId(index), d.onRemoveDiskSucc)
+	})
 }
 
 func (d *SGuestDiskSyncTask) onRemoveDiskSucc(results string) {
@@ -305,7 +312,33 @@ func (d *SGuestDiskSyncTask) startAddDisk(disk jsonutils.JSONObject) {
 	case DISK_DRIVER_SATA:
 		bus = fmt.Sprintf("ide.%d", diskIndex)
 	}
-	d.guest.Monitor.DriveAdd(bus, params, func(result string) { d.onAddDiskSucc(disk, result) })
+	enc, err := d.guest.getDiskEncrypt(context.Background(), disk)
+	if err != nil {
+		log.Errorf("get disk %s encrypt info: %s", diskPath, err)
+		d.syncDisksConf()
+		return
+	}
+	if enc == nil {
+		d.guest.Monitor.DriveAdd(bus, params, func(result string) { d.onAddDiskSucc(disk, result) })
+		return
+	}
+	if len(enc.format) > 0 {
+		params["format"] = enc.format
+	}
+	for _, opt := range enc.driveOpts {
+		kv := strings.SplitN(opt, "=", 2)
+		params[kv[0]] = kv[1]
+	}
+	// the master secret may be already there, the disk secret
+	// fails to be added in case it is not
+	d.guest.Monitor.ObjectAdd("secret", d.guest.getMasterSecretParams(), func(string) {
+		d.guest.Monitor.ObjectAdd("secret", enc.getSecretParams(), func(result string) {
+			if len(result) > 0 {
+				log.Errorf("add disk %s secret failed: %s", diskPath, result)
+			}
+			d.guest.Monitor.DriveAdd(bus, params, func(result string) { d.onAddDiskSucc(disk, result) })
+		})
+	})
 }
 
 func (d *SGuestDiskSyncTask) onAddDiskSucc(disk jsonutils.JSONObject, results string) {
@@ -939,9 +972,22 @@ func NewGuestDiskSnapshotTask(
 }
 
 func (s *SGuestDiskSnapshotTask) Start() {
+	if keyId, diskIndex := s.getEncryptDrive(s.disk.GetPath()); len(keyId) > 0 {
+		s.startEncryptSnapshot(diskIndex)
+		return
+	}
 	s.fetchDisksInfo(s.startSnapshot)
 }
 
+// startEncryptSnapshot takes the encrypted overlay created by storage as the
+// active layer, it is unlocked by the secret object added with the disk
+func (s *SGuestDiskSnapshotTask) startEncryptSnapshot(diskIndex int64) {
+	device := fmt.Sprintf("drive_%d", diskIndex)
+	s.Monitor.SimpleCommand("stop", func(string) {
+		s.Monitor.SnapshotEncryptedBlkdev(device, s.disk.GetPath(), getDiskSecretId(diskIndex), s.onReloadBlkdevSucc)
+	})
+}
+
 func (s *SGuestDiskSnapshotTask) startSnapshot(device string) {
 	s.doReloadDisk(device, s.onReloadBlkdevSucc)
 }
@@ -999,16 +1045,29 @@ func NewGuestSnapshotDeleteTask(
 }
 
 func (s *SGuestSnapshotDeleteTask) Start() {
-	if err := s.doDiskConvert(); err != nil {
+	keyId, _ := s.getEncryptDrive(s.disk.GetPath())
+	if err := s.doDiskConvert(keyId); err != nil {
 		s.taskFailed(err.Error())
+		return
+	}
+	if len(keyId) > 0 {
+		// the encrypted chain is not reloaded, qemu keeps reading the
+		// replaced images it has opened until the guest restarts, which
+		// holds the same data as the converted snapshot
+		s.onResumeSucc("")
+		return
 	}
 	s.fetchDisksInfo(s.doReloadDisk)
 }
 
-func (s *SGuestSnapshotDeleteTask) doDiskConvert() error {
+func (s *SGuestSnapshotDeleteTask) doDiskConvert(keyId string) error {
 	snapshotDir := s.disk.GetSnapshotDir()
 	snapshotPath := path.Join(snapshotDir, s.convertSnapshot)
-	img, err := qemuimg.NewQemuImage(snapshotPath)
+	encryptInfo, err := storageman.GetEncryptInfo(s.ctx, keyId)
+	if err != nil {
+		return errors.Wrap(err, "get encrypt info")
+	}
+	img, err := storageman.NewDiskQemuImage(snapshotPath, encryptInfo)
 	if err != nil {
 		log.Errorln(err)
 		return err
@@ -1286,6 +1345,10 @@ func (task *SGuestHotplugCpuMemTask) onGetSlotIndex(index int) {
 		"id":   fmt.Sprintf("mem%d", *task.memSlotNewIndex),
 		"size": fmt.Sprintf("%dM", task.addMemSize),
 	}
+	if placement := task.GetNumaPlacement(); placement != nil {
+		params["host-nodes"] = fmt.Sprintf("%d", placement.NodeId)
+		params["policy"] = "bind"
+	}
 	task.Monitor.ObjectAdd("memory-backend-ram", params, task.onAddMemObject)
 }
 
@@ -0,0 +1,153 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/sysutils"
+)
+
+// SNumaPlacement is the numa node vcpus and memory of guest are bound to
+type SNumaPlacement struct {
+	NodeId int   `json:"node_id"`
+	Cpus   []int `json:"cpus"`
+}
+
+func (s *SKVMGuestInstance) getNumaPlacementPath() string {
+	return path.Join(s.HomeDir(), "numa_node")
+}
+
+// GetNumaPlacement returns numa node guest was bound to when it started,
+// nil if guest floats across nodes
+func (s *SKVMGuestInstance) GetNumaPlacement() *SNumaPlacement {
+	content, err := fileutils2.FileGetContents(s.getNumaPlacementPath())
+	if err != nil {
+		return nil
+	}
+	obj, err := jsonutils.ParseString(content)
+	if err != nil {
+		return nil
+	}
+	placement := &SNumaPlacement{}
+	if err := obj.Unmarshal(placement); err != nil {
+		return nil
+	}
+	return placement
+}
+
+func (s *SKVMGuestInstance) saveNumaPlacement(placement *SNumaPlacement) error {
+	if placement == nil {
+		if err := os.Remove(s.getNumaPlacementPath()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return fileutils2.FilePutContents(s.getNumaPlacementPath(), jsonutils.Marshal(placement).String(), false)
+}
+
+// getNumaPinnedVcpus counts vcpus of running guests bound to each numa node
+func (m *SGuestManager) getNumaPinnedVcpus(excludeId string) map[int]int {
+	ret := make(map[int]int)
+	m.Servers.Range(func(k, v interface{}) bool {
+		guest := v.(*SKVMGuestInstance)
+		if guest.Id == excludeId || !guest.IsRunning() {
+			return true
+		}
+		if placement := guest.GetNumaPlacement(); placement != nil {
+			cpu, _ := guest.Desc.Int("cpu")
+			ret[placement.NodeId] += int(cpu)
+		}
+		return true
+	})
+	return ret
+}
+
+// chooseNumaNode picks the node with the least vcpus bound among nodes
+// having enough cpus and free memory, or hugepages if guest memory is
+// backed by them. Nil means guest can not fit into a single node.
+func (s *SKVMGuestInstance) chooseNumaNode(cpu, mem int) *SNumaPlacement {
+	if !options.HostOptions.EnableNumaPlacement {
+		return nil
+	}
+	topo, err := s.manager.host.GetNumaTopology()
+	if err != nil {
+		log.Errorf("get numa topology: %v", err)
+		return nil
+	}
+	if len(topo.Nodes) < 2 {
+		return nil
+	}
+	hugepageSizeKb := 0
+	if s.manager.host.IsHugepagesEnabled() {
+		hugepageSizeKb = s.manager.host.GetHugepageSizeMb() * 1024
+	}
+	pinned := s.manager.getNumaPinnedVcpus(s.Id)
+	var (
+		best     *api.HostNumaNode
+		bestLoad float64
+	)
+	for i := range topo.Nodes {
+		node := &topo.Nodes[i]
+		if len(node.Cpus) < cpu {
+			continue
+		}
+		free := node.MemFreeMb
+		if hugepageSizeKb > 0 {
+			free = node.FreeHugepagesMb(hugepageSizeKb)
+		}
+		if free < mem {
+			continue
+		}
+		load := float64(pinned[node.NodeId]+cpu) / float64(len(node.Cpus))
+		if best == nil || load < bestLoad {
+			best = node
+			bestLoad = load
+		}
+	}
+	if best == nil {
+		log.Infof("guest %s with %d vcpus and %dM memory does not fit into a numa node", s.GetName(), cpu, mem)
+		return nil
+	}
+	return &SNumaPlacement{NodeId: best.NodeId, Cpus: best.Cpus}
+}
+
+// getNumaMemDesc binds guest memory to host node by a memory backend
+// holding all boot memory of guest numa node 0
+func (s *SKVMGuestInstance) getNumaMemDesc(placement *SNumaPlacement, uuid string, mem int64) string {
+	var cmd string
+	if s.manager.host.IsHugepagesEnabled() {
+		cmd = fmt.Sprintf(" -object memory-backend-file,id=ram-node0,size=%dM,mem-path=/dev/hugepages/%s,prealloc=on,host-nodes=%d,policy=bind",
+			mem, uuid, placement.NodeId)
+	} else {
+		cmd = fmt.Sprintf(" -object memory-backend-ram,id=ram-node0,size=%dM,host-nodes=%d,policy=bind",
+			mem, placement.NodeId)
+	}
+	// maxcpus is 255, hotplugged vcpus belong to node 0 as well
+	cmd += " -numa node,nodeid=0,cpus=0-254,memdev=ram-node0"
+	return cmd
+}
+
+func (s *SKVMGuestInstance) getNumaCpuList(placement *SNumaPlacement) string {
+	return sysutils.FormatCpuList(placement.Cpus)
+}
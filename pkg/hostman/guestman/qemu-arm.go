@@ -248,9 +248,13 @@ func (s *SKVMGuestInstance) generateArmStartScript(data *jsonutils.JSONDict) (st
 		cmd += " -device pvscsi,id=scsi"
 	}
 
+	encDisksDesc, encDisks, err := s.getEncryptDisksDesc(disks)
+	if err != nil {
+		return "", fmt.Errorf("get encrypt disks desc: %v", err)
+	}
+	cmd += encDisksDesc
 	for _, disk := range disks {
-		format, _ := disk.GetString("format")
-		cmd += s.getDriveDesc(disk, format)
+		cmd += s.getEncryptDriveDesc(disk, encDisks)
 		cmd += s.getArmVdiskDesc(disk)
 	}
 
@@ -288,7 +292,9 @@ func (s *SKVMGuestInstance) generateArmStartScript(data *jsonutils.JSONDict) (st
 		cmd += fmt.Sprintf(" -%s %s", k, v.String())
 	}
 
-	// cmd += s.getQgaDesc()
+	// arm virt machine has no default virtio-serial bus for guest agent port
+	cmd += " -device virtio-serial-pci,id=virtio-serial-qga"
+	cmd += s.getQgaDesc()
 	if fileutils2.Exists("/dev/random") {
 		cmd += " -object rng-random,filename=/dev/random,id=rng0"
 		cmd += " -device virtio-rng-pci,rng=rng0,max-bytes=1024,period=1000"
@@ -0,0 +1,229 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/hostman/storageman"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/qemuimg"
+)
+
+// secret object holding the per-guest master key, the disk keys are handed
+// to qemu encrypted by it so that they never appear in clear on the command line
+const ENCRYPT_MASTER_SECRET_ID = "master0"
+
+type sDiskEncrypt struct {
+	secretId string
+	data     string
+	iv       string
+
+	// luks if the disk is a plain LUKS volume rather than encrypted qcow2
+	format    string
+	driveOpts []string
+}
+
+func (s *SKVMGuestInstance) getMasterKeyPath() string {
+	return path.Join(s.HomeDir(), "master-key")
+}
+
+func (s *SKVMGuestInstance) loadOrCreateMasterKey() ([]byte, error) {
+	keyPath := s.getMasterKeyPath()
+	if fileutils2.Exists(keyPath) {
+		content, err := ioutil.ReadFile(keyPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read %s", keyPath)
+		}
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	}
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, errors.Wrap(err, "generate master key")
+	}
+	err := ioutil.WriteFile(keyPath, []byte(base64.StdEncoding.EncodeToString(key)), 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "write %s", keyPath)
+	}
+	return key, nil
+}
+
+func (s *SKVMGuestInstance) getMasterSecretParams() map[string]string {
+	return map[string]string{
+		"id":     ENCRYPT_MASTER_SECRET_ID,
+		"file":   s.getMasterKeyPath(),
+		"format": "base64",
+	}
+}
+
+func getDiskSecretId(diskIndex int64) string {
+	return fmt.Sprintf("sec_%d", diskIndex)
+}
+
+// encryptDiskSecret seals the disk key with the master key by aes-256-cbc,
+// which is the cipher qemu uses to decrypt secrets with a keyid
+func encryptDiskSecret(masterKey []byte, secret string) (string, string, error) {
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return "", "", errors.Wrap(err, "new cipher")
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return "", "", errors.Wrap(err, "generate iv")
+	}
+	padding := aes.BlockSize - len(secret)%aes.BlockSize
+	plaintext := append([]byte(secret), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plaintext)
+	return base64.StdEncoding.EncodeToString(ciphertext), base64.StdEncoding.EncodeToString(iv), nil
+}
+
+// getDiskEncrypt returns the secret and drive options of an encrypted disk,
+// nil is returned if the disk is not encrypted
+func (s *SKVMGuestInstance) getDiskEncrypt(ctx context.Context, disk jsonutils.JSONObject) (*sDiskEncrypt, error) {
+	keyId, _ := disk.GetString("encrypt_key_id")
+	if len(keyId) == 0 {
+		return nil, nil
+	}
+	diskPath, _ := disk.GetString("path")
+	iDisk := storageman.GetManager().GetDiskByPath(diskPath)
+	if iDisk == nil {
+		return nil, errors.Errorf("get disk %s by storage error", diskPath)
+	}
+	encryptInfo, err := storageman.GetEncryptInfo(ctx, keyId)
+	if err != nil {
+		return nil, err
+	}
+	masterKey, err := s.loadOrCreateMasterKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "load master key")
+	}
+	diskIndex, _ := disk.Int("index")
+	enc := &sDiskEncrypt{secretId: getDiskSecretId(diskIndex)}
+	enc.data, enc.iv, err = encryptDiskSecret(masterKey, encryptInfo.Key)
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypt disk secret")
+	}
+	img, err := qemuimg.NewQemuImage(iDisk.GetPath())
+	if err != nil {
+		return nil, errors.Wrapf(err, "open image %s", iDisk.GetPath())
+	}
+	if img.Format == qemuimg.LUKS {
+		enc.format = img.Format.String()
+	}
+	enc.driveOpts, err = img.GetEncryptOptions(enc.secretId)
+	if err != nil {
+		return nil, errors.Wrap(err, "get encrypt options")
+	}
+	return enc, nil
+}
+
+// getEncryptDrive returns the encrypt key id and index of the disk at
+// diskPath, empty key id is returned if the disk is not encrypted
+func (s *SKVMGuestInstance) getEncryptDrive(diskPath string) (string, int64) {
+	disks, _ := s.Desc.GetArray("disks")
+	for _, disk := range disks {
+		if p, _ := disk.GetString("path"); p != diskPath {
+			continue
+		}
+		keyId, _ := disk.GetString("encrypt_key_id")
+		if len(keyId) == 0 {
+			break
+		}
+		diskIndex, _ := disk.Int("index")
+		return keyId, diskIndex
+	}
+	return "", -1
+}
+
+func (enc *sDiskEncrypt) getSecretParams() map[string]string {
+	return map[string]string{
+		"id":     enc.secretId,
+		"data":   enc.data,
+		"keyid":  ENCRYPT_MASTER_SECRET_ID,
+		"iv":     enc.iv,
+		"format": "base64",
+	}
+}
+
+func getSecretObjectDesc(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k != "id" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	opts := []string{"secret", "id=" + params["id"]}
+	for _, k := range keys {
+		opts = append(opts, fmt.Sprintf("%s=%s", k, params[k]))
+	}
+	return " -object " + strings.Join(opts, ",")
+}
+
+// getEncryptDisksDesc returns the secret objects of the encrypted disks
+// and their encrypt settings keyed by disk index
+func (s *SKVMGuestInstance) getEncryptDisksDesc(disks []jsonutils.JSONObject) (string, map[int64]*sDiskEncrypt, error) {
+	cmd := ""
+	encDisks := map[int64]*sDiskEncrypt{}
+	for _, disk := range disks {
+		enc, err := s.getDiskEncrypt(context.Background(), disk)
+		if err != nil {
+			return "", nil, err
+		}
+		if enc == nil {
+			continue
+		}
+		if len(encDisks) == 0 {
+			cmd += getSecretObjectDesc(s.getMasterSecretParams())
+		}
+		diskIndex, _ := disk.Int("index")
+		encDisks[diskIndex] = enc
+		cmd += getSecretObjectDesc(enc.getSecretParams())
+	}
+	return cmd, encDisks, nil
+}
+
+// getEncryptDriveDesc returns the drive desc of the disk with its encrypt options
+func (s *SKVMGuestInstance) getEncryptDriveDesc(disk jsonutils.JSONObject, encDisks map[int64]*sDiskEncrypt) string {
+	format, _ := disk.GetString("format")
+	diskIndex, _ := disk.Int("index")
+	enc, ok := encDisks[diskIndex]
+	if !ok {
+		return s.getDriveDesc(disk, format)
+	}
+	if len(enc.format) > 0 {
+		format = enc.format
+	}
+	cmd := s.getDriveDesc(disk, format)
+	for _, opt := range enc.driveOpts {
+		cmd += "," + opt
+	}
+	return cmd
+}
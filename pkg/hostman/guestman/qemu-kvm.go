@@ -22,6 +22,7 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"yunion.io/x/jsonutils"
@@ -66,10 +67,18 @@ type SKVMGuestInstance struct {
 
 	Desc        *jsonutils.JSONDict
 	Monitor     monitor.Monitor
+	guestAgent  *monitor.GuestAgent
 	manager     *SGuestManager
 	startupTask *SGuestResumeTask
 	stopping    bool
 	syncMeta    *jsonutils.JSONDict
+
+	// guards guestAgent and fsfreezeTimer
+	qgaLock sync.Mutex
+	// thaws guest filesystems if nobody does in time
+	fsfreezeTimer *time.Timer
+	// device => callback of running backup job
+	backupJobs sync.Map
 }
 
 func NewKVMGuestInstance(id string, manager *SGuestManager) *SKVMGuestInstance {
@@ -479,8 +488,10 @@ func (s *SKVMGuestInstance) onReceiveQMPEvent(event *monitor.Event) {
 				}
 			}
 		}
-	case event.Event == `"BLOCK_JOB_ERROR"`:
+	case event.Event == `"BLOCK_JOB_ERROR"` && !s.isBackupJobEvent(event):
 		s.SyncMirrorJobFailed("BLOCK_JOB_ERROR")
+	case event.Event == `"BLOCK_JOB_COMPLETED"` || event.Event == `"BLOCK_JOB_CANCELLED"`:
+		s.onBackupJobFinished(event)
 	case event.Event == `"GUEST_PANICKED"`:
 		// qemu runc state event source qemu/src/qapi/run-state.json
 		params := jsonutils.NewDict()
@@ -557,6 +568,7 @@ func (s *SKVMGuestInstance) onMonitorDisConnect(err error) {
 	}
 	s.clearCgroup(0)
 	s.Monitor = nil
+	s.closeGuestAgent()
 }
 
 func (s *SKVMGuestInstance) startDiskBackupMirror(ctx context.Context) {
@@ -892,6 +904,7 @@ func (s *SKVMGuestInstance) ExitCleanup(clear bool) {
 		s.Monitor.Disconnect()
 		s.Monitor = nil
 	}
+	s.closeGuestAgent()
 }
 
 func (s *SKVMGuestInstance) CleanupCpuset() {
@@ -920,6 +933,13 @@ func (s *SKVMGuestInstance) delTmpDisks(ctx context.Context, migrated bool) erro
 					log.Errorln(err)
 					return err
 				}
+			} else if iscsiDisk, ok := d.(*storageman.SISCSIDisk); ok && migrated {
+				// the lun is used by the destination host now, only drop the local paths
+				if err := iscsiDisk.Unmap(); err != nil {
+					log.Errorln(err)
+					return err
+				}
+				iscsiDisk.Storage.RemoveDisk(iscsiDisk)
 			}
 		}
 	}
@@ -1348,6 +1368,11 @@ func (s *SKVMGuestInstance) OnResumeSyncMetadataInfo() {
 	if options.HostOptions.HugepagesOption == "native" {
 		meta.Set("__hugepage", jsonutils.NewString("native"))
 	}
+	if placement := s.GetNumaPlacement(); placement != nil {
+		meta.Set(compute.VM_METADATA_NUMA_NODE, jsonutils.NewString(fmt.Sprintf("%d", placement.NodeId)))
+	} else {
+		meta.Set(compute.VM_METADATA_NUMA_NODE, jsonutils.NewString(""))
+	}
 	if !options.HostOptions.HostCpuPassthrough || s.getOsname() == OS_NAME_MACOS {
 		meta.Set("__cpu_mode", jsonutils.NewString(compute.CPU_MODE_QEMU))
 	} else {
@@ -148,6 +148,8 @@ func (s *SKVMGuestInstance) getDriveDesc(disk jsonutils.JSONObject, format strin
 		// pass    # qemu will automatically detect image format
 	} else if format == "raw" {
 		cmd += ",format=raw"
+	} else if format == "luks" {
+		cmd += ",format=luks"
 	}
 	cmd += fmt.Sprintf(",cache=%s", cacheMode)
 	cmd += fmt.Sprintf(",aio=%s", aioMode)
@@ -303,9 +305,13 @@ func (s *SKVMGuestInstance) getVnicDesc(nic jsonutils.JSONObject) string {
 	return cmd
 }
 
+func (s *SKVMGuestInstance) GetQgaSocketPath() string {
+	return path.Join(s.HomeDir(), "qga.sock")
+}
+
 func (s *SKVMGuestInstance) getQgaDesc() string {
 	cmd := " -chardev socket,path="
-	cmd += path.Join(s.HomeDir(), "qga.sock")
+	cmd += s.GetQgaSocketPath()
 	cmd += ",server,nowait,id=qga0"
 	cmd += " -device virtserialport,chardev=qga0,name=org.qemu.guest_agent.0"
 	return cmd
@@ -362,8 +368,13 @@ func (s *SKVMGuestInstance) _generateStartScript(data *jsonutils.JSONDict) (stri
 
 	if s.manager.host.IsHugepagesEnabled() {
 		cmd += fmt.Sprintf("mkdir -p /dev/hugepages/%s\n", uuid)
-		cmd += fmt.Sprintf("mount -t hugetlbfs -o size=%dM hugetlbfs-%s /dev/hugepages/%s\n",
-			mem, uuid, uuid)
+		cmd += fmt.Sprintf("mount -t hugetlbfs -o pagesize=%dM,size=%dM hugetlbfs-%s /dev/hugepages/%s\n",
+			s.manager.host.GetHugepageSizeMb(), mem, uuid, uuid)
+	}
+
+	numaPlacement := s.chooseNumaNode(int(cpu), int(mem))
+	if err := s.saveNumaPlacement(numaPlacement); err != nil {
+		return "", fmt.Errorf("save numa placement: %v", err)
 	}
 
 	cmd += "sleep 1\n"
@@ -433,7 +444,12 @@ function nic_mtu() {
 `
 
 	// Generate Start VM script
-	cmd += `CMD="$QEMU_CMD`
+	if numaPlacement != nil {
+		// threads of qemu inherit cpu affinity
+		cmd += fmt.Sprintf(`CMD="taskset -c %s $QEMU_CMD`, s.getNumaCpuList(numaPlacement))
+	} else {
+		cmd += `CMD="$QEMU_CMD`
+	}
 	var accel, cpuType string
 	if s.IsKvmSupport() {
 		cmd += " -enable-kvm"
@@ -489,16 +505,32 @@ function nic_mtu() {
 	// #cmd += fmt.Sprintf(" -uuid %s", self.desc["uuid"])
 	cmd += fmt.Sprintf(" -m %dM,slots=4,maxmem=524288M", mem)
 
-	if s.manager.host.IsHugepagesEnabled() {
+	if numaPlacement != nil {
+		cmd += s.getNumaMemDesc(numaPlacement, uuid, mem)
+	} else if s.manager.host.IsHugepagesEnabled() {
 		cmd += fmt.Sprintf(" -mem-prealloc -mem-path %s", fmt.Sprintf("/dev/hugepages/%s", uuid))
 	}
 
 	bootOrder, _ := s.Desc.GetString("boot_order")
-	cmd += fmt.Sprintf(" -boot order=%s", bootOrder)
 	cdrom, _ := s.Desc.Get("cdrom")
+	cmd += fmt.Sprintf(" -boot order=%s", bootOrder)
 	if cdrom != nil && cdrom.Contains("path") {
 		cmd += ",menu=on"
 	}
+	if s.isRescueMode() {
+		rescue, err := s.getRescueBoot()
+		if err != nil {
+			return "", fmt.Errorf("get rescue boot: %v", err)
+		}
+		rescueDesc, err := s.getRescueBootDesc(rescue)
+		if err != nil {
+			return "", fmt.Errorf("get rescue boot desc: %v", err)
+		}
+		cmd += rescueDesc
+	} else if err := s.removeRescuePassword(); err != nil {
+		// left by rescue mode stopped
+		log.Errorf("guest %s: %v", s.GetName(), err)
+	}
 
 	if s.getBios() == "UEFI" {
 		cmd += fmt.Sprintf(" -bios %s", options.HostOptions.OvmfPath)
@@ -579,9 +611,13 @@ function nic_mtu() {
 		cmd += " -device pvscsi,id=scsi"
 	}
 
+	encDisksDesc, encDisks, err := s.getEncryptDisksDesc(disks)
+	if err != nil {
+		return "", fmt.Errorf("get encrypt disks desc: %v", err)
+	}
+	cmd += encDisksDesc
 	for _, disk := range disks {
-		format, _ := disk.GetString("format")
-		cmd += s.getDriveDesc(disk, format)
+		cmd += s.getEncryptDriveDesc(disk, encDisks)
 		cmd += s.getVdiskDesc(disk)
 	}
 
@@ -667,6 +703,8 @@ function nic_mtu() {
 	// cmd += "else\n"
 	cmd += "$CMD\n"
 	// cmd += "fi\n"
+	// qemu has read rescue password on start
+	cmd += fmt.Sprintf("rm -f %s\n", s.getRescuePasswordPath())
 
 	return cmd, nil
 }
@@ -701,6 +739,7 @@ func (s *SKVMGuestInstance) generateStopScript(data *jsonutils.JSONDict) string
 	cmd += "  rm -f $PID_FILE\n"
 	cmd += "fi\n"
 
+	cmd += fmt.Sprintf("rm -f %s\n", s.getRescuePasswordPath())
 	if s.manager.host.IsHugepagesEnabled() {
 		cmd += fmt.Sprintf("if [ -d /dev/hugepages/%s ]; then\n", uuid)
 		cmd += fmt.Sprintf("  umount /dev/hugepages/%s\n", uuid)
@@ -0,0 +1,295 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"yunion.io/x/log"
+
+	hostapi "yunion.io/x/onecloud/pkg/apis/host"
+	"yunion.io/x/onecloud/pkg/hostman/monitor"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+)
+
+const (
+	QGA_EXEC_DEFAULT_TIMEOUT     = 60
+	QGA_FSFREEZE_DEFAULT_TIMEOUT = 30
+	QGA_FILE_READ_MAX_SIZE       = 4 * 1024 * 1024
+)
+
+func (s *SKVMGuestInstance) GetGuestAgent() (*monitor.GuestAgent, error) {
+	if !s.IsRunning() {
+		return nil, httperrors.NewInvalidStatusError("guest %s not running", s.Id)
+	}
+	if !fileutils2.Exists(s.GetQgaSocketPath()) {
+		return nil, httperrors.NewNotSupportedError("guest %s has no guest agent channel", s.Id)
+	}
+	s.qgaLock.Lock()
+	defer s.qgaLock.Unlock()
+	if s.guestAgent == nil {
+		s.guestAgent = monitor.NewGuestAgent(s.Id, s.GetQgaSocketPath())
+	}
+	return s.guestAgent, nil
+}
+
+func (s *SKVMGuestInstance) closeGuestAgent() {
+	s.qgaLock.Lock()
+	defer s.qgaLock.Unlock()
+	s.stopFsfreezeTimerLocked()
+	if s.guestAgent != nil {
+		s.guestAgent.Close()
+		s.guestAgent = nil
+	}
+}
+
+// IsGuestAgentResponsive checks whether qemu-ga inside guest answers in time
+func (s *SKVMGuestInstance) IsGuestAgentResponsive() bool {
+	qga, err := s.GetGuestAgent()
+	if err != nil {
+		return false
+	}
+	return qga.GuestSync(monitor.QGA_SYNC_TIMEOUT) == nil
+}
+
+func (m *SGuestManager) getGuestAgent(sid string) (*monitor.GuestAgent, error) {
+	guest, ok := m.GetServer(sid)
+	if !ok {
+		return nil, httperrors.NewNotFoundError("Not found")
+	}
+	return guest.GetGuestAgent()
+}
+
+func (m *SGuestManager) QgaPing(ctx context.Context, sid string, req *hostapi.GuestQgaPingRequest) error {
+	qga, err := m.getGuestAgent(sid)
+	if err != nil {
+		return err
+	}
+	timeout := monitor.QGA_SYNC_TIMEOUT
+	if req.Timeout > 0 {
+		timeout = time.Duration(req.Timeout) * time.Second
+	}
+	if err := qga.GuestSync(timeout); err != nil {
+		return httperrors.NewTimeoutError("guest agent not responsive in %s: %v", timeout, err)
+	}
+	return qga.GuestPing(timeout)
+}
+
+func (m *SGuestManager) QgaSetPassword(ctx context.Context, sid string, req *hostapi.GuestQgaSetPasswordRequest) error {
+	qga, err := m.getGuestAgent(sid)
+	if err != nil {
+		return err
+	}
+	return qga.GuestSetUserPassword(req.Username, req.Password, req.Crypted)
+}
+
+func (m *SGuestManager) QgaExec(ctx context.Context, sid string, req *hostapi.GuestQgaExecRequest) (*hostapi.GuestQgaExecResponse, error) {
+	qga, err := m.getGuestAgent(sid)
+	if err != nil {
+		return nil, err
+	}
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = QGA_EXEC_DEFAULT_TIMEOUT
+	}
+	status, err := qga.GuestExecWait(req.Path, req.Args, req.Env, req.Input, time.Duration(timeout)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &hostapi.GuestQgaExecResponse{
+		Exited:       status.Exited,
+		ExitCode:     status.Exitcode,
+		Signal:       status.Signal,
+		Stdout:       status.OutData,
+		Stderr:       status.ErrData,
+		OutTruncated: status.OutTruncated,
+		ErrTruncated: status.ErrTruncated,
+	}, nil
+}
+
+func (m *SGuestManager) QgaGetNetworkInterfaces(ctx context.Context, sid string) (*hostapi.GuestQgaNetworkInterfacesResponse, error) {
+	qga, err := m.getGuestAgent(sid)
+	if err != nil {
+		return nil, err
+	}
+	ifaces, err := qga.GuestNetworkGetInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	resp := &hostapi.GuestQgaNetworkInterfacesResponse{}
+	for _, iface := range ifaces {
+		nic := hostapi.GuestQgaNetworkInterface{
+			Name:            iface.Name,
+			HardwareAddress: iface.HardwareAddress,
+		}
+		for _, addr := range iface.IpAddresses {
+			nic.IpAddresses = append(nic.IpAddresses, hostapi.GuestQgaIpAddress{
+				IpAddressType: addr.IpAddressType,
+				IpAddress:     addr.IpAddress,
+				Prefix:        addr.Prefix,
+			})
+		}
+		resp.Interfaces = append(resp.Interfaces, nic)
+	}
+	return resp, nil
+}
+
+func (m *SGuestManager) QgaGetOsInfo(ctx context.Context, sid string) (*hostapi.GuestQgaOsInfoResponse, error) {
+	qga, err := m.getGuestAgent(sid)
+	if err != nil {
+		return nil, err
+	}
+	info, err := qga.GuestGetOsInfo()
+	if err != nil {
+		return nil, err
+	}
+	return &hostapi.GuestQgaOsInfoResponse{
+		Id:            info.Id,
+		Name:          info.Name,
+		PrettyName:    info.PrettyName,
+		Version:       info.Version,
+		VersionId:     info.VersionId,
+		KernelRelease: info.KernelRelease,
+		KernelVersion: info.KernelVersion,
+		Machine:       info.Machine,
+	}, nil
+}
+
+func fsfreezeTimeout(req *hostapi.GuestQgaFsfreezeRequest) time.Duration {
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = QGA_FSFREEZE_DEFAULT_TIMEOUT
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+// QgaFsfreeze freezes guest filesystems, they are thawed again if freeze fails halfway
+// or no thaw arrives in AutoThawTimeout seconds
+func (m *SGuestManager) QgaFsfreeze(ctx context.Context, sid string, req *hostapi.GuestQgaFsfreezeRequest) (*hostapi.GuestQgaFsfreezeResponse, error) {
+	guest, ok := m.GetServer(sid)
+	if !ok {
+		return nil, httperrors.NewNotFoundError("Not found")
+	}
+	qga, err := guest.GetGuestAgent()
+	if err != nil {
+		return nil, err
+	}
+	count, err := qga.GuestFsfreezeFreeze(fsfreezeTimeout(req))
+	if err != nil {
+		if _, thawErr := qga.GuestFsfreezeThaw(fsfreezeTimeout(req)); thawErr != nil {
+			return nil, errors.Wrapf(err, "thaw after freeze failure: %v", thawErr)
+		}
+		return nil, err
+	}
+	if req.AutoThawTimeout > 0 {
+		guest.startFsfreezeTimer(time.Duration(req.AutoThawTimeout) * time.Second)
+	}
+	return &hostapi.GuestQgaFsfreezeResponse{Count: count, Status: "frozen"}, nil
+}
+
+func (m *SGuestManager) QgaFsthaw(ctx context.Context, sid string, req *hostapi.GuestQgaFsfreezeRequest) (*hostapi.GuestQgaFsfreezeResponse, error) {
+	guest, ok := m.GetServer(sid)
+	if !ok {
+		return nil, httperrors.NewNotFoundError("Not found")
+	}
+	guest.stopFsfreezeTimer()
+	qga, err := guest.GetGuestAgent()
+	if err != nil {
+		return nil, err
+	}
+	count, err := qga.GuestFsfreezeThaw(fsfreezeTimeout(req))
+	if err != nil {
+		return nil, err
+	}
+	return &hostapi.GuestQgaFsfreezeResponse{Count: count, Status: "thawed"}, nil
+}
+
+func (s *SKVMGuestInstance) startFsfreezeTimer(timeout time.Duration) {
+	s.qgaLock.Lock()
+	defer s.qgaLock.Unlock()
+	s.stopFsfreezeTimerLocked()
+	s.fsfreezeTimer = time.AfterFunc(timeout, func() {
+		log.Warningf("guest %s filesystems frozen over %s, thaw them", s.Id, timeout)
+		qga, err := s.GetGuestAgent()
+		if err != nil {
+			log.Errorf("guest %s auto thaw: %v", s.Id, err)
+			return
+		}
+		if _, err := qga.GuestFsfreezeThaw(QGA_FSFREEZE_DEFAULT_TIMEOUT * time.Second); err != nil {
+			log.Errorf("guest %s auto thaw: %v", s.Id, err)
+		}
+	})
+}
+
+func (s *SKVMGuestInstance) stopFsfreezeTimer() {
+	s.qgaLock.Lock()
+	defer s.qgaLock.Unlock()
+	s.stopFsfreezeTimerLocked()
+}
+
+func (s *SKVMGuestInstance) stopFsfreezeTimerLocked() {
+	if s.fsfreezeTimer != nil {
+		s.fsfreezeTimer.Stop()
+		s.fsfreezeTimer = nil
+	}
+}
+
+func (m *SGuestManager) QgaFsfreezeStatus(ctx context.Context, sid string) (*hostapi.GuestQgaFsfreezeResponse, error) {
+	qga, err := m.getGuestAgent(sid)
+	if err != nil {
+		return nil, err
+	}
+	status, err := qga.GuestFsfreezeStatus()
+	if err != nil {
+		return nil, err
+	}
+	return &hostapi.GuestQgaFsfreezeResponse{Status: status}, nil
+}
+
+func (m *SGuestManager) QgaFileRead(ctx context.Context, sid string, req *hostapi.GuestQgaFileReadRequest) (*hostapi.GuestQgaFileResponse, error) {
+	qga, err := m.getGuestAgent(sid)
+	if err != nil {
+		return nil, err
+	}
+	maxSize := req.MaxSize
+	if maxSize <= 0 || maxSize > QGA_FILE_READ_MAX_SIZE {
+		maxSize = QGA_FILE_READ_MAX_SIZE
+	}
+	content, err := qga.GuestFileRead(req.Path, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	return &hostapi.GuestQgaFileResponse{
+		Path:    req.Path,
+		Content: base64.StdEncoding.EncodeToString(content),
+	}, nil
+}
+
+func (m *SGuestManager) QgaFileWrite(ctx context.Context, sid string, req *hostapi.GuestQgaFileWriteRequest) error {
+	qga, err := m.getGuestAgent(sid)
+	if err != nil {
+		return err
+	}
+	content, err := base64.StdEncoding.DecodeString(req.Content)
+	if err != nil {
+		return httperrors.NewInputParameterError("content must be base64 encoded: %v", err)
+	}
+	return qga.GuestFileWrite(req.Path, content)
+}
@@ -0,0 +1,128 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+)
+
+const (
+	RESCUE_KERNEL = "vmlinuz"
+	RESCUE_INITRD = "initrd.img"
+	RESCUE_ISO    = "rescue.iso"
+
+	// rescue system reads its one-time root password from
+	// /sys/firmware/qemu_fw_cfg/by_name/opt/cloud/rescue_password/raw
+	RESCUE_PASSWORD_FW_CFG = "opt/cloud/rescue_password"
+
+	// drive id of the rescue iso, attached besides cdrom of guest
+	RESCUE_CDROM_DRIVE = "rescue-cd0"
+)
+
+// SRescueBoot is the rescue image guest boots from, either a kernel with
+// initrd or an iso, disks and cdrom of guest are attached as usual
+type SRescueBoot struct {
+	Kernel   string
+	Initrd   string
+	Iso      string
+	Password string
+}
+
+func (s *SKVMGuestInstance) isRescueMode() bool {
+	mode, _ := s.Desc.GetString("metadata", compute.VM_METADATA_RESCUE_MODE)
+	return mode == "true"
+}
+
+func (s *SKVMGuestInstance) getRescueBoot() (*SRescueBoot, error) {
+	rescue := &SRescueBoot{}
+	key, _ := s.Desc.GetString("metadata", compute.VM_METADATA_RESCUE_LOGIN_KEY)
+	if len(key) > 0 {
+		password, err := utils.DescryptAESBase64(s.Id, key)
+		if err != nil {
+			return nil, errors.Wrap(err, "decrypt rescue password")
+		}
+		rescue.Password = password
+	}
+	dir := options.HostOptions.RescueDirPath
+	kernel, initrd := path.Join(dir, RESCUE_KERNEL), path.Join(dir, RESCUE_INITRD)
+	if fileutils2.Exists(kernel) && fileutils2.Exists(initrd) {
+		rescue.Kernel, rescue.Initrd = kernel, initrd
+		return rescue, nil
+	}
+	if iso := path.Join(dir, RESCUE_ISO); fileutils2.Exists(iso) {
+		rescue.Iso = iso
+		return rescue, nil
+	}
+	return nil, fmt.Errorf("no rescue image found in %s", dir)
+}
+
+// getRescuePasswordPath is the file passing rescue password to qemu, it
+// is only readable by root so that the password never shows up in the
+// command line of qemu
+func (s *SKVMGuestInstance) getRescuePasswordPath() string {
+	return path.Join(s.HomeDir(), "rescue_password")
+}
+
+func (s *SKVMGuestInstance) saveRescuePassword(password string) error {
+	passwordPath := s.getRescuePasswordPath()
+	// recreate the file so that the mode applies
+	if err := s.removeRescuePassword(); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(passwordPath, []byte(password), 0600); err != nil {
+		return errors.Wrapf(err, "write %s", passwordPath)
+	}
+	return nil
+}
+
+func (s *SKVMGuestInstance) removeRescuePassword() error {
+	err := os.Remove(s.getRescuePasswordPath())
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove rescue password")
+	}
+	return nil
+}
+
+// getRescueBootDesc returns qemu options booting the rescue kernel or iso
+// and passing the one-time password. The iso is attached as an extra
+// cdrom on its own ahci controller booting first, leaving the cdrom of
+// guest untouched
+func (s *SKVMGuestInstance) getRescueBootDesc(rescue *SRescueBoot) (string, error) {
+	cmd := ""
+	if len(rescue.Kernel) > 0 {
+		cmd += fmt.Sprintf(" -kernel %s -initrd %s -append console=ttyS0", rescue.Kernel, rescue.Initrd)
+	} else if len(rescue.Iso) > 0 {
+		cmd += " -device ich9-ahci,id=rescue-ahci"
+		cmd += fmt.Sprintf(" -device ide-cd,drive=%s,bus=rescue-ahci.0,bootindex=0", RESCUE_CDROM_DRIVE)
+		cmd += fmt.Sprintf(" -drive id=%s,media=cdrom,if=none,readonly=on,file=%s", RESCUE_CDROM_DRIVE, rescue.Iso)
+	}
+	if len(rescue.Password) > 0 {
+		if err := s.saveRescuePassword(rescue.Password); err != nil {
+			return "", err
+		}
+		cmd += fmt.Sprintf(" -fw_cfg name=%s,file=%s", RESCUE_PASSWORD_FW_CFG, s.getRescuePasswordPath())
+	}
+	return cmd, nil
+}
@@ -947,6 +947,7 @@ type DeployParams struct {
 	GuestDesc            *GuestDesc   `protobuf:"bytes,2,opt,name=guest_desc,json=guestDesc,proto3" json:"guest_desc,omitempty"`
 	DeployInfo           *DeployInfo  `protobuf:"bytes,3,opt,name=deploy_info,json=deployInfo,proto3" json:"deploy_info,omitempty"`
 	VddkInfo             *VDDKConInfo `protobuf:"bytes,4,opt,name=vddk_info,json=vddkInfo,proto3" json:"vddk_info,omitempty"`
+	EncryptInfo          *EncryptInfo `protobuf:"bytes,5,opt,name=encrypt_info,json=encryptInfo,proto3" json:"encrypt_info,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
 	XXX_unrecognized     []byte       `json:"-"`
 	XXX_sizecache        int32        `json:"-"`
@@ -1005,10 +1006,18 @@ func (m *DeployParams) GetVddkInfo() *VDDKConInfo {
 	return nil
 }
 
+func (m *DeployParams) GetEncryptInfo() *EncryptInfo {
+	if m != nil {
+		return m.EncryptInfo
+	}
+	return nil
+}
+
 type ResizeFsParams struct {
 	DiskPath             string       `protobuf:"bytes,1,opt,name=disk_path,json=diskPath,proto3" json:"disk_path,omitempty"`
 	Hypervisor           string       `protobuf:"bytes,2,opt,name=hypervisor,proto3" json:"hypervisor,omitempty"`
 	VddkInfo             *VDDKConInfo `protobuf:"bytes,3,opt,name=vddk_info,json=vddkInfo,proto3" json:"vddk_info,omitempty"`
+	EncryptInfo          *EncryptInfo `protobuf:"bytes,4,opt,name=encrypt_info,json=encryptInfo,proto3" json:"encrypt_info,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
 	XXX_unrecognized     []byte       `json:"-"`
 	XXX_sizecache        int32        `json:"-"`
@@ -1060,13 +1069,21 @@ func (m *ResizeFsParams) GetVddkInfo() *VDDKConInfo {
 	return nil
 }
 
+func (m *ResizeFsParams) GetEncryptInfo() *EncryptInfo {
+	if m != nil {
+		return m.EncryptInfo
+	}
+	return nil
+}
+
 type FormatFsParams struct {
-	DiskPath             string   `protobuf:"bytes,1,opt,name=disk_path,json=diskPath,proto3" json:"disk_path,omitempty"`
-	FsFormat             string   `protobuf:"bytes,2,opt,name=fs_format,json=fsFormat,proto3" json:"fs_format,omitempty"`
-	Uuid                 string   `protobuf:"bytes,3,opt,name=uuid,proto3" json:"uuid,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	DiskPath             string       `protobuf:"bytes,1,opt,name=disk_path,json=diskPath,proto3" json:"disk_path,omitempty"`
+	FsFormat             string       `protobuf:"bytes,2,opt,name=fs_format,json=fsFormat,proto3" json:"fs_format,omitempty"`
+	Uuid                 string       `protobuf:"bytes,3,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	EncryptInfo          *EncryptInfo `protobuf:"bytes,4,opt,name=encrypt_info,json=encryptInfo,proto3" json:"encrypt_info,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
 }
 
 func (m *FormatFsParams) Reset()         { *m = FormatFsParams{} }
@@ -1115,6 +1132,13 @@ func (m *FormatFsParams) GetUuid() string {
 	return ""
 }
 
+func (m *FormatFsParams) GetEncryptInfo() *EncryptInfo {
+	if m != nil {
+		return m.EncryptInfo
+	}
+	return nil
+}
+
 type ReleaseInfo struct {
 	Distro               string   `protobuf:"bytes,1,opt,name=distro,proto3" json:"distro,omitempty"`
 	Version              string   `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
@@ -1523,6 +1547,69 @@ func (m *EsxiDisksConnectionInfo) GetDisks() []*EsxiDiskInfo {
 	return nil
 }
 
+type EncryptInfo struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Key                  string   `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	Alg                  string   `protobuf:"bytes,4,opt,name=alg,proto3" json:"alg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EncryptInfo) Reset()         { *m = EncryptInfo{} }
+func (m *EncryptInfo) String() string { return proto.CompactTextString(m) }
+func (*EncryptInfo) ProtoMessage()    {}
+func (*EncryptInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_05f09e103004e384, []int{20}
+}
+
+func (m *EncryptInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EncryptInfo.Unmarshal(m, b)
+}
+func (m *EncryptInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EncryptInfo.Marshal(b, m, deterministic)
+}
+func (m *EncryptInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EncryptInfo.Merge(m, src)
+}
+func (m *EncryptInfo) XXX_Size() int {
+	return xxx_messageInfo_EncryptInfo.Size(m)
+}
+func (m *EncryptInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_EncryptInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EncryptInfo proto.InternalMessageInfo
+
+func (m *EncryptInfo) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *EncryptInfo) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *EncryptInfo) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *EncryptInfo) GetAlg() string {
+	if m != nil {
+		return m.Alg
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*GuestDesc)(nil), "apis.GuestDesc")
 	proto.RegisterType((*Disk)(nil), "apis.Disk")
@@ -1544,121 +1631,125 @@ func init() {
 	proto.RegisterType((*EsxiDiskInfo)(nil), "apis.EsxiDiskInfo")
 	proto.RegisterType((*ConnectEsxiDisksParams)(nil), "apis.ConnectEsxiDisksParams")
 	proto.RegisterType((*EsxiDisksConnectionInfo)(nil), "apis.EsxiDisksConnectionInfo")
+	proto.RegisterType((*EncryptInfo)(nil), "apis.EncryptInfo")
 }
 
 func init() { proto.RegisterFile("deploy.proto", fileDescriptor_05f09e103004e384) }
 
 var fileDescriptor_05f09e103004e384 = []byte{
-	// 1735 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x57, 0x4f, 0x6f, 0x1c, 0x49,
-	0x15, 0xd7, 0xfc, 0x9f, 0x7e, 0xe3, 0xd8, 0x4e, 0xc5, 0xb1, 0x7b, 0x27, 0x9b, 0x5d, 0x6b, 0x10,
-	0xc8, 0x0a, 0x59, 0x4b, 0x38, 0xc0, 0x05, 0x21, 0x11, 0xd9, 0x9b, 0xdd, 0x51, 0xd8, 0xc5, 0x6a,
-	0x27, 0x70, 0xa3, 0x55, 0xee, 0xae, 0x99, 0x29, 0xdc, 0x5d, 0xd5, 0xea, 0xaa, 0x99, 0xc9, 0x20,
-	0xed, 0x37, 0xe2, 0x84, 0xc4, 0x95, 0x23, 0xdf, 0x01, 0x3e, 0x02, 0x5f, 0x80, 0x2b, 0x7a, 0xaf,
-	0xaa, 0x7b, 0x7a, 0xbc, 0x21, 0x9b, 0x0b, 0xa7, 0xae, 0xf7, 0xa7, 0xaa, 0xde, 0xdf, 0xdf, 0xab,
-	0x86, 0xbd, 0x54, 0x14, 0x99, 0xde, 0x9c, 0x17, 0xa5, 0xb6, 0x9a, 0x75, 0x79, 0x21, 0xcd, 0xe4,
-	0x5f, 0x2d, 0x08, 0xbe, 0x5a, 0x0a, 0x63, 0xaf, 0x84, 0x49, 0x18, 0x83, 0xae, 0xe2, 0xb9, 0x08,
-	0x5b, 0xa7, 0xad, 0xb3, 0x20, 0xa2, 0x35, 0xf2, 0x96, 0x4b, 0x99, 0x86, 0x6d, 0xc7, 0xc3, 0x35,
-	0x3b, 0x86, 0x7e, 0xaa, 0x73, 0x2e, 0x55, 0xd8, 0x21, 0xae, 0xa7, 0xd8, 0x53, 0xe8, 0x2a, 0x99,
-	0x98, 0xb0, 0x7b, 0xda, 0x39, 0x1b, 0x5d, 0x04, 0xe7, 0x78, 0xc5, 0xf9, 0xb7, 0x32, 0x89, 0x88,
-	0xcd, 0x9e, 0xc3, 0x1e, 0x7e, 0x63, 0x63, 0xb9, 0x4a, 0x6f, 0x37, 0x61, 0xef, 0xbe, 0xda, 0x08,
-	0xc5, 0x37, 0x4e, 0xca, 0x4e, 0xa1, 0x97, 0x4a, 0x73, 0x67, 0xc2, 0x3e, 0xa9, 0x81, 0x53, 0xbb,
-	0x92, 0xe6, 0x2e, 0x72, 0x02, 0xf6, 0x19, 0xc0, 0xd7, 0x9b, 0x42, 0x94, 0x2b, 0x69, 0x74, 0x19,
-	0x0e, 0xc8, 0x94, 0x06, 0x67, 0xf2, 0xcf, 0x0e, 0x74, 0x51, 0x9f, 0x9d, 0xc0, 0x00, 0x77, 0xc4,
-	0x32, 0xf5, 0xae, 0xf5, 0x91, 0x9c, 0x3a, 0x47, 0x4a, 0xb9, 0x12, 0xa5, 0x77, 0xcf, 0x53, 0xec,
-	0x29, 0x40, 0xc2, 0x93, 0x85, 0x88, 0x73, 0x9d, 0x0a, 0xef, 0x64, 0x40, 0x9c, 0x6f, 0x74, 0x2a,
-	0xd8, 0x27, 0x30, 0xe4, 0x52, 0x3b, 0x61, 0x97, 0x84, 0x03, 0x2e, 0x35, 0x89, 0x18, 0x74, 0x8d,
-	0xfc, 0xb3, 0x08, 0x7b, 0xa7, 0xad, 0xb3, 0x4e, 0x44, 0x6b, 0xf6, 0x39, 0x8c, 0xac, 0xc8, 0x8b,
-	0x8c, 0x5b, 0x81, 0x26, 0xf4, 0x9d, 0xa1, 0x15, 0x6b, 0x9a, 0xe2, 0x75, 0x32, 0xe7, 0x73, 0x11,
-	0x17, 0xdc, 0x2e, 0xbc, 0x23, 0x01, 0x71, 0xae, 0xb9, 0x5d, 0xa0, 0xd8, 0x58, 0x5d, 0xa2, 0x82,
-	0x4c, 0xc3, 0xa1, 0x13, 0x7b, 0xce, 0x34, 0x65, 0x9f, 0x42, 0x90, 0xcb, 0x79, 0xc9, 0xad, 0x54,
-	0xf3, 0x30, 0x38, 0x6d, 0x9d, 0x0d, 0xa3, 0x2d, 0x83, 0x3d, 0x83, 0x87, 0x96, 0x97, 0x73, 0x61,
-	0xe3, 0xc6, 0x19, 0x40, 0x67, 0x1c, 0x38, 0xc1, 0x4d, 0x7d, 0x12, 0x83, 0x2e, 0x59, 0x30, 0x72,
-	0xb9, 0xc6, 0x35, 0x86, 0x68, 0xa6, 0xcb, 0x9c, 0xdb, 0x70, 0xcf, 0x85, 0xc8, 0x51, 0xec, 0x08,
-	0x7a, 0x52, 0xa5, 0xe2, 0x5d, 0xf8, 0xe0, 0xb4, 0x75, 0xd6, 0x8b, 0x1c, 0xc1, 0x7e, 0x0c, 0xfb,
-	0xb9, 0x28, 0xe7, 0x22, 0x36, 0x8a, 0x17, 0x66, 0xa1, 0x6d, 0xb8, 0x4f, 0x06, 0x3d, 0x20, 0xee,
-	0x8d, 0x67, 0xb2, 0x7d, 0x68, 0xcf, 0x4c, 0x78, 0x40, 0x07, 0xb6, 0x67, 0x94, 0xc9, 0x5c, 0x2f,
-	0x95, 0x2d, 0xb4, 0x54, 0x36, 0x3c, 0x74, 0x01, 0xda, 0x72, 0xd8, 0x21, 0x74, 0x52, 0xb1, 0x0a,
-	0x1f, 0x92, 0x00, 0x97, 0x93, 0x7f, 0x77, 0xa1, 0xf3, 0xad, 0x4c, 0x50, 0x92, 0xf3, 0xc4, 0xa7,
-	0x15, 0x97, 0x78, 0xb6, 0x2c, 0x7c, 0x3e, 0xdb, 0xb2, 0x40, 0x0d, 0x25, 0xac, 0x4f, 0x22, 0x2e,
-	0xd9, 0x63, 0xe8, 0x2b, 0x61, 0x31, 0x0e, 0x2e, 0x79, 0x3d, 0x25, 0xec, 0x34, 0x65, 0x21, 0x0c,
-	0x56, 0xb2, 0xb4, 0x4b, 0x9e, 0x51, 0xf6, 0x86, 0x51, 0x45, 0xa2, 0x64, 0xce, 0xad, 0x58, 0xf3,
-	0x8d, 0x4f, 0x5e, 0x45, 0x92, 0x61, 0xca, 0xf8, 0x94, 0xe1, 0xb2, 0xd1, 0x1b, 0xc3, 0x9d, 0xde,
-	0x38, 0x86, 0x7e, 0xa9, 0x97, 0x56, 0x18, 0x4a, 0x51, 0x10, 0x79, 0x0a, 0xf9, 0x72, 0x46, 0x5d,
-	0xe7, 0x92, 0xe2, 0x29, 0xbc, 0x33, 0xe7, 0xe6, 0x2e, 0x13, 0x8a, 0xd2, 0xd1, 0x8b, 0x2a, 0xb2,
-	0x51, 0xb4, 0x7b, 0x3b, 0x45, 0x7b, 0x0c, 0xfd, 0xdb, 0x52, 0xa6, 0x73, 0x41, 0x29, 0x09, 0x22,
-	0x4f, 0x61, 0xf5, 0xaf, 0x65, 0x49, 0x79, 0xdf, 0x77, 0x02, 0x24, 0x5d, 0xba, 0x57, 0x19, 0x57,
-	0x94, 0x87, 0x5e, 0x44, 0x6b, 0x2c, 0x26, 0xa9, 0xac, 0x28, 0x67, 0x3c, 0x11, 0x3e, 0x11, 0x5b,
-	0x06, 0xc6, 0xf6, 0x76, 0x4d, 0x69, 0xe8, 0x45, 0xed, 0xdb, 0xf5, 0xb6, 0x08, 0x58, 0xb3, 0x08,
-	0x3e, 0x87, 0x91, 0x8f, 0x5c, 0x2c, 0x0b, 0x13, 0x3e, 0x3a, 0xed, 0x60, 0x3a, 0x3d, 0x6b, 0x5a,
-	0x18, 0x54, 0x10, 0xef, 0xac, 0x28, 0x95, 0xc8, 0xd0, 0xaa, 0x23, 0x97, 0xef, 0x8a, 0x35, 0x4d,
-	0xd9, 0x13, 0x08, 0xac, 0xe0, 0x79, 0xbc, 0x96, 0x76, 0x11, 0x3e, 0x26, 0xf1, 0x10, 0x19, 0x7f,
-	0x90, 0xae, 0x22, 0x73, 0xae, 0x30, 0x4d, 0xc7, 0x94, 0x26, 0x4f, 0x61, 0x57, 0x2a, 0x99, 0xc4,
-	0x76, 0x53, 0x88, 0xf0, 0xc4, 0xa5, 0x49, 0xc9, 0xe4, 0xcd, 0xa6, 0xa0, 0x10, 0x64, 0x52, 0xdd,
-	0xc5, 0xcb, 0x22, 0x0c, 0xdd, 0x1e, 0x24, 0xdf, 0x52, 0x71, 0xe4, 0x76, 0x19, 0x7e, 0x42, 0xdd,
-	0x8a, 0xcb, 0x1a, 0x03, 0xc7, 0x5b, 0x0c, 0x9c, 0xac, 0x61, 0xf4, 0xfb, 0xab, 0xab, 0xd7, 0x97,
-	0x5a, 0x4d, 0xd5, 0x4c, 0xa3, 0xca, 0x42, 0x1b, 0x5b, 0xc1, 0x24, 0xae, 0xa9, 0x75, 0x74, 0x69,
-	0xa9, 0xee, 0x7a, 0x11, 0xad, 0x09, 0x3a, 0x8d, 0x28, 0x7d, 0xe9, 0xd1, 0x1a, 0x8d, 0x2f, 0xb8,
-	0x31, 0xeb, 0xaa, 0xf6, 0x3c, 0x85, 0x91, 0x5c, 0xe5, 0xa5, 0x98, 0x51, 0xe9, 0x05, 0x91, 0x23,
-	0x26, 0xff, 0x69, 0x03, 0x5c, 0x11, 0x6a, 0xd3, 0xc5, 0xcf, 0x01, 0x8a, 0xe5, 0x6d, 0x26, 0x93,
-	0xf8, 0x4e, 0x6c, 0xe8, 0xfa, 0xd1, 0xc5, 0x03, 0x87, 0x8b, 0x37, 0x37, 0x5f, 0xbf, 0x16, 0x1b,
-	0x13, 0x05, 0x4e, 0xe1, 0xb5, 0xd8, 0xb0, 0x2f, 0x60, 0xe0, 0x10, 0xdf, 0x84, 0x6d, 0x82, 0xd0,
-	0x47, 0x1e, 0x42, 0x89, 0x79, 0xa9, 0x95, 0x15, 0xca, 0x46, 0x95, 0x0e, 0x1b, 0xc3, 0x90, 0x6c,
-	0xd1, 0x65, 0xea, 0x2d, 0xae, 0x69, 0x8c, 0x9f, 0x34, 0xb1, 0x54, 0xd2, 0x92, 0xd9, 0xc3, 0xa8,
-	0x2f, 0xcd, 0x54, 0x49, 0x8b, 0xd0, 0x24, 0x14, 0xbf, 0xcd, 0x44, 0x6c, 0xed, 0xc6, 0xb7, 0x4d,
-	0xe0, 0x38, 0x6f, 0xec, 0x06, 0xc1, 0x27, 0x15, 0x33, 0xbe, 0xcc, 0x6c, 0x5c, 0x6a, 0x6d, 0x63,
-	0x0a, 0x47, 0x9f, 0xb4, 0x0e, 0xbc, 0x20, 0xd2, 0xda, 0xbe, 0xc5, 0xc8, 0xfc, 0x0a, 0xc6, 0x6b,
-	0xa9, 0x52, 0xbd, 0x36, 0x71, 0xb5, 0x87, 0xa7, 0xb9, 0x54, 0x6e, 0xd3, 0x80, 0x36, 0x9d, 0x78,
-	0x8d, 0x2b, 0xa7, 0xf0, 0x12, 0xe5, 0xb4, 0xf9, 0x19, 0x3c, 0xf4, 0x76, 0x24, 0x99, 0x5e, 0xa6,
-	0xce, 0xd4, 0xa1, 0xbb, 0xc8, 0x09, 0x2e, 0x91, 0x4f, 0x36, 0xff, 0x08, 0x1e, 0x64, 0x7a, 0x2e,
-	0x55, 0xcc, 0x93, 0x04, 0x21, 0xc6, 0x37, 0xe4, 0x1e, 0x31, 0x5f, 0x3a, 0xde, 0xe4, 0x2f, 0x2d,
-	0x18, 0xf8, 0x98, 0xa2, 0x93, 0xf7, 0xc2, 0x1e, 0x34, 0xe3, 0x4c, 0x4e, 0x66, 0xc2, 0x8a, 0xb8,
-	0xa1, 0xe5, 0xf0, 0xe7, 0xc0, 0x09, 0xae, 0x6b, 0xdd, 0x33, 0x38, 0x74, 0x4e, 0x35, 0x54, 0x5d,
-	0xb0, 0xf7, 0x89, 0xbf, 0xd5, 0x7c, 0x0e, 0xac, 0x28, 0xf5, 0x9f, 0x44, 0x62, 0x9b, 0xba, 0xae,
-	0x68, 0x0e, 0xbd, 0xa4, 0xd6, 0x9e, 0xbc, 0x85, 0x07, 0x3b, 0x69, 0xad, 0xa1, 0xbc, 0xd5, 0x80,
-	0xf2, 0x10, 0x06, 0x89, 0x13, 0x7b, 0xf3, 0x2a, 0x12, 0xab, 0x92, 0x27, 0x56, 0xea, 0x7a, 0xa0,
-	0x3b, 0x6a, 0x32, 0x80, 0xde, 0x97, 0x79, 0x61, 0x37, 0x93, 0xbf, 0xb5, 0xe0, 0xb1, 0xbb, 0x80,
-	0x5e, 0x0b, 0xaf, 0x4c, 0x24, 0x4c, 0xa1, 0x95, 0x11, 0x84, 0x46, 0xd2, 0xd8, 0x52, 0x37, 0x46,
-	0xab, 0x2d, 0x35, 0xa1, 0xa9, 0x28, 0x0d, 0x9e, 0xe9, 0x2f, 0xf3, 0x24, 0x9a, 0xc6, 0xcb, 0x64,
-	0x51, 0xb5, 0x05, 0xae, 0xb1, 0xf8, 0x32, 0xae, 0xe6, 0x4b, 0x3e, 0xaf, 0x26, 0x6a, 0x4d, 0x23,
-	0xe8, 0x68, 0xe3, 0xfb, 0xa2, 0xad, 0x0d, 0x9e, 0x5c, 0x65, 0xce, 0xa3, 0xb1, 0x27, 0xb1, 0x9b,
-	0x31, 0x48, 0x1e, 0x8d, 0xef, 0xc4, 0x66, 0xf2, 0xf7, 0x16, 0xec, 0x39, 0xbb, 0xaf, 0x79, 0xc9,
-	0x73, 0x83, 0xc8, 0x42, 0x4f, 0x81, 0x46, 0x70, 0x86, 0xc8, 0xa0, 0x41, 0x7b, 0x0e, 0x30, 0x47,
-	0xf7, 0xe2, 0x54, 0x98, 0x84, 0xcc, 0x1e, 0x5d, 0x1c, 0xb8, 0xa6, 0xa9, 0x1f, 0x49, 0x51, 0x30,
-	0xaf, 0xdf, 0x4b, 0x3f, 0x83, 0x91, 0xeb, 0x9e, 0x58, 0xaa, 0x99, 0x26, 0x87, 0x46, 0x17, 0x87,
-	0xcd, 0x2e, 0xc3, 0xb6, 0x8d, 0x20, 0xdd, 0xb6, 0xf0, 0x39, 0x04, 0xab, 0x34, 0xbd, 0x73, 0x1b,
-	0xba, 0xb4, 0xe1, 0xa1, 0xdb, 0xd0, 0x40, 0x98, 0x68, 0x88, 0x3a, 0xb8, 0x9a, 0x7c, 0x07, 0xfb,
-	0x91, 0xc0, 0x57, 0xc4, 0x2b, 0xf3, 0x31, 0x1e, 0x7c, 0x06, 0xb0, 0xd8, 0x3e, 0x89, 0x5c, 0xe0,
-	0x1b, 0x9c, 0xdd, 0xeb, 0x3b, 0x3f, 0x7c, 0xfd, 0x1f, 0x61, 0xff, 0x15, 0xcd, 0xfb, 0x8f, 0xbb,
-	0xfe, 0x09, 0x04, 0x33, 0x13, 0xfb, 0xf7, 0x82, 0xbb, 0x7d, 0x38, 0x33, 0xee, 0x84, 0xfa, 0x25,
-	0xd9, 0xd9, 0xbe, 0x24, 0x27, 0x1a, 0x46, 0x91, 0xc8, 0x04, 0x37, 0x82, 0xa2, 0xf3, 0x7f, 0x2f,
-	0xa6, 0xc9, 0x37, 0xc0, 0x6e, 0xf8, 0x4a, 0xbc, 0xd1, 0x5f, 0x65, 0x5c, 0x25, 0xe2, 0x63, 0x9c,
-	0x1a, 0xc3, 0x30, 0xd1, 0x79, 0x51, 0x0a, 0x63, 0xe8, 0xf6, 0x61, 0x54, 0xd3, 0x13, 0x01, 0x47,
-	0xcd, 0xe3, 0xea, 0xae, 0x38, 0x81, 0x81, 0x36, 0x2e, 0xca, 0xde, 0x13, 0x6d, 0xc8, 0xc3, 0x9f,
-	0xc3, 0x5e, 0xe9, 0x1c, 0x76, 0xd2, 0x76, 0x33, 0x07, 0x8d, 0x50, 0x44, 0xa3, 0x72, 0x4b, 0x4c,
-	0x5e, 0xc0, 0xd1, 0x75, 0xa9, 0x6f, 0xc5, 0x14, 0xdf, 0x84, 0xc8, 0xb9, 0x2e, 0x79, 0xce, 0x3f,
-	0x6c, 0xf7, 0xe4, 0xaf, 0x6d, 0x08, 0xea, 0x0d, 0xec, 0xd9, 0xae, 0x45, 0xef, 0xbd, 0xb3, 0x32,
-	0xd2, 0x59, 0x4f, 0x83, 0xb4, 0x5d, 0x59, 0x4f, 0x73, 0xf4, 0x27, 0x70, 0x20, 0x4d, 0xbc, 0x14,
-	0x33, 0x19, 0x9b, 0x65, 0x41, 0x03, 0xaf, 0xe3, 0xde, 0x77, 0xd2, 0xbc, 0x15, 0x33, 0x79, 0xe3,
-	0x98, 0x08, 0x73, 0xd2, 0xc4, 0xd9, 0x2a, 0x8f, 0x0b, 0x5e, 0x5a, 0x49, 0xc8, 0xe2, 0x06, 0xc7,
-	0xbe, 0x34, 0xbf, 0x5d, 0xe5, 0xd7, 0x15, 0x17, 0x9f, 0x02, 0xd2, 0xc4, 0xa5, 0xe0, 0xa9, 0x56,
-	0x59, 0x35, 0x41, 0x40, 0x9a, 0xc8, 0x73, 0xd8, 0x2f, 0xe1, 0xa4, 0x58, 0x6c, 0x8c, 0x4c, 0x78,
-	0xb6, 0x3d, 0xcc, 0xd9, 0xe6, 0xba, 0xff, 0x71, 0x25, 0xae, 0x0f, 0x25, 0x53, 0x7f, 0x01, 0x27,
-	0x34, 0xb2, 0x8c, 0xe5, 0x59, 0x26, 0xd2, 0xe6, 0x5c, 0x70, 0xb3, 0xe4, 0x08, 0x47, 0x98, 0x97,
-	0xd6, 0xc3, 0x61, 0xf2, 0x53, 0xd8, 0xfb, 0xd2, 0xbc, 0x93, 0xf8, 0xdb, 0x40, 0xa1, 0xf8, 0x60,
-	0x84, 0xbf, 0x83, 0xe3, 0x4b, 0xad, 0x94, 0x48, 0x6c, 0xb5, 0xa7, 0xea, 0x92, 0x9d, 0x3e, 0x6b,
-	0xfd, 0x60, 0x9f, 0xb1, 0x17, 0x30, 0xe2, 0x49, 0x22, 0x8c, 0xa9, 0xaa, 0x02, 0xe7, 0x35, 0x73,
-	0x3b, 0x9a, 0xf6, 0x44, 0xe0, 0xd4, 0xa8, 0x2a, 0x2e, 0xe1, 0xa4, 0xbe, 0xd7, 0xdb, 0x21, 0xfd,
-	0x13, 0xe5, 0xac, 0xfa, 0x79, 0x6a, 0xfd, 0xcf, 0x93, 0x9c, 0xc2, 0xc5, 0x3f, 0x3a, 0x30, 0x72,
-	0x58, 0xf5, 0x72, 0x8e, 0xa3, 0xe0, 0x37, 0xd5, 0x24, 0xf1, 0x40, 0xcf, 0x58, 0x13, 0xcf, 0x9c,
-	0x7b, 0xe3, 0x27, 0x4d, 0xde, 0xfd, 0x89, 0xf0, 0x05, 0x0c, 0x2b, 0xc8, 0x62, 0x47, 0x55, 0x91,
-	0x35, 0x21, 0x6c, 0x3c, 0xf2, 0xe6, 0xe0, 0x68, 0x41, 0xf5, 0x0a, 0x62, 0x2a, 0xf5, 0x5d, 0xc8,
-	0xd9, 0x55, 0xbf, 0x82, 0xbd, 0x66, 0xc7, 0xb1, 0xd0, 0xbf, 0x7f, 0xbe, 0xd7, 0xd4, 0xe3, 0xf1,
-	0xf7, 0x25, 0xb5, 0x8d, 0xbf, 0x86, 0xfd, 0xdd, 0x86, 0x62, 0x5e, 0xfb, 0x7d, 0x6d, 0x36, 0xf6,
-	0x33, 0x60, 0xab, 0xfc, 0x3b, 0x38, 0xbc, 0x9f, 0x78, 0xf6, 0xa9, 0x53, 0x7a, 0x7f, 0x41, 0x8c,
-	0x9f, 0xee, 0x66, 0xe0, 0x7e, 0xbe, 0x5e, 0xc2, 0xa3, 0x2b, 0x69, 0x92, 0xfb, 0x67, 0x7e, 0x78,
-	0xd7, 0x4e, 0x60, 0x6e, 0xfb, 0xf4, 0x5f, 0xff, 0xe2, 0xbf, 0x01, 0x00, 0x00, 0xff, 0xff, 0x30,
-	0xc4, 0xb6, 0xf0, 0xe7, 0x0f, 0x00, 0x00,
+	// 1792 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x58, 0xdd, 0x6e, 0x1c, 0x49,
+	0x15, 0xd6, 0xfc, 0x4f, 0x9f, 0x71, 0x6c, 0xa7, 0xe2, 0xd8, 0xbd, 0x93, 0xcd, 0xae, 0x35, 0x08,
+	0x64, 0x85, 0xac, 0x25, 0x1c, 0xe0, 0x06, 0x21, 0x11, 0xd9, 0xc9, 0xae, 0x15, 0x76, 0xb1, 0xda,
+	0x31, 0x5c, 0xb6, 0xca, 0xdd, 0x35, 0x33, 0x85, 0xbb, 0xab, 0x5a, 0x5d, 0x35, 0x33, 0x19, 0x24,
+	0xde, 0x82, 0xc7, 0xe0, 0x0a, 0xc4, 0x2b, 0xf0, 0x0e, 0xf0, 0x08, 0x5c, 0x23, 0x71, 0x8b, 0xce,
+	0xa9, 0xea, 0x9e, 0x1e, 0x6f, 0x08, 0x01, 0x69, 0xaf, 0x5c, 0xe7, 0x3b, 0xa7, 0xaa, 0xbf, 0x3a,
+	0xbf, 0x35, 0x86, 0x9d, 0x54, 0x14, 0x99, 0x5e, 0x9f, 0x16, 0xa5, 0xb6, 0x9a, 0x75, 0x79, 0x21,
+	0xcd, 0xe4, 0xef, 0x2d, 0x08, 0xbe, 0x5c, 0x08, 0x63, 0x2f, 0x84, 0x49, 0x18, 0x83, 0xae, 0xe2,
+	0xb9, 0x08, 0x5b, 0xc7, 0xad, 0x93, 0x20, 0xa2, 0x35, 0x62, 0x8b, 0x85, 0x4c, 0xc3, 0xb6, 0xc3,
+	0x70, 0xcd, 0x0e, 0xa1, 0x9f, 0xea, 0x9c, 0x4b, 0x15, 0x76, 0x08, 0xf5, 0x12, 0x7b, 0x0a, 0x5d,
+	0x25, 0x13, 0x13, 0x76, 0x8f, 0x3b, 0x27, 0xa3, 0xb3, 0xe0, 0x14, 0x3f, 0x71, 0xfa, 0x8d, 0x4c,
+	0x22, 0x82, 0xd9, 0x73, 0xd8, 0xc1, 0xbf, 0xb1, 0xb1, 0x5c, 0xa5, 0xb7, 0xeb, 0xb0, 0x77, 0xdf,
+	0x6c, 0x84, 0xea, 0x6b, 0xa7, 0x65, 0xc7, 0xd0, 0x4b, 0xa5, 0xb9, 0x33, 0x61, 0x9f, 0xcc, 0xc0,
+	0x99, 0x5d, 0x48, 0x73, 0x17, 0x39, 0x05, 0xfb, 0x0c, 0xe0, 0xab, 0x75, 0x21, 0xca, 0xa5, 0x34,
+	0xba, 0x0c, 0x07, 0x44, 0xa5, 0x81, 0x4c, 0xfe, 0xd6, 0x81, 0x2e, 0xda, 0xb3, 0x23, 0x18, 0xe0,
+	0x8e, 0x58, 0xa6, 0xfe, 0x6a, 0x7d, 0x14, 0x2f, 0xdd, 0x45, 0x4a, 0xb9, 0x14, 0xa5, 0xbf, 0x9e,
+	0x97, 0xd8, 0x53, 0x80, 0x84, 0x27, 0x73, 0x11, 0xe7, 0x3a, 0x15, 0xfe, 0x92, 0x01, 0x21, 0x5f,
+	0xeb, 0x54, 0xb0, 0x4f, 0x60, 0xc8, 0xa5, 0x76, 0xca, 0x2e, 0x29, 0x07, 0x5c, 0x6a, 0x52, 0x31,
+	0xe8, 0x1a, 0xf9, 0x3b, 0x11, 0xf6, 0x8e, 0x5b, 0x27, 0x9d, 0x88, 0xd6, 0xec, 0x73, 0x18, 0x59,
+	0x91, 0x17, 0x19, 0xb7, 0x02, 0x29, 0xf4, 0x1d, 0xd1, 0x0a, 0xba, 0x4c, 0xf1, 0x73, 0x32, 0xe7,
+	0x33, 0x11, 0x17, 0xdc, 0xce, 0xfd, 0x45, 0x02, 0x42, 0xae, 0xb8, 0x9d, 0xa3, 0xda, 0x58, 0x5d,
+	0xa2, 0x81, 0x4c, 0xc3, 0xa1, 0x53, 0x7b, 0xe4, 0x32, 0x65, 0x9f, 0x42, 0x90, 0xcb, 0x59, 0xc9,
+	0xad, 0x54, 0xb3, 0x30, 0x38, 0x6e, 0x9d, 0x0c, 0xa3, 0x0d, 0xc0, 0x9e, 0xc1, 0x43, 0xcb, 0xcb,
+	0x99, 0xb0, 0x71, 0xe3, 0x0c, 0xa0, 0x33, 0xf6, 0x9c, 0xe2, 0xba, 0x3e, 0x89, 0x41, 0x97, 0x18,
+	0x8c, 0x5c, 0xac, 0x71, 0x8d, 0x2e, 0x9a, 0xea, 0x32, 0xe7, 0x36, 0xdc, 0x71, 0x2e, 0x72, 0x12,
+	0x3b, 0x80, 0x9e, 0x54, 0xa9, 0x78, 0x17, 0x3e, 0x38, 0x6e, 0x9d, 0xf4, 0x22, 0x27, 0xb0, 0xef,
+	0xc3, 0x6e, 0x2e, 0xca, 0x99, 0x88, 0x8d, 0xe2, 0x85, 0x99, 0x6b, 0x1b, 0xee, 0x12, 0xa1, 0x07,
+	0x84, 0x5e, 0x7b, 0x90, 0xed, 0x42, 0x7b, 0x6a, 0xc2, 0x3d, 0x3a, 0xb0, 0x3d, 0xa5, 0x48, 0xe6,
+	0x7a, 0xa1, 0x6c, 0xa1, 0xa5, 0xb2, 0xe1, 0xbe, 0x73, 0xd0, 0x06, 0x61, 0xfb, 0xd0, 0x49, 0xc5,
+	0x32, 0x7c, 0x48, 0x0a, 0x5c, 0x4e, 0xfe, 0xd1, 0x85, 0xce, 0x37, 0x32, 0x41, 0x4d, 0xce, 0x13,
+	0x1f, 0x56, 0x5c, 0xe2, 0xd9, 0xb2, 0xf0, 0xf1, 0x6c, 0xcb, 0x02, 0x2d, 0x94, 0xb0, 0x3e, 0x88,
+	0xb8, 0x64, 0x8f, 0xa1, 0xaf, 0x84, 0x45, 0x3f, 0xb8, 0xe0, 0xf5, 0x94, 0xb0, 0x97, 0x29, 0x0b,
+	0x61, 0xb0, 0x94, 0xa5, 0x5d, 0xf0, 0x8c, 0xa2, 0x37, 0x8c, 0x2a, 0x11, 0x35, 0x33, 0x6e, 0xc5,
+	0x8a, 0xaf, 0x7d, 0xf0, 0x2a, 0x91, 0x88, 0x29, 0xe3, 0x43, 0x86, 0xcb, 0x46, 0x6d, 0x0c, 0xb7,
+	0x6a, 0xe3, 0x10, 0xfa, 0xa5, 0x5e, 0x58, 0x61, 0x28, 0x44, 0x41, 0xe4, 0x25, 0xc4, 0xe5, 0x94,
+	0xaa, 0xce, 0x05, 0xc5, 0x4b, 0xf8, 0xcd, 0x9c, 0x9b, 0xbb, 0x4c, 0x28, 0x0a, 0x47, 0x2f, 0xaa,
+	0xc4, 0x46, 0xd2, 0xee, 0x6c, 0x25, 0xed, 0x21, 0xf4, 0x6f, 0x4b, 0x99, 0xce, 0x04, 0x85, 0x24,
+	0x88, 0xbc, 0x84, 0xd9, 0xbf, 0x92, 0x25, 0xc5, 0x7d, 0xd7, 0x29, 0x50, 0x74, 0xe1, 0x5e, 0x66,
+	0x5c, 0x51, 0x1c, 0x7a, 0x11, 0xad, 0x31, 0x99, 0xa4, 0xb2, 0xa2, 0x9c, 0xf2, 0x44, 0xf8, 0x40,
+	0x6c, 0x00, 0xf4, 0xed, 0xed, 0x8a, 0xc2, 0xd0, 0x8b, 0xda, 0xb7, 0xab, 0x4d, 0x12, 0xb0, 0x66,
+	0x12, 0x7c, 0x0e, 0x23, 0xef, 0xb9, 0x58, 0x16, 0x26, 0x7c, 0x74, 0xdc, 0xc1, 0x70, 0x7a, 0xe8,
+	0xb2, 0x30, 0x68, 0x20, 0xde, 0x59, 0x51, 0x2a, 0x91, 0x21, 0xab, 0x03, 0x17, 0xef, 0x0a, 0xba,
+	0x4c, 0xd9, 0x13, 0x08, 0xac, 0xe0, 0x79, 0xbc, 0x92, 0x76, 0x1e, 0x3e, 0x26, 0xf5, 0x10, 0x81,
+	0xdf, 0x48, 0x97, 0x91, 0x39, 0x57, 0x18, 0xa6, 0x43, 0x0a, 0x93, 0x97, 0xb0, 0x2a, 0x95, 0x4c,
+	0x62, 0xbb, 0x2e, 0x44, 0x78, 0xe4, 0xc2, 0xa4, 0x64, 0xf2, 0x76, 0x5d, 0x90, 0x0b, 0x32, 0xa9,
+	0xee, 0xe2, 0x45, 0x11, 0x86, 0x6e, 0x0f, 0x8a, 0x37, 0x94, 0x1c, 0xb9, 0x5d, 0x84, 0x9f, 0x50,
+	0xb5, 0xe2, 0xb2, 0xee, 0x81, 0xe3, 0x4d, 0x0f, 0x9c, 0xac, 0x60, 0xf4, 0xeb, 0x8b, 0x8b, 0x37,
+	0xe7, 0x5a, 0x5d, 0xaa, 0xa9, 0x46, 0x93, 0xb9, 0x36, 0xb6, 0x6a, 0x93, 0xb8, 0x46, 0xac, 0xd0,
+	0xa5, 0xa5, 0xbc, 0xeb, 0x45, 0xb4, 0x46, 0x6c, 0x61, 0x44, 0xe9, 0x53, 0x8f, 0xd6, 0x48, 0xbe,
+	0xe0, 0xc6, 0xac, 0xaa, 0xdc, 0xf3, 0x12, 0x7a, 0x72, 0x99, 0x97, 0x62, 0x4a, 0xa9, 0x17, 0x44,
+	0x4e, 0x98, 0xfc, 0xab, 0x0d, 0x70, 0x41, 0x5d, 0x9b, 0x3e, 0xfc, 0x1c, 0xa0, 0x58, 0xdc, 0x66,
+	0x32, 0x89, 0xef, 0xc4, 0x9a, 0x3e, 0x3f, 0x3a, 0x7b, 0xe0, 0xfa, 0xe2, 0xf5, 0xf5, 0x57, 0x6f,
+	0xc4, 0xda, 0x44, 0x81, 0x33, 0x78, 0x23, 0xd6, 0xec, 0x0b, 0x18, 0xb8, 0x8e, 0x6f, 0xc2, 0x36,
+	0xb5, 0xd0, 0x47, 0xbe, 0x85, 0x12, 0x78, 0xae, 0x95, 0x15, 0xca, 0x46, 0x95, 0x0d, 0x1b, 0xc3,
+	0x90, 0xb8, 0xe8, 0x32, 0xf5, 0x8c, 0x6b, 0x19, 0xfd, 0x27, 0x4d, 0x2c, 0x95, 0xb4, 0x44, 0x7b,
+	0x18, 0xf5, 0xa5, 0xb9, 0x54, 0xd2, 0x62, 0x6b, 0x12, 0x8a, 0xdf, 0x66, 0x22, 0xb6, 0x76, 0xed,
+	0xcb, 0x26, 0x70, 0xc8, 0x5b, 0xbb, 0xc6, 0xe6, 0x93, 0x8a, 0x29, 0x5f, 0x64, 0x36, 0x2e, 0xb5,
+	0xb6, 0x31, 0xb9, 0xa3, 0x4f, 0x56, 0x7b, 0x5e, 0x11, 0x69, 0x6d, 0x6f, 0xd0, 0x33, 0x3f, 0x83,
+	0xf1, 0x4a, 0xaa, 0x54, 0xaf, 0x4c, 0x5c, 0xed, 0xe1, 0x69, 0x2e, 0x95, 0xdb, 0x34, 0xa0, 0x4d,
+	0x47, 0xde, 0xe2, 0xc2, 0x19, 0xbc, 0x44, 0x3d, 0x6d, 0x7e, 0x06, 0x0f, 0x3d, 0x8f, 0x24, 0xd3,
+	0x8b, 0xd4, 0x51, 0x1d, 0xba, 0x0f, 0x39, 0xc5, 0x39, 0xe2, 0xc4, 0xf9, 0x7b, 0xf0, 0x20, 0xd3,
+	0x33, 0xa9, 0x62, 0x9e, 0x24, 0xd8, 0x62, 0x7c, 0x41, 0xee, 0x10, 0xf8, 0xd2, 0x61, 0x93, 0x3f,
+	0xb6, 0x60, 0xe0, 0x7d, 0x8a, 0x97, 0xbc, 0xe7, 0xf6, 0xa0, 0xe9, 0x67, 0xba, 0x64, 0x26, 0xac,
+	0x88, 0x1b, 0x56, 0xae, 0xff, 0xec, 0x39, 0xc5, 0x55, 0x6d, 0x7b, 0x02, 0xfb, 0xee, 0x52, 0x0d,
+	0x53, 0xe7, 0xec, 0x5d, 0xc2, 0x37, 0x96, 0xcf, 0x81, 0x15, 0xa5, 0xfe, 0xad, 0x48, 0x6c, 0xd3,
+	0xd6, 0x25, 0xcd, 0xbe, 0xd7, 0xd4, 0xd6, 0x93, 0x1b, 0x78, 0xb0, 0x15, 0xd6, 0xba, 0x95, 0xb7,
+	0x1a, 0xad, 0x3c, 0x84, 0x41, 0xe2, 0xd4, 0x9e, 0x5e, 0x25, 0x62, 0x56, 0xf2, 0xc4, 0x4a, 0x5d,
+	0x0f, 0x74, 0x27, 0x4d, 0x06, 0xd0, 0x7b, 0x95, 0x17, 0x76, 0x3d, 0xf9, 0x4b, 0x0b, 0x1e, 0xbb,
+	0x0f, 0xd0, 0x6b, 0xe1, 0xb5, 0x89, 0x84, 0x29, 0xb4, 0x32, 0x02, 0xb7, 0xa6, 0xd2, 0xd8, 0x52,
+	0x37, 0x46, 0xab, 0x2d, 0x35, 0x75, 0x53, 0x51, 0x1a, 0x3c, 0xd3, 0x7f, 0xcc, 0x8b, 0x48, 0x8d,
+	0x97, 0xc9, 0xbc, 0x2a, 0x0b, 0x5c, 0x63, 0xf2, 0x65, 0x5c, 0xcd, 0x16, 0x7c, 0x56, 0x4d, 0xd4,
+	0x5a, 0xc6, 0xa6, 0xa3, 0x8d, 0xaf, 0x8b, 0xb6, 0x36, 0x78, 0x72, 0x15, 0x39, 0xdf, 0x8d, 0xbd,
+	0x88, 0xd5, 0x8c, 0x4e, 0xf2, 0xdd, 0xf8, 0x4e, 0xac, 0x27, 0xff, 0x6c, 0xc1, 0x8e, 0xe3, 0x7d,
+	0xc5, 0x4b, 0x9e, 0x1b, 0xec, 0x2c, 0xf4, 0x14, 0x68, 0x38, 0x67, 0x88, 0x00, 0x0d, 0xda, 0x53,
+	0x80, 0x19, 0x5e, 0x2f, 0x4e, 0x85, 0x49, 0x88, 0xf6, 0xe8, 0x6c, 0xcf, 0x15, 0x4d, 0xfd, 0x48,
+	0x8a, 0x82, 0x59, 0xb5, 0x64, 0x3f, 0x82, 0x91, 0xab, 0x9e, 0x58, 0xaa, 0xa9, 0xa6, 0x0b, 0x8d,
+	0xce, 0xf6, 0x9b, 0x55, 0x86, 0x65, 0x1b, 0x41, 0x5a, 0xaf, 0xd9, 0x29, 0x04, 0xcb, 0x34, 0xbd,
+	0x73, 0x1b, 0xba, 0xb4, 0xe1, 0xa1, 0xdb, 0xd0, 0xe8, 0x30, 0xd1, 0x10, 0x6d, 0xc8, 0xfe, 0xc7,
+	0xb0, 0x23, 0x54, 0x52, 0xae, 0x0b, 0xeb, 0xb6, 0xf4, 0x9a, 0x5b, 0x5e, 0x39, 0x0d, 0x6d, 0x19,
+	0x89, 0x8d, 0x30, 0xf9, 0x73, 0x0b, 0x76, 0x23, 0x81, 0x8f, 0x8f, 0xd7, 0xe6, 0x63, 0x2e, 0xfe,
+	0x19, 0xc0, 0x7c, 0xf3, 0x92, 0x72, 0xf1, 0x6a, 0x20, 0xdb, 0xac, 0x3b, 0xff, 0x3b, 0xeb, 0xee,
+	0x47, 0xb1, 0xfe, 0x43, 0x0b, 0x76, 0x5f, 0xd3, 0xeb, 0xe2, 0xe3, 0x58, 0x3f, 0x81, 0x60, 0x6a,
+	0x62, 0xff, 0x3a, 0x71, 0xa4, 0x87, 0x53, 0xe3, 0x4e, 0xa8, 0xdf, 0xad, 0x9d, 0xc6, 0xbb, 0xf5,
+	0xff, 0xa3, 0xa5, 0x61, 0x14, 0x89, 0x4c, 0x70, 0x23, 0xe8, 0x6e, 0xdf, 0x79, 0xc2, 0x4f, 0xbe,
+	0x06, 0x76, 0xcd, 0x97, 0xe2, 0xad, 0xfe, 0x32, 0xe3, 0x2a, 0x11, 0x1f, 0xe3, 0x8a, 0x31, 0x0c,
+	0x13, 0x9d, 0x17, 0xa5, 0x30, 0x86, 0xbe, 0x3e, 0x8c, 0x6a, 0x79, 0x22, 0xe0, 0xa0, 0x79, 0x5c,
+	0x5d, 0xb9, 0x47, 0x30, 0xd0, 0xc6, 0x39, 0xc2, 0xdf, 0x44, 0x9b, 0x2a, 0x7a, 0xa5, 0xbb, 0xb0,
+	0xd3, 0xb6, 0x9b, 0x6e, 0x6a, 0xb8, 0x22, 0x1a, 0x95, 0x1b, 0x61, 0xf2, 0x02, 0x0e, 0xae, 0x4a,
+	0x7d, 0x2b, 0x2e, 0xf1, 0xdd, 0x8a, 0xc8, 0x55, 0xc9, 0x73, 0xfe, 0x61, 0xde, 0x93, 0x3f, 0xb5,
+	0x21, 0xa8, 0x37, 0xb0, 0x67, 0xdb, 0x8c, 0xde, 0xfb, 0xcd, 0x8a, 0xa4, 0x63, 0x4f, 0xc3, 0xbe,
+	0x5d, 0xb1, 0xa7, 0x59, 0xff, 0x03, 0xd8, 0x93, 0x26, 0x5e, 0x88, 0xa9, 0x8c, 0xcd, 0xa2, 0xa0,
+	0xa1, 0xdc, 0x71, 0x6f, 0x50, 0x69, 0x6e, 0xc4, 0x54, 0x5e, 0x3b, 0x10, 0x5b, 0xb1, 0x34, 0x71,
+	0xb6, 0xcc, 0xe3, 0x82, 0x97, 0x56, 0x52, 0xf7, 0x73, 0xc3, 0x6d, 0x57, 0x9a, 0x5f, 0x2e, 0xf3,
+	0xab, 0x0a, 0xc5, 0xe7, 0x8a, 0x34, 0x71, 0x29, 0x78, 0xaa, 0x55, 0x56, 0x4d, 0x39, 0x90, 0x26,
+	0xf2, 0x08, 0xfb, 0x29, 0x1c, 0x15, 0xf3, 0xb5, 0x91, 0x09, 0xcf, 0x36, 0x87, 0x39, 0x6e, 0xae,
+	0x43, 0x3d, 0xae, 0xd4, 0xf5, 0xa1, 0x44, 0xf5, 0x27, 0x70, 0x44, 0x63, 0xd5, 0x58, 0x9e, 0x65,
+	0x22, 0x6d, 0xce, 0x2e, 0x37, 0xef, 0x0e, 0x70, 0xcc, 0x7a, 0x6d, 0x3d, 0xc0, 0x26, 0x3f, 0x84,
+	0x9d, 0x57, 0xe6, 0x9d, 0xc4, 0x9f, 0x36, 0xe4, 0x8a, 0x0f, 0x7a, 0xf8, 0xf7, 0x70, 0x78, 0xae,
+	0x95, 0x12, 0x89, 0xad, 0xf6, 0x54, 0xb5, 0xb5, 0x55, 0xd4, 0xad, 0xff, 0x5e, 0xd4, 0x2f, 0x60,
+	0xc4, 0x93, 0x44, 0x18, 0x53, 0x65, 0x05, 0xbe, 0x29, 0x98, 0x2f, 0x9e, 0x06, 0x9f, 0x08, 0x9c,
+	0x19, 0x65, 0xc5, 0x39, 0x1c, 0xd5, 0xdf, 0xf5, 0x3c, 0xa4, 0x3b, 0x99, 0x9d, 0x54, 0x3f, 0xf0,
+	0x5a, 0xff, 0xf1, 0x24, 0x67, 0x30, 0xb9, 0x81, 0x51, 0xa3, 0x3a, 0xe9, 0x85, 0x5f, 0xfd, 0x92,
+	0x6b, 0xcb, 0xb4, 0x7e, 0xb2, 0xb5, 0x1b, 0x3f, 0x5b, 0xfd, 0x28, 0xe8, 0xd4, 0xa3, 0x00, 0x11,
+	0x9e, 0xcd, 0x7c, 0xb1, 0xe1, 0xf2, 0xec, 0xaf, 0x1d, 0x18, 0xb9, 0x36, 0xfd, 0x72, 0x86, 0x53,
+	0xf0, 0x17, 0xd5, 0x10, 0xf5, 0x33, 0x8e, 0xb1, 0x66, 0x2b, 0x77, 0x5e, 0x1b, 0x3f, 0x69, 0x62,
+	0xf7, 0x87, 0xe1, 0x17, 0x30, 0xac, 0xda, 0x2e, 0x3b, 0xa8, 0x72, 0xb7, 0xd9, 0x86, 0xc7, 0x23,
+	0x7f, 0x4b, 0x9c, 0xaa, 0x68, 0x5e, 0xf5, 0xbb, 0xca, 0x7c, 0xbb, 0xff, 0x6d, 0x9b, 0x5f, 0xc0,
+	0x4e, 0xb3, 0x90, 0x59, 0xe8, 0x9f, 0x7e, 0xdf, 0xea, 0x15, 0xe3, 0xf1, 0xb7, 0x35, 0x35, 0xc7,
+	0x9f, 0xc3, 0xee, 0x76, 0x9d, 0x32, 0x6f, 0xfd, 0xbe, 0xea, 0x1d, 0xfb, 0xf1, 0xb7, 0x31, 0xfe,
+	0x15, 0xec, 0xdf, 0xcf, 0x27, 0xf6, 0xa9, 0x33, 0x7a, 0x7f, 0x9e, 0x8d, 0x9f, 0x6e, 0x07, 0xf6,
+	0x7e, 0x1a, 0xbc, 0x84, 0x47, 0x17, 0xd2, 0x24, 0xf7, 0xcf, 0xfc, 0xf0, 0xae, 0x2d, 0xc7, 0xdc,
+	0xf6, 0xe9, 0x5f, 0x1a, 0x2f, 0xfe, 0x3d, 0x00, 0x1e, 0x7f, 0x94, 0x42, 0xe2, 0x10, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
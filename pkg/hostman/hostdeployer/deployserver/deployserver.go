@@ -64,9 +64,10 @@ func (*DeployerServer) DeployGuestFs(ctx context.Context, req *deployapi.DeployP
 	}()
 	log.Infof("********* Deploy guest fs on %s", req.DiskPath)
 	var disk = diskutils.GetIDisk(diskutils.DiskParams{
-		Hypervisor: req.GuestDesc.Hypervisor,
-		DiskPath:   req.DiskPath,
-		VddkInfo:   req.VddkInfo,
+		Hypervisor:  req.GuestDesc.Hypervisor,
+		DiskPath:    req.DiskPath,
+		VddkInfo:    req.VddkInfo,
+		EncryptInfo: req.EncryptInfo,
 	}, DeployOption.ImageDeployDriver)
 	if len(req.GuestDesc.Hypervisor) == 0 {
 		req.GuestDesc.Hypervisor = comapi.HYPERVISOR_KVM
@@ -107,9 +108,10 @@ func (*DeployerServer) ResizeFs(ctx context.Context, req *deployapi.ResizeFsPara
 	}()
 	log.Infof("********* Resize fs on %s", req.DiskPath)
 	var disk = diskutils.GetIDisk(diskutils.DiskParams{
-		Hypervisor: req.Hypervisor,
-		DiskPath:   req.DiskPath,
-		VddkInfo:   req.VddkInfo,
+		Hypervisor:  req.Hypervisor,
+		DiskPath:    req.DiskPath,
+		VddkInfo:    req.VddkInfo,
+		EncryptInfo: req.EncryptInfo,
 	}, DeployOption.ImageDeployDriver)
 	defer disk.Disconnect()
 	if err := disk.Connect(); err != nil {
@@ -143,7 +145,7 @@ func (*DeployerServer) ResizeFs(ctx context.Context, req *deployapi.ResizeFsPara
 
 func (*DeployerServer) FormatFs(ctx context.Context, req *deployapi.FormatFsParams) (*deployapi.Empty, error) {
 	log.Infof("********* Format fs on %s", req.DiskPath)
-	gd := diskutils.NewKVMGuestDisk(req.DiskPath, DeployOption.ImageDeployDriver)
+	gd := diskutils.NewKVMGuestDisk(req.DiskPath, DeployOption.ImageDeployDriver, req.EncryptInfo)
 	defer gd.Disconnect()
 	if err := gd.Connect(); err == nil {
 		if err := gd.MakePartition(req.FsFormat); err == nil {
@@ -163,7 +165,7 @@ func (*DeployerServer) FormatFs(ctx context.Context, req *deployapi.FormatFsPara
 func (*DeployerServer) SaveToGlance(ctx context.Context, req *deployapi.SaveToGlanceParams) (*deployapi.SaveToGlanceResponse, error) {
 	log.Infof("********* %s save to glance", req.DiskPath)
 	var (
-		kvmDisk = diskutils.NewKVMGuestDisk(req.DiskPath, DeployOption.ImageDeployDriver)
+		kvmDisk = diskutils.NewKVMGuestDisk(req.DiskPath, DeployOption.ImageDeployDriver, nil)
 		osInfo  string
 		relInfo *deployapi.ReleaseInfo
 	)
@@ -224,7 +226,7 @@ func (*DeployerServer) getImageInfo(kvmDisk *diskutils.SKVMGuestDisk) (*deployap
 
 func (s *DeployerServer) ProbeImageInfo(ctx context.Context, req *deployapi.ProbeImageInfoPramas) (*deployapi.ImageInfo, error) {
 	log.Infof("********* %s probe image info", req.DiskPath)
-	kvmDisk := diskutils.NewKVMGuestDisk(req.DiskPath, DeployOption.ImageDeployDriver)
+	kvmDisk := diskutils.NewKVMGuestDisk(req.DiskPath, DeployOption.ImageDeployDriver, nil)
 	defer kvmDisk.Disconnect()
 	if err := kvmDisk.Connect(); err != nil {
 		log.Infof("Failed to connect kvm disk %s: %s", req.DiskPath, err)
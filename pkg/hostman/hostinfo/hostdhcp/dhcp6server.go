@@ -0,0 +1,165 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostdhcp
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/ipv6"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/types"
+	guestman "yunion.io/x/onecloud/pkg/hostman/guestman/types"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/util/dhcp"
+	"yunion.io/x/onecloud/pkg/util/netutils2"
+)
+
+// SGuestDHCP6Server serves DHCPv6 and router advertisement for guests
+// with ipv6 address on the bridge
+type SGuestDHCP6Server struct {
+	conn *ipv6.PacketConn
+	ra   *SGuestRAServer
+
+	iface string
+	ifi   *net.Interface
+}
+
+func NewGuestDHCP6Server(iface string) (*SGuestDHCP6Server, error) {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, errors.Wrapf(err, "InterfaceByName %s", iface)
+	}
+	conn, err := dhcp.ListenPacketOnInterface(iface, "udp6", fmt.Sprintf("[::]:%d", dhcp.DHCPV6_SERVER_PORT))
+	if err != nil {
+		return nil, errors.Wrap(err, "listen dhcpv6")
+	}
+	pconn := ipv6.NewPacketConn(conn)
+	if err := pconn.JoinGroup(ifi, &net.UDPAddr{IP: dhcp.DHCPV6_MULTICAST_ADDR}); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "join dhcpv6 multicast group")
+	}
+	ra, err := NewGuestRAServer(iface, ifi)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "NewGuestRAServer")
+	}
+	return &SGuestDHCP6Server{
+		conn:  pconn,
+		ra:    ra,
+		iface: iface,
+		ifi:   ifi,
+	}, nil
+}
+
+func (s *SGuestDHCP6Server) Start() {
+	log.Infof("SGuestDHCP6Server starting ...")
+	go func() {
+		err := s.serve()
+		if err != nil {
+			log.Errorf("DHCPv6 serve error: %s", err)
+		}
+	}()
+	s.ra.Start()
+}
+
+func (s *SGuestDHCP6Server) serve() error {
+	buf := make([]byte, 1500)
+	for {
+		n, _, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return errors.Wrap(err, "ReadFrom")
+		}
+		pkt, err := dhcp.Unmarshal6(buf[:n])
+		if err != nil {
+			log.Debugf("invalid DHCPv6 packet from %s: %s", addr, err)
+			continue
+		}
+		udpAddr, ok := addr.(*net.UDPAddr)
+		if !ok {
+			continue
+		}
+		resp, err := s.serveDHCP6(pkt, udpAddr)
+		if err != nil {
+			log.Errorf("serve DHCPv6 from %s: %s", addr, err)
+			continue
+		}
+		if resp == nil {
+			continue
+		}
+		if _, err := s.conn.WriteTo(resp.Marshal(), nil, addr); err != nil {
+			log.Errorf("send DHCPv6 reply to %s: %s", addr, err)
+		}
+	}
+}
+
+// getGuestNic6 returns the desc of the guest nic with ipv6 address by
+// any of the mac addresses
+func getGuestNic6(iface string, macs ...net.HardwareAddr) *types.SServerNic {
+	for _, mac := range macs {
+		if mac == nil {
+			continue
+		}
+		_, guestNic := guestman.GuestDescGetter.GetGuestNicDesc(mac.String(), "", "", iface, false)
+		if guestNic == nil {
+			_, guestNic = guestman.GuestDescGetter.GetGuestNicDesc(mac.String(), "", "", iface, true)
+		}
+		if guestNic == nil || jsonutils.QueryBoolean(guestNic, "virtual", false) {
+			continue
+		}
+		nicdesc := new(types.SServerNic)
+		if err := guestNic.Unmarshal(nicdesc); err != nil {
+			log.Errorln(err)
+			continue
+		}
+		if len(nicdesc.Ip6) > 0 {
+			return nicdesc
+		}
+	}
+	return nil
+}
+
+func (s *SGuestDHCP6Server) serveDHCP6(pkt *dhcp.Packet6, addr *net.UDPAddr) (*dhcp.Packet6, error) {
+	// the link local address is mostly derived from the nic mac,
+	// fallback to the mac in client DUID
+	nicdesc := getGuestNic6(s.iface, netutils2.EUI64ToMac(addr.IP), pkt.ClientMac())
+	if nicdesc == nil {
+		return nil, nil
+	}
+	conf := &dhcp.ResponseConfig6{
+		ServerDUID: dhcp.MakeDUIDLL(s.ifi.HardwareAddr),
+		Domain:     nicdesc.Domain,
+		LeaseTime:  time.Duration(options.HostOptions.DhcpLeaseTime) * time.Second,
+	}
+	// in slaac mode the address is configured by router advertisement,
+	// only stateless configuration is served
+	if nicdesc.Ip6AllocMode != api.IPV6_ALLOC_MODE_SLAAC {
+		conf.ClientIP = net.ParseIP(nicdesc.Ip6)
+	}
+	for _, dns := range strings.Split(nicdesc.Dns6, ",") {
+		if ip := net.ParseIP(dns); ip != nil {
+			conf.DNSServers = append(conf.DNSServers, ip)
+		}
+	}
+	log.Infof("Make DHCPv6 Reply %s TO %s", nicdesc.Ip6, nicdesc.Mac)
+	return dhcp.MakeReplyPacket6(pkt, conf)
+}
@@ -0,0 +1,211 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostdhcp
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+
+	"golang.org/x/net/ipv6"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/types"
+	"yunion.io/x/onecloud/pkg/util/dhcp"
+	"yunion.io/x/onecloud/pkg/util/netutils2"
+)
+
+// Neighbor discovery options, RFC 4861 and RFC 8106
+const (
+	ndOptSourceLinkAddr = 1
+	ndOptPrefixInfo     = 3
+	ndOptRDNSS          = 25
+
+	raFlagManaged    = 0x80
+	raFlagOther      = 0x40
+	pioFlagOnLink    = 0x80
+	pioFlagAutonomus = 0x40
+
+	infiniteLifetime = 0xffffffff
+)
+
+var ipv6AllRouters = net.ParseIP("ff02::2")
+
+// SGuestRAServer answers router solicitations of guests with ipv6 address,
+// the advertisements are unicast as a bridge may carry networks with
+// different alloc modes. Router lifetime is always 0, the host is not a
+// router, the default route is learnt from advertisements of the gateway.
+type SGuestRAServer struct {
+	conn *ipv6.PacketConn
+
+	iface string
+	ifi   *net.Interface
+}
+
+func NewGuestRAServer(iface string, ifi *net.Interface) (*SGuestRAServer, error) {
+	conn, err := dhcp.ListenPacketOnInterface(iface, "ip6:ipv6-icmp", "::")
+	if err != nil {
+		return nil, errors.Wrap(err, "listen icmpv6")
+	}
+	pconn := ipv6.NewPacketConn(conn)
+	var filter ipv6.ICMPFilter
+	filter.SetAll(true)
+	filter.Accept(ipv6.ICMPTypeRouterSolicitation)
+	if err := pconn.SetICMPFilter(&filter); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "SetICMPFilter")
+	}
+	// neighbor discovery messages must be sent with hop limit 255
+	if err := pconn.SetHopLimit(255); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "SetHopLimit")
+	}
+	if err := pconn.SetMulticastHopLimit(255); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "SetMulticastHopLimit")
+	}
+	if err := pconn.JoinGroup(ifi, &net.IPAddr{IP: ipv6AllRouters}); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "join all routers multicast group")
+	}
+	return &SGuestRAServer{
+		conn:  pconn,
+		iface: iface,
+		ifi:   ifi,
+	}, nil
+}
+
+func (s *SGuestRAServer) Start() {
+	log.Infof("SGuestRAServer starting ...")
+	go func() {
+		err := s.serve()
+		if err != nil {
+			log.Errorf("RA serve error: %s", err)
+		}
+	}()
+}
+
+func (s *SGuestRAServer) serve() error {
+	buf := make([]byte, 1500)
+	for {
+		n, _, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return errors.Wrap(err, "ReadFrom")
+		}
+		ipAddr, ok := addr.(*net.IPAddr)
+		if !ok || n < 8 || buf[0] != byte(ipv6.ICMPTypeRouterSolicitation) {
+			continue
+		}
+		srcMac := getSourceLinkAddr(buf[8:n])
+		nicdesc := getGuestNic6(s.iface, srcMac, netutils2.EUI64ToMac(ipAddr.IP))
+		if nicdesc == nil {
+			continue
+		}
+		ra, err := s.makeRouterAdvertisement(nicdesc)
+		if err != nil {
+			log.Errorf("make router advertisement for %s: %s", nicdesc.Mac, err)
+			continue
+		}
+		dst := &net.IPAddr{IP: ipAddr.IP, Zone: s.iface}
+		if ipAddr.IP.IsUnspecified() {
+			dst.IP = net.IPv6linklocalallnodes
+		}
+		log.Infof("Make RA %s/%d TO %s", nicdesc.Ip6, nicdesc.Masklen6, nicdesc.Mac)
+		if _, err := s.conn.WriteTo(ra, &ipv6.ControlMessage{IfIndex: s.ifi.Index}, dst); err != nil {
+			log.Errorf("send router advertisement to %s: %s", dst, err)
+		}
+	}
+}
+
+// getSourceLinkAddr returns the source link-layer address option of
+// router solicitation
+func getSourceLinkAddr(opts []byte) net.HardwareAddr {
+	// skip the reserved field
+	if len(opts) < 4 {
+		return nil
+	}
+	opts = opts[4:]
+	for len(opts) >= 8 {
+		length := int(opts[1]) * 8
+		if length == 0 || length > len(opts) {
+			return nil
+		}
+		if opts[0] == ndOptSourceLinkAddr && length == 8 {
+			return net.HardwareAddr(opts[2:8])
+		}
+		opts = opts[length:]
+	}
+	return nil
+}
+
+func (s *SGuestRAServer) makeRouterAdvertisement(nicdesc *types.SServerNic) ([]byte, error) {
+	ip6, err := netutils2.NewIPV6Addr(nicdesc.Ip6)
+	if err != nil {
+		return nil, err
+	}
+	isSlaac := nicdesc.Ip6AllocMode == api.IPV6_ALLOC_MODE_SLAAC
+
+	// checksum is filled by kernel
+	ra := make([]byte, 16)
+	ra[0] = byte(ipv6.ICMPTypeRouterAdvertisement)
+	// cur hop limit
+	ra[4] = 64
+	// dns and domain are always served by dhcpv6
+	ra[5] = raFlagOther
+	if !isSlaac {
+		ra[5] |= raFlagManaged
+	}
+
+	slla := make([]byte, 8)
+	slla[0] = ndOptSourceLinkAddr
+	slla[1] = 1
+	copy(slla[2:], s.ifi.HardwareAddr)
+	ra = append(ra, slla...)
+
+	pio := make([]byte, 32)
+	pio[0] = ndOptPrefixInfo
+	pio[1] = 4
+	pio[2] = byte(nicdesc.Masklen6)
+	pio[3] = pioFlagOnLink
+	if isSlaac {
+		pio[3] |= pioFlagAutonomus
+	}
+	binary.BigEndian.PutUint32(pio[4:8], infiniteLifetime)
+	binary.BigEndian.PutUint32(pio[8:12], infiniteLifetime)
+	prefix := ip6.NetAddr(int8(nicdesc.Masklen6))
+	copy(pio[16:32], prefix[:])
+	ra = append(ra, pio...)
+
+	dnsList := make([]net.IP, 0)
+	for _, dns := range strings.Split(nicdesc.Dns6, ",") {
+		if ip := net.ParseIP(dns); ip != nil {
+			dnsList = append(dnsList, ip.To16())
+		}
+	}
+	if len(dnsList) > 0 {
+		rdnss := make([]byte, 8, 8+16*len(dnsList))
+		rdnss[0] = ndOptRDNSS
+		rdnss[1] = byte(1 + 2*len(dnsList))
+		binary.BigEndian.PutUint32(rdnss[4:8], infiniteLifetime)
+		for _, ip := range dnsList {
+			rdnss = append(rdnss, ip...)
+		}
+		ra = append(ra, rdnss...)
+	}
+	return ra, nil
+}
@@ -118,6 +118,9 @@ func (h *SHostInfo) GetBridgeDev(bridge string) hostbridge.IBridgeDriver {
 func (h *SHostInfo) StartDHCPServer() {
 	for _, nic := range h.Nics {
 		nic.dhcpServer.Start()
+		if nic.dhcp6Server != nil {
+			nic.dhcp6Server.Start()
+		}
 	}
 }
 
@@ -372,7 +375,7 @@ func (h *SHostInfo) prepareEnv() error {
 	case "disable":
 		h.DisableHugepages()
 	case "native":
-		size, err := h.Mem.GetHugepageTotal()
+		size, err := h.getHugepageTotalMb()
 		if err != nil {
 			return err
 		}
@@ -410,6 +413,7 @@ func (h *SHostInfo) detectHostInfo() error {
 	}
 
 	h.detectStorageSystem()
+	h.detectNumaTopology()
 
 	system_service.Init()
 	if options.HostOptions.CheckSystemServices {
@@ -492,13 +496,33 @@ func (h *SHostInfo) EnableTransparentHugepages() {
 
 func (h *SHostInfo) GetMemory() (int, error) {
 	if options.HostOptions.HugepagesOption == "native" {
-		return h.Mem.GetHugepageTotal()
+		return h.getHugepageTotalMb()
 	}
 	return h.Mem.Total, nil // - options.reserved_memory
 }
 
+// GetHugepageSizeMb returns size of hugepages backing guest memory
+func (h *SHostInfo) GetHugepageSizeMb() int {
+	if options.HostOptions.HugepageSizeMb > 0 {
+		return options.HostOptions.HugepageSizeMb
+	}
+	return h.Mem.GetHugepagesizeMb()
+}
+
+func (h *SHostInfo) getHugepageNrPath() string {
+	return fmt.Sprintf("/sys/kernel/mm/hugepages/hugepages-%dkB/nr_hugepages", h.GetHugepageSizeMb()*1024)
+}
+
+func (h *SHostInfo) getHugepageTotalMb() (int, error) {
+	nr, err := h.getCurrentHugepageNr()
+	if err != nil {
+		return 0, err
+	}
+	return int(nr) * h.GetHugepageSizeMb(), nil
+}
+
 func (h *SHostInfo) getCurrentHugepageNr() (int64, error) {
-	nrStr, err := fileutils2.FileGetContents("/proc/sys/vm/nr_hugepages")
+	nrStr, err := fileutils2.FileGetContents(h.getHugepageNrPath())
 	if err != nil {
 		return 0, errors.Wrap(err, "file get content nr hugepages")
 	}
@@ -526,10 +550,10 @@ func (h *SHostInfo) EnableNativeHugepages() error {
 		return err
 	}
 	mem -= h.getReservedMem()
-	desiredNr := int64(mem/h.Mem.GetHugepagesizeMb() + 1)
+	desiredNr := int64(mem/h.GetHugepageSizeMb() + 1)
 	if nr < desiredNr {
 		err = timeutils2.CommandWithTimeout(1, "sh", "-c",
-			fmt.Sprintf("echo %d > /proc/sys/vm/nr_hugepages", desiredNr)).Run()
+			fmt.Sprintf("echo %d > %s", desiredNr, h.getHugepageNrPath())).Run()
 		if err != nil {
 			return err
 		}
@@ -540,7 +564,7 @@ func (h *SHostInfo) EnableNativeHugepages() error {
 	}
 	if currentNr < desiredNr {
 		err = timeutils2.CommandWithTimeout(1, "sh", "-c",
-			fmt.Sprintf("echo %d > /proc/sys/vm/nr_hugepages", nr)).Run()
+			fmt.Sprintf("echo %d > %s", nr, h.getHugepageNrPath())).Run()
 		return fmt.Errorf("no enough memory to resize hugepage, current nr %d, desired nr %d", currentNr, desiredNr)
 	}
 	return nil
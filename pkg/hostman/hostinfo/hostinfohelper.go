@@ -33,6 +33,7 @@ import (
 	"yunion.io/x/pkg/util/netutils"
 	"yunion.io/x/pkg/util/regutils"
 
+	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/types"
 	"yunion.io/x/onecloud/pkg/hostman/hostinfo/hostbridge"
 	"yunion.io/x/onecloud/pkg/hostman/hostinfo/hostdhcp"
@@ -237,9 +238,10 @@ type SNIC struct {
 	WireId  string
 	Mask    int
 
-	Bandwidth  int
-	BridgeDev  hostbridge.IBridgeDriver
-	dhcpServer *hostdhcp.SGuestDHCPServer
+	Bandwidth   int
+	BridgeDev   hostbridge.IBridgeDriver
+	dhcpServer  *hostdhcp.SGuestDHCPServer
+	dhcp6Server *hostdhcp.SGuestDHCP6Server
 }
 
 func (n *SNIC) EnableDHCPRelay() bool {
@@ -363,6 +365,13 @@ func NewNIC(desc string) (*SNIC, error) {
 	if err != nil {
 		return nil, err
 	}
+	if options.HostOptions.EnableDhcp6 {
+		// ipv6 may be disabled on the host, guests then only get ipv4 address
+		nic.dhcp6Server, err = hostdhcp.NewGuestDHCP6Server(nic.Bridge)
+		if err != nil {
+			log.Errorf("NewGuestDHCP6Server on %s: %v", nic.Bridge, err)
+		}
+	}
 	// dhcp server start after guest manager init
 	return nic, nil
 }
@@ -381,6 +390,8 @@ type SSysInfo struct {
 	CpuMicrocode   string `json:"cpu_microcode"`
 
 	StorageType string `json:"storage_type"`
+
+	Numa *api.HostNumaTopology `json:"numa,omitempty"`
 }
 
 func StartDetachStorages(hs []jsonutils.JSONObject) {
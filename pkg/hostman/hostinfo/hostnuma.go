@@ -0,0 +1,133 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostinfo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/sysutils"
+)
+
+const (
+	SYS_NODE_PATH = "/sys/devices/system/node"
+)
+
+func readIntFile(filename string) (int, error) {
+	content, err := fileutils2.FileGetContents(filename)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(content))
+}
+
+func detectNumaNodeHugepages(nodePath string) []api.HostNumaHugepages {
+	ret := make([]api.HostNumaHugepages, 0)
+	hugepagesPath := path.Join(nodePath, "hugepages")
+	files, err := ioutil.ReadDir(hugepagesPath)
+	if err != nil {
+		return ret
+	}
+	for _, f := range files {
+		// hugepages-2048kB
+		var sizeKb int
+		if _, err := fmt.Sscanf(f.Name(), "hugepages-%dkB", &sizeKb); err != nil {
+			continue
+		}
+		total, err := readIntFile(path.Join(hugepagesPath, f.Name(), "nr_hugepages"))
+		if err != nil {
+			continue
+		}
+		free, err := readIntFile(path.Join(hugepagesPath, f.Name(), "free_hugepages"))
+		if err != nil {
+			continue
+		}
+		ret = append(ret, api.HostNumaHugepages{SizeKb: sizeKb, Total: total, Free: free})
+	}
+	return ret
+}
+
+// DetectNumaTopology reads numa nodes of host from sysfs
+func DetectNumaTopology() (*api.HostNumaTopology, error) {
+	files, err := ioutil.ReadDir(SYS_NODE_PATH)
+	if err != nil {
+		return nil, errors.Wrap(err, "read numa nodes")
+	}
+	topo := &api.HostNumaTopology{Nodes: make([]api.HostNumaNode, 0)}
+	for _, f := range files {
+		var nodeId int
+		if _, err := fmt.Sscanf(f.Name(), "node%d", &nodeId); err != nil {
+			continue
+		}
+		nodePath := path.Join(SYS_NODE_PATH, f.Name())
+		cpuList, err := fileutils2.FileGetContents(path.Join(nodePath, "cpulist"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "read cpulist of node %d", nodeId)
+		}
+		cpus, err := sysutils.ParseCpuList(cpuList)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse cpulist of node %d", nodeId)
+		}
+		meminfo, err := fileutils2.FileGetContents(path.Join(nodePath, "meminfo"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "read meminfo of node %d", nodeId)
+		}
+		total, free := sysutils.ParseNodeMeminfo(strings.Split(meminfo, "\n"))
+		topo.Nodes = append(topo.Nodes, api.HostNumaNode{
+			NodeId:    nodeId,
+			Cpus:      cpus,
+			MemSizeMb: total,
+			MemFreeMb: free,
+			Hugepages: detectNumaNodeHugepages(nodePath),
+		})
+	}
+	if len(topo.Nodes) == 0 {
+		return nil, errors.Wrap(errors.ErrNotFound, "no numa node")
+	}
+	sort.Slice(topo.Nodes, func(i, j int) bool {
+		return topo.Nodes[i].NodeId < topo.Nodes[j].NodeId
+	})
+	return topo, nil
+}
+
+func (h *SHostInfo) detectNumaTopology() {
+	topo, err := DetectNumaTopology()
+	if err != nil {
+		log.Errorf("detect numa topology: %v", err)
+		return
+	}
+	h.sysinfo.Numa = topo
+}
+
+// GetNumaTopology returns numa nodes with current free memory
+func (h *SHostInfo) GetNumaTopology() (*api.HostNumaTopology, error) {
+	topo, err := DetectNumaTopology()
+	if err != nil {
+		return nil, err
+	}
+	if h.IsHugepagesEnabled() {
+		topo.HugepageSizeKb = h.GetHugepageSizeMb() * 1024
+	}
+	return topo, nil
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"time"
 
+	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 
 	"yunion.io/x/onecloud/pkg/hostman/hostutils"
@@ -81,8 +82,13 @@ func (p *SHostPingTask) Start() {
 }
 
 func (p *SHostPingTask) ping(div int, hostId string) error {
+	// free memory and hugepages of numa nodes change as guests come and go
+	var data jsonutils.JSONObject
+	if numa, err := Instance().GetNumaTopology(); err == nil {
+		data = jsonutils.Marshal(map[string]interface{}{"numa": numa})
+	}
 	res, err := modules.Hosts.PerformAction(hostutils.GetComputeSession(context.Background()),
-		hostId, "ping", nil)
+		hostId, "ping", data)
 	if err != nil {
 		return err
 	} else {
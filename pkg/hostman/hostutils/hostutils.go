@@ -22,6 +22,7 @@ import (
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 
+	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/appctx"
 	"yunion.io/x/onecloud/pkg/appsrv"
 	"yunion.io/x/onecloud/pkg/cloudcommon/workmanager"
@@ -42,6 +43,8 @@ type IHost interface {
 	GetMasterIp() string
 	GetCpuArchitecture() string
 	IsHugepagesEnabled() bool
+	GetHugepageSizeMb() int
+	GetNumaTopology() (*api.HostNumaTopology, error)
 
 	IsKvmSupport() bool
 	IsNestedVirtualization() bool
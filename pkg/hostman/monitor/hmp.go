@@ -384,6 +384,10 @@ func (m *HmpMonitor) ReloadDiskBlkdev(device, path string, callback StringCallba
 	m.Query(fmt.Sprintf("reload_disk_snapshot_blkdev -n %s %s", device, path), callback)
 }
 
+func (m *HmpMonitor) SnapshotEncryptedBlkdev(device, path, secretId string, callback StringCallback) {
+	callback("snapshot of encrypted blkdev is not supported by hmp")
+}
+
 func (m *HmpMonitor) DriveMirror(callback StringCallback, drive, target, syncMode string, unmap, blockReplication bool) {
 	cmd := "drive_mirror -n"
 	if blockReplication {
@@ -396,6 +400,27 @@ func (m *HmpMonitor) DriveMirror(callback StringCallback, drive, target, syncMod
 	m.Query(cmd, callback)
 }
 
+func (m *HmpMonitor) DriveBackup(drive, target, syncMode, bitmap string, clearBitmap bool, callback StringCallback) {
+	if syncMode == "incremental" || clearBitmap {
+		go callback("dirty bitmap is not supported by hmp monitor")
+		return
+	}
+	cmd := "drive_backup -n"
+	if syncMode == "full" {
+		cmd += " -f"
+	}
+	cmd += fmt.Sprintf(" %s %s qcow2", drive, target)
+	m.Query(cmd, callback)
+}
+
+func (m *HmpMonitor) BlockDirtyBitmapAdd(node, name string, persistent bool, callback StringCallback) {
+	go callback("dirty bitmap is not supported by hmp monitor")
+}
+
+func (m *HmpMonitor) BlockDirtyBitmapRemove(node, name string, callback StringCallback) {
+	go callback("dirty bitmap is not supported by hmp monitor")
+}
+
 func (m *HmpMonitor) BlockStream(drive string, callback StringCallback) {
 	var (
 		speed = 100 // limit 100 MB/s
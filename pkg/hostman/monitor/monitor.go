@@ -61,12 +61,17 @@ type Monitor interface {
 
 	BlockStream(drive string, callback StringCallback)
 	DriveMirror(callback StringCallback, drive, target, syncMode string, unmap, blockReplication bool)
+	DriveBackup(drive, target, syncMode, bitmap string, clearBitmap bool, callback StringCallback)
+
+	BlockDirtyBitmapAdd(node, name string, persistent bool, callback StringCallback)
+	BlockDirtyBitmapRemove(node, name string, callback StringCallback)
 
 	MigrateSetCapability(capability, state string, callback StringCallback)
 	Migrate(destStr string, copyIncremental, copyFull bool, callback StringCallback)
 	GetMigrateStatus(callback StringCallback)
 
 	ReloadDiskBlkdev(device, path string, callback StringCallback)
+	SnapshotEncryptedBlkdev(device, path, secretId string, callback StringCallback)
 	SetVncPassword(proto, password string, callback StringCallback)
 	StartNbdServer(port int, exportAllDevice, writable bool, callback StringCallback)
 
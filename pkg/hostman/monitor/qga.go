@@ -0,0 +1,502 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"yunion.io/x/log"
+)
+
+// https://qemu.readthedocs.io/en/latest/interop/qemu-ga-ref.html
+/*
+Guest agent speaks the same json protocol as QMP, but without greeting
+message and capabilities negotiation.  Since a previous client may leave
+partial data in the channel, every new connection must be synchronized by
+guest-sync-delimited, whose response is prefixed by a 0xFF sentinel byte.
+*/
+
+const (
+	QGA_DEFAULT_TIMEOUT = 10 * time.Second
+	QGA_SYNC_TIMEOUT    = 3 * time.Second
+
+	QGA_FILE_READ_COUNT = 48 * 1024
+
+	qgaSentinel = 0xFF
+)
+
+type GuestAgent struct {
+	id            string
+	qgaSocketPath string
+
+	mutex   *sync.Mutex
+	rwc     net.Conn
+	reader  *bufio.Reader
+	synced  bool
+	timeout time.Duration
+}
+
+type GuestExecStatus struct {
+	Exited       bool   `json:"exited"`
+	Exitcode     int    `json:"exitcode"`
+	Signal       int    `json:"signal"`
+	OutData      string `json:"out-data"`
+	ErrData      string `json:"err-data"`
+	OutTruncated bool   `json:"out-truncated"`
+	ErrTruncated bool   `json:"err-truncated"`
+}
+
+type GuestIpAddress struct {
+	IpAddressType string `json:"ip-address-type"`
+	IpAddress     string `json:"ip-address"`
+	Prefix        int    `json:"prefix"`
+}
+
+type GuestNetworkInterface struct {
+	Name            string           `json:"name"`
+	HardwareAddress string           `json:"hardware-address"`
+	IpAddresses     []GuestIpAddress `json:"ip-addresses"`
+}
+
+type GuestOsInfo struct {
+	Id            string `json:"id"`
+	Name          string `json:"name"`
+	PrettyName    string `json:"pretty-name"`
+	Version       string `json:"version"`
+	VersionId     string `json:"version-id"`
+	KernelRelease string `json:"kernel-release"`
+	KernelVersion string `json:"kernel-version"`
+	Machine       string `json:"machine"`
+}
+
+type guestFileRead struct {
+	Count  int    `json:"count"`
+	BufB64 string `json:"buf-b64"`
+	Eof    bool   `json:"eof"`
+}
+
+type qgaResponse struct {
+	Return json.RawMessage `json:"return"`
+	Error  *Error          `json:"error"`
+}
+
+func NewGuestAgent(id, qgaSocketPath string) *GuestAgent {
+	return &GuestAgent{
+		id:            id,
+		qgaSocketPath: qgaSocketPath,
+		mutex:         &sync.Mutex{},
+		timeout:       QGA_DEFAULT_TIMEOUT,
+	}
+}
+
+func (qga *GuestAgent) connect() error {
+	conn, err := net.DialTimeout("unix", qga.qgaSocketPath, QGA_SYNC_TIMEOUT)
+	if err != nil {
+		return errors.Wrapf(err, "connect qga socket %s", qga.qgaSocketPath)
+	}
+	qga.rwc = conn
+	qga.reader = bufio.NewReader(conn)
+	qga.synced = false
+	return nil
+}
+
+// Close drops the connection, next command will reconnect and resync
+func (qga *GuestAgent) Close() {
+	qga.mutex.Lock()
+	defer qga.mutex.Unlock()
+	qga.close()
+}
+
+func (qga *GuestAgent) close() {
+	if qga.rwc != nil {
+		qga.rwc.Close()
+		qga.rwc = nil
+		qga.reader = nil
+	}
+	qga.synced = false
+}
+
+// isQgaSensitiveCommand tells whether arguments of the command may carry
+// passwords or file contents, which must not be logged
+func isQgaSensitiveCommand(execute string) bool {
+	switch execute {
+	case "guest-set-user-password", "guest-exec", "guest-file-write":
+		return true
+	}
+	return false
+}
+
+func (qga *GuestAgent) write(cmd *Command) error {
+	c, err := json.Marshal(cmd)
+	if err != nil {
+		return errors.Wrap(err, "marshal command")
+	}
+	if isQgaSensitiveCommand(cmd.Execute) {
+		log.Debugf("QGA %s Write: %s (arguments omitted)", qga.id, cmd.Execute)
+	} else {
+		log.Debugf("QGA %s Write: %s", qga.id, c)
+	}
+	length, index := len(c), 0
+	for index < length {
+		i, err := qga.rwc.Write(c[index:])
+		if err != nil {
+			return err
+		}
+		index += i
+	}
+	return nil
+}
+
+func (qga *GuestAgent) readResponse() ([]byte, error) {
+	line, err := qga.reader.ReadBytes('\n')
+	if err != nil {
+		return nil, errors.Wrap(err, "read response")
+	}
+	res := qgaResponse{}
+	if err := json.Unmarshal(line, &res); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal response %q", line)
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	return res.Return, nil
+}
+
+// sync flushes stale data in the channel with guest-sync-delimited
+func (qga *GuestAgent) sync(timeout time.Duration) error {
+	qga.rwc.SetDeadline(time.Now().Add(timeout))
+	// a leading sentinel byte resets the parser of guest agent
+	if _, err := qga.rwc.Write([]byte{qgaSentinel}); err != nil {
+		return errors.Wrap(err, "write sentinel")
+	}
+	id := rand.Int31()
+	cmd := &Command{
+		Execute: "guest-sync-delimited",
+		Args:    map[string]interface{}{"id": id},
+	}
+	if err := qga.write(cmd); err != nil {
+		return errors.Wrap(err, "write guest-sync-delimited")
+	}
+	for {
+		if _, err := qga.reader.ReadBytes(qgaSentinel); err != nil {
+			return errors.Wrap(err, "wait sentinel")
+		}
+		ret, err := qga.readResponse()
+		if err != nil {
+			return errors.Wrap(err, "guest-sync-delimited")
+		}
+		var rid int32
+		if err := json.Unmarshal(ret, &rid); err == nil && rid == id {
+			break
+		}
+	}
+	qga.synced = true
+	return nil
+}
+
+func (qga *GuestAgent) execCmd(cmd *Command, timeout time.Duration) ([]byte, error) {
+	qga.mutex.Lock()
+	defer qga.mutex.Unlock()
+	if qga.rwc == nil {
+		if err := qga.connect(); err != nil {
+			return nil, err
+		}
+	}
+	if !qga.synced {
+		if err := qga.sync(QGA_SYNC_TIMEOUT); err != nil {
+			qga.close()
+			return nil, errors.Wrap(err, "sync")
+		}
+	}
+	qga.rwc.SetDeadline(time.Now().Add(timeout))
+	if err := qga.write(cmd); err != nil {
+		qga.close()
+		return nil, errors.Wrapf(err, "write %s", cmd.Execute)
+	}
+	ret, err := qga.readResponse()
+	if err != nil {
+		if _, ok := errors.Cause(err).(*Error); !ok {
+			// channel state is unknown after io error or timeout
+			qga.close()
+		}
+		return nil, errors.Wrap(err, cmd.Execute)
+	}
+	return ret, nil
+}
+
+func (qga *GuestAgent) execCmdUnmarshal(cmd *Command, timeout time.Duration, val interface{}) error {
+	ret, err := qga.execCmd(cmd, timeout)
+	if err != nil {
+		return err
+	}
+	if val == nil {
+		return nil
+	}
+	if err := json.Unmarshal(ret, val); err != nil {
+		return errors.Wrapf(err, "unmarshal %s result %q", cmd.Execute, ret)
+	}
+	return nil
+}
+
+func (qga *GuestAgent) SetTimeout(timeout time.Duration) {
+	qga.timeout = timeout
+}
+
+// GuestSync checks the guest agent is responsive in timeout, resynchronizing
+// the channel
+func (qga *GuestAgent) GuestSync(timeout time.Duration) error {
+	qga.mutex.Lock()
+	defer qga.mutex.Unlock()
+	qga.close()
+	if err := qga.connect(); err != nil {
+		return err
+	}
+	if err := qga.sync(timeout); err != nil {
+		qga.close()
+		return err
+	}
+	return nil
+}
+
+func (qga *GuestAgent) GuestPing(timeout time.Duration) error {
+	_, err := qga.execCmd(&Command{Execute: "guest-ping"}, timeout)
+	return err
+}
+
+func (qga *GuestAgent) GuestInfo() (map[string]interface{}, error) {
+	info := map[string]interface{}{}
+	err := qga.execCmdUnmarshal(&Command{Execute: "guest-info"}, qga.timeout, &info)
+	return info, err
+}
+
+// GuestExec starts path inside guest and returns its pid
+func (qga *GuestAgent) GuestExec(path string, args []string, env []string, input string, captureOutput bool) (int, error) {
+	params := map[string]interface{}{
+		"path":           path,
+		"capture-output": captureOutput,
+	}
+	if len(args) > 0 {
+		params["arg"] = args
+	}
+	if len(env) > 0 {
+		params["env"] = env
+	}
+	if len(input) > 0 {
+		params["input-data"] = base64.StdEncoding.EncodeToString([]byte(input))
+	}
+	ret := struct {
+		Pid int `json:"pid"`
+	}{}
+	err := qga.execCmdUnmarshal(&Command{Execute: "guest-exec", Args: params}, qga.timeout, &ret)
+	if err != nil {
+		return -1, err
+	}
+	return ret.Pid, nil
+}
+
+// GuestExecStatus returns status of process pid, outputs are base64 decoded
+func (qga *GuestAgent) GuestExecStatus(pid int) (*GuestExecStatus, error) {
+	status := &GuestExecStatus{}
+	cmd := &Command{
+		Execute: "guest-exec-status",
+		Args:    map[string]interface{}{"pid": pid},
+	}
+	if err := qga.execCmdUnmarshal(cmd, qga.timeout, status); err != nil {
+		return nil, err
+	}
+	for _, data := range []*string{&status.OutData, &status.ErrData} {
+		if len(*data) == 0 {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(*data)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode output")
+		}
+		*data = string(decoded)
+	}
+	return status, nil
+}
+
+// GuestExecWait runs path inside guest and waits for it to exit with output captured
+func (qga *GuestAgent) GuestExecWait(path string, args []string, env []string, input string, timeout time.Duration) (*GuestExecStatus, error) {
+	pid, err := qga.GuestExec(path, args, env, input, true)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	interval := 100 * time.Millisecond
+	for {
+		status, err := qga.GuestExecStatus(pid)
+		if err != nil {
+			return nil, err
+		}
+		if status.Exited {
+			return status, nil
+		}
+		if time.Now().After(deadline) {
+			return status, errors.Errorf("wait guest process %d timeout after %s", pid, timeout)
+		}
+		time.Sleep(interval)
+		if interval < time.Second {
+			interval *= 2
+		}
+	}
+}
+
+func (qga *GuestAgent) GuestSetUserPassword(username, password string, crypted bool) error {
+	cmd := &Command{
+		Execute: "guest-set-user-password",
+		Args: map[string]interface{}{
+			"username": username,
+			"password": base64.StdEncoding.EncodeToString([]byte(password)),
+			"crypted":  crypted,
+		},
+	}
+	_, err := qga.execCmd(cmd, qga.timeout)
+	return err
+}
+
+// GuestFsfreezeFreeze freezes all guest filesystems, returns number of frozen filesystems
+func (qga *GuestAgent) GuestFsfreezeFreeze(timeout time.Duration) (int, error) {
+	var count int
+	err := qga.execCmdUnmarshal(&Command{Execute: "guest-fsfreeze-freeze"}, timeout, &count)
+	return count, err
+}
+
+// GuestFsfreezeThaw thaws all guest filesystems, returns number of thawed filesystems
+func (qga *GuestAgent) GuestFsfreezeThaw(timeout time.Duration) (int, error) {
+	var count int
+	err := qga.execCmdUnmarshal(&Command{Execute: "guest-fsfreeze-thaw"}, timeout, &count)
+	return count, err
+}
+
+// GuestFsfreezeStatus returns "thawed" or "frozen"
+func (qga *GuestAgent) GuestFsfreezeStatus() (string, error) {
+	var status string
+	err := qga.execCmdUnmarshal(&Command{Execute: "guest-fsfreeze-status"}, qga.timeout, &status)
+	return status, err
+}
+
+func (qga *GuestAgent) GuestNetworkGetInterfaces() ([]GuestNetworkInterface, error) {
+	ifaces := []GuestNetworkInterface{}
+	err := qga.execCmdUnmarshal(&Command{Execute: "guest-network-get-interfaces"}, qga.timeout, &ifaces)
+	return ifaces, err
+}
+
+func (qga *GuestAgent) GuestGetOsInfo() (*GuestOsInfo, error) {
+	info := &GuestOsInfo{}
+	err := qga.execCmdUnmarshal(&Command{Execute: "guest-get-osinfo"}, qga.timeout, info)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (qga *GuestAgent) guestFileOpen(path, mode string) (int, error) {
+	var handle int
+	cmd := &Command{
+		Execute: "guest-file-open",
+		Args:    map[string]interface{}{"path": path, "mode": mode},
+	}
+	err := qga.execCmdUnmarshal(cmd, qga.timeout, &handle)
+	return handle, err
+}
+
+func (qga *GuestAgent) guestFileClose(handle int) error {
+	cmd := &Command{
+		Execute: "guest-file-close",
+		Args:    map[string]interface{}{"handle": handle},
+	}
+	_, err := qga.execCmd(cmd, qga.timeout)
+	return err
+}
+
+// GuestFileRead reads at most maxSize bytes of file path inside guest
+func (qga *GuestAgent) GuestFileRead(path string, maxSize int) ([]byte, error) {
+	handle, err := qga.guestFileOpen(path, "r")
+	if err != nil {
+		return nil, err
+	}
+	defer qga.guestFileClose(handle)
+
+	content := []byte{}
+	for len(content) < maxSize {
+		count := maxSize - len(content)
+		if count > QGA_FILE_READ_COUNT {
+			count = QGA_FILE_READ_COUNT
+		}
+		ret := guestFileRead{}
+		cmd := &Command{
+			Execute: "guest-file-read",
+			Args:    map[string]interface{}{"handle": handle, "count": count},
+		}
+		if err := qga.execCmdUnmarshal(cmd, qga.timeout, &ret); err != nil {
+			return nil, err
+		}
+		buf, err := base64.StdEncoding.DecodeString(ret.BufB64)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode file content")
+		}
+		content = append(content, buf...)
+		if ret.Eof || ret.Count == 0 {
+			break
+		}
+	}
+	return content, nil
+}
+
+// GuestFileWrite writes content to file path inside guest, truncating it
+func (qga *GuestAgent) GuestFileWrite(path string, content []byte) error {
+	handle, err := qga.guestFileOpen(path, "w")
+	if err != nil {
+		return err
+	}
+	defer qga.guestFileClose(handle)
+
+	for offset := 0; offset < len(content); offset += QGA_FILE_READ_COUNT {
+		end := offset + QGA_FILE_READ_COUNT
+		if end > len(content) {
+			end = len(content)
+		}
+		cmd := &Command{
+			Execute: "guest-file-write",
+			Args: map[string]interface{}{
+				"handle":  handle,
+				"buf-b64": base64.StdEncoding.EncodeToString(content[offset:end]),
+			},
+		}
+		if _, err := qga.execCmd(cmd, qga.timeout); err != nil {
+			return err
+		}
+	}
+	_, err = qga.execCmd(&Command{
+		Execute: "guest-file-flush",
+		Args:    map[string]interface{}{"handle": handle},
+	}, qga.timeout)
+	return err
+}
+
+func (qga *GuestAgent) String() string {
+	return fmt.Sprintf("GuestAgent(%s)", qga.id)
+}
@@ -0,0 +1,122 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"testing"
+)
+
+// fakeGuestAgent answers a few qga commands on unix socket
+func fakeGuestAgent(t *testing.T, sockPath string, passwords map[string]string) net.Listener {
+	l, err := net.Listen("unix", sockPath)
+	if err != nil {
+		t.Fatalf("listen %s: %v", sockPath, err)
+	}
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		buf := []byte{}
+		for {
+			b, err := r.ReadByte()
+			if err != nil {
+				return
+			}
+			if b == qgaSentinel {
+				continue
+			}
+			buf = append(buf, b)
+			if b != '}' || !json.Valid(buf) {
+				continue
+			}
+			cmd := struct {
+				Execute   string                 `json:"execute"`
+				Arguments map[string]interface{} `json:"arguments"`
+			}{}
+			json.Unmarshal(buf, &cmd)
+			buf = buf[:0]
+
+			var resp string
+			switch cmd.Execute {
+			case "guest-sync-delimited":
+				id, _ := json.Marshal(cmd.Arguments["id"])
+				resp = string([]byte{qgaSentinel}) + `{"return": ` + string(id) + `}`
+			case "guest-ping":
+				resp = `{"return": {}}`
+			case "guest-set-user-password":
+				pass, _ := base64.StdEncoding.DecodeString(cmd.Arguments["password"].(string))
+				passwords[cmd.Arguments["username"].(string)] = string(pass)
+				resp = `{"return": {}}`
+			case "guest-exec":
+				resp = `{"return": {"pid": 42}}`
+			case "guest-exec-status":
+				out := base64.StdEncoding.EncodeToString([]byte("hello\n"))
+				resp = `{"return": {"exited": true, "exitcode": 0, "out-data": "` + out + `"}}`
+			default:
+				resp = `{"error": {"class": "CommandNotFound", "desc": "The command ` + cmd.Execute + ` has not been found"}}`
+			}
+			conn.Write([]byte(resp + "\n"))
+		}
+	}()
+	return l
+}
+
+func TestGuestAgent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qga")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	sockPath := path.Join(dir, "qga.sock")
+	passwords := map[string]string{}
+	l := fakeGuestAgent(t, sockPath, passwords)
+	defer l.Close()
+
+	qga := NewGuestAgent("test", sockPath)
+	defer qga.Close()
+	if err := qga.GuestPing(QGA_SYNC_TIMEOUT); err != nil {
+		t.Fatalf("GuestPing: %v", err)
+	}
+	if err := qga.GuestSetUserPassword("root", "Passw0rd", false); err != nil {
+		t.Fatalf("GuestSetUserPassword: %v", err)
+	}
+	if passwords["root"] != "Passw0rd" {
+		t.Errorf("password of root: want Passw0rd, got %q", passwords["root"])
+	}
+	status, err := qga.GuestExecWait("/bin/echo", []string{"hello"}, nil, "", QGA_DEFAULT_TIMEOUT)
+	if err != nil {
+		t.Fatalf("GuestExecWait: %v", err)
+	}
+	if !status.Exited || status.OutData != "hello\n" {
+		t.Errorf("unexpected exec status %#v", status)
+	}
+	if _, err := qga.GuestGetOsInfo(); err == nil {
+		t.Errorf("GuestGetOsInfo: expect command not found error")
+	}
+	// connection stays usable after command error
+	if err := qga.GuestPing(QGA_SYNC_TIMEOUT); err != nil {
+		t.Fatalf("GuestPing after error: %v", err)
+	}
+}
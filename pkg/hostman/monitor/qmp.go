@@ -692,6 +692,47 @@ func (m *QmpMonitor) ReloadDiskBlkdev(device, path string, callback StringCallba
 	m.Query(cmd, cb)
 }
 
+// SnapshotEncryptedBlkdev takes the LUKS encrypted qcow2 image at path as the
+// new active layer of device, the image is unlocked by secret object secretId
+func (m *QmpMonitor) SnapshotEncryptedBlkdev(device, path, secretId string, callback StringCallback) {
+	nodeName := fmt.Sprintf("snap-%s-%d", device, time.Now().UnixNano())
+	var (
+		addCmd = &Command{
+			Execute: "blockdev-add",
+			Args: map[string]interface{}{
+				"driver":    "qcow2",
+				"node-name": nodeName,
+				"file": map[string]string{
+					"driver":   "file",
+					"filename": path,
+				},
+				"encrypt": map[string]string{
+					"format":     "luks",
+					"key-secret": secretId,
+				},
+				// the current image of device becomes the backing node
+				"backing": nil,
+			},
+		}
+		snapshotCmd = &Command{
+			Execute: "blockdev-snapshot",
+			Args: map[string]string{
+				"node":    device,
+				"overlay": nodeName,
+			},
+		}
+	)
+	m.Query(addCmd, func(res *Response) {
+		if ret := m.actionResult(res); len(ret) > 0 {
+			callback(ret)
+			return
+		}
+		m.Query(snapshotCmd, func(res *Response) {
+			callback(m.actionResult(res))
+		})
+	})
+}
+
 func (m *QmpMonitor) DriveMirror(callback StringCallback, drive, target, syncMode string, unmap, blockReplication bool) {
 	var (
 		cb = func(res *Response) {
@@ -716,6 +757,86 @@ func (m *QmpMonitor) DriveMirror(callback StringCallback, drive, target, syncMod
 	m.Query(cmd, cb)
 }
 
+// DriveBackup copies drive to an existing qcow2 target, with syncMode
+// incremental only clusters recorded in bitmap are copied. If clearBitmap
+// is set the bitmap is reset in the same transaction the backup starts,
+// so that next incremental backup is based on this one.
+func (m *QmpMonitor) DriveBackup(drive, target, syncMode, bitmap string, clearBitmap bool, callback StringCallback) {
+	var (
+		cb = func(res *Response) {
+			callback(m.actionResult(res))
+		}
+		args = map[string]interface{}{
+			"device": drive,
+			"target": target,
+			"mode":   "existing",
+			"format": "qcow2",
+			"sync":   syncMode,
+		}
+	)
+	if syncMode == "incremental" {
+		args["bitmap"] = bitmap
+	}
+	cmd := &Command{
+		Execute: "drive-backup",
+		Args:    args,
+	}
+	if clearBitmap {
+		cmd = &Command{
+			Execute: "transaction",
+			Args: map[string]interface{}{
+				"actions": []interface{}{
+					map[string]interface{}{
+						"type": "block-dirty-bitmap-clear",
+						"data": map[string]string{
+							"node": drive,
+							"name": bitmap,
+						},
+					},
+					map[string]interface{}{
+						"type": "drive-backup",
+						"data": args,
+					},
+				},
+			},
+		}
+	}
+	m.Query(cmd, cb)
+}
+
+func (m *QmpMonitor) BlockDirtyBitmapAdd(node, name string, persistent bool, callback StringCallback) {
+	var (
+		cb = func(res *Response) {
+			callback(m.actionResult(res))
+		}
+		cmd = &Command{
+			Execute: "block-dirty-bitmap-add",
+			Args: map[string]interface{}{
+				"node":       node,
+				"name":       name,
+				"persistent": persistent,
+			},
+		}
+	)
+	m.Query(cmd, cb)
+}
+
+func (m *QmpMonitor) BlockDirtyBitmapRemove(node, name string, callback StringCallback) {
+	var (
+		cb = func(res *Response) {
+			callback(m.actionResult(res))
+		}
+		cmd = &Command{
+			Execute: "block-dirty-bitmap-remove",
+			Args: map[string]string{
+				"node": node,
+				"name": name,
+			},
+		}
+	)
+	m.Query(cmd, cb)
+}
+
 func (m *QmpMonitor) BlockStream(drive string, callback StringCallback) {
 	var (
 		speed = 100 * 1024 * 1024 // limit 100 MB/s
@@ -845,3 +966,28 @@ func (m *QmpMonitor) NetdevDel(id string, callback StringCallback) {
 	cmd := fmt.Sprintf("netdev_del %s", id)
 	m.HumanMonitorCommand(cmd, callback)
 }
+
+type BlockDirtyBitmap struct {
+	Name       string `json:"name"`
+	Persistent bool   `json:"persistent"`
+	// bitmap not saved properly, e.g. qemu crashed, can not be used any more
+	Inconsistent bool  `json:"inconsistent"`
+	Count        int64 `json:"count"`
+}
+
+// GetBlockDirtyBitmaps returns dirty bitmaps attached to a block device
+// returned by query-block
+func GetBlockDirtyBitmaps(block jsonutils.JSONObject) []BlockDirtyBitmap {
+	ret := []BlockDirtyBitmap{}
+	for _, key := range [][]string{{"dirty-bitmaps"}, {"inserted", "dirty-bitmaps"}} {
+		bitmaps, _ := block.GetArray(key...)
+		for i := range bitmaps {
+			bitmap := BlockDirtyBitmap{}
+			if err := bitmaps[i].Unmarshal(&bitmap); err != nil || len(bitmap.Name) == 0 {
+				continue
+			}
+			ret = append(ret, bitmap)
+		}
+	}
+	return ret
+}
@@ -18,6 +18,7 @@ import (
 	"testing"
 	"time"
 
+	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 )
 
@@ -43,3 +44,33 @@ func TestQmpMonitor_Connect(t *testing.T) {
 	m.Disconnect()
 	time.Sleep(3 * time.Second)
 }
+
+func TestGetBlockDirtyBitmaps(t *testing.T) {
+	cases := []struct {
+		block string
+		want  []string
+	}{
+		{`{"device":"drive_0","inserted":{"file":"/opt/disk"}}`, []string{}},
+		{`{"device":"drive_0","dirty-bitmaps":[{"name":"backup-0","persistent":true}]}`, []string{"backup-0"}},
+		{`{"device":"drive_0","inserted":{"dirty-bitmaps":[{"name":"backup-0"},{"name":"backup-1","inconsistent":true}]}}`, []string{"backup-0", "backup-1"}},
+	}
+	for _, c := range cases {
+		block, err := jsonutils.ParseString(c.block)
+		if err != nil {
+			t.Fatalf("parse %s: %v", c.block, err)
+		}
+		got := GetBlockDirtyBitmaps(block)
+		if len(got) == 2 && !got[1].Inconsistent {
+			t.Errorf("%s: bitmap %s should be inconsistent", c.block, got[1].Name)
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("%s: want %v got %v", c.block, c.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i].Name != c.want[i] {
+				t.Errorf("%s: want %v got %v", c.block, c.want, got)
+			}
+		}
+	}
+}
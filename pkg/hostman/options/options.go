@@ -57,16 +57,21 @@ type SHostOptions struct {
 
 	ChntpwPath           string `help:"path to chntpw tool" default:"/usr/local/bin/chntpw.static"`
 	OvmfPath             string `help:"Path to OVMF.fd" default:"/opt/cloud/contrib/OVMF.fd"`
+	RescueDirPath        string `help:"Directory of rescue image, either vmlinuz with initrd.img or rescue.iso" default:"/opt/cloud/contrib/rescue"`
 	LinuxDefaultRootUser bool   `help:"Default account for linux system is root"`
 
 	BlockIoScheduler string `help:"Block IO scheduler, deadline or cfq" default:"deadline"`
 	EnableKsm        bool   `help:"Enable Kernel Same Page Merging"`
 	HugepagesOption  string `help:"Hugepages option: disable|native|transparent" default:"transparent"`
+	HugepageSizeMb   int    `help:"Size of native hugepages in MB, 2 or 1024, 0 means default hugepage size of system" default:"0"`
 	EnableQmpMonitor bool   `help:"Enable qmp monitor" default:"true"`
 
+	EnableNumaPlacement bool `help:"Pin vcpus and bind memory of guest to a single numa node if it fits" default:"true"`
+
 	PrivatePrefixes []string `help:"IPv4 private prefixes"`
 	LocalImagePath  []string `help:"Local image storage paths"`
 	SharedStorages  []string `help:"Path of shared storages"`
+	LVMVolumeGroups []string `help:"LVM volume groups used as local storages, in form of vg or vg/thinpool"`
 
 	DefaultQemuVersion string `help:"Default qemu version" default:"2.12.1"`
 
@@ -74,6 +79,8 @@ type SHostOptions struct {
 	DhcpLeaseTime   int      `default:"100663296" help:"DHCP lease time in seconds"`
 	DhcpRenewalTime int      `default:"67108864" help:"DHCP renewal time in seconds"`
 
+	EnableDhcp6 bool `default:"true" help:"Serve DHCPv6 and router advertisement for guests with ipv6 address"`
+
 	TunnelPaddingBytes int64 `help:"Specify tunnel padding bytes" default:"0"`
 
 	CheckSystemServices bool `help:"Check system services (ntpd, telegraf) on startup" default:"true"`
@@ -102,6 +109,14 @@ type SHostOptions struct {
 	SnapshotDirSuffix  string `help:"Snapshot dir name equal diskId concat snapshot dir suffix" default:"_snap"`
 	SnapshotRecycleDay int    `default:"1" help:"Snapshot Recycle delete Duration day"`
 
+	DiskBackupStorage      string `help:"Backup storage disk backups are saved to" default:"nfs" choices:"nfs|s3"`
+	DiskBackupPath         string `help:"Directory of disk backups, usually mount point of a NFS share, works as staging directory for s3" default:"/opt/cloud/workspace/disk_backups"`
+	DiskBackupS3Endpoint   string `help:"s3 endpoint of disk backups"`
+	DiskBackupS3AccessKey  string `help:"s3 access key of disk backups"`
+	DiskBackupS3SecretKey  string `help:"s3 secret key of disk backups"`
+	DiskBackupS3UseSSL     bool   `help:"s3 access of disk backups use ssl"`
+	DiskBackupS3BucketName string `help:"s3 bucket name of disk backups" default:"onecloud-disk-backups"`
+
 	EnableTelegraf          bool `default:"true" help:"enable send monitoring data to telegraf"`
 	WindowsDefaultAdminUser bool `default:"true" help:"Default account for Windows system is Administrator"`
 
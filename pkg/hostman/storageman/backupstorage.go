@@ -0,0 +1,331 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageman
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sync"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	hostapi "yunion.io/x/onecloud/pkg/apis/host"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/image/drivers/s3"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+	"yunion.io/x/onecloud/pkg/util/qemuimg"
+)
+
+// IBackupStorage keeps disk backups. Backup files are always written to and
+// read from a local directory, storages other than nfs move them in and out
+// of it. Incremental backups refer to their parents by file name relatively,
+// so a chain works as long as all its files are in the same directory.
+type IBackupStorage interface {
+	StorageType() string
+
+	// GetBackupDir returns local directory of backup files of a disk
+	GetBackupDir(diskId string) (string, error)
+	// SaveBackup persists backup file written to backup dir, returns its location
+	SaveBackup(diskId, backupId string) (string, error)
+	// FetchBackup makes backup file available in backup dir
+	FetchBackup(diskId, backupId, location string) error
+	// ReleaseBackup drops local copy of backup file fetched
+	ReleaseBackup(diskId, backupId string)
+	DeleteBackup(diskId, backupId, location string) error
+}
+
+var (
+	backupStorage     IBackupStorage
+	backupStorageLock sync.Mutex
+)
+
+func GetBackupFileName(backupId string) string {
+	return fmt.Sprintf("%s.qcow2", backupId)
+}
+
+func GetBackupStorage() (IBackupStorage, error) {
+	backupStorageLock.Lock()
+	defer backupStorageLock.Unlock()
+	if backupStorage != nil {
+		return backupStorage, nil
+	}
+	switch options.HostOptions.DiskBackupStorage {
+	case api.DISK_BACKUP_STORAGE_S3:
+		err := s3.Init(
+			options.HostOptions.DiskBackupS3Endpoint,
+			options.HostOptions.DiskBackupS3AccessKey,
+			options.HostOptions.DiskBackupS3SecretKey,
+			options.HostOptions.DiskBackupS3BucketName,
+			options.HostOptions.DiskBackupS3UseSSL,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "init s3 client")
+		}
+		backupStorage = &SS3BackupStorage{SNfsBackupStorage{options.HostOptions.DiskBackupPath}}
+	default:
+		backupStorage = &SNfsBackupStorage{options.HostOptions.DiskBackupPath}
+	}
+	return backupStorage, nil
+}
+
+type SNfsBackupStorage struct {
+	Path string
+}
+
+func (s *SNfsBackupStorage) StorageType() string {
+	return api.DISK_BACKUP_STORAGE_NFS
+}
+
+func (s *SNfsBackupStorage) GetBackupDir(diskId string) (string, error) {
+	dir := path.Join(s.Path, diskId)
+	if !fileutils2.Exists(dir) {
+		output, err := procutils.NewCommand("mkdir", "-p", dir).Output()
+		if err != nil {
+			return "", errors.Wrapf(err, "mkdir %s failed: %s", dir, output)
+		}
+	}
+	return dir, nil
+}
+
+func (s *SNfsBackupStorage) getBackupPath(diskId, backupId string) string {
+	return path.Join(s.Path, diskId, GetBackupFileName(backupId))
+}
+
+func (s *SNfsBackupStorage) SaveBackup(diskId, backupId string) (string, error) {
+	return s.getBackupPath(diskId, backupId), nil
+}
+
+func (s *SNfsBackupStorage) FetchBackup(diskId, backupId, location string) error {
+	if !fileutils2.Exists(s.getBackupPath(diskId, backupId)) {
+		return errors.Wrapf(errors.ErrNotFound, "backup %s", location)
+	}
+	return nil
+}
+
+func (s *SNfsBackupStorage) ReleaseBackup(diskId, backupId string) {
+}
+
+func (s *SNfsBackupStorage) DeleteBackup(diskId, backupId, location string) error {
+	output, err := procutils.NewCommand("rm", "-f", s.getBackupPath(diskId, backupId)).Output()
+	if err != nil {
+		return errors.Wrapf(err, "rm backup %s failed: %s", backupId, output)
+	}
+	return nil
+}
+
+// SS3BackupStorage stages backup files in a local directory laid out
+// the same as nfs backup storage
+type SS3BackupStorage struct {
+	SNfsBackupStorage
+}
+
+func (s *SS3BackupStorage) StorageType() string {
+	return api.DISK_BACKUP_STORAGE_S3
+}
+
+func (s *SS3BackupStorage) getObjectName(diskId, backupId string) string {
+	return path.Join(diskId, GetBackupFileName(backupId))
+}
+
+func (s *SS3BackupStorage) SaveBackup(diskId, backupId string) (string, error) {
+	location, err := s3.Put(s.getBackupPath(diskId, backupId), s.getObjectName(diskId, backupId))
+	if err != nil {
+		return "", errors.Wrapf(err, "upload backup %s", backupId)
+	}
+	s.ReleaseBackup(diskId, backupId)
+	return location, nil
+}
+
+func (s *SS3BackupStorage) FetchBackup(diskId, backupId, location string) error {
+	if _, err := s.GetBackupDir(diskId); err != nil {
+		return err
+	}
+	obj, err := s3.Get(s.getObjectName(diskId, backupId))
+	if err != nil {
+		return errors.Wrapf(err, "get backup %s", location)
+	}
+	defer obj.Close()
+	f, err := os.Create(s.getBackupPath(diskId, backupId))
+	if err != nil {
+		return errors.Wrap(err, "create backup file")
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, obj); err != nil {
+		return errors.Wrapf(err, "download backup %s", location)
+	}
+	return nil
+}
+
+func (s *SS3BackupStorage) ReleaseBackup(diskId, backupId string) {
+	procutils.NewCommand("rm", "-f", s.getBackupPath(diskId, backupId)).Run()
+}
+
+func (s *SS3BackupStorage) DeleteBackup(diskId, backupId, location string) error {
+	s.ReleaseBackup(diskId, backupId)
+	if err := s3.Remove(s.getObjectName(diskId, backupId)); err != nil {
+		return errors.Wrapf(err, "remove backup %s", location)
+	}
+	return nil
+}
+
+// FinishDiskBackup saves backup file written to backup dir
+// and reports what region needs to know about it
+func FinishDiskBackup(diskId, backupId, mode string, diskSizeMb int) (*hostapi.DiskBackupResponse, error) {
+	bs, err := GetBackupStorage()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := bs.GetBackupDir(diskId)
+	if err != nil {
+		return nil, err
+	}
+	// backing file of incremental backup may be absent, do not open it as image
+	fileInfo, err := os.Stat(path.Join(dir, GetBackupFileName(backupId)))
+	if err != nil {
+		return nil, errors.Wrap(err, "stat backup file")
+	}
+	resp := &hostapi.DiskBackupResponse{
+		Mode:        mode,
+		StorageType: bs.StorageType(),
+		SizeMb:      int(fileInfo.Size() / 1024 / 1024),
+		DiskSizeMb:  diskSizeMb,
+	}
+	resp.Location, err = bs.SaveBackup(diskId, backupId)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+type SDiskBackup struct {
+	Disk    IDisk
+	Request *hostapi.DiskBackupRequest
+}
+
+// BackupDiskOffline takes full backup of a disk not in use, dirty bitmaps
+// persisted in the image are left as is, so that incremental backups
+// taken after guest starts are still based on the latest one
+func BackupDiskOffline(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	backupParams, ok := params.(*SDiskBackup)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	var (
+		disk = backupParams.Disk
+		req  = backupParams.Request
+	)
+	bs, err := GetBackupStorage()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := bs.GetBackupDir(disk.GetId())
+	if err != nil {
+		return nil, err
+	}
+	img, err := qemuimg.NewQemuImage(disk.GetPath())
+	if err != nil {
+		return nil, errors.Wrap(err, "open disk image")
+	}
+	target := path.Join(dir, GetBackupFileName(req.BackupId))
+	if err := img.Convert2Qcow2To(target, true); err != nil {
+		procutils.NewCommand("rm", "-f", target).Run()
+		return nil, errors.Wrap(err, "convert disk image")
+	}
+	resp, err := FinishDiskBackup(disk.GetId(), req.BackupId, api.DISK_BACKUP_MODE_FULL, img.GetSizeMB())
+	if err != nil {
+		return nil, err
+	}
+	return jsonutils.Marshal(resp), nil
+}
+
+type SDiskBackupRestore struct {
+	Disk    IDisk
+	Request *hostapi.DiskBackupRestoreRequest
+}
+
+// RestoreDiskBackup overwrites disk with content of the last backup in
+// chain given, the disk must not be in use
+func RestoreDiskBackup(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	restoreParams, ok := params.(*SDiskBackupRestore)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	var (
+		disk    = restoreParams.Disk
+		backups = restoreParams.Request.Backups
+	)
+	if len(backups) == 0 {
+		return nil, fmt.Errorf("no backups to restore")
+	}
+	bs, err := GetBackupStorage()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := bs.GetBackupDir(disk.GetId())
+	if err != nil {
+		return nil, err
+	}
+	for i := range backups {
+		defer bs.ReleaseBackup(disk.GetId(), backups[i].BackupId)
+		if err := bs.FetchBackup(disk.GetId(), backups[i].BackupId, backups[i].Location); err != nil {
+			return nil, err
+		}
+	}
+	img, err := qemuimg.NewQemuImage(path.Join(dir, GetBackupFileName(backups[len(backups)-1].BackupId)))
+	if err != nil {
+		return nil, errors.Wrap(err, "open backup image")
+	}
+	// the disk keeps its format recorded by region
+	format := qemuimg.QCOW2
+	if diskImg, err := qemuimg.NewQemuImage(disk.GetPath()); err == nil && diskImg.IsValid() {
+		format = diskImg.Format
+	}
+	output := disk.GetPath() + ".restore"
+	if _, err := img.Clone(output, format, false); err != nil {
+		procutils.NewCommand("rm", "-f", output).Run()
+		return nil, errors.Wrap(err, "convert backup image")
+	}
+	if out, err := procutils.NewCommand("mv", "-f", output, disk.GetPath()).Output(); err != nil {
+		procutils.NewCommand("rm", "-f", output).Run()
+		return nil, errors.Wrapf(err, "mv %s to %s failed: %s", output, disk.GetPath(), out)
+	}
+	log.Infof("disk %s restored from backup %s", disk.GetId(), backups[len(backups)-1].BackupId)
+	return nil, nil
+}
+
+func DeleteDiskBackups(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	req, ok := params.(*hostapi.DiskBackupDeleteRequest)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	bs, err := GetBackupStorage()
+	if err != nil {
+		return nil, err
+	}
+	for _, backup := range req.Backups {
+		if err := bs.DeleteBackup(req.DiskId, backup.BackupId, backup.Location); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
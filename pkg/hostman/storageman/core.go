@@ -53,6 +53,7 @@ type SStorageManager struct {
 
 	RbdStorageImagecacheManagers        map[string]IImageCacheManger
 	SharedFileStorageImagecacheManagers map[string]IImageCacheManger
+	LVMStorageImagecacheManagers        map[string]IImageCacheManger
 }
 
 func NewStorageManager(host hostutils.IHost) (*SStorageManager, error) {
@@ -77,6 +78,18 @@ func NewStorageManager(host hostutils.IHost) (*SStorageManager, error) {
 		}
 	}
 
+	for _, vg := range options.HostOptions.LVMVolumeGroups {
+		s := NewLVMStorage(ret, vg)
+		if err := s.Accessible(); err == nil {
+			ret.Storages = append(ret.Storages, s)
+			if allFull && s.GetFreeSizeMb() > MINIMAL_FREE_SPACE {
+				allFull = false
+			}
+		} else {
+			log.Errorf("lvm storage %s not accessible: %s", vg, err)
+		}
+	}
+
 	for _, d := range options.HostOptions.SharedStorages {
 		s := ret.NewSharedStorageInstance(d, "")
 		if s != nil {
@@ -97,7 +110,7 @@ func NewStorageManager(host hostutils.IHost) (*SStorageManager, error) {
 }
 
 func (s *SStorageManager) Remove(storage IStorage) {
-	if utils.IsInStringArray(storage.StorageType(), api.SHARED_FILE_STORAGE) {
+	if utils.IsInStringArray(storage.StorageType(), api.SHARED_FILE_STORAGE) || storage.StorageType() == api.STORAGE_ISCSI {
 		delete(s.SharedFileStorageImagecacheManagers, storage.GetStoragecacheId())
 	} else if storage.StorageType() == api.STORAGE_RBD {
 		delete(s.RbdStorageImagecacheManagers, storage.GetStoragecacheId())
@@ -237,6 +250,9 @@ func (s *SStorageManager) GetStoragecacheById(scId string) IImageCacheManger {
 	if sc, ok := s.RbdStorageImagecacheManagers[scId]; ok {
 		return sc
 	}
+	if sc, ok := s.LVMStorageImagecacheManagers[scId]; ok {
+		return sc
+	}
 	return nil
 }
 
@@ -247,6 +263,9 @@ func (s *SStorageManager) NewSharedStorageInstance(mountPoint, storageType strin
 func (s *SStorageManager) InitSharedStorageImageCache(storageType, storagecacheId, imagecachePath string, storage IStorage) {
 	if utils.IsInStringArray(storageType, api.SHARED_FILE_STORAGE) {
 		s.InitSharedFileStorageImagecache(storagecacheId, imagecachePath)
+	} else if storageType == api.STORAGE_ISCSI {
+		// images are cached on each host and written onto luns
+		s.InitSharedFileStorageImagecache(storagecacheId, imagecachePath)
 	} else if storageType == api.STORAGE_RBD {
 		if rbdStorage := s.GetStoragecacheById(storagecacheId); rbdStorage == nil {
 			s.AddRbdStorageImagecache(imagecachePath, storage, storagecacheId)
@@ -279,6 +298,14 @@ func (s *SStorageManager) AddRbdStorageImagecache(imagecachePath string, storage
 	}
 }
 
+func (s *SStorageManager) AddLVMStorageImagecache(storagecacheId string, imagecache IImageCacheManger) {
+	if s.LVMStorageImagecacheManagers == nil {
+		s.LVMStorageImagecacheManagers = map[string]IImageCacheManger{}
+	}
+	imagecache.SetStoragecacheId(storagecacheId)
+	s.LVMStorageImagecacheManagers[storagecacheId] = imagecache
+}
+
 var storageManager *SStorageManager
 
 func GetManager() *SStorageManager {
@@ -347,7 +374,7 @@ func StartSyncStorageSizeTask(interval time.Duration) {
 		manager := GetManager()
 		for i := 0; i < len(manager.Storages); i++ {
 			iS := manager.Storages[i]
-			if iS.StorageType() == api.STORAGE_LOCAL {
+			if utils.IsInStringArray(iS.StorageType(), []string{api.STORAGE_LOCAL, api.STORAGE_LVM}) {
 				err := iS.SyncStorageSize()
 				if err != nil {
 					log.Errorf("sync storage %s size failed: %s", iS.GetStorageName(), err)
@@ -24,6 +24,7 @@ import (
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 
+	"yunion.io/x/onecloud/pkg/apis"
 	deployapi "yunion.io/x/onecloud/pkg/hostman/hostdeployer/apis"
 	"yunion.io/x/onecloud/pkg/hostman/hostdeployer/deployclient"
 )
@@ -51,12 +52,12 @@ type IDisk interface {
 
 	PrepareMigrate(liveMigrate bool) (string, error)
 	CreateFromUrl(ctx context.Context, url string, size int64) error
-	CreateFromTemplate(context.Context, string, string, int64) (jsonutils.JSONObject, error)
+	CreateFromTemplate(context.Context, string, string, int64, *apis.SEncryptInfo) (jsonutils.JSONObject, error)
 	CreateFromSnapshotLocation(ctx context.Context, location string, size int64) error
 	CreateFromRbdSnapshot(ctx context.Context, snapshotId, srcDiskId, srcPool string) error
 	CreateFromImageFuse(ctx context.Context, url string, size int64) error
 	CreateRaw(ctx context.Context, sizeMb int, diskFromat string, fsFormat string,
-		encryption bool, diskId string, back string) (jsonutils.JSONObject, error)
+		encryptInfo *apis.SEncryptInfo, diskId string, back string) (jsonutils.JSONObject, error)
 	PostCreateFromImageFuse()
 	CreateSnapshot(snapshotId string) error
 	DeleteSnapshot(snapshotId, convertSnapshot string, pendingDelete bool) error
@@ -100,7 +101,7 @@ func (d *SBaseDisk) CreateFromUrl(ctx context.Context, url string, size int64) e
 	return fmt.Errorf("Not implemented")
 }
 
-func (d *SBaseDisk) CreateFromTemplate(context.Context, string, string, int64) (jsonutils.JSONObject, error) {
+func (d *SBaseDisk) CreateFromTemplate(context.Context, string, string, int64, *apis.SEncryptInfo) (jsonutils.JSONObject, error) {
 	return nil, fmt.Errorf("Not implemented")
 }
 
@@ -122,11 +123,16 @@ func (d *SBaseDisk) DeployGuestFs(diskPath string, guestDesc *jsonutils.JSONDict
 	if err != nil {
 		return nil, errors.Wrap(err, "guest desc to deploy desc")
 	}
+	encryptInfo, err := d.GetEncryptInfo(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, "get encrypt info")
+	}
 	ret, err := deployclient.GetDeployClient().DeployGuestFs(
 		context.Background(), &deployapi.DeployParams{
-			DiskPath:   diskPath,
-			GuestDesc:  deployGuestDesc,
-			DeployInfo: deployInfo,
+			DiskPath:    diskPath,
+			GuestDesc:   deployGuestDesc,
+			DeployInfo:  deployInfo,
+			EncryptInfo: toDeployEncryptInfo(encryptInfo),
 		},
 	)
 	if err != nil {
@@ -135,9 +141,12 @@ func (d *SBaseDisk) DeployGuestFs(diskPath string, guestDesc *jsonutils.JSONDict
 	return jsonutils.Marshal(ret), nil
 }
 
-func (d *SBaseDisk) ResizeFs(diskPath string) error {
+func (d *SBaseDisk) ResizeFs(diskPath string, encryptInfo *apis.SEncryptInfo) error {
 	_, err := deployclient.GetDeployClient().ResizeFs(
-		context.Background(), &deployapi.ResizeFsParams{DiskPath: diskPath})
+		context.Background(), &deployapi.ResizeFsParams{
+			DiskPath:    diskPath,
+			EncryptInfo: toDeployEncryptInfo(encryptInfo),
+		})
 	return err
 }
 
@@ -149,14 +158,15 @@ func (d *SBaseDisk) GetSnapshotLocation() string {
 	return ""
 }
 
-func (d *SBaseDisk) FormatFs(fsFormat, uuid, diskPath string) {
+func (d *SBaseDisk) FormatFs(fsFormat, uuid, diskPath string, encryptInfo *apis.SEncryptInfo) {
 	log.Infof("Make disk %s fs %s", uuid, fsFormat)
 	_, err := deployclient.GetDeployClient().FormatFs(
 		context.Background(),
 		&deployapi.FormatFsParams{
-			DiskPath: diskPath,
-			FsFormat: fsFormat,
-			Uuid:     uuid,
+			DiskPath:    diskPath,
+			FsFormat:    fsFormat,
+			Uuid:        uuid,
+			EncryptInfo: toDeployEncryptInfo(encryptInfo),
 		},
 	)
 	if err != nil {
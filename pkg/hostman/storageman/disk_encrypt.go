@@ -0,0 +1,77 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageman
+
+import (
+	"context"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	deployapi "yunion.io/x/onecloud/pkg/hostman/hostdeployer/apis"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/util/qemuimg"
+)
+
+// GetEncryptInfo fetches the data key of an encrypted disk from keystone,
+// nil is returned if the key id is empty
+func GetEncryptInfo(ctx context.Context, keyId string) (*apis.SEncryptInfo, error) {
+	if len(keyId) == 0 {
+		return nil, nil
+	}
+	key, err := modules.Credentials.GetEncryptKey(hostutils.GetComputeSession(ctx), keyId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get encrypt key %s", keyId)
+	}
+	info := key.EncryptInfo()
+	return &info, nil
+}
+
+// GetEncryptInfo returns the data key of the disk recorded by region,
+// nil is returned if the disk is not encrypted
+func (d *SBaseDisk) GetEncryptInfo(ctx context.Context) (*apis.SEncryptInfo, error) {
+	disk, err := modules.Disks.Get(hostutils.GetComputeSession(ctx), d.Id, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get disk %s", d.Id)
+	}
+	keyId, _ := disk.GetString("encrypt_key_id")
+	return GetEncryptInfo(ctx, keyId)
+}
+
+// NewDiskQemuImage opens the image with the data key so that
+// its encrypted layers can be read and written
+func NewDiskQemuImage(path string, encryptInfo *apis.SEncryptInfo) (*qemuimg.SQemuImage, error) {
+	img, err := qemuimg.NewQemuImage(path)
+	if err != nil {
+		return nil, err
+	}
+	if encryptInfo != nil {
+		img.SetEncryptKey(encryptInfo.Key)
+	}
+	return img, nil
+}
+
+func toDeployEncryptInfo(encryptInfo *apis.SEncryptInfo) *deployapi.EncryptInfo {
+	if encryptInfo == nil {
+		return nil
+	}
+	return &deployapi.EncryptInfo{
+		Id:   encryptInfo.Id,
+		Name: encryptInfo.Name,
+		Key:  encryptInfo.Key,
+		Alg:  encryptInfo.Alg,
+	}
+}
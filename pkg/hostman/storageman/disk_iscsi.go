@@ -0,0 +1,296 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageman
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/appctx"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/hostman/storageman/iscsiutils"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+	"yunion.io/x/onecloud/pkg/util/qemuimg"
+	"yunion.io/x/onecloud/pkg/util/qemutils"
+)
+
+var ErrISCSIEncryptNotSupported = errors.Error("disk encryption is not supported by iscsi storage")
+
+// SISCSIDisk is a link to the lun device the disk is allocated on
+type SISCSIDisk struct {
+	SBaseDisk
+}
+
+func NewISCSIDisk(storage IStorage, id string) *SISCSIDisk {
+	var ret = new(SISCSIDisk)
+	ret.SBaseDisk = *NewBaseDisk(storage, id)
+	return ret
+}
+
+func (d *SISCSIDisk) GetType() string {
+	return api.STORAGE_ISCSI
+}
+
+func (d *SISCSIDisk) getStorage() *SISCSIStorage {
+	return d.Storage.(*SISCSIStorage)
+}
+
+func (d *SISCSIDisk) GetPath() string {
+	return path.Join(d.Storage.GetPath(), d.Id)
+}
+
+func (d *SISCSIDisk) GetSnapshotDir() string {
+	return ""
+}
+
+func (d *SISCSIDisk) Probe() error {
+	if !fileutils2.Exists(d.GetPath()) {
+		return errors.Wrap(iscsiutils.ErrDeviceNotFound, d.GetPath())
+	}
+	return nil
+}
+
+func (d *SISCSIDisk) getSizeMb() (int64, error) {
+	return iscsiutils.GetDeviceSizeMb(d.GetPath())
+}
+
+func (d *SISCSIDisk) GetDiskDesc() jsonutils.JSONObject {
+	sizeMb, err := d.getSizeMb()
+	if err != nil {
+		log.Errorln(err)
+		return nil
+	}
+	desc := map[string]interface{}{
+		"disk_id":     d.Id,
+		"disk_format": "raw",
+		"disk_path":   d.GetPath(),
+		"disk_size":   sizeMb,
+	}
+	return jsonutils.Marshal(desc)
+}
+
+func (d *SISCSIDisk) GetDiskSetupScripts(idx int) string {
+	return fmt.Sprintf("DISK_%d=%s\n", idx, d.GetPath())
+}
+
+// Unmap removes the link of disk and the multipath map of the lun,
+// the lun itself stays allocated to the disk
+func (d *SISCSIDisk) Unmap() error {
+	diskPath := d.GetPath()
+	dev, err := os.Readlink(diskPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "readlink %s", diskPath)
+	}
+	if iscsiutils.IsMultipathDevice(dev) {
+		if err := iscsiutils.FlushMultipath(dev); err != nil {
+			log.Warningf("flush multipath %s: %s", dev, err)
+		}
+	}
+	return os.Remove(diskPath)
+}
+
+// Delete zeroes the lun before releasing it, region frees the lun once the
+// disk is deleted, so the lun stays allocated if it can't be wiped.  A disk
+// whose lun device is gone has nothing to wipe and is deleted directly
+func (d *SISCSIDisk) Delete(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	log.Infof("Delete guest disk %s", d.GetPath())
+	if err := d.Probe(); err != nil {
+		log.Warningf("lun device of disk %s is absent, skip wiping: %s", d.Id, err)
+		// drop the dangling link if any
+		if err := os.Remove(d.GetPath()); err != nil && !os.IsNotExist(err) {
+			log.Warningf("remove %s: %s", d.GetPath(), err)
+		}
+	} else {
+		if err := iscsiutils.ZeroDevice(d.GetPath()); err != nil {
+			return nil, errors.Wrapf(err, "zero disk %s", d.GetPath())
+		}
+		if err := d.Unmap(); err != nil {
+			return nil, err
+		}
+	}
+	d.Storage.RemoveDisk(d)
+	return nil, nil
+}
+
+func (d *SISCSIDisk) OnRebuildRoot(ctx context.Context, params jsonutils.JSONObject) error {
+	// the disk keeps its lun, it is overwritten on creation
+	d.Storage.RemoveDisk(d)
+	return nil
+}
+
+// Resize only grows the filesystem, luns are resized on the array
+func (d *SISCSIDisk) Resize(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	diskInfo, ok := params.(*jsonutils.JSONDict)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	sizeMb, _ := diskInfo.Int("size")
+	d.getStorage().rescan()
+	curSizeMb, err := d.getSizeMb()
+	if err != nil {
+		return nil, err
+	}
+	if sizeMb > curSizeMb {
+		return nil, fmt.Errorf("lun of disk %s is %dMB, smaller than %dMB", d.Id, curSizeMb, sizeMb)
+	}
+
+	if err := d.ResizeFs(d.GetPath(), nil); err != nil {
+		return nil, errors.Wrapf(err, "resize fs %s", d.GetPath())
+	}
+
+	return d.GetDiskDesc(), nil
+}
+
+func (d *SISCSIDisk) CreateRaw(ctx context.Context, sizeMb int, diskFromat string, fsFormat string,
+	encryptInfo *apis.SEncryptInfo, diskId string, back string) (jsonutils.JSONObject, error) {
+	if encryptInfo != nil {
+		return nil, ErrISCSIEncryptNotSupported
+	}
+	if err := d.Probe(); err != nil {
+		return nil, err
+	}
+	if err := procutils.NewRemoteCommandAsFarAsPossible("wipefs", "-a", d.GetPath()).Run(); err != nil {
+		log.Warningf("wipefs %s: %s", d.GetPath(), err)
+	}
+
+	if utils.IsInStringArray(fsFormat, []string{"swap", "ext2", "ext3", "ext4", "xfs"}) {
+		d.FormatFs(fsFormat, diskId, d.GetPath(), nil)
+	}
+
+	return d.GetDiskDesc(), nil
+}
+
+func (d *SISCSIDisk) CreateFromTemplate(ctx context.Context, imageId string, format string, size int64, encryptInfo *apis.SEncryptInfo) (jsonutils.JSONObject, error) {
+	if encryptInfo != nil {
+		return nil, ErrISCSIEncryptNotSupported
+	}
+	ret, err := d.createFromTemplate(ctx, imageId)
+	if err != nil {
+		return nil, err
+	}
+
+	retSize, _ := ret.Int("disk_size")
+	log.Infof("REQSIZE: %d, RETSIZE: %d", size, retSize)
+	if size > retSize {
+		params := jsonutils.NewDict()
+		params.Set("size", jsonutils.NewInt(size))
+		return d.Resize(ctx, params)
+	}
+
+	return ret, nil
+}
+
+// createFromTemplate writes the cached image onto the lun
+func (d *SISCSIDisk) createFromTemplate(ctx context.Context, imageId string) (jsonutils.JSONObject, error) {
+	imageCacheManager := storageManager.GetStoragecacheById(d.Storage.GetStoragecacheId())
+	if imageCacheManager == nil {
+		return nil, fmt.Errorf("failed to find image cache manger for storage %s", d.Storage.GetStorageName())
+	}
+	imageCache := imageCacheManager.AcquireImage(ctx, imageId, d.GetZoneName(), "", "")
+	if imageCache == nil {
+		return nil, fmt.Errorf("Fail to fetch image %s", imageId)
+	}
+	defer imageCacheManager.ReleaseImage(ctx, imageId)
+
+	if err := d.Probe(); err != nil {
+		return nil, err
+	}
+	img, err := qemuimg.NewQemuImage(imageCache.GetPath())
+	if err != nil {
+		return nil, errors.Wrapf(err, "open image cache %s", imageCache.GetPath())
+	}
+	sizeMb, err := d.getSizeMb()
+	if err != nil {
+		return nil, err
+	}
+	if int64(img.GetSizeMB()) > sizeMb {
+		return nil, fmt.Errorf("image %s of %dMB doesn't fit lun of %dMB", imageId, img.GetSizeMB(), sizeMb)
+	}
+	err = procutils.NewRemoteCommandAsFarAsPossible(qemutils.GetQemuImg(), "convert", "-n",
+		"-O", "raw", imageCache.GetPath(), d.GetPath()).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "write image %s to disk %s", imageId, d.Id)
+	}
+	return d.GetDiskDesc(), nil
+}
+
+func (d *SISCSIDisk) CreateFromImageFuse(ctx context.Context, url string, size int64) error {
+	return fmt.Errorf("Not support")
+}
+
+func (d *SISCSIDisk) PostCreateFromImageFuse() {
+	log.Errorf("Not support PostCreateFromImageFuse")
+}
+
+func (d *SISCSIDisk) PrepareMigrate(liveMigrate bool) (string, error) {
+	return "", fmt.Errorf("Not support")
+}
+
+// PrepareSaveToGlance exports the lun to local storage, the image is uploaded from there
+func (d *SISCSIDisk) PrepareSaveToGlance(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	if err := d.Probe(); err != nil {
+		return nil, err
+	}
+	destDir := d.Storage.GetImgsaveBackupPath()
+	if len(destDir) == 0 {
+		return nil, fmt.Errorf("no local storage to save image")
+	}
+	if err := procutils.NewCommand("mkdir", "-p", destDir).Run(); err != nil {
+		return nil, err
+	}
+	backupPath := path.Join(destDir, fmt.Sprintf("%s.%s", d.Id, appctx.AppContextTaskId(ctx)))
+	err := procutils.NewRemoteCommandAsFarAsPossible(qemutils.GetQemuImg(), "convert",
+		"-f", "raw", "-O", "qcow2", d.GetPath(), backupPath).Run()
+	if err != nil {
+		procutils.NewCommand("rm", "-f", backupPath).Run()
+		return nil, errors.Wrap(err, "backup disk")
+	}
+	res := jsonutils.NewDict()
+	res.Set("backup", jsonutils.NewString(backupPath))
+	return res, nil
+}
+
+func (d *SISCSIDisk) CreateSnapshot(snapshotId string) error {
+	return fmt.Errorf("Not support snapshot on iscsi storage")
+}
+
+func (d *SISCSIDisk) DeleteSnapshot(snapshotId, convertSnapshot string, pendingDelete bool) error {
+	return fmt.Errorf("Not support snapshot on iscsi storage")
+}
+
+func (d *SISCSIDisk) DeleteAllSnapshot() error {
+	return nil
+}
+
+func (d *SISCSIDisk) ResetFromSnapshot(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	return nil, fmt.Errorf("Not support snapshot on iscsi storage")
+}
+
+func (d *SISCSIDisk) CleanupSnapshots(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	return nil, nil
+}
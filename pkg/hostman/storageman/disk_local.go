@@ -27,6 +27,7 @@ import (
 	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/utils"
 
+	"yunion.io/x/onecloud/pkg/apis"
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/appctx"
 	"yunion.io/x/onecloud/pkg/hostman/hostutils"
@@ -141,7 +142,11 @@ func (d *SLocalDisk) Resize(ctx context.Context, params interface{}) (jsonutils.
 	}
 
 	sizeMb, _ := diskInfo.Int("size")
-	disk, err := qemuimg.NewQemuImage(d.GetPath())
+	encryptInfo, err := d.GetEncryptInfo(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get encrypt info")
+	}
+	disk, err := NewDiskQemuImage(d.GetPath(), encryptInfo)
 	if err != nil {
 		log.Errorf("qemuimg.NewQemuImage %s fail: %s", d.GetPath(), err)
 		return nil, err
@@ -154,7 +159,7 @@ func (d *SLocalDisk) Resize(ctx context.Context, params interface{}) (jsonutils.
 		// d.Fallocate()
 	}
 
-	if err := d.ResizeFs(d.GetPath()); err != nil {
+	if err := d.ResizeFs(d.GetPath(), encryptInfo); err != nil {
 		return nil, errors.Wrapf(err, "resize fs %s", d.GetPath())
 	}
 
@@ -197,9 +202,9 @@ func (d *SLocalDisk) CreateFromImageFuse(ctx context.Context, url string, size i
 	return nil
 }
 
-func (d *SLocalDisk) CreateFromTemplate(ctx context.Context, imageId, format string, size int64) (jsonutils.JSONObject, error) {
+func (d *SLocalDisk) CreateFromTemplate(ctx context.Context, imageId, format string, size int64, encryptInfo *apis.SEncryptInfo) (jsonutils.JSONObject, error) {
 	var imageCacheManager = storageManager.LocalStorageImagecacheManager
-	ret, err := d.createFromTemplate(ctx, imageId, format, imageCacheManager)
+	ret, err := d.createFromTemplate(ctx, imageId, format, imageCacheManager, encryptInfo)
 	if err != nil {
 		return nil, err
 	}
@@ -214,7 +219,7 @@ func (d *SLocalDisk) CreateFromTemplate(ctx context.Context, imageId, format str
 }
 
 func (d *SLocalDisk) createFromTemplate(
-	ctx context.Context, imageId, format string, imageCacheManager IImageCacheManger,
+	ctx context.Context, imageId, format string, imageCacheManager IImageCacheManger, encryptInfo *apis.SEncryptInfo,
 ) (jsonutils.JSONObject, error) {
 	imageCache := imageCacheManager.AcquireImage(ctx, imageId, d.GetZoneName(), "", "")
 	if imageCache != nil {
@@ -229,7 +234,9 @@ func (d *SLocalDisk) createFromTemplate(
 			}
 		}
 
-		newImg, err := qemuimg.NewQemuImage(d.GetPath())
+		// data written by guest is sealed in the encrypted overlay,
+		// the template image itself stays shared in the image cache
+		newImg, err := NewDiskQemuImage(d.GetPath(), encryptInfo)
 		if err != nil {
 			log.Errorln(err)
 			return nil, err
@@ -259,12 +266,12 @@ func (d *SLocalDisk) CreateFromUrl(ctx context.Context, url string, size int64)
 }
 
 func (d *SLocalDisk) CreateRaw(ctx context.Context, sizeMB int, diskFormat, fsFormat string,
-	encryption bool, uuid string, back string) (jsonutils.JSONObject, error) {
+	encryptInfo *apis.SEncryptInfo, uuid string, back string) (jsonutils.JSONObject, error) {
 	if fileutils2.Exists(d.GetPath()) {
 		os.Remove(d.GetPath())
 	}
 
-	img, err := qemuimg.NewQemuImage(d.GetPath())
+	img, err := NewDiskQemuImage(d.GetPath(), encryptInfo)
 	if err != nil {
 		log.Errorln(err)
 		return nil, err
@@ -289,7 +296,7 @@ func (d *SLocalDisk) CreateRaw(ctx context.Context, sizeMB int, diskFormat, fsFo
 	}
 
 	if utils.IsInStringArray(fsFormat, []string{"swap", "ext2", "ext3", "ext4", "xfs"}) {
-		d.FormatFs(fsFormat, uuid, d.GetPath())
+		d.FormatFs(fsFormat, uuid, d.GetPath(), encryptInfo)
 	}
 
 	return d.GetDiskDesc(), nil
@@ -347,13 +354,17 @@ func (d *SLocalDisk) CreateSnapshot(snapshotId string) error {
 			return errors.Wrapf(err, "mkdir %s failed: %s", snapshotDir, output)
 		}
 	}
+	encryptInfo, err := d.GetEncryptInfo(context.Background())
+	if err != nil {
+		return errors.Wrap(err, "get encrypt info")
+	}
 	snapshotPath := path.Join(snapshotDir, snapshotId)
 	output, err := procutils.NewCommand("mv", "-f", d.getPath(), snapshotPath).Output()
 	if err != nil {
 		log.Errorf("mv %s to %s failed %s", d.getPath(), snapshotPath, output)
 		return errors.Wrapf(err, "mv %s to %s failed %s", d.getPath(), snapshotPath, output)
 	}
-	img, err := qemuimg.NewQemuImage(d.getPath())
+	img, err := NewDiskQemuImage(d.getPath(), encryptInfo)
 	if err != nil {
 		log.Errorln(err)
 		procutils.NewCommand("mv", "-f", snapshotPath, d.getPath()).Run()
@@ -377,12 +388,16 @@ func (d *SLocalDisk) DeleteSnapshot(snapshotId, convertSnapshot string, pendingD
 				return err
 			}
 		}
+		encryptInfo, err := d.GetEncryptInfo(context.Background())
+		if err != nil {
+			return errors.Wrap(err, "get encrypt info")
+		}
 		convertSnapshotPath := path.Join(snapshotDir, convertSnapshot)
 		output := convertSnapshotPath + ".tmp"
 		if fileutils2.Exists(output) {
 			procutils.NewCommand("rm", "-f", output).Run()
 		}
-		img, err := qemuimg.NewQemuImage(convertSnapshotPath)
+		img, err := NewDiskQemuImage(convertSnapshotPath, encryptInfo)
 		if err != nil {
 			log.Errorln(err)
 			return err
@@ -455,17 +470,21 @@ func (d *SLocalDisk) ResetFromSnapshot(ctx context.Context, params interface{})
 
 	snapshotDir := d.GetSnapshotDir()
 	snapshotPath := path.Join(snapshotDir, resetParams.SnapshotId)
-	return d.resetFromSnapshot(snapshotPath, outOfChain)
+	encryptInfo, err := d.GetEncryptInfo(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get encrypt info")
+	}
+	return d.resetFromSnapshot(snapshotPath, outOfChain, encryptInfo)
 }
 
-func (d *SLocalDisk) resetFromSnapshot(snapshotPath string, outOfChain bool) (jsonutils.JSONObject, error) {
+func (d *SLocalDisk) resetFromSnapshot(snapshotPath string, outOfChain bool, encryptInfo *apis.SEncryptInfo) (jsonutils.JSONObject, error) {
 	diskTmpPath := d.GetPath() + "_reset.tmp"
 	if output, err := procutils.NewCommand("mv", "-f", d.GetPath(), diskTmpPath).Output(); err != nil {
 		err = errors.Wrapf(err, "mv disk to tmp failed: %s", output)
 		return nil, err
 	}
 	if !outOfChain {
-		img, err := qemuimg.NewQemuImage(d.GetPath())
+		img, err := NewDiskQemuImage(d.GetPath(), encryptInfo)
 		if err != nil {
 			err = errors.Wrap(err, "new qemu img")
 			procutils.NewCommand("mv", "-f", diskTmpPath, d.GetPath()).Run()
@@ -498,11 +517,15 @@ func (d *SLocalDisk) CleanupSnapshots(ctx context.Context, params interface{}) (
 		return nil, hostutils.ParamsError
 	}
 	snapshotDir := d.GetSnapshotDir()
+	encryptInfo, err := d.GetEncryptInfo(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get encrypt info")
+	}
 	for _, snapshotId := range cleanupParams.ConvertSnapshots {
 		snapId, _ := snapshotId.GetString()
 		snapshotPath := path.Join(snapshotDir, snapId)
 		output := snapshotPath + "_convert.tmp"
-		img, err := qemuimg.NewQemuImage(snapshotPath)
+		img, err := NewDiskQemuImage(snapshotPath, encryptInfo)
 		if err != nil {
 			log.Errorln(err)
 			return nil, err
@@ -0,0 +1,325 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageman
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/appctx"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/hostman/storageman/lvmutils"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+	"yunion.io/x/onecloud/pkg/util/qemutils"
+)
+
+var ErrLVMEncryptNotSupported = errors.Error("disk encryption is not supported by lvm storage")
+
+type SLVMDisk struct {
+	SBaseDisk
+}
+
+func NewLVMDisk(storage IStorage, id string) *SLVMDisk {
+	var ret = new(SLVMDisk)
+	ret.SBaseDisk = *NewBaseDisk(storage, id)
+	return ret
+}
+
+func (d *SLVMDisk) GetType() string {
+	return api.STORAGE_LVM
+}
+
+func (d *SLVMDisk) getStorage() *SLVMStorage {
+	return d.Storage.(*SLVMStorage)
+}
+
+func (d *SLVMDisk) getVg() string {
+	return d.getStorage().VgName
+}
+
+func (d *SLVMDisk) GetPath() string {
+	return lvmutils.GetLvPath(d.getVg(), d.Id)
+}
+
+func (d *SLVMDisk) GetSnapshotDir() string {
+	return ""
+}
+
+func (d *SLVMDisk) Probe() error {
+	lv, err := lvmutils.GetLv(d.getVg(), d.Id)
+	if err != nil {
+		return err
+	}
+	// thin snapshots and volumes of a rebooted host may be left inactive
+	if !lv.IsActive() {
+		return lvmutils.ActivateLv(d.getVg(), d.Id)
+	}
+	return nil
+}
+
+func (d *SLVMDisk) GetDiskDesc() jsonutils.JSONObject {
+	lv, err := lvmutils.GetLv(d.getVg(), d.Id)
+	if err != nil {
+		log.Errorln(err)
+		return nil
+	}
+	desc := map[string]interface{}{
+		"disk_id":     d.Id,
+		"disk_format": "raw",
+		"disk_path":   d.GetPath(),
+		"disk_size":   lv.SizeMb,
+	}
+	return jsonutils.Marshal(desc)
+}
+
+func (d *SLVMDisk) GetDiskSetupScripts(idx int) string {
+	return fmt.Sprintf("DISK_%d=%s\n", idx, d.GetPath())
+}
+
+func (d *SLVMDisk) Delete(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	log.Infof("Delete guest disk %s", d.GetPath())
+	if err := lvmutils.RemoveLv(d.getVg(), d.Id); err != nil {
+		return nil, err
+	}
+	d.Storage.RemoveDisk(d)
+	return nil, nil
+}
+
+func (d *SLVMDisk) OnRebuildRoot(ctx context.Context, params jsonutils.JSONObject) error {
+	_, err := d.Delete(ctx, params)
+	return err
+}
+
+func (d *SLVMDisk) Resize(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	diskInfo, ok := params.(*jsonutils.JSONDict)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	sizeMb, _ := diskInfo.Int("size")
+	lv, err := lvmutils.GetLv(d.getVg(), d.Id)
+	if err != nil {
+		return nil, err
+	}
+	if sizeMb > lv.SizeMb {
+		if err := lvmutils.ExtendLv(d.getVg(), d.Id, sizeMb); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := d.ResizeFs(d.GetPath(), nil); err != nil {
+		return nil, errors.Wrapf(err, "resize fs %s", d.GetPath())
+	}
+
+	return d.GetDiskDesc(), nil
+}
+
+// createLv allocates volume from the thin pool if the storage has one
+func (d *SLVMDisk) createLv(name string, sizeMb int64) error {
+	return lvmutils.CreateLv(d.getVg(), d.getStorage().ThinPool, name, sizeMb)
+}
+
+// copyLv clones volume src to dest, thin volumes are cloned by thin
+// snapshot while thick volumes have to be copied block by block
+func (d *SLVMDisk) copyLv(src, dest string) error {
+	if d.getStorage().IsThin() {
+		return lvmutils.CreateThinSnapshot(d.getVg(), src, dest)
+	}
+	lv, err := lvmutils.GetLv(d.getVg(), src)
+	if err != nil {
+		return err
+	}
+	if err := lvmutils.CreateLv(d.getVg(), "", dest, lv.SizeMb); err != nil {
+		return err
+	}
+	err = procutils.NewRemoteCommandAsFarAsPossible(qemutils.GetQemuImg(), "convert", "-n",
+		"-f", "raw", "-O", "raw", lvmutils.GetLvPath(d.getVg(), src), lvmutils.GetLvPath(d.getVg(), dest)).Run()
+	if err != nil {
+		lvmutils.RemoveLv(d.getVg(), dest)
+		return errors.Wrapf(err, "copy %s to %s", src, dest)
+	}
+	return nil
+}
+
+func (d *SLVMDisk) CreateRaw(ctx context.Context, sizeMb int, diskFromat string, fsFormat string,
+	encryptInfo *apis.SEncryptInfo, diskId string, back string) (jsonutils.JSONObject, error) {
+	if encryptInfo != nil {
+		return nil, ErrLVMEncryptNotSupported
+	}
+	if err := d.createLv(d.Id, int64(sizeMb)); err != nil {
+		return nil, errors.Wrap(err, "create logical volume")
+	}
+
+	if utils.IsInStringArray(fsFormat, []string{"swap", "ext2", "ext3", "ext4", "xfs"}) {
+		d.FormatFs(fsFormat, diskId, d.GetPath(), nil)
+	}
+
+	return d.GetDiskDesc(), nil
+}
+
+func (d *SLVMDisk) CreateFromTemplate(ctx context.Context, imageId string, format string, size int64, encryptInfo *apis.SEncryptInfo) (jsonutils.JSONObject, error) {
+	if encryptInfo != nil {
+		return nil, ErrLVMEncryptNotSupported
+	}
+	ret, err := d.createFromTemplate(ctx, imageId)
+	if err != nil {
+		return nil, err
+	}
+
+	retSize, _ := ret.Int("disk_size")
+	log.Infof("REQSIZE: %d, RETSIZE: %d", size, retSize)
+	if size > retSize {
+		params := jsonutils.NewDict()
+		params.Set("size", jsonutils.NewInt(size))
+		return d.Resize(ctx, params)
+	}
+
+	return ret, nil
+}
+
+func (d *SLVMDisk) createFromTemplate(ctx context.Context, imageId string) (jsonutils.JSONObject, error) {
+	imageCacheManager := d.getStorage().imagecacheManager
+	imageCache := imageCacheManager.AcquireImage(ctx, imageId, d.GetZoneName(), "", "")
+	if imageCache == nil {
+		return nil, fmt.Errorf("Fail to fetch image %s", imageId)
+	}
+	defer imageCacheManager.ReleaseImage(ctx, imageId)
+
+	if d.Probe() == nil {
+		if err := lvmutils.RemoveLv(d.getVg(), d.Id); err != nil {
+			return nil, errors.Wrapf(err, "remove stale disk %s", d.Id)
+		}
+	}
+	if err := d.copyLv(imageCache.GetName(), d.Id); err != nil {
+		return nil, errors.Wrapf(err, "create disk %s from image cache", d.Id)
+	}
+	return d.GetDiskDesc(), nil
+}
+
+// CreateFromSnapshotLocation clones the disk from snapshot volume of the same volume group
+func (d *SLVMDisk) CreateFromSnapshotLocation(ctx context.Context, location string, size int64) error {
+	if err := d.copyLv(location, d.Id); err != nil {
+		return errors.Wrapf(err, "create disk %s from snapshot %s", d.Id, location)
+	}
+	if lv, err := lvmutils.GetLv(d.getVg(), d.Id); err == nil && size > lv.SizeMb {
+		return lvmutils.ExtendLv(d.getVg(), d.Id, size)
+	}
+	return nil
+}
+
+func (d *SLVMDisk) CreateFromImageFuse(ctx context.Context, url string, size int64) error {
+	return fmt.Errorf("Not support")
+}
+
+func (d *SLVMDisk) PostCreateFromImageFuse() {
+	log.Errorf("Not support PostCreateFromImageFuse")
+}
+
+func (d *SLVMDisk) PrepareMigrate(liveMigrate bool) (string, error) {
+	return "", fmt.Errorf("Not support")
+}
+
+func (d *SLVMDisk) PrepareSaveToGlance(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	if err := d.Probe(); err != nil {
+		return nil, err
+	}
+	backupName := fmt.Sprintf("%s%s_%s", _LVM_IMGSAVE_PREFIX_, d.Id, appctx.AppContextTaskId(ctx))
+	if err := d.copyLv(d.Id, backupName); err != nil {
+		return nil, errors.Wrap(err, "backup disk")
+	}
+	res := jsonutils.NewDict()
+	res.Set("backup", jsonutils.NewString(lvmutils.GetLvPath(d.getVg(), backupName)))
+	return res, nil
+}
+
+func (d *SLVMDisk) CreateSnapshot(snapshotId string) error {
+	if !d.getStorage().IsThin() {
+		return fmt.Errorf("snapshot requires lvm storage %s with thin pool", d.getVg())
+	}
+	return lvmutils.CreateThinSnapshot(d.getVg(), d.Id, d.getStorage().getSnapshotLvName(d.Id, snapshotId))
+}
+
+func (d *SLVMDisk) DeleteSnapshot(snapshotId, convertSnapshot string, pendingDelete bool) error {
+	return d.DoDeleteSnapshot(snapshotId)
+}
+
+func (d *SLVMDisk) DoDeleteSnapshot(snapshotId string) error {
+	return lvmutils.RemoveLv(d.getVg(), d.getStorage().getSnapshotLvName(d.Id, snapshotId))
+}
+
+func (d *SLVMDisk) DeleteAllSnapshot() error {
+	return d.getStorage().deleteLvsWithPrefix(d.Id + _LVM_SNAPSHOT_INFIX_)
+}
+
+func (d *SLVMDisk) DiskSnapshot(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	snapshotId, ok := params.(string)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	return nil, d.CreateSnapshot(snapshotId)
+}
+
+func (d *SLVMDisk) DiskDeleteSnapshot(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	snapshotId, ok := params.(string)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	err := d.DeleteSnapshot(snapshotId, "", false)
+	if err != nil {
+		return nil, err
+	} else {
+		res := jsonutils.NewDict()
+		res.Set("deleted", jsonutils.JSONTrue)
+		return res, nil
+	}
+}
+
+func (d *SLVMDisk) ResetFromSnapshot(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	resetParams, ok := params.(*SDiskReset)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	snapshotLv := d.getStorage().getSnapshotLvName(d.Id, resetParams.SnapshotId)
+	diskTmpLv := d.Id + "_reset"
+	if err := lvmutils.RenameLv(d.getVg(), d.Id, diskTmpLv); err != nil {
+		return nil, errors.Wrap(err, "rename disk to tmp")
+	}
+	if err := lvmutils.CreateThinSnapshot(d.getVg(), snapshotLv, d.Id); err != nil {
+		lvmutils.RenameLv(d.getVg(), diskTmpLv, d.Id)
+		return nil, errors.Wrap(err, "create disk by snapshot")
+	}
+	return nil, lvmutils.RemoveLv(d.getVg(), diskTmpLv)
+}
+
+func (d *SLVMDisk) CleanupSnapshots(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	cleanupParams, ok := params.(*SDiskCleanupSnapshots)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	// thin snapshots are out of chain, nothing to convert
+	for _, snapshotId := range cleanupParams.DeleteSnapshots {
+		snapId, _ := snapshotId.GetString()
+		if err := d.DoDeleteSnapshot(snapId); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
@@ -24,6 +24,7 @@ import (
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 
+	"yunion.io/x/onecloud/pkg/apis"
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/hostman/hostutils"
 	"yunion.io/x/onecloud/pkg/httperrors"
@@ -38,9 +39,9 @@ func NewNasDisk(storage IStorage, id string) *SNasDisk {
 	return &SNasDisk{*NewLocalDisk(storage, id)}
 }
 
-func (d *SNasDisk) CreateFromTemplate(ctx context.Context, imageId, format string, size int64) (jsonutils.JSONObject, error) {
+func (d *SNasDisk) CreateFromTemplate(ctx context.Context, imageId, format string, size int64, encryptInfo *apis.SEncryptInfo) (jsonutils.JSONObject, error) {
 	imageCacheManager := storageManager.GetStoragecacheById(d.Storage.GetStoragecacheId())
-	ret, err := d.SLocalDisk.createFromTemplate(ctx, imageId, format, imageCacheManager)
+	ret, err := d.SLocalDisk.createFromTemplate(ctx, imageId, format, imageCacheManager, encryptInfo)
 	if err != nil {
 		return nil, err
 	}
@@ -102,7 +103,11 @@ func (d *SNasDisk) ResetFromSnapshot(ctx context.Context, params interface{}) (j
 	}
 	snapshotPath := path.Join(d.Storage.GetPath(), location)
 	log.Infof("Snapshot path is %s", snapshotPath)
-	return d.resetFromSnapshot(snapshotPath, outOfChain)
+	encryptInfo, err := d.GetEncryptInfo(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get encrypt info")
+	}
+	return d.resetFromSnapshot(snapshotPath, outOfChain, encryptInfo)
 }
 
 func (d *SNasDisk) GetSnapshotLocation() string {
@@ -27,6 +27,7 @@ import (
 	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/utils"
 
+	"yunion.io/x/onecloud/pkg/apis"
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/appctx"
 	"yunion.io/x/onecloud/pkg/hostman/hostutils"
@@ -121,11 +122,25 @@ func (d *SRBDDisk) Resize(ctx context.Context, params interface{}) (jsonutils.JS
 	storageConf := d.Storage.GetStorageConf()
 	pool, _ := storageConf.GetString("pool")
 	sizeMb, _ := diskInfo.Int("size")
-	if err := storage.resizeImage(pool, d.Id, uint64(sizeMb)); err != nil {
+	encryptInfo, err := d.GetEncryptInfo(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get encrypt info")
+	}
+	if encryptInfo != nil {
+		// the rbd image also holds the LUKS header, let qemu-img
+		// grow the payload instead of resizing the image directly
+		img, err := NewDiskQemuImage(d.GetPath(), encryptInfo)
+		if err != nil {
+			return nil, errors.Wrap(err, "open encrypted image")
+		}
+		if err := img.Resize(int(sizeMb)); err != nil {
+			return nil, errors.Wrap(err, "resize encrypted image")
+		}
+	} else if err := storage.resizeImage(pool, d.Id, uint64(sizeMb)); err != nil {
 		return nil, err
 	}
 
-	if err := d.ResizeFs(d.GetPath()); err != nil {
+	if err := d.ResizeFs(d.GetPath(), encryptInfo); err != nil {
 		return nil, errors.Wrapf(err, "resize fs %s", d.GetPath())
 	}
 
@@ -160,8 +175,8 @@ func (d *SRBDDisk) PrepareMigrate(liveMigrate bool) (string, error) {
 	return "", fmt.Errorf("Not support")
 }
 
-func (d *SRBDDisk) CreateFromTemplate(ctx context.Context, imageId string, format string, size int64) (jsonutils.JSONObject, error) {
-	ret, err := d.createFromTemplate(ctx, imageId, format)
+func (d *SRBDDisk) CreateFromTemplate(ctx context.Context, imageId string, format string, size int64, encryptInfo *apis.SEncryptInfo) (jsonutils.JSONObject, error) {
+	ret, err := d.createFromTemplate(ctx, imageId, format, encryptInfo)
 	if err != nil {
 		return nil, err
 	}
@@ -177,7 +192,7 @@ func (d *SRBDDisk) CreateFromTemplate(ctx context.Context, imageId string, forma
 	return ret, nil
 }
 
-func (d *SRBDDisk) createFromTemplate(ctx context.Context, imageId, format string) (jsonutils.JSONObject, error) {
+func (d *SRBDDisk) createFromTemplate(ctx context.Context, imageId, format string, encryptInfo *apis.SEncryptInfo) (jsonutils.JSONObject, error) {
 	var imageCacheManager = storageManager.GetStoragecacheById(d.Storage.GetStoragecacheId())
 	if imageCacheManager == nil {
 		return nil, fmt.Errorf("failed to find image cache manger for storage %s", d.Storage.GetStorageName())
@@ -190,6 +205,19 @@ func (d *SRBDDisk) createFromTemplate(ctx context.Context, imageId, format strin
 	storage := d.Storage.(*SRbdStorage)
 	destPool, _ := storage.StorageConf.GetString("pool")
 	storage.deleteImage(destPool, d.Id) //重装系统时，需要删除以前的系统盘
+	if encryptInfo != nil {
+		// a clone would share the plain image cache, copy the template
+		// into a LUKS image instead
+		cachePath := fmt.Sprintf("rbd:%s/%s%s", imageCacheManager.GetPath(), imageCache.GetName(), storage.getStorageConfString())
+		img, err := NewDiskQemuImage(cachePath, encryptInfo)
+		if err != nil {
+			return nil, errors.Wrap(err, "open image cache")
+		}
+		if _, err := img.CloneRaw(d.GetPath()); err != nil {
+			return nil, errors.Wrap(err, "convert image cache to encrypted image")
+		}
+		return d.GetDiskDesc(), nil
+	}
 	if err := storage.cloneImage(ctx, imageCacheManager.GetPath(), imageCache.GetName(), destPool, d.Id); err != nil {
 		return nil, err
 	}
@@ -200,15 +228,23 @@ func (d *SRBDDisk) CreateFromImageFuse(ctx context.Context, url string, size int
 	return fmt.Errorf("Not support")
 }
 
-func (d *SRBDDisk) CreateRaw(ctx context.Context, sizeMb int, diskFromat string, fsFormat string, encryption bool, diskId string, back string) (jsonutils.JSONObject, error) {
+func (d *SRBDDisk) CreateRaw(ctx context.Context, sizeMb int, diskFromat string, fsFormat string, encryptInfo *apis.SEncryptInfo, diskId string, back string) (jsonutils.JSONObject, error) {
 	storage := d.Storage.(*SRbdStorage)
 	pool, _ := storage.StorageConf.GetString("pool")
-	if err := storage.createImage(pool, diskId, uint64(sizeMb)); err != nil {
+	if encryptInfo != nil {
+		img, err := NewDiskQemuImage(d.GetPath(), encryptInfo)
+		if err != nil {
+			return nil, errors.Wrap(err, "open encrypted image")
+		}
+		if err := img.CreateRaw(sizeMb); err != nil {
+			return nil, errors.Wrap(err, "create encrypted image")
+		}
+	} else if err := storage.createImage(pool, diskId, uint64(sizeMb)); err != nil {
 		return nil, err
 	}
 
 	if utils.IsInStringArray(fsFormat, []string{"swap", "ext2", "ext3", "ext4", "xfs"}) {
-		d.FormatFs(fsFormat, diskId, d.GetPath())
+		d.FormatFs(fsFormat, diskId, d.GetPath(), encryptInfo)
 	}
 
 	return d.GetDiskDesc(), nil
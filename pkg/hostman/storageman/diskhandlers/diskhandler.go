@@ -25,6 +25,7 @@ import (
 	"yunion.io/x/pkg/util/regutils"
 
 	"yunion.io/x/onecloud/pkg/apis/compute"
+	hostapi "yunion.io/x/onecloud/pkg/apis/host"
 	"yunion.io/x/onecloud/pkg/appsrv"
 	"yunion.io/x/onecloud/pkg/cloudcommon/workmanager"
 	"yunion.io/x/onecloud/pkg/cloudprovider"
@@ -48,6 +49,8 @@ var (
 		"snapshot":          diskSnapshot,
 		"delete-snapshot":   diskDeleteSnapshot,
 		"cleanup-snapshots": diskCleanupSnapshots,
+		"backup":            diskBackup,
+		"restore-backup":    diskRestoreBackup,
 	}
 )
 
@@ -76,6 +79,9 @@ func AddDiskHandler(prefix string, app *appsrv.Application) {
 			fmt.Sprintf("%s/%s/<storageId>/<diskId>/status", prefix, keyWord),
 			auth.Authenticate(getDiskStatus))
 	}
+	app.AddHandler("POST",
+		fmt.Sprintf("%s/disk_backups/delete", prefix),
+		auth.Authenticate(deleteDiskBackups))
 	for _, keyWord := range snapshotKeywords {
 		app.AddHandler("GET",
 			fmt.Sprintf("%s/%s/<storageId>/<diskId>/<snapshotId>/status", prefix, keyWord),
@@ -348,3 +354,52 @@ func diskCleanupSnapshots(ctx context.Context, storage storageman.IStorage, disk
 	})
 	return nil, nil
 }
+
+func diskBackup(ctx context.Context, storage storageman.IStorage, diskId string, disk storageman.IDisk, body jsonutils.JSONObject) (interface{}, error) {
+	req := &hostapi.DiskBackupRequest{}
+	if err := body.Unmarshal(req); err != nil {
+		return nil, httperrors.NewInputParameterError("unmarshal: %v", err)
+	}
+	if len(req.BackupId) == 0 {
+		return nil, httperrors.NewMissingParameterError("backup_id")
+	}
+	if req.Mode == compute.DISK_BACKUP_MODE_INCREMENTAL && len(req.ParentId) == 0 {
+		return nil, httperrors.NewMissingParameterError("parent_id")
+	}
+	// online backup task reports result by itself
+	hostutils.DelayTaskWithoutReqctx(ctx, guestman.GetGuestManager().DiskBackup, &storageman.SDiskBackup{
+		Disk:    disk,
+		Request: req,
+	})
+	return nil, nil
+}
+
+func diskRestoreBackup(ctx context.Context, storage storageman.IStorage, diskId string, disk storageman.IDisk, body jsonutils.JSONObject) (interface{}, error) {
+	req := &hostapi.DiskBackupRestoreRequest{}
+	if err := body.Unmarshal(req); err != nil {
+		return nil, httperrors.NewInputParameterError("unmarshal: %v", err)
+	}
+	if len(req.Backups) == 0 {
+		return nil, httperrors.NewMissingParameterError("backups")
+	}
+	hostutils.DelayTask(ctx, storageman.RestoreDiskBackup, &storageman.SDiskBackupRestore{
+		Disk:    disk,
+		Request: req,
+	})
+	return nil, nil
+}
+
+func deleteDiskBackups(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	_, _, body := appsrv.FetchEnv(ctx, w, r)
+	req := &hostapi.DiskBackupDeleteRequest{}
+	if body == nil || body.Unmarshal(req) != nil {
+		hostutils.Response(ctx, w, httperrors.NewInputParameterError("invalid body"))
+		return
+	}
+	if len(req.DiskId) == 0 {
+		hostutils.Response(ctx, w, httperrors.NewMissingParameterError("disk_id"))
+		return
+	}
+	hostutils.DelayTask(ctx, storageman.DeleteDiskBackups, req)
+	hostutils.ResponseOk(ctx, w)
+}
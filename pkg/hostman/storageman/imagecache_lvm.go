@@ -0,0 +1,135 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageman
+
+import (
+	"context"
+
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/hostman/storageman/lvmutils"
+	"yunion.io/x/onecloud/pkg/hostman/storageman/remotefile"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+	"yunion.io/x/onecloud/pkg/util/qemuimg"
+	"yunion.io/x/onecloud/pkg/util/qemutils"
+)
+
+type SLVMImageCache struct {
+	imageId   string
+	imageName string
+	Manager   *SLVMImageCacheManager
+}
+
+func NewLVMImageCache(imageId string, imagecacheManager *SLVMImageCacheManager) *SLVMImageCache {
+	imageCache := new(SLVMImageCache)
+	imageCache.imageId = imageId
+	imageCache.Manager = imagecacheManager
+	return imageCache
+}
+
+func (r *SLVMImageCache) getVg() string {
+	return r.Manager.storage.VgName
+}
+
+func (r *SLVMImageCache) GetName() string {
+	return _LVM_IMAGECACHE_PREFIX_ + r.imageId
+}
+
+func (r *SLVMImageCache) GetPath() string {
+	return lvmutils.GetLvPath(r.getVg(), r.GetName())
+}
+
+func (r *SLVMImageCache) Load() bool {
+	_, err := lvmutils.GetLv(r.getVg(), r.GetName())
+	return err == nil
+}
+
+func (r *SLVMImageCache) Acquire(ctx context.Context, zone, srcUrl, format string) bool {
+	localImageCacheManager := storageManager.LocalStorageImagecacheManager
+	localImageCache := localImageCacheManager.AcquireImage(ctx, r.imageId, zone, srcUrl, format)
+	if localImageCache == nil {
+		log.Errorf("failed to acquireimage %s ", r.imageId)
+		return false
+	}
+	defer localImageCacheManager.ReleaseImage(ctx, r.imageId)
+	r.imageName = localImageCache.GetName()
+	if r.Load() {
+		return true
+	}
+
+	log.Infof("convert local image %s to volume group %s", r.imageId, r.getVg())
+	origin, err := qemuimg.NewQemuImage(localImageCache.GetPath())
+	if err != nil {
+		log.Errorf("failed to open local image %s: %s", localImageCache.GetPath(), err)
+		return false
+	}
+	// convert to a temporary volume first, a half written
+	// volume must never be loaded as image cache
+	tmpName := r.GetName() + "_tmp"
+	lvmutils.RemoveLv(r.getVg(), tmpName)
+	if err := lvmutils.CreateLv(r.getVg(), r.Manager.storage.ThinPool, tmpName, int64(origin.GetSizeMB())); err != nil {
+		log.Errorf("failed to create image cache volume %s", err)
+		return false
+	}
+	err = procutils.NewRemoteCommandAsFarAsPossible(qemutils.GetQemuImg(),
+		"convert", "-n", "-O", "raw", localImageCache.GetPath(), lvmutils.GetLvPath(r.getVg(), tmpName)).Run()
+	if err != nil {
+		log.Errorf("failed to convert image %s", err)
+		lvmutils.RemoveLv(r.getVg(), tmpName)
+		return false
+	}
+	if err := lvmutils.RenameLv(r.getVg(), tmpName, r.GetName()); err != nil {
+		log.Errorf("failed to rename image cache volume %s", err)
+		lvmutils.RemoveLv(r.getVg(), tmpName)
+		return false
+	}
+	return r.Load()
+}
+
+func (r *SLVMImageCache) Release() {
+	return
+}
+
+func (r *SLVMImageCache) Remove(ctx context.Context) error {
+	if err := lvmutils.RemoveLv(r.getVg(), r.GetName()); err != nil {
+		return err
+	}
+
+	go func() {
+		_, err := modules.Storagecachedimages.Detach(hostutils.GetComputeSession(ctx),
+			r.Manager.GetId(), r.imageId, nil)
+		if err != nil {
+			log.Errorf("Fail to delete host cached image: %s", err)
+		}
+	}()
+	return nil
+}
+
+func (r *SLVMImageCache) GetDesc() *remotefile.SImageDesc {
+	var size int64
+	if lv, err := lvmutils.GetLv(r.getVg(), r.GetName()); err == nil {
+		size = lv.SizeMb
+	}
+	return &remotefile.SImageDesc{
+		Size: size,
+		Name: r.imageName,
+	}
+}
+
+func (r *SLVMImageCache) GetImageId() string {
+	return r.imageId
+}
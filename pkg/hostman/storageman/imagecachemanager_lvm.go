@@ -0,0 +1,145 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageman
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/hostman/storageman/lvmutils"
+)
+
+const _LVM_IMAGECACHE_PREFIX_ = "imagecache_"
+
+// SLVMImageCacheManager keeps image caches as logical volumes
+// of the volume group, disks are cloned from them
+type SLVMImageCacheManager struct {
+	SBaseImageCacheManager
+	storage *SLVMStorage
+}
+
+func NewLVMImageCacheManager(manager IStorageManager, storage *SLVMStorage) *SLVMImageCacheManager {
+	imageCacheManager := new(SLVMImageCacheManager)
+
+	imageCacheManager.storageManager = manager
+	imageCacheManager.storage = storage
+	imageCacheManager.cachePath = storage.GetPath()
+	imageCacheManager.cachedImages = make(map[string]IImageCache, 0)
+	imageCacheManager.loadCache(context.Background())
+	return imageCacheManager
+}
+
+func (c *SLVMImageCacheManager) loadCache(ctx context.Context) {
+	lockman.LockRawObject(ctx, "LVM", c.storage.VgName)
+	defer lockman.ReleaseRawObject(ctx, "LVM", c.storage.VgName)
+
+	lvs, err := lvmutils.ListLvs(c.storage.VgName)
+	if err != nil {
+		log.Errorf("get volume group %s logical volumes error: %v", c.storage.VgName, err)
+		return
+	}
+	for _, lv := range lvs {
+		if strings.HasPrefix(lv.Name, _LVM_IMAGECACHE_PREFIX_) {
+			c.LoadImageCache(strings.TrimPrefix(lv.Name, _LVM_IMAGECACHE_PREFIX_))
+		}
+	}
+}
+
+func (c *SLVMImageCacheManager) LoadImageCache(imageId string) {
+	imageCache := NewLVMImageCache(imageId, c)
+	if imageCache.Load() {
+		c.cachedImages[imageId] = imageCache
+	}
+}
+
+func (c *SLVMImageCacheManager) PrefetchImageCache(ctx context.Context, data interface{}) (jsonutils.JSONObject, error) {
+	body, ok := data.(*jsonutils.JSONDict)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+
+	imageId, err := body.GetString("image_id")
+	if err != nil {
+		return nil, err
+	}
+	format, _ := body.GetString("format")
+	srcUrl, _ := body.GetString("src_url")
+	zone, _ := body.GetString("zone")
+
+	cache := c.AcquireImage(ctx, imageId, zone, srcUrl, format)
+	if cache == nil {
+		return nil, fmt.Errorf("failed to cache image %s.%s", imageId, format)
+	}
+
+	res := map[string]interface{}{
+		"image_id": imageId,
+		"path":     cache.GetPath(),
+	}
+	if desc := cache.GetDesc(); desc != nil {
+		res["name"] = desc.Name
+		res["size"] = desc.Size
+	}
+	return jsonutils.Marshal(res), nil
+}
+
+func (c *SLVMImageCacheManager) DeleteImageCache(ctx context.Context, data interface{}) (jsonutils.JSONObject, error) {
+	body, ok := data.(*jsonutils.JSONDict)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+
+	imageId, _ := body.GetString("image_id")
+	return nil, c.removeImage(ctx, imageId)
+}
+
+func (c *SLVMImageCacheManager) removeImage(ctx context.Context, imageId string) error {
+	lockman.LockRawObject(ctx, "image-cache", imageId)
+	defer lockman.ReleaseRawObject(ctx, "image-cache", imageId)
+
+	if img, ok := c.cachedImages[imageId]; ok {
+		delete(c.cachedImages, imageId)
+		return img.Remove(ctx)
+	}
+	return nil
+}
+
+func (c *SLVMImageCacheManager) AcquireImage(ctx context.Context, imageId, zone, srcUrl, format string) IImageCache {
+	lockman.LockRawObject(ctx, "image-cache", imageId)
+	defer lockman.ReleaseRawObject(ctx, "image-cache", imageId)
+
+	img, ok := c.cachedImages[imageId]
+	if !ok {
+		img = NewLVMImageCache(imageId, c)
+		c.cachedImages[imageId] = img
+	}
+	if img.Acquire(ctx, zone, srcUrl, format) {
+		return img
+	}
+	return nil
+}
+
+func (c *SLVMImageCacheManager) ReleaseImage(ctx context.Context, imageId string) {
+	lockman.LockRawObject(ctx, "image-cache", imageId)
+	defer lockman.ReleaseRawObject(ctx, "image-cache", imageId)
+	if img, ok := c.cachedImages[imageId]; ok {
+		img.Release()
+	}
+}
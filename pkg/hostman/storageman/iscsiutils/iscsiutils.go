@@ -0,0 +1,282 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iscsiutils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+)
+
+const (
+	ErrDeviceNotFound = errors.Error("device not found")
+
+	byPathDir = "/dev/disk/by-path"
+	byIdDir   = "/dev/disk/by-id"
+)
+
+type SSession struct {
+	Sid    int
+	Portal string
+	Target string
+}
+
+func run(name string, args ...string) ([]byte, error) {
+	out, err := procutils.NewRemoteCommandAsFarAsPossible(name, args...).Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s: %s", name, strings.Join(args, " "), out)
+	}
+	return out, nil
+}
+
+func iscsiadmNode(target, portal string, args ...string) ([]byte, error) {
+	return run("iscsiadm", append([]string{"-m", "node", "-T", target, "-p", portal}, args...)...)
+}
+
+// parseSessions parses lines of `iscsiadm -m session` like
+// tcp: [1] 192.168.222.10:3260,1 iqn.2004-04.com.qnap:target0 (non-flash)
+func parseSessions(output string) ([]SSession, error) {
+	sessions := []SSession{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		segs := strings.Fields(line)
+		if len(segs) < 4 || !strings.HasPrefix(segs[1], "[") || !strings.HasSuffix(segs[1], "]") {
+			return nil, errors.Errorf("invalid session line %q", line)
+		}
+		sid, err := strconv.Atoi(strings.Trim(segs[1], "[]"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse session id %q", segs[1])
+		}
+		portal := segs[2]
+		if pos := strings.LastIndex(portal, ","); pos > 0 {
+			portal = portal[:pos]
+		}
+		sessions = append(sessions, SSession{Sid: sid, Portal: portal, Target: segs[3]})
+	}
+	return sessions, nil
+}
+
+func ListSessions() ([]SSession, error) {
+	out, err := procutils.NewRemoteCommandAsFarAsPossible("iscsiadm", "-m", "session").Output()
+	if err != nil {
+		// iscsiadm exits with error if there is no session at all
+		if strings.Contains(string(out), "No active sessions") {
+			return []SSession{}, nil
+		}
+		return nil, errors.Wrapf(err, "iscsiadm -m session: %s", out)
+	}
+	return parseSessions(string(out))
+}
+
+func HasSession(target, portal string) (bool, error) {
+	sessions, err := ListSessions()
+	if err != nil {
+		return false, err
+	}
+	for _, s := range sessions {
+		if s.Target == target && s.Portal == portal {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Login creates the node record of target on portal if it doesn't
+// exist and logs in to it, nothing is done if the session is present
+func Login(target, portal, chapUsername, chapPassword string) error {
+	if ok, err := HasSession(target, portal); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+	if _, err := iscsiadmNode(target, portal); err != nil {
+		if _, err := iscsiadmNode(target, portal, "-o", "new"); err != nil {
+			return errors.Wrap(err, "create node record")
+		}
+	}
+	settings := [][2]string{{"node.startup", "automatic"}}
+	if len(chapUsername) > 0 {
+		settings = append(settings,
+			[2]string{"node.session.auth.authmethod", "CHAP"},
+			[2]string{"node.session.auth.username", chapUsername},
+			[2]string{"node.session.auth.password", chapPassword},
+		)
+	} else {
+		settings = append(settings, [2]string{"node.session.auth.authmethod", "None"})
+	}
+	for _, kv := range settings {
+		if _, err := iscsiadmNode(target, portal, "-o", "update", "-n", kv[0], "-v", kv[1]); err != nil {
+			return errors.Wrapf(err, "update node %s", kv[0])
+		}
+	}
+	_, err := iscsiadmNode(target, portal, "--login")
+	return err
+}
+
+// Logout logs out of the target on portal and removes the node record
+func Logout(target, portal string) error {
+	if ok, err := HasSession(target, portal); err != nil {
+		return err
+	} else if ok {
+		if _, err := iscsiadmNode(target, portal, "--logout"); err != nil {
+			return err
+		}
+	}
+	_, err := iscsiadmNode(target, portal, "-o", "delete")
+	return err
+}
+
+// Rescan makes the luns newly exported by the target visible
+func Rescan(target, portal string) error {
+	_, err := iscsiadmNode(target, portal, "--rescan")
+	return err
+}
+
+func lunPathPrefix(portal, target string) string {
+	return fmt.Sprintf("ip-%s-iscsi-%s-lun-", portal, target)
+}
+
+// GetLunPath returns the udev by-path link of the lun reached through portal
+func GetLunPath(portal, target string, lun int) string {
+	return path.Join(byPathDir, fmt.Sprintf("%s%d", lunPathPrefix(portal, target), lun))
+}
+
+// parseLunPath returns the lun number of by-path link name, partitions are skipped
+func parseLunPath(name, portal, target string) (int, bool) {
+	prefix := lunPathPrefix(portal, target)
+	if !strings.HasPrefix(name, prefix) {
+		return 0, false
+	}
+	lun, err := strconv.Atoi(name[len(prefix):])
+	if err != nil {
+		return 0, false
+	}
+	return lun, true
+}
+
+// ListLunPaths returns by-path links of luns of target reached through portal
+func ListLunPaths(portal, target string) (map[int]string, error) {
+	files, err := ioutil.ReadDir(byPathDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s", byPathDir)
+	}
+	ret := map[int]string{}
+	for _, f := range files {
+		if lun, ok := parseLunPath(f.Name(), portal, target); ok {
+			ret[lun] = path.Join(byPathDir, f.Name())
+		}
+	}
+	return ret, nil
+}
+
+func getDeviceSize(dev string) (int64, error) {
+	out, err := run("blockdev", "--getsize64", dev)
+	if err != nil {
+		return 0, err
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "parse size %q", out)
+	}
+	return size, nil
+}
+
+func GetDeviceSizeMb(dev string) (int64, error) {
+	size, err := getDeviceSize(dev)
+	if err != nil {
+		return 0, err
+	}
+	return size / 1024 / 1024, nil
+}
+
+// GetWwid returns the scsi identifier shared by all paths of the lun
+func GetWwid(dev string) (string, error) {
+	out, err := run("/lib/udev/scsi_id", "-g", "-u", "-d", dev)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func GetMultipathPath(wwid string) string {
+	return path.Join(byIdDir, "dm-uuid-mpath-"+wwid)
+}
+
+// AddMultipath whitelists the wwid of dev and assembles its multipath map
+func AddMultipath(dev string) error {
+	if _, err := run("multipath", "-a", dev); err != nil {
+		return err
+	}
+	_, err := run("multipath", dev)
+	return err
+}
+
+// FlushMultipath removes the multipath map, it fails if the map is still opened
+func FlushMultipath(dev string) error {
+	realPath, err := filepath.EvalSymlinks(dev)
+	if err != nil {
+		return errors.Wrapf(err, "resolve %s", dev)
+	}
+	_, err = run("multipath", "-f", realPath)
+	return err
+}
+
+// IsMultipathDevice tells whether the link resolves to a device mapper device
+func IsMultipathDevice(dev string) bool {
+	realPath, err := filepath.EvalSymlinks(dev)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(path.Base(realPath), "dm-")
+}
+
+// ZeroDevice overwrites the whole device with zeroes, blkdiscard -z offloads
+// it to the array if supported, otherwise zeroes are written by dd
+func ZeroDevice(dev string) error {
+	_, err := run("blkdiscard", "-z", dev)
+	if err == nil {
+		return nil
+	}
+	log.Warningf("blkdiscard -z %s: %s, fallback to dd", dev, err)
+	size, err := getDeviceSize(dev)
+	if err != nil {
+		return errors.Wrap(err, "get device size")
+	}
+	_, err = run("dd", "if=/dev/zero", "of="+dev, "bs=1M", fmt.Sprintf("count=%d", size),
+		"iflag=count_bytes", "oflag=direct", "conv=fsync")
+	return err
+}
+
+func WaitDevice(dev string, timeout time.Duration) error {
+	for start := time.Now(); time.Since(start) < timeout; time.Sleep(time.Second) {
+		if fileutils2.Exists(dev) {
+			return nil
+		}
+	}
+	return errors.Wrap(ErrDeviceNotFound, dev)
+}
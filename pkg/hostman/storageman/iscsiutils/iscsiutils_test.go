@@ -0,0 +1,71 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iscsiutils
+
+import (
+	"testing"
+)
+
+func TestParseSessions(t *testing.T) {
+	output := `tcp: [1] 192.168.222.10:3260,1 iqn.2004-04.com.qnap:target0 (non-flash)
+tcp: [12] [fd00::10]:3260,2 iqn.2004-04.com.qnap:target1 (non-flash)
+`
+	sessions, err := parseSessions(output)
+	if err != nil {
+		t.Fatalf("parse sessions: %v", err)
+	}
+	want := []SSession{
+		{Sid: 1, Portal: "192.168.222.10:3260", Target: "iqn.2004-04.com.qnap:target0"},
+		{Sid: 12, Portal: "[fd00::10]:3260", Target: "iqn.2004-04.com.qnap:target1"},
+	}
+	if len(sessions) != len(want) {
+		t.Fatalf("want %d sessions, got %d", len(want), len(sessions))
+	}
+	for i := range want {
+		if sessions[i] != want[i] {
+			t.Errorf("session %d: want %#v, got %#v", i, want[i], sessions[i])
+		}
+	}
+	if _, err := parseSessions("tcp: 1 192.168.222.10:3260,1\n"); err == nil {
+		t.Errorf("invalid line should fail")
+	}
+}
+
+func TestParseLunPath(t *testing.T) {
+	const (
+		portal = "192.168.222.10:3260"
+		target = "iqn.2004-04.com.qnap:target0"
+	)
+	cases := []struct {
+		name string
+		lun  int
+		ok   bool
+	}{
+		{"ip-192.168.222.10:3260-iscsi-iqn.2004-04.com.qnap:target0-lun-0", 0, true},
+		{"ip-192.168.222.10:3260-iscsi-iqn.2004-04.com.qnap:target0-lun-12", 12, true},
+		{"ip-192.168.222.10:3260-iscsi-iqn.2004-04.com.qnap:target0-lun-1-part1", 0, false},
+		{"ip-192.168.222.11:3260-iscsi-iqn.2004-04.com.qnap:target0-lun-1", 0, false},
+		{"pci-0000:00:1f.2-ata-1", 0, false},
+	}
+	for _, c := range cases {
+		lun, ok := parseLunPath(c.name, portal, target)
+		if ok != c.ok || lun != c.lun {
+			t.Errorf("%s: want (%d, %v), got (%d, %v)", c.name, c.lun, c.ok, lun, ok)
+		}
+	}
+	if GetLunPath(portal, target, 3) != "/dev/disk/by-path/"+cases[0].name[:len(cases[0].name)-1]+"3" {
+		t.Errorf("unexpected lun path %s", GetLunPath(portal, target, 3))
+	}
+}
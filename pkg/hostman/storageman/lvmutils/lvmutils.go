@@ -0,0 +1,227 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lvmutils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/util/procutils"
+)
+
+const (
+	ErrLvNotFound = errors.Error("logical volume not found")
+	ErrVgNotFound = errors.Error("volume group not found")
+
+	reportSeparator = "|"
+)
+
+type SVolumeGroup struct {
+	Name   string
+	SizeMb int64
+	FreeMb int64
+}
+
+type SLogicalVolume struct {
+	Name   string
+	VgName string
+	Attr   string
+	SizeMb int64
+	Pool   string
+	Origin string
+	// DataPercent is the usage of thin pools and thin volumes
+	DataPercent float64
+}
+
+// IsThinPool tells from the first lv_attr bit whether the volume is a thin pool
+func (lv *SLogicalVolume) IsThinPool() bool {
+	return strings.HasPrefix(lv.Attr, "t")
+}
+
+// IsActive tells from the fifth lv_attr bit whether the volume is active
+func (lv *SLogicalVolume) IsActive() bool {
+	return len(lv.Attr) > 4 && lv.Attr[4] == 'a'
+}
+
+func (lv *SLogicalVolume) GetUsedSizeMb() int64 {
+	return int64(float64(lv.SizeMb) * lv.DataPercent / 100)
+}
+
+func GetLvPath(vg, lv string) string {
+	return fmt.Sprintf("/dev/%s/%s", vg, lv)
+}
+
+func run(name string, args ...string) ([]byte, error) {
+	out, err := procutils.NewRemoteCommandAsFarAsPossible(name, args...).Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s: %s", name, strings.Join(args, " "), out)
+	}
+	return out, nil
+}
+
+func reportArgs(fields ...string) []string {
+	return []string{
+		"--noheadings", "--nosuffix", "--units", "m",
+		"--separator", reportSeparator, "-o", strings.Join(fields, ","),
+	}
+}
+
+func parseSizeMb(s string) (int64, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+	size, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "parse size %q", s)
+	}
+	return int64(size), nil
+}
+
+func parseVgs(output string) ([]SVolumeGroup, error) {
+	vgs := []SVolumeGroup{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		segs := strings.Split(line, reportSeparator)
+		if len(segs) != 3 {
+			return nil, errors.Errorf("invalid vgs line %q", line)
+		}
+		vg := SVolumeGroup{Name: segs[0]}
+		var err error
+		if vg.SizeMb, err = parseSizeMb(segs[1]); err != nil {
+			return nil, err
+		}
+		if vg.FreeMb, err = parseSizeMb(segs[2]); err != nil {
+			return nil, err
+		}
+		vgs = append(vgs, vg)
+	}
+	return vgs, nil
+}
+
+func parseLvs(output string) ([]SLogicalVolume, error) {
+	lvs := []SLogicalVolume{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		segs := strings.Split(line, reportSeparator)
+		if len(segs) != 7 {
+			return nil, errors.Errorf("invalid lvs line %q", line)
+		}
+		lv := SLogicalVolume{
+			Name:   segs[0],
+			VgName: segs[1],
+			Attr:   segs[2],
+			Pool:   segs[4],
+			Origin: segs[5],
+		}
+		var err error
+		if lv.SizeMb, err = parseSizeMb(segs[3]); err != nil {
+			return nil, err
+		}
+		if len(segs[6]) > 0 {
+			if lv.DataPercent, err = strconv.ParseFloat(segs[6], 64); err != nil {
+				return nil, errors.Wrapf(err, "parse data percent %q", segs[6])
+			}
+		}
+		lvs = append(lvs, lv)
+	}
+	return lvs, nil
+}
+
+func GetVg(vg string) (*SVolumeGroup, error) {
+	args := append(reportArgs("vg_name", "vg_size", "vg_free"), vg)
+	out, err := run("vgs", args...)
+	if err != nil {
+		return nil, err
+	}
+	vgs, err := parseVgs(string(out))
+	if err != nil {
+		return nil, err
+	}
+	if len(vgs) == 0 {
+		return nil, errors.Wrap(ErrVgNotFound, vg)
+	}
+	return &vgs[0], nil
+}
+
+func ListLvs(vg string) ([]SLogicalVolume, error) {
+	args := append(reportArgs("lv_name", "vg_name", "lv_attr", "lv_size", "pool_lv", "origin", "data_percent"), vg)
+	out, err := run("lvs", args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLvs(string(out))
+}
+
+func GetLv(vg, lv string) (*SLogicalVolume, error) {
+	lvs, err := ListLvs(vg)
+	if err != nil {
+		return nil, err
+	}
+	for i := range lvs {
+		if lvs[i].Name == lv {
+			return &lvs[i], nil
+		}
+	}
+	return nil, errors.Wrapf(ErrLvNotFound, "%s/%s", vg, lv)
+}
+
+// CreateLv allocates a thick volume, or a thin volume if pool is not empty
+func CreateLv(vg, pool, lv string, sizeMb int64) error {
+	args := []string{"-y", "-n", lv}
+	if len(pool) > 0 {
+		args = append(args, "-V", fmt.Sprintf("%dM", sizeMb), "-T", fmt.Sprintf("%s/%s", vg, pool))
+	} else {
+		args = append(args, "-Wy", "-Zy", "-L", fmt.Sprintf("%dM", sizeMb), vg)
+	}
+	_, err := run("lvcreate", args...)
+	return err
+}
+
+// CreateThinSnapshot creates a writable thin snapshot of origin, snapshots
+// are activated as usual volumes so they can be used as guest disks directly
+func CreateThinSnapshot(vg, origin, lv string) error {
+	_, err := run("lvcreate", "-y", "-s", "-kn", "-n", lv, fmt.Sprintf("%s/%s", vg, origin))
+	return err
+}
+
+func RemoveLv(vg, lv string) error {
+	_, err := run("lvremove", "-f", fmt.Sprintf("%s/%s", vg, lv))
+	return err
+}
+
+func RenameLv(vg, src, dest string) error {
+	_, err := run("lvrename", vg, src, dest)
+	return err
+}
+
+// ExtendLv grows the volume to sizeMb, volumes never shrink
+func ExtendLv(vg, lv string, sizeMb int64) error {
+	_, err := run("lvextend", "-L", fmt.Sprintf("%dM", sizeMb), fmt.Sprintf("%s/%s", vg, lv))
+	return err
+}
+
+func ActivateLv(vg, lv string) error {
+	_, err := run("lvchange", "-ay", "-K", fmt.Sprintf("%s/%s", vg, lv))
+	return err
+}
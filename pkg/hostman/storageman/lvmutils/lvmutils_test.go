@@ -0,0 +1,83 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lvmutils
+
+import (
+	"testing"
+)
+
+func TestParseVgs(t *testing.T) {
+	vgs, err := parseVgs("  vg0|102396.00|51200.00\n\n")
+	if err != nil {
+		t.Fatalf("parse vgs: %v", err)
+	}
+	if len(vgs) != 1 {
+		t.Fatalf("want 1 vg, got %d", len(vgs))
+	}
+	if vgs[0].Name != "vg0" || vgs[0].SizeMb != 102396 || vgs[0].FreeMb != 51200 {
+		t.Errorf("unexpected vg %#v", vgs[0])
+	}
+	if _, err := parseVgs("  vg0|102396.00\n"); err == nil {
+		t.Errorf("short line should fail")
+	}
+}
+
+func TestParseLvs(t *testing.T) {
+	output := `  pool0|vg0|twi-aotz--|40960.00|||12.50
+  disk0|vg0|Vwi-a-tz--|10240.00|pool0||25.00
+  snap0|vg0|Vwi---tz-k|10240.00|pool0|disk0|
+  thick|vg0|-wi-a-----|2048.00|||
+`
+	lvs, err := parseLvs(output)
+	if err != nil {
+		t.Fatalf("parse lvs: %v", err)
+	}
+	if len(lvs) != 4 {
+		t.Fatalf("want 4 lvs, got %d", len(lvs))
+	}
+	cases := []struct {
+		name     string
+		thinPool bool
+		active   bool
+		origin   string
+		usedMb   int64
+	}{
+		{"pool0", true, true, "", 5120},
+		{"disk0", false, true, "", 2560},
+		{"snap0", false, false, "disk0", 0},
+		{"thick", false, true, "", 0},
+	}
+	for i, c := range cases {
+		lv := lvs[i]
+		if lv.Name != c.name {
+			t.Errorf("lv %d: want name %s, got %s", i, c.name, lv.Name)
+		}
+		if lv.IsThinPool() != c.thinPool {
+			t.Errorf("%s: want thin pool %v", c.name, c.thinPool)
+		}
+		if lv.IsActive() != c.active {
+			t.Errorf("%s: want active %v", c.name, c.active)
+		}
+		if lv.Origin != c.origin {
+			t.Errorf("%s: want origin %q, got %q", c.name, c.origin, lv.Origin)
+		}
+		if lv.GetUsedSizeMb() != c.usedMb {
+			t.Errorf("%s: want used %d, got %d", c.name, c.usedMb, lv.GetUsedSizeMb())
+		}
+	}
+	if lvs[1].Pool != "pool0" {
+		t.Errorf("disk0: want pool pool0, got %s", lvs[1].Pool)
+	}
+}
@@ -334,9 +334,13 @@ func (s *SBaseStorage) CreateRawDisk(ctx context.Context, disk IDisk, createPara
 	size, _ := createParams.DiskInfo.Int("size")
 	diskFromat, _ := createParams.DiskInfo.GetString("format")
 	fsFormat, _ := createParams.DiskInfo.GetString("fs_format")
-	encryption := jsonutils.QueryBoolean(createParams.DiskInfo, "encryption", false)
+	encryptKeyId, _ := createParams.DiskInfo.GetString("encrypt_key_id")
+	encryptInfo, err := GetEncryptInfo(ctx, encryptKeyId)
+	if err != nil {
+		return nil, errors.Wrap(err, "get encrypt info")
+	}
 
-	return disk.CreateRaw(ctx, int(size), diskFromat, fsFormat, encryption, createParams.DiskId, "")
+	return disk.CreateRaw(ctx, int(size), diskFromat, fsFormat, encryptInfo, createParams.DiskId, "")
 }
 
 func (s *SBaseStorage) CreateDiskFromTemplate(ctx context.Context, disk IDisk, createParams *SDiskCreateByDiskinfo) (jsonutils.JSONObject, error) {
@@ -344,9 +348,15 @@ func (s *SBaseStorage) CreateDiskFromTemplate(ctx context.Context, disk IDisk, c
 		imageId, _ = createParams.DiskInfo.GetString("image_id")
 		format     = "qcow2" // force qcow2
 		size, _    = createParams.DiskInfo.Int("size")
+
+		encryptKeyId, _ = createParams.DiskInfo.GetString("encrypt_key_id")
 	)
+	encryptInfo, err := GetEncryptInfo(ctx, encryptKeyId)
+	if err != nil {
+		return nil, errors.Wrap(err, "get encrypt info")
+	}
 
-	return disk.CreateFromTemplate(ctx, imageId, format, size)
+	return disk.CreateFromTemplate(ctx, imageId, format, size, encryptInfo)
 }
 
 func (s *SBaseStorage) CreateDiskFromSnpashot(ctx context.Context, disk IDisk, createParams *SDiskCreateByDiskinfo) (jsonutils.JSONObject, error) {
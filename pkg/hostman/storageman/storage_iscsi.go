@@ -0,0 +1,476 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageman
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/hostman/storageman/iscsiutils"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+)
+
+const _ISCSI_DEVICE_TIMEOUT_ = 30 * time.Second
+
+func init() {
+	registerStorageFactory(&SISCSIStorageFactory{})
+}
+
+type SISCSIStorageFactory struct {
+}
+
+func (factory *SISCSIStorageFactory) NewStorage(manager *SStorageManager, mountPoint string) IStorage {
+	return NewISCSIStorage(manager, mountPoint)
+}
+
+func (factory *SISCSIStorageFactory) StorageType() string {
+	return api.STORAGE_ISCSI
+}
+
+// SISCSIStorage logs in to the target through all portals, every disk
+// takes a lun of the target and is linked to the lun device under the
+// storage path, the link is the same on all hosts so guests can live
+// migrate between them
+type SISCSIStorage struct {
+	SBaseStorage
+
+	Portals      []string
+	Target       string
+	ChapUsername string
+	ChapPassword string
+}
+
+func NewISCSIStorage(manager *SStorageManager, path string) *SISCSIStorage {
+	var ret = new(SISCSIStorage)
+	ret.SBaseStorage = *NewBaseStorage(manager, path)
+	return ret
+}
+
+func (s *SISCSIStorage) StorageType() string {
+	return api.STORAGE_ISCSI
+}
+
+func (s *SISCSIStorage) IsMultipath() bool {
+	return len(s.Portals) > 1
+}
+
+func (s *SISCSIStorage) GetSnapshotDir() string {
+	return ""
+}
+
+func (s *SISCSIStorage) GetSnapshotPathByIds(diskId, snapshotId string) string {
+	return ""
+}
+
+func (s *SISCSIStorage) IsSnapshotExist(diskId, snapshotId string) (bool, error) {
+	return false, nil
+}
+
+func (s *SISCSIStorage) GetFuseTmpPath() string {
+	return ""
+}
+
+func (s *SISCSIStorage) GetFuseMountPath() string {
+	return ""
+}
+
+func (s *SISCSIStorage) GetImgsaveBackupPath() string {
+	if storage := s.getLocalStorage(); storage != nil {
+		return storage.GetImgsaveBackupPath()
+	}
+	return ""
+}
+
+// getLocalStorage returns the local storage images are saved through
+func (s *SISCSIStorage) getLocalStorage() *SLocalStorage {
+	for _, storage := range s.Manager.Storages {
+		if local, ok := storage.(*SLocalStorage); ok {
+			return local
+		}
+	}
+	return nil
+}
+
+func (s *SISCSIStorage) SetStorageInfo(storageId, storageName string, conf jsonutils.JSONObject) error {
+	s.StorageId = storageId
+	s.StorageName = storageName
+	if dconf, ok := conf.(*jsonutils.JSONDict); ok {
+		s.StorageConf = dconf
+		s.Portals = []string{}
+		dconf.Unmarshal(&s.Portals, "portals")
+		s.Target, _ = dconf.GetString("target")
+		s.ChapUsername, _ = dconf.GetString("chap_username")
+		s.ChapPassword, _ = dconf.GetString("chap_password")
+	}
+	if !fileutils2.Exists(s.Path) {
+		if err := procutils.NewCommand("mkdir", "-p", s.Path).Run(); err != nil {
+			return errors.Wrapf(err, "mkdir %s", s.Path)
+		}
+	}
+	return s.login()
+}
+
+// login logs in to the target through every portal, the storage
+// is usable as long as one of the paths is up
+func (s *SISCSIStorage) login() error {
+	if len(s.Target) == 0 || len(s.Portals) == 0 {
+		return fmt.Errorf("iscsi storage %s missing target or portals", s.StorageName)
+	}
+	var loggedIn int
+	for _, portal := range s.Portals {
+		if err := iscsiutils.Login(s.Target, portal, s.ChapUsername, s.ChapPassword); err != nil {
+			log.Errorf("login iscsi target %s on %s: %s", s.Target, portal, err)
+			continue
+		}
+		loggedIn++
+	}
+	if loggedIn == 0 {
+		return fmt.Errorf("failed to login iscsi target %s on any portal", s.Target)
+	}
+	return nil
+}
+
+func (s *SISCSIStorage) rescan() {
+	for _, portal := range s.Portals {
+		if err := iscsiutils.Rescan(s.Target, portal); err != nil {
+			log.Warningf("rescan iscsi target %s on %s: %s", s.Target, portal, err)
+		}
+	}
+}
+
+// listLuns lists the luns of target through the first working portal
+func (s *SISCSIStorage) listLuns() ([]api.SIscsiLun, error) {
+	var paths map[int]string
+	for _, portal := range s.Portals {
+		var err error
+		paths, err = iscsiutils.ListLunPaths(portal, s.Target)
+		if err == nil && len(paths) > 0 {
+			break
+		}
+	}
+	luns := []api.SIscsiLun{}
+	for lun, dev := range paths {
+		sizeMb, err := iscsiutils.GetDeviceSizeMb(dev)
+		if err != nil {
+			log.Errorf("get size of lun %d: %s", lun, err)
+			continue
+		}
+		wwid, err := iscsiutils.GetWwid(dev)
+		if err != nil {
+			log.Warningf("get wwid of lun %d: %s", lun, err)
+		}
+		luns = append(luns, api.SIscsiLun{Target: s.Target, Lun: lun, SizeMb: sizeMb, Wwid: wwid})
+	}
+	sort.Slice(luns, func(i, j int) bool { return luns[i].Lun < luns[j].Lun })
+	return luns, nil
+}
+
+func (s *SISCSIStorage) getCapacity(luns []api.SIscsiLun) int64 {
+	var capacity int64
+	for _, lun := range luns {
+		capacity += lun.SizeMb
+	}
+	return capacity
+}
+
+func (s *SISCSIStorage) GetCapacity() int {
+	luns, err := s.listLuns()
+	if err != nil {
+		log.Errorf("list luns of iscsi storage %s: %s", s.StorageName, err)
+		return 0
+	}
+	return int(s.getCapacity(luns))
+}
+
+// getAllocatedLuns asks region for the luns of the target allocated to disks
+func (s *SISCSIStorage) getAllocatedLuns() (map[int]bool, error) {
+	params := jsonutils.NewDict()
+	params.Set("resources", jsonutils.NewStringArray([]string{"disk"}))
+	params.Set("key", jsonutils.NewStringArray([]string{api.DISK_META_ISCSI_LUN}))
+	params.Set("with_sys_meta", jsonutils.JSONTrue)
+	params.Set("scope", jsonutils.NewString("system"))
+	params.Set("limit", jsonutils.NewInt(0))
+	result, err := modules.Metadatas.List(hostutils.GetComputeSession(context.Background()), params)
+	if err != nil {
+		return nil, errors.Wrap(err, "list disk luns")
+	}
+	used := map[int]bool{}
+	for _, meta := range result.Data {
+		val, _ := meta.GetString("value")
+		obj, err := jsonutils.ParseString(val)
+		if err != nil {
+			continue
+		}
+		lun := api.SIscsiLun{}
+		if err := obj.Unmarshal(&lun); err != nil || lun.Target != s.Target {
+			continue
+		}
+		used[lun.Lun] = true
+	}
+	return used, nil
+}
+
+// GetFreeSizeMb sums the luns not allocated yet, each disk takes a whole lun
+func (s *SISCSIStorage) GetFreeSizeMb() int {
+	luns, err := s.listLuns()
+	if err != nil {
+		log.Errorf("list luns of iscsi storage %s: %s", s.StorageName, err)
+		return 0
+	}
+	used, err := s.getAllocatedLuns()
+	if err != nil {
+		log.Errorf("get allocated luns of iscsi storage %s: %s", s.StorageName, err)
+		return 0
+	}
+	var free int64
+	for _, lun := range luns {
+		if !used[lun.Lun] {
+			free += lun.SizeMb
+		}
+	}
+	return int(free)
+}
+
+func (s *SISCSIStorage) SyncStorageSize() error {
+	_, err := s.SyncStorageInfo()
+	return err
+}
+
+func (s *SISCSIStorage) SyncStorageInfo() (jsonutils.JSONObject, error) {
+	if len(s.StorageId) == 0 {
+		return nil, fmt.Errorf("Sync iscsi storage without storage id")
+	}
+	s.rescan()
+	luns, err := s.listLuns()
+	if err != nil {
+		log.Errorf("list luns of iscsi storage %s: %s", s.StorageName, err)
+		return modules.Storages.PerformAction(hostutils.GetComputeSession(context.Background()), s.StorageId, "offline", nil)
+	}
+	content := jsonutils.NewDict()
+	content.Set("capacity", jsonutils.NewInt(s.getCapacity(luns)))
+	content.Set("storage_type", jsonutils.NewString(s.StorageType()))
+	content.Set("status", jsonutils.NewString(api.STORAGE_ONLINE))
+	content.Set("zone", jsonutils.NewString(s.GetZoneName()))
+	content.Set("iscsi_luns", jsonutils.Marshal(luns))
+	log.Infof("Sync storage info %s", s.StorageId)
+	res, err := modules.Storages.Put(
+		hostutils.GetComputeSession(context.Background()),
+		s.StorageId, content)
+	if err != nil {
+		log.Errorf("SyncStorageInfo Failed: %s: %s", content, err)
+	}
+	return res, err
+}
+
+// mapLun returns the device of lun, it is the multipath device if the
+// target is reached through multiple portals
+func (s *SISCSIStorage) mapLun(lun *api.SIscsiLun) (string, error) {
+	paths := []string{}
+	for _, portal := range s.Portals {
+		dev := iscsiutils.GetLunPath(portal, lun.Target, lun.Lun)
+		if !fileutils2.Exists(dev) {
+			iscsiutils.Rescan(lun.Target, portal)
+		}
+		if err := iscsiutils.WaitDevice(dev, _ISCSI_DEVICE_TIMEOUT_); err != nil {
+			log.Warningf("lun %d not found through portal %s: %s", lun.Lun, portal, err)
+			continue
+		}
+		paths = append(paths, dev)
+	}
+	if len(paths) == 0 {
+		return "", errors.Wrapf(iscsiutils.ErrDeviceNotFound, "lun %d of %s", lun.Lun, lun.Target)
+	}
+	if !s.IsMultipath() {
+		return paths[0], nil
+	}
+
+	wwid := lun.Wwid
+	if len(wwid) == 0 {
+		var err error
+		if wwid, err = iscsiutils.GetWwid(paths[0]); err != nil {
+			return "", errors.Wrap(err, "get wwid")
+		}
+	}
+	for _, dev := range paths {
+		if err := iscsiutils.AddMultipath(dev); err != nil {
+			log.Warningf("add multipath %s: %s", dev, err)
+		}
+	}
+	mpath := iscsiutils.GetMultipathPath(wwid)
+	if err := iscsiutils.WaitDevice(mpath, _ISCSI_DEVICE_TIMEOUT_); err != nil {
+		return "", errors.Wrapf(err, "multipath of lun %d", lun.Lun)
+	}
+	return mpath, nil
+}
+
+// linkLun links the disk to the device of lun
+func (s *SISCSIStorage) linkLun(diskId string, lun *api.SIscsiLun) error {
+	dev, err := s.mapLun(lun)
+	if err != nil {
+		return err
+	}
+	diskPath := path.Join(s.Path, diskId)
+	if target, err := os.Readlink(diskPath); err == nil && target == dev {
+		return nil
+	}
+	log.Infof("Link disk %s to lun %d of %s: %s", diskId, lun.Lun, lun.Target, dev)
+	return procutils.NewCommand("ln", "-sfn", dev, diskPath).Run()
+}
+
+// fetchDiskLun asks region for the lun the disk is allocated on,
+// disks are linked lazily on hosts other than the one creating it
+func (s *SISCSIStorage) fetchDiskLun(diskId string) (*api.SIscsiLun, error) {
+	params := jsonutils.NewDict()
+	params.Set("field", jsonutils.NewString(api.DISK_META_ISCSI_LUN))
+	meta, err := modules.Disks.GetMetadata(hostutils.GetComputeSession(context.Background()), diskId, params)
+	if err != nil {
+		return nil, errors.Wrap(err, "get disk metadata")
+	}
+	val, _ := meta.GetString(api.DISK_META_ISCSI_LUN)
+	if len(val) == 0 {
+		return nil, cloudprovider.ErrNotFound
+	}
+	obj, err := jsonutils.ParseString(val)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse lun %s", val)
+	}
+	lun := &api.SIscsiLun{}
+	return lun, obj.Unmarshal(lun)
+}
+
+func (s *SISCSIStorage) GetDiskById(diskId string) (IDisk, error) {
+	s.DiskLock.Lock()
+	defer s.DiskLock.Unlock()
+	for i := 0; i < len(s.Disks); i++ {
+		if s.Disks[i].GetId() == diskId {
+			return s.Disks[i], s.Disks[i].Probe()
+		}
+	}
+	var disk = NewISCSIDisk(s, diskId)
+	if disk.Probe() != nil {
+		lun, err := s.fetchDiskLun(diskId)
+		if err != nil {
+			if errors.Cause(err) == cloudprovider.ErrNotFound {
+				return nil, cloudprovider.ErrNotFound
+			}
+			return nil, errors.Wrapf(err, "fetch lun of disk %s", diskId)
+		}
+		if err := s.linkLun(diskId, lun); err != nil {
+			return nil, errors.Wrapf(err, "link disk %s", diskId)
+		}
+	}
+	s.Disks = append(s.Disks, disk)
+	return disk, nil
+}
+
+func (s *SISCSIStorage) CreateDisk(diskId string) IDisk {
+	s.DiskLock.Lock()
+	defer s.DiskLock.Unlock()
+	disk := NewISCSIDisk(s, diskId)
+	s.Disks = append(s.Disks, disk)
+	return disk
+}
+
+// CreateDiskByDiskinfo links the disk to the lun allocated by region before creating it
+func (s *SISCSIStorage) CreateDiskByDiskinfo(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	createParams, ok := params.(*SDiskCreateByDiskinfo)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	lun := &api.SIscsiLun{}
+	if err := createParams.DiskInfo.Unmarshal(lun, "iscsi_lun"); err != nil {
+		return nil, errors.Wrap(err, "missing iscsi_lun")
+	}
+	if err := s.linkLun(createParams.DiskId, lun); err != nil {
+		return nil, errors.Wrapf(err, "link disk %s", createParams.DiskId)
+	}
+	return s.SBaseStorage.CreateDiskByDiskinfo(ctx, params)
+}
+
+func (s *SISCSIStorage) Accessible() error {
+	var c = make(chan error)
+	go func() {
+		for _, portal := range s.Portals {
+			if ok, err := iscsiutils.HasSession(s.Target, portal); err == nil && ok {
+				c <- nil
+				return
+			}
+		}
+		c <- fmt.Errorf("no session of iscsi target %s", s.Target)
+	}()
+	var err error
+	select {
+	case err = <-c:
+		break
+	case <-time.After(time.Second * 10):
+		err = ErrStorageTimeout
+	}
+	return err
+}
+
+// Detach unlinks all disks and logs out of the target
+func (s *SISCSIStorage) Detach() error {
+	s.DiskLock.Lock()
+	for _, disk := range s.Disks {
+		if d, ok := disk.(*SISCSIDisk); ok {
+			if err := d.Unmap(); err != nil {
+				log.Errorf("unmap disk %s: %s", d.Id, err)
+			}
+		}
+	}
+	s.Disks = []IDisk{}
+	s.DiskLock.Unlock()
+
+	for _, portal := range s.Portals {
+		if err := iscsiutils.Logout(s.Target, portal); err != nil {
+			log.Errorf("logout iscsi target %s on %s: %s", s.Target, portal, err)
+		}
+	}
+	return procutils.NewCommand("rm", "-rf", s.Path).Run()
+}
+
+func (s *SISCSIStorage) DeleteSnapshots(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	return nil, nil
+}
+
+func (s *SISCSIStorage) CreateSnapshotFormUrl(ctx context.Context, snapshotUrl, diskId, snapshotPath string) error {
+	return fmt.Errorf("Not support")
+}
+
+func (s *SISCSIStorage) CreateDiskFromSnapshot(ctx context.Context, disk IDisk, createParams *SDiskCreateByDiskinfo) error {
+	return fmt.Errorf("Not support create disk from snapshot on iscsi storage")
+}
+
+// SaveToGlance uploads the image exported to local storage by PrepareSaveToGlance
+func (s *SISCSIStorage) SaveToGlance(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	storage := s.getLocalStorage()
+	if storage == nil {
+		return nil, fmt.Errorf("no local storage to save image")
+	}
+	return storage.SaveToGlance(ctx, params)
+}
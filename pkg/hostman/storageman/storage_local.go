@@ -27,6 +27,7 @@ import (
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/util/timeutils"
 
+	"yunion.io/x/onecloud/pkg/apis"
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudprovider"
 	deployapi "yunion.io/x/onecloud/pkg/hostman/hostdeployer/apis"
@@ -368,6 +369,11 @@ func (s *SLocalStorage) DestinationPrepareMigrate(
 	}
 
 	templateId, _ := diskinfo.GetString("template_id")
+	encryptKeyId, _ := diskinfo.GetString("encrypt_key_id")
+	encryptInfo, err := GetEncryptInfo(ctx, encryptKeyId)
+	if err != nil {
+		return errors.Wrap(err, "get encrypt info")
+	}
 	// prepare disk snapshot dir
 	if len(snapshots) > 0 && !fileutils2.Exists(disk.GetSnapshotDir()) {
 		output, err := procutils.NewCommand("mkdir", "-p", disk.GetSnapshotDir()).Output()
@@ -392,11 +398,11 @@ func (s *SLocalStorage) DestinationPrepareMigrate(
 		}
 		if i == 0 && len(templateId) > 0 {
 			templatePath := path.Join(storageManager.LocalStorageImagecacheManager.GetPath(), templateId)
-			if err := doRebaseDisk(snapshotPath, templatePath); err != nil {
+			if err := doRebaseDisk(snapshotPath, templatePath, encryptInfo); err != nil {
 				return err
 			}
 		} else if rebaseDisks && len(baseImagePath) > 0 {
-			if err := doRebaseDisk(snapshotPath, baseImagePath); err != nil {
+			if err := doRebaseDisk(snapshotPath, baseImagePath, encryptInfo); err != nil {
 				return err
 			}
 		}
@@ -407,7 +413,7 @@ func (s *SLocalStorage) DestinationPrepareMigrate(
 		// create local disk
 		backingFile, _ := disksBackingFile.GetString(diskId)
 		size, _ := diskinfo.Int("size")
-		_, err := disk.CreateRaw(ctx, int(size), "qcow2", "", false, "", backingFile)
+		_, err := disk.CreateRaw(ctx, int(size), "qcow2", "", encryptInfo, "", backingFile)
 		if err != nil {
 			log.Errorln(err)
 			return err
@@ -422,11 +428,11 @@ func (s *SLocalStorage) DestinationPrepareMigrate(
 	}
 	if rebaseDisks && len(templateId) > 0 && len(baseImagePath) == 0 {
 		templatePath := path.Join(storageManager.LocalStorageImagecacheManager.GetPath(), templateId)
-		if err := doRebaseDisk(disk.GetPath(), templatePath); err != nil {
+		if err := doRebaseDisk(disk.GetPath(), templatePath, encryptInfo); err != nil {
 			return err
 		}
 	} else if rebaseDisks && len(baseImagePath) > 0 {
-		if err := doRebaseDisk(disk.GetPath(), baseImagePath); err != nil {
+		if err := doRebaseDisk(disk.GetPath(), baseImagePath, encryptInfo); err != nil {
 			return err
 		}
 	}
@@ -435,8 +441,8 @@ func (s *SLocalStorage) DestinationPrepareMigrate(
 	return nil
 }
 
-func doRebaseDisk(diskPath, newBasePath string) error {
-	img, err := qemuimg.NewQemuImage(diskPath)
+func doRebaseDisk(diskPath, newBasePath string, encryptInfo *apis.SEncryptInfo) error {
+	img, err := NewDiskQemuImage(diskPath, encryptInfo)
 	if err != nil {
 		return errors.Wrap(err, "failed open disk as qemu image")
 	}
@@ -0,0 +1,469 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageman
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	deployapi "yunion.io/x/onecloud/pkg/hostman/hostdeployer/apis"
+	"yunion.io/x/onecloud/pkg/hostman/hostdeployer/deployclient"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/hostman/storageman/lvmutils"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+	"yunion.io/x/onecloud/pkg/util/qemutils"
+)
+
+const (
+	_LVM_SNAPSHOT_INFIX_ = "_snap_"
+	_LVM_IMGSAVE_PREFIX_ = "imgsave_"
+)
+
+// SLVMStorage carves guest disks as logical volumes of a volume group,
+// volumes are allocated from ThinPool if it is set
+type SLVMStorage struct {
+	SBaseStorage
+
+	VgName   string
+	ThinPool string
+
+	imagecacheManager *SLVMImageCacheManager
+}
+
+// NewLVMStorage accepts volume group in form of vg or vg/thinpool
+func NewLVMStorage(manager *SStorageManager, vg string) *SLVMStorage {
+	var ret = new(SLVMStorage)
+	ret.VgName = vg
+	if pos := strings.Index(vg, "/"); pos > 0 {
+		ret.VgName, ret.ThinPool = vg[:pos], vg[pos+1:]
+	}
+	ret.SBaseStorage = *NewBaseStorage(manager, path.Join("/dev", ret.VgName))
+	ret.imagecacheManager = NewLVMImageCacheManager(manager, ret)
+	return ret
+}
+
+func (s *SLVMStorage) StorageType() string {
+	return api.STORAGE_LVM
+}
+
+func (s *SLVMStorage) IsThin() bool {
+	return len(s.ThinPool) > 0
+}
+
+func (s *SLVMStorage) GetComposedName() string {
+	return fmt.Sprintf("host_%s_%s_storage_%s", s.Manager.host.GetMasterIp(), s.StorageType(), s.VgName)
+}
+
+func (s *SLVMStorage) GetFuseTmpPath() string {
+	return ""
+}
+
+func (s *SLVMStorage) GetFuseMountPath() string {
+	return ""
+}
+
+func (s *SLVMStorage) GetImgsaveBackupPath() string {
+	return ""
+}
+
+func (s *SLVMStorage) GetSnapshotDir() string {
+	return ""
+}
+
+func (s *SLVMStorage) getSnapshotLvName(diskId, snapshotId string) string {
+	return diskId + _LVM_SNAPSHOT_INFIX_ + snapshotId
+}
+
+func (s *SLVMStorage) GetSnapshotPathByIds(diskId, snapshotId string) string {
+	return lvmutils.GetLvPath(s.VgName, s.getSnapshotLvName(diskId, snapshotId))
+}
+
+func (s *SLVMStorage) IsSnapshotExist(diskId, snapshotId string) (bool, error) {
+	_, err := lvmutils.GetLv(s.VgName, s.getSnapshotLvName(diskId, snapshotId))
+	if err != nil {
+		if errors.Cause(err) == lvmutils.ErrLvNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// getSizeMb returns total and used size of the thin pool or the volume group
+func (s *SLVMStorage) getSizeMb() (int64, int64, error) {
+	if s.IsThin() {
+		pool, err := lvmutils.GetLv(s.VgName, s.ThinPool)
+		if err != nil {
+			return 0, 0, errors.Wrap(err, "get thin pool")
+		}
+		return pool.SizeMb, pool.GetUsedSizeMb(), nil
+	}
+	vg, err := lvmutils.GetVg(s.VgName)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "get volume group")
+	}
+	return vg.SizeMb, vg.SizeMb - vg.FreeMb, nil
+}
+
+func (s *SLVMStorage) GetTotalSizeMb() int {
+	total, _, err := s.getSizeMb()
+	if err != nil {
+		log.Errorf("failed get lvm storage %s total size: %s", s.Path, err)
+		return -1
+	}
+	return int(total)
+}
+
+func (s *SLVMStorage) GetUsedSizeMb() int {
+	_, used, err := s.getSizeMb()
+	if err != nil {
+		log.Errorf("failed get lvm storage %s used size: %s", s.Path, err)
+		return -1
+	}
+	return int(used)
+}
+
+func (s *SLVMStorage) GetFreeSizeMb() int {
+	total, used, err := s.getSizeMb()
+	if err != nil {
+		log.Errorf("failed get lvm storage %s free size: %s", s.Path, err)
+		return -1
+	}
+	return int(total - used)
+}
+
+func (s *SLVMStorage) GetAvailSizeMb() int {
+	return s.GetTotalSizeMb()
+}
+
+func (s *SLVMStorage) GetCapacity() int {
+	return s.GetAvailSizeMb()
+}
+
+func (s *SLVMStorage) SetStorageInfo(storageId, storageName string, conf jsonutils.JSONObject) error {
+	s.StorageId = storageId
+	s.StorageName = storageName
+	if dconf, ok := conf.(*jsonutils.JSONDict); ok {
+		s.StorageConf = dconf
+	}
+	return nil
+}
+
+func (s *SLVMStorage) SetStoragecacheId(storagecacheId string) {
+	s.SBaseStorage.SetStoragecacheId(storagecacheId)
+	if len(storagecacheId) > 0 {
+		s.Manager.AddLVMStorageImagecache(storagecacheId, s.imagecacheManager)
+	}
+}
+
+// syncStoragecacheInfo registers the volume group as image cache of the host
+func (s *SLVMStorage) syncStoragecacheInfo() error {
+	if len(s.GetStoragecacheId()) > 0 {
+		return nil
+	}
+	session := hostutils.GetComputeSession(context.Background())
+	params := jsonutils.NewDict()
+	params.Set("external_id", jsonutils.NewString(s.Manager.GetHostId()))
+	params.Set("path", jsonutils.NewString(s.imagecacheManager.GetPath()))
+	res, err := modules.Storagecaches.List(session, params)
+	if err != nil {
+		return errors.Wrap(err, "list storagecaches")
+	}
+	var storagecacheId string
+	if len(res.Data) > 0 {
+		storagecacheId, _ = res.Data[0].GetString("id")
+	} else {
+		body := jsonutils.NewDict()
+		body.Set("name", jsonutils.NewString(fmt.Sprintf("lvm-%s-%s", s.GetName(s.GetComposedName), time.Now().String())))
+		body.Set("path", jsonutils.NewString(s.imagecacheManager.GetPath()))
+		body.Set("external_id", jsonutils.NewString(s.Manager.GetHostId()))
+		sc, err := modules.Storagecaches.Create(session, body)
+		if err != nil {
+			return errors.Wrap(err, "create storagecache")
+		}
+		storagecacheId, _ = sc.GetString("id")
+	}
+	s.SetStoragecacheId(storagecacheId)
+	return nil
+}
+
+func (s *SLVMStorage) SyncStorageSize() error {
+	content := jsonutils.NewDict()
+	content.Set("actual_capacity_used", jsonutils.NewInt(int64(s.GetUsedSizeMb())))
+	_, err := modules.Storages.Put(
+		hostutils.GetComputeSession(context.Background()),
+		s.StorageId, content)
+	return err
+}
+
+func (s *SLVMStorage) SyncStorageInfo() (jsonutils.JSONObject, error) {
+	if err := s.syncStoragecacheInfo(); err != nil {
+		log.Errorf("sync lvm storage %s storagecache: %s", s.VgName, err)
+	}
+	total, used, err := s.getSizeMb()
+	if err != nil {
+		return nil, errors.Wrapf(err, "get lvm storage %s size", s.VgName)
+	}
+	content := jsonutils.NewDict()
+	content.Set("name", jsonutils.NewString(s.GetName(s.GetComposedName)))
+	content.Set("capacity", jsonutils.NewInt(total))
+	content.Set("actual_capacity_used", jsonutils.NewInt(used))
+	content.Set("storage_type", jsonutils.NewString(s.StorageType()))
+	content.Set("medium_type", jsonutils.NewString(s.GetMediumType()))
+	content.Set("zone", jsonutils.NewString(s.GetZoneName()))
+	// region tells thin storages taking snapshots by thin_pool
+	conf := jsonutils.NewDict()
+	conf.Set("vg", jsonutils.NewString(s.VgName))
+	if s.IsThin() {
+		conf.Set("thin_pool", jsonutils.NewString(s.ThinPool))
+	}
+	content.Set("storage_conf", conf)
+	if len(s.GetStoragecacheId()) > 0 {
+		content.Set("storagecache_id", jsonutils.NewString(s.GetStoragecacheId()))
+	}
+	var res jsonutils.JSONObject
+
+	log.Infof("Sync storage info %s", s.StorageId)
+
+	if len(s.StorageId) > 0 {
+		res, err = modules.Storages.Put(
+			hostutils.GetComputeSession(context.Background()),
+			s.StorageId, content)
+	} else {
+		res, err = modules.Storages.Create(
+			hostutils.GetComputeSession(context.Background()), content)
+	}
+	if err != nil {
+		log.Errorf("SyncStorageInfo Failed: %s: %s", content, err)
+	}
+	return res, err
+}
+
+func (s *SLVMStorage) GetDiskById(diskId string) (IDisk, error) {
+	s.DiskLock.Lock()
+	defer s.DiskLock.Unlock()
+	for i := 0; i < len(s.Disks); i++ {
+		if s.Disks[i].GetId() == diskId {
+			return s.Disks[i], s.Disks[i].Probe()
+		}
+	}
+	var disk = NewLVMDisk(s, diskId)
+	if disk.Probe() == nil {
+		s.Disks = append(s.Disks, disk)
+		return disk, nil
+	}
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (s *SLVMStorage) CreateDisk(diskId string) IDisk {
+	s.DiskLock.Lock()
+	defer s.DiskLock.Unlock()
+	disk := NewLVMDisk(s, diskId)
+	s.Disks = append(s.Disks, disk)
+	return disk
+}
+
+func (s *SLVMStorage) Accessible() error {
+	var c = make(chan error)
+	go func() {
+		if _, err := lvmutils.GetVg(s.VgName); err != nil {
+			c <- err
+			return
+		}
+		if s.IsThin() {
+			pool, err := lvmutils.GetLv(s.VgName, s.ThinPool)
+			if err != nil {
+				c <- err
+				return
+			}
+			if !pool.IsThinPool() {
+				c <- fmt.Errorf("%s/%s isn't thin pool", s.VgName, s.ThinPool)
+				return
+			}
+		}
+		c <- nil
+	}()
+	var err error
+	select {
+	case err = <-c:
+		break
+	case <-time.After(time.Second * 10):
+		err = ErrStorageTimeout
+	}
+	return err
+}
+
+func (s *SLVMStorage) Detach() error {
+	return nil
+}
+
+func (s *SLVMStorage) DeleteSnapshots(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	diskId, ok := params.(string)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	return nil, s.deleteLvsWithPrefix(diskId + _LVM_SNAPSHOT_INFIX_)
+}
+
+func (s *SLVMStorage) deleteLvsWithPrefix(prefix string) error {
+	lvs, err := lvmutils.ListLvs(s.VgName)
+	if err != nil {
+		return errors.Wrap(err, "list logical volumes")
+	}
+	for _, lv := range lvs {
+		if strings.HasPrefix(lv.Name, prefix) {
+			log.Infof("Remove logical volume %s/%s", s.VgName, lv.Name)
+			if err := lvmutils.RemoveLv(s.VgName, lv.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *SLVMStorage) CreateSnapshotFormUrl(ctx context.Context, snapshotUrl, diskId, snapshotPath string) error {
+	return fmt.Errorf("Not support")
+}
+
+func (s *SLVMStorage) CreateDiskFromSnapshot(
+	ctx context.Context, disk IDisk, createParams *SDiskCreateByDiskinfo,
+) error {
+	var (
+		snapshotId, _ = createParams.DiskInfo.GetString("snapshot_url")
+		srcDiskId, _  = createParams.DiskInfo.GetString("src_disk_id")
+		diskSize, _   = createParams.DiskInfo.Int("size")
+	)
+	return disk.CreateFromSnapshotLocation(ctx, s.getSnapshotLvName(srcDiskId, snapshotId), diskSize)
+}
+
+func (s *SLVMStorage) DestinationPrepareMigrate(
+	ctx context.Context, liveMigrate bool, disksUri string, snapshotsUri string,
+	disksBackingFile, srcSnapshots jsonutils.JSONObject, rebaseDisks bool, diskinfo jsonutils.JSONObject,
+) error {
+	return fmt.Errorf("Not support migrate disks of lvm storage")
+}
+
+func (s *SLVMStorage) SaveToGlance(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	data, ok := params.(*jsonutils.JSONDict)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+
+	var (
+		imageId, _   = data.GetString("image_id")
+		imagePath, _ = data.GetString("image_path")
+		compress     = jsonutils.QueryBoolean(data, "compress", true)
+		format, _    = data.GetString("format")
+	)
+
+	if err := s.saveToGlance(ctx, imageId, imagePath, compress, format); err != nil {
+		log.Errorf("Save to glance failed: %s", err)
+		s.onSaveToGlanceFailed(ctx, imageId, err.Error())
+	}
+
+	// the saved volume is kept as image cache of the volume group
+	imageCache := NewLVMImageCache(imageId, s.imagecacheManager)
+	if err := lvmutils.RenameLv(s.VgName, path.Base(imagePath), imageCache.GetName()); err != nil {
+		log.Errorf("Fail to move saved image to cache: %s", err)
+		return nil, lvmutils.RemoveLv(s.VgName, path.Base(imagePath))
+	}
+	s.imagecacheManager.LoadImageCache(imageId)
+	_, err := hostutils.RemoteStoragecacheCacheImage(ctx,
+		s.imagecacheManager.GetId(), imageId, "ready", imageCache.GetPath())
+	if err != nil {
+		log.Errorf("Fail to remote cache image: %s", err)
+	}
+	return nil, nil
+}
+
+func (s *SLVMStorage) saveToGlance(ctx context.Context, imageId, imagePath string, compress bool, format string) error {
+	ret, err := deployclient.GetDeployClient().SaveToGlance(context.Background(),
+		&deployapi.SaveToGlanceParams{DiskPath: imagePath, Compress: compress})
+	if err != nil {
+		return err
+	}
+
+	tmpImageFile := fmt.Sprintf("/tmp/%s.img", imageId)
+	if len(format) == 0 {
+		format = options.HostOptions.DefaultImageSaveFormat
+	}
+
+	err = procutils.NewRemoteCommandAsFarAsPossible(qemutils.GetQemuImg(),
+		"convert", "-f", "raw", "-O", format, imagePath, tmpImageFile).Run()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(tmpImageFile)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpImageFile)
+	defer f.Close()
+
+	finfo, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := finfo.Size()
+
+	var params = jsonutils.NewDict()
+	if len(ret.OsInfo) > 0 {
+		params.Set("os_type", jsonutils.NewString(ret.OsInfo))
+	}
+	relInfo := ret.ReleaseInfo
+	if relInfo != nil {
+		params.Set("os_distribution", jsonutils.NewString(relInfo.Distro))
+		if len(relInfo.Version) > 0 {
+			params.Set("os_version", jsonutils.NewString(relInfo.Version))
+		}
+		if len(relInfo.Arch) > 0 {
+			params.Set("os_arch", jsonutils.NewString(relInfo.Arch))
+		}
+		if len(relInfo.Version) > 0 {
+			params.Set("os_language", jsonutils.NewString(relInfo.Language))
+		}
+	}
+	params.Set("image_id", jsonutils.NewString(imageId))
+
+	_, err = modules.Images.Upload(hostutils.GetImageSession(ctx, s.GetZoneName()),
+		params, f, size)
+	return err
+}
+
+func (s *SLVMStorage) onSaveToGlanceFailed(ctx context.Context, imageId string, reason string) {
+	params := jsonutils.NewDict()
+	params.Set("status", jsonutils.NewString("killed"))
+	params.Set("reason", jsonutils.NewString(reason))
+	_, err := modules.Images.PerformAction(
+		hostutils.GetImageSession(ctx, s.GetZoneName()),
+		imageId, "update-status", params,
+	)
+	if err != nil {
+		log.Errorln(err)
+	}
+}
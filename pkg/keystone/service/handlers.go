@@ -48,6 +48,7 @@ func InitHandlers(app *appsrv.Application) {
 		taskman.TaskManager,
 		taskman.SubTaskManager,
 		taskman.TaskObjectManager,
+		taskman.TaskWorkerManager,
 		db.Metadata,
 		models.SensitiveConfigManager,
 		models.WhitelistedConfigManager,
@@ -15,6 +15,7 @@
 package modules
 
 import (
+	"crypto/rand"
 	"encoding/base64"
 	"fmt"
 	"net/url"
@@ -26,6 +27,7 @@ import (
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/pkg/util/seclib"
 
+	"yunion.io/x/onecloud/pkg/apis"
 	api "yunion.io/x/onecloud/pkg/apis/identity"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
@@ -43,6 +45,7 @@ const (
 	TOTP_TYPE             = api.TOTP_TYPE
 	RECOVERY_SECRETS_TYPE = api.RECOVERY_SECRETS_TYPE
 	OIDC_CREDENTIAL_TYPE  = api.OIDC_CREDENTIAL_TYPE
+	ENCRYPT_KEY_TYPE      = api.ENCRYPT_KEY_TYPE
 )
 
 type STotpSecret struct {
@@ -67,6 +70,21 @@ type SRecoverySecretSet struct {
 	Timestamp int64
 }
 
+type SEncryptKeySecret struct {
+	KeyId   string `json:"-"`
+	KeyName string `json:"-"`
+	api.SEncryptKeySecretBlob
+}
+
+func (key SEncryptKeySecret) EncryptInfo() apis.SEncryptInfo {
+	return apis.SEncryptInfo{
+		Id:   key.KeyId,
+		Name: key.KeyName,
+		Key:  key.Key,
+		Alg:  key.Alg,
+	}
+}
+
 type SOpenIDConnectCredential struct {
 	ClientId string `json:"client_id"`
 	// Secret      string `json:"secret"`
@@ -275,6 +293,68 @@ func (manager *SCredentialManager) CreateAccessKeySecret(s *mcclient.ClientSessi
 	return aksk, nil
 }
 
+// CreateEncryptKey generates a random data key and stores it as a credential,
+// keystone wraps the blob with its credential key before persisting it
+func (manager *SCredentialManager) CreateEncryptKey(s *mcclient.ClientSession, name string) (SEncryptKeySecret, error) {
+	key := SEncryptKeySecret{}
+	raw := make([]byte, 32)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return key, errors.Wrap(err, "rand.Read")
+	}
+	key.Key = base64.StdEncoding.EncodeToString(raw)
+	key.Alg = apis.ENCRYPT_ALG_AES256
+	blobJson := jsonutils.Marshal(&key)
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString(ENCRYPT_KEY_TYPE), "type")
+	params.Add(jsonutils.NewString(blobJson.String()), "blob")
+	params.Add(jsonutils.NewString(fmt.Sprintf("%s-%d", name, time.Now().UnixNano())), "name")
+	result, err := manager.Create(s, params)
+	if err != nil {
+		return key, err
+	}
+	key.KeyId, _ = result.GetString("id")
+	key.KeyName, _ = result.GetString("name")
+	return key, nil
+}
+
+func DecodeEncryptKey(secret jsonutils.JSONObject) (SEncryptKeySecret, error) {
+	curr := SEncryptKeySecret{}
+	secType, _ := secret.GetString("type")
+	if secType != ENCRYPT_KEY_TYPE {
+		return curr, errors.Errorf("credential type %s is not %s", secType, ENCRYPT_KEY_TYPE)
+	}
+	blobStr, err := secret.GetString("blob")
+	if err != nil {
+		return curr, errors.Wrap(err, "secret.GetString")
+	}
+	blobJson, err := jsonutils.ParseString(blobStr)
+	if err != nil {
+		return curr, errors.Wrap(err, "jsonutils.ParseString")
+	}
+	err = blobJson.Unmarshal(&curr)
+	if err != nil {
+		return curr, errors.Wrap(err, "blobJson.Unmarshal")
+	}
+	if len(curr.Key) == 0 {
+		return curr, errors.Errorf("empty encrypt key")
+	}
+	curr.KeyId, err = secret.GetString("id")
+	if err != nil {
+		return curr, errors.Wrap(err, "secret.GetString('id')")
+	}
+	curr.KeyName, _ = secret.GetString("name")
+	return curr, nil
+}
+
+func (manager *SCredentialManager) GetEncryptKey(s *mcclient.ClientSession, kid string) (SEncryptKeySecret, error) {
+	secret, err := manager.Get(s, kid, nil)
+	if err != nil {
+		return SEncryptKeySecret{}, errors.Wrapf(err, "get encrypt key %s", kid)
+	}
+	return DecodeEncryptKey(secret)
+}
+
 func (manager *SCredentialManager) DoCreateOidcSecret(s *mcclient.ClientSession, params jsonutils.JSONObject) (jsonutils.JSONObject, error) {
 	redirectUri, _ := params.GetString("redirect_uri")
 
@@ -0,0 +1,34 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modules
+
+import "yunion.io/x/onecloud/pkg/mcclient/modulebase"
+
+var (
+	DiskBackups modulebase.ResourceManager
+)
+
+func init() {
+	DiskBackups = NewComputeManager("disk_backup", "disk_backups",
+		[]string{"ID", "Name",
+			"Status", "Disk_Id", "Backup_Mode",
+			"Parent_Id", "Chain_Id", "Backup_Storage",
+			"Size_Mb", "Disk_Size_Mb", "Created_At",
+		},
+		[]string{"Disk_Name", "Guest", "Host_Id", "Location"},
+	)
+
+	registerCompute(&DiskBackups)
+}
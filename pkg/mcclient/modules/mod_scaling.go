@@ -17,9 +17,12 @@ package modules
 import "yunion.io/x/onecloud/pkg/mcclient/modulebase"
 
 var (
-	ScalingGroup    modulebase.ResourceManager
-	ScalingPolicy   modulebase.ResourceManager
-	ScalingActivity modulebase.ResourceManager
+	ScalingGroup           modulebase.ResourceManager
+	ScalingPolicy          modulebase.ResourceManager
+	ScalingActivity        modulebase.ResourceManager
+	ScalingInstanceRefresh modulebase.ResourceManager
+	ScalingLifecycleHook   modulebase.ResourceManager
+	ScalingLifecycleAction modulebase.ResourceManager
 )
 
 func init() {
@@ -38,7 +41,25 @@ func init() {
 			"End_Time", "Reason"},
 		[]string{},
 	)
+	ScalingInstanceRefresh = NewComputeManager("scalinginstancerefresh", "scalinginstancerefreshes",
+		[]string{"ID", "Name", "Scaling_Group_ID", "Status", "Guest_Template_ID", "Min_Healthy_Percentage",
+			"Batch_Size", "Pause", "Refreshed_Number", "Start_Time", "End_Time", "Reason"},
+		[]string{},
+	)
+	ScalingLifecycleHook = NewComputeManager("scalinglifecyclehook", "scalinglifecyclehooks",
+		[]string{"ID", "Name", "Scaling_Group_ID", "Transition", "Heartbeat_Timeout", "Default_Result",
+			"Notification_Metadata"},
+		[]string{},
+	)
+	ScalingLifecycleAction = NewComputeManager("scalinglifecycleaction", "scalinglifecycleactions",
+		[]string{"ID", "Lifecycle_Hook", "Scaling_Group_ID", "Guest", "Transition", "Status", "Result",
+			"Start_Time", "Timeout_At", "End_Time"},
+		[]string{},
+	)
 	registerCompute(&ScalingGroup)
 	registerCompute(&ScalingPolicy)
 	registerCompute(&ScalingActivity)
+	registerCompute(&ScalingInstanceRefresh)
+	registerCompute(&ScalingLifecycleHook)
+	registerCompute(&ScalingLifecycleAction)
 }
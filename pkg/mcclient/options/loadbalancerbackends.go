@@ -34,8 +34,9 @@ type LoadbalancerBackendListOptions struct {
 	Address      string
 	Port         *int
 
-	SendProxy string `choices:"off|v1|v2|v2-ssl|v2-ssl-on"`
-	Ssl       string `choices:"on|off"`
+	SendProxy    string `choices:"off|v1|v2|v2-ssl|v2-ssl-on"`
+	Ssl          string `choices:"on|off"`
+	HealthStatus string `choices:"online|offline|unknown"`
 }
 
 type LoadbalancerBackendUpdateOptions struct {
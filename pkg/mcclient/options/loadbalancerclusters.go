@@ -17,8 +17,9 @@ package options
 type LoadbalancerClusterCreateOptions struct {
 	NAME string
 
-	Zone string `required:"true"`
-	Wire string
+	Zone        string `required:"true"`
+	Wire        string
+	ClusterType string `choices:"lbagent|ovn"`
 }
 
 type LoadbalancerClusterUpdateOptions struct {
@@ -30,8 +31,9 @@ type LoadbalancerClusterUpdateOptions struct {
 type LoadbalancerClusterListOptions struct {
 	BaseListOptions
 
-	Zone string
-	Wire string
+	Zone        string
+	Wire        string
+	ClusterType string `choices:"lbagent|ovn"`
 }
 
 type LoadbalancerClusterGetOptions struct {
@@ -22,7 +22,43 @@ import (
 )
 
 type DataSourceCreateOptions struct {
-	NAME string
+	NAME     string `help:"data source name"`
+	TYPE     string `help:"data source type" choices:"influxdb|prometheus"`
+	URL      string `help:"data source url, e.g. http://prometheus:9090"`
+	User     string `help:"basic auth user"`
+	Password string `help:"basic auth password"`
+	Database string `help:"default database of influxdb"`
+}
+
+func (opt DataSourceCreateOptions) Params() (*monitor.DataSourceCreateInput, error) {
+	ret := &monitor.DataSourceCreateInput{
+		Type:     opt.TYPE,
+		Url:      opt.URL,
+		User:     opt.User,
+		Password: opt.Password,
+		Database: opt.Database,
+	}
+	ret.Name = opt.NAME
+	return ret, nil
+}
+
+type DataSourceUpdateOptions struct {
+	ID       string `help:"ID or name of the data source" json:"-"`
+	Type     string `help:"data source type" choices:"influxdb|prometheus"`
+	Url      string `help:"data source url"`
+	User     string `help:"basic auth user"`
+	Password string `help:"basic auth password"`
+	Database string `help:"default database of influxdb"`
+}
+
+func (opt DataSourceUpdateOptions) Params() (*monitor.DataSourceUpdateInput, error) {
+	return &monitor.DataSourceUpdateInput{
+		Type:     opt.Type,
+		Url:      opt.Url,
+		User:     opt.User,
+		Password: opt.Password,
+		Database: opt.Database,
+	}, nil
 }
 
 type DataSourceListOptions struct {
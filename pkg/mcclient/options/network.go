@@ -69,6 +69,13 @@ type NetworkUpdateOptions struct {
 	ExternalId  string `help:"External ID"`
 	AllocPolicy string `help:"Address allocation policy" choices:"none|stepdown|stepup|random"`
 	IsAutoAlloc *bool  `help:"Add network into auto-allocation pool" negative:"no_auto_alloc"`
+
+	StartIp6     string `help:"Start ipv6 address"`
+	EndIp6       string `help:"End ipv6 address"`
+	NetMask6     int64  `help:"IPv6 prefix length"`
+	Gateway6     string `help:"IPv6 address of gateway"`
+	Dns6         string `help:"IPv6 address of DNS server"`
+	Ip6AllocMode string `help:"IPv6 address allocation mode" choices:"slaac|dhcpv6"`
 }
 
 func (opts *NetworkUpdateOptions) Params() (jsonutils.JSONObject, error) {
@@ -124,6 +131,24 @@ func (opts *NetworkUpdateOptions) Params() (jsonutils.JSONObject, error) {
 	if opts.IsAutoAlloc != nil {
 		params.Add(jsonutils.NewBool(*opts.IsAutoAlloc), "is_auto_alloc")
 	}
+	if len(opts.StartIp6) > 0 {
+		params.Add(jsonutils.NewString(opts.StartIp6), "guest_ip6_start")
+	}
+	if len(opts.EndIp6) > 0 {
+		params.Add(jsonutils.NewString(opts.EndIp6), "guest_ip6_end")
+	}
+	if opts.NetMask6 > 0 {
+		params.Add(jsonutils.NewInt(opts.NetMask6), "guest_ip6_mask")
+	}
+	if len(opts.Gateway6) > 0 {
+		params.Add(jsonutils.NewString(opts.Gateway6), "guest_gateway6")
+	}
+	if len(opts.Dns6) > 0 {
+		params.Add(jsonutils.NewString(opts.Dns6), "guest_dns6")
+	}
+	if len(opts.Ip6AllocMode) > 0 {
+		params.Add(jsonutils.NewString(opts.Ip6AllocMode), "guest_ip6_alloc_mode")
+	}
 	if params.Size() == 0 {
 		return nil, shell.InvalidUpdateError()
 	}
@@ -0,0 +1,49 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"yunion.io/x/jsonutils"
+)
+
+type ServerQgaPingOptions struct {
+	ServerIdOptions
+	Timeout int `json:"timeout" help:"seconds to wait guest agent response"`
+}
+
+func (o *ServerQgaPingOptions) Params() (jsonutils.JSONObject, error) {
+	return StructToParams(o)
+}
+
+type ServerQgaSetPasswordOptions struct {
+	ServerIdOptions
+	Username string `json:"username" help:"login account, default to login account of server"`
+	Password string `json:"password" help:"new password" required:"true"`
+}
+
+func (o *ServerQgaSetPasswordOptions) Params() (jsonutils.JSONObject, error) {
+	return StructToParams(o)
+}
+
+type ServerQgaCommandOptions struct {
+	ServerIdOptions
+	COMMAND string   `json:"command" help:"path of program inside guest"`
+	Args    []string `json:"args" help:"arguments of command"`
+	Timeout int      `json:"timeout" help:"seconds to wait command exit"`
+}
+
+func (o *ServerQgaCommandOptions) Params() (jsonutils.JSONObject, error) {
+	return StructToParams(o)
+}
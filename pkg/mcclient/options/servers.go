@@ -903,6 +903,15 @@ func (o *ServerLiveMigrateOptions) Params() (jsonutils.JSONObject, error) {
 	return StructToParams(o)
 }
 
+type ServerStopRescueOptions struct {
+	ServerIdOptions
+	AutoStart *bool `help:"Start server normally after leaving rescue mode, default true" json:"auto_start"`
+}
+
+func (o *ServerStopRescueOptions) Params() (jsonutils.JSONObject, error) {
+	return StructToParams(o)
+}
+
 type ResourceMetadataOptions struct {
 	ID   string   `help:"ID or name of resources" json:"-"`
 	TAGS []string `help:"Tags info, eg: hypervisor=aliyun、os_type=Linux、os_version"`
@@ -90,10 +90,13 @@ func (e *AlertEngine) alertingTicker(ctx context.Context) error {
 		case tick := <-e.ticker.C:
 			// TEMP SOLUTION update rules ever tenth tick
 			if tickIndex%10 == 0 {
-				e.Scheduler.Update(e.ruleReader.fetch())
+				rules := e.ruleReader.fetch()
+				e.Scheduler.Update(rules)
+				metrics.updateRules(rules)
 			}
 
 			e.Scheduler.Tick(tick, e.execQueue)
+			metrics.tick(tick, len(e.execQueue))
 			tickIndex++
 		}
 	}
@@ -223,6 +226,7 @@ func (e *AlertEngine) processJob(attemptID int, attemptChan chan int, cancelChan
 		// don't reuse the evalContext and get its own context.
 		evalContext.Ctx = resultHandleCtx
 		evalContext.Rule.State = evalContext.GetNewState()
+		metrics.observeEvaluation(evalContext)
 		if evalContext.Rule.Name == "cloudaccount_balance.balance" {
 			log.Errorf("cloudaccount_balance.balance newState:%s", string(evalContext.Rule.State))
 		}
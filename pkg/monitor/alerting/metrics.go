@@ -0,0 +1,172 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/util/promutils"
+)
+
+type alertMetric struct {
+	id            string
+	name          string
+	state         monitor.AlertStateType
+	lastEvalTime  time.Time
+	evalDuration  time.Duration
+	evalErrorsCnt int64
+}
+
+// alertingMetrics keeps the runtime state of alert engine for /metrics,
+// the scheduler jobs are not touched since they are owned by ticker goroutine
+type alertingMetrics struct {
+	lock sync.Mutex
+
+	alerts          map[string]*alertMetric
+	scheduledJobs   int
+	lastTick        time.Time
+	execQueueLength int
+
+	evalDuration   prometheus.Histogram
+	notifyFailures *prometheus.CounterVec
+}
+
+var (
+	alertLabels = []string{"alert_id", "alert_name"}
+
+	alertStateDesc     = prometheus.NewDesc("monitor_alert_state", "Current state of alert, the value is always 1.", append(alertLabels, "state"), nil)
+	alertLastEvalDesc  = prometheus.NewDesc("monitor_alert_last_evaluation_timestamp_seconds", "Unix time of the last evaluation of alert.", alertLabels, nil)
+	alertDurationDesc  = prometheus.NewDesc("monitor_alert_last_evaluation_duration_seconds", "Duration of the last evaluation of alert.", alertLabels, nil)
+	alertEvalErrorDesc = prometheus.NewDesc("monitor_alert_evaluation_errors_total", "Number of failed evaluations of alert.", alertLabels, nil)
+	scheduledJobsDesc  = prometheus.NewDesc("monitor_alerting_scheduled_jobs", "Number of alerts scheduled by alerting engine.", nil, nil)
+	execQueueDesc      = prometheus.NewDesc("monitor_alerting_exec_queue_length", "Number of jobs waiting in the execution queue.", nil, nil)
+	lastTickDesc       = prometheus.NewDesc("monitor_alerting_ticker_last_tick_timestamp_seconds", "Unix time of the last tick of alerting scheduler.", nil, nil)
+	tickLagDesc        = prometheus.NewDesc("monitor_alerting_ticker_lag_seconds", "Seconds since the last tick of alerting scheduler, it should not exceed a few seconds.", nil, nil)
+)
+
+var metrics = newAlertingMetrics()
+
+func init() {
+	promutils.Register(metrics)
+}
+
+func newAlertingMetrics() *alertingMetrics {
+	return &alertingMetrics{
+		alerts: make(map[string]*alertMetric),
+		evalDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "monitor_alerting_evaluation_duration_seconds",
+			Help:    "Duration of all alert evaluations.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		notifyFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "monitor_alerting_notification_failures_total",
+			Help: "Number of failed notifications by notifier type.",
+		}, []string{"type"}),
+	}
+}
+
+// updateRules drops the metrics of alerts no longer scheduled
+func (m *alertingMetrics) updateRules(rules []*Rule) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	alerts := make(map[string]*alertMetric)
+	for _, rule := range rules {
+		am, ok := m.alerts[rule.Id]
+		if !ok {
+			am = &alertMetric{id: rule.Id}
+		}
+		am.name = rule.Name
+		am.state = rule.State
+		alerts[rule.Id] = am
+	}
+	m.alerts = alerts
+	m.scheduledJobs = len(rules)
+}
+
+func (m *alertingMetrics) tick(tickTime time.Time, execQueueLength int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.lastTick = tickTime
+	m.execQueueLength = execQueueLength
+}
+
+func (m *alertingMetrics) observeEvaluation(evalCtx *EvalContext) {
+	duration := evalCtx.EndTime.Sub(evalCtx.StartTime)
+	if evalCtx.EndTime.IsZero() {
+		duration = time.Since(evalCtx.StartTime)
+	}
+	m.evalDuration.Observe(duration.Seconds())
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	rule := evalCtx.Rule
+	am, ok := m.alerts[rule.Id]
+	if !ok {
+		am = &alertMetric{id: rule.Id}
+		m.alerts[rule.Id] = am
+	}
+	am.name = rule.Name
+	am.state = rule.State
+	am.lastEvalTime = evalCtx.StartTime
+	am.evalDuration = duration
+	if evalCtx.Error != nil {
+		am.evalErrorsCnt += 1
+	}
+}
+
+func (m *alertingMetrics) incNotificationFailure(notifierType string) {
+	m.notifyFailures.WithLabelValues(notifierType).Inc()
+}
+
+func (m *alertingMetrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		alertStateDesc, alertLastEvalDesc, alertDurationDesc, alertEvalErrorDesc,
+		scheduledJobsDesc, execQueueDesc, lastTickDesc, tickLagDesc,
+	} {
+		ch <- desc
+	}
+	m.evalDuration.Describe(ch)
+	m.notifyFailures.Describe(ch)
+}
+
+func (m *alertingMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.evalDuration.Collect(ch)
+	m.notifyFailures.Collect(ch)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, am := range m.alerts {
+		ch <- prometheus.MustNewConstMetric(alertStateDesc, prometheus.GaugeValue, 1, am.id, am.name, string(am.state))
+		ch <- prometheus.MustNewConstMetric(alertEvalErrorDesc, prometheus.CounterValue, float64(am.evalErrorsCnt), am.id, am.name)
+		if !am.lastEvalTime.IsZero() {
+			ch <- prometheus.MustNewConstMetric(alertLastEvalDesc, prometheus.GaugeValue, float64(am.lastEvalTime.Unix()), am.id, am.name)
+			ch <- prometheus.MustNewConstMetric(alertDurationDesc, prometheus.GaugeValue, am.evalDuration.Seconds(), am.id, am.name)
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(scheduledJobsDesc, prometheus.GaugeValue, float64(m.scheduledJobs))
+	ch <- prometheus.MustNewConstMetric(execQueueDesc, prometheus.GaugeValue, float64(m.execQueueLength))
+	if !m.lastTick.IsZero() {
+		ch <- prometheus.MustNewConstMetric(lastTickDesc, prometheus.GaugeValue, float64(m.lastTick.Unix()))
+		ch <- prometheus.MustNewConstMetric(tickLagDesc, prometheus.GaugeValue, time.Since(m.lastTick).Seconds())
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"yunion.io/x/onecloud/pkg/apis/monitor"
+)
+
+func scrapeMetrics(c prometheus.Collector) string {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+	w := httptest.NewRecorder()
+	promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	return w.Body.String()
+}
+
+func TestAlertingMetrics(t *testing.T) {
+	Convey("Test alerting metrics", t, func() {
+		m := newAlertingMetrics()
+		m.updateRules([]*Rule{
+			{Id: "a1", Name: "cpu", State: monitor.AlertStateOK},
+			{Id: "a2", Name: "mem", State: monitor.AlertStateOK},
+		})
+
+		start := time.Now().Add(-time.Second)
+		evalCtx := &EvalContext{
+			Rule:      &Rule{Id: "a1", Name: "cpu", State: monitor.AlertStateAlerting},
+			StartTime: start,
+			EndTime:   start.Add(500 * time.Millisecond),
+			Error:     errors.New("query failed"),
+		}
+		m.observeEvaluation(evalCtx)
+		m.incNotificationFailure("dingtalk")
+		m.tick(time.Now(), 3)
+
+		out := scrapeMetrics(m)
+
+		So(out, ShouldContainSubstring, `monitor_alert_state{alert_id="a1",alert_name="cpu",state="alerting"} 1`)
+		So(out, ShouldContainSubstring, `monitor_alert_state{alert_id="a2",alert_name="mem",state="ok"} 1`)
+		So(out, ShouldContainSubstring, `monitor_alert_last_evaluation_duration_seconds{alert_id="a1",alert_name="cpu"} 0.5`)
+		So(out, ShouldContainSubstring, `monitor_alert_evaluation_errors_total{alert_id="a1",alert_name="cpu"} 1`)
+		So(out, ShouldContainSubstring, `monitor_alerting_notification_failures_total{type="dingtalk"} 1`)
+		So(out, ShouldContainSubstring, "monitor_alerting_scheduled_jobs 2")
+		So(out, ShouldContainSubstring, "monitor_alerting_exec_queue_length 3")
+		So(out, ShouldContainSubstring, "monitor_alerting_evaluation_duration_seconds_count 1")
+
+		Convey("removed rules are dropped", func() {
+			m.updateRules([]*Rule{{Id: "a2", Name: "mem", State: monitor.AlertStateOK}})
+			So(scrapeMetrics(m), ShouldNotContainSubstring, `alert_id="a1"`)
+		})
+	})
+}
@@ -74,6 +74,7 @@ func (n *notificationService) sendAndMarkAsComplete(evalCtx *EvalContext, state
 
 	if err := notifier.Notify(evalCtx, state.state.GetParams()); err != nil {
 		log.Errorf("failed to send notification %s: %v", notifier.GetNotifierId(), err)
+		metrics.incNotificationFailure(notifier.GetType())
 		return err
 	}
 
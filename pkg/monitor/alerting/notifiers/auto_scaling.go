@@ -56,6 +56,10 @@ func (as *AutoScalingNotifier) Notify(ctx *alerting.EvalContext, data jsonutils.
 	alarmID := ctx.Rule.Id
 	params := jsonutils.NewDict()
 	params.Set("alarm_id", jsonutils.NewString(alarmID))
+	// step and target tracking policies scale according to the value of indicator
+	if value, ok := averageEvalMatchValue(ctx.EvalMatches); ok {
+		params.Set("metric_value", jsonutils.NewFloat64(value))
+	}
 	_, err := modules.ScalingPolicy.PerformAction(as.session, scalingPolicyId, "trigger", params)
 	if err != nil {
 		return errors.Wrap(err, "Request to trigger ScalingPolicy '%s' failed")
@@ -70,3 +74,21 @@ func (as *AutoScalingNotifier) ShouldNotify(ctx context.Context, evalContext *al
 	}
 	return as.NotifierBase.ShouldNotify(ctx, evalContext, notificationState)
 }
+
+func averageEvalMatchValue(matches []*monitor.EvalMatch) (float64, bool) {
+	var (
+		sum   float64
+		count int
+	)
+	for _, match := range matches {
+		if match.Value == nil {
+			continue
+		}
+		sum += *match.Value
+		count++
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return sum / float64(count), true
+}
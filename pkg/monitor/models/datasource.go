@@ -20,6 +20,7 @@ import (
 	"database/sql"
 	"fmt"
 	"math"
+	"net/url"
 	"regexp"
 	"sort"
 	"strings"
@@ -38,6 +39,7 @@ import (
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/hostman/hostinfo/hostconsts"
 	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/mcclient/auth"
 	merrors "yunion.io/x/onecloud/pkg/monitor/errors"
 	"yunion.io/x/onecloud/pkg/monitor/options"
@@ -59,6 +61,8 @@ const (
 
 const (
 	ErrDataSourceDefaultNotFound = errors.Error("Default data source not found")
+	// ErrDataSourceSubscriptionNotSupported 数据源不支持订阅，只有 influxdb 支持
+	ErrDataSourceSubscriptionNotSupported = errors.Error("Data source not support subscription")
 )
 
 func init() {
@@ -112,6 +116,10 @@ func (man *SDataSourceManager) initDefaultDataSource(ctx context.Context) error
 			return
 		}
 		if ds != nil {
+			if ds.Type != monitor.DataSourceTypeInfluxdb {
+				// default data source switched to other backend by admin
+				return
+			}
 			if _, err := db.Update(ds, func() error {
 				ds.Url = url
 				return nil
@@ -148,11 +156,11 @@ func (man *SDataSourceManager) GetDefaultSource() (*SDataSource, error) {
 type SDataSource struct {
 	db.SStandaloneResourceBase
 
-	Type      string            `nullable:"false" list:"user"`
-	Url       string            `nullable:"false" list:"user"`
-	User      string            `width:"64" charset:"utf8" nullable:"true"`
-	Password  string            `width:"64" charset:"utf8" nullable:"true"`
-	Database  string            `width:"64" charset:"utf8" nullable:"true"`
+	Type      string            `nullable:"false" list:"user" create:"required" update:"admin"`
+	Url       string            `nullable:"false" list:"user" create:"required" update:"admin"`
+	User      string            `width:"64" charset:"utf8" nullable:"true" create:"optional" update:"admin"`
+	Password  string            `width:"256" charset:"utf8" nullable:"true" create:"optional" update:"admin"`
+	Database  string            `width:"64" charset:"utf8" nullable:"true" list:"user" create:"optional" update:"admin"`
 	IsDefault tristate.TriState `nullable:"false" default:"false" create:"optional"`
 	/*
 		TimeInterval string
@@ -162,6 +170,77 @@ type SDataSource struct {
 	*/
 }
 
+func (man *SDataSourceManager) validateTypeAndUrl(dsType, dsUrl string) error {
+	if !utils.IsInStringArray(dsType, monitor.DataSourceTypes) {
+		return httperrors.NewInputParameterError("unsupported data source type %q", dsType)
+	}
+	u, err := url.Parse(dsUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return httperrors.NewInputParameterError("invalid data source url %q", dsUrl)
+	}
+	return nil
+}
+
+func (man *SDataSourceManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input monitor.DataSourceCreateInput) (monitor.DataSourceCreateInput, error) {
+	if input.Type == "" {
+		return input, merrors.NewArgIsEmptyErr("type")
+	}
+	if input.Url == "" {
+		return input, merrors.NewArgIsEmptyErr("url")
+	}
+	if err := man.validateTypeAndUrl(input.Type, input.Url); err != nil {
+		return input, err
+	}
+	var err error
+	input.StandaloneResourceCreateInput, err = man.SStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.StandaloneResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+// CustomizeCreate stores the password encrypted by the id of data source
+func (ds *SDataSource) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	if len(ds.Password) > 0 {
+		if len(ds.Id) == 0 {
+			ds.Id = db.DefaultUUIDGenerator()
+		}
+		passwd, err := utils.EncryptAESBase64(ds.Id, ds.Password)
+		if err != nil {
+			return errors.Wrap(err, "encrypt password")
+		}
+		ds.Password = passwd
+	}
+	return ds.SStandaloneResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
+}
+
+func (ds *SDataSource) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input monitor.DataSourceUpdateInput) (monitor.DataSourceUpdateInput, error) {
+	if input.Type != "" || input.Url != "" {
+		dsType, dsUrl := ds.Type, ds.Url
+		if input.Type != "" {
+			dsType = input.Type
+		}
+		if input.Url != "" {
+			dsUrl = input.Url
+		}
+		if err := DataSourceManager.validateTypeAndUrl(dsType, dsUrl); err != nil {
+			return input, err
+		}
+	}
+	var err error
+	if input.Password != "" {
+		input.Password, err = utils.EncryptAESBase64(ds.Id, input.Password)
+		if err != nil {
+			return input, errors.Wrap(err, "encrypt password")
+		}
+	}
+	input.StandaloneResourceBaseUpdateInput, err = ds.SStandaloneResourceBase.ValidateUpdateData(ctx, userCred, query, input.StandaloneResourceBaseUpdateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SStandaloneResourceBase.ValidateUpdateData")
+	}
+	return input, nil
+}
+
 func (m *SDataSourceManager) GetSource(id string) (*SDataSource, error) {
 	ret, err := m.FetchById(id)
 	if err != nil {
@@ -196,9 +275,14 @@ func (self *SDataSourceManager) GetDatabases() (jsonutils.JSONObject, error) {
 	if err != nil {
 		return jsonutils.JSONNull, errors.Wrap(err, "s.GetDefaultSource")
 	}
-	db := influxdb.NewInfluxdb(dataSource.Url)
-	//db.SetDatabase("telegraf")
-	databases, err := db.GetDatabases()
+	var databases []string
+	if dataSource.isPrometheus() {
+		databases, err = self.getPrometheusDatabases()
+	} else {
+		db := influxdb.NewInfluxdb(dataSource.Url)
+		//db.SetDatabase("telegraf")
+		databases, err = db.GetDatabases()
+	}
 	if err != nil {
 		return jsonutils.JSONNull, errors.Wrap(err, "GetDatabases")
 	}
@@ -210,7 +294,16 @@ func (self *SDataSourceManager) GetMeasurements(query jsonutils.JSONObject,
 	measurementFilter, tagFilter string) (jsonutils.JSONObject,
 	error) {
 	ret := jsonutils.NewDict()
-	measurements, err := self.getMeasurementQueryInfluxdb(query, measurementFilter, tagFilter)
+	dataSource, err := self.GetDefaultSource()
+	if err != nil {
+		return jsonutils.JSONNull, errors.Wrap(err, "s.GetDefaultSource")
+	}
+	var measurements []monitor.InfluxMeasurement
+	if dataSource.isPrometheus() {
+		measurements, err = self.getMeasurementQueryPrometheus(dataSource, query, measurementFilter, tagFilter)
+	} else {
+		measurements, err = self.getMeasurementQueryInfluxdb(dataSource, query, measurementFilter, tagFilter)
+	}
 	if err != nil {
 		return jsonutils.JSONNull, err
 	}
@@ -218,16 +311,12 @@ func (self *SDataSourceManager) GetMeasurements(query jsonutils.JSONObject,
 	return ret, nil
 }
 
-func (self *SDataSourceManager) getMeasurementQueryInfluxdb(query jsonutils.JSONObject,
+func (self *SDataSourceManager) getMeasurementQueryInfluxdb(dataSource *SDataSource, query jsonutils.JSONObject,
 	measurementFilter, tagFilter string) (rtnMeasurements []monitor.InfluxMeasurement, err error) {
 	database, _ := query.GetString("database")
 	if database == "" {
 		return rtnMeasurements, merrors.NewArgIsEmptyErr("database")
 	}
-	dataSource, err := self.GetDefaultSource()
-	if err != nil {
-		return rtnMeasurements, errors.Wrap(err, "s.GetDefaultSource")
-	}
 	db := influxdb.NewInfluxdb(dataSource.Url)
 	db.SetDatabase(database)
 	var buffer bytes.Buffer
@@ -261,8 +350,8 @@ func (self *SDataSourceManager) getMeasurementQueryInfluxdb(query jsonutils.JSON
 	return
 }
 
-func (self *SDataSourceManager) GetMeasurementsWithDescriptionInfos(query jsonutils.JSONObject, measurementFilter,
-	tagFilter string) (jsonutils.JSONObject, error) {
+func (self *SDataSourceManager) GetMeasurementsWithDescriptionInfos(query jsonutils.JSONObject, measurementFilter string,
+	tags []monitor.MetricQueryTag) (jsonutils.JSONObject, error) {
 	ret := jsonutils.NewDict()
 	rtnMeasurements := make([]monitor.InfluxMeasurement, 0)
 	measurements, err := MetricMeasurementManager.getInfluxdbMeasurements()
@@ -273,8 +362,13 @@ func (self *SDataSourceManager) GetMeasurementsWithDescriptionInfos(query jsonut
 	if err != nil {
 		return jsonutils.JSONNull, errors.Wrap(err, "s.GetDefaultSource")
 	}
-	db := influxdb.NewInfluxdb(dataSource.Url)
-	filterMeasurements, err := self.filterMeasurementsByTime(*db, measurements, query, tagFilter)
+	var filterMeasurements []monitor.InfluxMeasurement
+	if dataSource.isPrometheus() {
+		filterMeasurements, err = self.filterPrometheusMeasurementsByTime(dataSource, measurements, query, tags)
+	} else {
+		db := influxdb.NewInfluxdb(dataSource.Url)
+		filterMeasurements, err = self.filterMeasurementsByTime(*db, measurements, query, renderInfluxdbTagFilter(tags))
+	}
 	if err != nil {
 		return jsonutils.JSONNull, errors.Wrap(err, "filterMeasurementsByTime error")
 	}
@@ -321,6 +415,14 @@ func (self *SDataSourceManager) GetMeasurementsWithOutTimeFilter(query jsonutils
 	if err != nil {
 		return jsonutils.JSONNull, errors.Wrap(err, "s.GetDefaultSource")
 	}
+	if dataSource.isPrometheus() {
+		measurements, err := self.getMeasurementQueryPrometheus(dataSource, query, measurementFilter, tagFilter)
+		if err != nil {
+			return jsonutils.JSONNull, err
+		}
+		ret.Add(jsonutils.Marshal(&measurements), "measurements")
+		return ret, nil
+	}
 	db := influxdb.NewInfluxdb(dataSource.Url)
 	db.SetDatabase(database)
 	var buffer bytes.Buffer
@@ -564,7 +666,7 @@ func (self *SDataSourceManager) renderTimeFilter(from, to string) string {
 
 }
 
-func (self *SDataSourceManager) GetMetricMeasurement(query jsonutils.JSONObject, tagFilter string) (jsonutils.JSONObject, error) {
+func (self *SDataSourceManager) GetMetricMeasurement(query jsonutils.JSONObject, tags []monitor.MetricQueryTag) (jsonutils.JSONObject, error) {
 	database, _ := query.GetString("database")
 	if database == "" {
 		return jsonutils.JSONNull, merrors.NewArgIsEmptyErr("database")
@@ -591,6 +693,16 @@ func (self *SDataSourceManager) GetMetricMeasurement(query jsonutils.JSONObject,
 		return nil, err
 	}
 
+	if dataSource.isPrometheus() {
+		output, err := self.getPrometheusMetricMeasurement(dataSource, database, measurement, field, timeF, tags)
+		if err != nil {
+			return jsonutils.JSONNull, errors.Wrap(err, "getPrometheusMetricMeasurement")
+		}
+		self.filterRtnTags(output)
+		return jsonutils.Marshal(output), nil
+	}
+
+	tagFilter := renderInfluxdbTagFilter(tags)
 	db := influxdb.NewInfluxdb(dataSource.Url)
 	db.SetDatabase(database)
 
@@ -752,6 +864,9 @@ func (self *SDataSourceManager) AddSubscription(subscription InfluxdbSubscriptio
 	if err != nil {
 		return errors.Wrap(err, "s.GetDefaultSource")
 	}
+	if dataSource.isPrometheus() {
+		return ErrDataSourceSubscriptionNotSupported
+	}
 
 	db := influxdb.NewInfluxdbWithDebug(dataSource.Url, true)
 	db.SetDatabase(subscription.DataBase)
@@ -778,6 +893,9 @@ func (self *SDataSourceManager) DropSubscription(subscription InfluxdbSubscripti
 	if err != nil {
 		return errors.Wrap(err, "s.GetDefaultSource")
 	}
+	if dataSource.isPrometheus() {
+		return ErrDataSourceSubscriptionNotSupported
+	}
 
 	db := influxdb.NewInfluxdb(dataSource.Url)
 	db.SetDatabase(subscription.DataBase)
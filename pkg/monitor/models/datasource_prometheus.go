@@ -0,0 +1,207 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/monitor/tsdb"
+	"yunion.io/x/onecloud/pkg/monitor/tsdb/driver/prometheus"
+)
+
+func (ds *SDataSource) isPrometheus() bool {
+	return ds.Type == monitor.DataSourceTypePrometheus
+}
+
+func (ds *SDataSource) getPrometheusClient() (*prometheus.MetadataClient, error) {
+	return prometheus.NewMetadataClient(ds.ToTSDBDataSource(""))
+}
+
+// getPrometheusDatabases returns the databases of the registered measurements,
+// prometheus keeps all the metrics in one namespace
+func (self *SDataSourceManager) getPrometheusDatabases() ([]string, error) {
+	measurements, err := MetricMeasurementManager.getInfluxdbMeasurements()
+	if err != nil {
+		return nil, errors.Wrap(err, "getInfluxdbMeasurements")
+	}
+	databases := make([]string, 0)
+	for _, m := range measurements {
+		if len(m.Database) != 0 && !utils.IsInStringArray(m.Database, databases) {
+			databases = append(databases, m.Database)
+		}
+	}
+	sort.Strings(databases)
+	return databases, nil
+}
+
+func renderPrometheusMatches(measurement, field string, tags []monitor.MetricQueryTag) ([]string, error) {
+	selector, err := prometheus.RenderSelector(measurement, field, tags)
+	if err != nil {
+		return nil, httperrors.NewInputParameterError("invalid tag filter: %v", err)
+	}
+	if len(selector) == 0 {
+		return nil, nil
+	}
+	return []string{selector}, nil
+}
+
+// groupPrometheusMetrics splits the metric names to the fields of
+// measurements, a metric name belongs to the measurement of the longest
+// prefix, e.g. net_response_result_code is field result_code of
+// net_response rather than field response_result_code of net
+func groupPrometheusMetrics(measurements []monitor.InfluxMeasurement, names []string) []monitor.InfluxMeasurement {
+	fields := make([][]string, len(measurements))
+	for _, name := range names {
+		idx, prefixLen := -1, 0
+		for i := range measurements {
+			prefix := prometheus.MetricName(measurements[i].Measurement, "")
+			if len(prefix) > prefixLen && len(name) > len(prefix) && strings.HasPrefix(name, prefix) {
+				idx, prefixLen = i, len(prefix)
+			}
+		}
+		if idx >= 0 {
+			fields[idx] = append(fields[idx], name[prefixLen:])
+		}
+	}
+	ret := make([]monitor.InfluxMeasurement, 0)
+	for i := range measurements {
+		if len(fields[i]) == 0 {
+			continue
+		}
+		sort.Strings(fields[i])
+		ret = append(ret, monitor.InfluxMeasurement{
+			Measurement: measurements[i].Measurement,
+			Database:    measurements[i].Database,
+			ResType:     measurements[i].ResType,
+			FieldKey:    fields[i],
+		})
+	}
+	return ret
+}
+
+func (self *SDataSourceManager) getPrometheusMeasurements(ds *SDataSource, measurements []monitor.InfluxMeasurement,
+	start, end time.Time, tags []monitor.MetricQueryTag) ([]monitor.InfluxMeasurement, error) {
+	cli, err := ds.getPrometheusClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "getPrometheusClient")
+	}
+	matches, err := renderPrometheusMatches("", "", tags)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
+	defer cancel()
+	names, err := cli.LabelValues(ctx, prometheus.LabelMetricName, matches, start, end)
+	if err != nil {
+		return nil, errors.Wrap(err, "get metric names")
+	}
+	return groupPrometheusMetrics(measurements, names), nil
+}
+
+// getMeasurementQueryPrometheus lists the registered measurements having
+// series in prometheus, the filters in influxdb syntax could not be applied
+func (self *SDataSourceManager) getMeasurementQueryPrometheus(ds *SDataSource, query jsonutils.JSONObject,
+	measurementFilter, tagFilter string) ([]monitor.InfluxMeasurement, error) {
+	if len(measurementFilter) != 0 || len(tagFilter) != 0 {
+		return nil, httperrors.NewNotSupportedError("prometheus data source not support influxdb filter")
+	}
+	database, _ := query.GetString("database")
+	measurements, err := MetricMeasurementManager.getInfluxdbMeasurements()
+	if err != nil {
+		return nil, errors.Wrap(err, "getInfluxdbMeasurements")
+	}
+	dbMeasurements := make([]monitor.InfluxMeasurement, 0)
+	for _, m := range measurements {
+		if len(database) == 0 || m.Database == database {
+			dbMeasurements = append(dbMeasurements, m)
+		}
+	}
+	return self.getPrometheusMeasurements(ds, dbMeasurements, time.Time{}, time.Time{}, nil)
+}
+
+func (self *SDataSourceManager) filterPrometheusMeasurementsByTime(ds *SDataSource,
+	measurements []monitor.InfluxMeasurement, query jsonutils.JSONObject, tags []monitor.MetricQueryTag) ([]monitor.InfluxMeasurement, error) {
+	timeF, err := self.getFromAndToFromParam(query)
+	if err != nil {
+		return nil, err
+	}
+	tr := tsdb.NewTimeRange(timeF.From, timeF.To)
+	return self.getPrometheusMeasurements(ds, measurements, tr.MustGetFrom(), tr.MustGetTo(), tags)
+}
+
+// getPrometheusMetricMeasurement fetches the tag keys and values of the
+// series of field within the time range
+func (self *SDataSourceManager) getPrometheusMetricMeasurement(ds *SDataSource, database, measurement, field string,
+	timeF timeFilter, tags []monitor.MetricQueryTag) (*monitor.InfluxMeasurement, error) {
+	cli, err := ds.getPrometheusClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "getPrometheusClient")
+	}
+	matches, err := renderPrometheusMatches(measurement, field, tags)
+	if err != nil {
+		return nil, err
+	}
+	tr := tsdb.NewTimeRange(timeF.From, timeF.To)
+	start, end := tr.MustGetFrom(), tr.MustGetTo()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	output := &monitor.InfluxMeasurement{
+		Measurement: measurement,
+		Database:    database,
+		FieldKey:    []string{field},
+		TagValue:    make(map[string][]string, 0),
+	}
+	labels, err := cli.Labels(ctx, matches, start, end)
+	if err != nil {
+		return nil, errors.Wrap(err, "get labels")
+	}
+	for _, label := range labels {
+		if label == prometheus.LabelMetricName || filterTagKey(label) {
+			continue
+		}
+		output.TagKey = append(output.TagKey, label)
+	}
+	series, err := cli.Series(ctx, matches, start, end)
+	if err != nil {
+		return nil, errors.Wrap(err, "get series")
+	}
+	tagValMap := make(map[string][]string)
+	for _, labelSet := range series {
+		for key, val := range labelSet {
+			val = self._renderTagVal(val)
+			if len(val) == 0 || val == "null" || filterTagValue(val) {
+				continue
+			}
+			if !utils.IsInStringArray(key, output.TagKey) {
+				continue
+			}
+			if !utils.IsInStringArray(val, tagValMap[key]) {
+				tagValMap[key] = append(tagValMap[key], val)
+			}
+		}
+	}
+	tagValUnion(output, tagValMap)
+	return output, nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	"yunion.io/x/onecloud/pkg/apis/monitor"
+)
+
+func TestGroupPrometheusMetrics(t *testing.T) {
+	measurements := []monitor.InfluxMeasurement{
+		{Measurement: "net", Database: "telegraf", ResType: "host"},
+		{Measurement: "net_response", Database: "telegraf", ResType: "host"},
+		{Measurement: "cpu", Database: "telegraf", ResType: "host"},
+		{Measurement: "mem", Database: "telegraf", ResType: "host"},
+	}
+	names := []string{"net_bytes_recv", "net_response_result_code", "cpu_usage_active", "cpu_usage_idle", "disk_used", "net_"}
+	want := []monitor.InfluxMeasurement{
+		{Measurement: "net", Database: "telegraf", ResType: "host", FieldKey: []string{"bytes_recv"}},
+		{Measurement: "net_response", Database: "telegraf", ResType: "host", FieldKey: []string{"result_code"}},
+		{Measurement: "cpu", Database: "telegraf", ResType: "host", FieldKey: []string{"usage_active", "usage_idle"}},
+	}
+	if got := groupPrometheusMetrics(measurements, names); !reflect.DeepEqual(got, want) {
+		t.Errorf("groupPrometheusMetrics() = %#v, want %#v", got, want)
+	}
+}
+
+func TestRenderInfluxdbTagFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []monitor.MetricQueryTag
+		want string
+	}{
+		{
+			name: "no tags",
+			want: "",
+		},
+		{
+			name: "project",
+			tags: []monitor.MetricQueryTag{{Key: "tenant_id", Operator: "=~", Value: "/p1/"}},
+			want: `"tenant_id" =~ /p1/`,
+		},
+		{
+			name: "default and",
+			tags: []monitor.MetricQueryTag{
+				{Key: "domain_id", Operator: "=~", Value: "/d1/"},
+				{Key: "tenant_id", Operator: "=~", Value: "/p1/"},
+			},
+			want: `"domain_id" =~ /d1/ AND "tenant_id" =~ /p1/`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderInfluxdbTagFilter(tt.tags); got != tt.want {
+				t.Errorf("renderInfluxdbTagFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/util/promutils"
+)
+
+var (
+	umQueries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "monitor_unifiedmonitor_queries_total",
+		Help: "Number of unified monitor queries by status.",
+	}, []string{"status"})
+	umQueryDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "monitor_unifiedmonitor_query_duration_seconds",
+		Help:    "Duration of unified monitor queries.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+func init() {
+	promutils.Register(umQueries)
+	promutils.Register(umQueryDuration)
+	promutils.Register(newAlertStatsCollector(alertStatsCacheTTL, fetchAlertStats))
+}
+
+func observeUnifiedMonitorQuery(start time.Time, err error) {
+	umQueryDuration.Observe(time.Since(start).Seconds())
+
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	umQueries.WithLabelValues(status).Inc()
+}
+
+type sAlertStateCount struct {
+	State   string
+	UsedBy  string
+	Enabled bool
+	Count   int
+}
+
+type sAlertRecordLevelCount struct {
+	Level string
+	State string
+	Count int
+}
+
+// alertStatsCacheTTL bounds how often the alerts are counted in database,
+// scrapes within it get the cached counts
+const alertStatsCacheTTL = time.Minute
+
+// alertStatsCollector exports the counts of alerts and recent alert records
+// in database, the counts are cached so that scrapes never run the GROUP BY
+// queries more than once per ttl
+type alertStatsCollector struct {
+	lock      sync.Mutex
+	ttl       time.Duration
+	updatedAt time.Time
+	fetch     func() ([]sAlertStateCount, []sAlertRecordLevelCount, error)
+
+	alerts  *prometheus.GaugeVec
+	records *prometheus.GaugeVec
+}
+
+func newAlertStatsCollector(ttl time.Duration, fetch func() ([]sAlertStateCount, []sAlertRecordLevelCount, error)) *alertStatsCollector {
+	return &alertStatsCollector{
+		ttl:   ttl,
+		fetch: fetch,
+		alerts: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "monitor_alerts",
+			Help: "Number of alerts by state, enabled and used_by.",
+		}, []string{"state", "used_by", "enabled"}),
+		records: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "monitor_alert_records_last_hour",
+			Help: "Number of alert records created in the last hour by level and state.",
+		}, []string{"level", "state"}),
+	}
+}
+
+func (c *alertStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.alerts.Describe(ch)
+	c.records.Describe(ch)
+}
+
+func (c *alertStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.Lock()
+	if time.Since(c.updatedAt) >= c.ttl {
+		c.refresh()
+	}
+	c.lock.Unlock()
+
+	c.alerts.Collect(ch)
+	c.records.Collect(ch)
+}
+
+// refresh keeps the previous counts on failure, the next scrape after ttl
+// retries
+func (c *alertStatsCollector) refresh() {
+	c.updatedAt = time.Now()
+	stateCnts, levelCnts, err := c.fetch()
+	if err != nil {
+		log.Errorf("fetch alert stats: %v", err)
+		return
+	}
+	c.alerts.Reset()
+	for _, cnt := range stateCnts {
+		enabled := "false"
+		if cnt.Enabled {
+			enabled = "true"
+		}
+		c.alerts.WithLabelValues(cnt.State, cnt.UsedBy, enabled).Set(float64(cnt.Count))
+	}
+	c.records.Reset()
+	for _, cnt := range levelCnts {
+		c.records.WithLabelValues(cnt.Level, cnt.State).Set(float64(cnt.Count))
+	}
+}
+
+// fetchAlertStats counts the alerts and recent alert records in database
+func fetchAlertStats() ([]sAlertStateCount, []sAlertRecordLevelCount, error) {
+	if AlertManager == nil || AlertRecordManager == nil {
+		return nil, nil, nil
+	}
+
+	aq := AlertManager.Query().SubQuery()
+	q := aq.Query(aq.Field("state"), aq.Field("used_by"), aq.Field("enabled"), sqlchemy.COUNT("count")).
+		GroupBy(aq.Field("state"), aq.Field("used_by"), aq.Field("enabled"))
+	stateCnts := make([]sAlertStateCount, 0)
+	if err := q.All(&stateCnts); err != nil {
+		return nil, nil, errors.Wrap(err, "count alerts by state")
+	}
+
+	rq := AlertRecordManager.Query().SubQuery()
+	q = rq.Query(rq.Field("level"), rq.Field("state"), sqlchemy.COUNT("count")).
+		Filter(sqlchemy.GE(rq.Field("created_at"), time.Now().Add(-time.Hour))).
+		GroupBy(rq.Field("level"), rq.Field("state"))
+	levelCnts := make([]sAlertRecordLevelCount, 0)
+	if err := q.All(&levelCnts); err != nil {
+		return nil, nil, errors.Wrap(err, "count alert records by level")
+	}
+	return stateCnts, levelCnts, nil
+}
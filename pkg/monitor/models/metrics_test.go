@@ -0,0 +1,74 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"yunion.io/x/pkg/errors"
+)
+
+func scrapeMetrics(c prometheus.Collector) string {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+	w := httptest.NewRecorder()
+	promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	return w.Body.String()
+}
+
+func TestAlertStatsCollector(t *testing.T) {
+	fetchCnt := 0
+	var fetchErr error
+	c := newAlertStatsCollector(time.Hour, func() ([]sAlertStateCount, []sAlertRecordLevelCount, error) {
+		fetchCnt += 1
+		if fetchErr != nil {
+			return nil, nil, fetchErr
+		}
+		return []sAlertStateCount{{State: "ok", UsedBy: "", Enabled: true, Count: fetchCnt}},
+			[]sAlertRecordLevelCount{{Level: "fatal", State: "alerting", Count: 2}}, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		out := scrapeMetrics(c)
+		for _, want := range []string{
+			`monitor_alerts{enabled="true",state="ok",used_by=""} 1`,
+			`monitor_alert_records_last_hour{level="fatal",state="alerting"} 2`,
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("scrape %d: %q not found in:\n%s", i, want, out)
+			}
+		}
+	}
+	if fetchCnt != 1 {
+		t.Errorf("stats should be fetched once within ttl, fetched %d times", fetchCnt)
+	}
+
+	// expired cache is refreshed, failures keep the previous counts
+	c.ttl = 0
+	fetchErr = errors.Error("db down")
+	if out := scrapeMetrics(c); !strings.Contains(out, `monitor_alerts{enabled="true",state="ok",used_by=""} 1`) {
+		t.Errorf("previous counts should be kept on failure:\n%s", out)
+	}
+	fetchErr = nil
+	if out := scrapeMetrics(c); !strings.Contains(out, `monitor_alerts{enabled="true",state="ok",used_by=""} 3`) {
+		t.Errorf("counts should be refreshed after ttl:\n%s", out)
+	}
+}
@@ -73,20 +73,20 @@ func (self *SUnifiedMonitorManager) GetPropertyMeasurements(ctx context.Context,
 	return DataSourceManager.GetMeasurementsWithDescriptionInfos(query, "", filter)
 }
 
-func getTagFilterByRequestQuery(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (filter string, err error) {
+func getTagFilterByRequestQuery(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (filter []monitor.MetricQueryTag, err error) {
 
 	scope, _ := query.GetString("scope")
 	filter, err = filterByScope(ctx, userCred, scope, query)
 	return
 }
 
-func filterByScope(ctx context.Context, userCred mcclient.TokenCredential, scope string, data jsonutils.JSONObject) (string, error) {
+func filterByScope(ctx context.Context, userCred mcclient.TokenCredential, scope string, data jsonutils.JSONObject) ([]monitor.MetricQueryTag, error) {
 	domainId := jsonutils.GetAnyString(data, []string{"domain_id", "domain", "project_domain_id", "project_domain"})
 	projectId := jsonutils.GetAnyString(data, []string{"project_id", "project"})
 	if projectId != "" {
 		project, err := db.DefaultProjectFetcher(ctx, projectId)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		projectId = project.GetProjectId()
 		domainId = project.GetProjectDomainId()
@@ -94,14 +94,14 @@ func filterByScope(ctx context.Context, userCred mcclient.TokenCredential, scope
 	if domainId != "" {
 		domain, err := db.DefaultDomainFetcher(ctx, domainId)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		domainId = domain.GetProjectDomainId()
 		domain.GetProjectId()
 	}
 	switch scope {
 	case "system":
-		return "", nil
+		return nil, nil
 	case "domain":
 		if domainId == "" {
 			domainId = userCred.GetProjectDomainId()
@@ -117,21 +117,27 @@ func filterByScope(ctx context.Context, userCred mcclient.TokenCredential, scope
 }
 
 func getTenantIdStr(role string, userCred mcclient.TokenCredential) (string, error) {
-	if role == "admin" {
+	var (
+		tags []monitor.MetricQueryTag
+		err  error
+	)
+	switch role {
+	case "admin":
 		return "", nil
+	case "domainadmin":
+		tags, err = getProjectIdsFilterByDomain(userCred.GetDomainId())
+	case "member":
+		tags, err = getProjectIdFilterByProject(userCred.GetProjectId())
+	default:
+		return "", errors.ErrNotFound
 	}
-	if role == "domainadmin" {
-		domainId := userCred.GetDomainId()
-		return getProjectIdsFilterByDomain(domainId)
-	}
-	if role == "member" {
-		tenantId := userCred.GetProjectId()
-		return getProjectIdFilterByProject(tenantId)
+	if err != nil {
+		return "", err
 	}
-	return "", errors.ErrNotFound
+	return renderInfluxdbTagFilter(tags), nil
 }
 
-func getProjectIdsFilterByDomain(domainId string) (string, error) {
+func getProjectIdsFilterByDomain(domainId string) ([]monitor.MetricQueryTag, error) {
 	//s := auth.GetAdminSession(context.Background(), "", "")
 	//params := jsonutils.Marshal(map[string]string{"domain_id": domainId})
 	//tenants, err := modules.Projects.List(s, params)
@@ -150,12 +156,28 @@ func getProjectIdsFilterByDomain(domainId string) (string, error) {
 	//}
 	//buffer.WriteString(" )")
 	//return buffer.String(), nil
-	return fmt.Sprintf(`"%s" =~ /%s/`, "domain_id", domainId), nil
+	return []monitor.MetricQueryTag{{Key: "domain_id", Operator: "=~", Value: fmt.Sprintf("/%s/", domainId)}}, nil
 
 }
 
-func getProjectIdFilterByProject(projectId string) (string, error) {
-	return fmt.Sprintf(`"%s" =~ /%s/`, "tenant_id", projectId), nil
+func getProjectIdFilterByProject(projectId string) ([]monitor.MetricQueryTag, error) {
+	return []monitor.MetricQueryTag{{Key: "tenant_id", Operator: "=~", Value: fmt.Sprintf("/%s/", projectId)}}, nil
+}
+
+// renderInfluxdbTagFilter renders tags to the where condition of influxdb
+func renderInfluxdbTagFilter(tags []monitor.MetricQueryTag) string {
+	var filter strings.Builder
+	for i, tag := range tags {
+		if i > 0 {
+			cond := tag.Condition
+			if cond == "" {
+				cond = "AND"
+			}
+			filter.WriteString(fmt.Sprintf(" %s ", cond))
+		}
+		filter.WriteString(fmt.Sprintf(`"%s" %s %s`, tag.Key, tag.Operator, tag.Value))
+	}
+	return filter.String()
 }
 
 func (self *SUnifiedMonitorManager) AllowGetPropertyMetricMeasurement(ctx context.Context,
@@ -225,7 +247,9 @@ func (self *SUnifiedMonitorManager) PerformQuery(ctx context.Context, userCred m
 		}
 	}
 
+	start := time.Now()
 	rtn, err := doQuery(*inputQuery)
+	observeUnifiedMonitorQuery(start, err)
 	if err != nil {
 		return jsonutils.NewDict(), err
 	}
@@ -62,17 +62,21 @@ func (self *SSubscriptionManager) AddSubscription() {
 		Url:      self.getThisFunctionUrl(),
 	}
 	err := models.DataSourceManager.DropSubscription(sub)
-	if err != nil {
-		log.Errorln("DropSubscription err:", err)
-		return
-	}
-	log.Infof("drop success")
-	err = models.DataSourceManager.AddSubscription(sub)
-	if err != nil {
-		log.Errorln("add subscription err:", err)
-		return
+	if errors.Cause(err) == models.ErrDataSourceSubscriptionNotSupported {
+		log.Infof("default data source not support subscription, skip")
+	} else {
+		if err != nil {
+			log.Errorln("DropSubscription err:", err)
+			return
+		}
+		log.Infof("drop success")
+		err = models.DataSourceManager.AddSubscription(sub)
+		if err != nil {
+			log.Errorln("add subscription err:", err)
+			return
+		}
+		log.Infof("add success")
 	}
-	log.Infof("add success")
 	if err := self.LoadSystemAlerts(); err != nil {
 		log.Errorf("load system alerts error: %v", err)
 		return
@@ -21,6 +21,8 @@ import (
 	"sync"
 	"time"
 
+	"yunion.io/x/pkg/utils"
+
 	"yunion.io/x/onecloud/pkg/monitor/options"
 )
 
@@ -142,15 +144,24 @@ func (ds *DataSource) GetTLSConfig() (*tls.Config, error) {
 	return tlsConfig, nil
 }
 
+// DecryptedPassword returns the password stored encrypted by the id of data
+// source, a password saved in plain text before is returned as is
+func (ds *DataSource) DecryptedPassword() string {
+	if len(ds.Password) == 0 {
+		return ""
+	}
+	passwd, err := utils.DescryptAESBase64(ds.Id, ds.Password)
+	if err != nil {
+		return ds.Password
+	}
+	return passwd
+}
+
 /*
 func (ds *DataSource) DecryptedBasicAuthPassword() string {
 	return ds.decryptedValue("basicAuthPassword", ds.BasicAuthPassword)
 }
 
-func (ds *DataSource) DecryptedPassword() string {
-	return ds.decryptedValue("password", ds.Password)
-}
-
 func (ds *DataSource) decryptedValue(field string, fallback string) string {
 	if value, ok := ds.DecryptedValue(field); ok {
 		return value
@@ -0,0 +1,59 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tsdb
+
+import (
+	"testing"
+
+	"yunion.io/x/pkg/utils"
+)
+
+func TestDecryptedPassword(t *testing.T) {
+	const id = "0c3e8b2e-5a3b-4b5e-8f4e-2f1d6a7b9c10"
+	encrypted, err := utils.EncryptAESBase64(id, "s3cret")
+	if err != nil {
+		t.Fatalf("encrypt password: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		want     string
+	}{
+		{
+			name:     "empty password",
+			password: "",
+			want:     "",
+		},
+		{
+			name:     "encrypted password",
+			password: encrypted,
+			want:     "s3cret",
+		},
+		{
+			name:     "plain password saved before",
+			password: "plain-password",
+			want:     "plain-password",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &DataSource{Id: id, Password: tt.password}
+			if got := ds.DecryptedPassword(); got != tt.want {
+				t.Errorf("DecryptedPassword() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
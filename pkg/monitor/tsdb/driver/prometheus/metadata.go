@@ -0,0 +1,148 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context/ctxhttp"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/monitor/tsdb"
+)
+
+const (
+	// LabelMetricName is the label holding the metric name of series
+	LabelMetricName = "__name__"
+)
+
+// MetadataClient lists the metric names, label names and label values of
+// prometheus, which replaces the SHOW queries of influxdb
+type MetadataClient struct {
+	dsInfo     *tsdb.DataSource
+	httpClient *http.Client
+}
+
+type metadataResponse struct {
+	Status    string          `json:"status"`
+	Data      json.RawMessage `json:"data"`
+	ErrorType string          `json:"errorType,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Warnings  []string        `json:"warnings,omitempty"`
+}
+
+func NewMetadataClient(dsInfo *tsdb.DataSource) (*MetadataClient, error) {
+	httpClient, err := dsInfo.GetHttpClient()
+	if err != nil {
+		return nil, err
+	}
+	return &MetadataClient{
+		dsInfo:     dsInfo,
+		httpClient: httpClient,
+	}, nil
+}
+
+// Labels returns the label names of the series matched by any of matches
+// between start and end, zero start or end leaves the range open
+func (c *MetadataClient) Labels(ctx context.Context, matches []string, start, end time.Time) ([]string, error) {
+	ret := make([]string, 0)
+	if err := c.get(ctx, "api/v1/labels", matches, start, end, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// LabelValues returns the values of label name, label __name__ lists the
+// metric names
+func (c *MetadataClient) LabelValues(ctx context.Context, name string, matches []string, start, end time.Time) ([]string, error) {
+	ret := make([]string, 0)
+	if err := c.get(ctx, path.Join("api/v1/label", name, "values"), matches, start, end, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Series returns the label sets of the series matched by any of matches,
+// at least one match is required by prometheus
+func (c *MetadataClient) Series(ctx context.Context, matches []string, start, end time.Time) ([]map[string]string, error) {
+	if len(matches) == 0 {
+		return nil, errors.Error("series requires at least one match")
+	}
+	ret := make([]map[string]string, 0)
+	if err := c.get(ctx, "api/v1/series", matches, start, end, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (c *MetadataClient) get(ctx context.Context, api string, matches []string, start, end time.Time, data interface{}) error {
+	u, err := url.Parse(c.dsInfo.Url)
+	if err != nil {
+		return errors.Wrapf(err, "parse url %q", c.dsInfo.Url)
+	}
+	u.Path = path.Join(u.Path, api)
+	params := url.Values{}
+	for _, m := range matches {
+		params.Add("match[]", m)
+	}
+	if !start.IsZero() {
+		params.Set("start", formatTimestamp(start))
+	}
+	if !end.IsZero() {
+		params.Set("end", formatTimestamp(end))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "OneCloud Monitor")
+	req.Header.Set("Content-type", "application/x-www-form-urlencoded")
+	if c.dsInfo.User != "" {
+		req.SetBasicAuth(c.dsInfo.User, c.dsInfo.DecryptedPassword())
+	}
+
+	resp, err := ctxhttp.Do(ctx, c.httpClient, req)
+	if err != nil {
+		return errors.Wrapf(err, "request %s", api)
+	}
+	defer resp.Body.Close()
+
+	response := new(metadataResponse)
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		if resp.StatusCode/100 != 2 {
+			return errors.Wrapf(ErrPrometheusInvalidResponse, "status code: %v", resp.Status)
+		}
+		return errors.Wrap(err, "decode response")
+	}
+	if response.Status != "success" {
+		return errors.Wrapf(ErrPrometheusInvalidResponse, "status code: %v, %s: %s", resp.Status, response.ErrorType, response.Error)
+	}
+	for _, w := range response.Warnings {
+		log.Warningf("Prometheus %s warning: %s", api, w)
+	}
+	if err := json.Unmarshal(response.Data, data); err != nil {
+		return errors.Wrapf(err, "decode %s data", api)
+	}
+	return nil
+}
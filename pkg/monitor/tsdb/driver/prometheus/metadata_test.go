@@ -0,0 +1,92 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"yunion.io/x/onecloud/pkg/monitor/tsdb"
+)
+
+func TestPrometheusMetadataClient(t *testing.T) {
+	Convey("Prometheus metadata client", t, func() {
+		var (
+			reqPath string
+			matches []string
+			start   string
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+			reqPath = r.URL.Path
+			matches = r.Form["match[]"]
+			start = r.Form.Get("start")
+			switch r.URL.Path {
+			case "/api/v1/labels":
+				w.Write([]byte(`{"status":"success","data":["__name__","host","tenant_id"]}`))
+			case "/api/v1/label/__name__/values":
+				w.Write([]byte(`{"status":"success","data":["cpu_usage_active","mem_used"]}`))
+			case "/api/v1/series":
+				w.Write([]byte(`{"status":"success","data":[{"__name__":"cpu_usage_active","host":"h1"}]}`))
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"unknown api"}`))
+			}
+		}))
+		defer srv.Close()
+
+		cli, err := NewMetadataClient(&tsdb.DataSource{Url: srv.URL})
+		So(err, ShouldBeNil)
+		ctx := context.Background()
+		end := time.Unix(1600000000, 0)
+
+		Convey("list label names", func() {
+			labels, err := cli.Labels(ctx, []string{`{tenant_id="p1"}`}, end.Add(-time.Hour), end)
+			So(err, ShouldBeNil)
+			So(labels, ShouldResemble, []string{"__name__", "host", "tenant_id"})
+			So(matches, ShouldResemble, []string{`{tenant_id="p1"}`})
+			So(start, ShouldEqual, "1599996400")
+		})
+
+		Convey("list metric names without range", func() {
+			names, err := cli.LabelValues(ctx, LabelMetricName, nil, time.Time{}, time.Time{})
+			So(err, ShouldBeNil)
+			So(reqPath, ShouldEqual, "/api/v1/label/__name__/values")
+			So(names, ShouldResemble, []string{"cpu_usage_active", "mem_used"})
+			So(start, ShouldEqual, "")
+		})
+
+		Convey("list series", func() {
+			series, err := cli.Series(ctx, []string{"cpu_usage_active"}, time.Time{}, end)
+			So(err, ShouldBeNil)
+			So(series, ShouldResemble, []map[string]string{{"__name__": "cpu_usage_active", "host": "h1"}})
+
+			_, err = cli.Series(ctx, nil, time.Time{}, end)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("report prometheus error", func() {
+			cli.dsInfo.Url = srv.URL + "/prefix"
+			_, err := cli.Labels(ctx, nil, time.Time{}, time.Time{})
+			So(err, ShouldNotBeNil)
+			So(reqPath, ShouldEqual, "/prefix/api/v1/labels")
+		})
+	})
+}
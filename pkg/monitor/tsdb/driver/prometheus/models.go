@@ -0,0 +1,60 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/monitor"
+)
+
+type Query struct {
+	Measurement  string
+	ResultFormat string
+	Tags         []api.MetricQueryTag
+	GroupBy      []string
+	Selects      []api.MetricQuerySelect
+	Alias        string
+	Interval     time.Duration
+}
+
+// Target is a single PromQL expression rendered from one select of the query,
+// its result becomes the Column of the merged time series.
+type Target struct {
+	Expr   string
+	Column string
+}
+
+type Response struct {
+	Status    string       `json:"status"`
+	Data      ResponseData `json:"data"`
+	ErrorType string       `json:"errorType,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Warnings  []string     `json:"warnings,omitempty"`
+}
+
+type ResponseData struct {
+	// ResultType is one of matrix, vector, scalar and string
+	ResultType string   `json:"resultType"`
+	Result     []Sample `json:"result"`
+}
+
+type Sample struct {
+	Metric map[string]string `json:"metric"`
+	// Value is set by instant query: [<unix_time>, "<value>"]
+	Value []interface{} `json:"value,omitempty"`
+	// Values is set by range query: [[<unix_time>, "<value>"], ...]
+	Values [][]interface{} `json:"values,omitempty"`
+}
@@ -0,0 +1,179 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moul/http2curl"
+	"golang.org/x/net/context/ctxhttp"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/monitor/tsdb"
+)
+
+const (
+	ErrPrometheusInvalidResponse = errors.Error("Prometheus invalid response")
+	ErrUnsupportedQueryPart      = errors.Error("Unsupported query part for prometheus")
+	ErrUnsupportedTagCondition   = errors.Error("Unsupported tag condition for prometheus")
+)
+
+const (
+	// ResultFormatInstant makes the query evaluated only at the end of time range
+	ResultFormatInstant = "instant"
+)
+
+func init() {
+	tsdb.RegisterTsdbQueryEndpoint(api.DataSourceTypePrometheus, NewPrometheusExecutor)
+}
+
+type PrometheusExecutor struct {
+	QueryParser    *PrometheusQueryParser
+	ResponseParser *ResponseParser
+}
+
+func NewPrometheusExecutor(datasource *tsdb.DataSource) (tsdb.TsdbQueryEndpoint, error) {
+	return &PrometheusExecutor{
+		QueryParser:    &PrometheusQueryParser{},
+		ResponseParser: &ResponseParser{},
+	}, nil
+}
+
+func (e *PrometheusExecutor) Query(ctx context.Context, dsInfo *tsdb.DataSource, tsdbQuery *tsdb.TsdbQuery) (*tsdb.Response, error) {
+	if len(tsdbQuery.Queries) == 0 {
+		return nil, errors.Error("query request contains no queries")
+	}
+
+	httpClient, err := dsInfo.GetHttpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &tsdb.Response{
+		Results: make(map[string]*tsdb.QueryResult),
+	}
+	for _, q := range tsdbQuery.Queries {
+		query, err := e.QueryParser.Parse(q, dsInfo)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse query %s", q.RefId)
+		}
+		targets, err := query.Build(tsdbQuery)
+		if err != nil {
+			return nil, errors.Wrapf(err, "build query %s", q.RefId)
+		}
+
+		responses := make([]*Response, len(targets))
+		exprs := make([]string, len(targets))
+		for i, target := range targets {
+			req, err := e.createRequest(dsInfo, query, target, tsdbQuery)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := e.doRequest(ctx, httpClient, req)
+			if err != nil {
+				return nil, errors.Wrapf(err, "query %q", target.Expr)
+			}
+			responses[i] = resp
+			exprs[i] = target.Expr
+		}
+
+		ret, err := e.ResponseParser.Parse(responses, targets, query)
+		if err != nil {
+			return nil, err
+		}
+		ret.RefId = q.RefId
+		ret.Meta = tsdb.QueryResultMeta{
+			RawQuery: strings.Join(exprs, ";"),
+		}
+		result.Results[q.RefId] = ret
+	}
+
+	return result, nil
+}
+
+func (e *PrometheusExecutor) createRequest(dsInfo *tsdb.DataSource, query *Query, target *Target, tsdbQuery *tsdb.TsdbQuery) (*http.Request, error) {
+	u, err := url.Parse(dsInfo.Url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse url %q", dsInfo.Url)
+	}
+
+	timeRange := tsdbQuery.TimeRange
+	bodyValues := url.Values{}
+	bodyValues.Set("query", target.Expr)
+	if query.ResultFormat == ResultFormatInstant {
+		u.Path = path.Join(u.Path, "api/v1/query")
+		bodyValues.Set("time", formatTimestamp(timeRange.MustGetTo()))
+	} else {
+		u.Path = path.Join(u.Path, "api/v1/query_range")
+		bodyValues.Set("start", formatTimestamp(timeRange.MustGetFrom()))
+		bodyValues.Set("end", formatTimestamp(timeRange.MustGetTo()))
+		bodyValues.Set("step", strconv.FormatFloat(query.Step(tsdbQuery).Seconds(), 'f', -1, 64))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), strings.NewReader(bodyValues.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "OneCloud Monitor")
+	req.Header.Set("Content-type", "application/x-www-form-urlencoded")
+	// logged before the credential is added
+	curlCmd, _ := http2curl.GetCurlCommand(req)
+	log.Debugf("Prometheus raw query: %q, curl: %s", target.Expr, curlCmd)
+
+	if dsInfo.User != "" {
+		req.SetBasicAuth(dsInfo.User, dsInfo.DecryptedPassword())
+	}
+	return req, nil
+}
+
+func (e *PrometheusExecutor) doRequest(ctx context.Context, httpClient *http.Client, req *http.Request) (*Response, error) {
+	resp, err := ctxhttp.Do(ctx, httpClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	response := new(Response)
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(response); err != nil {
+		if resp.StatusCode/100 != 2 {
+			return nil, errors.Wrapf(ErrPrometheusInvalidResponse, "status code: %v", resp.Status)
+		}
+		return nil, errors.Wrap(err, "decode response")
+	}
+	// prometheus reports bad query with 400 or 422 along with error message
+	if response.Status != "success" {
+		return nil, errors.Wrapf(ErrPrometheusInvalidResponse, "status code: %v, %s: %s", resp.Status, response.ErrorType, response.Error)
+	}
+	for _, w := range response.Warnings {
+		log.Warningf("Prometheus query %s warning: %s", req.URL.Path, w)
+	}
+	return response, nil
+}
+
+func formatTimestamp(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano()/int64(time.Millisecond))/1000, 'f', -1, 64)
+}
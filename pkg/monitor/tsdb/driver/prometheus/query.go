@@ -0,0 +1,306 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/monitor/tsdb"
+)
+
+var (
+	regexpOperatorPattern = regexp.MustCompile(`^\/.*\/$`)
+	invalidMetricChars    = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+	invalidLabelChars     = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+)
+
+var (
+	// overTimeFuncs maps influxdb style selectors to the range vector
+	// function applied to each series inside a step
+	overTimeFuncs = map[string]string{
+		"mean":   "avg_over_time",
+		"max":    "max_over_time",
+		"min":    "min_over_time",
+		"sum":    "sum_over_time",
+		"count":  "count_over_time",
+		"last":   "last_over_time",
+		"stddev": "stddev_over_time",
+	}
+
+	// transformFuncs maps influxdb transformations to range vector functions
+	transformFuncs = map[string]string{
+		"derivative":              "deriv",
+		"non_negative_derivative": "rate",
+		"difference":              "delta",
+		"non_negative_difference": "increase",
+	}
+
+	// aggregators are used to merge series of the same group, influxdb
+	// always aggregates all the matched series unless grouped by tags
+	aggregators = map[string]string{
+		"max":   "max",
+		"min":   "min",
+		"sum":   "sum",
+		"count": "sum",
+	}
+)
+
+// Step returns the resolution of range query, it is never smaller than
+// the interval of the query.
+func (query *Query) Step(queryCtx *tsdb.TsdbQuery) time.Duration {
+	calculator := tsdb.NewIntervalCalculator(&tsdb.IntervalOptions{})
+	interval := calculator.Calculate(queryCtx.TimeRange, query.Interval)
+	return interval.Value
+}
+
+// Build renders every select of the query to a PromQL expression.
+func (query *Query) Build(queryCtx *tsdb.TsdbQuery) ([]*Target, error) {
+	if len(query.Selects) == 0 {
+		return nil, errors.Error("query contains no select")
+	}
+	selector, err := renderLabelMatchers(query.Tags)
+	if err != nil {
+		return nil, err
+	}
+	step := query.Step(queryCtx)
+	targets := make([]*Target, 0, len(query.Selects))
+	for _, sel := range query.Selects {
+		target, err := query.renderSelect(sel, selector, step)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+func (query *Query) renderSelect(sel api.MetricQuerySelect, selector string, step time.Duration) (*Target, error) {
+	var (
+		field      string
+		column     string
+		aggregator string
+		transform  string
+		quantile   string
+		abs        bool
+		maths      []string
+	)
+	for _, part := range sel {
+		switch part.Type {
+		case "field":
+			if len(part.Params) == 0 {
+				return nil, errors.Wrap(ErrUnsupportedQueryPart, "field without name")
+			}
+			field = part.Params[0]
+			column = field
+		case "mean", "max", "min", "sum", "count", "last", "stddev":
+			aggregator = part.Type
+			column = part.Type
+		case "first":
+			// prometheus has no first_over_time, the oldest sample of the
+			// window could not be selected
+			return nil, errors.Wrapf(ErrUnsupportedQueryPart, "select %s", part.Type)
+		case "median":
+			aggregator = part.Type
+			column = part.Type
+			quantile = "0.5"
+		case "percentile":
+			if len(part.Params) == 0 {
+				return nil, errors.Wrap(ErrUnsupportedQueryPart, "percentile without param")
+			}
+			p, err := strconv.ParseFloat(part.Params[0], 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "parse percentile %q", part.Params[0])
+			}
+			aggregator = part.Type
+			column = part.Type
+			quantile = strconv.FormatFloat(p/100, 'f', -1, 64)
+		case "derivative", "non_negative_derivative", "difference", "non_negative_difference":
+			transform = part.Type
+			column = part.Type
+		case "abs":
+			abs = true
+		case "math":
+			if len(part.Params) > 0 {
+				maths = append(maths, part.Params[0])
+			}
+		case "alias":
+			if len(part.Params) > 0 {
+				column = part.Params[0]
+			}
+		default:
+			return nil, errors.Wrapf(ErrUnsupportedQueryPart, "select %s", part.Type)
+		}
+	}
+	if field == "" {
+		return nil, errors.Wrap(ErrUnsupportedQueryPart, "select without field")
+	}
+
+	window := formatDuration(step)
+	expr := MetricName(query.Measurement, field) + selector
+	switch {
+	case transform != "":
+		expr = fmt.Sprintf("%s(%s[%s])", transformFuncs[transform], expr, window)
+	case quantile != "":
+		expr = fmt.Sprintf("quantile_over_time(%s, %s[%s])", quantile, expr, window)
+	case aggregator != "":
+		expr = fmt.Sprintf("%s(%s[%s])", overTimeFuncs[aggregator], expr, window)
+	}
+	if aggregator != "" || transform != "" {
+		agg, ok := aggregators[aggregator]
+		if !ok {
+			agg = "avg"
+		}
+		expr = fmt.Sprintf("%s%s(%s)", agg, query.renderGroupBy(), expr)
+	}
+	if abs {
+		expr = fmt.Sprintf("abs(%s)", expr)
+	}
+	for _, m := range maths {
+		m = strings.Replace(m, "$__interval_ms", strconv.FormatInt(int64(step/time.Millisecond), 10), -1)
+		expr = fmt.Sprintf("(%s) %s", expr, strings.TrimSpace(m))
+	}
+	return &Target{
+		Expr:   expr,
+		Column: column,
+	}, nil
+}
+
+func (query *Query) renderGroupBy() string {
+	if len(query.GroupBy) == 0 {
+		return ""
+	}
+	keys := make([]string, len(query.GroupBy))
+	for i, key := range query.GroupBy {
+		keys[i] = labelName(key)
+	}
+	return fmt.Sprintf(" by (%s) ", strings.Join(keys, ", "))
+}
+
+type labelMatcher struct {
+	key      string
+	operator string
+	value    string
+}
+
+func (m labelMatcher) String() string {
+	return fmt.Sprintf("%s%s%s", labelName(m.key), m.operator, strconv.Quote(m.value))
+}
+
+func renderLabelMatchers(tags []api.MetricQueryTag) (string, error) {
+	matchers := make([]*labelMatcher, 0)
+	for i, tag := range tags {
+		operator, value, err := parseTagOperator(tag)
+		if err != nil {
+			return "", err
+		}
+		if i > 0 && strings.EqualFold(tag.Condition, "or") {
+			// only alternatives of the same label could be expressed as
+			// a regex matcher, label matchers are always conjunctive
+			prev := matchers[len(matchers)-1]
+			if prev.key != tag.Key || !isPositiveOperator(prev.operator) || !isPositiveOperator(operator) {
+				return "", errors.Wrapf(ErrUnsupportedTagCondition, "%s %s %s", tag.Condition, tag.Key, tag.Value)
+			}
+			prev.value = toRegexValue(prev.operator, prev.value) + "|" + toRegexValue(operator, value)
+			prev.operator = "=~"
+			continue
+		}
+		matchers = append(matchers, &labelMatcher{
+			key:      tag.Key,
+			operator: operator,
+			value:    value,
+		})
+	}
+	if len(matchers) == 0 {
+		return "", nil
+	}
+	strs := make([]string, len(matchers))
+	for i, m := range matchers {
+		strs[i] = m.String()
+	}
+	return "{" + strings.Join(strs, ", ") + "}", nil
+}
+
+func parseTagOperator(tag api.MetricQueryTag) (string, string, error) {
+	operator := tag.Operator
+	value := tag.Value
+	// If the operator is missing we fall back to sensible defaults
+	if operator == "" {
+		if regexpOperatorPattern.MatchString(value) {
+			operator = "=~"
+		} else {
+			operator = "="
+		}
+	}
+	switch operator {
+	case "=", "!=":
+	case "<>":
+		operator = "!="
+	case "=~", "!~":
+		if regexpOperatorPattern.MatchString(value) {
+			value = value[1 : len(value)-1]
+		}
+	default:
+		return "", "", errors.Wrapf(ErrUnsupportedTagCondition, "operator %s of tag %s", operator, tag.Key)
+	}
+	return operator, value, nil
+}
+
+func isPositiveOperator(op string) bool {
+	return op == "=" || op == "=~"
+}
+
+func toRegexValue(op string, value string) string {
+	if op == "=" {
+		return regexp.QuoteMeta(value)
+	}
+	return value
+}
+
+// RenderSelector renders the series selector of field of measurement
+// filtered by tags, the metric name is omitted if field is empty
+func RenderSelector(measurement, field string, tags []api.MetricQueryTag) (string, error) {
+	matchers, err := renderLabelMatchers(tags)
+	if err != nil {
+		return "", err
+	}
+	if field == "" {
+		return matchers, nil
+	}
+	return MetricName(measurement, field) + matchers, nil
+}
+
+// MetricName follows the naming of telegraf prometheus output,
+// e.g. field usage_active of measurement cpu becomes cpu_usage_active
+func MetricName(measurement, field string) string {
+	return invalidMetricChars.ReplaceAllString(measurement+"_"+field, "_")
+}
+
+func labelName(key string) string {
+	return invalidLabelChars.ReplaceAllString(key, "_")
+}
+
+func formatDuration(d time.Duration) string {
+	if d%time.Second == 0 {
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+	return fmt.Sprintf("%dms", d/time.Millisecond)
+}
@@ -0,0 +1,81 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/monitor/tsdb"
+)
+
+const (
+	// DefaultInterval is the fallback step when neither the query nor the
+	// data source specify one, it matches the usual scrape interval.
+	DefaultInterval = time.Minute
+)
+
+type PrometheusQueryParser struct{}
+
+func (qp *PrometheusQueryParser) Parse(model *tsdb.Query, dsInfo *tsdb.DataSource) (*Query, error) {
+	interval, err := tsdb.GetIntervalFrom(dsInfo, model, DefaultInterval)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse interval %q", model.Interval)
+	}
+
+	groupBy := make([]string, 0)
+	for _, gb := range model.GroupBy {
+		switch gb.Type {
+		case "tag":
+			if len(gb.Params) == 0 {
+				return nil, errors.Wrap(ErrUnsupportedQueryPart, "group by tag without key")
+			}
+			groupBy = append(groupBy, gb.Params[0])
+		case "time":
+			// $__interval and friends are resolved to the query step
+			if len(gb.Params) == 0 || isIntervalVariable(gb.Params[0]) {
+				continue
+			}
+			d, err := time.ParseDuration(gb.Params[0])
+			if err != nil {
+				return nil, errors.Wrapf(err, "parse group by time %q", gb.Params[0])
+			}
+			interval = d
+		case "fill":
+			// prometheus never fills the gaps of range query
+		default:
+			return nil, errors.Wrapf(ErrUnsupportedQueryPart, "group by %s", gb.Type)
+		}
+	}
+
+	return &Query{
+		Measurement:  model.Measurement,
+		ResultFormat: model.ResultFormat,
+		Tags:         model.Tags,
+		GroupBy:      groupBy,
+		Selects:      model.Selects,
+		Alias:        model.Alias,
+		Interval:     interval,
+	}, nil
+}
+
+func isIntervalVariable(val string) bool {
+	switch val {
+	case "$__interval", "$interval", "auto":
+		return true
+	}
+	return false
+}
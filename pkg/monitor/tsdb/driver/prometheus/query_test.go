@@ -0,0 +1,165 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/monitor/tsdb"
+)
+
+func TestPrometheusQueryBuilder(t *testing.T) {
+	Convey("Prometheus query builder", t, func() {
+		field := api.MetricQueryPart{Type: "field", Params: []string{"usage_active"}}
+		mean := api.MetricQueryPart{Type: "mean"}
+
+		queryContext := &tsdb.TsdbQuery{
+			TimeRange: tsdb.NewTimeRange("5m", "now"),
+		}
+
+		Convey("can build simple query", func() {
+			query := &Query{
+				Measurement: "cpu",
+				Selects:     []api.MetricQuerySelect{{field, mean}},
+				Interval:    time.Minute,
+			}
+			targets, err := query.Build(queryContext)
+			So(err, ShouldBeNil)
+			So(len(targets), ShouldEqual, 1)
+			So(targets[0].Expr, ShouldEqual, `avg(avg_over_time(cpu_usage_active[60s]))`)
+			So(targets[0].Column, ShouldEqual, "mean")
+		})
+
+		Convey("can build query with tags, group by and math", func() {
+			query := &Query{
+				Measurement: "cpu",
+				Selects: []api.MetricQuerySelect{{
+					field,
+					{Type: "max"},
+					{Type: "math", Params: []string{"/ 100"}},
+					{Type: "alias", Params: []string{"usage"}},
+				}},
+				Tags: []api.MetricQueryTag{
+					{Key: "host", Operator: "=", Value: "server1"},
+					{Key: "host", Operator: "=", Value: "server.2", Condition: "OR"},
+					{Key: "res_type", Value: "/^guest$/", Condition: "AND"},
+				},
+				GroupBy:  []string{"host"},
+				Interval: time.Minute,
+			}
+			targets, err := query.Build(queryContext)
+			So(err, ShouldBeNil)
+			So(targets[0].Expr, ShouldEqual, `(max by (host) (max_over_time(cpu_usage_active{host=~"server1|server\\.2", res_type=~"^guest$"}[60s]))) / 100`)
+			So(targets[0].Column, ShouldEqual, "usage")
+		})
+
+		Convey("can build counter rate and percentile query", func() {
+			query := &Query{
+				Measurement: "net",
+				Selects: []api.MetricQuerySelect{
+					{{Type: "field", Params: []string{"bytes_recv"}}, mean, {Type: "non_negative_derivative"}},
+					{{Type: "field", Params: []string{"bytes_recv"}}, {Type: "percentile", Params: []string{"95"}}},
+				},
+				Interval: 30 * time.Second,
+			}
+			targets, err := query.Build(queryContext)
+			So(err, ShouldBeNil)
+			So(targets[0].Expr, ShouldEqual, `avg(rate(net_bytes_recv[30s]))`)
+			So(targets[1].Expr, ShouldEqual, `avg(quantile_over_time(0.95, net_bytes_recv[30s]))`)
+		})
+
+		Convey("raw field is not aggregated", func() {
+			query := &Query{
+				Measurement: "disk",
+				Selects:     []api.MetricQuerySelect{{{Type: "field", Params: []string{"used_percent"}}}},
+				Tags:        []api.MetricQueryTag{{Key: "path", Operator: "<>", Value: "/"}},
+				Interval:    time.Minute,
+			}
+			targets, err := query.Build(queryContext)
+			So(err, ShouldBeNil)
+			So(targets[0].Expr, ShouldEqual, `disk_used_percent{path!="/"}`)
+		})
+
+		Convey("reject conditions could not be expressed", func() {
+			query := &Query{
+				Measurement: "cpu",
+				Selects:     []api.MetricQuerySelect{{field, mean}},
+				Tags: []api.MetricQueryTag{
+					{Key: "host", Operator: "=", Value: "server1"},
+					{Key: "zone", Operator: "=", Value: "zone1", Condition: "OR"},
+				},
+			}
+			_, err := query.Build(queryContext)
+			So(err, ShouldNotBeNil)
+
+			query.Tags = []api.MetricQueryTag{{Key: "cpu_count", Operator: ">", Value: "2"}}
+			_, err = query.Build(queryContext)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("reject selector without prometheus equivalent", func() {
+			query := &Query{
+				Measurement: "cpu",
+				Selects:     []api.MetricQuerySelect{{field, {Type: "first"}}},
+			}
+			_, err := query.Build(queryContext)
+			So(errors.Cause(err), ShouldEqual, ErrUnsupportedQueryPart)
+		})
+
+		Convey("render series selector", func() {
+			tags := []api.MetricQueryTag{{Key: "tenant_id", Operator: "=~", Value: "/p1/"}}
+			selector, err := RenderSelector("cpu", "usage_active", tags)
+			So(err, ShouldBeNil)
+			So(selector, ShouldEqual, `cpu_usage_active{tenant_id=~"p1"}`)
+			selector, err = RenderSelector("cpu", "", tags)
+			So(err, ShouldBeNil)
+			So(selector, ShouldEqual, `{tenant_id=~"p1"}`)
+		})
+	})
+
+	Convey("Prometheus query parser", t, func() {
+		parser := &PrometheusQueryParser{}
+		model := &tsdb.Query{
+			MetricQuery: api.MetricQuery{
+				Measurement: "cpu",
+				GroupBy: []api.MetricQueryPart{
+					{Type: "time", Params: []string{"$__interval"}},
+					{Type: "tag", Params: []string{"host_id"}},
+					{Type: "fill", Params: []string{"none"}},
+				},
+			},
+		}
+
+		Convey("use default interval", func() {
+			query, err := parser.Parse(model, &tsdb.DataSource{})
+			So(err, ShouldBeNil)
+			So(query.Interval, ShouldEqual, DefaultInterval)
+			So(query.GroupBy, ShouldResemble, []string{"host_id"})
+		})
+
+		Convey("group by time overrides interval", func() {
+			model.GroupBy[0].Params = []string{"5m"}
+			query, err := parser.Parse(model, &tsdb.DataSource{})
+			So(err, ShouldBeNil)
+			So(query.Interval, ShouldEqual, 5*time.Minute)
+		})
+	})
+}
@@ -0,0 +1,209 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/monitor/tsdb"
+)
+
+type ResponseParser struct{}
+
+var (
+	legendFormat *regexp.Regexp
+)
+
+func init() {
+	legendFormat = regexp.MustCompile(`\[\[(\w+)(\.\w+)*\]\]*|\$\s*(\w+?)*`)
+}
+
+type mergedSerie struct {
+	tags       map[string]string
+	timestamps []float64
+	points     map[float64][]*float64
+}
+
+// Parse merges the responses of all targets into time series, samples with
+// the same labels share one serie and every target becomes a column of it.
+func (rp *ResponseParser) Parse(responses []*Response, targets []*Target, query *Query) (*tsdb.QueryResult, error) {
+	queryRes := tsdb.NewQueryResult()
+
+	keys := make([]string, 0)
+	series := make(map[string]*mergedSerie)
+	for idx, resp := range responses {
+		switch resp.Data.ResultType {
+		case "matrix", "vector":
+		default:
+			return nil, errors.Wrapf(ErrPrometheusInvalidResponse, "unsupported result type %q", resp.Data.ResultType)
+		}
+		for _, sample := range resp.Data.Result {
+			tags := make(map[string]string)
+			for k, v := range sample.Metric {
+				if k == "__name__" {
+					continue
+				}
+				tags[k] = v
+			}
+			key := seriesKey(tags)
+			serie, ok := series[key]
+			if !ok {
+				serie = &mergedSerie{
+					tags:   tags,
+					points: make(map[float64][]*float64),
+				}
+				series[key] = serie
+				keys = append(keys, key)
+			}
+			values := sample.Values
+			if len(sample.Value) > 0 {
+				values = [][]interface{}{sample.Value}
+			}
+			for _, pair := range values {
+				ts, val, err := rp.parseValuePair(pair)
+				if err != nil {
+					return nil, err
+				}
+				point, ok := serie.points[ts]
+				if !ok {
+					point = make([]*float64, len(targets))
+					serie.points[ts] = point
+					serie.timestamps = append(serie.timestamps, ts)
+				}
+				point[idx] = val
+			}
+		}
+	}
+
+	columns := make([]string, 0, len(targets)+1)
+	for _, target := range targets {
+		columns = append(columns, target.Column)
+	}
+	col := strings.Join(columns, "-")
+	columns = append(columns, "time")
+
+	for _, key := range keys {
+		serie := series[key]
+		sort.Float64s(serie.timestamps)
+		points := make(tsdb.TimeSeriesPoints, 0, len(serie.timestamps))
+		for _, ts := range serie.timestamps {
+			point := make(tsdb.TimePoint, 0, len(targets)+1)
+			for _, val := range serie.points[ts] {
+				// keep null value untyped as the influxdb driver does
+				if val == nil {
+					point = append(point, nil)
+				} else {
+					point = append(point, val)
+				}
+			}
+			point = append(point, ts)
+			points = append(points, point)
+		}
+		queryRes.Series = append(queryRes.Series, &tsdb.TimeSeries{
+			Name:    rp.formatSerieName(serie.tags, col, query),
+			Columns: columns,
+			Points:  points,
+			Tags:    serie.tags,
+		})
+	}
+	return queryRes, nil
+}
+
+// parseValuePair parses [<unix_time>, "<value>"] to millisecond timestamp
+// and value, NaN and Inf are treated as null.
+func (rp *ResponseParser) parseValuePair(pair []interface{}) (float64, *float64, error) {
+	if len(pair) != 2 {
+		return 0, nil, errors.Wrapf(ErrPrometheusInvalidResponse, "invalid sample %v", pair)
+	}
+	var ts float64
+	switch v := pair[0].(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return 0, nil, errors.Wrapf(err, "parse timestamp %s", v)
+		}
+		ts = f
+	case float64:
+		ts = v
+	default:
+		return 0, nil, errors.Wrapf(ErrPrometheusInvalidResponse, "invalid timestamp %v", pair[0])
+	}
+	ts = math.Round(ts * 1000)
+
+	str, ok := pair[1].(string)
+	if !ok {
+		return ts, nil, nil
+	}
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return ts, nil, nil
+	}
+	return ts, &f, nil
+}
+
+func (rp *ResponseParser) formatSerieName(tags map[string]string, column string, query *Query) string {
+	if query.Alias == "" {
+		return fmt.Sprintf("%s.%s", query.Measurement, column)
+	}
+
+	result := legendFormat.ReplaceAllFunc([]byte(query.Alias), func(in []byte) []byte {
+		aliasFormat := string(in)
+		aliasFormat = strings.Replace(aliasFormat, "[[", "", 1)
+		aliasFormat = strings.Replace(aliasFormat, "]]", "", 1)
+		aliasFormat = strings.Replace(aliasFormat, "$", "", 1)
+
+		if aliasFormat == "m" || aliasFormat == "measurement" {
+			return []byte(query.Measurement)
+		}
+		if aliasFormat == "col" {
+			return []byte(column)
+		}
+
+		if !strings.HasPrefix(aliasFormat, "tag_") {
+			return in
+		}
+
+		tagKey := strings.Replace(aliasFormat, "tag_", "", 1)
+		tagValue, exist := tags[tagKey]
+		if exist {
+			return []byte(tagValue)
+		}
+
+		return in
+	})
+
+	return string(result)
+}
+
+func seriesKey(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%q", k, tags[k])
+	}
+	return strings.Join(parts, ",")
+}
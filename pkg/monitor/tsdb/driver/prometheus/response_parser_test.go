@@ -0,0 +1,83 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func decodeResponse(body string) *Response {
+	resp := new(Response)
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(resp); err != nil {
+		panic(err)
+	}
+	return resp
+}
+
+func TestPrometheusResponseParser(t *testing.T) {
+	Convey("Prometheus response parser", t, func() {
+		parser := &ResponseParser{}
+
+		Convey("merge range responses by labels", func() {
+			mean := decodeResponse(`{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{"host":"server1"},"values":[[1600000000,"1.5"],[1600000060,"NaN"]]},
+				{"metric":{"host":"server2"},"values":[[1600000000,"3"]]}]}}`)
+			max := decodeResponse(`{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{"host":"server1"},"values":[[1600000000,"2"],[1600000060,"4"]]}]}}`)
+			targets := []*Target{{Column: "mean"}, {Column: "max"}}
+
+			result, err := parser.Parse([]*Response{mean, max}, targets, &Query{Measurement: "cpu"})
+			So(err, ShouldBeNil)
+			So(len(result.Series), ShouldEqual, 2)
+
+			serie := result.Series[0]
+			So(serie.Name, ShouldEqual, "cpu.mean-max")
+			So(serie.Columns, ShouldResemble, []string{"mean", "max", "time"})
+			So(serie.Tags, ShouldResemble, map[string]string{"host": "server1"})
+			So(len(serie.Points), ShouldEqual, 2)
+			So(serie.Points[0].Value(), ShouldEqual, 1.5)
+			So(serie.Points[0].Values(), ShouldResemble, []float64{1.5, 2})
+			So(serie.Points[0].Timestamp(), ShouldEqual, float64(1600000000000))
+			So(serie.Points[1].IsValid(), ShouldBeFalse)
+
+			So(result.Series[1].Points[0].IsValids(), ShouldBeFalse)
+		})
+
+		Convey("parse instant vector with alias", func() {
+			resp := decodeResponse(`{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"__name__":"cpu_usage_active","host":"server1"},"value":[1600000000.5,"10"]}]}}`)
+			targets := []*Target{{Column: "usage_active"}}
+
+			result, err := parser.Parse([]*Response{resp}, targets, &Query{Measurement: "cpu", Alias: "$m $col [[tag_host]]"})
+			So(err, ShouldBeNil)
+			So(len(result.Series), ShouldEqual, 1)
+			So(result.Series[0].Name, ShouldEqual, "cpu usage_active server1")
+			So(result.Series[0].Tags, ShouldResemble, map[string]string{"host": "server1"})
+			So(result.Series[0].Points[0].Timestamp(), ShouldEqual, float64(1600000000500))
+		})
+
+		Convey("reject scalar result", func() {
+			resp := decodeResponse(`{"status":"success","data":{"resultType":"scalar","result":[]}}`)
+			_, err := parser.Parse([]*Response{resp}, []*Target{{Column: "value"}}, &Query{})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
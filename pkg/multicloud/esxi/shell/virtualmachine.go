@@ -213,15 +213,16 @@ func init() {
 
 	type VirtualMachineSnapshotCreateOptions struct {
 		VirtualMachineShowOptions
-		NAME string `help:"Name of snapshot"`
-		Desc string `help:"Description of snapshot"`
+		NAME    string `help:"Name of snapshot"`
+		Desc    string `help:"Description of snapshot"`
+		Quiesce bool   `help:"Quiesce guest filesystems by vmware tools"`
 	}
 	shellutils.R(&VirtualMachineSnapshotCreateOptions{}, "vm-snapshot-create", "Create vm snapshot", func(cli *esxi.SESXiClient, args *VirtualMachineSnapshotCreateOptions) error {
 		vm, err := getVM(cli, &args.VirtualMachineShowOptions)
 		if err != nil {
 			return err
 		}
-		sp, err := vm.CreateInstanceSnapshot(context.Background(), args.NAME, args.Desc)
+		sp, err := vm.CreateInstanceSnapshot(context.Background(), args.NAME, args.Desc, args.Quiesce)
 		if err != nil {
 			return err
 		}
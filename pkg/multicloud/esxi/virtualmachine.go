@@ -1425,9 +1425,10 @@ func (self *SVirtualMachine) GetInstanceSnapshot(idStr string) (cloudprovider.IC
 	return nil, errors.ErrNotFound
 }
 
-func (self *SVirtualMachine) CreateInstanceSnapshot(ctx context.Context, name string, desc string) (cloudprovider.ICloudInstanceSnapshot, error) {
+func (self *SVirtualMachine) CreateInstanceSnapshot(ctx context.Context, name string, desc string, quiesce bool) (cloudprovider.ICloudInstanceSnapshot, error) {
 	ovm := self.getVmObj()
-	task, err := ovm.CreateSnapshot(ctx, name, desc, false, false)
+	// quiescing requires vmware tools running inside guest
+	task, err := ovm.CreateSnapshot(ctx, name, desc, false, quiesce)
 	if err != nil {
 		return nil, errors.Wrap(err, "CreateSnapshot")
 	}
@@ -55,7 +55,7 @@ func (instance *SInstanceBase) GetInstanceSnapshots() ([]cloudprovider.ICloudIns
 	return nil, cloudprovider.ErrNotImplemented
 }
 
-func (instance *SInstanceBase) CreateInstanceSnapshot(ctx context.Context, name string, desc string) (cloudprovider.ICloudInstanceSnapshot, error) {
+func (instance *SInstanceBase) CreateInstanceSnapshot(ctx context.Context, name string, desc string, quiesce bool) (cloudprovider.ICloudInstanceSnapshot, error) {
 	return nil, cloudprovider.ErrNotImplemented
 }
 
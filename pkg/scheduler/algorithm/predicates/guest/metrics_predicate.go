@@ -0,0 +1,80 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"fmt"
+
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/scheduler/algorithm/predicates"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+	o "yunion.io/x/onecloud/pkg/scheduler/options"
+)
+
+// MetricsPredicate filters out hosts whose real utilization reported by
+// telegraf exceeds the configured thresholds, hosts without fresh
+// metrics always pass
+type MetricsPredicate struct {
+	predicates.BasePredicate
+}
+
+func (p *MetricsPredicate) Name() string {
+	return "host_metrics"
+}
+
+func (p *MetricsPredicate) Clone() core.FitPredicate {
+	return &MetricsPredicate{}
+}
+
+func (p *MetricsPredicate) PreExecute(u *core.Unit, cs []core.Candidater) (bool, error) {
+	opts := o.GetOptions()
+	if !opts.HostMetricsEnabled {
+		return false, nil
+	}
+	if opts.HostMetricsMaxCPUPercent <= 0 && opts.HostMetricsMaxMemPercent <= 0 &&
+		opts.HostMetricsMaxDiskIOPercent <= 0 && opts.HostMetricsMaxNetPercent <= 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (p *MetricsPredicate) Execute(u *core.Unit, c core.Candidater) (bool, []core.PredicateFailureReason, error) {
+	h := predicates.NewPredicateHelper(p, u, c)
+
+	opts := o.GetOptions()
+	m := c.Getter().HostMetrics()
+	if m.IsEmpty() || m.IsStale(utils.ToDuration(opts.HostMetricsStaleness)) {
+		return h.GetResult()
+	}
+	for _, item := range []struct {
+		name  string
+		usage *float64
+		max   int
+	}{
+		{"cpu usage", m.CPUUsage, opts.HostMetricsMaxCPUPercent},
+		{"memory usage", m.MemUsage, opts.HostMetricsMaxMemPercent},
+		{"disk io utilization", m.DiskIOUtil, opts.HostMetricsMaxDiskIOPercent},
+		{"network usage", m.NetUsage, opts.HostMetricsMaxNetPercent},
+	} {
+		if item.usage == nil || item.max <= 0 {
+			continue
+		}
+		if *item.usage*100 > float64(item.max) {
+			h.Exclude(fmt.Sprintf("%s %.1f%% exceeds %d%%", item.name, *item.usage*100, item.max))
+		}
+	}
+	return h.GetResult()
+}
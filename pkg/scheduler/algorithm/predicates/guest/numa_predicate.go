@@ -0,0 +1,111 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"fmt"
+	"sort"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/scheduler/algorithm/predicates"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+)
+
+// NumaPredicate filters out hosts backing guest memory with hugepages
+// which do not have enough free hugepages left, the memory commit rate
+// is meaningless for hugepages since they can't be overcommitted
+type NumaPredicate struct {
+	predicates.BasePredicate
+}
+
+func (p *NumaPredicate) Name() string {
+	return "host_numa"
+}
+
+func (p *NumaPredicate) Clone() core.FitPredicate {
+	return &NumaPredicate{}
+}
+
+func (p *NumaPredicate) PreExecute(u *core.Unit, cs []core.Candidater) (bool, error) {
+	if u.SchedData().Memory <= 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (p *NumaPredicate) Execute(u *core.Unit, c core.Candidater) (bool, []core.PredicateFailureReason, error) {
+	h := predicates.NewPredicateHelper(p, u, c)
+
+	topo := c.Getter().NumaTopology()
+	if topo == nil || topo.HugepageSizeKb <= 0 {
+		return h.GetResult()
+	}
+	reqMem := u.SchedData().Memory
+	pendingMem := 0
+	if pending := c.Getter().GetPendingUsage(); pending != nil {
+		pendingMem = pending.Memory
+	}
+	capacity := hugepageCapacity(topo, reqMem, pendingMem)
+	if capacity <= 0 {
+		h.Exclude(fmt.Sprintf("free hugepages %dM of numa nodes not enough, request %dM", topo.FreeHugepagesMb(topo.HugepageSizeKb)-pendingMem, reqMem))
+		return h.GetResult()
+	}
+	h.SetCapacity(int64(capacity))
+	return h.GetResult()
+}
+
+// hugepageCapacity returns how many guests of reqMem can be backed by free
+// hugepages of topo.  A guest is bound to a single numa node if it fits
+// into one, so the capacity of nodes are counted separately.  pendingMem
+// of guests scheduled but not started yet is taken from the nodes with the
+// most free hugepages first
+func hugepageCapacity(topo *api.HostNumaTopology, reqMem int, pendingMem int) int {
+	if reqMem <= 0 {
+		return 0
+	}
+	frees := make([]int, len(topo.Nodes))
+	maxNodeMem := 0
+	for i := range topo.Nodes {
+		frees[i] = topo.Nodes[i].FreeHugepagesMb(topo.HugepageSizeKb)
+		if total := topo.Nodes[i].TotalHugepagesMb(topo.HugepageSizeKb); total > maxNodeMem {
+			maxNodeMem = total
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(frees)))
+	for i := range frees {
+		if pendingMem <= 0 {
+			break
+		}
+		used := frees[i]
+		if used > pendingMem {
+			used = pendingMem
+		}
+		frees[i] -= used
+		pendingMem -= used
+	}
+	capacity := 0
+	if reqMem > maxNodeMem {
+		// guest never fits into a single node, its memory spans all nodes
+		free := 0
+		for i := range frees {
+			free += frees[i]
+		}
+		return free / reqMem
+	}
+	for i := range frees {
+		capacity += frees[i] / reqMem
+	}
+	return capacity
+}
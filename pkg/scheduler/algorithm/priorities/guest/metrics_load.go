@@ -0,0 +1,86 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/scheduler/algorithm/priorities"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+	"yunion.io/x/onecloud/pkg/scheduler/core/score"
+	o "yunion.io/x/onecloud/pkg/scheduler/options"
+)
+
+// MetricsLoadPriority prefers hosts with low real utilization reported by
+// telegraf, hosts without fresh metrics are left unscored
+type MetricsLoadPriority struct {
+	priorities.BasePriority
+}
+
+func (p *MetricsLoadPriority) Name() string {
+	return "host_metrics_load"
+}
+
+func (p *MetricsLoadPriority) Clone() core.Priority {
+	return &MetricsLoadPriority{}
+}
+
+func (p *MetricsLoadPriority) PreExecute(u *core.Unit, cs []core.Candidater) (bool, []core.PredicateFailureReason, error) {
+	return o.GetOptions().HostMetricsEnabled, nil, nil
+}
+
+// GetMetricsLoad returns weighted average of known utilizations of host
+func GetMetricsLoad(m *core.HostMetrics) (float64, bool) {
+	opts := o.GetOptions()
+	var total, weights float64
+	for _, item := range []struct {
+		usage  *float64
+		weight int
+	}{
+		{m.CPUUsage, opts.HostMetricsCPUWeight},
+		{m.MemUsage, opts.HostMetricsMemWeight},
+		{m.DiskIOUtil, opts.HostMetricsDiskIOWeight},
+		{m.NetUsage, opts.HostMetricsNetWeight},
+	} {
+		if item.usage == nil || item.weight <= 0 {
+			continue
+		}
+		total += *item.usage * float64(item.weight)
+		weights += float64(item.weight)
+	}
+	if weights == 0 {
+		return 0, false
+	}
+	return total / weights, true
+}
+
+func (p *MetricsLoadPriority) Map(u *core.Unit, c core.Candidater) (core.HostPriority, error) {
+	h := priorities.NewPriorityHelper(p, u, c)
+
+	m := c.Getter().HostMetrics()
+	if m.IsEmpty() || m.IsStale(utils.ToDuration(o.GetOptions().HostMetricsStaleness)) {
+		return h.GetResult()
+	}
+	load, ok := GetMetricsLoad(m)
+	if ok {
+		h.SetScore(int(100 * (1 - load)))
+	}
+	return h.GetResult()
+}
+
+// ScoreIntervals takes load above 90% as min score and below 30% as max
+func (p *MetricsLoadPriority) ScoreIntervals() score.Intervals {
+	return score.NewIntervals(10, 40, 70)
+}
@@ -48,6 +48,8 @@ func defaultPredicates() sets.String {
 		factory.RegisterFitPredicate("p-CloudproviderschedtagFilter", predicates.NewCloudproviderSchedtagPredicate()),
 		factory.RegisterFitPredicate("q-CloudregionschedtagFilter", predicates.NewCloudregionSchedtagPredicate()),
 		factory.RegisterFitPredicate("r-ZoneschedtagFilter", predicates.NewZoneSchedtagPredicate()),
+		factory.RegisterFitPredicate("s-GuestHostMetricsFilter", &predicateguest.MetricsPredicate{}),
+		factory.RegisterFitPredicate("t-GuestNumaFilter", &predicateguest.NumaPredicate{}),
 		factory.RegisterFitPredicate("z-QuotaFilter", &predicates.SQuotaPredicate{}),
 	)
 }
@@ -58,5 +60,7 @@ func defaultPriorities() sets.String {
 		factory.RegisterPriority("guest-lowload", &priorityguest.LowLoadPriority{}, 1),
 		factory.RegisterPriority("guest-creating", &priorityguest.CreatingPriority{}, 1),
 		factory.RegisterPriority("guest-capacity", &priorityguest.CapacityPriority{}, 1),
+		factory.RegisterPriority("guest-metrics-load", &priorityguest.MetricsLoadPriority{}, 1),
+		factory.RegisterPriority("guest-numa", &priorityguest.NumaPriority{}, 1),
 	)
 }
@@ -163,6 +163,14 @@ func (b baseHostGetter) OvnCapable() bool {
 	return false
 }
 
+func (b baseHostGetter) HostMetrics() *core.HostMetrics {
+	return nil
+}
+
+func (b baseHostGetter) NumaTopology() *computeapi.HostNumaTopology {
+	return nil
+}
+
 func (b baseHostGetter) ResourceType() string {
 	return reviseResourceType(b.h.ResourceType)
 }
@@ -0,0 +1,181 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package candidate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	computemodels "yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+	o "yunion.io/x/onecloud/pkg/scheduler/options"
+	"yunion.io/x/onecloud/pkg/util/influxdb"
+)
+
+// Queries of host utilization, telegraf on every host tags its metrics
+// with host_id. io_time and bytes counters are turned into rates per
+// second and the busiest disk or nic decides the utilization.
+const (
+	hostCPUUsageSQL   = `SELECT mean("usage_active") FROM "%s".."cpu" WHERE time > now() - %ds AND "cpu" = 'cpu-total' GROUP BY "host_id"`
+	hostMemUsageSQL   = `SELECT mean("used_percent") FROM "%s".."mem" WHERE time > now() - %ds GROUP BY "host_id"`
+	hostDiskIOUtilSQL = `SELECT max("util") FROM (SELECT non_negative_derivative(max("io_time"), 1s) AS "util" FROM "%s".."diskio" WHERE time > now() - %ds GROUP BY time(1m), "host_id", "name") GROUP BY "host_id"`
+	hostNetBpsSQL     = `SELECT max("recv"), max("sent") FROM (SELECT non_negative_derivative(max("bytes_recv"), 1s) AS "recv", non_negative_derivative(max("bytes_sent"), 1s) AS "sent" FROM "%s".."net" WHERE time > now() - %ds GROUP BY time(1m), "host_id", "interface") GROUP BY "host_id"`
+)
+
+func toUsage(val float64) *float64 {
+	if val < 0 {
+		val = 0
+	} else if val > 1 {
+		val = 1
+	}
+	return &val
+}
+
+// parseHostValues returns host_id tag and the last row of values of a
+// series, the first column is time and skipped
+func parseHostValues(tags *jsonutils.JSONDict, values [][]jsonutils.JSONObject) (string, []float64, bool) {
+	if tags == nil || len(values) == 0 {
+		return "", nil, false
+	}
+	hostId, _ := tags.GetString("host_id")
+	if len(hostId) == 0 {
+		return "", nil, false
+	}
+	row := values[len(values)-1]
+	if len(row) < 2 {
+		return "", nil, false
+	}
+	vals := make([]float64, 0, len(row)-1)
+	for i := 1; i < len(row); i++ {
+		val, err := row[i].Float()
+		if err != nil {
+			return "", nil, false
+		}
+		vals = append(vals, val)
+	}
+	return hostId, vals, true
+}
+
+// FetchHostMetrics fetches recent utilization of all hosts from tsdb,
+// hosts reporting nothing within the window are absent from the result
+func FetchHostMetrics() (map[string]*core.HostMetrics, error) {
+	urls, err := auth.GetServiceURLs(apis.SERVICE_TYPE_INFLUXDB, o.GetOptions().Region, "", "")
+	if err != nil {
+		return nil, errors.Wrap(err, "get influxdb endpoints")
+	}
+	if len(urls) == 0 {
+		return nil, errors.Wrap(errors.ErrNotFound, "no influxdb endpoint")
+	}
+	dbName := o.GetOptions().HostMetricsDatabase
+	window := int(utils.ToDuration(o.GetOptions().HostMetricsWindow).Seconds())
+	if window <= 0 {
+		window = 300
+	}
+	sqls := []string{
+		fmt.Sprintf(hostCPUUsageSQL, dbName, window),
+		fmt.Sprintf(hostMemUsageSQL, dbName, window),
+		fmt.Sprintf(hostDiskIOUtilSQL, dbName, window),
+		fmt.Sprintf(hostNetBpsSQL, dbName, window),
+	}
+	results, err := influxdb.NewInfluxdb(urls[0]).Query(strings.Join(sqls, "; "))
+	if err != nil {
+		return nil, errors.Wrap(err, "query influxdb")
+	}
+	if len(results) != len(sqls) {
+		return nil, fmt.Errorf("query influxdb: expecting %d set of results, got %d", len(sqls), len(results))
+	}
+
+	now := time.Now()
+	ret := make(map[string]*core.HostMetrics)
+	each := func(i int, f func(hostId string, vals []float64)) {
+		for _, series := range results[i] {
+			if hostId, vals, ok := parseHostValues(series.Tags, series.Values); ok {
+				f(hostId, vals)
+			}
+		}
+	}
+	get := func(hostId string) *core.HostMetrics {
+		m, ok := ret[hostId]
+		if !ok {
+			m = &core.HostMetrics{UpdatedAt: now}
+			ret[hostId] = m
+		}
+		return m
+	}
+	each(0, func(hostId string, vals []float64) {
+		get(hostId).CPUUsage = toUsage(vals[0] / 100)
+	})
+	each(1, func(hostId string, vals []float64) {
+		get(hostId).MemUsage = toUsage(vals[0] / 100)
+	})
+	each(2, func(hostId string, vals []float64) {
+		// milliseconds spent doing io per second
+		get(hostId).DiskIOUtil = toUsage(vals[0] / 1000)
+	})
+	bandwidth := float64(o.GetOptions().HostMetricsNetBandwidthMbps) * 1000 * 1000 / 8
+	if bandwidth > 0 {
+		each(3, func(hostId string, vals []float64) {
+			bps := vals[0]
+			if len(vals) > 1 && vals[1] > bps {
+				bps = vals[1]
+			}
+			get(hostId).NetUsage = toUsage(bps / bandwidth)
+		})
+	}
+	return ret, nil
+}
+
+func (b *HostBuilder) setHostMetrics() {
+	if !o.GetOptions().HostMetricsEnabled {
+		return
+	}
+	metrics, err := FetchHostMetrics()
+	if err != nil {
+		// scheduling goes on with commit rate only
+		log.Warningf("fetch host metrics: %v", err)
+		return
+	}
+	b.hostMetrics = metrics
+	b.cpuIOLoads = make(map[string]map[string]float64)
+	for hostId, m := range metrics {
+		loads := make(map[string]float64)
+		if m.CPUUsage != nil {
+			loads["cpu_load"] = *m.CPUUsage
+		} else {
+			loads["cpu_load"] = -1
+		}
+		if m.DiskIOUtil != nil {
+			loads["io_load"] = *m.DiskIOUtil
+		} else {
+			loads["io_load"] = -1
+		}
+		b.cpuIOLoads[hostId] = loads
+	}
+}
+
+func (b *HostBuilder) fillHostMetrics(desc *HostDesc, host *computemodels.SHost) error {
+	if b.hostMetrics != nil {
+		desc.Metrics = b.hostMetrics[host.Id]
+	}
+	return nil
+}
@@ -97,6 +97,14 @@ func (h *hostGetter) OvnCapable() bool {
 	return len(h.h.OvnVersion) > 0
 }
 
+func (h *hostGetter) HostMetrics() *core.HostMetrics {
+	return h.h.Metrics
+}
+
+func (h *hostGetter) NumaTopology() *computeapi.HostNumaTopology {
+	return h.h.NumaTopology
+}
+
 type HostDesc struct {
 	*BaseHostDesc
 
@@ -127,6 +135,11 @@ type HostDesc struct {
 	IOBoundCount int64    `json:"io_bound_count"`
 	IOLoad       *float64 `json:"io_load"`
 
+	// utilization reported by telegraf
+	Metrics *core.HostMetrics `json:"metrics"`
+
+	NumaTopology *computeapi.HostNumaTopology `json:"numa_topology"`
+
 	// server
 	GuestCount         int64 `json:"guest_count"`
 	CreatingGuestCount int64 `json:"creating_guest_count"`
@@ -244,7 +257,8 @@ type HostBuilder struct {
 	//diskStats           []models.StorageCapacity
 	// isolatedDevicesDict map[string][]interface{}
 
-	cpuIOLoads map[string]map[string]float64
+	cpuIOLoads  map[string]map[string]float64
+	hostMetrics map[string]*core.HostMetrics
 
 	schedtags []computemodels.SSchedtag
 	zoneSkus  map[string][]computemodels.SServerSku
@@ -454,6 +468,7 @@ func (b *HostBuilder) init(ids []string) error {
 			b.setGuests(ids, errMessageChannel)
 			b.setIsolatedDevs(ids, errMessageChannel)
 		},
+		b.setHostMetrics,
 	}
 
 	for _, f := range setFuncs {
@@ -803,6 +818,7 @@ func (b *HostBuilder) buildOne(host *computemodels.SHost) (interface{}, error) {
 
 	desc.CPUCmtbound = host.GetCPUOvercommitBound()
 	desc.MemCmtbound = host.GetMemoryOvercommitBound()
+	desc.NumaTopology = host.GetNumaTopology()
 
 	desc.GuestReservedResource = NewGuestReservedResourceByBuilder(b, host)
 	guestRsvdUsed, err := NewGuestReservedResourceUsedByBuilder(b, host, desc.GuestReservedResource)
@@ -816,6 +832,7 @@ func (b *HostBuilder) buildOne(host *computemodels.SHost) (interface{}, error) {
 		//b.fillResidentGroups,
 		b.fillMetadata,
 		b.fillCPUIOLoads,
+		b.fillHostMetrics,
 	}
 
 	for _, f := range fillFuncs {
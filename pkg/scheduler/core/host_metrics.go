@@ -0,0 +1,44 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"time"
+)
+
+// HostMetrics is the recent utilization of a host collected by telegraf,
+// every usage is a ratio in [0, 1] and nil means the host reported nothing
+type HostMetrics struct {
+	CPUUsage   *float64 `json:"cpu_usage"`
+	MemUsage   *float64 `json:"mem_usage"`
+	DiskIOUtil *float64 `json:"disk_io_util"`
+	NetUsage   *float64 `json:"net_usage"`
+
+	// UpdatedAt is the time metrics were fetched from tsdb
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsStale tells whether metrics are too old to reflect current load of host
+func (m *HostMetrics) IsStale(staleness time.Duration) bool {
+	if m == nil {
+		return true
+	}
+	return time.Since(m.UpdatedAt) > staleness
+}
+
+// IsEmpty tells whether none of the utilizations is known
+func (m *HostMetrics) IsEmpty() bool {
+	return m == nil || (m.CPUUsage == nil && m.MemUsage == nil && m.DiskIOUtil == nil && m.NetUsage == nil)
+}
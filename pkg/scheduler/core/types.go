@@ -101,6 +101,9 @@ type CandidatePropertyGetter interface {
 
 	GetFreePort(netId string) int
 
+	HostMetrics() *HostMetrics
+	NumaTopology() *computeapi.HostNumaTopology
+
 	InstanceGroups() map[string]*api.CandidateGroup
 	GetFreeGroupCount(groupId string) (int, error)
 
@@ -89,6 +89,23 @@ type SchedOptions struct {
 	WireDBCachePeriod string `help:"Wire database cache period" default:"5m"`
 
 	SkuRefreshInterval string `help:"Server SKU refresh interval" default:"12h"`
+
+	// host metrics options
+	HostMetricsEnabled          bool   `help:"Fetch utilization of hosts from tsdb to score and filter hosts" default:"false"`
+	HostMetricsDatabase         string `help:"Tsdb database of host metrics reported by telegraf" default:"telegraf"`
+	HostMetricsWindow           string `help:"Time window to average host metrics over" default:"5m"`
+	HostMetricsStaleness        string `help:"Host metrics fetched earlier than this are ignored" default:"10m"`
+	HostMetricsNetBandwidthMbps int    `help:"Bandwidth of host nic used to calculate network usage" default:"1000"`
+
+	HostMetricsCPUWeight    int `help:"Weight of cpu usage when scoring host by metrics" default:"4"`
+	HostMetricsMemWeight    int `help:"Weight of memory usage when scoring host by metrics" default:"3"`
+	HostMetricsDiskIOWeight int `help:"Weight of disk io utilization when scoring host by metrics" default:"2"`
+	HostMetricsNetWeight    int `help:"Weight of network usage when scoring host by metrics" default:"1"`
+
+	HostMetricsMaxCPUPercent    int `help:"Exclude hosts whose cpu usage is above this percent, 0 means no limit" default:"0"`
+	HostMetricsMaxMemPercent    int `help:"Exclude hosts whose memory usage is above this percent, 0 means no limit" default:"0"`
+	HostMetricsMaxDiskIOPercent int `help:"Exclude hosts whose disk io utilization is above this percent, 0 means no limit" default:"0"`
+	HostMetricsMaxNetPercent    int `help:"Exclude hosts whose network usage is above this percent, 0 means no limit" default:"0"`
 }
 
 var (
@@ -25,7 +25,6 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 
 	"yunion.io/x/log"
-	"yunion.io/x/pkg/util/prometheus"
 	"yunion.io/x/pkg/utils"
 
 	compute_api "yunion.io/x/onecloud/pkg/apis/scheduler"
@@ -107,8 +106,9 @@ func startHTTP(opt *o.SchedulerOptions) error {
 	router.Use(middleware.Logger())
 	router.Use(middleware.ErrorHandler)
 	router.Use(middleware.KeystoneTokenVerifyMiddleware())
+	router.Use(middleware.RequestMetrics())
 
-	prometheus.InstallHandler(router)
+	middleware.InstallMetricsHandler(router)
 	schedhandler.InstallHandler(router)
 
 	server := &http.Server{
@@ -0,0 +1,207 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	apisdu "yunion.io/x/onecloud/pkg/apis/scheduler"
+	predicateguest "yunion.io/x/onecloud/pkg/scheduler/algorithm/predicates/guest"
+	priorityguest "yunion.io/x/onecloud/pkg/scheduler/algorithm/priorities/guest"
+	"yunion.io/x/onecloud/pkg/scheduler/api"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+	"yunion.io/x/onecloud/pkg/scheduler/core/score"
+	o "yunion.io/x/onecloud/pkg/scheduler/options"
+)
+
+func usage(val float64) *float64 {
+	return &val
+}
+
+func buildMetricsUnit() *core.Unit {
+	info := &api.SchedInfo{
+		ScheduleInput: &apisdu.ScheduleInput{
+			ServerConfig: apisdu.ServerConfig{
+				ServerConfigs: &compute.ServerConfigs{
+					Hypervisor: compute.HYPERVISOR_KVM,
+					Count:      1,
+				},
+				Memory:  1024,
+				Ncpu:    1,
+				Project: GlobalProject,
+				Domain:  GlobalDoamin,
+			},
+		},
+	}
+	return core.NewScheduleUnit(info, nil)
+}
+
+func setMetricsOptions(t *testing.T) {
+	opts := o.GetOptions()
+	saved := *opts
+	t.Cleanup(func() {
+		*opts = saved
+	})
+	opts.HostMetricsEnabled = true
+	opts.HostMetricsStaleness = "10m"
+	opts.HostMetricsCPUWeight = 4
+	opts.HostMetricsMemWeight = 3
+	opts.HostMetricsDiskIOWeight = 2
+	opts.HostMetricsNetWeight = 1
+	opts.HostMetricsMaxCPUPercent = 0
+	opts.HostMetricsMaxMemPercent = 0
+	opts.HostMetricsMaxDiskIOPercent = 0
+	opts.HostMetricsMaxNetPercent = 0
+}
+
+func TestGetMetricsLoad(t *testing.T) {
+	setMetricsOptions(t)
+	cases := []struct {
+		name    string
+		metrics core.HostMetrics
+		want    float64
+		wantOk  bool
+	}{
+		{
+			name:   "empty",
+			wantOk: false,
+		},
+		{
+			name:    "cpu only",
+			metrics: core.HostMetrics{CPUUsage: usage(0.5)},
+			want:    0.5,
+			wantOk:  true,
+		},
+		{
+			name: "weighted",
+			metrics: core.HostMetrics{
+				CPUUsage:   usage(1),
+				MemUsage:   usage(0.5),
+				DiskIOUtil: usage(0),
+				NetUsage:   usage(0),
+			},
+			// (1*4 + 0.5*3) / 10
+			want:   0.55,
+			wantOk: true,
+		},
+	}
+	for _, c := range cases {
+		got, ok := priorityguest.GetMetricsLoad(&c.metrics)
+		if ok != c.wantOk || (ok && (got-c.want > 1e-9 || c.want-got > 1e-9)) {
+			t.Errorf("%s: want %v, %v got %v, %v", c.name, c.want, c.wantOk, got, ok)
+		}
+	}
+
+	o.GetOptions().HostMetricsCPUWeight = 0
+	if _, ok := priorityguest.GetMetricsLoad(&core.HostMetrics{CPUUsage: usage(0.5)}); ok {
+		t.Errorf("metrics of zero weight should be ignored")
+	}
+}
+
+func TestMetricsPredicate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	setMetricsOptions(t)
+
+	p := &predicateguest.MetricsPredicate{}
+	u := buildMetricsUnit()
+	if ok, _ := p.PreExecute(u, nil); ok {
+		t.Errorf("predicate should be skipped without thresholds")
+	}
+	o.GetOptions().HostMetricsMaxCPUPercent = 80
+	if ok, _ := p.PreExecute(u, nil); !ok {
+		t.Errorf("predicate should be executed with thresholds")
+	}
+	o.GetOptions().HostMetricsEnabled = false
+	if ok, _ := p.PreExecute(u, nil); ok {
+		t.Errorf("predicate should be skipped when host metrics disabled")
+	}
+	o.GetOptions().HostMetricsEnabled = true
+
+	cases := []struct {
+		name    string
+		metrics *core.HostMetrics
+		want    bool
+	}{
+		{"no metrics", nil, true},
+		{"idle", &core.HostMetrics{CPUUsage: usage(0.2), UpdatedAt: time.Now()}, true},
+		{"busy", &core.HostMetrics{CPUUsage: usage(0.9), UpdatedAt: time.Now()}, false},
+		{"busy but stale", &core.HostMetrics{CPUUsage: usage(0.9), UpdatedAt: time.Now().Add(-time.Hour)}, true},
+		{"memory not limited", &core.HostMetrics{MemUsage: usage(0.99), UpdatedAt: time.Now()}, true},
+	}
+	for _, c := range cases {
+		candidate := buildCandidate(ctrl, sGetterParams{
+			HostId:      c.name,
+			HostName:    c.name,
+			Zone:        GlobalZone,
+			HostMetrics: c.metrics,
+		})
+		fit, _, err := p.Clone().Execute(buildMetricsUnit(), candidate)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if fit != c.want {
+			t.Errorf("%s: want fit %v got %v", c.name, c.want, fit)
+		}
+	}
+}
+
+func TestMetricsLoadPriority(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	setMetricsOptions(t)
+
+	p := &priorityguest.MetricsLoadPriority{}
+	u := buildMetricsUnit()
+	if ok, _, _ := p.PreExecute(u, nil); !ok {
+		t.Errorf("priority should be executed when host metrics enabled")
+	}
+
+	hosts := map[string]*core.HostMetrics{
+		"idle":  {CPUUsage: usage(0.1), MemUsage: usage(0.2), UpdatedAt: time.Now()},
+		"busy":  {CPUUsage: usage(0.9), MemUsage: usage(0.8), UpdatedAt: time.Now()},
+		"stale": {CPUUsage: usage(0.1), UpdatedAt: time.Now().Add(-time.Hour)},
+		"none":  nil,
+	}
+	for id, m := range hosts {
+		candidate := buildCandidate(ctrl, sGetterParams{
+			HostId:      id,
+			HostName:    id,
+			Zone:        GlobalZone,
+			HostMetrics: m,
+		})
+		if _, err := p.Map(u, candidate); err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+	}
+	if !score.NormalLess(u.GetScore("busy").ScoreBucket, u.GetScore("idle").ScoreBucket) {
+		t.Errorf("idle host should score higher than busy one: idle %s, busy %s", u.GetScoreDetails("idle"), u.GetScoreDetails("busy"))
+	}
+	for _, id := range []string{"stale", "none"} {
+		if details := u.GetScoreDetails(id); details != "EmptyScore" {
+			t.Errorf("%s host should be left unscored, got %s", id, details)
+		}
+	}
+
+	o.GetOptions().HostMetricsEnabled = false
+	if ok, _, _ := p.PreExecute(u, nil); ok {
+		t.Errorf("priority should be skipped when host metrics disabled")
+	}
+}
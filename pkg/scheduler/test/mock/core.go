@@ -20,6 +20,7 @@ import (
 
 	jsonutils "yunion.io/x/jsonutils"
 
+	compute "yunion.io/x/onecloud/pkg/apis/compute"
 	types "yunion.io/x/onecloud/pkg/cloudcommon/types"
 	baremetal "yunion.io/x/onecloud/pkg/compute/baremetal"
 	models "yunion.io/x/onecloud/pkg/compute/models"
@@ -263,6 +264,20 @@ func (mr *MockCandidatePropertyGetterMockRecorder) Host() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Host", reflect.TypeOf((*MockCandidatePropertyGetter)(nil).Host))
 }
 
+// HostMetrics mocks base method
+func (m *MockCandidatePropertyGetter) HostMetrics() *core.HostMetrics {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HostMetrics")
+	ret0, _ := ret[0].(*core.HostMetrics)
+	return ret0
+}
+
+// HostMetrics indicates an expected call of HostMetrics
+func (mr *MockCandidatePropertyGetterMockRecorder) HostMetrics() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HostMetrics", reflect.TypeOf((*MockCandidatePropertyGetter)(nil).HostMetrics))
+}
+
 // HostSchedtags mocks base method
 func (m *MockCandidatePropertyGetter) HostSchedtags() []models.SSchedtag {
 	m.ctrl.T.Helper()
@@ -417,6 +432,20 @@ func (mr *MockCandidatePropertyGetterMockRecorder) Networks() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Networks", reflect.TypeOf((*MockCandidatePropertyGetter)(nil).Networks))
 }
 
+// NumaTopology mocks base method
+func (m *MockCandidatePropertyGetter) NumaTopology() *compute.HostNumaTopology {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NumaTopology")
+	ret0, _ := ret[0].(*compute.HostNumaTopology)
+	return ret0
+}
+
+// NumaTopology indicates an expected call of NumaTopology
+func (mr *MockCandidatePropertyGetterMockRecorder) NumaTopology() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NumaTopology", reflect.TypeOf((*MockCandidatePropertyGetter)(nil).NumaTopology))
+}
+
 // OvnCapable mocks base method
 func (m *MockCandidatePropertyGetter) OvnCapable() bool {
 	m.ctrl.T.Helper()
@@ -0,0 +1,147 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	apisdu "yunion.io/x/onecloud/pkg/apis/scheduler"
+	predicateguest "yunion.io/x/onecloud/pkg/scheduler/algorithm/predicates/guest"
+	"yunion.io/x/onecloud/pkg/scheduler/api"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+	schedmodels "yunion.io/x/onecloud/pkg/scheduler/models"
+)
+
+// buildHugepageTopology returns topology of nodes with 2M hugepages, the
+// total and free memory of each node are given in MB
+func buildHugepageTopology(nodes ...[2]int) *compute.HostNumaTopology {
+	topo := &compute.HostNumaTopology{HugepageSizeKb: 2048}
+	for i, node := range nodes {
+		topo.Nodes = append(topo.Nodes, compute.HostNumaNode{
+			NodeId: i,
+			Hugepages: []compute.HostNumaHugepages{
+				{SizeKb: 2048, Total: node[0] / 2, Free: node[1] / 2},
+			},
+		})
+	}
+	return topo
+}
+
+func TestNumaPredicate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cases := []struct {
+		name     string
+		memory   int
+		count    int
+		topo     *compute.HostNumaTopology
+		pending  int
+		want     bool
+		capacity int64
+	}{
+		{
+			name:   "no hugepages",
+			memory: 4096,
+			count:  1,
+			topo:   &compute.HostNumaTopology{},
+			want:   true,
+		},
+		{
+			name:     "fits into each node",
+			memory:   4096,
+			count:    2,
+			topo:     buildHugepageTopology([2]int{16384, 8192}, [2]int{16384, 4096}),
+			want:     true,
+			capacity: 3,
+		},
+		{
+			// 6G free in total but 3G on each node
+			name:   "fragmented over nodes",
+			memory: 4096,
+			count:  1,
+			topo:   buildHugepageTopology([2]int{16384, 3072}, [2]int{16384, 3072}),
+			want:   false,
+		},
+		{
+			name:     "pending usage",
+			memory:   4096,
+			count:    1,
+			topo:     buildHugepageTopology([2]int{16384, 8192}, [2]int{16384, 4096}),
+			pending:  8192,
+			want:     true,
+			capacity: 1,
+		},
+		{
+			name:    "pending usage exhausts",
+			memory:  4096,
+			count:   1,
+			topo:    buildHugepageTopology([2]int{16384, 8192}, [2]int{16384, 4096}),
+			pending: 10240,
+			want:    false,
+		},
+		{
+			// larger than any node, spans all nodes
+			name:     "spans nodes",
+			memory:   24576,
+			count:    1,
+			topo:     buildHugepageTopology([2]int{16384, 16384}, [2]int{16384, 12288}),
+			want:     true,
+			capacity: 1,
+		},
+	}
+	for _, c := range cases {
+		info := &api.SchedInfo{
+			ScheduleInput: &apisdu.ScheduleInput{
+				ServerConfig: apisdu.ServerConfig{
+					ServerConfigs: &compute.ServerConfigs{
+						Hypervisor: compute.HYPERVISOR_KVM,
+						Count:      c.count,
+					},
+					Memory:  c.memory,
+					Ncpu:    1,
+					Project: GlobalProject,
+					Domain:  GlobalDoamin,
+				},
+			},
+		}
+		u := core.NewScheduleUnit(info, nil)
+		candidate := buildCandidate(ctrl, sGetterParams{
+			HostId:       c.name,
+			HostName:     c.name,
+			Zone:         GlobalZone,
+			NumaTopology: c.topo,
+			PendingUsage: &schedmodels.SPendingUsage{Memory: c.pending},
+		})
+		p := &predicateguest.NumaPredicate{}
+		fit, _, err := p.Execute(u, candidate)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if fit != c.want {
+			t.Errorf("%s: want fit %v got %v", c.name, c.want, fit)
+			continue
+		}
+		if c.capacity > 0 {
+			if got := u.GetCapacityOfName(c.name, p.Name()); got != c.capacity {
+				t.Errorf("%s: want capacity %d got %d", c.name, c.capacity, got)
+			}
+		}
+	}
+}
@@ -32,6 +32,7 @@ import (
 	"yunion.io/x/onecloud/pkg/scheduler/core"
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/sku"
 	"yunion.io/x/onecloud/pkg/scheduler/factory"
+	schedmodels "yunion.io/x/onecloud/pkg/scheduler/models"
 	"yunion.io/x/onecloud/pkg/scheduler/test/mock"
 )
 
@@ -96,6 +97,9 @@ type sGetterParams struct {
 	Storages               []*api.CandidateStorage
 	Networks               []*api.CandidateNetwork
 	OvnCapable             *bool
+	HostMetrics            *core.HostMetrics
+	NumaTopology           *computeapi.HostNumaTopology
+	PendingUsage           *schedmodels.SPendingUsage
 	Status                 string
 	HostStatus             string
 	Enabled                *bool
@@ -171,6 +175,9 @@ func buildGetter(ctrl *gomock.Controller, param sGetterParams) *mock.MockCandida
 	cg.EXPECT().FreeMemorySize(gomock.Any()).AnyTimes().Return(param.FreeMemorySize)
 	cg.EXPECT().GetFreeStorageSizeOfType(gomock.Any(), gomock.Any()).AnyTimes().Return(param.FreeStorageSizeAnyType, int64(0))
 	cg.EXPECT().GetFreePort(gomock.Any()).AnyTimes().Return(param.FreePort)
+	cg.EXPECT().HostMetrics().AnyTimes().Return(param.HostMetrics)
+	cg.EXPECT().NumaTopology().AnyTimes().Return(param.NumaTopology)
+	cg.EXPECT().GetPendingUsage().AnyTimes().Return(param.PendingUsage)
 	if param.QuotaKeys != nil {
 		cg.EXPECT().GetQuotaKeys(gomock.Any()).AnyTimes().Return(param.QuotaKeys)
 	}
@@ -0,0 +1,272 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dhcp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// DHCPv6, RFC 8415
+
+const (
+	DHCPV6_SERVER_PORT = 547
+	DHCPV6_CLIENT_PORT = 546
+)
+
+// All_DHCP_Relay_Agents_and_Servers
+var DHCPV6_MULTICAST_ADDR = net.ParseIP("ff02::1:2")
+
+type MessageType6 byte
+
+const (
+	Solicit6            MessageType6 = 1
+	Advertise6          MessageType6 = 2
+	Request6            MessageType6 = 3
+	Confirm6            MessageType6 = 4
+	Renew6              MessageType6 = 5
+	Rebind6             MessageType6 = 6
+	Reply6              MessageType6 = 7
+	Release6            MessageType6 = 8
+	Decline6            MessageType6 = 9
+	InformationRequest6 MessageType6 = 11
+)
+
+type OptionCode6 uint16
+
+const (
+	OptClientId6    OptionCode6 = 1
+	OptServerId6    OptionCode6 = 2
+	OptIANA6        OptionCode6 = 3
+	OptIAAddr6      OptionCode6 = 5
+	OptORO6         OptionCode6 = 6
+	OptStatusCode6  OptionCode6 = 13
+	OptRapidCommit6 OptionCode6 = 14
+	OptDNSServers6  OptionCode6 = 23
+	OptDomainList6  OptionCode6 = 24
+)
+
+const (
+	duidTypeLLT = 1
+	duidTypeLL  = 3
+
+	hwTypeEthernet = 1
+)
+
+type Option6 struct {
+	Code OptionCode6
+	Data []byte
+}
+
+type Packet6 struct {
+	Type          MessageType6
+	TransactionId [3]byte
+	Options       []Option6
+}
+
+func parseOptions6(b []byte) ([]Option6, error) {
+	opts := make([]Option6, 0)
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return nil, fmt.Errorf("option header truncated")
+		}
+		code := OptionCode6(binary.BigEndian.Uint16(b[0:2]))
+		length := int(binary.BigEndian.Uint16(b[2:4]))
+		if len(b) < 4+length {
+			return nil, fmt.Errorf("option %d truncated", code)
+		}
+		opts = append(opts, Option6{Code: code, Data: b[4 : 4+length]})
+		b = b[4+length:]
+	}
+	return opts, nil
+}
+
+func marshalOptions6(opts []Option6) []byte {
+	ret := make([]byte, 0)
+	for _, opt := range opts {
+		hdr := make([]byte, 4)
+		binary.BigEndian.PutUint16(hdr[0:2], uint16(opt.Code))
+		binary.BigEndian.PutUint16(hdr[2:4], uint16(len(opt.Data)))
+		ret = append(ret, hdr...)
+		ret = append(ret, opt.Data...)
+	}
+	return ret
+}
+
+func Unmarshal6(b []byte) (*Packet6, error) {
+	if len(b) < 4 {
+		return nil, fmt.Errorf("DHCPv6 packet too short")
+	}
+	pkt := &Packet6{Type: MessageType6(b[0])}
+	copy(pkt.TransactionId[:], b[1:4])
+	opts, err := parseOptions6(b[4:])
+	if err != nil {
+		return nil, err
+	}
+	pkt.Options = opts
+	return pkt, nil
+}
+
+func (p *Packet6) Marshal() []byte {
+	ret := []byte{byte(p.Type), p.TransactionId[0], p.TransactionId[1], p.TransactionId[2]}
+	return append(ret, marshalOptions6(p.Options)...)
+}
+
+func (p *Packet6) GetOption(code OptionCode6) []byte {
+	for _, opt := range p.Options {
+		if opt.Code == code {
+			return opt.Data
+		}
+	}
+	return nil
+}
+
+func (p *Packet6) AddOption(code OptionCode6, data []byte) {
+	p.Options = append(p.Options, Option6{Code: code, Data: data})
+}
+
+// ClientMac returns the link layer address in the client DUID if
+// the DUID is DUID-LLT or DUID-LL of ethernet
+func (p *Packet6) ClientMac() net.HardwareAddr {
+	duid := p.GetOption(OptClientId6)
+	if len(duid) < 4 || binary.BigEndian.Uint16(duid[2:4]) != hwTypeEthernet {
+		return nil
+	}
+	var lladdr []byte
+	switch binary.BigEndian.Uint16(duid[0:2]) {
+	case duidTypeLLT:
+		lladdr = duid[8:]
+	case duidTypeLL:
+		lladdr = duid[4:]
+	default:
+		return nil
+	}
+	if len(lladdr) != 6 {
+		return nil
+	}
+	return net.HardwareAddr(lladdr)
+}
+
+// MakeDUIDLL returns the DUID-LL of mac, used as server identifier
+func MakeDUIDLL(mac net.HardwareAddr) []byte {
+	duid := make([]byte, 4, 4+len(mac))
+	binary.BigEndian.PutUint16(duid[0:2], duidTypeLL)
+	binary.BigEndian.PutUint16(duid[2:4], hwTypeEthernet)
+	return append(duid, mac...)
+}
+
+// encodeDomainList6 encodes domain names in DNS wire format without
+// compression, as required by RFC 3646
+func encodeDomainList6(domains []string) []byte {
+	ret := make([]byte, 0)
+	for _, domain := range domains {
+		for _, label := range strings.Split(strings.Trim(domain, "."), ".") {
+			if len(label) == 0 || len(label) > 63 {
+				continue
+			}
+			ret = append(ret, byte(len(label)))
+			ret = append(ret, label...)
+		}
+		ret = append(ret, 0)
+	}
+	return ret
+}
+
+type ResponseConfig6 struct {
+	ServerDUID []byte
+	ClientIP   net.IP        // OptIAAddr6 5
+	DNSServers []net.IP      // OptDNSServers6 23
+	Domain     string        // OptDomainList6 24
+	LeaseTime  time.Duration // valid lifetime of OptIAAddr6
+}
+
+func makeIANA6(reqIANA []byte, conf *ResponseConfig6) []byte {
+	iaid := make([]byte, 4)
+	if len(reqIANA) >= 4 {
+		copy(iaid, reqIANA[0:4])
+	}
+	lease := uint32(conf.LeaseTime / time.Second)
+	preferred := lease / 10 * 8
+
+	iaAddr := make([]byte, 24)
+	copy(iaAddr[0:16], conf.ClientIP.To16())
+	binary.BigEndian.PutUint32(iaAddr[16:20], preferred)
+	binary.BigEndian.PutUint32(iaAddr[20:24], lease)
+
+	iana := make([]byte, 12)
+	copy(iana[0:4], iaid)
+	// T1 and T2 are 0.5 and 0.8 of the preferred lifetime
+	binary.BigEndian.PutUint32(iana[4:8], preferred/2)
+	binary.BigEndian.PutUint32(iana[8:12], preferred/10*8)
+	return append(iana, marshalOptions6([]Option6{{Code: OptIAAddr6, Data: iaAddr}})...)
+}
+
+// MakeReplyPacket6 makes Advertise or Reply for client message, address is
+// not assigned when conf.ClientIP is nil, i.e. only stateless configuration
+// is served
+func MakeReplyPacket6(pkt *Packet6, conf *ResponseConfig6) (*Packet6, error) {
+	reply := &Packet6{
+		Type:          Reply6,
+		TransactionId: pkt.TransactionId,
+	}
+	switch pkt.Type {
+	case Solicit6:
+		if pkt.GetOption(OptRapidCommit6) != nil && conf.ClientIP != nil {
+			reply.AddOption(OptRapidCommit6, []byte{})
+		} else {
+			reply.Type = Advertise6
+		}
+	case Request6, Renew6, Rebind6, Confirm6, Release6, Decline6, InformationRequest6:
+	default:
+		return nil, fmt.Errorf("unsupported DHCPv6 message type %d", pkt.Type)
+	}
+
+	clientId := pkt.GetOption(OptClientId6)
+	if clientId == nil && pkt.Type != InformationRequest6 {
+		return nil, fmt.Errorf("DHCPv6 message type %d without client id", pkt.Type)
+	}
+	if clientId != nil {
+		reply.AddOption(OptClientId6, clientId)
+	}
+	reply.AddOption(OptServerId6, conf.ServerDUID)
+
+	switch pkt.Type {
+	case Release6, Decline6, Confirm6:
+		// status Success
+		reply.AddOption(OptStatusCode6, []byte{0, 0})
+		return reply, nil
+	case InformationRequest6:
+	default:
+		reqIANA := pkt.GetOption(OptIANA6)
+		if reqIANA != nil && conf.ClientIP != nil {
+			reply.AddOption(OptIANA6, makeIANA6(reqIANA, conf))
+		}
+	}
+
+	if len(conf.DNSServers) > 0 {
+		dns := make([]byte, 0, 16*len(conf.DNSServers))
+		for _, ip := range conf.DNSServers {
+			dns = append(dns, ip.To16()...)
+		}
+		reply.AddOption(OptDNSServers6, dns)
+	}
+	if len(conf.Domain) > 0 {
+		reply.AddOption(OptDomainList6, encodeDomainList6([]string{conf.Domain}))
+	}
+	return reply, nil
+}
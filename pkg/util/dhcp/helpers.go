@@ -26,7 +26,8 @@ import (
 )
 
 const (
-	PXECLIENT = "PXEClient"
+	PXECLIENT  = "PXEClient"
+	HTTPCLIENT = "HTTPClient"
 
 	OptClasslessRouteLin OptionCode = OptionClasslessRouteFormat //Classless Static Route Option
 	OptClasslessRouteWin OptionCode = 249
@@ -50,6 +51,9 @@ type ResponseConfig struct {
 	BootServer string
 	BootFile   string
 	BootBlock  uint16
+
+	// OptVendorClassIdentifier 60, must be HTTPClient for UEFI HTTP boot
+	VendorClassId string
 }
 
 func (conf ResponseConfig) GetHostname() string {
@@ -143,9 +147,14 @@ func makeDHCPReplyPacket(req Packet, conf *ResponseConfig, msgType MessageType)
 	}
 	if conf.BootFile != "" {
 		resp.AddOption(OptionBootFileName, []byte(fmt.Sprintf("%s\x00", conf.BootFile)))
-		sz := make([]byte, 2)
-		binary.BigEndian.PutUint16(sz, conf.BootBlock)
-		resp.AddOption(OptionBootFileSize, sz)
+		if conf.BootBlock > 0 {
+			sz := make([]byte, 2)
+			binary.BigEndian.PutUint16(sz, conf.BootBlock)
+			resp.AddOption(OptionBootFileSize, sz)
+		}
+	}
+	if conf.VendorClassId != "" {
+		resp.AddOption(OptionVendorClassIdentifier, []byte(conf.VendorClassId))
 	}
 	//if bs, _ := req.ParseOptions().Bytes(OptionClientMachineIdentifier); bs != nil {
 	//resp.AddOption(OptionClientMachineIdentifier, bs)
@@ -0,0 +1,96 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dhcp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMakeReplyPacketBootOptions(t *testing.T) {
+	mac, _ := net.ParseMAC("00:22:46:9f:1b:5e")
+	cases := []struct {
+		name          string
+		vendorClsId   string
+		conf          ResponseConfig
+		wantBootFile  string
+		wantBootSize  []byte
+		wantVendorCls string
+	}{
+		{
+			name:        "legacy PXE",
+			vendorClsId: "PXEClient:Arch:00000:UNDI:002001",
+			conf: ResponseConfig{
+				BootServer: "10.168.26.2",
+				BootFile:   "lpxelinux.0",
+				BootBlock:  85,
+			},
+			wantBootFile: "lpxelinux.0\x00",
+			wantBootSize: []byte{0, 85},
+		},
+		{
+			name:        "UEFI HTTP Boot",
+			vendorClsId: "HTTPClient:Arch:00016:UNDI:003001",
+			conf: ResponseConfig{
+				BootServer:    "10.168.26.2",
+				BootFile:      "http://10.168.26.2:9885/tftp/ipxe.efi",
+				VendorClassId: HTTPCLIENT,
+			},
+			wantBootFile:  "http://10.168.26.2:9885/tftp/ipxe.efi\x00",
+			wantVendorCls: HTTPCLIENT,
+		},
+		{
+			name:        "iPXE",
+			vendorClsId: "PXEClient:Arch:00000:UNDI:002001",
+			conf: ResponseConfig{
+				BootServer: "10.168.26.2",
+				BootFile:   "http://10.168.26.2:9885/ipxe/00-22-46-9f-1b-5e",
+			},
+			wantBootFile: "http://10.168.26.2:9885/ipxe/00-22-46-9f-1b-5e\x00",
+		},
+	}
+	for _, c := range cases {
+		req := RequestPacket(Discover, mac, nil, []byte{1, 2, 3, 4}, true, []Option{
+			{Code: OptionVendorClassIdentifier, Value: []byte(c.vendorClsId)},
+		})
+		c.conf.ServerIP = net.ParseIP("10.168.26.2")
+		c.conf.ClientIP = net.ParseIP("10.168.26.100")
+		c.conf.SubnetMask = net.ParseIP("255.255.255.0")
+		c.conf.Gateway = net.ParseIP("10.168.26.1")
+		c.conf.BroadcastAddr = net.ParseIP("10.168.26.255")
+		c.conf.DNSServer = net.ParseIP("10.168.26.2")
+		c.conf.Domain = "cloud.local"
+		c.conf.LeaseTime = time.Hour
+		resp, err := MakeReplyPacket(req, &c.conf)
+		if err != nil {
+			t.Errorf("%s: MakeReplyPacket: %v", c.name, err)
+			continue
+		}
+		opts := resp.ParseOptions()
+		if got := string(opts[OptionBootFileName]); got != c.wantBootFile {
+			t.Errorf("%s: want boot file %q, got %q", c.name, c.wantBootFile, got)
+		}
+		if got := opts[OptionBootFileSize]; string(got) != string(c.wantBootSize) {
+			t.Errorf("%s: want boot file size %v, got %v", c.name, c.wantBootSize, got)
+		}
+		if got := string(opts[OptionVendorClassIdentifier]); got != c.wantVendorCls {
+			t.Errorf("%s: want vendor class %q, got %q", c.name, c.wantVendorCls, got)
+		}
+		if got := resp.SIAddr().String(); got != c.conf.BootServer {
+			t.Errorf("%s: want next server %s, got %s", c.name, c.conf.BootServer, got)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	gin "github.com/gin-gonic/gin"
+
+	"yunion.io/x/onecloud/pkg/appsrv"
+	"yunion.io/x/onecloud/pkg/util/promutils"
+)
+
+// RequestMetrics records the latency of requests by route to the request
+// metrics of appsrv, the metrics are exported by InstallMetricsHandler
+func RequestMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t := time.Now()
+
+		c.Next()
+
+		path := c.FullPath()
+		if path == "" {
+			path = "*"
+		}
+		code := fmt.Sprintf("%dXX", c.Writer.Status()/100)
+		appsrv.ObserveRequest(c.Request.Method, path, "", code, time.Since(t).Seconds())
+	}
+}
+
+// InstallMetricsHandler serves /metrics with the metrics of all the
+// collectors registered to promutils.Registry
+func InstallMetricsHandler(r *gin.Engine) {
+	r.GET("/metrics", gin.WrapH(promutils.Handler()))
+}
@@ -148,6 +148,9 @@ const (
 	ACT_SET_PRIVILEGES   = "set_privileges"
 	ACT_RESTORE          = "restore"
 	ACT_RESET_PASSWORD   = "reset_password"
+	ACT_QGA_COMMAND      = "qga_command"
+	ACT_START_RESCUE     = "start_rescue"
+	ACT_STOP_RESCUE      = "stop_rescue"
 
 	ACT_VM_ASSOCIATE            = "vm_associate"
 	ACT_VM_DISSOCIATE           = "vm_dissociate"
@@ -158,6 +161,11 @@ const (
 	ACT_PREPARE = "prepare"
 	ACT_PROBE   = "probe"
 
+	ACT_FIRMWARE_UPDATE    = "firmware_update"
+	ACT_BIOS_SETTINGS      = "bios_settings"
+	ACT_RAID_VOLUME_CREATE = "raid_volume_create"
+	ACT_RAID_VOLUME_DELETE = "raid_volume_delete"
+
 	ACT_INSTANCE_GROUP_BIND   = "instance_group_bind"
 	ACT_INSTANCE_GROUP_UNBIND = "instance_group_unbind"
 
@@ -184,6 +184,18 @@ func init() {
 		EN("Vm Reset Pswd").
 		CN("重置密码"),
 	)
+	t.Set(ACT_QGA_COMMAND, i18n.NewTableEntry().
+		EN("Guest Agent Command").
+		CN("执行虚拟机代理命令"),
+	)
+	t.Set(ACT_START_RESCUE, i18n.NewTableEntry().
+		EN("Start Rescue").
+		CN("进入救援模式"),
+	)
+	t.Set(ACT_STOP_RESCUE, i18n.NewTableEntry().
+		EN("Stop Rescue").
+		CN("退出救援模式"),
+	)
 	t.Set(ACT_VM_CHANGE_BANDWIDTH, i18n.NewTableEntry().
 		EN("Vm Change Bandwidth").
 		CN("调整带宽"),
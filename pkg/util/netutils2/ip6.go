@@ -0,0 +1,170 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netutils2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"net"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/regutils"
+)
+
+// IPV6Addr is the IPv6 counterpart of netutils.IPV4Addr
+type IPV6Addr [net.IPv6len]byte
+
+func NewIPV6Addr(ipstr string) (IPV6Addr, error) {
+	var addr IPV6Addr
+	if !regutils.MatchIP6Addr(ipstr) {
+		return addr, errors.Errorf("invalid ipv6 address %s", ipstr)
+	}
+	copy(addr[:], net.ParseIP(ipstr).To16())
+	return addr, nil
+}
+
+func (addr IPV6Addr) ToIP() net.IP {
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, addr[:])
+	return ip
+}
+
+func (addr IPV6Addr) String() string {
+	return addr.ToIP().String()
+}
+
+func (addr IPV6Addr) toInt() *big.Int {
+	return new(big.Int).SetBytes(addr[:])
+}
+
+func ipv6FromInt(n *big.Int) IPV6Addr {
+	var addr IPV6Addr
+	bs := n.Bytes()
+	if len(bs) > net.IPv6len {
+		bs = bs[len(bs)-net.IPv6len:]
+	}
+	copy(addr[net.IPv6len-len(bs):], bs)
+	return addr
+}
+
+func (addr IPV6Addr) StepUp() IPV6Addr {
+	return ipv6FromInt(new(big.Int).Add(addr.toInt(), big.NewInt(1)))
+}
+
+func (addr IPV6Addr) StepDown() IPV6Addr {
+	return ipv6FromInt(new(big.Int).Sub(addr.toInt(), big.NewInt(1)))
+}
+
+func (addr IPV6Addr) NetAddr(maskLen int8) IPV6Addr {
+	var ret IPV6Addr
+	mask := net.CIDRMask(int(maskLen), 128)
+	for i := range addr {
+		ret[i] = addr[i] & mask[i]
+	}
+	return ret
+}
+
+func (addr IPV6Addr) BroadcastAddr(maskLen int8) IPV6Addr {
+	var ret IPV6Addr
+	mask := net.CIDRMask(int(maskLen), 128)
+	for i := range addr {
+		ret[i] = addr[i] | ^mask[i]
+	}
+	return ret
+}
+
+func (addr IPV6Addr) Compare(addr2 IPV6Addr) int {
+	return bytes.Compare(addr[:], addr2[:])
+}
+
+type IPV6AddrRange struct {
+	start IPV6Addr
+	end   IPV6Addr
+}
+
+func NewIPV6AddrRange(ip1 IPV6Addr, ip2 IPV6Addr) IPV6AddrRange {
+	if ip1.Compare(ip2) > 0 {
+		ip1, ip2 = ip2, ip1
+	}
+	return IPV6AddrRange{start: ip1, end: ip2}
+}
+
+func (ar IPV6AddrRange) StartIp() IPV6Addr {
+	return ar.start
+}
+
+func (ar IPV6AddrRange) EndIp() IPV6Addr {
+	return ar.end
+}
+
+func (ar IPV6AddrRange) Contains(ip IPV6Addr) bool {
+	return ar.start.Compare(ip) <= 0 && ip.Compare(ar.end) <= 0
+}
+
+func (ar IPV6AddrRange) IsOverlap(ar2 IPV6AddrRange) bool {
+	return ar.start.Compare(ar2.end) <= 0 && ar2.start.Compare(ar.end) <= 0
+}
+
+// Random returns a random address in the range
+func (ar IPV6AddrRange) Random() IPV6Addr {
+	size := new(big.Int).Sub(ar.end.toInt(), ar.start.toInt())
+	size.Add(size, big.NewInt(1))
+	off, err := rand.Int(rand.Reader, size)
+	if err != nil {
+		return ar.start
+	}
+	return ipv6FromInt(off.Add(off, ar.start.toInt()))
+}
+
+func (ar IPV6AddrRange) String() string {
+	return ar.start.String() + "-" + ar.end.String()
+}
+
+// MacToEUI64 returns the modified EUI-64 interface identifier of mac
+func MacToEUI64(mac net.HardwareAddr) []byte {
+	if len(mac) != 6 {
+		return nil
+	}
+	return []byte{mac[0] ^ 0x02, mac[1], mac[2], 0xff, 0xfe, mac[3], mac[4], mac[5]}
+}
+
+// SlaacAddr6 returns the stateless autoconfigured address (RFC 4862)
+// of mac in the /64 prefix of netAddr
+func SlaacAddr6(netAddr IPV6Addr, mac net.HardwareAddr) (IPV6Addr, error) {
+	eui64 := MacToEUI64(mac)
+	if eui64 == nil {
+		return netAddr, errors.Errorf("invalid mac %s", mac)
+	}
+	ret := netAddr.NetAddr(64)
+	copy(ret[8:], eui64)
+	return ret, nil
+}
+
+// LinkLocalAddr6 returns the fe80::/64 link local address of mac
+func LinkLocalAddr6(mac net.HardwareAddr) (IPV6Addr, error) {
+	prefix, _ := NewIPV6Addr("fe80::")
+	return SlaacAddr6(prefix, mac)
+}
+
+// EUI64ToMac recovers the mac address from an EUI-64 derived address,
+// returns nil if ip is not derived from a mac
+func EUI64ToMac(ip net.IP) net.HardwareAddr {
+	ip = ip.To16()
+	if ip == nil || ip[11] != 0xff || ip[12] != 0xfe {
+		return nil
+	}
+	return net.HardwareAddr{ip[8] ^ 0x02, ip[9], ip[10], ip[13], ip[14], ip[15]}
+}
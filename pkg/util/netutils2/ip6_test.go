@@ -0,0 +1,65 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netutils2
+
+import (
+	"net"
+	"testing"
+)
+
+func TestSlaacAddr6(t *testing.T) {
+	mac, _ := net.ParseMAC("00:22:d2:3c:1a:0b")
+	prefix, err := NewIPV6Addr("2001:db8:1:2::")
+	if err != nil {
+		t.Fatalf("NewIPV6Addr: %v", err)
+	}
+	addr, err := SlaacAddr6(prefix, mac)
+	if err != nil {
+		t.Fatalf("SlaacAddr6: %v", err)
+	}
+	if got, want := addr.String(), "2001:db8:1:2:222:d2ff:fe3c:1a0b"; got != want {
+		t.Errorf("SlaacAddr6() = %s, want %s", got, want)
+	}
+	if got := EUI64ToMac(addr.ToIP()); got.String() != mac.String() {
+		t.Errorf("EUI64ToMac() = %s, want %s", got, mac)
+	}
+	ll, _ := LinkLocalAddr6(mac)
+	if got, want := ll.String(), "fe80::222:d2ff:fe3c:1a0b"; got != want {
+		t.Errorf("LinkLocalAddr6() = %s, want %s", got, want)
+	}
+}
+
+func TestIPV6AddrRange(t *testing.T) {
+	start, _ := NewIPV6Addr("2001:db8::ffff")
+	end, _ := NewIPV6Addr("2001:db8::1:10")
+	r := NewIPV6AddrRange(end, start)
+	if r.StartIp() != start {
+		t.Errorf("StartIp() = %s, want %s", r.StartIp(), start)
+	}
+	if got, want := start.StepUp().String(), "2001:db8::1:0"; got != want {
+		t.Errorf("StepUp() = %s, want %s", got, want)
+	}
+	if got, want := end.NetAddr(64).String(), "2001:db8::"; got != want {
+		t.Errorf("NetAddr() = %s, want %s", got, want)
+	}
+	for i := 0; i < 20; i++ {
+		if ip := r.Random(); !r.Contains(ip) {
+			t.Errorf("Random() %s not in %s", ip, r)
+		}
+	}
+	if _, err := NewIPV6Addr("10.0.0.1"); err == nil {
+		t.Errorf("NewIPV6Addr() accepts ipv4 address")
+	}
+}
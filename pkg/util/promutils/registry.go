@@ -0,0 +1,79 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package promutils
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds all the collectors of the process, including the go
+// runtime and process collectors, it is served by Handler on /metrics
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+}
+
+// Register adds collector to Registry, the collector registered before
+// with the same descriptions is returned instead, so that packages could
+// register their collectors lazily
+func Register(collector prometheus.Collector) prometheus.Collector {
+	if err := Registry.Register(collector); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return are.ExistingCollector
+		}
+		panic(err)
+	}
+	return collector
+}
+
+// Handler exports the metrics of Registry in the format negotiated with
+// the scraper
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// SCollector adapts a collect function producing metrics of fixed
+// descriptions to prometheus.Collector, it suits the metrics read from
+// state owned by others, e.g. the queue length of workers
+type SCollector struct {
+	descs   []*prometheus.Desc
+	collect func(ch chan<- prometheus.Metric)
+}
+
+func NewCollector(collect func(ch chan<- prometheus.Metric), descs ...*prometheus.Desc) *SCollector {
+	return &SCollector{
+		descs:   descs,
+		collect: collect,
+	}
+}
+
+func (c *SCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range c.descs {
+		ch <- desc
+	}
+}
+
+func (c *SCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collect(ch)
+}
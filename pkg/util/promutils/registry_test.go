@@ -0,0 +1,53 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package promutils
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRegistry(t *testing.T) {
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_requests_total", Help: "Test requests."})
+	if Register(counter) != counter {
+		t.Fatalf("first register should return the collector")
+	}
+	dup := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_requests_total", Help: "Test requests."})
+	if Register(dup) != counter {
+		t.Errorf("register again should return the existing collector")
+	}
+	counter.Add(3)
+
+	desc := prometheus.NewDesc("test_queue_size", "Test queue.", []string{"queue"}, nil)
+	Register(NewCollector(func(ch chan<- prometheus.Metric) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 2, "q1")
+	}, desc))
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		"# TYPE test_requests_total counter\ntest_requests_total 3\n",
+		`test_queue_size{queue="q1"} 2`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("%q not found in:\n%s", want, body)
+		}
+	}
+}
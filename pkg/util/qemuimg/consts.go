@@ -27,6 +27,9 @@ const (
 	VHD   = TImageFormat("vhd")
 	ISO   = TImageFormat("iso")
 	RAW   = TImageFormat("raw")
+
+	// raw image wrapped in a LUKS container
+	LUKS = TImageFormat("luks")
 )
 
 var supportedImageFormats = []TImageFormat{
@@ -63,6 +66,8 @@ func String2ImageFormat(fmt string) TImageFormat {
 		return ISO
 	case "raw":
 		return RAW
+	case "luks":
+		return LUKS
 	}
 	// log.Fatalf("unknown image format!!! %s", fmt)
 	return TImageFormat(fmt)
@@ -0,0 +1,137 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qemuimg
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/util/procutils"
+)
+
+type TEncryptFormat string
+
+const (
+	ENCRYPT_FORMAT_LUKS = TEncryptFormat("luks")
+
+	// secret object id used by qemu-img to unlock or seal LUKS layers
+	ENCRYPT_SECRET_ID = "sec0"
+)
+
+// SetEncryptKey sets the passphrase used to open an encrypted image,
+// an invalid image will be created as a LUKS encrypted one with the key
+func (img *SQemuImage) SetEncryptKey(key string) {
+	img.EncryptKey = key
+	if len(img.EncryptFormat) == 0 {
+		img.EncryptFormat = ENCRYPT_FORMAT_LUKS
+	}
+}
+
+func (img *SQemuImage) IsEncrypted() bool {
+	return img.Encryption || img.Format == LUKS
+}
+
+// GetEncryptOptions returns the block options unlocking every encrypted
+// layer of the image chain with the given secret object
+func (img *SQemuImage) GetEncryptOptions(secretId string) ([]string, error) {
+	return img.getEncryptOptions(secretId, true)
+}
+
+func (img *SQemuImage) getEncryptOptions(secretId string, chain bool) ([]string, error) {
+	opts := make([]string, 0)
+	prefix := ""
+	cur := img
+	for {
+		if cur.Format == LUKS {
+			opts = append(opts, fmt.Sprintf("%skey-secret=%s", prefix, secretId))
+		} else if cur.Encryption {
+			opts = append(opts, fmt.Sprintf("%sencrypt.key-secret=%s", prefix, secretId))
+		}
+		if !chain || !cur.IsChained() {
+			break
+		}
+		back, err := NewQemuImage(cur.BackFilePath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "open backing file %s", cur.BackFilePath)
+		}
+		cur = back
+		prefix += "backing."
+	}
+	return opts, nil
+}
+
+// encryptCreateOptions returns the format and creation options sealing a new
+// image of the given format with the secret object
+func encryptCreateOptions(format TImageFormat) (TImageFormat, []string, error) {
+	switch format {
+	case QCOW2:
+		return QCOW2, []string{
+			fmt.Sprintf("encrypt.format=%s", ENCRYPT_FORMAT_LUKS),
+			fmt.Sprintf("encrypt.key-secret=%s", ENCRYPT_SECRET_ID),
+		}, nil
+	case RAW, LUKS:
+		return LUKS, []string{fmt.Sprintf("key-secret=%s", ENCRYPT_SECRET_ID)}, nil
+	default:
+		return format, nil, errors.Wrapf(ErrUnsupportedFormat, "encrypt %s image", format)
+	}
+}
+
+// secretArgs declares the secret object, the key itself is fed through
+// stdin to keep it out of the process list
+func secretArgs() []string {
+	return []string{"--object", fmt.Sprintf("secret,id=%s,file=/dev/stdin", ENCRYPT_SECRET_ID)}
+}
+
+// imageOpts describes the image as --image-opts so that qemu-img
+// can unlock its encrypted layers, backing files are left out when
+// chain is false as they are not opened, e.g. by unsafe rebase
+func (img *SQemuImage) imageOpts(chain bool) (string, error) {
+	opts := []string{
+		fmt.Sprintf("driver=%s", img.Format.String()),
+		fmt.Sprintf("file.filename=%s", strings.Replace(img.Path, ",", ",,", -1)),
+	}
+	encOpts, err := img.getEncryptOptions(ENCRYPT_SECRET_ID, chain)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(append(opts, encOpts...), ","), nil
+}
+
+// EncryptImageArgs returns the arguments opening the image through
+// --image-opts with other qemu tools such as qemu-nbd, the key must be
+// fed to the command by RunWithSecret
+func (img *SQemuImage) EncryptImageArgs() ([]string, error) {
+	imgOpts, err := img.imageOpts(true)
+	if err != nil {
+		return nil, errors.Wrap(err, "image opts")
+	}
+	return append(secretArgs(), "--image-opts", imgOpts), nil
+}
+
+// RunWithSecret runs the command with the key written to its stdin
+func RunWithSecret(cmd *procutils.Command, key string) ([]byte, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "stdin pipe")
+	}
+	go func() {
+		defer stdin.Close()
+		io.WriteString(stdin, key)
+	}()
+	return cmd.Output()
+}
@@ -0,0 +1,90 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qemuimg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEncryptCreateOptions(t *testing.T) {
+	cases := []struct {
+		in      TImageFormat
+		format  TImageFormat
+		options []string
+		wantErr bool
+	}{
+		{
+			in:      QCOW2,
+			format:  QCOW2,
+			options: []string{"encrypt.format=luks", "encrypt.key-secret=sec0"},
+		},
+		{
+			in:      RAW,
+			format:  LUKS,
+			options: []string{"key-secret=sec0"},
+		},
+		{
+			in:      VMDK,
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		format, options, err := encryptCreateOptions(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("encrypt %s: want error", c.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("encrypt %s: %v", c.in, err)
+			continue
+		}
+		if format != c.format || !reflect.DeepEqual(options, c.options) {
+			t.Errorf("encrypt %s: got %s %v, want %s %v", c.in, format, options, c.format, c.options)
+		}
+	}
+}
+
+func TestGetEncryptOptions(t *testing.T) {
+	cases := []struct {
+		img  SQemuImage
+		want []string
+	}{
+		{
+			img:  SQemuImage{Path: "/disk", Format: QCOW2},
+			want: []string{},
+		},
+		{
+			img:  SQemuImage{Path: "/disk", Format: QCOW2, Encryption: true},
+			want: []string{"encrypt.key-secret=sec_1"},
+		},
+		{
+			img:  SQemuImage{Path: "rbd:pool/disk", Format: LUKS, Encryption: true},
+			want: []string{"key-secret=sec_1"},
+		},
+	}
+	for _, c := range cases {
+		got, err := c.img.GetEncryptOptions("sec_1")
+		if err != nil {
+			t.Errorf("%s: %v", c.img.Path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.img.Path, got, c.want)
+		}
+	}
+}
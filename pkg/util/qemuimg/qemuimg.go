@@ -58,6 +58,8 @@ type SQemuImage struct {
 	BackFilePath    string
 	Compat          string
 	Encryption      bool
+	EncryptFormat   TEncryptFormat
+	EncryptKey      string
 	Subformat       string
 	IoLevel         TIONiceLevel
 }
@@ -188,6 +190,9 @@ func (img *SQemuImage) parse() error {
 				}
 			case strings.HasPrefix(line, "create type:"):
 				img.Subformat = line[strings.LastIndexByte(line, ' ')+1:]
+			case img.Encryption && strings.HasPrefix(line, "format:"):
+				// format of the encrypt section in format specific information
+				img.EncryptFormat = TEncryptFormat(line[strings.LastIndexByte(line, ' ')+1:])
 			}
 		}
 		if err != nil {
@@ -195,6 +200,10 @@ func (img *SQemuImage) parse() error {
 			return fmt.Errorf("read output fail %s", err)
 		}
 	}
+	if img.Format == LUKS {
+		img.Encryption = true
+		img.EncryptFormat = ENCRYPT_FORMAT_LUKS
+	}
 	if img.Format == RAW && fileutils2.IsFile(img.Path) {
 		// test if it is an ISO
 		blkType := fileutils2.GetBlkidType(img.Path)
@@ -217,6 +226,12 @@ func (img *SQemuImage) doConvert(name string, format TImageFormat, options []str
 	if !img.IsValid() {
 		return fmt.Errorf("self is not valid")
 	}
+	if len(img.EncryptKey) > 0 {
+		if len(img.Password) > 0 || len(password) > 0 {
+			return fmt.Errorf("LUKS encrypted image can't be converted with password")
+		}
+		return img.doEncryptedConvert(name, format, options)
+	}
 	cmdline := []string{"-c", strconv.Itoa(int(img.IoLevel)),
 		qemutils.GetQemuImg(), "convert"}
 	if compact {
@@ -266,6 +281,33 @@ func (img *SQemuImage) doConvert(name string, format TImageFormat, options []str
 	return nil
 }
 
+// doEncryptedConvert converts an encrypted image, the output is sealed with
+// the same key, compression is not supported by encrypted qcow2
+func (img *SQemuImage) doEncryptedConvert(name string, format TImageFormat, options []string) error {
+	srcOpts, err := img.imageOpts(true)
+	if err != nil {
+		return errors.Wrap(err, "image opts")
+	}
+	format, encOpts, err := encryptCreateOptions(format)
+	if err != nil {
+		return err
+	}
+	options = append(options, encOpts...)
+	cmdline := []string{"-c", strconv.Itoa(int(img.IoLevel)),
+		qemutils.GetQemuImg(), "convert"}
+	cmdline = append(cmdline, secretArgs()...)
+	cmdline = append(cmdline, "--image-opts", "-O", format.String(),
+		"-o", strings.Join(options, ","), srcOpts, name)
+	cmd := procutils.NewRemoteCommandAsFarAsPossible("ionice", cmdline...)
+	output, err := RunWithSecret(cmd, img.EncryptKey)
+	if err != nil {
+		log.Errorf("convert encrypted image %s fail %s: %s", img.Path, err, output)
+		os.Remove(name)
+		return errors.Wrapf(err, "convert encrypted image: %s", output)
+	}
+	return nil
+}
+
 func (img *SQemuImage) Clone(name string, format TImageFormat, compact bool) (*SQemuImage, error) {
 	switch format {
 	case QCOW2:
@@ -286,7 +328,14 @@ func (img *SQemuImage) clone(name string, format TImageFormat, options []string,
 	if err != nil {
 		return nil, err
 	}
-	return NewQemuImage(name)
+	newImg, err := NewQemuImage(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(img.EncryptKey) > 0 {
+		newImg.SetEncryptKey(img.EncryptKey)
+	}
+	return newImg, nil
 }
 
 func (img *SQemuImage) convert(format TImageFormat, options []string, compact bool, password string) error {
@@ -435,7 +484,20 @@ func (img *SQemuImage) create(sizeMB int, format TImageFormat, options []string)
 		return fmt.Errorf("create: the image is valid??? %s", img.Format)
 	}
 	args := []string{"-c", strconv.Itoa(int(img.IoLevel)),
-		qemutils.GetQemuImg(), "create", "-f", format.String()}
+		qemutils.GetQemuImg(), "create"}
+	if len(img.EncryptKey) > 0 {
+		var (
+			encOpts []string
+			err     error
+		)
+		format, encOpts, err = encryptCreateOptions(format)
+		if err != nil {
+			return err
+		}
+		options = append(options, encOpts...)
+		args = append(args, secretArgs()...)
+	}
+	args = append(args, "-f", format.String())
 	if len(options) > 0 {
 		args = append(args, "-o", strings.Join(options, ","))
 	}
@@ -444,7 +506,15 @@ func (img *SQemuImage) create(sizeMB int, format TImageFormat, options []string)
 		args = append(args, fmt.Sprintf("%dM", sizeMB))
 	}
 	cmd := procutils.NewRemoteCommandAsFarAsPossible("ionice", args...)
-	output, err := cmd.Output()
+	var (
+		output []byte
+		err    error
+	)
+	if len(img.EncryptKey) > 0 {
+		output, err = RunWithSecret(cmd, img.EncryptKey)
+	} else {
+		output, err = cmd.Output()
+	}
 	if err != nil {
 		log.Errorf("%v create error %s %s", args, output, err)
 		return errors.Wrapf(err, "create image failed: %s", output)
@@ -459,6 +529,14 @@ func (img *SQemuImage) CreateQcow2(sizeMB int, compact bool, backPath string) er
 		if !compact {
 			options = append(options, "cluster_size=2M")
 		}
+		if len(img.EncryptKey) > 0 && sizeMB <= 0 {
+			// qemu-img can't open an encrypted backing file to probe the size
+			back, err := NewQemuImage(backPath)
+			if err != nil {
+				return errors.Wrapf(err, "open backing file %s", backPath)
+			}
+			sizeMB = back.GetSizeMB()
+		}
 	} else if !compact {
 		sparseOpts := qcow2SparseOptions()
 		options = append(options, sparseOpts...)
@@ -490,9 +568,20 @@ func (img *SQemuImage) Resize(sizeMB int) error {
 	if !img.IsValid() {
 		return fmt.Errorf("self is not valid")
 	}
-	cmd := procutils.NewRemoteCommandAsFarAsPossible("ionice", "-c", strconv.Itoa(int(img.IoLevel)),
-		qemutils.GetQemuImg(), "resize", img.Path, fmt.Sprintf("%dM", sizeMB))
-	err := cmd.Run()
+	args := []string{"-c", strconv.Itoa(int(img.IoLevel)),
+		qemutils.GetQemuImg(), "resize"}
+	if len(img.EncryptKey) > 0 {
+		imgOpts, err := img.imageOpts(true)
+		if err != nil {
+			return errors.Wrap(err, "image opts")
+		}
+		args = append(args, secretArgs()...)
+		args = append(args, "--image-opts", imgOpts)
+	} else {
+		args = append(args, img.Path)
+	}
+	args = append(args, fmt.Sprintf("%dM", sizeMB))
+	err := img.run(procutils.NewRemoteCommandAsFarAsPossible("ionice", args...))
 	if err != nil {
 		log.Errorf("resize fail %s", err)
 		return err
@@ -509,9 +598,18 @@ func (img *SQemuImage) Rebase(backPath string, force bool) error {
 	if force {
 		args = append(args, "-u")
 	}
-	args = append(args, "-b", backPath, img.Path)
-	cmd := procutils.NewRemoteCommandAsFarAsPossible("ionice", args...)
-	err := cmd.Run()
+	args = append(args, "-b", backPath)
+	if len(img.EncryptKey) > 0 {
+		imgOpts, err := img.imageOpts(!force)
+		if err != nil {
+			return errors.Wrap(err, "image opts")
+		}
+		args = append(args, secretArgs()...)
+		args = append(args, "--image-opts", imgOpts)
+	} else {
+		args = append(args, img.Path)
+	}
+	err := img.run(procutils.NewRemoteCommandAsFarAsPossible("ionice", args...))
 	if err != nil {
 		log.Errorf("rebase fail %s", err)
 		return err
@@ -519,6 +617,17 @@ func (img *SQemuImage) Rebase(backPath string, force bool) error {
 	return img.parse()
 }
 
+func (img *SQemuImage) run(cmd *procutils.Command) error {
+	if len(img.EncryptKey) == 0 {
+		return cmd.Run()
+	}
+	output, err := RunWithSecret(cmd, img.EncryptKey)
+	if err != nil {
+		return errors.Wrapf(err, "%s", output)
+	}
+	return nil
+}
+
 func (img *SQemuImage) Delete() error {
 	if !img.IsValid() {
 		return nil
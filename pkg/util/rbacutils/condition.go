@@ -0,0 +1,349 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbacutils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+)
+
+// Conditions restrict an allow rule to the requests satisfying all of them,
+// a conditional rule is a leaf of policy in the form of
+//
+//	{"result": "allow", "conditions": [{"key": "tag:env", "op": "not_in", "values": ["prod"]}]}
+//
+// the condition keys are attributes of the resource (tag:<key>, owner:*),
+// the request time (time:*) and the caller (caller:*). A value prefixed
+// by $ refers to the caller attribute, e.g. $caller:user_id
+type TConditionOperator string
+
+const (
+	ConditionIn        = TConditionOperator("in")
+	ConditionNotIn     = TConditionOperator("not_in")
+	ConditionExists    = TConditionOperator("exists")
+	ConditionNotExists = TConditionOperator("not_exists")
+	// values[0] <= value < values[1] in string order, the range wraps
+	// around if values[0] > values[1], e.g. 22:00-06:00
+	ConditionBetween = TConditionOperator("between")
+
+	ConditionKeyTagPrefix      = "tag:"
+	ConditionKeyOwnerProjectId = "owner:project_id"
+	ConditionKeyOwnerDomainId  = "owner:domain_id"
+	ConditionKeyOwnerUserId    = "owner:user_id"
+
+	// mon, tue, wed, thu, fri, sat, sun
+	ConditionKeyTimeWeekday = "time:weekday"
+	// hh:mm of the local time
+	ConditionKeyTimeClock = "time:clock"
+
+	ConditionKeyCallerUserId    = "caller:user_id"
+	ConditionKeyCallerUser      = "caller:user"
+	ConditionKeyCallerProjectId = "caller:project_id"
+	ConditionKeyCallerProject   = "caller:project"
+	ConditionKeyCallerDomainId  = "caller:domain_id"
+	ConditionKeyCallerDomain    = "caller:domain"
+	ConditionKeyCallerRole      = "caller:role"
+
+	conditionValueRefPrefix = "$"
+)
+
+var (
+	conditionResourceKeys = []string{
+		ConditionKeyOwnerProjectId,
+		ConditionKeyOwnerDomainId,
+		ConditionKeyOwnerUserId,
+	}
+	conditionRequestKeys = []string{
+		ConditionKeyTimeWeekday,
+		ConditionKeyTimeClock,
+	}
+	conditionCallerKeys = []string{
+		ConditionKeyCallerUserId,
+		ConditionKeyCallerUser,
+		ConditionKeyCallerProjectId,
+		ConditionKeyCallerProject,
+		ConditionKeyCallerDomainId,
+		ConditionKeyCallerDomain,
+		ConditionKeyCallerRole,
+	}
+)
+
+type SRbacCondition struct {
+	Key      string             `json:"key"`
+	Operator TConditionOperator `json:"op"`
+	Values   []string           `json:"values"`
+}
+
+type TRbacConditions []SRbacCondition
+
+// TRbacConditionSet is the conditions of alternative allow rules, the
+// request is allowed if any of them is satisfied
+type TRbacConditionSet []TRbacConditions
+
+type IRbacConditionCaller interface {
+	GetUserId() string
+	GetUserName() string
+	GetProjectId() string
+	GetProjectName() string
+	GetProjectDomainId() string
+	GetProjectDomain() string
+	GetRoles() []string
+}
+
+type IRbacConditionResource interface {
+	// GetConditionAttribute returns the values of resource attribute and
+	// whether the attribute exists
+	GetConditionAttribute(key string) ([]string, bool)
+}
+
+type SRbacConditionContext struct {
+	Time     time.Time
+	Caller   IRbacConditionCaller
+	Resource IRbacConditionResource
+}
+
+func IsResourceConditionKey(key string) bool {
+	return strings.HasPrefix(key, ConditionKeyTagPrefix) || contains(conditionResourceKeys, key)
+}
+
+func isValidConditionKey(key string) bool {
+	if strings.HasPrefix(key, ConditionKeyTagPrefix) {
+		return len(key) > len(ConditionKeyTagPrefix)
+	}
+	return contains(conditionResourceKeys, key) || contains(conditionRequestKeys, key) || contains(conditionCallerKeys, key)
+}
+
+func (cond SRbacCondition) Validate() error {
+	if !isValidConditionKey(cond.Key) {
+		return errors.Wrapf(ErrInvalidCondition, "unknown key %q", cond.Key)
+	}
+	switch cond.Operator {
+	case ConditionIn, ConditionNotIn:
+		if len(cond.Values) == 0 {
+			return errors.Wrapf(ErrInvalidCondition, "%s %s requires values", cond.Key, cond.Operator)
+		}
+	case ConditionBetween:
+		if len(cond.Values) != 2 {
+			return errors.Wrapf(ErrInvalidCondition, "%s %s requires 2 values", cond.Key, cond.Operator)
+		}
+	case ConditionExists, ConditionNotExists:
+		if len(cond.Values) > 0 {
+			return errors.Wrapf(ErrInvalidCondition, "%s %s accepts no values", cond.Key, cond.Operator)
+		}
+	default:
+		return errors.Wrapf(ErrInvalidCondition, "unknown operator %q", cond.Operator)
+	}
+	for _, v := range cond.Values {
+		if strings.HasPrefix(v, conditionValueRefPrefix) && !contains(conditionCallerKeys, v[len(conditionValueRefPrefix):]) {
+			return errors.Wrapf(ErrInvalidCondition, "invalid reference %q, only caller attributes are allowed", v)
+		}
+	}
+	return nil
+}
+
+func (cond SRbacCondition) String() string {
+	return fmt.Sprintf("%s %s %s", cond.Key, cond.Operator, strings.Join(cond.Values, ","))
+}
+
+func singleAttribute(val string) ([]string, bool) {
+	if len(val) == 0 {
+		return nil, false
+	}
+	return []string{val}, true
+}
+
+func (ctx *SRbacConditionContext) getCallerAttribute(key string) ([]string, bool) {
+	if ctx.Caller == nil {
+		return nil, false
+	}
+	switch key {
+	case ConditionKeyCallerUserId:
+		return singleAttribute(ctx.Caller.GetUserId())
+	case ConditionKeyCallerUser:
+		return singleAttribute(ctx.Caller.GetUserName())
+	case ConditionKeyCallerProjectId:
+		return singleAttribute(ctx.Caller.GetProjectId())
+	case ConditionKeyCallerProject:
+		return singleAttribute(ctx.Caller.GetProjectName())
+	case ConditionKeyCallerDomainId:
+		return singleAttribute(ctx.Caller.GetProjectDomainId())
+	case ConditionKeyCallerDomain:
+		return singleAttribute(ctx.Caller.GetProjectDomain())
+	case ConditionKeyCallerRole:
+		roles := ctx.Caller.GetRoles()
+		return roles, len(roles) > 0
+	}
+	return nil, false
+}
+
+func (ctx *SRbacConditionContext) getAttribute(key string) ([]string, bool) {
+	switch {
+	case key == ConditionKeyTimeWeekday:
+		return singleAttribute(strings.ToLower(ctx.Time.Weekday().String()[:3]))
+	case key == ConditionKeyTimeClock:
+		return singleAttribute(ctx.Time.Format("15:04"))
+	case contains(conditionCallerKeys, key):
+		return ctx.getCallerAttribute(key)
+	case IsResourceConditionKey(key):
+		if ctx.Resource == nil {
+			return nil, false
+		}
+		return ctx.Resource.GetConditionAttribute(key)
+	}
+	return nil, false
+}
+
+// resolveValues replaces the references to caller attributes with their values
+func (cond SRbacCondition) resolveValues(ctx *SRbacConditionContext) []string {
+	ret := make([]string, 0, len(cond.Values))
+	for _, v := range cond.Values {
+		if strings.HasPrefix(v, conditionValueRefPrefix) {
+			vals, _ := ctx.getCallerAttribute(v[len(conditionValueRefPrefix):])
+			ret = append(ret, vals...)
+		} else {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+func IsBetween(val string, start string, end string) bool {
+	if start <= end {
+		return start <= val && val < end
+	}
+	return start <= val || val < end
+}
+
+func (cond SRbacCondition) matchValues(attrs []string, exists bool, values []string) bool {
+	switch cond.Operator {
+	case ConditionExists:
+		return exists
+	case ConditionNotExists:
+		return !exists
+	case ConditionIn:
+		return exists && intersect(attrs, values)
+	case ConditionNotIn:
+		return !exists || !intersect(attrs, values)
+	case ConditionBetween:
+		if !exists || len(values) != 2 {
+			return false
+		}
+		for _, attr := range attrs {
+			if IsBetween(attr, values[0], values[1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (cond SRbacCondition) Match(ctx *SRbacConditionContext) bool {
+	if IsResourceConditionKey(cond.Key) && ctx.Resource == nil {
+		// resource attributes are unknown, never matches
+		return false
+	}
+	attrs, exists := ctx.getAttribute(cond.Key)
+	return cond.matchValues(attrs, exists, cond.resolveValues(ctx))
+}
+
+func (conds TRbacConditions) Match(ctx *SRbacConditionContext) bool {
+	for i := range conds {
+		if !conds[i].Match(ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveRequest evaluates the conditions on request time and caller,
+// returns the remaining resource conditions with references resolved and
+// whether the request conditions are satisfied
+func (conds TRbacConditions) ResolveRequest(ctx *SRbacConditionContext) (TRbacConditions, bool) {
+	ret := make(TRbacConditions, 0)
+	for i := range conds {
+		if IsResourceConditionKey(conds[i].Key) {
+			cond := conds[i]
+			cond.Values = cond.resolveValues(ctx)
+			ret = append(ret, cond)
+		} else if !conds[i].Match(ctx) {
+			return nil, false
+		}
+	}
+	return ret, true
+}
+
+func (conds TRbacConditions) Validate() error {
+	for i := range conds {
+		if err := conds[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (conds TRbacConditions) Equals(conds2 TRbacConditions) bool {
+	return conds.String() == conds2.String()
+}
+
+func (conds TRbacConditions) String() string {
+	strs := make([]string, len(conds))
+	for i := range conds {
+		strs[i] = conds[i].String()
+	}
+	return strings.Join(strs, " && ")
+}
+
+func (conds TRbacConditions) Encode() jsonutils.JSONObject {
+	ret := jsonutils.NewArray()
+	for i := range conds {
+		cond := jsonutils.NewDict()
+		cond.Add(jsonutils.NewString(conds[i].Key), "key")
+		cond.Add(jsonutils.NewString(string(conds[i].Operator)), "op")
+		if len(conds[i].Values) > 0 {
+			cond.Add(jsonutils.NewStringArray(conds[i].Values), "values")
+		}
+		ret.Add(cond)
+	}
+	return ret
+}
+
+func DecodeConditions(input jsonutils.JSONObject) (TRbacConditions, error) {
+	conds := make(TRbacConditions, 0)
+	err := input.Unmarshal(&conds)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidCondition, err.Error())
+	}
+	if len(conds) == 0 {
+		return nil, errors.Wrap(ErrInvalidCondition, "empty conditions")
+	}
+	err = conds.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return conds, nil
+}
+
+func (set TRbacConditionSet) Match(ctx *SRbacConditionContext) bool {
+	for i := range set {
+		if set[i].Match(ctx) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbacutils
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+)
+
+type sTestConditionCaller struct {
+	userId    string
+	projectId string
+	roles     []string
+}
+
+func (c sTestConditionCaller) GetUserId() string          { return c.userId }
+func (c sTestConditionCaller) GetUserName() string        { return "" }
+func (c sTestConditionCaller) GetProjectId() string       { return c.projectId }
+func (c sTestConditionCaller) GetProjectName() string     { return "" }
+func (c sTestConditionCaller) GetProjectDomainId() string { return "default" }
+func (c sTestConditionCaller) GetProjectDomain() string   { return "Default" }
+func (c sTestConditionCaller) GetRoles() []string         { return c.roles }
+
+type sTestConditionResource map[string]string
+
+func (r sTestConditionResource) GetConditionAttribute(key string) ([]string, bool) {
+	val, ok := r[key]
+	if !ok {
+		return nil, false
+	}
+	return []string{val}, true
+}
+
+func TestConditionalPolicyJson(t *testing.T) {
+	policyJson, err := jsonutils.ParseString(`{"compute":{"servers":{"get":"allow","perform":{"stop":{"result":"allow","conditions":[{"key":"tag:env","op":"not_in","values":["prod"]}]}}}}}`)
+	if err != nil {
+		t.Fatalf("ParseString: %s", err)
+	}
+	policy, err := DecodePolicy(policyJson)
+	if err != nil {
+		t.Fatalf("DecodePolicy: %s", err)
+	}
+	stopRule := policy.GetMatchRule("compute", "servers", "perform", "stop")
+	if stopRule == nil || stopRule.Result != Allow || len(stopRule.Conditions) != 1 || stopRule.Conditions[0].Key != "tag:env" {
+		t.Fatalf("unexpected match rule %#v", stopRule)
+	}
+	rule := policy.GetMatchRule("compute", "servers", "get")
+	if rule == nil || len(rule.Conditions) != 0 {
+		t.Fatalf("unexpected match rule %#v", rule)
+	}
+
+	policy2, err := DecodePolicy(policy.Encode())
+	if err != nil {
+		t.Fatalf("DecodePolicy encoded: %s", err)
+	}
+	rule = policy2.GetMatchRule("compute", "servers", "perform", "stop")
+	if rule == nil || !rule.Conditions.Equals(stopRule.Conditions) {
+		t.Fatalf("conditions lost after encode: %s", policy2.Encode())
+	}
+
+	// conditional rules are never merged
+	rules := reduceRules(append(policy, SRbacRule{Service: "compute", Resource: "servers", Action: "perform", Extra: []string{"start"}, Result: Allow}))
+	found := false
+	for i := range rules {
+		if len(rules[i].Conditions) > 0 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("conditional rule reduced: %s", jsonutils.Marshal(rules))
+	}
+}
+
+func TestDecodeConditionsInvalid(t *testing.T) {
+	cases := []string{
+		`[]`,
+		`[{"key":"unknown","op":"in","values":["a"]}]`,
+		`[{"key":"tag:","op":"exists"}]`,
+		`[{"key":"tag:env","op":"equals","values":["a"]}]`,
+		`[{"key":"tag:env","op":"in"}]`,
+		`[{"key":"time:clock","op":"between","values":["09:00"]}]`,
+		`[{"key":"tag:env","op":"exists","values":["a"]}]`,
+		`[{"key":"owner:user_id","op":"in","values":["$owner:project_id"]}]`,
+	}
+	for _, c := range cases {
+		json, _ := jsonutils.ParseString(c)
+		if _, err := DecodeConditions(json); err == nil {
+			t.Errorf("%s should be invalid", c)
+		}
+	}
+
+	json, _ := jsonutils.ParseString(`{"result":"deny","conditions":[{"key":"tag:env","op":"exists"}]}`)
+	if _, err := DecodePolicy(json); err == nil {
+		t.Errorf("conditional deny should be invalid")
+	}
+}
+
+func TestConditionsMatch(t *testing.T) {
+	// Monday
+	workTime := time.Date(2020, 6, 1, 10, 30, 0, 0, time.Local)
+	nightTime := time.Date(2020, 6, 1, 23, 0, 0, 0, time.Local)
+	weekend := time.Date(2020, 6, 6, 10, 30, 0, 0, time.Local)
+	caller := sTestConditionCaller{userId: "u1", projectId: "p1", roles: []string{"member"}}
+
+	businessHours := TRbacConditions{
+		{Key: ConditionKeyTimeWeekday, Operator: ConditionIn, Values: []string{"mon", "tue", "wed", "thu", "fri"}},
+		{Key: ConditionKeyTimeClock, Operator: ConditionBetween, Values: []string{"09:00", "18:00"}},
+	}
+	notProd := TRbacConditions{
+		{Key: "tag:env", Operator: ConditionNotIn, Values: []string{"prod"}},
+	}
+	ownedBySelf := TRbacConditions{
+		{Key: ConditionKeyOwnerUserId, Operator: ConditionIn, Values: []string{"$" + ConditionKeyCallerUserId}},
+	}
+	nightWindow := TRbacConditions{
+		{Key: ConditionKeyTimeClock, Operator: ConditionBetween, Values: []string{"22:00", "06:00"}},
+	}
+	adminOnly := TRbacConditions{
+		{Key: ConditionKeyCallerRole, Operator: ConditionIn, Values: []string{"admin"}},
+	}
+
+	cases := []struct {
+		name     string
+		conds    TRbacConditions
+		time     time.Time
+		resource IRbacConditionResource
+		want     bool
+	}{
+		{"business hours", businessHours, workTime, nil, true},
+		{"business hours at night", businessHours, nightTime, nil, false},
+		{"business hours on weekend", businessHours, weekend, nil, false},
+		{"night window", nightWindow, nightTime, nil, true},
+		{"night window at work", nightWindow, workTime, nil, false},
+		{"not prod untagged", notProd, workTime, sTestConditionResource{}, true},
+		{"not prod on dev", notProd, workTime, sTestConditionResource{"tag:env": "dev"}, true},
+		{"not prod on prod", notProd, workTime, sTestConditionResource{"tag:env": "prod"}, false},
+		{"not prod without resource", notProd, workTime, nil, false},
+		{"owned by self", ownedBySelf, workTime, sTestConditionResource{ConditionKeyOwnerUserId: "u1"}, true},
+		{"owned by other", ownedBySelf, workTime, sTestConditionResource{ConditionKeyOwnerUserId: "u2"}, false},
+		{"admin only", adminOnly, workTime, nil, false},
+	}
+	for _, c := range cases {
+		ctx := &SRbacConditionContext{Time: c.time, Caller: caller, Resource: c.resource}
+		if got := c.conds.Match(ctx); got != c.want {
+			t.Errorf("%s: want %v got %v", c.name, c.want, got)
+		}
+	}
+
+	set := TRbacConditionSet{adminOnly, businessHours}
+	if !set.Match(&SRbacConditionContext{Time: workTime, Caller: caller}) {
+		t.Errorf("condition set should match business hours")
+	}
+}
+
+func TestConditionsResolveRequest(t *testing.T) {
+	caller := sTestConditionCaller{userId: "u1", projectId: "p1"}
+	conds := TRbacConditions{
+		{Key: ConditionKeyTimeClock, Operator: ConditionBetween, Values: []string{"09:00", "18:00"}},
+		{Key: ConditionKeyOwnerProjectId, Operator: ConditionIn, Values: []string{"$" + ConditionKeyCallerProjectId, "p2"}},
+	}
+	ctx := &SRbacConditionContext{Time: time.Date(2020, 6, 1, 10, 30, 0, 0, time.Local), Caller: caller}
+	resConds, ok := conds.ResolveRequest(ctx)
+	if !ok {
+		t.Fatalf("request conditions should be satisfied")
+	}
+	if len(resConds) != 1 || resConds[0].Key != ConditionKeyOwnerProjectId || len(resConds[0].Values) != 2 || resConds[0].Values[0] != "p1" {
+		t.Errorf("unexpected resource conditions %s", resConds)
+	}
+
+	ctx.Time = time.Date(2020, 6, 1, 20, 0, 0, 0, time.Local)
+	if _, ok := conds.ResolveRequest(ctx); ok {
+		t.Errorf("request conditions should not be satisfied")
+	}
+}
@@ -24,4 +24,6 @@ var (
 	ErrConflict = errors.New("conflict?")
 
 	ErrInvalidRules = errors.New("invalid rules")
+
+	ErrInvalidCondition = errors.New("invalid condition")
 )
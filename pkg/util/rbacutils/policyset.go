@@ -89,7 +89,7 @@ func (policies TPolicySet) violatedByPolicy(policy TPolicy, expect TRbacResult)
 		}
 		matchRules := policies.GetMatchRules(rule.Service, rule.Resource, rule.Action, rule.Extra...)
 		matchRule := GetMatchRule(matchRules, rule.Service, rule.Resource, rule.Action, rule.Extra...)
-		if expect == Allow && (matchRule == nil || matchRule.Result == Deny) {
+		if expect == Allow && (matchRule == nil || matchRule.Result == Deny || (len(matchRule.Conditions) > 0 && !matchRule.Conditions.Equals(rule.Conditions))) {
 			return true
 		} else if expect == Deny && matchRule != nil && matchRule.Result == Allow {
 			return true
@@ -90,6 +90,8 @@ type SRbacRule struct {
 	Action   string
 	Extra    []string
 	Result   TRbacResult
+	// the rule allows only if all conditions are satisfied
+	Conditions TRbacConditions
 }
 
 func (r SRbacRule) clone() SRbacRule {
@@ -98,6 +100,10 @@ func (r SRbacRule) clone() SRbacRule {
 	if len(r.Extra) > 0 {
 		copy(nr.Extra, r.Extra)
 	}
+	if len(r.Conditions) > 0 {
+		nr.Conditions = make(TRbacConditions, len(r.Conditions))
+		copy(nr.Conditions, r.Conditions)
+	}
 	return nr
 }
 
@@ -125,6 +131,9 @@ func (rule *SRbacRule) contains(rule2 *SRbacRule) bool {
 	if string(rule.Result) != string(rule2.Result) {
 		return false
 	}
+	if !rule.Conditions.Equals(rule2.Conditions) {
+		return false
+	}
 	return true
 }
 
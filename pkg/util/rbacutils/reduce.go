@@ -28,6 +28,7 @@ type sRbacNode struct {
 	defNode    *sRbacNode
 	downStream map[string]*sRbacNode
 	result     *TRbacResult
+	conditions TRbacConditions
 	level      int
 }
 
@@ -51,7 +52,9 @@ func (n *sRbacNode) addRule(rule SRbacRule, level int) {
 			}
 			n.defNode = newRbacNode(n.level + 1)
 			n.defNode.result = n.result
+			n.defNode.conditions = n.conditions
 			n.result = nil
+			n.conditions = nil
 		}
 		var key string
 		if level == levelService {
@@ -83,11 +86,12 @@ func (n *sRbacNode) addRule(rule SRbacRule, level int) {
 			log.Warningf("node has been occupide!!!")
 		}
 		n.result = &rule.Result
+		n.conditions = rule.Conditions
 	}
 }
 
 func (n *sRbacNode) isLeaf() bool {
-	return n.result != nil && n.defNode == nil && len(n.downStream) == 0
+	return n.result != nil && len(n.conditions) == 0 && n.defNode == nil && len(n.downStream) == 0
 }
 
 func (n *sRbacNode) reduceDownstream() {
@@ -95,7 +99,8 @@ func (n *sRbacNode) reduceDownstream() {
 	denyKey := make([]string, 0)
 	skipKey := make([]string, 0)
 	for k, v := range n.downStream {
-		if v.result == nil {
+		if v.result == nil || len(v.conditions) > 0 {
+			// conditional rules are never merged
 			skipKey = append(skipKey, k)
 			continue
 		}
@@ -192,6 +197,7 @@ func (n *sRbacNode) getRules(seed SRbacRule, level int) []SRbacRule {
 	if n.result != nil {
 		rule := seed.clone()
 		rule.Result = *n.result
+		rule.Conditions = n.conditions
 		return []SRbacRule{rule}
 	} else {
 		if n.defNode != nil {
@@ -257,6 +263,12 @@ func json2Rules(json jsonutils.JSONObject) ([]SRbacRule, error) {
 func (n *sRbacNode) json() jsonutils.JSONObject {
 	var result jsonutils.JSONObject
 	if n.result != nil {
+		if len(n.conditions) > 0 {
+			leaf := jsonutils.NewDict()
+			leaf.Add(jsonutils.NewString(string(*n.result)), "result")
+			leaf.Add(n.conditions.Encode(), "conditions")
+			return leaf
+		}
 		return jsonutils.NewString(string(*n.result))
 	} else {
 		result = jsonutils.NewDict()
@@ -277,15 +289,13 @@ func (n *sRbacNode) parseJson(input jsonutils.JSONObject) error {
 		if err != nil {
 			return errors.Wrap(err, "val.GetString")
 		}
-		var result TRbacResult
-		switch ruleStr {
-		case string(Allow), string(AdminAllow), string(OwnerAllow), string(UserAllow), string(GuestAllow):
-			result = Allow
-		default:
-			result = Deny
-		}
+		result := parseResult(ruleStr)
 		n.result = &result
 	case *jsonutils.JSONDict:
+		if val.Contains("result") && val.Contains("conditions") {
+			// conditional leaf
+			return n.parseConditionalJson(val)
+		}
 		ruleJsonDict, err := val.GetMap()
 		if err != nil {
 			return errors.Wrap(err, "val.GetMap")
@@ -310,3 +320,31 @@ func (n *sRbacNode) parseJson(input jsonutils.JSONObject) error {
 	}
 	return nil
 }
+
+func parseResult(ruleStr string) TRbacResult {
+	switch ruleStr {
+	case string(Allow), string(AdminAllow), string(OwnerAllow), string(UserAllow), string(GuestAllow):
+		return Allow
+	default:
+		return Deny
+	}
+}
+
+func (n *sRbacNode) parseConditionalJson(input *jsonutils.JSONDict) error {
+	ruleStr, err := input.GetString("result")
+	if err != nil {
+		return errors.Wrap(err, "GetString result")
+	}
+	result := parseResult(ruleStr)
+	if result != Allow {
+		return errors.Wrap(ErrInvalidCondition, "conditions only apply to allow rules")
+	}
+	condJson, _ := input.Get("conditions")
+	conds, err := DecodeConditions(condJson)
+	if err != nil {
+		return errors.Wrap(err, "DecodeConditions")
+	}
+	n.result = &result
+	n.conditions = conds
+	return nil
+}
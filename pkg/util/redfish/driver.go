@@ -71,7 +71,19 @@ type IRedfishDriver interface {
 
 	BmcReset(ctx context.Context) error
 
+	GetBiosSettingsPath(biosPath string, bios jsonutils.JSONObject) string
 	GetBiosInfo(ctx context.Context) (SBiosInfo, error)
+	// stage BIOS attributes, which take effect after next system reset
+	SetBiosAttributes(ctx context.Context, attrs jsonutils.JSONObject) error
+
+	// push firmware via UpdateService, return the path to track the update task
+	SimpleUpdate(ctx context.Context, params SFirmwareUpdateParams) (string, error)
+	GetTaskInfo(ctx context.Context, path string) (STaskInfo, error)
+
+	GetStorages(ctx context.Context) ([]SStorageInfo, error)
+	// return the task path if the operation is asynchronous
+	CreateVolume(ctx context.Context, params SVolumeCreateParams) (string, error)
+	DeleteVolume(ctx context.Context, storage string, volume string) (string, error)
 
 	GetIndicatorLED(ctx context.Context) (bool, error)
 	SetIndicatorLED(ctx context.Context, on bool) error
@@ -85,6 +97,11 @@ type IRedfishDriver interface {
 	SetNTPConf(ctx context.Context, conf SNTPConf) error
 
 	GetConsoleJNLP(ctx context.Context) (string, error)
+
+	GetEventSubscriptions(ctx context.Context) ([]SEventSubscription, error)
+	// subscribe events pushed to destination, return the subscription path
+	SubscribeEvents(ctx context.Context, destination string, context string) (string, error)
+	UnsubscribeEvents(ctx context.Context, path string) error
 }
 
 var defaultFactory IRedfishDriverFactory
@@ -0,0 +1,286 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/util/redfish"
+	"yunion.io/x/onecloud/pkg/util/redfish/test"
+)
+
+const (
+	testSystem = `{
+		"@odata.id": "/redfish/v1/Systems/1",
+		"Id": "1",
+		"Bios": {"@odata.id": "/redfish/v1/Systems/1/Bios"},
+		"Storage": {"@odata.id": "/redfish/v1/Systems/1/Storage"}
+	}`
+	testUpdateService = `{
+		"@odata.id": "/redfish/v1/UpdateService",
+		"Actions": {
+			"#UpdateService.SimpleUpdate": {
+				"target": "/redfish/v1/UpdateService/Actions/UpdateService.SimpleUpdate",
+				"TransferProtocol@Redfish.AllowableValues": ["HTTP", "HTTPS"]
+			}
+		}
+	}`
+)
+
+var testResources = map[string]string{
+	"/redfish/v1/Systems":       `{"Members": [{"@odata.id": "/redfish/v1/Systems/1"}]}`,
+	"/redfish/v1/Systems/1":     testSystem,
+	"/redfish/v1/UpdateService": testUpdateService,
+	"/redfish/v1/Systems/1/Bios": `{
+		"@odata.id": "/redfish/v1/Systems/1/Bios",
+		"AttributeRegistry": "BiosAttributeRegistry.1.0.0",
+		"Attributes": {"BootMode": "Bios", "ProcVirtualization": "Enabled"},
+		"@Redfish.Settings": {"SettingsObject": {"@odata.id": "/redfish/v1/Systems/1/Bios/Pending"}}
+	}`,
+	"/redfish/v1/Systems/1/Bios/Pending": `{"Attributes": {"BootMode": "Uefi"}}`,
+	"/redfish/v1/Systems/1/Storage":      `{"Members": [{"@odata.id": "/redfish/v1/Systems/1/Storage/1"}]}`,
+	"/redfish/v1/Systems/1/Storage/1": `{
+		"@odata.id": "/redfish/v1/Systems/1/Storage/1",
+		"Id": "1",
+		"Name": "RAID Controller",
+		"Drives": [
+			{"@odata.id": "/redfish/v1/Systems/1/Storage/1/Drives/0"},
+			{"@odata.id": "/redfish/v1/Systems/1/Storage/1/Drives/1"}
+		],
+		"Volumes": {"@odata.id": "/redfish/v1/Systems/1/Storage/1/Volumes"}
+	}`,
+	"/redfish/v1/Systems/1/Storage/1/Drives/0": `{"Id": "0", "Name": "Drive 0", "Model": "ST1000NX0443", "MediaType": "HDD", "Protocol": "SATA", "CapacityBytes": 1000204886016, "PhysicalLocation": {"PartLocation": {"ServiceLabel": "Slot 0"}}}`,
+	"/redfish/v1/Systems/1/Storage/1/Drives/1": `{"Id": "1", "Name": "Drive 1", "Model": "ST1000NX0443", "MediaType": "HDD", "Protocol": "SATA", "CapacityBytes": 1000204886016}`,
+	"/redfish/v1/Systems/1/Storage/1/Volumes":  `{"Members": [{"@odata.id": "/redfish/v1/Systems/1/Storage/1/Volumes/0"}]}`,
+	"/redfish/v1/Systems/1/Storage/1/Volumes/0": `{
+		"Id": "0",
+		"Name": "os",
+		"VolumeType": "Mirrored",
+		"CapacityBytes": 999653638144,
+		"Status": {"Health": "OK"},
+		"Links": {"Drives": [{"@odata.id": "/redfish/v1/Systems/1/Storage/1/Drives/0"}, {"@odata.id": "/redfish/v1/Systems/1/Storage/1/Drives/1"}]}
+	}`,
+}
+
+func newTestApi(bmc *test.SFakeBMC) *SGenericRefishApi {
+	api := NewGenericRedfishApi(bmc.URL, "root", "password", false).(*SGenericRefishApi)
+	api.Registries = map[string]string{
+		"Systems":       "/redfish/v1/Systems",
+		"UpdateService": "/redfish/v1/UpdateService",
+	}
+	return api
+}
+
+func assertRequests(t *testing.T, name string, got []test.SRequest, want []test.SRequest) {
+	if len(got) != len(want) {
+		t.Errorf("%s: want %d requests, got %d", name, len(want), len(got))
+		return
+	}
+	for i := range want {
+		if got[i].Method != want[i].Method || got[i].Path != want[i].Path {
+			t.Errorf("%s: want %s %s, got %s %s", name, want[i].Method, want[i].Path, got[i].Method, got[i].Path)
+		}
+		if !test.SameBody(got[i].Body, want[i].Body) {
+			t.Errorf("%s: want body %s, got %s", name, want[i].Body, got[i].Body)
+		}
+	}
+}
+
+func parseJSON(t *testing.T, str string) jsonutils.JSONObject {
+	obj, err := jsonutils.ParseString(str)
+	if err != nil {
+		t.Fatalf("parse %s: %v", str, err)
+	}
+	return obj
+}
+
+func TestSimpleUpdate(t *testing.T) {
+	const actionPath = "/redfish/v1/UpdateService/Actions/UpdateService.SimpleUpdate"
+	cases := []struct {
+		name     string
+		params   redfish.SFirmwareUpdateParams
+		reply    test.SReply
+		wantBody string
+		wantTask string
+		wantErr  bool
+	}{
+		{
+			name: "task in location",
+			params: redfish.SFirmwareUpdateParams{
+				ImageURI: "http://10.168.26.2/firmware/bios-2.8.2.exe",
+			},
+			reply:    test.SReply{StatusCode: 202, Location: "https://10.168.26.10/redfish/v1/TaskService/Tasks/1"},
+			wantBody: `{"ImageURI": "http://10.168.26.2/firmware/bios-2.8.2.exe"}`,
+			wantTask: "/redfish/v1/TaskService/Tasks/1",
+		},
+		{
+			name: "task in body",
+			params: redfish.SFirmwareUpdateParams{
+				ImageURI:         "10.168.26.2/firmware/bios-2.8.2.exe",
+				TransferProtocol: "HTTPS",
+				Targets:          []string{"/redfish/v1/UpdateService/FirmwareInventory/BIOS"},
+			},
+			reply:    test.SReply{StatusCode: 202, Body: `{"@odata.id": "/redfish/v1/TaskService/Tasks/2", "TaskState": "New"}`},
+			wantBody: `{"ImageURI": "10.168.26.2/firmware/bios-2.8.2.exe", "TransferProtocol": "HTTPS", "Targets": ["/redfish/v1/UpdateService/FirmwareInventory/BIOS"]}`,
+			wantTask: "/redfish/v1/TaskService/Tasks/2",
+		},
+		{
+			name: "synchronous update",
+			params: redfish.SFirmwareUpdateParams{
+				ImageURI: "http://10.168.26.2/firmware/bios-2.8.2.exe",
+			},
+			wantBody: `{"ImageURI": "http://10.168.26.2/firmware/bios-2.8.2.exe"}`,
+		},
+		{
+			name: "unsupported protocol",
+			params: redfish.SFirmwareUpdateParams{
+				ImageURI:         "10.168.26.2/firmware/bios-2.8.2.exe",
+				TransferProtocol: "TFTP",
+			},
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		replies := map[string]test.SReply{}
+		if c.reply.StatusCode > 0 {
+			replies["POST "+actionPath] = c.reply
+		}
+		bmc := test.NewFakeBMC(testResources, replies)
+		task, err := newTestApi(bmc).SimpleUpdate(context.Background(), c.params)
+		bmc.Close()
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: want error", c.name)
+			}
+			assertRequests(t, c.name, bmc.Requests(), nil)
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: SimpleUpdate: %v", c.name, err)
+			continue
+		}
+		if task != c.wantTask {
+			t.Errorf("%s: want task %q, got %q", c.name, c.wantTask, task)
+		}
+		assertRequests(t, c.name, bmc.Requests(), []test.SRequest{
+			{Method: "POST", Path: actionPath, Body: parseJSON(t, c.wantBody)},
+		})
+	}
+}
+
+func TestBios(t *testing.T) {
+	bmc := test.NewFakeBMC(testResources, nil)
+	defer bmc.Close()
+	api := newTestApi(bmc)
+
+	info, err := api.GetBiosInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GetBiosInfo: %v", err)
+	}
+	if info.Path != "/redfish/v1/Systems/1/Bios" || info.SettingsPath != "/redfish/v1/Systems/1/Bios/Pending" {
+		t.Errorf("want bios paths /redfish/v1/Systems/1/Bios and /redfish/v1/Systems/1/Bios/Pending, got %s and %s", info.Path, info.SettingsPath)
+	}
+	if !info.HasPendingAttributes() {
+		t.Errorf("want pending attributes, got %s", info.PendingAttributes)
+	}
+
+	err = api.SetBiosAttributes(context.Background(), parseJSON(t, `{"ProcVirtualization": "Disabled"}`))
+	if err != nil {
+		t.Fatalf("SetBiosAttributes: %v", err)
+	}
+	assertRequests(t, "SetBiosAttributes", bmc.Requests(), []test.SRequest{
+		{Method: "PATCH", Path: "/redfish/v1/Systems/1/Bios/Pending", Body: parseJSON(t, `{"Attributes": {"ProcVirtualization": "Disabled"}}`)},
+	})
+}
+
+func TestGetStorages(t *testing.T) {
+	bmc := test.NewFakeBMC(testResources, nil)
+	defer bmc.Close()
+
+	storages, err := newTestApi(bmc).GetStorages(context.Background())
+	if err != nil {
+		t.Fatalf("GetStorages: %v", err)
+	}
+	want := []redfish.SStorageInfo{
+		{
+			Id:          "1",
+			Name:        "RAID Controller",
+			Path:        "/redfish/v1/Systems/1/Storage/1",
+			VolumesPath: "/redfish/v1/Systems/1/Storage/1/Volumes",
+			Drives: []redfish.SDrive{
+				{Id: "0", Name: "Drive 0", Path: "/redfish/v1/Systems/1/Storage/1/Drives/0", Model: "ST1000NX0443", MediaType: "HDD", Protocol: "SATA", CapacityBytes: 1000204886016, Location: "Slot 0"},
+				{Id: "1", Name: "Drive 1", Path: "/redfish/v1/Systems/1/Storage/1/Drives/1", Model: "ST1000NX0443", MediaType: "HDD", Protocol: "SATA", CapacityBytes: 1000204886016},
+			},
+			Volumes: []redfish.SVolume{
+				{Id: "0", Name: "os", Path: "/redfish/v1/Systems/1/Storage/1/Volumes/0", RAIDType: "Mirrored", CapacityBytes: 999653638144, Status: "OK", Drives: []string{"0", "1"}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(storages, want) {
+		t.Errorf("want %s, got %s", jsonutils.Marshal(want), jsonutils.Marshal(storages))
+	}
+}
+
+func TestVolume(t *testing.T) {
+	const volumesPath = "/redfish/v1/Systems/1/Storage/1/Volumes"
+	bmc := test.NewFakeBMC(testResources, map[string]test.SReply{
+		"POST " + volumesPath:          {StatusCode: 202, Location: "/redfish/v1/TaskService/Tasks/3"},
+		"DELETE " + volumesPath + "/0": {StatusCode: 200, Body: `{"@odata.id": "/redfish/v1/TaskService/Tasks/4", "TaskState": "Running"}`},
+	})
+	defer bmc.Close()
+	api := newTestApi(bmc)
+
+	task, err := api.CreateVolume(context.Background(), redfish.SVolumeCreateParams{
+		Name:     "data",
+		RAIDType: "RAID1",
+		Drives:   []string{"Slot 0", "1"},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume: %v", err)
+	}
+	if task != "/redfish/v1/TaskService/Tasks/3" {
+		t.Errorf("CreateVolume: want task /redfish/v1/TaskService/Tasks/3, got %s", task)
+	}
+	task, err = api.DeleteVolume(context.Background(), "1", "os")
+	if err != nil {
+		t.Fatalf("DeleteVolume: %v", err)
+	}
+	if task != "/redfish/v1/TaskService/Tasks/4" {
+		t.Errorf("DeleteVolume: want task /redfish/v1/TaskService/Tasks/4, got %s", task)
+	}
+	_, err = api.CreateVolume(context.Background(), redfish.SVolumeCreateParams{
+		RAIDType: "RAID0",
+		Drives:   []string{"2"},
+	})
+	if err == nil {
+		t.Errorf("CreateVolume: want error for unknown drive")
+	}
+	assertRequests(t, "volume", bmc.Requests(), []test.SRequest{
+		{
+			Method: "POST",
+			Path:   volumesPath,
+			Body: parseJSON(t, `{
+				"Name": "data",
+				"RAIDType": "RAID1",
+				"Links": {"Drives": [{"@odata.id": "/redfish/v1/Systems/1/Storage/1/Drives/0"}, {"@odata.id": "/redfish/v1/Systems/1/Storage/1/Drives/1"}]}
+			}`),
+		},
+		{Method: "DELETE", Path: volumesPath + "/0"},
+	})
+}
@@ -383,3 +383,117 @@ func (r *SIDracRefishApi) GetPowerPath() string {
 func (r *SIDracRefishApi) GetThermalPath() string {
 	return "/redfish/v1/Chassis/System.Embedded.1/Thermal"
 }
+
+// pending BIOS attributes on iDRAC are only applied by a scheduled config job
+func (r *SIDracRefishApi) SetBiosAttributes(ctx context.Context, attrs jsonutils.JSONObject) error {
+	err := r.SGenericRefishApi.SetBiosAttributes(ctx, attrs)
+	if err != nil {
+		return errors.Wrap(err, "SetBiosAttributes")
+	}
+	biosInfo, err := r.GetBiosInfo(ctx)
+	if err != nil {
+		return errors.Wrap(err, "GetBiosInfo")
+	}
+	path, _, err := r.GetResource(ctx, "Managers", "0")
+	if err != nil {
+		return errors.Wrap(err, "GetResource Managers 0")
+	}
+	jobsPath := httputils.JoinPath(path, "Jobs")
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString(biosInfo.SettingsPath), "TargetSettingsURI")
+	hdr, _, err := r.Post(ctx, jobsPath, params)
+	if err != nil {
+		return errors.Wrapf(err, "create bios config job %s", jobsPath)
+	}
+	if r.IsDebug {
+		log.Debugf("bios config job %s", hdr.Get("Location"))
+	}
+	return nil
+}
+
+func (r *SIDracRefishApi) GetTaskInfo(ctx context.Context, path string) (redfish.STaskInfo, error) {
+	resp, err := r.Get(ctx, path)
+	if err != nil {
+		return redfish.STaskInfo{Path: path}, errors.Wrapf(err, "r.Get %s", path)
+	}
+	jobState, _ := resp.GetString("JobState")
+	if len(jobState) == 0 {
+		return redfish.ParseTaskInfo(path, resp), nil
+	}
+	// iDRAC job resource
+	info := redfish.STaskInfo{Path: path}
+	pct, _ := resp.Int("PercentComplete")
+	info.PercentComplete = int(pct)
+	msg, _ := resp.GetString("Message")
+	if len(msg) > 0 {
+		info.Messages = []string{msg}
+	}
+	switch jobState {
+	case "Completed":
+		info.TaskState = redfish.TASK_STATE_COMPLETED
+		info.TaskStatus = "OK"
+	case "CompletedWithErrors":
+		info.TaskState = redfish.TASK_STATE_COMPLETED
+		info.TaskStatus = "Warning"
+	case "Failed":
+		info.TaskState = redfish.TASK_STATE_EXCEPTION
+		info.TaskStatus = "Critical"
+	default:
+		info.TaskState = redfish.TASK_STATE_RUNNING
+	}
+	return info, nil
+}
+
+var raidVolumeTypes = map[string]string{
+	"RAID0":  "NonRedundant",
+	"RAID1":  "Mirrored",
+	"RAID5":  "StripedWithParity",
+	"RAID6":  "StripedWithParity",
+	"RAID10": "SpannedMirrors",
+	"RAID50": "SpannedStripesWithParity",
+	"RAID60": "SpannedStripesWithParity",
+}
+
+func (r *SIDracRefishApi) CreateVolume(ctx context.Context, params redfish.SVolumeCreateParams) (string, error) {
+	storages, err := r.GetStorages(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "GetStorages")
+	}
+	stor, err := redfish.FindStorage(storages, params.Storage)
+	if err != nil {
+		return "", errors.Wrap(err, "FindStorage")
+	}
+	if len(stor.VolumesPath) == 0 {
+		return "", errors.Wrapf(httperrors.ErrNotSupported, "storage %s has no Volumes collection", stor.Id)
+	}
+	volType, ok := raidVolumeTypes[strings.ToUpper(params.RAIDType)]
+	if !ok {
+		return "", errors.Wrapf(httperrors.ErrNotSupported, "raid type %s", params.RAIDType)
+	}
+	drives := make([]jsonutils.JSONObject, 0, len(params.Drives))
+	for _, id := range params.Drives {
+		drive, err := stor.FindDrive(id)
+		if err != nil {
+			return "", errors.Wrap(err, "FindDrive")
+		}
+		link := jsonutils.NewDict()
+		link.Add(jsonutils.NewString(drive.Path), r.LinkKey())
+		drives = append(drives, link)
+	}
+	body := jsonutils.NewDict()
+	if len(params.Name) > 0 {
+		body.Add(jsonutils.NewString(params.Name), "Name")
+	}
+	body.Add(jsonutils.NewString(volType), "VolumeType")
+	if params.CapacityBytes > 0 {
+		body.Add(jsonutils.NewInt(params.CapacityBytes), "CapacityBytes")
+	}
+	body.Add(jsonutils.NewArray(drives...), "Drives")
+	// realtime job, no system reset required for PERC9 and later
+	body.Add(jsonutils.NewString("Immediate"), "@Redfish.OperationApplyTime")
+	hdr, resp, err := r.Post(ctx, stor.VolumesPath, body)
+	if err != nil {
+		return "", errors.Wrapf(err, "r.Post %s", stor.VolumesPath)
+	}
+	return r.TaskPathFromResponse(hdr, resp), nil
+}
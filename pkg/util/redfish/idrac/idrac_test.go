@@ -0,0 +1,228 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idrac
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/util/redfish"
+	"yunion.io/x/onecloud/pkg/util/redfish/test"
+)
+
+const (
+	testJobPath     = "/redfish/v1/Managers/iDRAC.Embedded.1/Jobs/JID_924369311959"
+	testVolumesPath = "/redfish/v1/Systems/System.Embedded.1/Storage/RAID.Integrated.1-1/Volumes"
+)
+
+var testResources = map[string]string{
+	"/redfish/v1/Systems":  `{"Members": [{"@odata.id": "/redfish/v1/Systems/System.Embedded.1"}]}`,
+	"/redfish/v1/Managers": `{"Members": [{"@odata.id": "/redfish/v1/Managers/iDRAC.Embedded.1"}]}`,
+	"/redfish/v1/Systems/System.Embedded.1": `{
+		"@odata.id": "/redfish/v1/Systems/System.Embedded.1",
+		"Id": "System.Embedded.1",
+		"Bios": {"@odata.id": "/redfish/v1/Systems/System.Embedded.1/Bios"},
+		"Storage": {"@odata.id": "/redfish/v1/Systems/System.Embedded.1/Storage"}
+	}`,
+	"/redfish/v1/Managers/iDRAC.Embedded.1": `{"@odata.id": "/redfish/v1/Managers/iDRAC.Embedded.1", "Id": "iDRAC.Embedded.1"}`,
+	"/redfish/v1/Systems/System.Embedded.1/Bios": `{
+		"@odata.id": "/redfish/v1/Systems/System.Embedded.1/Bios",
+		"AttributeRegistry": "BiosAttributeRegistry.v1_0_3",
+		"Attributes": {"BootMode": "Uefi", "SriovGlobalEnable": "Disabled"},
+		"@Redfish.Settings": {"SettingsObject": {"@odata.id": "/redfish/v1/Systems/System.Embedded.1/Bios/Settings"}}
+	}`,
+	"/redfish/v1/Systems/System.Embedded.1/Bios/Settings": `{"Attributes": {}}`,
+	"/redfish/v1/Systems/System.Embedded.1/Storage":       `{"Members": [{"@odata.id": "/redfish/v1/Systems/System.Embedded.1/Storage/RAID.Integrated.1-1"}]}`,
+	"/redfish/v1/Systems/System.Embedded.1/Storage/RAID.Integrated.1-1": `{
+		"@odata.id": "/redfish/v1/Systems/System.Embedded.1/Storage/RAID.Integrated.1-1",
+		"Id": "RAID.Integrated.1-1",
+		"Name": "PERC H730P Mini",
+		"Drives": [
+			{"@odata.id": "/redfish/v1/Systems/System.Embedded.1/Storage/Drives/Disk.Bay.0:Enclosure.Internal.0-1:RAID.Integrated.1-1"},
+			{"@odata.id": "/redfish/v1/Systems/System.Embedded.1/Storage/Drives/Disk.Bay.1:Enclosure.Internal.0-1:RAID.Integrated.1-1"}
+		],
+		"Volumes": {"@odata.id": "/redfish/v1/Systems/System.Embedded.1/Storage/RAID.Integrated.1-1/Volumes"}
+	}`,
+	"/redfish/v1/Systems/System.Embedded.1/Storage/Drives/Disk.Bay.0:Enclosure.Internal.0-1:RAID.Integrated.1-1": `{"Id": "Disk.Bay.0:Enclosure.Internal.0-1:RAID.Integrated.1-1", "MediaType": "SSD", "Protocol": "SATA", "CapacityBytes": 479559942144}`,
+	"/redfish/v1/Systems/System.Embedded.1/Storage/Drives/Disk.Bay.1:Enclosure.Internal.0-1:RAID.Integrated.1-1": `{"Id": "Disk.Bay.1:Enclosure.Internal.0-1:RAID.Integrated.1-1", "MediaType": "SSD", "Protocol": "SATA", "CapacityBytes": 479559942144}`,
+	testVolumesPath: `{"Members": []}`,
+}
+
+func newTestApi(bmc *test.SFakeBMC) *SIDracRefishApi {
+	api := NewIDracRedfishApi(bmc.URL, "root", "calvin", false).(*SIDracRefishApi)
+	api.Registries = map[string]string{
+		"Systems":  "/redfish/v1/Systems",
+		"Managers": "/redfish/v1/Managers",
+	}
+	return api
+}
+
+func TestGetTaskInfo(t *testing.T) {
+	cases := []struct {
+		name string
+		resp string
+		want redfish.STaskInfo
+	}{
+		{
+			name: "scheduled job",
+			resp: `{"@odata.id": "` + testJobPath + `", "Id": "JID_924369311959", "JobState": "Scheduled", "JobType": "BIOSConfiguration", "Message": "Task successfully scheduled.", "PercentComplete": 0}`,
+			want: redfish.STaskInfo{TaskState: redfish.TASK_STATE_RUNNING, Messages: []string{"Task successfully scheduled."}},
+		},
+		{
+			name: "completed job",
+			resp: `{"@odata.id": "` + testJobPath + `", "JobState": "Completed", "JobType": "RealTimeNoRebootConfiguration", "Message": "Job completed successfully.", "PercentComplete": 100}`,
+			want: redfish.STaskInfo{TaskState: redfish.TASK_STATE_COMPLETED, TaskStatus: "OK", PercentComplete: 100, Messages: []string{"Job completed successfully."}},
+		},
+		{
+			name: "job completed with errors",
+			resp: `{"JobState": "CompletedWithErrors", "Message": "Job completed with errors.", "PercentComplete": 100}`,
+			want: redfish.STaskInfo{TaskState: redfish.TASK_STATE_COMPLETED, TaskStatus: "Warning", PercentComplete: 100, Messages: []string{"Job completed with errors."}},
+		},
+		{
+			name: "failed job",
+			resp: `{"JobState": "Failed", "Message": "Unable to complete the operation because the virtual disk size is invalid.", "PercentComplete": 100}`,
+			want: redfish.STaskInfo{TaskState: redfish.TASK_STATE_EXCEPTION, TaskStatus: "Critical", PercentComplete: 100, Messages: []string{"Unable to complete the operation because the virtual disk size is invalid."}},
+		},
+		{
+			name: "task service task",
+			resp: `{"@odata.id": "/redfish/v1/TaskService/Tasks/JID_924369311959", "TaskState": "Running", "TaskStatus": "OK", "PercentComplete": 34, "Messages": [{"Message": "Job in progress."}]}`,
+			want: redfish.STaskInfo{TaskState: redfish.TASK_STATE_RUNNING, TaskStatus: "OK", PercentComplete: 34, Messages: []string{"Job in progress."}},
+		},
+	}
+	for _, c := range cases {
+		bmc := test.NewFakeBMC(map[string]string{testJobPath: c.resp}, nil)
+		got, err := newTestApi(bmc).GetTaskInfo(context.Background(), testJobPath)
+		bmc.Close()
+		if err != nil {
+			t.Errorf("%s: GetTaskInfo: %v", c.name, err)
+			continue
+		}
+		c.want.Path = testJobPath
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: want %#v, got %#v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestSetBiosAttributes(t *testing.T) {
+	bmc := test.NewFakeBMC(testResources, map[string]test.SReply{
+		"POST /redfish/v1/Managers/iDRAC.Embedded.1/Jobs": {StatusCode: 200, Location: testJobPath},
+	})
+	defer bmc.Close()
+
+	attrs, _ := jsonutils.ParseString(`{"SriovGlobalEnable": "Enabled"}`)
+	err := newTestApi(bmc).SetBiosAttributes(context.Background(), attrs)
+	if err != nil {
+		t.Fatalf("SetBiosAttributes: %v", err)
+	}
+	want := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"PATCH", "/redfish/v1/Systems/System.Embedded.1/Bios/Settings", `{"Attributes": {"SriovGlobalEnable": "Enabled"}}`},
+		{"POST", "/redfish/v1/Managers/iDRAC.Embedded.1/Jobs", `{"TargetSettingsURI": "/redfish/v1/Systems/System.Embedded.1/Bios/Settings"}`},
+	}
+	got := bmc.Requests()
+	if len(got) != len(want) {
+		t.Fatalf("want %d requests, got %d", len(want), len(got))
+	}
+	for i := range want {
+		body, _ := jsonutils.ParseString(want[i].body)
+		if got[i].Method != want[i].method || got[i].Path != want[i].path || !test.SameBody(got[i].Body, body) {
+			t.Errorf("want %s %s %s, got %s %s %s", want[i].method, want[i].path, body, got[i].Method, got[i].Path, got[i].Body)
+		}
+	}
+}
+
+func TestCreateVolume(t *testing.T) {
+	cases := []struct {
+		name     string
+		params   redfish.SVolumeCreateParams
+		wantBody string
+		wantErr  bool
+	}{
+		{
+			name: "raid1",
+			params: redfish.SVolumeCreateParams{
+				Name:     "os",
+				RAIDType: "raid1",
+				Drives:   []string{"Disk.Bay.0:Enclosure.Internal.0-1:RAID.Integrated.1-1", "Disk.Bay.1:Enclosure.Internal.0-1:RAID.Integrated.1-1"},
+			},
+			wantBody: `{
+				"Name": "os",
+				"VolumeType": "Mirrored",
+				"Drives": [
+					{"@odata.id": "/redfish/v1/Systems/System.Embedded.1/Storage/Drives/Disk.Bay.0:Enclosure.Internal.0-1:RAID.Integrated.1-1"},
+					{"@odata.id": "/redfish/v1/Systems/System.Embedded.1/Storage/Drives/Disk.Bay.1:Enclosure.Internal.0-1:RAID.Integrated.1-1"}
+				],
+				"@Redfish.OperationApplyTime": "Immediate"
+			}`,
+		},
+		{
+			name: "raid0 with capacity",
+			params: redfish.SVolumeCreateParams{
+				Storage:       "RAID.Integrated.1-1",
+				RAIDType:      "RAID0",
+				Drives:        []string{"Disk.Bay.1:Enclosure.Internal.0-1:RAID.Integrated.1-1"},
+				CapacityBytes: 107374182400,
+			},
+			wantBody: `{
+				"VolumeType": "NonRedundant",
+				"CapacityBytes": 107374182400,
+				"Drives": [
+					{"@odata.id": "/redfish/v1/Systems/System.Embedded.1/Storage/Drives/Disk.Bay.1:Enclosure.Internal.0-1:RAID.Integrated.1-1"}
+				],
+				"@Redfish.OperationApplyTime": "Immediate"
+			}`,
+		},
+		{
+			name: "unsupported raid type",
+			params: redfish.SVolumeCreateParams{
+				RAIDType: "JBOD",
+				Drives:   []string{"Disk.Bay.0:Enclosure.Internal.0-1:RAID.Integrated.1-1"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		bmc := test.NewFakeBMC(testResources, map[string]test.SReply{
+			"POST " + testVolumesPath: {StatusCode: 202, Location: testJobPath},
+		})
+		task, err := newTestApi(bmc).CreateVolume(context.Background(), c.params)
+		bmc.Close()
+		got := bmc.Requests()
+		if c.wantErr {
+			if err == nil || len(got) > 0 {
+				t.Errorf("%s: want error without requests, got %v and %d requests", c.name, err, len(got))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: CreateVolume: %v", c.name, err)
+			continue
+		}
+		if task != testJobPath {
+			t.Errorf("%s: want task %s, got %s", c.name, testJobPath, task)
+		}
+		body, _ := jsonutils.ParseString(c.wantBody)
+		if len(got) != 1 || got[0].Path != testVolumesPath || !test.SameBody(got[0].Body, body) {
+			t.Errorf("%s: want POST %s %s, got %#v", c.name, testVolumesPath, body, got)
+		}
+	}
+}
@@ -307,3 +307,207 @@ func (r *SILORefishApi) GetPowerPath() string {
 func (r *SILORefishApi) GetThermalPath() string {
 	return "/redfish/v1/Chassis/1/Thermal/"
 }
+
+func (r *SILORefishApi) GetBiosSettingsPath(biosPath string, bios jsonutils.JSONObject) string {
+	settingsPath, _ := bios.GetString("@Redfish.Settings", "SettingsObject", r.LinkKey())
+	if len(settingsPath) > 0 {
+		return settingsPath
+	}
+	for _, oemKey := range []string{"Hpe", "Hp"} {
+		settingsPath, _ = bios.GetString("Oem", oemKey, "Links", "Settings", r.LinkKey())
+		if len(settingsPath) > 0 {
+			return settingsPath
+		}
+	}
+	return httputils.JoinPath(biosPath, "settings/")
+}
+
+// iLO does not create a task for SimpleUpdate, the update progress
+// is tracked by the Oem state of UpdateService instead
+func (r *SILORefishApi) SimpleUpdate(ctx context.Context, params redfish.SFirmwareUpdateParams) (string, error) {
+	taskPath, err := r.SGenericRefishApi.SimpleUpdate(ctx, params)
+	if err != nil {
+		return "", errors.Wrap(err, "SimpleUpdate")
+	}
+	if len(taskPath) > 0 {
+		return taskPath, nil
+	}
+	path, _, err := r.GetResource(ctx, "UpdateService")
+	if err != nil {
+		return "", errors.Wrap(err, "GetResource UpdateService")
+	}
+	return path, nil
+}
+
+func (r *SILORefishApi) GetTaskInfo(ctx context.Context, path string) (redfish.STaskInfo, error) {
+	resp, err := r.Get(ctx, path)
+	if err != nil {
+		return redfish.STaskInfo{Path: path}, errors.Wrapf(err, "r.Get %s", path)
+	}
+	var state string
+	var oem jsonutils.JSONObject
+	for _, oemKey := range []string{"Hpe", "Hp"} {
+		oem, _ = resp.Get("Oem", oemKey)
+		if oem != nil {
+			state, _ = oem.GetString("State")
+			break
+		}
+	}
+	if len(state) == 0 {
+		return redfish.ParseTaskInfo(path, resp), nil
+	}
+	info := redfish.STaskInfo{Path: path}
+	pct, _ := oem.Int("FlashProgressPercent")
+	info.PercentComplete = int(pct)
+	switch state {
+	case "Complete":
+		info.TaskState = redfish.TASK_STATE_COMPLETED
+		info.TaskStatus = "OK"
+	case "Error":
+		info.TaskState = redfish.TASK_STATE_EXCEPTION
+		info.TaskStatus = "Critical"
+		result, _ := oem.GetString("Result", "MessageId")
+		if len(result) > 0 {
+			info.Messages = []string{result}
+		}
+	case "Idle":
+		// the update service turns idle after a finished flash
+		if info.PercentComplete >= 100 {
+			info.TaskState = redfish.TASK_STATE_COMPLETED
+			info.TaskStatus = "OK"
+		} else {
+			info.TaskState = redfish.TASK_STATE_PENDING
+		}
+	default:
+		info.TaskState = redfish.TASK_STATE_RUNNING
+	}
+	return info, nil
+}
+
+/*
+ * Smart Array controllers are configured through the Oem SmartStorageConfig
+ * resource, changes are staged in its settings object and applied on next reset
+ */
+func (r *SILORefishApi) getSmartStorageConfigPath(ctx context.Context) (string, error) {
+	path, _, err := r.GetResource(ctx, "Systems", "0")
+	if err != nil {
+		return "", errors.Wrap(err, "GetResource Systems 0")
+	}
+	return httputils.JoinPath(path, "smartstorageconfig/"), nil
+}
+
+func (r *SILORefishApi) GetStorages(ctx context.Context) ([]redfish.SStorageInfo, error) {
+	path, err := r.getSmartStorageConfigPath(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getSmartStorageConfigPath")
+	}
+	resp, err := r.Get(ctx, path)
+	if err != nil {
+		if httputils.ErrorCode(errors.Cause(err)) == 404 {
+			return r.SGenericRefishApi.GetStorages(ctx)
+		}
+		return nil, errors.Wrapf(err, "r.Get %s", path)
+	}
+	stor := redfish.SStorageInfo{Path: path}
+	stor.Id, _ = resp.GetString("Id")
+	stor.Name, _ = resp.GetString("Name")
+	pdrives, _ := resp.GetArray("PhysicalDrives")
+	for i := range pdrives {
+		drive := redfish.SDrive{}
+		drive.Location, _ = pdrives[i].GetString("Location")
+		drive.Id = drive.Location
+		drive.Model, _ = pdrives[i].GetString("Model")
+		drive.SerialNumber, _ = pdrives[i].GetString("SerialNumber")
+		drive.MediaType, _ = pdrives[i].GetString("MediaType")
+		drive.Protocol, _ = pdrives[i].GetString("InterfaceType")
+		sizeGB, _ := pdrives[i].Int("CapacityGB")
+		drive.CapacityBytes = sizeGB * 1000 * 1000 * 1000
+		stor.Drives = append(stor.Drives, drive)
+	}
+	ldrives, _ := resp.GetArray("LogicalDrives")
+	for i := range ldrives {
+		vol := redfish.SVolume{}
+		vol.Id, _ = ldrives[i].GetString("VolumeUniqueIdentifier")
+		vol.Name, _ = ldrives[i].GetString("LogicalDriveName")
+		raid, _ := ldrives[i].GetString("Raid")
+		vol.RAIDType = strings.ToUpper(raid)
+		sizeGiB, _ := ldrives[i].Int("CapacityGiB")
+		vol.CapacityBytes = sizeGiB * 1024 * 1024 * 1024
+		vol.Drives, _ = jsonutils.GetStringArray(ldrives[i], "DataDrives")
+		stor.Volumes = append(stor.Volumes, vol)
+	}
+	return []redfish.SStorageInfo{stor}, nil
+}
+
+func (r *SILORefishApi) CreateVolume(ctx context.Context, params redfish.SVolumeCreateParams) (string, error) {
+	storages, err := r.GetStorages(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "GetStorages")
+	}
+	stor, err := redfish.FindStorage(storages, params.Storage)
+	if err != nil {
+		return "", errors.Wrap(err, "FindStorage")
+	}
+	if len(stor.VolumesPath) > 0 {
+		// standard Storage model, not a Smart Array controller
+		return r.SGenericRefishApi.CreateVolume(ctx, params)
+	}
+	locations := make([]string, 0, len(params.Drives))
+	for _, id := range params.Drives {
+		drive, err := stor.FindDrive(id)
+		if err != nil {
+			return "", errors.Wrap(err, "FindDrive")
+		}
+		locations = append(locations, drive.Location)
+	}
+	ldrive := jsonutils.NewDict()
+	if len(params.Name) > 0 {
+		ldrive.Add(jsonutils.NewString(params.Name), "LogicalDriveName")
+	}
+	ldrive.Add(jsonutils.NewString("Raid"+strings.TrimPrefix(strings.ToUpper(params.RAIDType), "RAID")), "Raid")
+	ldrive.Add(jsonutils.NewStringArray(locations), "DataDrives")
+	if params.CapacityBytes > 0 {
+		ldrive.Add(jsonutils.NewInt(params.CapacityBytes/1024/1024/1024), "CapacityGiB")
+	}
+	body := jsonutils.NewDict()
+	body.Add(jsonutils.NewArray(ldrive), "LogicalDrives")
+	body.Add(jsonutils.NewString("Disabled"), "DataGuard")
+	settingsPath := httputils.JoinPath(stor.Path, "settings/")
+	_, _, err = r.Put(ctx, settingsPath, body)
+	if err != nil {
+		return "", errors.Wrapf(err, "r.Put %s", settingsPath)
+	}
+	return "", nil
+}
+
+func (r *SILORefishApi) DeleteVolume(ctx context.Context, storage string, volume string) (string, error) {
+	storages, err := r.GetStorages(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "GetStorages")
+	}
+	stor, err := redfish.FindStorage(storages, storage)
+	if err != nil {
+		return "", errors.Wrap(err, "FindStorage")
+	}
+	if len(stor.VolumesPath) > 0 {
+		return r.SGenericRefishApi.DeleteVolume(ctx, storage, volume)
+	}
+	vol, err := stor.FindVolume(volume)
+	if err != nil {
+		return "", errors.Wrap(err, "FindVolume")
+	}
+	action := jsonutils.NewDict()
+	action.Add(jsonutils.NewString("LogicalDriveDelete"), "Action")
+	ldrive := jsonutils.NewDict()
+	ldrive.Add(jsonutils.NewArray(action), "Actions")
+	ldrive.Add(jsonutils.NewString(vol.Id), "VolumeUniqueIdentifier")
+	body := jsonutils.NewDict()
+	body.Add(jsonutils.NewArray(ldrive), "LogicalDrives")
+	body.Add(jsonutils.NewString("Permissive"), "DataGuard")
+	settingsPath := httputils.JoinPath(stor.Path, "settings/")
+	_, _, err = r.Put(ctx, settingsPath, body)
+	if err != nil {
+		return "", errors.Wrapf(err, "r.Put %s", settingsPath)
+	}
+	return "", nil
+}
@@ -0,0 +1,270 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ilo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/util/redfish"
+	"yunion.io/x/onecloud/pkg/util/redfish/test"
+)
+
+const (
+	testUpdateServicePath = "/redfish/v1/UpdateService"
+	testSmartStoragePath  = "/redfish/v1/Systems/1/smartstorageconfig"
+	testSmartStorage      = `{
+		"@odata.id": "/redfish/v1/systems/1/smartstorageconfig/",
+		"Id": "smartstorageconfig",
+		"Name": "SmartStorageConfig",
+		"Location": "Slot 0",
+		"PhysicalDrives": [
+			{"Location": "1I:1:1", "LocationFormat": "ControllerPort:Box:Bay", "Model": "MM1000GEFQV", "SerialNumber": "W470ZEJ8", "MediaType": "HDD", "InterfaceType": "SATA", "CapacityGB": 1000},
+			{"Location": "1I:1:2", "LocationFormat": "ControllerPort:Box:Bay", "Model": "MM1000GEFQV", "SerialNumber": "W470ZEJ9", "MediaType": "HDD", "InterfaceType": "SATA", "CapacityGB": 1000}
+		],
+		"LogicalDrives": [
+			{"LogicalDriveName": "os", "LogicalDriveNumber": 1, "Raid": "Raid1", "CapacityGiB": 931, "DataDrives": ["1I:1:1", "1I:1:2"], "VolumeUniqueIdentifier": "600508B1001C5A3B2E1AAB4D8D1D7C1F"}
+		]
+	}`
+)
+
+var testResources = map[string]string{
+	"/redfish/v1/Systems":   `{"Members": [{"@odata.id": "/redfish/v1/Systems/1/"}]}`,
+	"/redfish/v1/Systems/1": `{"@odata.id": "/redfish/v1/Systems/1/", "Id": "1"}`,
+	testUpdateServicePath: `{
+		"@odata.id": "/redfish/v1/UpdateService/",
+		"Actions": {"#UpdateService.SimpleUpdate": {"target": "/redfish/v1/UpdateService/Actions/UpdateService.SimpleUpdate/"}},
+		"Oem": {"Hpe": {"State": "Idle", "FlashProgressPercent": 0}}
+	}`,
+	testSmartStoragePath: testSmartStorage,
+}
+
+func newTestApi(bmc *test.SFakeBMC) *SILORefishApi {
+	api := NewILORedfishApi(bmc.URL, "Administrator", "password", false).(*SILORefishApi)
+	api.Registries = map[string]string{
+		"Systems":       "/redfish/v1/Systems",
+		"UpdateService": testUpdateServicePath,
+	}
+	return api
+}
+
+func TestGetBiosSettingsPath(t *testing.T) {
+	cases := []struct {
+		name string
+		bios string
+		want string
+	}{
+		{
+			name: "iLO 5",
+			bios: `{"@Redfish.Settings": {"SettingsObject": {"@odata.id": "/redfish/v1/systems/1/bios/settings/"}}}`,
+			want: "/redfish/v1/systems/1/bios/settings/",
+		},
+		{
+			name: "iLO 4",
+			bios: `{"Oem": {"Hp": {"Links": {"Settings": {"@odata.id": "/redfish/v1/Systems/1/bios/Settings/"}}}}}`,
+			want: "/redfish/v1/Systems/1/bios/Settings/",
+		},
+		{
+			name: "no settings link",
+			bios: `{"Attributes": {}}`,
+			want: "/redfish/v1/Systems/1/bios/settings",
+		},
+	}
+	api := NewILORedfishApi("https://10.168.26.11", "Administrator", "password", false)
+	for _, c := range cases {
+		bios, _ := jsonutils.ParseString(c.bios)
+		if got := api.GetBiosSettingsPath("/redfish/v1/Systems/1/bios/", bios); got != c.want {
+			t.Errorf("%s: want %s, got %s", c.name, c.want, got)
+		}
+	}
+}
+
+func TestGetTaskInfo(t *testing.T) {
+	cases := []struct {
+		name string
+		resp string
+		want redfish.STaskInfo
+	}{
+		{
+			name: "flashing",
+			resp: `{"Oem": {"Hpe": {"State": "Flashing", "FlashProgressPercent": 45}}}`,
+			want: redfish.STaskInfo{TaskState: redfish.TASK_STATE_RUNNING, PercentComplete: 45},
+		},
+		{
+			name: "complete",
+			resp: `{"Oem": {"Hpe": {"State": "Complete", "FlashProgressPercent": 100}}}`,
+			want: redfish.STaskInfo{TaskState: redfish.TASK_STATE_COMPLETED, TaskStatus: "OK", PercentComplete: 100},
+		},
+		{
+			name: "error",
+			resp: `{"Oem": {"Hpe": {"State": "Error", "FlashProgressPercent": 0, "Result": {"MessageId": "iLO.2.8.UpdateBadSignature"}}}}`,
+			want: redfish.STaskInfo{TaskState: redfish.TASK_STATE_EXCEPTION, TaskStatus: "Critical", Messages: []string{"iLO.2.8.UpdateBadSignature"}},
+		},
+		{
+			name: "idle after flash on iLO 4",
+			resp: `{"Oem": {"Hp": {"State": "Idle", "FlashProgressPercent": 100}}}`,
+			want: redfish.STaskInfo{TaskState: redfish.TASK_STATE_COMPLETED, TaskStatus: "OK", PercentComplete: 100},
+		},
+		{
+			name: "idle before flash",
+			resp: `{"Oem": {"Hpe": {"State": "Idle", "FlashProgressPercent": 0}}}`,
+			want: redfish.STaskInfo{TaskState: redfish.TASK_STATE_PENDING},
+		},
+		{
+			name: "task service task",
+			resp: `{"TaskState": "Running", "TaskStatus": "OK", "PercentComplete": 10}`,
+			want: redfish.STaskInfo{TaskState: redfish.TASK_STATE_RUNNING, TaskStatus: "OK", PercentComplete: 10},
+		},
+	}
+	for _, c := range cases {
+		bmc := test.NewFakeBMC(map[string]string{testUpdateServicePath: c.resp}, nil)
+		got, err := newTestApi(bmc).GetTaskInfo(context.Background(), testUpdateServicePath)
+		bmc.Close()
+		if err != nil {
+			t.Errorf("%s: GetTaskInfo: %v", c.name, err)
+			continue
+		}
+		c.want.Path = testUpdateServicePath
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: want %#v, got %#v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestSimpleUpdate(t *testing.T) {
+	bmc := test.NewFakeBMC(testResources, nil)
+	defer bmc.Close()
+
+	task, err := newTestApi(bmc).SimpleUpdate(context.Background(), redfish.SFirmwareUpdateParams{
+		ImageURI: "http://10.168.26.2/firmware/ilo5_230.bin",
+	})
+	if err != nil {
+		t.Fatalf("SimpleUpdate: %v", err)
+	}
+	// no task is created, the update is tracked by UpdateService itself
+	if task != testUpdateServicePath {
+		t.Errorf("want task %s, got %s", testUpdateServicePath, task)
+	}
+}
+
+func TestGetStorages(t *testing.T) {
+	bmc := test.NewFakeBMC(testResources, nil)
+	defer bmc.Close()
+
+	storages, err := newTestApi(bmc).GetStorages(context.Background())
+	if err != nil {
+		t.Fatalf("GetStorages: %v", err)
+	}
+	want := []redfish.SStorageInfo{
+		{
+			Id:   "smartstorageconfig",
+			Name: "SmartStorageConfig",
+			Path: testSmartStoragePath,
+			Drives: []redfish.SDrive{
+				{Id: "1I:1:1", Location: "1I:1:1", Model: "MM1000GEFQV", SerialNumber: "W470ZEJ8", MediaType: "HDD", Protocol: "SATA", CapacityBytes: 1000000000000},
+				{Id: "1I:1:2", Location: "1I:1:2", Model: "MM1000GEFQV", SerialNumber: "W470ZEJ9", MediaType: "HDD", Protocol: "SATA", CapacityBytes: 1000000000000},
+			},
+			Volumes: []redfish.SVolume{
+				{Id: "600508B1001C5A3B2E1AAB4D8D1D7C1F", Name: "os", RAIDType: "RAID1", CapacityBytes: 931 * 1024 * 1024 * 1024, Drives: []string{"1I:1:1", "1I:1:2"}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(storages, want) {
+		t.Errorf("want %s, got %s", jsonutils.Marshal(want), jsonutils.Marshal(storages))
+	}
+}
+
+func TestVolume(t *testing.T) {
+	cases := []struct {
+		name     string
+		create   *redfish.SVolumeCreateParams
+		delete   string
+		wantBody string
+		wantErr  bool
+	}{
+		{
+			name: "create raid1",
+			create: &redfish.SVolumeCreateParams{
+				Name:     "data",
+				RAIDType: "RAID1",
+				Drives:   []string{"1I:1:1", "1I:1:2"},
+			},
+			wantBody: `{"LogicalDrives": [{"LogicalDriveName": "data", "Raid": "Raid1", "DataDrives": ["1I:1:1", "1I:1:2"]}], "DataGuard": "Disabled"}`,
+		},
+		{
+			name: "create raid0 with capacity",
+			create: &redfish.SVolumeCreateParams{
+				RAIDType:      "raid0",
+				Drives:        []string{"1I:1:2"},
+				CapacityBytes: 100 * 1024 * 1024 * 1024,
+			},
+			wantBody: `{"LogicalDrives": [{"Raid": "Raid0", "DataDrives": ["1I:1:2"], "CapacityGiB": 100}], "DataGuard": "Disabled"}`,
+		},
+		{
+			name: "create on unknown drive",
+			create: &redfish.SVolumeCreateParams{
+				RAIDType: "RAID0",
+				Drives:   []string{"2I:1:1"},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "delete by name",
+			delete:   "os",
+			wantBody: `{"LogicalDrives": [{"Actions": [{"Action": "LogicalDriveDelete"}], "VolumeUniqueIdentifier": "600508B1001C5A3B2E1AAB4D8D1D7C1F"}], "DataGuard": "Permissive"}`,
+		},
+		{
+			name:    "delete unknown volume",
+			delete:  "data",
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		bmc := test.NewFakeBMC(testResources, nil)
+		api := newTestApi(bmc)
+		var (
+			task string
+			err  error
+		)
+		if c.create != nil {
+			task, err = api.CreateVolume(context.Background(), *c.create)
+		} else {
+			task, err = api.DeleteVolume(context.Background(), "", c.delete)
+		}
+		bmc.Close()
+		got := bmc.Requests()
+		if c.wantErr {
+			if err == nil || len(got) > 0 {
+				t.Errorf("%s: want error without requests, got %v and %d requests", c.name, err, len(got))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		// staged in settings and applied on next reset, no task to wait
+		if len(task) > 0 {
+			t.Errorf("%s: want no task, got %s", c.name, task)
+		}
+		body, _ := jsonutils.ParseString(c.wantBody)
+		if len(got) != 1 || got[0].Method != "PUT" || got[0].Path != testSmartStoragePath+"/settings" || !test.SameBody(got[0].Body, body) {
+			t.Errorf("%s: want PUT %s/settings %s, got %#v", c.name, testSmartStoragePath, body, got)
+		}
+	}
+}
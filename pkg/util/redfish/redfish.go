@@ -157,6 +157,19 @@ func (r *SBaseRedfishClient) Post(ctx context.Context, path string, body jsonuti
 	return r.request(ctx, httputils.POST, path, nil, body)
 }
 
+func (r *SBaseRedfishClient) Put(ctx context.Context, path string, body jsonutils.JSONObject) (http.Header, jsonutils.JSONObject, error) {
+	return r.request(ctx, httputils.PUT, path, nil, body)
+}
+
+func (r *SBaseRedfishClient) PatchIfMatch(ctx context.Context, path string, etag string, body jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	header := http.Header{}
+	if len(etag) > 0 {
+		header.Set("If-Match", etag)
+	}
+	_, resp, err := r.request(ctx, httputils.PATCH, path, header, body)
+	return resp, err
+}
+
 func (r *SBaseRedfishClient) Delete(ctx context.Context, path string) (http.Header, jsonutils.JSONObject, error) {
 	return r.request(ctx, httputils.DELETE, path, nil, nil)
 }
@@ -638,21 +651,339 @@ func (r *SBaseRedfishClient) ClearManagerLogs(ctx context.Context) error {
 	return r.ClearLogs(ctx, r.IRedfishDriver().GetClearManagerLogsPath(), "Managers", 1)
 }
 
+func (r *SBaseRedfishClient) GetBiosSettingsPath(biosPath string, bios jsonutils.JSONObject) string {
+	settingsPath, _ := bios.GetString("@Redfish.Settings", "SettingsObject", r.IRedfishDriver().LinkKey())
+	if len(settingsPath) > 0 {
+		return settingsPath
+	}
+	return httputils.JoinPath(biosPath, "Settings")
+}
+
 func (r *SBaseRedfishClient) GetBiosInfo(ctx context.Context) (SBiosInfo, error) {
 	biosInfo := SBiosInfo{}
-	path, _, err := r.GetResource(ctx, "Systems", "0")
+	path, system, err := r.GetResource(ctx, "Systems", "0")
 	if err != nil {
 		return biosInfo, errors.Wrap(err, "r.GetResource Systems 0")
 	}
-	biosPath := httputils.JoinPath(path, "Bios/")
+	biosPath, _ := system.GetString("Bios", r.IRedfishDriver().LinkKey())
+	if len(biosPath) == 0 {
+		biosPath = httputils.JoinPath(path, "Bios/")
+	}
 	resp, err := r.Get(ctx, biosPath)
 	if err != nil {
 		return biosInfo, errors.Wrapf(err, "r.Get %s", biosPath)
 	}
-	log.Debugf("%s", resp.PrettyString())
+	if r.IsDebug {
+		log.Debugf("%s", resp.PrettyString())
+	}
+	biosInfo.Path = biosPath
+	biosInfo.AttributeRegistry, _ = resp.GetString("AttributeRegistry")
+	biosInfo.Attributes, _ = resp.Get("Attributes")
+	biosInfo.SettingsPath = r.IRedfishDriver().GetBiosSettingsPath(biosPath, resp)
+	settings, err := r.Get(ctx, biosInfo.SettingsPath)
+	if err != nil {
+		// settings object is optional, BIOS is read-only in this case
+		log.Warningf("r.Get bios settings %s fail: %s", biosInfo.SettingsPath, err)
+		biosInfo.SettingsPath = ""
+	} else {
+		biosInfo.PendingAttributes, _ = settings.Get("Attributes")
+	}
 	return biosInfo, nil
 }
 
+func (r *SBaseRedfishClient) SetBiosAttributes(ctx context.Context, attrs jsonutils.JSONObject) error {
+	biosInfo, err := r.IRedfishDriver().GetBiosInfo(ctx)
+	if err != nil {
+		return errors.Wrap(err, "GetBiosInfo")
+	}
+	if len(biosInfo.SettingsPath) == 0 {
+		return errors.Wrap(httperrors.ErrNotSupported, "no bios settings object")
+	}
+	params := jsonutils.NewDict()
+	params.Add(attrs, "Attributes")
+	resp, err := r.Patch(ctx, biosInfo.SettingsPath, params)
+	if err != nil {
+		return errors.Wrapf(err, "r.Patch %s", biosInfo.SettingsPath)
+	}
+	if r.IsDebug && resp != nil {
+		log.Debugf("%s", resp.PrettyString())
+	}
+	return nil
+}
+
+// TaskPathFromResponse finds the task monitor of an asynchronous operation
+// from either the Location header or a returned Task resource
+func (r *SBaseRedfishClient) TaskPathFromResponse(hdr http.Header, resp jsonutils.JSONObject) string {
+	var taskPath string
+	if hdr != nil {
+		taskPath = hdr.Get("Location")
+	}
+	if len(taskPath) == 0 && resp != nil && resp.Contains("TaskState") {
+		taskPath, _ = resp.GetString(r.IRedfishDriver().LinkKey())
+	}
+	pos := strings.Index(taskPath, r.IRedfishDriver().BasePath())
+	if pos > 0 {
+		taskPath = taskPath[pos:]
+	}
+	return taskPath
+}
+
+func (r *SBaseRedfishClient) SimpleUpdate(ctx context.Context, params SFirmwareUpdateParams) (string, error) {
+	_, updateSvc, err := r.GetResource(ctx, "UpdateService")
+	if err != nil {
+		return "", errors.Wrap(err, "GetResource UpdateService")
+	}
+	urlPath, err := updateSvc.GetString("Actions", "#UpdateService.SimpleUpdate", "target")
+	if err != nil {
+		return "", errors.Wrap(httperrors.ErrNotSupported, "Actions.#UpdateService.SimpleUpdate.target")
+	}
+	body := jsonutils.NewDict()
+	body.Add(jsonutils.NewString(params.ImageURI), "ImageURI")
+	if len(params.TransferProtocol) > 0 {
+		protocols, _ := jsonutils.GetStringArray(updateSvc, "Actions", "#UpdateService.SimpleUpdate", "TransferProtocol@Redfish.AllowableValues")
+		if len(protocols) > 0 && !utils.IsInStringArray(params.TransferProtocol, protocols) {
+			return "", errors.Wrapf(httperrors.ErrBadRequest, "%s not supported: %s", params.TransferProtocol, protocols)
+		}
+		body.Add(jsonutils.NewString(params.TransferProtocol), "TransferProtocol")
+	}
+	if len(params.Targets) > 0 {
+		body.Add(jsonutils.NewStringArray(params.Targets), "Targets")
+	}
+	hdr, resp, err := r.Post(ctx, urlPath, body)
+	if err != nil {
+		return "", errors.Wrap(err, "Actions/UpdateService.SimpleUpdate")
+	}
+	return r.TaskPathFromResponse(hdr, resp), nil
+}
+
+func ParseTaskInfo(path string, resp jsonutils.JSONObject) STaskInfo {
+	info := STaskInfo{Path: path}
+	if resp == nil {
+		info.TaskState = TASK_STATE_RUNNING
+		return info
+	}
+	info.TaskState, _ = resp.GetString("TaskState")
+	info.TaskStatus, _ = resp.GetString("TaskStatus")
+	pct, _ := resp.Int("PercentComplete")
+	info.PercentComplete = int(pct)
+	msgs, _ := resp.GetArray("Messages")
+	for i := range msgs {
+		msg, _ := msgs[i].GetString("Message")
+		if len(msg) > 0 {
+			info.Messages = append(info.Messages, msg)
+		}
+	}
+	if len(info.TaskState) == 0 {
+		// task monitor returns the operation result once the task is done
+		info.TaskState = TASK_STATE_COMPLETED
+	}
+	return info
+}
+
+func (r *SBaseRedfishClient) GetTaskInfo(ctx context.Context, path string) (STaskInfo, error) {
+	resp, err := r.Get(ctx, path)
+	if err != nil {
+		return STaskInfo{Path: path}, errors.Wrapf(err, "r.Get %s", path)
+	}
+	return ParseTaskInfo(path, resp), nil
+}
+
+func (r *SBaseRedfishClient) getMemberPaths(ctx context.Context, path string) ([]string, error) {
+	resp, err := r.Get(ctx, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "r.Get %s", path)
+	}
+	members, err := resp.GetArray(r.IRedfishDriver().MemberKey())
+	if err != nil {
+		return nil, errors.Wrap(err, "find member error")
+	}
+	ret := make([]string, 0, len(members))
+	for i := range members {
+		memberPath, _ := members[i].GetString(r.IRedfishDriver().LinkKey())
+		if len(memberPath) > 0 {
+			ret = append(ret, memberPath)
+		}
+	}
+	return ret, nil
+}
+
+func (r *SBaseRedfishClient) fetchDrive(ctx context.Context, path string) (SDrive, error) {
+	drive := SDrive{Path: path}
+	resp, err := r.Get(ctx, path)
+	if err != nil {
+		return drive, errors.Wrapf(err, "r.Get %s", path)
+	}
+	err = resp.Unmarshal(&drive)
+	if err != nil {
+		return drive, errors.Wrap(err, "Unmarshal drive")
+	}
+	drive.Path = path
+	drive.Location, _ = resp.GetString("PhysicalLocation", "PartLocation", "ServiceLabel")
+	return drive, nil
+}
+
+func (r *SBaseRedfishClient) fetchVolume(ctx context.Context, path string) (SVolume, error) {
+	vol := SVolume{Path: path}
+	resp, err := r.Get(ctx, path)
+	if err != nil {
+		return vol, errors.Wrapf(err, "r.Get %s", path)
+	}
+	vol.Id, _ = resp.GetString("Id")
+	vol.Name, _ = resp.GetString("Name")
+	vol.RAIDType, _ = resp.GetString("RAIDType")
+	if len(vol.RAIDType) == 0 {
+		vol.RAIDType, _ = resp.GetString("VolumeType")
+	}
+	vol.CapacityBytes, _ = resp.Int("CapacityBytes")
+	vol.Status, _ = resp.GetString("Status", "Health")
+	drives, _ := resp.GetArray("Links", "Drives")
+	for i := range drives {
+		drivePath, _ := drives[i].GetString(r.IRedfishDriver().LinkKey())
+		if len(drivePath) > 0 {
+			vol.Drives = append(vol.Drives, drivePath[strings.LastIndex(strings.TrimRight(drivePath, "/"), "/")+1:])
+		}
+	}
+	return vol, nil
+}
+
+func (r *SBaseRedfishClient) GetStorages(ctx context.Context) ([]SStorageInfo, error) {
+	_, system, err := r.GetResource(ctx, "Systems", "0")
+	if err != nil {
+		return nil, errors.Wrap(err, "GetResource Systems 0")
+	}
+	storagePath, err := system.GetString("Storage", r.IRedfishDriver().LinkKey())
+	if err != nil {
+		return nil, errors.Wrap(httperrors.ErrNotSupported, "no Storage collection")
+	}
+	paths, err := r.getMemberPaths(ctx, storagePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "getMemberPaths Storage")
+	}
+	ret := make([]SStorageInfo, 0, len(paths))
+	for _, path := range paths {
+		resp, err := r.Get(ctx, path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "r.Get %s", path)
+		}
+		stor := SStorageInfo{Path: path}
+		stor.Id, _ = resp.GetString("Id")
+		stor.Name, _ = resp.GetString("Name")
+		drives, _ := resp.GetArray("Drives")
+		for i := range drives {
+			drivePath, _ := drives[i].GetString(r.IRedfishDriver().LinkKey())
+			if len(drivePath) == 0 {
+				continue
+			}
+			drive, err := r.fetchDrive(ctx, drivePath)
+			if err != nil {
+				return nil, errors.Wrap(err, "fetchDrive")
+			}
+			stor.Drives = append(stor.Drives, drive)
+		}
+		stor.VolumesPath, _ = resp.GetString("Volumes", r.IRedfishDriver().LinkKey())
+		if len(stor.VolumesPath) > 0 {
+			volPaths, err := r.getMemberPaths(ctx, stor.VolumesPath)
+			if err != nil {
+				return nil, errors.Wrap(err, "getMemberPaths Volumes")
+			}
+			for _, volPath := range volPaths {
+				vol, err := r.fetchVolume(ctx, volPath)
+				if err != nil {
+					return nil, errors.Wrap(err, "fetchVolume")
+				}
+				stor.Volumes = append(stor.Volumes, vol)
+			}
+		}
+		ret = append(ret, stor)
+	}
+	return ret, nil
+}
+
+func FindStorage(storages []SStorageInfo, id string) (*SStorageInfo, error) {
+	for i := range storages {
+		if len(id) == 0 || storages[i].Id == id {
+			return &storages[i], nil
+		}
+	}
+	return nil, errors.Wrapf(httperrors.ErrNotFound, "storage %q", id)
+}
+
+func (stor *SStorageInfo) FindDrive(id string) (*SDrive, error) {
+	for i := range stor.Drives {
+		if stor.Drives[i].Id == id || (len(stor.Drives[i].Location) > 0 && stor.Drives[i].Location == id) {
+			return &stor.Drives[i], nil
+		}
+	}
+	return nil, errors.Wrapf(httperrors.ErrNotFound, "drive %q", id)
+}
+
+func (stor *SStorageInfo) FindVolume(id string) (*SVolume, error) {
+	for i := range stor.Volumes {
+		if stor.Volumes[i].Id == id || stor.Volumes[i].Name == id {
+			return &stor.Volumes[i], nil
+		}
+	}
+	return nil, errors.Wrapf(httperrors.ErrNotFound, "volume %q", id)
+}
+
+func (r *SBaseRedfishClient) CreateVolume(ctx context.Context, params SVolumeCreateParams) (string, error) {
+	storages, err := r.IRedfishDriver().GetStorages(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "GetStorages")
+	}
+	stor, err := FindStorage(storages, params.Storage)
+	if err != nil {
+		return "", errors.Wrap(err, "FindStorage")
+	}
+	if len(stor.VolumesPath) == 0 {
+		return "", errors.Wrapf(httperrors.ErrNotSupported, "storage %s has no Volumes collection", stor.Id)
+	}
+	drives := make([]jsonutils.JSONObject, 0, len(params.Drives))
+	for _, id := range params.Drives {
+		drive, err := stor.FindDrive(id)
+		if err != nil {
+			return "", errors.Wrap(err, "FindDrive")
+		}
+		link := jsonutils.NewDict()
+		link.Add(jsonutils.NewString(drive.Path), r.IRedfishDriver().LinkKey())
+		drives = append(drives, link)
+	}
+	body := jsonutils.NewDict()
+	if len(params.Name) > 0 {
+		body.Add(jsonutils.NewString(params.Name), "Name")
+	}
+	body.Add(jsonutils.NewString(params.RAIDType), "RAIDType")
+	if params.CapacityBytes > 0 {
+		body.Add(jsonutils.NewInt(params.CapacityBytes), "CapacityBytes")
+	}
+	body.Add(jsonutils.NewArray(drives...), "Links", "Drives")
+	hdr, resp, err := r.Post(ctx, stor.VolumesPath, body)
+	if err != nil {
+		return "", errors.Wrapf(err, "r.Post %s", stor.VolumesPath)
+	}
+	return r.TaskPathFromResponse(hdr, resp), nil
+}
+
+func (r *SBaseRedfishClient) DeleteVolume(ctx context.Context, storage string, volume string) (string, error) {
+	storages, err := r.IRedfishDriver().GetStorages(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "GetStorages")
+	}
+	stor, err := FindStorage(storages, storage)
+	if err != nil {
+		return "", errors.Wrap(err, "FindStorage")
+	}
+	vol, err := stor.FindVolume(volume)
+	if err != nil {
+		return "", errors.Wrap(err, "FindVolume")
+	}
+	hdr, resp, err := r.Delete(ctx, vol.Path)
+	if err != nil {
+		return "", errors.Wrapf(err, "r.Delete %s", vol.Path)
+	}
+	return r.TaskPathFromResponse(hdr, resp), nil
+}
+
 func (r *SBaseRedfishClient) GetIndicatorLEDInternal(ctx context.Context, subsys string) (string, string, error) {
 	path, resp, err := r.GetResource(ctx, subsys, "0")
 	if err != nil {
@@ -849,3 +1180,87 @@ func (r *SBaseRedfishClient) GetLanConfigs(ctx context.Context) ([]types.SIPMILa
 	}
 	return ret, nil
 }
+
+func (r *SBaseRedfishClient) getEventService(ctx context.Context) (jsonutils.JSONObject, string, error) {
+	_, eventSvc, err := r.GetResource(ctx, "EventService")
+	if err != nil {
+		return nil, "", errors.Wrap(err, "GetResource EventService")
+	}
+	if enabled, err := eventSvc.Bool("ServiceEnabled"); err == nil && !enabled {
+		return nil, "", errors.Wrap(httperrors.ErrNotSupported, "EventService disabled")
+	}
+	subsPath, err := eventSvc.GetString("Subscriptions", r.IRedfishDriver().LinkKey())
+	if err != nil {
+		return nil, "", errors.Wrap(httperrors.ErrNotSupported, "no EventService Subscriptions")
+	}
+	return eventSvc, subsPath, nil
+}
+
+func (r *SBaseRedfishClient) GetEventSubscriptions(ctx context.Context) ([]SEventSubscription, error) {
+	_, subsPath, err := r.getEventService(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getEventService")
+	}
+	paths, err := r.getMemberPaths(ctx, subsPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "getMemberPaths")
+	}
+	ret := make([]SEventSubscription, 0, len(paths))
+	for _, path := range paths {
+		resp, err := r.Get(ctx, path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "r.Get %s", path)
+		}
+		sub := SEventSubscription{}
+		err = resp.Unmarshal(&sub)
+		if err != nil {
+			return nil, errors.Wrap(err, "Unmarshal SEventSubscription")
+		}
+		sub.Path = path
+		ret = append(ret, sub)
+	}
+	return ret, nil
+}
+
+func (r *SBaseRedfishClient) SubscribeEvents(ctx context.Context, destination string, context string) (string, error) {
+	eventSvc, subsPath, err := r.getEventService(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "getEventService")
+	}
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString(destination), "Destination")
+	params.Add(jsonutils.NewString(context), "Context")
+	params.Add(jsonutils.NewString("Redfish"), "Protocol")
+	// EventTypes is deprecated since EventService 1.3, but still required by many BMCs
+	supported, _ := jsonutils.GetStringArray(eventSvc, "EventTypesForSubscription")
+	eventTypes := make([]string, 0)
+	for _, et := range []string{"Alert", "StatusChange"} {
+		if utils.IsInStringArray(et, supported) {
+			eventTypes = append(eventTypes, et)
+		}
+	}
+	if len(eventTypes) > 0 {
+		params.Add(jsonutils.NewStringArray(eventTypes), "EventTypes")
+	}
+	hdr, resp, err := r.Post(ctx, subsPath, params)
+	if err != nil {
+		return "", errors.Wrapf(err, "r.Post %s", subsPath)
+	}
+	path := hdr.Get("Location")
+	if len(path) == 0 && resp != nil {
+		path, _ = resp.GetString(r.IRedfishDriver().LinkKey())
+	}
+	pos := strings.Index(path, r.IRedfishDriver().BasePath())
+	if pos > 0 {
+		path = path[pos:]
+	}
+	return path, nil
+}
+
+func (r *SBaseRedfishClient) UnsubscribeEvents(ctx context.Context, path string) error {
+	_, _, err := r.Delete(ctx, path)
+	if err != nil {
+		return errors.Wrapf(err, "r.Delete %s", path)
+	}
+	return nil
+}
@@ -21,6 +21,8 @@ import (
 	"strings"
 	"time"
 
+	"yunion.io/x/jsonutils"
+
 	"yunion.io/x/onecloud/pkg/util/influxdb"
 )
 
@@ -61,6 +63,23 @@ type SEvent struct {
 
 type SEventList []SEvent
 
+const (
+	EVENT_TYPE_ALERT = "alert"
+
+	EVENT_SEVERITY_OK       = "OK"
+	EVENT_SEVERITY_WARNING  = "Warning"
+	EVENT_SEVERITY_CRITICAL = "Critical"
+)
+
+type SEventSubscription struct {
+	Id          string   `json:"Id"`
+	Path        string   `json:"Path"`
+	Destination string   `json:"Destination"`
+	Context     string   `json:"Context"`
+	Protocol    string   `json:"Protocol"`
+	EventTypes  []string `json:"EventTypes"`
+}
+
 func (el SEventList) Len() int           { return len(el) }
 func (el SEventList) Swap(i, j int)      { el[i], el[j] = el[j], el[i] }
 func (el SEventList) Less(i, j int) bool { return el[i].Created.Before(el[j].Created) }
@@ -70,6 +89,115 @@ func SortEvents(el []SEvent) {
 }
 
 type SBiosInfo struct {
+	Path              string `json:"Path"`
+	SettingsPath      string `json:"SettingsPath"`
+	AttributeRegistry string `json:"AttributeRegistry"`
+
+	// current effective attributes
+	Attributes jsonutils.JSONObject `json:"Attributes"`
+	// attributes staged in the settings object, applied on next reset
+	PendingAttributes jsonutils.JSONObject `json:"PendingAttributes"`
+}
+
+func (b SBiosInfo) HasPendingAttributes() bool {
+	if b.PendingAttributes == nil {
+		return false
+	}
+	pendings, _ := b.PendingAttributes.GetMap()
+	for k, v := range pendings {
+		if b.Attributes == nil {
+			return true
+		}
+		cur, _ := b.Attributes.Get(k)
+		if cur == nil || cur.String() != v.String() {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	TASK_STATE_NEW         = "New"
+	TASK_STATE_STARTING    = "Starting"
+	TASK_STATE_RUNNING     = "Running"
+	TASK_STATE_SUSPENDED   = "Suspended"
+	TASK_STATE_INTERRUPTED = "Interrupted"
+	TASK_STATE_PENDING     = "Pending"
+	TASK_STATE_STOPPING    = "Stopping"
+	TASK_STATE_COMPLETED   = "Completed"
+	TASK_STATE_KILLED      = "Killed"
+	TASK_STATE_EXCEPTION   = "Exception"
+	TASK_STATE_SERVICE     = "Service"
+	TASK_STATE_CANCELLING  = "Cancelling"
+	TASK_STATE_CANCELLED   = "Cancelled"
+)
+
+type STaskInfo struct {
+	Path            string   `json:"Path"`
+	TaskState       string   `json:"TaskState"`
+	TaskStatus      string   `json:"TaskStatus"`
+	PercentComplete int      `json:"PercentComplete"`
+	Messages        []string `json:"Messages"`
+}
+
+func (t STaskInfo) IsFinished() bool {
+	switch t.TaskState {
+	case TASK_STATE_COMPLETED, TASK_STATE_KILLED, TASK_STATE_EXCEPTION, TASK_STATE_CANCELLED:
+		return true
+	}
+	return false
+}
+
+func (t STaskInfo) IsSuccess() bool {
+	return t.TaskState == TASK_STATE_COMPLETED && (t.TaskStatus == "" || t.TaskStatus == "OK" || t.TaskStatus == "Warning")
+}
+
+type SFirmwareUpdateParams struct {
+	ImageURI         string   `json:"ImageURI"`
+	TransferProtocol string   `json:"TransferProtocol"`
+	Targets          []string `json:"Targets"`
+}
+
+type SDrive struct {
+	Id            string `json:"Id"`
+	Name          string `json:"Name"`
+	Path          string `json:"Path"`
+	Model         string `json:"Model"`
+	SerialNumber  string `json:"SerialNumber"`
+	MediaType     string `json:"MediaType"`
+	Protocol      string `json:"Protocol"`
+	CapacityBytes int64  `json:"CapacityBytes"`
+	// physical location used by vendors addressing drives by bay, e.g. iLO 1I:1:1
+	Location string `json:"Location"`
+}
+
+type SVolume struct {
+	Id            string   `json:"Id"`
+	Name          string   `json:"Name"`
+	Path          string   `json:"Path"`
+	RAIDType      string   `json:"RAIDType"`
+	CapacityBytes int64    `json:"CapacityBytes"`
+	Status        string   `json:"Status"`
+	Drives        []string `json:"Drives"`
+}
+
+type SStorageInfo struct {
+	Id          string    `json:"Id"`
+	Name        string    `json:"Name"`
+	Path        string    `json:"Path"`
+	VolumesPath string    `json:"VolumesPath"`
+	Drives      []SDrive  `json:"Drives"`
+	Volumes     []SVolume `json:"Volumes"`
+}
+
+type SVolumeCreateParams struct {
+	// storage Id, empty for the first storage controller
+	Storage  string `json:"Storage"`
+	Name     string `json:"Name"`
+	RAIDType string `json:"RAIDType"`
+	// drive Ids, or drive locations for vendors addressing drives by bay
+	Drives        []string `json:"Drives"`
+	CapacityBytes int64    `json:"CapacityBytes"`
 }
 
 type SPower struct {
@@ -0,0 +1,205 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redfish
+
+import (
+	"reflect"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+)
+
+func TestParseTaskInfo(t *testing.T) {
+	cases := []struct {
+		name    string
+		resp    string
+		want    STaskInfo
+		finish  bool
+		success bool
+	}{
+		{
+			name: "running task",
+			resp: `{"@odata.id": "/redfish/v1/TaskService/Tasks/JID_123", "Id": "JID_123", "TaskState": "Running", "TaskStatus": "OK", "PercentComplete": 40, "Messages": [{"Message": "Downloading the image."}]}`,
+			want: STaskInfo{
+				TaskState:       TASK_STATE_RUNNING,
+				TaskStatus:      "OK",
+				PercentComplete: 40,
+				Messages:        []string{"Downloading the image."},
+			},
+		},
+		{
+			name: "completed task",
+			resp: `{"TaskState": "Completed", "TaskStatus": "OK", "PercentComplete": 100, "Messages": [{"MessageId": "Base.1.0.Success"}]}`,
+			want: STaskInfo{
+				TaskState:       TASK_STATE_COMPLETED,
+				TaskStatus:      "OK",
+				PercentComplete: 100,
+			},
+			finish:  true,
+			success: true,
+		},
+		{
+			name: "failed task",
+			resp: `{"TaskState": "Exception", "TaskStatus": "Critical", "Messages": [{"Message": "Unable to transfer the image."}]}`,
+			want: STaskInfo{
+				TaskState:  TASK_STATE_EXCEPTION,
+				TaskStatus: "Critical",
+				Messages:   []string{"Unable to transfer the image."},
+			},
+			finish: true,
+		},
+		{
+			name: "operation result of finished task",
+			resp: `{"@odata.id": "/redfish/v1/Systems/1/Storage/1/Volumes/2", "Id": "2", "RAIDType": "RAID1"}`,
+			want: STaskInfo{
+				TaskState: TASK_STATE_COMPLETED,
+			},
+			finish:  true,
+			success: true,
+		},
+		{
+			name: "empty task monitor",
+			want: STaskInfo{
+				TaskState: TASK_STATE_RUNNING,
+			},
+		},
+	}
+	for _, c := range cases {
+		var resp jsonutils.JSONObject
+		if len(c.resp) > 0 {
+			var err error
+			resp, err = jsonutils.ParseString(c.resp)
+			if err != nil {
+				t.Fatalf("%s: parse resp: %v", c.name, err)
+			}
+		}
+		c.want.Path = "/redfish/v1/TaskService/Tasks/1"
+		got := ParseTaskInfo(c.want.Path, resp)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: want %#v, got %#v", c.name, c.want, got)
+		}
+		if got.IsFinished() != c.finish {
+			t.Errorf("%s: want finished %v, got %v", c.name, c.finish, got.IsFinished())
+		}
+		if got.IsSuccess() != c.success {
+			t.Errorf("%s: want success %v, got %v", c.name, c.success, got.IsSuccess())
+		}
+	}
+}
+
+func TestBiosInfoHasPendingAttributes(t *testing.T) {
+	cases := []struct {
+		name    string
+		current string
+		pending string
+		want    bool
+	}{
+		{
+			name:    "no settings object",
+			current: `{"BootMode": "Uefi"}`,
+		},
+		{
+			name:    "settings applied",
+			current: `{"BootMode": "Uefi", "ProcVirtualization": "Enabled"}`,
+			pending: `{"BootMode": "Uefi"}`,
+		},
+		{
+			name:    "settings staged",
+			current: `{"BootMode": "Bios", "ProcVirtualization": "Enabled"}`,
+			pending: `{"BootMode": "Uefi"}`,
+			want:    true,
+		},
+		{
+			name:    "new attribute staged",
+			current: `{"BootMode": "Uefi"}`,
+			pending: `{"SriovGlobalEnable": "Enabled"}`,
+			want:    true,
+		},
+	}
+	for _, c := range cases {
+		info := SBiosInfo{}
+		info.Attributes, _ = jsonutils.ParseString(c.current)
+		if len(c.pending) > 0 {
+			info.PendingAttributes, _ = jsonutils.ParseString(c.pending)
+		}
+		if got := info.HasPendingAttributes(); got != c.want {
+			t.Errorf("%s: want %v, got %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestStorageInfoFind(t *testing.T) {
+	storages := []SStorageInfo{
+		{
+			Id: "RAID.Integrated.1-1",
+			Drives: []SDrive{
+				{Id: "Disk.Bay.0:Enclosure.Internal.0-1:RAID.Integrated.1-1"},
+				{Id: "0", Location: "1I:1:1"},
+			},
+			Volumes: []SVolume{
+				{Id: "Disk.Virtual.0:RAID.Integrated.1-1", Name: "os"},
+			},
+		},
+		{
+			Id: "AHCI.Embedded.1-1",
+		},
+	}
+	cases := []struct {
+		name    string
+		storage string
+		drive   string
+		volume  string
+		wantErr bool
+	}{
+		{
+			name:   "first storage by default",
+			drive:  "Disk.Bay.0:Enclosure.Internal.0-1:RAID.Integrated.1-1",
+			volume: "Disk.Virtual.0:RAID.Integrated.1-1",
+		},
+		{
+			name:    "drive by location and volume by name",
+			storage: "RAID.Integrated.1-1",
+			drive:   "1I:1:1",
+			volume:  "os",
+		},
+		{
+			name:    "unknown storage",
+			storage: "RAID.Slot.1-1",
+			wantErr: true,
+		},
+		{
+			name:    "unknown drive",
+			drive:   "Disk.Bay.9:Enclosure.Internal.0-1:RAID.Integrated.1-1",
+			wantErr: true,
+		},
+		{
+			name:    "unknown volume",
+			volume:  "data",
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		stor, err := FindStorage(storages, c.storage)
+		if err == nil && len(c.drive) > 0 {
+			_, err = stor.FindDrive(c.drive)
+		}
+		if err == nil && len(c.volume) > 0 {
+			_, err = stor.FindVolume(c.volume)
+		}
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: want error %v, got %v", c.name, c.wantErr, err)
+		}
+	}
+}
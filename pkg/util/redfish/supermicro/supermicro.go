@@ -250,3 +250,37 @@ func (r *SSupermicroRefishApi) SetNTPConf(ctx context.Context, conf redfish.SNTP
 	}
 	return nil
 }
+
+func (r *SSupermicroRefishApi) GetBiosSettingsPath(biosPath string, bios jsonutils.JSONObject) string {
+	settingsPath, _ := bios.GetString("@Redfish.Settings", "SettingsObject", r.LinkKey())
+	if len(settingsPath) > 0 {
+		return settingsPath
+	}
+	return httputils.JoinPath(biosPath, "SD")
+}
+
+// Supermicro BMC rejects BIOS changes without the ETag of current BIOS resource
+func (r *SSupermicroRefishApi) SetBiosAttributes(ctx context.Context, attrs jsonutils.JSONObject) error {
+	biosInfo, err := r.GetBiosInfo(ctx)
+	if err != nil {
+		return errors.Wrap(err, "GetBiosInfo")
+	}
+	if len(biosInfo.SettingsPath) == 0 {
+		return errors.Wrap(httperrors.ErrNotSupported, "no bios settings object")
+	}
+	resp, err := r.Get(ctx, biosInfo.Path)
+	if err != nil {
+		return errors.Wrapf(err, "r.Get %s", biosInfo.Path)
+	}
+	etag, _ := resp.GetString("@odata.etag")
+	params := jsonutils.NewDict()
+	params.Add(attrs, "Attributes")
+	resp, err = r.PatchIfMatch(ctx, biosInfo.SettingsPath, etag, params)
+	if err != nil {
+		return errors.Wrapf(err, "r.PatchIfMatch %s", biosInfo.SettingsPath)
+	}
+	if r.IsDebug && resp != nil {
+		log.Debugf("%s", resp.PrettyString())
+	}
+	return nil
+}
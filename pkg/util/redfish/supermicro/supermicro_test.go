@@ -0,0 +1,77 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package supermicro
+
+import (
+	"context"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/util/redfish/test"
+)
+
+func TestSetBiosAttributes(t *testing.T) {
+	cases := []struct {
+		name     string
+		bios     string
+		wantPath string
+		wantEtag string
+	}{
+		{
+			name: "settings object with etag",
+			bios: `{
+				"@odata.id": "/redfish/v1/Systems/1/Bios",
+				"@odata.etag": "\"7e2cc8ef56b1a0e6d3e7c6c10b9b56d1\"",
+				"Attributes": {"QuietBoot": true},
+				"@Redfish.Settings": {"SettingsObject": {"@odata.id": "/redfish/v1/Systems/1/Bios/SD"}}
+			}`,
+			wantPath: "/redfish/v1/Systems/1/Bios/SD",
+			wantEtag: `"7e2cc8ef56b1a0e6d3e7c6c10b9b56d1"`,
+		},
+		{
+			name:     "no settings link nor etag",
+			bios:     `{"@odata.id": "/redfish/v1/Systems/1/Bios", "Attributes": {"QuietBoot": true}}`,
+			wantPath: "/redfish/v1/Systems/1/Bios/SD",
+		},
+	}
+	for _, c := range cases {
+		bmc := test.NewFakeBMC(map[string]string{
+			"/redfish/v1/Systems":           `{"Members": [{"@odata.id": "/redfish/v1/Systems/1"}]}`,
+			"/redfish/v1/Systems/1":         `{"@odata.id": "/redfish/v1/Systems/1", "Bios": {"@odata.id": "/redfish/v1/Systems/1/Bios"}}`,
+			"/redfish/v1/Systems/1/Bios":    c.bios,
+			"/redfish/v1/Systems/1/Bios/SD": `{"Attributes": {}}`,
+		}, nil)
+		api := NewSupermicroRedfishApi(bmc.URL, "ADMIN", "ADMIN", false).(*SSupermicroRefishApi)
+		api.Registries = map[string]string{"Systems": "/redfish/v1/Systems"}
+
+		attrs, _ := jsonutils.ParseString(`{"QuietBoot": false}`)
+		err := api.SetBiosAttributes(context.Background(), attrs)
+		bmc.Close()
+		if err != nil {
+			t.Errorf("%s: SetBiosAttributes: %v", c.name, err)
+			continue
+		}
+		body, _ := jsonutils.ParseString(`{"Attributes": {"QuietBoot": false}}`)
+		got := bmc.Requests()
+		if len(got) != 1 || got[0].Method != "PATCH" || got[0].Path != c.wantPath || !test.SameBody(got[0].Body, body) {
+			t.Errorf("%s: want PATCH %s %s, got %#v", c.name, c.wantPath, body, got)
+			continue
+		}
+		if etag := got[0].Header.Get("If-Match"); etag != c.wantEtag {
+			t.Errorf("%s: want If-Match %q, got %q", c.name, c.wantEtag, etag)
+		}
+	}
+}
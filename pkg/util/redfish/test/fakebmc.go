@@ -0,0 +1,119 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"yunion.io/x/jsonutils"
+)
+
+// SRequest is a modifying request received by the fake BMC
+type SRequest struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   jsonutils.JSONObject
+}
+
+// SReply is the canned reply of a modifying request
+type SReply struct {
+	StatusCode int
+	Location   string
+	Body       string
+}
+
+// SFakeBMC serves GET requests from static resources and records
+// other requests, replying them with the canned replies
+type SFakeBMC struct {
+	*httptest.Server
+
+	// resources keyed by path
+	Resources map[string]string
+	// replies keyed by "METHOD path", 204 for requests not in the map
+	Replies map[string]SReply
+
+	lock     sync.Mutex
+	requests []SRequest
+}
+
+func NewFakeBMC(resources map[string]string, replies map[string]SReply) *SFakeBMC {
+	bmc := &SFakeBMC{
+		Resources: resources,
+		Replies:   replies,
+	}
+	bmc.Server = httptest.NewServer(http.HandlerFunc(bmc.serve))
+	return bmc
+}
+
+func (bmc *SFakeBMC) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		body, ok := bmc.Resources[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+		return
+	}
+	req := SRequest{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header,
+	}
+	data, _ := ioutil.ReadAll(r.Body)
+	if len(data) > 0 {
+		req.Body, _ = jsonutils.Parse(data)
+	}
+	bmc.lock.Lock()
+	bmc.requests = append(bmc.requests, req)
+	bmc.lock.Unlock()
+
+	reply, ok := bmc.Replies[r.Method+" "+r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if len(reply.Location) > 0 {
+		w.Header().Set("Location", reply.Location)
+	}
+	if reply.StatusCode == 0 {
+		reply.StatusCode = http.StatusOK
+	}
+	if len(reply.Body) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(reply.StatusCode)
+	w.Write([]byte(reply.Body))
+}
+
+// Requests returns the modifying requests received so far
+func (bmc *SFakeBMC) Requests() []SRequest {
+	bmc.lock.Lock()
+	defer bmc.lock.Unlock()
+	return append([]SRequest{}, bmc.requests...)
+}
+
+// SameBody tells whether two request bodies are of the same JSON
+func SameBody(a, b jsonutils.JSONObject) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.String() == b.String()
+}
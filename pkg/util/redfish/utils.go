@@ -16,8 +16,15 @@ package redfish
 
 import (
 	"context"
+	"strings"
+	"time"
 
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/httperrors"
 )
 
 func MountVirtualCdrom(ctx context.Context, api IRedfishDriver, cdromUrl string, boot bool) error {
@@ -50,3 +57,96 @@ func UmountVirtualCdrom(ctx context.Context, api IRedfishDriver) error {
 	}
 	return api.UmountVirtualCdrom(ctx, path)
 }
+
+func WaitTask(ctx context.Context, api IRedfishDriver, path string, interval time.Duration, timeout time.Duration) (STaskInfo, error) {
+	info := STaskInfo{Path: path}
+	if len(path) == 0 {
+		// synchronous operation, nothing to wait
+		info.TaskState = TASK_STATE_COMPLETED
+		return info, nil
+	}
+	var err error
+	for waited := time.Duration(0); waited < timeout; waited += interval {
+		info, err = api.GetTaskInfo(ctx, path)
+		if err != nil {
+			return info, errors.Wrap(err, "api.GetTaskInfo")
+		}
+		log.Debugf("redfish task %s: %s %d%%", path, info.TaskState, info.PercentComplete)
+		if info.IsFinished() {
+			if !info.IsSuccess() {
+				return info, errors.Errorf("task %s %s(%s): %s", path, info.TaskState, info.TaskStatus, strings.Join(info.Messages, "; "))
+			}
+			return info, nil
+		}
+		time.Sleep(interval)
+	}
+	return info, errors.Wrapf(httperrors.ErrTimeout, "task %s", path)
+}
+
+// RebootToApply resets the system so that pending settings take effect,
+// the system is powered on if it is off
+func RebootToApply(ctx context.Context, api IRedfishDriver) error {
+	_, sysInfo, err := api.GetSystemInfo(ctx)
+	if err != nil {
+		return errors.Wrap(err, "api.GetSystemInfo")
+	}
+	if strings.EqualFold(sysInfo.PowerState, "off") {
+		return api.Reset(ctx, "On")
+	}
+	for _, action := range []string{"GracefulRestart", "ForceRestart", "PowerCycle"} {
+		if utils.IsInStringArray(action, sysInfo.ResetTypeSupported) {
+			return api.Reset(ctx, action)
+		}
+	}
+	return errors.Wrapf(httperrors.ErrNotSupported, "no restart action in %s", sysInfo.ResetTypeSupported)
+}
+
+func SetBiosAttributes(ctx context.Context, api IRedfishDriver, attrs jsonutils.JSONObject, reboot bool) error {
+	err := api.SetBiosAttributes(ctx, attrs)
+	if err != nil {
+		return errors.Wrap(err, "api.SetBiosAttributes")
+	}
+	if !reboot {
+		return nil
+	}
+	err = RebootToApply(ctx, api)
+	if err != nil {
+		return errors.Wrap(err, "RebootToApply")
+	}
+	return nil
+}
+
+// ParseEvents converts an Event resource pushed by BMC into events
+func ParseEvents(body jsonutils.JSONObject) ([]SEvent, error) {
+	records, err := body.GetArray("Events")
+	if err != nil {
+		return nil, errors.Wrap(err, "GetArray Events")
+	}
+	ret := make([]SEvent, 0, len(records))
+	for i := range records {
+		event := SEvent{Type: EVENT_TYPE_ALERT}
+		event.EventId, _ = records[i].GetString("EventId")
+		if len(event.EventId) == 0 {
+			event.EventId, _ = records[i].GetString("MemberId")
+		}
+		event.Message, _ = records[i].GetString("Message")
+		if len(event.Message) == 0 {
+			event.Message, _ = records[i].GetString("MessageId")
+		}
+		event.Severity, _ = records[i].GetString("MessageSeverity")
+		if len(event.Severity) == 0 {
+			event.Severity, _ = records[i].GetString("Severity")
+		}
+		event.Created, _ = records[i].GetTime("EventTimestamp")
+		if event.Created.IsZero() {
+			// iDRAC omits the colon in zone offset, e.g. 2019-11-25T09:39:03-0600
+			tsStr, _ := records[i].GetString("EventTimestamp")
+			event.Created, _ = time.Parse("2006-01-02T15:04:05-0700", tsStr)
+		}
+		if event.Created.IsZero() {
+			event.Created = time.Now().UTC()
+		}
+		ret = append(ret, event)
+	}
+	return ret, nil
+}
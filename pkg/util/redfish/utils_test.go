@@ -0,0 +1,168 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redfish
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+)
+
+func TestParseEvents(t *testing.T) {
+	cases := []struct {
+		name    string
+		body    string
+		want    []SEvent
+		wantErr bool
+	}{
+		{
+			name: "iDRAC 9",
+			body: `{
+				"@odata.context": "/redfish/v1/$metadata#Event.Event",
+				"@odata.id": "/redfish/v1/EventService/Events/5e004f5a-e3d1-11e9-9bf2-d094662a05e6",
+				"@odata.type": "#Event.v1_2_0.Event",
+				"Context": "c4a3e5a3b1d6e0f6a7c1d5e8b9a0f2c3",
+				"Events": [
+					{
+						"EventId": "2162",
+						"EventTimestamp": "2019-11-25T09:39:03-0600",
+						"EventType": "Alert",
+						"MemberId": "7e675c8e-127a-11ea-8d4b-d094662a05e6",
+						"Message": "The system inlet temperature is less than the lower warning threshold.",
+						"MessageArgs": [],
+						"MessageId": "TMP0118",
+						"OriginOfCondition": {"@odata.id": "/redfish/v1/Chassis/System.Embedded.1"},
+						"Severity": "Warning"
+					},
+					{
+						"EventId": "8508",
+						"EventTimestamp": "2019-11-25T09:40:12-0600",
+						"EventType": "Alert",
+						"MemberId": "a3d1f2c4-127a-11ea-8d4b-d094662a05e6",
+						"Message": "Power supply redundancy is lost.",
+						"MessageId": "RDU0012",
+						"Severity": "Critical"
+					}
+				],
+				"Id": "5e004f5a-e3d1-11e9-9bf2-d094662a05e6",
+				"Name": "Event Array"
+			}`,
+			want: []SEvent{
+				{
+					Created:  time.Date(2019, 11, 25, 15, 39, 3, 0, time.UTC),
+					EventId:  "2162",
+					Message:  "The system inlet temperature is less than the lower warning threshold.",
+					Severity: EVENT_SEVERITY_WARNING,
+					Type:     EVENT_TYPE_ALERT,
+				},
+				{
+					Created:  time.Date(2019, 11, 25, 15, 40, 12, 0, time.UTC),
+					EventId:  "8508",
+					Message:  "Power supply redundancy is lost.",
+					Severity: EVENT_SEVERITY_CRITICAL,
+					Type:     EVENT_TYPE_ALERT,
+				},
+			},
+		},
+		{
+			name: "iLO 5",
+			body: `{
+				"@odata.type": "#Event.v1_0_0.Event",
+				"Context": "c4a3e5a3b1d6e0f6a7c1d5e8b9a0f2c3",
+				"Events": [
+					{
+						"EventTimestamp": "2020-05-26T08:12:43Z",
+						"EventType": "Alert",
+						"MemberId": "0",
+						"MessageArgs": ["1"],
+						"MessageId": "iLOEvents.2.3.PowerSupplyFailed",
+						"OriginOfCondition": {"@odata.id": "/redfish/v1/Chassis/1/Power/"},
+						"Severity": "Critical"
+					}
+				],
+				"Id": "1",
+				"Name": "Events"
+			}`,
+			want: []SEvent{
+				{
+					Created:  time.Date(2020, 5, 26, 8, 12, 43, 0, time.UTC),
+					EventId:  "0",
+					Message:  "iLOEvents.2.3.PowerSupplyFailed",
+					Severity: EVENT_SEVERITY_CRITICAL,
+					Type:     EVENT_TYPE_ALERT,
+				},
+			},
+		},
+		{
+			name: "MessageSeverity of Event v1.3",
+			body: `{
+				"Events": [
+					{
+						"EventId": "1",
+						"EventTimestamp": "2021-03-01T00:00:00Z",
+						"Message": "Drive 1 is removed.",
+						"MessageSeverity": "Warning",
+						"Severity": "OK"
+					}
+				]
+			}`,
+			want: []SEvent{
+				{
+					Created:  time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+					EventId:  "1",
+					Message:  "Drive 1 is removed.",
+					Severity: EVENT_SEVERITY_WARNING,
+					Type:     EVENT_TYPE_ALERT,
+				},
+			},
+		},
+		{
+			name:    "not an event",
+			body:    `{"@odata.id": "/redfish/v1/EventService", "ServiceEnabled": true}`,
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		body, err := jsonutils.ParseString(c.body)
+		if err != nil {
+			t.Fatalf("%s: parse body: %v", c.name, err)
+		}
+		got, err := ParseEvents(body)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: want error", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: ParseEvents: %v", c.name, err)
+			continue
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("%s: want %d events, got %d", c.name, len(c.want), len(got))
+			continue
+		}
+		for i := range c.want {
+			if !got[i].Created.Equal(c.want[i].Created) {
+				t.Errorf("%s: want event %d created at %s, got %s", c.name, i, c.want[i].Created, got[i].Created)
+			}
+			got[i].Created = c.want[i].Created
+			if got[i] != c.want[i] {
+				t.Errorf("%s: want event %d %#v, got %#v", c.name, i, c.want[i], got[i])
+			}
+		}
+	}
+}
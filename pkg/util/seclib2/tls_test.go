@@ -14,7 +14,11 @@
 
 package seclib2
 
-import "testing"
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+)
 
 func TestSplitCert(t *testing.T) {
 	PEM := `-----BEGIN CERTIFICATE-----
@@ -31,3 +35,17 @@ Fc5CzfQAhw57y6LmnPVoKAE/TFHvvSFNxjwSaBCGQ46FfnZMjs48a2xwHaqwAw==
 		t.Logf("\n%s\n", string(pems[i]))
 	}
 }
+
+func TestGenerateSelfSignedCert(t *testing.T) {
+	certPEM, keyPEM, err := GenerateSelfSignedCert("baremetal", []string{"127.0.0.1", "localhost"}, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateSelfSignedCert: %s", err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("X509KeyPair: %s", err)
+	}
+	if len(cert.Certificate) != 1 {
+		t.Errorf("expect 1 certificate, got %d", len(cert.Certificate))
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sysutils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseCpuList parses cpu list format of sysfs, e.g. 0-3,8-11
+func ParseCpuList(cpuList string) ([]int, error) {
+	cpus := make([]int, 0)
+	cpuList = strings.TrimSpace(cpuList)
+	if len(cpuList) == 0 {
+		return cpus, nil
+	}
+	for _, seg := range strings.Split(cpuList, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(seg), "-", 2)
+		start, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cpu list %q", cpuList)
+		}
+		end := start
+		if len(bounds) == 2 {
+			end, err = strconv.Atoi(bounds[1])
+			if err != nil || end < start {
+				return nil, fmt.Errorf("invalid cpu list %q", cpuList)
+			}
+		}
+		for i := start; i <= end; i++ {
+			cpus = append(cpus, i)
+		}
+	}
+	return cpus, nil
+}
+
+// FormatCpuList formats cpus in cpu list format accepted by taskset
+func FormatCpuList(cpus []int) string {
+	sorted := make([]int, len(cpus))
+	copy(sorted, cpus)
+	sort.Ints(sorted)
+	segs := make([]string, 0)
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
+		}
+		if i == j {
+			segs = append(segs, strconv.Itoa(sorted[i]))
+		} else {
+			segs = append(segs, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(segs, ",")
+}
+
+// ParseNodeMeminfo parses /sys/devices/system/node/nodeN/meminfo and
+// returns total and free memory of node in MB
+func ParseNodeMeminfo(lines []string) (int, int) {
+	var total, free int
+	for _, line := range lines {
+		// Node 0 MemTotal:       32768000 kB
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		val, err := strconv.Atoi(fields[3])
+		if err != nil {
+			continue
+		}
+		switch fields[2] {
+		case "MemTotal:":
+			total = val / 1024
+		case "MemFree:":
+			free = val / 1024
+		}
+	}
+	return total, free
+}
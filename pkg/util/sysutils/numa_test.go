@@ -0,0 +1,74 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sysutils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCpuList(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []int
+		wantErr bool
+	}{
+		{"", []int{}, false},
+		{"0", []int{0}, false},
+		{"0-3,8-9\n", []int{0, 1, 2, 3, 8, 9}, false},
+		{"1,3,5", []int{1, 3, 5}, false},
+		{"3-1", nil, true},
+		{"a-b", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCpuList(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCpuList(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseCpuList(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatCpuList(t *testing.T) {
+	tests := []struct {
+		in   []int
+		want string
+	}{
+		{[]int{}, ""},
+		{[]int{0}, "0"},
+		{[]int{9, 8, 0, 1, 2, 3}, "0-3,8-9"},
+		{[]int{1, 3, 5}, "1,3,5"},
+	}
+	for _, tt := range tests {
+		if got := FormatCpuList(tt.in); got != tt.want {
+			t.Errorf("FormatCpuList(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseNodeMeminfo(t *testing.T) {
+	content := `Node 0 MemTotal:       32768000 kB
+Node 0 MemFree:        16384000 kB
+Node 0 MemUsed:        16384000 kB
+Node 0 HugePages_Total:     0`
+	total, free := ParseNodeMeminfo(strings.Split(content, "\n"))
+	if total != 32000 || free != 16000 {
+		t.Errorf("ParseNodeMeminfo = %d, %d, want 32000, 16000", total, free)
+	}
+}
@@ -21,8 +21,11 @@ import (
 type Vpc struct {
 	compute_models.SVpc
 
-	Wire     *Wire    `json:"-"`
-	Networks Networks `json:"-"`
+	Wire          *Wire         `json:"-"`
+	Networks      Networks      `json:"-"`
+	RouteTables   RouteTables   `json:"-"`
+	NatGateways   NatGateways   `json:"-"`
+	Loadbalancers Loadbalancers `json:"-"`
 }
 
 func (el *Vpc) Copy() *Vpc {
@@ -165,6 +168,69 @@ func (el *Elasticip) Copy() *Elasticip {
 	}
 }
 
+type RouteTable struct {
+	compute_models.SRouteTable
+
+	Vpc                    *Vpc                   `json:"-"`
+	RouteTableAssociations RouteTableAssociations `json:"-"`
+}
+
+func (el *RouteTable) Copy() *RouteTable {
+	return &RouteTable{
+		SRouteTable: el.SRouteTable,
+	}
+}
+
+type RouteTableAssociation struct {
+	compute_models.SRouteTableAssociation
+
+	RouteTable *RouteTable `json:"-"`
+}
+
+func (el *RouteTableAssociation) Copy() *RouteTableAssociation {
+	return &RouteTableAssociation{
+		SRouteTableAssociation: el.SRouteTableAssociation,
+	}
+}
+
+type NatGateway struct {
+	compute_models.SNatGateway
+
+	Vpc         *Vpc        `json:"-"`
+	NatSEntries NatSEntries `json:"-"`
+	NatDEntries NatDEntries `json:"-"`
+}
+
+func (el *NatGateway) Copy() *NatGateway {
+	return &NatGateway{
+		SNatGateway: el.SNatGateway,
+	}
+}
+
+type NatSEntry struct {
+	compute_models.SNatSEntry
+
+	NatGateway *NatGateway `json:"-"`
+}
+
+func (el *NatSEntry) Copy() *NatSEntry {
+	return &NatSEntry{
+		SNatSEntry: el.SNatSEntry,
+	}
+}
+
+type NatDEntry struct {
+	compute_models.SNatDEntry
+
+	NatGateway *NatGateway `json:"-"`
+}
+
+func (el *NatDEntry) Copy() *NatDEntry {
+	return &NatDEntry{
+		SNatDEntry: el.SNatDEntry,
+	}
+}
+
 type DnsRecord struct {
 	compute_models.SDnsRecord
 }
@@ -174,3 +240,77 @@ func (el *DnsRecord) Copy() *DnsRecord {
 		SDnsRecord: el.SDnsRecord,
 	}
 }
+
+type LoadbalancerCluster struct {
+	compute_models.SLoadbalancerCluster
+}
+
+func (el *LoadbalancerCluster) Copy() *LoadbalancerCluster {
+	return &LoadbalancerCluster{
+		SLoadbalancerCluster: el.SLoadbalancerCluster,
+	}
+}
+
+type Loadbalancer struct {
+	compute_models.SLoadbalancer
+
+	Vpc                   *Vpc                  `json:"-"`
+	LoadbalancerCluster   *LoadbalancerCluster  `json:"-"`
+	LoadbalancerNetworks  LoadbalancerNetworks  `json:"-"`
+	LoadbalancerListeners LoadbalancerListeners `json:"-"`
+}
+
+func (el *Loadbalancer) Copy() *Loadbalancer {
+	return &Loadbalancer{
+		SLoadbalancer: el.SLoadbalancer,
+	}
+}
+
+type LoadbalancerNetwork struct {
+	compute_models.SLoadbalancerNetwork
+
+	Loadbalancer *Loadbalancer `json:"-"`
+}
+
+func (el *LoadbalancerNetwork) Copy() *LoadbalancerNetwork {
+	return &LoadbalancerNetwork{
+		SLoadbalancerNetwork: el.SLoadbalancerNetwork,
+	}
+}
+
+type LoadbalancerListener struct {
+	compute_models.SLoadbalancerListener
+
+	Loadbalancer             *Loadbalancer             `json:"-"`
+	LoadbalancerBackendGroup *LoadbalancerBackendGroup `json:"-"`
+}
+
+func (el *LoadbalancerListener) Copy() *LoadbalancerListener {
+	return &LoadbalancerListener{
+		SLoadbalancerListener: el.SLoadbalancerListener,
+	}
+}
+
+type LoadbalancerBackendGroup struct {
+	compute_models.SLoadbalancerBackendGroup
+
+	LoadbalancerBackends LoadbalancerBackends `json:"-"`
+}
+
+func (el *LoadbalancerBackendGroup) Copy() *LoadbalancerBackendGroup {
+	return &LoadbalancerBackendGroup{
+		SLoadbalancerBackendGroup: el.SLoadbalancerBackendGroup,
+	}
+}
+
+type LoadbalancerBackend struct {
+	compute_models.SLoadbalancerBackend
+
+	LoadbalancerBackendGroup *LoadbalancerBackendGroup `json:"-"`
+}
+
+func (el *LoadbalancerBackend) Copy() *LoadbalancerBackend {
+	return &LoadbalancerBackend{
+		SLoadbalancerBackend: el.SLoadbalancerBackend,
+	}
+}
@@ -37,6 +37,20 @@ type (
 	Elasticips         map[string]*Elasticip
 	NetworkAddresses   map[string]*NetworkAddress
 
+	RouteTables            map[string]*RouteTable
+	RouteTableAssociations map[string]*RouteTableAssociation
+
+	NatGateways map[string]*NatGateway
+	NatSEntries map[string]*NatSEntry
+	NatDEntries map[string]*NatDEntry
+
+	LoadbalancerClusters      map[string]*LoadbalancerCluster
+	Loadbalancers             map[string]*Loadbalancer
+	LoadbalancerNetworks      map[string]*LoadbalancerNetwork // key: rowId
+	LoadbalancerListeners     map[string]*LoadbalancerListener
+	LoadbalancerBackendGroups map[string]*LoadbalancerBackendGroup
+	LoadbalancerBackends      map[string]*LoadbalancerBackend
+
 	Guestnetworks  map[string]*Guestnetwork  // key: rowId
 	Guestsecgroups map[string]*Guestsecgroup // key: guestId/secgroupId
 
@@ -117,6 +131,25 @@ func (ms Vpcs) joinNetworks(subEntries Networks) bool {
 	return correct
 }
 
+func (ms Vpcs) joinRouteTables(subEntries RouteTables) bool {
+	for _, m := range ms {
+		m.RouteTables = RouteTables{}
+	}
+	for subId, subEntry := range subEntries {
+		id := subEntry.VpcId
+		m, ok := ms[id]
+		if !ok {
+			log.Warningf("route table %s(%s): vpc id %s not found",
+				subEntry.Name, subEntry.Id, id)
+			delete(subEntries, subId)
+			continue
+		}
+		subEntry.Vpc = m
+		m.RouteTables[subId] = subEntry
+	}
+	return true
+}
+
 func (set Wires) ModelManager() mcclient_modulebase.IBaseManager {
 	return &mcclient_modules.Wires
 }
@@ -611,6 +644,415 @@ func (set Elasticips) Copy() apihelper.IModelSet {
 	return setCopy
 }
 
+func (set RouteTables) ModelManager() mcclient_modulebase.IBaseManager {
+	return &mcclient_modules.RouteTables
+}
+
+func (set RouteTables) NewModel() db.IModel {
+	return &RouteTable{}
+}
+
+func (set RouteTables) AddModel(i db.IModel) {
+	m := i.(*RouteTable)
+	set[m.Id] = m
+}
+
+func (set RouteTables) Copy() apihelper.IModelSet {
+	setCopy := RouteTables{}
+	for id, el := range set {
+		setCopy[id] = el.Copy()
+	}
+	return setCopy
+}
+
+func (ms RouteTables) joinRouteTableAssociations(subEntries RouteTableAssociations) bool {
+	for _, m := range ms {
+		m.RouteTableAssociations = RouteTableAssociations{}
+	}
+	for subId, subEntry := range subEntries {
+		id := subEntry.RouteTableId
+		m, ok := ms[id]
+		if !ok {
+			log.Warningf("route table association %s: route table %s not found",
+				subEntry.Id, id)
+			continue
+		}
+		subEntry.RouteTable = m
+		m.RouteTableAssociations[subId] = subEntry
+	}
+	return true
+}
+
+func (set RouteTableAssociations) ModelManager() mcclient_modulebase.IBaseManager {
+	return &mcclient_modules.RouteTableRouteAssociations
+}
+
+func (set RouteTableAssociations) NewModel() db.IModel {
+	return &RouteTableAssociation{}
+}
+
+func (set RouteTableAssociations) AddModel(i db.IModel) {
+	m := i.(*RouteTableAssociation)
+	set[m.Id] = m
+}
+
+func (set RouteTableAssociations) Copy() apihelper.IModelSet {
+	setCopy := RouteTableAssociations{}
+	for id, el := range set {
+		setCopy[id] = el.Copy()
+	}
+	return setCopy
+}
+
+func (ms Vpcs) joinNatGateways(subEntries NatGateways) bool {
+	for _, m := range ms {
+		m.NatGateways = NatGateways{}
+	}
+	for subId, subEntry := range subEntries {
+		id := subEntry.VpcId
+		m, ok := ms[id]
+		if !ok {
+			log.Warningf("nat gateway %s(%s): vpc id %s not found",
+				subEntry.Name, subEntry.Id, id)
+			delete(subEntries, subId)
+			continue
+		}
+		subEntry.Vpc = m
+		m.NatGateways[subId] = subEntry
+	}
+	return true
+}
+
+func (set NatGateways) ModelManager() mcclient_modulebase.IBaseManager {
+	return &mcclient_modules.NatGateways
+}
+
+func (set NatGateways) NewModel() db.IModel {
+	return &NatGateway{}
+}
+
+func (set NatGateways) AddModel(i db.IModel) {
+	m := i.(*NatGateway)
+	set[m.Id] = m
+}
+
+func (set NatGateways) Copy() apihelper.IModelSet {
+	setCopy := NatGateways{}
+	for id, el := range set {
+		setCopy[id] = el.Copy()
+	}
+	return setCopy
+}
+
+func (ms NatGateways) joinNatSEntries(subEntries NatSEntries) bool {
+	for _, m := range ms {
+		m.NatSEntries = NatSEntries{}
+	}
+	for subId, subEntry := range subEntries {
+		id := subEntry.NatgatewayId
+		m, ok := ms[id]
+		if !ok {
+			log.Warningf("snat entry %s(%s): nat gateway %s not found",
+				subEntry.Name, subEntry.Id, id)
+			continue
+		}
+		subEntry.NatGateway = m
+		m.NatSEntries[subId] = subEntry
+	}
+	return true
+}
+
+func (ms NatGateways) joinNatDEntries(subEntries NatDEntries) bool {
+	for _, m := range ms {
+		m.NatDEntries = NatDEntries{}
+	}
+	for subId, subEntry := range subEntries {
+		id := subEntry.NatgatewayId
+		m, ok := ms[id]
+		if !ok {
+			log.Warningf("dnat entry %s(%s): nat gateway %s not found",
+				subEntry.Name, subEntry.Id, id)
+			continue
+		}
+		subEntry.NatGateway = m
+		m.NatDEntries[subId] = subEntry
+	}
+	return true
+}
+
+func (set NatSEntries) ModelManager() mcclient_modulebase.IBaseManager {
+	return &mcclient_modules.NatSTable
+}
+
+func (set NatSEntries) NewModel() db.IModel {
+	return &NatSEntry{}
+}
+
+func (set NatSEntries) AddModel(i db.IModel) {
+	m := i.(*NatSEntry)
+	set[m.Id] = m
+}
+
+func (set NatSEntries) Copy() apihelper.IModelSet {
+	setCopy := NatSEntries{}
+	for id, el := range set {
+		setCopy[id] = el.Copy()
+	}
+	return setCopy
+}
+
+func (set NatDEntries) ModelManager() mcclient_modulebase.IBaseManager {
+	return &mcclient_modules.NatDTable
+}
+
+func (set NatDEntries) NewModel() db.IModel {
+	return &NatDEntry{}
+}
+
+func (set NatDEntries) AddModel(i db.IModel) {
+	m := i.(*NatDEntry)
+	set[m.Id] = m
+}
+
+func (set NatDEntries) Copy() apihelper.IModelSet {
+	setCopy := NatDEntries{}
+	for id, el := range set {
+		setCopy[id] = el.Copy()
+	}
+	return setCopy
+}
+
+func (ms Vpcs) joinLoadbalancers(subEntries Loadbalancers) bool {
+	for _, m := range ms {
+		m.Loadbalancers = Loadbalancers{}
+	}
+	for subId, subEntry := range subEntries {
+		id := subEntry.VpcId
+		m, ok := ms[id]
+		if !ok {
+			// classic loadbalancers or those of other clouds
+			continue
+		}
+		subEntry.Vpc = m
+		m.Loadbalancers[subId] = subEntry
+	}
+	return true
+}
+func (set LoadbalancerClusters) ModelManager() mcclient_modulebase.IBaseManager {
+	return &mcclient_modules.LoadbalancerClusters
+}
+
+func (set LoadbalancerClusters) NewModel() db.IModel {
+	return &LoadbalancerCluster{}
+}
+
+func (set LoadbalancerClusters) AddModel(i db.IModel) {
+	m := i.(*LoadbalancerCluster)
+	set[m.Id] = m
+}
+
+func (set LoadbalancerClusters) Copy() apihelper.IModelSet {
+	setCopy := LoadbalancerClusters{}
+	for id, el := range set {
+		setCopy[id] = el.Copy()
+	}
+	return setCopy
+}
+
+func (set Loadbalancers) ModelManager() mcclient_modulebase.IBaseManager {
+	return &mcclient_modules.Loadbalancers
+}
+
+func (set Loadbalancers) NewModel() db.IModel {
+	return &Loadbalancer{}
+}
+
+func (set Loadbalancers) AddModel(i db.IModel) {
+	m := i.(*Loadbalancer)
+	set[m.Id] = m
+}
+
+func (set Loadbalancers) Copy() apihelper.IModelSet {
+	setCopy := Loadbalancers{}
+	for id, el := range set {
+		setCopy[id] = el.Copy()
+	}
+	return setCopy
+}
+
+func (ms Loadbalancers) joinLoadbalancerClusters(subEntries LoadbalancerClusters) bool {
+	for _, m := range ms {
+		m.LoadbalancerCluster = nil
+		if m.ClusterId == "" {
+			continue
+		}
+		subEntry, ok := subEntries[m.ClusterId]
+		if !ok {
+			log.Warningf("loadbalancer %s(%s): cluster id %s not found",
+				m.Name, m.Id, m.ClusterId)
+			continue
+		}
+		m.LoadbalancerCluster = subEntry
+	}
+	return true
+}
+
+func (ms Loadbalancers) joinLoadbalancerNetworks(subEntries LoadbalancerNetworks) bool {
+	for _, m := range ms {
+		m.LoadbalancerNetworks = LoadbalancerNetworks{}
+	}
+	for subId, subEntry := range subEntries {
+		id := subEntry.LoadbalancerId
+		m, ok := ms[id]
+		if !ok {
+			log.Warningf("loadbalancernetwork (net:%s,ip:%s): loadbalancer id %s not found",
+				subEntry.NetworkId, subEntry.IpAddr, id)
+			continue
+		}
+		subEntry.Loadbalancer = m
+		m.LoadbalancerNetworks[subId] = subEntry
+	}
+	return true
+}
+
+func (ms Loadbalancers) joinLoadbalancerListeners(subEntries LoadbalancerListeners) bool {
+	for _, m := range ms {
+		m.LoadbalancerListeners = LoadbalancerListeners{}
+	}
+	for subId, subEntry := range subEntries {
+		id := subEntry.LoadbalancerId
+		m, ok := ms[id]
+		if !ok {
+			log.Warningf("loadbalancer listener %s(%s): loadbalancer id %s not found",
+				subEntry.Name, subEntry.Id, id)
+			continue
+		}
+		subEntry.Loadbalancer = m
+		m.LoadbalancerListeners[subId] = subEntry
+	}
+	return true
+}
+
+func (set LoadbalancerNetworks) ModelManager() mcclient_modulebase.IBaseManager {
+	return &mcclient_modules.Loadbalancernetworks
+}
+
+func (set LoadbalancerNetworks) NewModel() db.IModel {
+	return &LoadbalancerNetwork{}
+}
+
+func (set LoadbalancerNetworks) AddModel(i db.IModel) {
+	m := i.(*LoadbalancerNetwork)
+	k := fmt.Sprintf("%d", m.RowId)
+	set[k] = m
+}
+
+func (set LoadbalancerNetworks) Copy() apihelper.IModelSet {
+	setCopy := LoadbalancerNetworks{}
+	for id, el := range set {
+		setCopy[id] = el.Copy()
+	}
+	return setCopy
+}
+
+func (set LoadbalancerListeners) ModelManager() mcclient_modulebase.IBaseManager {
+	return &mcclient_modules.LoadbalancerListeners
+}
+
+func (set LoadbalancerListeners) NewModel() db.IModel {
+	return &LoadbalancerListener{}
+}
+
+func (set LoadbalancerListeners) AddModel(i db.IModel) {
+	m := i.(*LoadbalancerListener)
+	set[m.Id] = m
+}
+
+func (set LoadbalancerListeners) Copy() apihelper.IModelSet {
+	setCopy := LoadbalancerListeners{}
+	for id, el := range set {
+		setCopy[id] = el.Copy()
+	}
+	return setCopy
+}
+
+func (ms LoadbalancerListeners) joinLoadbalancerBackendGroups(subEntries LoadbalancerBackendGroups) bool {
+	for _, m := range ms {
+		m.LoadbalancerBackendGroup = nil
+		if m.BackendGroupId == "" {
+			continue
+		}
+		subEntry, ok := subEntries[m.BackendGroupId]
+		if !ok {
+			log.Warningf("loadbalancer listener %s(%s): backend group id %s not found",
+				m.Name, m.Id, m.BackendGroupId)
+			continue
+		}
+		m.LoadbalancerBackendGroup = subEntry
+	}
+	return true
+}
+
+func (set LoadbalancerBackendGroups) ModelManager() mcclient_modulebase.IBaseManager {
+	return &mcclient_modules.LoadbalancerBackendGroups
+}
+
+func (set LoadbalancerBackendGroups) NewModel() db.IModel {
+	return &LoadbalancerBackendGroup{}
+}
+
+func (set LoadbalancerBackendGroups) AddModel(i db.IModel) {
+	m := i.(*LoadbalancerBackendGroup)
+	set[m.Id] = m
+}
+
+func (set LoadbalancerBackendGroups) Copy() apihelper.IModelSet {
+	setCopy := LoadbalancerBackendGroups{}
+	for id, el := range set {
+		setCopy[id] = el.Copy()
+	}
+	return setCopy
+}
+
+func (ms LoadbalancerBackendGroups) joinLoadbalancerBackends(subEntries LoadbalancerBackends) bool {
+	for _, m := range ms {
+		m.LoadbalancerBackends = LoadbalancerBackends{}
+	}
+	for subId, subEntry := range subEntries {
+		id := subEntry.BackendGroupId
+		m, ok := ms[id]
+		if !ok {
+			log.Warningf("loadbalancer backend %s(%s): backend group id %s not found",
+				subEntry.Name, subEntry.Id, id)
+			continue
+		}
+		subEntry.LoadbalancerBackendGroup = m
+		m.LoadbalancerBackends[subId] = subEntry
+	}
+	return true
+}
+
+func (set LoadbalancerBackends) ModelManager() mcclient_modulebase.IBaseManager {
+	return &mcclient_modules.LoadbalancerBackends
+}
+
+func (set LoadbalancerBackends) NewModel() db.IModel {
+	return &LoadbalancerBackend{}
+}
+
+func (set LoadbalancerBackends) AddModel(i db.IModel) {
+	m := i.(*LoadbalancerBackend)
+	set[m.Id] = m
+}
+
+func (set LoadbalancerBackends) Copy() apihelper.IModelSet {
+	setCopy := LoadbalancerBackends{}
+	for id, el := range set {
+		setCopy[id] = el.Copy()
+	}
+	return setCopy
+}
+
 func (set DnsRecords) ModelManager() mcclient_modulebase.IBaseManager {
 	return &mcclient_modules.DNSRecords
 }
@@ -33,6 +33,20 @@ type ModelSetsMaxUpdatedAt struct {
 	Elasticips         time.Time
 	NetworkAddresses   time.Time
 
+	RouteTables            time.Time
+	RouteTableAssociations time.Time
+
+	NatGateways time.Time
+	NatSEntries time.Time
+	NatDEntries time.Time
+
+	LoadbalancerClusters      time.Time
+	Loadbalancers             time.Time
+	LoadbalancerNetworks      time.Time
+	LoadbalancerListeners     time.Time
+	LoadbalancerBackendGroups time.Time
+	LoadbalancerBackends      time.Time
+
 	DnsRecords time.Time
 }
 
@@ -50,6 +64,20 @@ func NewModelSetsMaxUpdatedAt() *ModelSetsMaxUpdatedAt {
 		Elasticips:         apihelper.PseudoZeroTime,
 		NetworkAddresses:   apihelper.PseudoZeroTime,
 
+		RouteTables:            apihelper.PseudoZeroTime,
+		RouteTableAssociations: apihelper.PseudoZeroTime,
+
+		NatGateways: apihelper.PseudoZeroTime,
+		NatSEntries: apihelper.PseudoZeroTime,
+		NatDEntries: apihelper.PseudoZeroTime,
+
+		LoadbalancerClusters:      apihelper.PseudoZeroTime,
+		Loadbalancers:             apihelper.PseudoZeroTime,
+		LoadbalancerNetworks:      apihelper.PseudoZeroTime,
+		LoadbalancerListeners:     apihelper.PseudoZeroTime,
+		LoadbalancerBackendGroups: apihelper.PseudoZeroTime,
+		LoadbalancerBackends:      apihelper.PseudoZeroTime,
+
 		DnsRecords: apihelper.PseudoZeroTime,
 	}
 }
@@ -67,6 +95,20 @@ type ModelSets struct {
 	Elasticips         Elasticips
 	NetworkAddresses   NetworkAddresses
 
+	RouteTables            RouteTables
+	RouteTableAssociations RouteTableAssociations
+
+	NatGateways NatGateways
+	NatSEntries NatSEntries
+	NatDEntries NatDEntries
+
+	LoadbalancerClusters      LoadbalancerClusters
+	Loadbalancers             Loadbalancers
+	LoadbalancerNetworks      LoadbalancerNetworks
+	LoadbalancerListeners     LoadbalancerListeners
+	LoadbalancerBackendGroups LoadbalancerBackendGroups
+	LoadbalancerBackends      LoadbalancerBackends
+
 	DnsRecords DnsRecords
 }
 
@@ -84,6 +126,20 @@ func NewModelSets() *ModelSets {
 		Elasticips:         Elasticips{},
 		NetworkAddresses:   NetworkAddresses{},
 
+		RouteTables:            RouteTables{},
+		RouteTableAssociations: RouteTableAssociations{},
+
+		NatGateways: NatGateways{},
+		NatSEntries: NatSEntries{},
+		NatDEntries: NatDEntries{},
+
+		LoadbalancerClusters:      LoadbalancerClusters{},
+		Loadbalancers:             Loadbalancers{},
+		LoadbalancerNetworks:      LoadbalancerNetworks{},
+		LoadbalancerListeners:     LoadbalancerListeners{},
+		LoadbalancerBackendGroups: LoadbalancerBackendGroups{},
+		LoadbalancerBackends:      LoadbalancerBackends{},
+
 		DnsRecords: DnsRecords{},
 	}
 }
@@ -103,6 +159,20 @@ func (mss *ModelSets) ModelSetList() []apihelper.IModelSet {
 		mss.Elasticips,
 		mss.NetworkAddresses,
 
+		mss.RouteTables,
+		mss.RouteTableAssociations,
+
+		mss.NatGateways,
+		mss.NatSEntries,
+		mss.NatDEntries,
+
+		mss.LoadbalancerClusters,
+		mss.Loadbalancers,
+		mss.LoadbalancerNetworks,
+		mss.LoadbalancerListeners,
+		mss.LoadbalancerBackendGroups,
+		mss.LoadbalancerBackends,
+
 		mss.DnsRecords,
 	}
 }
@@ -125,6 +195,20 @@ func (mss *ModelSets) copy_() *ModelSets {
 		Elasticips:         mss.Elasticips.Copy().(Elasticips),
 		NetworkAddresses:   mss.NetworkAddresses.Copy().(NetworkAddresses),
 
+		RouteTables:            mss.RouteTables.Copy().(RouteTables),
+		RouteTableAssociations: mss.RouteTableAssociations.Copy().(RouteTableAssociations),
+
+		NatGateways: mss.NatGateways.Copy().(NatGateways),
+		NatSEntries: mss.NatSEntries.Copy().(NatSEntries),
+		NatDEntries: mss.NatDEntries.Copy().(NatDEntries),
+
+		LoadbalancerClusters:      mss.LoadbalancerClusters.Copy().(LoadbalancerClusters),
+		Loadbalancers:             mss.Loadbalancers.Copy().(Loadbalancers),
+		LoadbalancerNetworks:      mss.LoadbalancerNetworks.Copy().(LoadbalancerNetworks),
+		LoadbalancerListeners:     mss.LoadbalancerListeners.Copy().(LoadbalancerListeners),
+		LoadbalancerBackendGroups: mss.LoadbalancerBackendGroups.Copy().(LoadbalancerBackendGroups),
+		LoadbalancerBackends:      mss.LoadbalancerBackends.Copy().(LoadbalancerBackends),
+
 		DnsRecords: mss.DnsRecords.Copy().(DnsRecords),
 	}
 	return mssCopy
@@ -176,6 +260,17 @@ func (mss *ModelSets) join() bool {
 	p = append(p, mss.Guestnetworks.joinGuests(mss.Guests))
 	p = append(p, mss.Guestnetworks.joinElasticips(mss.Elasticips))
 	p = append(p, mss.Guestnetworks.joinNetworkAddresses(mss.NetworkAddresses))
+	p = append(p, mss.Vpcs.joinRouteTables(mss.RouteTables))
+	p = append(p, mss.RouteTables.joinRouteTableAssociations(mss.RouteTableAssociations))
+	p = append(p, mss.Vpcs.joinNatGateways(mss.NatGateways))
+	p = append(p, mss.NatGateways.joinNatSEntries(mss.NatSEntries))
+	p = append(p, mss.NatGateways.joinNatDEntries(mss.NatDEntries))
+	p = append(p, mss.Vpcs.joinLoadbalancers(mss.Loadbalancers))
+	p = append(p, mss.Loadbalancers.joinLoadbalancerClusters(mss.LoadbalancerClusters))
+	p = append(p, mss.Loadbalancers.joinLoadbalancerNetworks(mss.LoadbalancerNetworks))
+	p = append(p, mss.Loadbalancers.joinLoadbalancerListeners(mss.LoadbalancerListeners))
+	p = append(p, mss.LoadbalancerListeners.joinLoadbalancerBackendGroups(mss.LoadbalancerBackendGroups))
+	p = append(p, mss.LoadbalancerBackendGroups.joinLoadbalancerBackends(mss.LoadbalancerBackends))
 	for _, b := range p {
 		if !b {
 			return false
@@ -39,6 +39,7 @@ type VpcAgentOptions struct {
 
 	OvnWorkerCheckInterval int    `default:"180"`
 	OvnNorthDatabase       string `help:"address for accessing ovn north database.  Default to local unix socket"`
+	OvnSouthDatabase       string `help:"address for accessing ovn south database, used for reading load balancer backend health status.  Default to local unix socket"`
 	OvnUnderlayMtu         int    `help:"mtu of ovn underlay network" default:"1500"`
 }
 
@@ -77,5 +78,10 @@ func (opts *Options) ValidateThenInit() error {
 	} else {
 		opts.OvnNorthDatabase = db
 	}
+	if db, err := ovsutils.NormalizeDbHost(opts.OvnSouthDatabase); err != nil {
+		return err
+	} else {
+		opts.OvnSouthDatabase = db
+	}
 	return nil
 }
@@ -1,9 +1,9 @@
 package ovn
 
 import (
-	"yunion.io/x/ovsdb/schema/ovn_nb"
 	"yunion.io/x/ovsdb/types"
 
+	"yunion.io/x/onecloud/pkg/vpcagent/ovn/schema/ovn_nb"
 	"yunion.io/x/onecloud/pkg/vpcagent/ovnutil"
 )
 
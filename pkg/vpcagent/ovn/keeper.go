@@ -21,14 +21,13 @@ import (
 	"strings"
 
 	"yunion.io/x/log"
-	"yunion.io/x/ovsdb/cli_util"
-	"yunion.io/x/ovsdb/schema/ovn_nb"
 	"yunion.io/x/ovsdb/types"
 	"yunion.io/x/pkg/errors"
 
 	apis "yunion.io/x/onecloud/pkg/apis/compute"
 	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
 	"yunion.io/x/onecloud/pkg/vpcagent/ovn/mac"
+	"yunion.io/x/onecloud/pkg/vpcagent/ovn/schema/ovn_nb"
 	"yunion.io/x/onecloud/pkg/vpcagent/ovnutil"
 )
 
@@ -40,6 +39,9 @@ const (
 type OVNNorthboundKeeper struct {
 	DB  ovn_nb.OVNNorthbound
 	cli *ovnutil.OvnNbCtl
+
+	// vpc peer links claimed in this round
+	vpcPeers map[string]struct{}
 }
 
 func DumpOVNNorthbound(ctx context.Context, cli *ovnutil.OvnNbCtl) (*OVNNorthboundKeeper, error) {
@@ -54,13 +56,16 @@ func DumpOVNNorthbound(ctx context.Context, cli *ovnutil.OvnNbCtl) (*OVNNorthbou
 		&db.DHCPOptions,
 		&db.QoS,
 		&db.DNS,
+		&db.NAT,
+		&db.LoadBalancer,
+		&db.LogicalRouterPolicy,
 	}
 	args := []string{"--format=json", "list", "<tbl>"}
 	for _, itbl := range itbls {
 		tbl := itbl.OvsdbTableName()
 		args[2] = tbl
 		res := cli.Must(ctx, "List "+tbl, args)
-		if err := cli_util.UnmarshalJSON([]byte(res.Output), itbl); err != nil {
+		if err := ovnutil.UnmarshalJSON([]byte(res.Output), itbl); err != nil {
 			return nil, errors.Wrapf(err, "Unmarshal %s:\n%s",
 				itbl.OvsdbTableName(), res.Output)
 		}
@@ -97,6 +102,8 @@ func (keeper *OVNNorthboundKeeper) ClaimVpc(ctx context.Context, vpc *agentmodel
 	var (
 		hasDistgw = vpcHasDistgw(vpc)
 		hasEipgw  = vpcHasEipgw(vpc)
+		// default route can be taken over by route tables
+		hasDefaultRoute = !vpcHasCustomDefaultRoute(vpc)
 	)
 
 	var (
@@ -142,11 +149,13 @@ func (keeper *OVNNorthboundKeeper) ClaimVpc(ctx context.Context, vpc *agentmodel
 				"router-port": vpcR2extpName(vpc.Id),
 			},
 		}
-		vpcDefaultRoute = &ovn_nb.LogicalRouterStaticRoute{
-			Policy:     ptr("dst-ip"),
-			IpPrefix:   "0.0.0.0/0",
-			Nexthop:    apis.VpcInterExtIP2().String(),
-			OutputPort: ptr(vpcR1extpName(vpc.Id)),
+		if hasDefaultRoute {
+			vpcDefaultRoute = &ovn_nb.LogicalRouterStaticRoute{
+				Policy:     ptr("dst-ip"),
+				IpPrefix:   "0.0.0.0/0",
+				Nexthop:    apis.VpcInterExtIP2().String(),
+				OutputPort: ptr(vpcR1extpName(vpc.Id)),
+			}
 		}
 		vpcExtDefaultRoute = &ovn_nb.LogicalRouterStaticRoute{
 			Policy:     ptr("dst-ip"),
@@ -161,9 +170,11 @@ func (keeper *OVNNorthboundKeeper) ClaimVpc(ctx context.Context, vpc *agentmodel
 			vpcExtr1p,
 			vpcR2extp,
 			vpcExtr2p,
-			vpcDefaultRoute,
 			vpcExtDefaultRoute,
 		)
+		if hasDefaultRoute {
+			irows = append(irows, vpcDefaultRoute)
+		}
 	}
 
 	// distgw
@@ -238,9 +249,11 @@ func (keeper *OVNNorthboundKeeper) ClaimVpc(ctx context.Context, vpc *agentmodel
 		args = append(args, ovnCreateArgs(vpcExtr1p, vpcExtr1p.Name)...)
 		args = append(args, ovnCreateArgs(vpcR2extp, vpcR2extp.Name)...)
 		args = append(args, ovnCreateArgs(vpcExtr2p, vpcExtr2p.Name)...)
-		args = append(args, ovnCreateArgs(vpcDefaultRoute, "vpcDefaultRoute")...)
+		if hasDefaultRoute {
+			args = append(args, ovnCreateArgs(vpcDefaultRoute, "vpcDefaultRoute")...)
+			args = append(args, "--", "add", "Logical_Router", vpcLrName(vpc.Id), "static_routes", "@vpcDefaultRoute")
+		}
 		args = append(args, ovnCreateArgs(vpcExtDefaultRoute, "vpcExtDefaultRoute")...)
-		args = append(args, "--", "add", "Logical_Router", vpcLrName(vpc.Id), "static_routes", "@vpcDefaultRoute")
 		args = append(args, "--", "add", "Logical_Router", vpcExtLrName(vpc.Id), "static_routes", "@vpcExtDefaultRoute")
 		args = append(args, "--", "add", "Logical_Switch", vpcExtLs.Name, "ports", "@"+vpcExtr1p.Name)
 		args = append(args, "--", "add", "Logical_Router", vpcLr.Name, "ports", "@"+vpcR1extp.Name)
@@ -759,6 +772,9 @@ func (keeper *OVNNorthboundKeeper) Mark(ctx context.Context) {
 		&db.DHCPOptions,
 		&db.QoS,
 		&db.DNS,
+		&db.NAT,
+		&db.LoadBalancer,
+		&db.LogicalRouterPolicy,
 	}
 	for _, itbl := range itbls {
 		for _, irow := range itbl.Rows() {
@@ -777,6 +793,7 @@ func (keeper *OVNNorthboundKeeper) Sweep(ctx context.Context) error {
 		&db.LogicalRouter,
 		&db.DHCPOptions,
 		&db.DNS,
+		&db.LoadBalancer,
 	}
 	var irows []types.IRow
 	for _, itbl := range itbls {
@@ -806,6 +823,18 @@ func (keeper *OVNNorthboundKeeper) Sweep(ctx context.Context) error {
 			keeper.cli.Must(ctx, "Sweep static routes", args)
 		}
 	}
+	{
+		var args []string
+		for _, irow := range db.LogicalRouterPolicy.Rows() {
+			_, ok := irow.GetExternalId(externalKeyOcVersion)
+			if !ok {
+				args = append(args, keeper.lrPolicyRemoveArgs(irow.OvsdbUuid())...)
+			}
+		}
+		if len(args) > 0 {
+			keeper.cli.Must(ctx, "Sweep router policies", args)
+		}
+	}
 	{
 		var args []string
 		for _, irow := range db.ACL.Rows() {
@@ -820,6 +849,20 @@ func (keeper *OVNNorthboundKeeper) Sweep(ctx context.Context) error {
 			keeper.cli.Must(ctx, "Sweep acls", args)
 		}
 	}
+	{
+		var args []string
+		for _, irow := range db.NAT.Rows() {
+			_, ok := irow.GetExternalId(externalKeyOcVersion)
+			if !ok {
+				for _, lr := range db.LogicalRouter.FindNATReferrer_nat(irow.OvsdbUuid()) {
+					args = append(args, "--", "--if-exists", "remove", "Logical_Router", lr.Name, "nat", irow.OvsdbUuid())
+				}
+			}
+		}
+		if len(args) > 0 {
+			keeper.cli.Must(ctx, "Sweep nat", args)
+		}
+	}
 	{ //  remove unused QoS rows
 		var args []string
 		for _, irow := range db.QoS.Rows() {
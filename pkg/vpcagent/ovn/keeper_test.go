@@ -0,0 +1,197 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovn
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"yunion.io/x/onecloud/pkg/vpcagent/ovn/schema/ovn_nb"
+	"yunion.io/x/onecloud/pkg/vpcagent/ovnutil"
+)
+
+const fakeOvnNbCtlScript = `#!/bin/sh
+for arg in "$@"; do
+	printf '%s\n' "$arg"
+done >> "$0.log"
+echo "@@" >> "$0.log"
+`
+
+// fakeOvnNbCtl is an ovn-nbctl in PATH recording the args of every call
+type fakeOvnNbCtl struct {
+	dir  string
+	path string
+}
+
+func newFakeOvnNbCtl(t *testing.T) *fakeOvnNbCtl {
+	dir, err := ioutil.TempDir("", "ovn-nbctl")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	prog := filepath.Join(dir, "ovn-nbctl")
+	if err := ioutil.WriteFile(prog, []byte(fakeOvnNbCtlScript), 0755); err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("write %s: %v", prog, err)
+	}
+	fake := &fakeOvnNbCtl{
+		dir:  dir,
+		path: os.Getenv("PATH"),
+	}
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+fake.path)
+	return fake
+}
+
+func (fake *fakeOvnNbCtl) Close() {
+	os.Setenv("PATH", fake.path)
+	os.RemoveAll(fake.dir)
+}
+
+// Calls returns args of the calls so far and forgets them
+func (fake *fakeOvnNbCtl) Calls(t *testing.T) [][]string {
+	logPath := filepath.Join(fake.dir, "ovn-nbctl.log")
+	data, err := ioutil.ReadFile(logPath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		t.Fatalf("read %s: %v", logPath, err)
+	}
+	os.Remove(logPath)
+	var (
+		calls [][]string
+		args  = []string{}
+	)
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		if line == "@@" {
+			calls = append(calls, args)
+			args = []string{}
+			continue
+		}
+		args = append(args, line)
+	}
+	return calls
+}
+
+func newTestKeeper() *OVNNorthboundKeeper {
+	return &OVNNorthboundKeeper{
+		cli: ovnutil.NewOvnNbCtl(""),
+	}
+}
+
+func marked(uuid string) map[string]string {
+	return map[string]string{
+		externalKeyOcVersion: "v." + uuid,
+	}
+}
+
+func TestKeeperSweep(t *testing.T) {
+	cases := []struct {
+		name   string
+		keeper func() *OVNNorthboundKeeper
+		want   [][]string
+	}{
+		{
+			name: "all marked",
+			keeper: func() *OVNNorthboundKeeper {
+				keeper := newTestKeeper()
+				keeper.DB.LogicalRouter = ovn_nb.LogicalRouterTable{
+					{Uuid: "lr0", Name: "vpc-r/vpc0", StaticRoutes: []string{"route0"}, Policies: []string{"policy0"}, ExternalIds: marked("lr0")},
+				}
+				keeper.DB.LogicalRouterStaticRoute = ovn_nb.LogicalRouterStaticRouteTable{
+					{Uuid: "route0", ExternalIds: marked("route0")},
+				}
+				keeper.DB.LogicalRouterPolicy = ovn_nb.LogicalRouterPolicyTable{
+					{Uuid: "policy0", ExternalIds: marked("policy0")},
+				}
+				return keeper
+			},
+		},
+		{
+			name: "stale static routes",
+			keeper: func() *OVNNorthboundKeeper {
+				keeper := newTestKeeper()
+				keeper.DB.LogicalRouter = ovn_nb.LogicalRouterTable{
+					{Uuid: "lr0", Name: "vpc-r/vpc0", StaticRoutes: []string{"route0", "route1"}, ExternalIds: marked("lr0")},
+					{Uuid: "lr1", Name: "vpc-ext-r/vpc0", StaticRoutes: []string{"route2"}, ExternalIds: marked("lr1")},
+				}
+				keeper.DB.LogicalRouterStaticRoute = ovn_nb.LogicalRouterStaticRouteTable{
+					{Uuid: "route0", ExternalIds: marked("route0")},
+					{Uuid: "route1"},
+					{Uuid: "route2"},
+				}
+				return keeper
+			},
+			want: [][]string{
+				{
+					"--", "--if-exists", "remove", "Logical_Router", "vpc-r/vpc0", "static_routes", "route1",
+					"--", "--if-exists", "remove", "Logical_Router", "vpc-ext-r/vpc0", "static_routes", "route2",
+				},
+			},
+		},
+		{
+			name: "stale router policies",
+			keeper: func() *OVNNorthboundKeeper {
+				keeper := newTestKeeper()
+				keeper.DB.LogicalRouter = ovn_nb.LogicalRouterTable{
+					{Uuid: "lr0", Name: "vpc-r/vpc0", Policies: []string{"policy0", "policy1"}, ExternalIds: marked("lr0")},
+				}
+				keeper.DB.LogicalRouterPolicy = ovn_nb.LogicalRouterPolicyTable{
+					{Uuid: "policy0", ExternalIds: marked("policy0")},
+					{Uuid: "policy1"},
+				}
+				return keeper
+			},
+			want: [][]string{
+				{"--", "--if-exists", "remove", "Logical_Router", "vpc-r/vpc0", "policies", "policy1"},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake := newFakeOvnNbCtl(t)
+			defer fake.Close()
+
+			keeper := c.keeper()
+			if err := keeper.Sweep(context.Background()); err != nil {
+				t.Fatalf("Sweep: %v", err)
+			}
+			got := fake.Calls(t)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got calls\n%q\nwant\n%q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestKeeperMark(t *testing.T) {
+	keeper := newTestKeeper()
+	keeper.DB.LogicalRouterStaticRoute = ovn_nb.LogicalRouterStaticRouteTable{
+		{Uuid: "route0", ExternalIds: marked("route0")},
+	}
+	keeper.DB.LogicalRouterPolicy = ovn_nb.LogicalRouterPolicyTable{
+		{Uuid: "policy0", ExternalIds: marked("policy0")},
+	}
+	keeper.Mark(context.Background())
+	if _, ok := keeper.DB.LogicalRouterStaticRoute[0].GetExternalId(externalKeyOcVersion); ok {
+		t.Errorf("static route is still marked")
+	}
+	if _, ok := keeper.DB.LogicalRouterPolicy[0].GetExternalId(externalKeyOcVersion); ok {
+		t.Errorf("router policy is still marked")
+	}
+}
@@ -0,0 +1,441 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/ovsdb/cli_util"
+	"yunion.io/x/ovsdb/types"
+	"yunion.io/x/pkg/errors"
+
+	apis "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	mcclient_modules "yunion.io/x/onecloud/pkg/mcclient/modules"
+	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+	"yunion.io/x/onecloud/pkg/vpcagent/ovn/mac"
+	"yunion.io/x/onecloud/pkg/vpcagent/ovn/schema/ovn_nb"
+	"yunion.io/x/onecloud/pkg/vpcagent/ovnutil"
+)
+
+const (
+	// Columns newer than the compiled schema are set with raw
+	// arguments.  Their digests are kept in external_ids so that changes
+	// can be detected by cmp()
+	externalKeyOcLbHealthCheck    = "oc-lb-health-check"
+	externalKeyOcLbSelectionField = "oc-lb-selection-fields"
+)
+
+func lbIsOvn(lb *agentmodels.Loadbalancer) bool {
+	return lb.LoadbalancerCluster != nil && lb.LoadbalancerCluster.ClusterType == apis.LB_CLUSTER_TYPE_OVN
+}
+
+// lbHealthCheckSrcIp returns the address allocated for ovn service monitor
+// to probe backends with.  It's the one besides the vip on the loadbalancer
+// network
+func lbHealthCheckSrcIp(lb *agentmodels.Loadbalancer) string {
+	var ips []string
+	for _, ln := range lb.LoadbalancerNetworks {
+		if ln.NetworkId == lb.NetworkId && ln.IpAddr != "" && ln.IpAddr != lb.Address {
+			ips = append(ips, ln.IpAddr)
+		}
+	}
+	if len(ips) == 0 {
+		return ""
+	}
+	sort.Strings(ips)
+	return ips[0]
+}
+
+// ClaimVpcLoadbalancers realizes loadbalancers of ovn lbcluster in the vpc
+// as ovn load balancers.
+//
+// Each enabled listener becomes a Load_Balancer row attached to every
+// network logical switch of the vpc, where dnat is done for clients before
+// the packet leaves their logical switch.  The vip itself is answered by a
+// dedicated router port on the loadbalancer network
+func (keeper *OVNNorthboundKeeper) ClaimVpcLoadbalancers(ctx context.Context, vpc *agentmodels.Vpc) error {
+	var errs []error
+	for _, lb := range vpc.Loadbalancers {
+		if !lbIsOvn(lb) || lb.Status != apis.LB_STATUS_ENABLED || lb.Address == "" {
+			continue
+		}
+		network, ok := vpc.Networks[lb.NetworkId]
+		if !ok {
+			errs = append(errs, errors.Errorf("loadbalancer %s(%s): network %s not found in vpc",
+				lb.Name, lb.Id, lb.NetworkId))
+			continue
+		}
+		if err := keeper.claimLoadbalancerVip(ctx, vpc, network, lb); err != nil {
+			errs = append(errs, errors.Wrapf(err, "loadbalancer %s(%s)", lb.Name, lb.Id))
+			continue
+		}
+		for _, lblis := range lb.LoadbalancerListeners {
+			if lblis.Status != apis.LB_STATUS_ENABLED {
+				continue
+			}
+			if err := keeper.claimLoadbalancerListener(ctx, vpc, lb, lblis); err != nil {
+				errs = append(errs, errors.Wrapf(err, "loadbalancer listener %s(%s)", lblis.Name, lblis.Id))
+			}
+		}
+	}
+	return errors.NewAggregate(errs)
+}
+
+func (keeper *OVNNorthboundKeeper) claimLoadbalancerVip(ctx context.Context, vpc *agentmodels.Vpc, network *agentmodels.Network, lb *agentmodels.Loadbalancer) error {
+	var (
+		ocVersion = fmt.Sprintf("%s.%d", lb.UpdatedAt, lb.UpdateVersion)
+	)
+	lbRvp := &ovn_nb.LogicalRouterPort{
+		Name:     lbRvpName(lb.Id),
+		Mac:      mac.HashLoadbalancerVipMac(lb.Id),
+		Networks: []string{lb.Address + "/32"},
+	}
+	lbVrp := &ovn_nb.LogicalSwitchPort{
+		Name:      lbVrpName(lb.Id),
+		Type:      "router",
+		Addresses: []string{"router"},
+		Options: map[string]string{
+			"router-port": lbRvpName(lb.Id),
+		},
+	}
+	allFound, args := cmp(&keeper.DB, ocVersion, lbRvp, lbVrp)
+	if allFound {
+		return nil
+	}
+	args = append(args, ovnCreateArgs(lbRvp, lbRvp.Name)...)
+	args = append(args, ovnCreateArgs(lbVrp, lbVrp.Name)...)
+	args = append(args, "--", "add", "Logical_Router", vpcLrName(vpc.Id), "ports", "@"+lbRvp.Name)
+	args = append(args, "--", "add", "Logical_Switch", netLsName(network.Id), "ports", "@"+lbVrp.Name)
+	keeper.cli.Must(ctx, "ClaimLoadbalancerVip", args)
+	return nil
+}
+
+// lbBackendLspName returns name of the logical switch port where the backend
+// lives.  Service monitor needs it to know where to send probes
+func lbBackendLspName(vpc *agentmodels.Vpc, lbb *agentmodels.LoadbalancerBackend) string {
+	for _, network := range vpc.Networks {
+		for _, gn := range network.Guestnetworks {
+			if gn.GuestId == lbb.BackendId && gn.IpAddr == lbb.Address {
+				return gnpName(gn.NetworkId, gn.Ifname)
+			}
+		}
+	}
+	return ""
+}
+
+func (keeper *OVNNorthboundKeeper) claimLoadbalancerListener(ctx context.Context, vpc *agentmodels.Vpc, lb *agentmodels.Loadbalancer, lblis *agentmodels.LoadbalancerListener) error {
+	switch lblis.ListenerType {
+	case apis.LB_LISTENER_TYPE_TCP, apis.LB_LISTENER_TYPE_UDP:
+	default:
+		return errors.Errorf("unsupported listener type %q", lblis.ListenerType)
+	}
+	lbbg := lblis.LoadbalancerBackendGroup
+	if lbbg == nil || len(lbbg.LoadbalancerBackends) == 0 {
+		return nil
+	}
+	var (
+		vip       = fmt.Sprintf("%s:%d", lb.Address, lblis.ListenerPort)
+		backends  []string
+		ipMapping = map[string]string{}
+		hcSrcIp   string
+	)
+	hasHealthCheck := lblis.HealthCheck == apis.LB_BOOL_ON
+	if hasHealthCheck {
+		hcSrcIp = lbHealthCheckSrcIp(lb)
+		if hcSrcIp == "" {
+			log.Warningf("loadbalancer %s(%s) has no health check source address", lb.Name, lb.Id)
+			hasHealthCheck = false
+		}
+	}
+	for _, lbb := range lbbg.LoadbalancerBackends {
+		if lbb.Address == "" {
+			continue
+		}
+		backends = append(backends, fmt.Sprintf("%s:%d", lbb.Address, lbb.Port))
+		if hasHealthCheck {
+			if lspName := lbBackendLspName(vpc, lbb); lspName != "" {
+				ipMapping[lbb.Address] = fmt.Sprintf("%s:%s", lspName, hcSrcIp)
+			}
+		}
+	}
+	if len(backends) == 0 {
+		return nil
+	}
+	sort.Strings(backends)
+
+	var (
+		ocVersion = fmt.Sprintf("%s.%d", lblis.UpdatedAt, lblis.UpdateVersion)
+		ocRef     = fmt.Sprintf("lblis/%s", lblis.Id)
+		extraArgs []string
+	)
+	lbLis := &ovn_nb.LoadBalancer{
+		Name:     lbListenerLbName(lblis.Id),
+		Protocol: ptr(lblis.ListenerType),
+		Vips: map[string]string{
+			vip: strings.Join(backends, ","),
+		},
+		ExternalIds: map[string]string{
+			externalKeyOcRef: ocRef,
+		},
+	}
+	if lblis.Scheduler == apis.LB_SCHEDULER_SCH {
+		lbLis.ExternalIds[externalKeyOcLbSelectionField] = "ip_src"
+		extraArgs = append(extraArgs, "selection_fields=ip_src")
+	}
+	var hcArgs []string
+	if hasHealthCheck {
+		hcOpts := []string{
+			fmt.Sprintf("interval=%d", lblis.HealthCheckInterval),
+			fmt.Sprintf("timeout=%d", lblis.HealthCheckTimeout),
+			fmt.Sprintf("success_count=%d", lblis.HealthCheckRise),
+			fmt.Sprintf("failure_count=%d", lblis.HealthCheckFall),
+		}
+		hcArgs = []string{
+			"--", "--id=@lbHc", "create", "Load_Balancer_Health_Check",
+			fmt.Sprintf("vip=%q", vip),
+		}
+		for _, opt := range hcOpts {
+			hcArgs = append(hcArgs, "options:"+opt)
+		}
+		extraArgs = append(extraArgs, "health_check=@lbHc")
+
+		var ips []string
+		for ip := range ipMapping {
+			ips = append(ips, ip)
+		}
+		sort.Strings(ips)
+		for _, ip := range ips {
+			extraArgs = append(extraArgs, fmt.Sprintf("ip_port_mappings:%q=%q", ip, ipMapping[ip]))
+			hcOpts = append(hcOpts, fmt.Sprintf("%s=%s", ip, ipMapping[ip]))
+		}
+		lbLis.ExternalIds[externalKeyOcLbHealthCheck] = strings.Join(hcOpts, ",")
+	}
+
+	allFound, args := cmp(&keeper.DB, ocVersion, lbLis)
+	if allFound {
+		// the vpc may have got new networks since the last time
+		lbUuid := keeper.markedLoadbalancerUuid(lbLis.Name, ocVersion)
+		if lbUuid == "" {
+			return nil
+		}
+		args = nil
+		for _, network := range vpc.Networks {
+			lsName := netLsName(network.Id)
+			if !keeper.lsHasLoadbalancer(lsName, lbUuid) {
+				args = append(args, "--", "add", "Logical_Switch", lsName, "load_balancer", lbUuid)
+			}
+		}
+		if len(args) > 0 {
+			keeper.cli.Must(ctx, "ClaimLoadbalancerListener attach", args)
+		}
+		return nil
+	}
+	args = append(args, hcArgs...)
+	args = append(args, ovnCreateArgs(lbLis, "lbLis")...)
+	args = append(args, extraArgs...)
+	for _, network := range vpc.Networks {
+		args = append(args, "--", "add", "Logical_Switch", netLsName(network.Id), "load_balancer", "@lbLis")
+	}
+	keeper.cli.Must(ctx, "ClaimLoadbalancerListener", args)
+	return nil
+}
+
+// markedLoadbalancerUuid returns uuid of the load balancer marked by cmp()
+// in this round
+func (keeper *OVNNorthboundKeeper) markedLoadbalancerUuid(name, ocVersion string) string {
+	for i := range keeper.DB.LoadBalancer {
+		row := &keeper.DB.LoadBalancer[i]
+		if row.Name != name {
+			continue
+		}
+		if v, ok := row.GetExternalId(externalKeyOcVersion); ok && v == ocVersion {
+			return row.Uuid
+		}
+	}
+	return ""
+}
+
+func (keeper *OVNNorthboundKeeper) lsHasLoadbalancer(lsName, lbUuid string) bool {
+	for i := range keeper.DB.LogicalSwitch {
+		ls := &keeper.DB.LogicalSwitch[i]
+		if ls.Name != lsName {
+			continue
+		}
+		for _, uuid := range ls.LoadBalancer {
+			if uuid == lbUuid {
+				return true
+			}
+		}
+		return false
+	}
+	// the switch is created in this round
+	return false
+}
+
+type serviceMonitor struct {
+	Ip       string
+	Port     int64
+	Protocol string
+	Status   string
+}
+
+func serviceMonitorKey(protocol, ip string, port int64) string {
+	return fmt.Sprintf("%s:%s:%d", protocol, ip, port)
+}
+
+func dumpServiceMonitors(ctx context.Context, cli *ovnutil.OvnSbCtl) ([]serviceMonitor, error) {
+	args := []string{"--format=json", "--columns=ip,port,protocol,status", "list", "Service_Monitor"}
+	res := cli.Must(ctx, "List Service_Monitor", args)
+	list := &cli_util.List{}
+	if err := json.Unmarshal([]byte(res.Output), list); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal Service_Monitor:\n%s", res.Output)
+	}
+	var sms []serviceMonitor
+	for _, row := range list.Data {
+		sm, err := func() (sm serviceMonitor, err error) {
+			defer func() {
+				if panicVal := recover(); panicVal != nil {
+					err = errors.Errorf("%v", panicVal)
+				}
+			}()
+			for i, col := range row {
+				if i >= len(list.Headings) {
+					break
+				}
+				switch list.Headings[i] {
+				case "ip":
+					sm.Ip = types.EnsureString(col)
+				case "port":
+					sm.Port = types.EnsureInteger(col)
+				case "protocol":
+					if v := types.EnsureStringOptional(col); v != nil {
+						sm.Protocol = *v
+					}
+				case "status":
+					if v := types.EnsureStringOptional(col); v != nil {
+						sm.Status = *v
+					}
+				}
+			}
+			return sm, nil
+		}()
+		if err != nil {
+			return nil, errors.Wrapf(err, "Service_Monitor row %#v", row)
+		}
+		if sm.Protocol == "" {
+			sm.Protocol = "tcp"
+		}
+		sms = append(sms, sm)
+	}
+	return sms, nil
+}
+
+// ReportLoadbalancerBackendHealth reads status of ovn service monitors and
+// reports them as health status of backends of ovn loadbalancers.
+//
+// A backend is offline if any of its monitors says so, online if all of them
+// are online, unknown otherwise
+func ReportLoadbalancerBackendHealth(ctx context.Context, s *mcclient.ClientSession, cli *ovnutil.OvnSbCtl, vpcs agentmodels.Vpcs) error {
+	type lbbMonitored struct {
+		lbb *agentmodels.LoadbalancerBackend
+		key string
+	}
+	var monitored []lbbMonitored
+	for _, vpc := range vpcs {
+		for _, lb := range vpc.Loadbalancers {
+			if !lbIsOvn(lb) {
+				continue
+			}
+			for _, lblis := range lb.LoadbalancerListeners {
+				lbbg := lblis.LoadbalancerBackendGroup
+				if lbbg == nil || lblis.HealthCheck != apis.LB_BOOL_ON {
+					continue
+				}
+				for _, lbb := range lbbg.LoadbalancerBackends {
+					monitored = append(monitored, lbbMonitored{
+						lbb: lbb,
+						key: serviceMonitorKey(lblis.ListenerType, lbb.Address, int64(lbb.Port)),
+					})
+				}
+			}
+		}
+	}
+	if len(monitored) == 0 {
+		// older ovn has no Service_Monitor table
+		return nil
+	}
+
+	sms, err := dumpServiceMonitors(ctx, cli)
+	if err != nil {
+		return err
+	}
+	smStatus := map[string][]string{}
+	for _, sm := range sms {
+		k := serviceMonitorKey(sm.Protocol, sm.Ip, sm.Port)
+		smStatus[k] = append(smStatus[k], sm.Status)
+	}
+	lbbStatus := map[string]string{}
+	lbbs := map[string]*agentmodels.LoadbalancerBackend{}
+	for _, m := range monitored {
+		lbbs[m.lbb.Id] = m.lbb
+		lbbStatus[m.lbb.Id] = mergeBackendHealthStatus(lbbStatus[m.lbb.Id], smStatus[m.key])
+	}
+
+	var errs []error
+	for id, status := range lbbStatus {
+		lbb := lbbs[id]
+		if lbb.HealthStatus == status {
+			continue
+		}
+		params := jsonutils.NewDict()
+		params.Set("health_status", jsonutils.NewString(status))
+		if _, err := mcclient_modules.LoadbalancerBackends.PerformAction(s, id, "health-status", params); err != nil {
+			errs = append(errs, errors.Wrapf(err, "loadbalancer backend %s(%s)", lbb.Name, lbb.Id))
+			continue
+		}
+		lbb.HealthStatus = status // update local copy in place
+	}
+	return errors.NewAggregate(errs)
+}
+
+func mergeBackendHealthStatus(cur string, smStatuses []string) string {
+	if cur == apis.LB_BACKEND_HEALTH_STATUS_OFFLINE {
+		return cur
+	}
+	if len(smStatuses) == 0 {
+		return apis.LB_BACKEND_HEALTH_STATUS_UNKNOWN
+	}
+	r := apis.LB_BACKEND_HEALTH_STATUS_ONLINE
+	for _, st := range smStatuses {
+		switch st {
+		case "online":
+		case "offline", "error":
+			return apis.LB_BACKEND_HEALTH_STATUS_OFFLINE
+		default:
+			r = apis.LB_BACKEND_HEALTH_STATUS_UNKNOWN
+		}
+	}
+	if cur == apis.LB_BACKEND_HEALTH_STATUS_UNKNOWN {
+		return cur
+	}
+	return r
+}
@@ -0,0 +1,452 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovn
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	apis "yunion.io/x/onecloud/pkg/apis/compute"
+	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+	"yunion.io/x/onecloud/pkg/vpcagent/ovn/mac"
+	"yunion.io/x/onecloud/pkg/vpcagent/ovn/schema/ovn_nb"
+)
+
+func vipMac(lbId string) string {
+	return mac.HashLoadbalancerVipMac(lbId)
+}
+
+// sortCallArgs sorts args of each command of the calls.  Map columns are
+// passed in the iteration order of the map
+func sortCallArgs(calls [][]string) [][]string {
+	r := make([][]string, len(calls))
+	for i, args := range calls {
+		var (
+			sorted = make([]string, 0, len(args))
+			start  = 0
+		)
+		for j := 0; j <= len(args); j++ {
+			if j == len(args) || (args[j] == "--" && j > start) {
+				cmd := append([]string{}, args[start:j]...)
+				sort.Strings(cmd)
+				sorted = append(sorted, cmd...)
+				start = j
+			}
+		}
+		r[i] = sorted
+	}
+	return r
+}
+
+func newTestLoadbalancer(vpc *agentmodels.Vpc, id string, networkId string, address string, ips ...string) *agentmodels.Loadbalancer {
+	lb := &agentmodels.Loadbalancer{
+		Vpc:                   vpc,
+		LoadbalancerNetworks:  agentmodels.LoadbalancerNetworks{},
+		LoadbalancerListeners: agentmodels.LoadbalancerListeners{},
+		LoadbalancerCluster:   &agentmodels.LoadbalancerCluster{},
+	}
+	lb.Id = id
+	lb.Status = apis.LB_STATUS_ENABLED
+	lb.NetworkId = networkId
+	lb.Address = address
+	lb.LoadbalancerCluster.ClusterType = apis.LB_CLUSTER_TYPE_OVN
+	for _, ip := range append([]string{address}, ips...) {
+		ln := &agentmodels.LoadbalancerNetwork{
+			Loadbalancer: lb,
+		}
+		ln.LoadbalancerId = id
+		ln.NetworkId = networkId
+		ln.IpAddr = ip
+		lb.LoadbalancerNetworks[id+"/"+ip] = ln
+	}
+	vpc.Loadbalancers[id] = lb
+	return lb
+}
+
+func newTestLoadbalancerListener(lb *agentmodels.Loadbalancer, id string, listenerType string, port int) *agentmodels.LoadbalancerListener {
+	lblis := &agentmodels.LoadbalancerListener{
+		Loadbalancer: lb,
+		LoadbalancerBackendGroup: &agentmodels.LoadbalancerBackendGroup{
+			LoadbalancerBackends: agentmodels.LoadbalancerBackends{},
+		},
+	}
+	lblis.Id = id
+	lblis.Status = apis.LB_STATUS_ENABLED
+	lblis.ListenerType = listenerType
+	lblis.ListenerPort = port
+	lblis.UpdateVersion = 1
+	lb.LoadbalancerListeners[id] = lblis
+	return lblis
+}
+
+func newTestLoadbalancerBackend(lblis *agentmodels.LoadbalancerListener, id string, guestId string, address string, port int) *agentmodels.LoadbalancerBackend {
+	lbbg := lblis.LoadbalancerBackendGroup
+	lbb := &agentmodels.LoadbalancerBackend{
+		LoadbalancerBackendGroup: lbbg,
+	}
+	lbb.Id = id
+	lbb.BackendId = guestId
+	lbb.Address = address
+	lbb.Port = port
+	lbbg.LoadbalancerBackends[id] = lbb
+	return lbb
+}
+
+func TestLbHealthCheckSrcIp(t *testing.T) {
+	cases := []struct {
+		name string
+		ips  []string
+		want string
+	}{
+		{
+			name: "vip only",
+		},
+		{
+			name: "one address",
+			ips:  []string{"192.168.0.101"},
+			want: "192.168.0.101",
+		},
+		{
+			name: "lowest address",
+			ips:  []string{"192.168.0.103", "192.168.0.101", "192.168.0.102"},
+			want: "192.168.0.101",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			vpc := newTestVpc("vpc0", apis.VPC_EXTERNAL_ACCESS_MODE_EIP)
+			lb := newTestLoadbalancer(vpc, "lb0", "net0", "192.168.0.100", c.ips...)
+			// addresses on other networks are not for the service monitor
+			ln := &agentmodels.LoadbalancerNetwork{}
+			ln.NetworkId = "net1"
+			ln.IpAddr = "192.168.1.100"
+			lb.LoadbalancerNetworks["lb0/net1"] = ln
+			if got := lbHealthCheckSrcIp(lb); got != c.want {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestMergeBackendHealthStatus(t *testing.T) {
+	const (
+		online  = apis.LB_BACKEND_HEALTH_STATUS_ONLINE
+		offline = apis.LB_BACKEND_HEALTH_STATUS_OFFLINE
+		unknown = apis.LB_BACKEND_HEALTH_STATUS_UNKNOWN
+	)
+	cases := []struct {
+		name       string
+		cur        string
+		smStatuses []string
+		want       string
+	}{
+		{"no monitor", "", nil, unknown},
+		{"online", "", []string{"online"}, online},
+		{"all online", "", []string{"online", "online"}, online},
+		{"offline", "", []string{"online", "offline"}, offline},
+		{"error", "", []string{"error", "online"}, offline},
+		{"monitor not ready", "", []string{"online", ""}, unknown},
+		{"offline in other listener", offline, []string{"online"}, offline},
+		{"unknown in other listener", unknown, []string{"online"}, unknown},
+		{"offline over unknown in other listener", unknown, []string{"offline"}, offline},
+		{"online in other listener", online, []string{"online"}, online},
+		{"no monitor in this listener", online, nil, unknown},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := mergeBackendHealthStatus(c.cur, c.smStatuses); got != c.want {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestClaimVpcLoadbalancers(t *testing.T) {
+	newVpc := func() (*agentmodels.Vpc, *agentmodels.Loadbalancer) {
+		vpc := newTestVpc("vpc0", apis.VPC_EXTERNAL_ACCESS_MODE_EIP)
+		network := newTestNetwork(vpc, "net0", "192.168.0.1", 24)
+		newTestGuestnetwork(network, "guest0", "eth0", "192.168.0.10")
+		newTestGuestnetwork(network, "guest1", "eth0", "192.168.0.11")
+		lb := newTestLoadbalancer(vpc, "lb0", "net0", "192.168.0.100", "192.168.0.101")
+		return vpc, lb
+	}
+	newListener := func(lb *agentmodels.Loadbalancer) *agentmodels.LoadbalancerListener {
+		lblis := newTestLoadbalancerListener(lb, "lblis0", apis.LB_LISTENER_TYPE_TCP, 80)
+		newTestLoadbalancerBackend(lblis, "lbb1", "guest1", "192.168.0.11", 8080)
+		newTestLoadbalancerBackend(lblis, "lbb0", "guest0", "192.168.0.10", 8080)
+		return lblis
+	}
+	// the realized loadbalancer vip and listener of case "tcp"
+	realizedKeeper := func(lsLbs ...string) *OVNNorthboundKeeper {
+		keeper := newTestKeeper()
+		keeper.DB.LogicalRouterPort = ovn_nb.LogicalRouterPortTable{
+			{
+				Uuid:     "lbRvp",
+				Name:     lbRvpName("lb0"),
+				Mac:      vipMac("lb0"),
+				Networks: []string{"192.168.0.100/32"},
+			},
+		}
+		keeper.DB.LogicalSwitchPort = ovn_nb.LogicalSwitchPortTable{
+			{
+				Uuid:      "lbVrp",
+				Name:      lbVrpName("lb0"),
+				Type:      "router",
+				Addresses: []string{"router"},
+				Options: map[string]string{
+					"router-port": lbRvpName("lb0"),
+				},
+			},
+		}
+		keeper.DB.LoadBalancer = ovn_nb.LoadBalancerTable{
+			{
+				Uuid:     "lb0",
+				Name:     lbListenerLbName("lblis0"),
+				Protocol: ptr("tcp"),
+				Vips: map[string]string{
+					"192.168.0.100:80": "192.168.0.10:8080,192.168.0.11:8080",
+				},
+				ExternalIds: map[string]string{
+					externalKeyOcRef: "lblis/lblis0",
+				},
+			},
+		}
+		keeper.DB.LogicalSwitch = ovn_nb.LogicalSwitchTable{
+			{Uuid: "ls0", Name: netLsName("net0"), LoadBalancer: lsLbs},
+		}
+		return keeper
+	}
+	vipArgs := []string{
+		"--", "--id=@lb-rv/lb0", "create", "Logical_Router_Port",
+		"mac=\"" + vipMac("lb0") + "\"",
+		"name=\"lb-rv/lb0\"",
+		"networks=[\"192.168.0.100/32\"]",
+		"--", "--id=@lb-vr/lb0", "create", "Logical_Switch_Port",
+		"addresses=[\"router\"]",
+		"name=\"lb-vr/lb0\"",
+		"options:\"router-port\"=\"lb-rv/lb0\"",
+		"type=\"router\"",
+		"--", "add", "Logical_Router", "vpc-r/vpc0", "ports", "@lb-rv/lb0",
+		"--", "add", "Logical_Switch", "subnet/net0", "ports", "@lb-vr/lb0",
+	}
+
+	cases := []struct {
+		name    string
+		vpc     func() *agentmodels.Vpc
+		keeper  func() *OVNNorthboundKeeper
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name: "tcp",
+			vpc: func() *agentmodels.Vpc {
+				vpc, lb := newVpc()
+				newListener(lb)
+				return vpc
+			},
+			want: [][]string{
+				vipArgs,
+				{
+					"--", "--id=@lbLis", "create", "Load_Balancer",
+					"external_ids:\"oc-ref\"=\"lblis/lblis0\"",
+					"name=\"lb-l/lblis0\"",
+					"protocol=\"tcp\"",
+					"vips:\"192.168.0.100:80\"=\"192.168.0.10:8080,192.168.0.11:8080\"",
+					"--", "add", "Logical_Switch", "subnet/net0", "load_balancer", "@lbLis",
+				},
+			},
+		},
+		{
+			name: "source ip hash with health check",
+			vpc: func() *agentmodels.Vpc {
+				vpc, lb := newVpc()
+				lblis := newListener(lb)
+				lblis.Scheduler = apis.LB_SCHEDULER_SCH
+				lblis.HealthCheck = apis.LB_BOOL_ON
+				lblis.HealthCheckInterval = 5
+				lblis.HealthCheckTimeout = 3
+				lblis.HealthCheckRise = 2
+				lblis.HealthCheckFall = 4
+				// backend not in vpc is balanced without being monitored
+				newTestLoadbalancerBackend(lblis, "lbb2", "guest2", "192.168.0.12", 8080)
+				return vpc
+			},
+			want: [][]string{
+				vipArgs,
+				{
+					"--", "--id=@lbHc", "create", "Load_Balancer_Health_Check",
+					"vip=\"192.168.0.100:80\"",
+					"options:interval=5",
+					"options:timeout=3",
+					"options:success_count=2",
+					"options:failure_count=4",
+					"--", "--id=@lbLis", "create", "Load_Balancer",
+					"external_ids:\"oc-lb-health-check\"=\"interval=5,timeout=3,success_count=2,failure_count=4," +
+						"192.168.0.10=" + gnpName("net0", "eth0") + ":192.168.0.101," +
+						"192.168.0.11=" + gnpName("net0", "eth0") + ":192.168.0.101\"",
+					"external_ids:\"oc-lb-selection-fields\"=\"ip_src\"",
+					"external_ids:\"oc-ref\"=\"lblis/lblis0\"",
+					"name=\"lb-l/lblis0\"",
+					"protocol=\"tcp\"",
+					"vips:\"192.168.0.100:80\"=\"192.168.0.10:8080,192.168.0.11:8080,192.168.0.12:8080\"",
+					"selection_fields=ip_src",
+					"health_check=@lbHc",
+					"ip_port_mappings:\"192.168.0.10\"=\"" + gnpName("net0", "eth0") + ":192.168.0.101\"",
+					"ip_port_mappings:\"192.168.0.11\"=\"" + gnpName("net0", "eth0") + ":192.168.0.101\"",
+					"--", "add", "Logical_Switch", "subnet/net0", "load_balancer", "@lbLis",
+				},
+			},
+		},
+		{
+			name: "health check without source address",
+			vpc: func() *agentmodels.Vpc {
+				vpc := newTestVpc("vpc0", apis.VPC_EXTERNAL_ACCESS_MODE_EIP)
+				network := newTestNetwork(vpc, "net0", "192.168.0.1", 24)
+				newTestGuestnetwork(network, "guest0", "eth0", "192.168.0.10")
+				newTestGuestnetwork(network, "guest1", "eth0", "192.168.0.11")
+				lb := newTestLoadbalancer(vpc, "lb0", "net0", "192.168.0.100")
+				lblis := newListener(lb)
+				lblis.HealthCheck = apis.LB_BOOL_ON
+				return vpc
+			},
+			want: [][]string{
+				vipArgs,
+				{
+					"--", "--id=@lbLis", "create", "Load_Balancer",
+					"external_ids:\"oc-ref\"=\"lblis/lblis0\"",
+					"name=\"lb-l/lblis0\"",
+					"protocol=\"tcp\"",
+					"vips:\"192.168.0.100:80\"=\"192.168.0.10:8080,192.168.0.11:8080\"",
+					"--", "add", "Logical_Switch", "subnet/net0", "load_balancer", "@lbLis",
+				},
+			},
+		},
+		{
+			name: "already realized",
+			vpc: func() *agentmodels.Vpc {
+				vpc, lb := newVpc()
+				newListener(lb)
+				return vpc
+			},
+			keeper: func() *OVNNorthboundKeeper {
+				return realizedKeeper("lb0")
+			},
+		},
+		{
+			name: "attach to new network",
+			vpc: func() *agentmodels.Vpc {
+				vpc, lb := newVpc()
+				newListener(lb)
+				newTestNetwork(vpc, "net1", "192.168.1.1", 24)
+				return vpc
+			},
+			keeper: func() *OVNNorthboundKeeper {
+				return realizedKeeper("lb0")
+			},
+			want: [][]string{
+				{"--", "add", "Logical_Switch", "subnet/net1", "load_balancer", "lb0"},
+			},
+		},
+		{
+			name: "http listener",
+			vpc: func() *agentmodels.Vpc {
+				vpc, lb := newVpc()
+				lblis := newListener(lb)
+				lblis.ListenerType = apis.LB_LISTENER_TYPE_HTTP
+				return vpc
+			},
+			want:    [][]string{vipArgs},
+			wantErr: true,
+		},
+		{
+			name: "no backend",
+			vpc: func() *agentmodels.Vpc {
+				vpc, lb := newVpc()
+				newTestLoadbalancerListener(lb, "lblis0", apis.LB_LISTENER_TYPE_UDP, 53)
+				return vpc
+			},
+			want: [][]string{vipArgs},
+		},
+		{
+			name: "lbagent cluster",
+			vpc: func() *agentmodels.Vpc {
+				vpc, lb := newVpc()
+				newListener(lb)
+				lb.LoadbalancerCluster.ClusterType = apis.LB_CLUSTER_TYPE_LBAGENT
+				return vpc
+			},
+		},
+		{
+			name: "network not in vpc",
+			vpc: func() *agentmodels.Vpc {
+				vpc, lb := newVpc()
+				newListener(lb)
+				lb.NetworkId = "net1"
+				return vpc
+			},
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake := newFakeOvnNbCtl(t)
+			defer fake.Close()
+
+			keeper := newTestKeeper()
+			if c.keeper != nil {
+				keeper = c.keeper()
+			}
+			err := keeper.ClaimVpcLoadbalancers(context.Background(), c.vpc())
+			if c.wantErr != (err != nil) {
+				t.Errorf("ClaimVpcLoadbalancers: %v, want error %v", err, c.wantErr)
+			}
+			got := fake.Calls(t)
+			if !reflect.DeepEqual(sortCallArgs(got), sortCallArgs(c.want)) {
+				t.Errorf("got calls\n%q\nwant\n%q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestKeeperSweepLoadbalancers(t *testing.T) {
+	fake := newFakeOvnNbCtl(t)
+	defer fake.Close()
+
+	keeper := newTestKeeper()
+	keeper.DB.LoadBalancer = ovn_nb.LoadBalancerTable{
+		{Uuid: "lb0", Name: lbListenerLbName("lblis0"), ExternalIds: marked("lb0")},
+		{Uuid: "lb1", Name: lbListenerLbName("lblis1")},
+		{Uuid: "lb2", Name: natDEntryLbName("dnat0")},
+	}
+	if err := keeper.Sweep(context.Background()); err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	want := [][]string{
+		{
+			"--", "--if-exists", "destroy", "Load_Balancer", "lb1",
+			"--", "--if-exists", "destroy", "Load_Balancer", "lb2",
+		},
+	}
+	if got := fake.Calls(t); !reflect.DeepEqual(got, want) {
+		t.Errorf("got calls\n%q\nwant\n%q", got, want)
+	}
+
+	keeper.Mark(context.Background())
+	if _, ok := keeper.DB.LoadBalancer[0].GetExternalId(externalKeyOcVersion); ok {
+		t.Errorf("load balancer is still marked")
+	}
+}
@@ -25,6 +25,10 @@ func HashVpcHostDistgwMac(hostId string) string {
 	return HashMac(hostId)
 }
 
+func HashVpcPeerMac(vpcId, peerVpcId string) string {
+	return HashMac(vpcId, peerVpcId, "peer")
+}
+
 func HashSubnetRouterPortMac(netId string) string {
 	return HashMac(netId, "rp")
 }
@@ -36,3 +40,7 @@ func HashSubnetDhcpMac(netId string) string {
 func HashSubnetMetadataMac(netId string) string {
 	return HashMac(netId, "md")
 }
+
+func HashLoadbalancerVipMac(lbId string) string {
+	return HashMac(lbId, "lbvip")
+}
@@ -67,6 +67,29 @@ func vpcEipLspName(vpcId string, eipgwId string) string {
 	return fmt.Sprintf("vpc-ep/%s/%s", vpcId, eipgwId)
 }
 
+// vpc peering
+func vpcPeerpName(vpcId string, peerVpcId string) string {
+	return fmt.Sprintf("vpc-peer/%s/%s", vpcId, peerVpcId)
+}
+
+// nat gateway
+func natDEntryLbName(dnatId string) string {
+	return fmt.Sprintf("nat-d/%s", dnatId)
+}
+
+// loadbalancer
+func lbRvpName(lbId string) string {
+	return fmt.Sprintf("lb-rv/%s", lbId)
+}
+
+func lbVrpName(lbId string) string {
+	return fmt.Sprintf("lb-vr/%s", lbId)
+}
+
+func lbListenerLbName(listenerId string) string {
+	return fmt.Sprintf("lb-l/%s", listenerId)
+}
+
 func netLsName(netId string) string {
 	return fmt.Sprintf("subnet/%s", netId)
 }
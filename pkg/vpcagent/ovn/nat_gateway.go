@@ -0,0 +1,168 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/netutils"
+
+	apis "yunion.io/x/onecloud/pkg/apis/compute"
+	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+	"yunion.io/x/onecloud/pkg/vpcagent/ovn/schema/ovn_nb"
+)
+
+// natSEntryPrefix returns the source cidr the snat entry applies to
+func natSEntryPrefix(vpc *agentmodels.Vpc, snat *agentmodels.NatSEntry) (string, error) {
+	if snat.SourceCIDR != "" {
+		prefix, err := netutils.NewIPV4Prefix(snat.SourceCIDR)
+		if err != nil {
+			return "", errors.Wrapf(err, "source cidr %s", snat.SourceCIDR)
+		}
+		return prefix.String(), nil
+	}
+	network, ok := vpc.Networks[snat.NetworkId]
+	if !ok {
+		return "", errors.Errorf("network %s not found in vpc %s", snat.NetworkId, vpc.Id)
+	}
+	prefix, err := netutils.NewIPV4Prefix(fmt.Sprintf("%s/%d", network.GuestIpStart, network.GuestIpMask))
+	if err != nil {
+		return "", errors.Wrapf(err, "network %s(%s)", network.Name, network.Id)
+	}
+	return prefix.String(), nil
+}
+
+// ClaimVpcNatGateways realizes nat gateways of the vpc on the vpc ext
+// logical router.
+//
+// Snat entries are realized as snat rules with eip as the external ip.
+// Dnat entries forward ports, which the NAT table can not express, so each
+// of them is realized as a load balancer with the eip port as the vip.
+// Traffic of the internal side is routed to the eip gateway with src-ip
+// routes so that it leaves the vpc from where the eip lives
+func (keeper *OVNNorthboundKeeper) ClaimVpcNatGateways(ctx context.Context, vpc *agentmodels.Vpc) error {
+	if len(vpc.NatGateways) == 0 {
+		return nil
+	}
+	if !vpcHasEipgw(vpc) {
+		log.Warningf("vpc %s(%s) has nat gateways but external access mode %s has no eip gateway",
+			vpc.Name, vpc.Id, vpc.ExternalAccessMode)
+		return nil
+	}
+	var errs []error
+	for _, nat := range vpc.NatGateways {
+		for _, snat := range nat.NatSEntries {
+			if snat.Status != apis.NAT_STAUTS_AVAILABLE {
+				continue
+			}
+			if err := keeper.claimNatSEntry(ctx, vpc, snat); err != nil {
+				errs = append(errs, errors.Wrapf(err, "snat entry %s(%s)", snat.Name, snat.Id))
+			}
+		}
+		for _, dnat := range nat.NatDEntries {
+			if dnat.Status != apis.NAT_STAUTS_AVAILABLE {
+				continue
+			}
+			if err := keeper.claimNatDEntry(ctx, vpc, dnat); err != nil {
+				errs = append(errs, errors.Wrapf(err, "dnat entry %s(%s)", dnat.Name, dnat.Id))
+			}
+		}
+	}
+	return errors.NewAggregate(errs)
+}
+
+func (keeper *OVNNorthboundKeeper) claimNatSEntry(ctx context.Context, vpc *agentmodels.Vpc, snat *agentmodels.NatSEntry) error {
+	prefix, err := natSEntryPrefix(vpc, snat)
+	if err != nil {
+		return err
+	}
+	var (
+		ocVersion = fmt.Sprintf("%s.%d", snat.UpdatedAt, snat.UpdateVersion)
+		ocRef     = fmt.Sprintf("snat/%s", snat.Id)
+	)
+	natRule := &ovn_nb.NAT{
+		Type:       "snat",
+		ExternalIp: snat.IP,
+		LogicalIp:  prefix,
+		ExternalIds: map[string]string{
+			externalKeyOcRef: ocRef,
+		},
+	}
+	natRoute := &ovn_nb.LogicalRouterStaticRoute{
+		Policy:     ptr("src-ip"),
+		IpPrefix:   prefix,
+		Nexthop:    apis.VpcEipGatewayIP3().String(),
+		OutputPort: ptr(vpcRepName(vpc.Id)),
+		ExternalIds: map[string]string{
+			externalKeyOcRef: ocRef,
+		},
+	}
+	allFound, args := cmp(&keeper.DB, ocVersion, natRule, natRoute)
+	if allFound {
+		return nil
+	}
+	args = append(args, ovnCreateArgs(natRule, "natRule")...)
+	args = append(args, "--", "add", "Logical_Router", vpcExtLrName(vpc.Id), "nat", "@natRule")
+	args = append(args, ovnCreateArgs(natRoute, "natRoute")...)
+	args = append(args, "--", "add", "Logical_Router", vpcExtLrName(vpc.Id), "static_routes", "@natRoute")
+	keeper.cli.Must(ctx, "ClaimNatSEntry", args)
+	return nil
+}
+
+func (keeper *OVNNorthboundKeeper) claimNatDEntry(ctx context.Context, vpc *agentmodels.Vpc, dnat *agentmodels.NatDEntry) error {
+	proto := strings.ToLower(dnat.IpProtocol)
+	switch proto {
+	case "tcp", "udp":
+	default:
+		return errors.Errorf("unsupported ip protocol %q", dnat.IpProtocol)
+	}
+	var (
+		ocVersion = fmt.Sprintf("%s.%d", dnat.UpdatedAt, dnat.UpdateVersion)
+		ocRef     = fmt.Sprintf("dnat/%s", dnat.Id)
+	)
+	natLb := &ovn_nb.LoadBalancer{
+		Name:     natDEntryLbName(dnat.Id),
+		Protocol: ptr(proto),
+		Vips: map[string]string{
+			fmt.Sprintf("%s:%d", dnat.ExternalIP, dnat.ExternalPort): fmt.Sprintf("%s:%d", dnat.InternalIP, dnat.InternalPort),
+		},
+		ExternalIds: map[string]string{
+			externalKeyOcRef: ocRef,
+		},
+	}
+	natRoute := &ovn_nb.LogicalRouterStaticRoute{
+		Policy:     ptr("src-ip"),
+		IpPrefix:   dnat.InternalIP + "/32",
+		Nexthop:    apis.VpcEipGatewayIP3().String(),
+		OutputPort: ptr(vpcRepName(vpc.Id)),
+		ExternalIds: map[string]string{
+			externalKeyOcRef: ocRef,
+		},
+	}
+	allFound, args := cmp(&keeper.DB, ocVersion, natLb, natRoute)
+	if allFound {
+		return nil
+	}
+	args = append(args, ovnCreateArgs(natLb, "natLb")...)
+	args = append(args, "--", "add", "Logical_Router", vpcExtLrName(vpc.Id), "load_balancer", "@natLb")
+	args = append(args, ovnCreateArgs(natRoute, "natRoute")...)
+	args = append(args, "--", "add", "Logical_Router", vpcExtLrName(vpc.Id), "static_routes", "@natRoute")
+	keeper.cli.Must(ctx, "ClaimNatDEntry", args)
+	return nil
+}
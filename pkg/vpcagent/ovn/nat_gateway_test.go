@@ -0,0 +1,261 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovn
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	apis "yunion.io/x/onecloud/pkg/apis/compute"
+	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+	"yunion.io/x/onecloud/pkg/vpcagent/ovn/schema/ovn_nb"
+)
+
+func newTestNatGateway(vpc *agentmodels.Vpc, id string) *agentmodels.NatGateway {
+	nat := &agentmodels.NatGateway{
+		Vpc:         vpc,
+		NatSEntries: agentmodels.NatSEntries{},
+		NatDEntries: agentmodels.NatDEntries{},
+	}
+	nat.Id = id
+	vpc.NatGateways[id] = nat
+	return nat
+}
+
+func newTestNatSEntry(nat *agentmodels.NatGateway, id string, ip string, networkId string, sourceCidr string) *agentmodels.NatSEntry {
+	snat := &agentmodels.NatSEntry{
+		NatGateway: nat,
+	}
+	snat.Id = id
+	snat.Status = apis.NAT_STAUTS_AVAILABLE
+	snat.IP = ip
+	snat.NetworkId = networkId
+	snat.SourceCIDR = sourceCidr
+	nat.NatSEntries[id] = snat
+	return snat
+}
+
+func newTestNatDEntry(nat *agentmodels.NatGateway, id string, proto string, externalIp string, externalPort int, internalIp string, internalPort int) *agentmodels.NatDEntry {
+	dnat := &agentmodels.NatDEntry{
+		NatGateway: nat,
+	}
+	dnat.Id = id
+	dnat.Status = apis.NAT_STAUTS_AVAILABLE
+	dnat.IpProtocol = proto
+	dnat.ExternalIP = externalIp
+	dnat.ExternalPort = externalPort
+	dnat.InternalIP = internalIp
+	dnat.InternalPort = internalPort
+	nat.NatDEntries[id] = dnat
+	return dnat
+}
+
+func TestNatSEntryPrefix(t *testing.T) {
+	vpc := newTestVpc("vpc0", apis.VPC_EXTERNAL_ACCESS_MODE_EIP)
+	newTestNetwork(vpc, "net0", "192.168.0.1", 24)
+	nat := newTestNatGateway(vpc, "nat0")
+	cases := []struct {
+		name       string
+		networkId  string
+		sourceCidr string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "source cidr",
+			sourceCidr: "10.0.1.0/24",
+			want:       "10.0.1.0/24",
+		},
+		{
+			name:       "source cidr takes precedence",
+			networkId:  "net0",
+			sourceCidr: "10.0.1.0/24",
+			want:       "10.0.1.0/24",
+		},
+		{
+			name:      "network",
+			networkId: "net0",
+			want:      "192.168.0.0/24",
+		},
+		{
+			name:      "network not in vpc",
+			networkId: "net1",
+			wantErr:   true,
+		},
+		{
+			name:       "invalid source cidr",
+			sourceCidr: "10.0.1.0/33",
+			wantErr:    true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			snat := newTestNatSEntry(nat, "snat0", "10.168.0.10", c.networkId, c.sourceCidr)
+			got, err := natSEntryPrefix(vpc, snat)
+			if c.wantErr {
+				if err == nil {
+					t.Errorf("want error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("natSEntryPrefix: %v", err)
+			}
+			if got != c.want {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestClaimVpcNatGateways(t *testing.T) {
+	cases := []struct {
+		name    string
+		mode    string
+		entries func(nat *agentmodels.NatGateway)
+		keeper  func() *OVNNorthboundKeeper
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name: "snat",
+			mode: apis.VPC_EXTERNAL_ACCESS_MODE_EIP,
+			entries: func(nat *agentmodels.NatGateway) {
+				newTestNatSEntry(nat, "snat0", "10.168.0.10", "net0", "")
+			},
+			want: [][]string{
+				{
+					"--", "--id=@natRule", "create", "NAT",
+					"external_ids:\"oc-ref\"=\"snat/snat0\"",
+					"external_ip=\"10.168.0.10\"",
+					"logical_ip=\"192.168.0.0/24\"",
+					"type=\"snat\"",
+					"--", "add", "Logical_Router", "vpc-ext-r/vpc0", "nat", "@natRule",
+					"--", "--id=@natRoute", "create", "Logical_Router_Static_Route",
+					"external_ids:\"oc-ref\"=\"snat/snat0\"",
+					"ip_prefix=\"192.168.0.0/24\"",
+					"nexthop=\"100.64.128.3\"",
+					"output_port=\"vpc-re/vpc0\"",
+					"policy=\"src-ip\"",
+					"--", "add", "Logical_Router", "vpc-ext-r/vpc0", "static_routes", "@natRoute",
+				},
+			},
+		},
+		{
+			name: "snat already realized",
+			mode: apis.VPC_EXTERNAL_ACCESS_MODE_EIP,
+			entries: func(nat *agentmodels.NatGateway) {
+				newTestNatSEntry(nat, "snat0", "10.168.0.10", "net0", "")
+			},
+			keeper: func() *OVNNorthboundKeeper {
+				keeper := newTestKeeper()
+				keeper.DB.NAT = ovn_nb.NATTable{
+					{
+						Uuid:       "nat0",
+						Type:       "snat",
+						ExternalIp: "10.168.0.10",
+						LogicalIp:  "192.168.0.0/24",
+						ExternalIds: map[string]string{
+							externalKeyOcRef: "snat/snat0",
+						},
+					},
+				}
+				keeper.DB.LogicalRouterStaticRoute = ovn_nb.LogicalRouterStaticRouteTable{
+					{
+						Uuid:       "route0",
+						Policy:     ptr("src-ip"),
+						IpPrefix:   "192.168.0.0/24",
+						Nexthop:    "100.64.128.3",
+						OutputPort: ptr("vpc-re/vpc0"),
+						ExternalIds: map[string]string{
+							externalKeyOcRef: "snat/snat0",
+						},
+					},
+				}
+				return keeper
+			},
+		},
+		{
+			name: "dnat",
+			mode: apis.VPC_EXTERNAL_ACCESS_MODE_EIP_DISTGW,
+			entries: func(nat *agentmodels.NatGateway) {
+				newTestNatDEntry(nat, "dnat0", "TCP", "10.168.0.10", 8080, "192.168.0.20", 80)
+			},
+			want: [][]string{
+				{
+					"--", "--id=@natLb", "create", "Load_Balancer",
+					"external_ids:\"oc-ref\"=\"dnat/dnat0\"",
+					"name=\"nat-d/dnat0\"",
+					"protocol=\"tcp\"",
+					"vips:\"10.168.0.10:8080\"=\"192.168.0.20:80\"",
+					"--", "add", "Logical_Router", "vpc-ext-r/vpc0", "load_balancer", "@natLb",
+					"--", "--id=@natRoute", "create", "Logical_Router_Static_Route",
+					"external_ids:\"oc-ref\"=\"dnat/dnat0\"",
+					"ip_prefix=\"192.168.0.20/32\"",
+					"nexthop=\"100.64.128.3\"",
+					"output_port=\"vpc-re/vpc0\"",
+					"policy=\"src-ip\"",
+					"--", "add", "Logical_Router", "vpc-ext-r/vpc0", "static_routes", "@natRoute",
+				},
+			},
+		},
+		{
+			name: "dnat unsupported protocol",
+			mode: apis.VPC_EXTERNAL_ACCESS_MODE_EIP,
+			entries: func(nat *agentmodels.NatGateway) {
+				newTestNatDEntry(nat, "dnat0", "icmp", "10.168.0.10", 0, "192.168.0.20", 0)
+			},
+			wantErr: true,
+		},
+		{
+			name: "entry not available",
+			mode: apis.VPC_EXTERNAL_ACCESS_MODE_EIP,
+			entries: func(nat *agentmodels.NatGateway) {
+				snat := newTestNatSEntry(nat, "snat0", "10.168.0.10", "net0", "")
+				snat.Status = apis.NAT_STATUS_ALLOCATE
+			},
+		},
+		{
+			name: "no eip gateway",
+			mode: apis.VPC_EXTERNAL_ACCESS_MODE_DISTGW,
+			entries: func(nat *agentmodels.NatGateway) {
+				newTestNatSEntry(nat, "snat0", "10.168.0.10", "net0", "")
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake := newFakeOvnNbCtl(t)
+			defer fake.Close()
+
+			keeper := newTestKeeper()
+			if c.keeper != nil {
+				keeper = c.keeper()
+			}
+			vpc := newTestVpc("vpc0", c.mode)
+			newTestNetwork(vpc, "net0", "192.168.0.1", 24)
+			c.entries(newTestNatGateway(vpc, "nat0"))
+			err := keeper.ClaimVpcNatGateways(context.Background(), vpc)
+			if c.wantErr != (err != nil) {
+				t.Errorf("ClaimVpcNatGateways: %v, want error %v", err, c.wantErr)
+			}
+			got := fake.Calls(t)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got calls\n%q\nwant\n%q", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,424 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovn
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"yunion.io/x/log"
+	"yunion.io/x/ovsdb/types"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/netutils"
+
+	apis "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+	"yunion.io/x/onecloud/pkg/vpcagent/ovn/mac"
+	"yunion.io/x/onecloud/pkg/vpcagent/ovn/schema/ovn_nb"
+)
+
+const (
+	routeDefaultCidr = "0.0.0.0/0"
+
+	// priority of router policies realizing routes of route tables
+	// associated with subnets, plus the mask length of route destination
+	routeTablePolicyPriorityBase = 1000
+)
+
+// vpcPeerIPs returns the addresses of both ends of the link between the
+// logical routers of the two vpcs.  The /30 of the link is picked from
+// VpcPeerCidr by hashing the vpc ids, so both vpcs agree on it
+func vpcPeerIPs(vpcId, peerVpcId string) (netutils.IPV4Addr, netutils.IPV4Addr) {
+	a, b := vpcId, peerVpcId
+	if a > b {
+		a, b = b, a
+	}
+	var (
+		sum   = md5.Sum([]byte(a + "/" + b))
+		cidr  = apis.VpcPeerCidr()
+		count = uint32(1) << uint(apis.VpcPeerMask-int(cidr.MaskLen))
+		idx   = binary.BigEndian.Uint32(sum[:4]) % count
+		base  = uint32(cidr.Address) + idx<<uint(32-apis.VpcPeerMask)
+		ip1   = netutils.IPV4Addr(base + 1)
+		ip2   = netutils.IPV4Addr(base + 2)
+	)
+	if vpcId == a {
+		return ip1, ip2
+	}
+	return ip2, ip1
+}
+
+// routeTableSrcPrefixes returns the cidrs of subnets the route table is
+// associated with.  nil means the route table applies to the whole vpc
+func routeTableSrcPrefixes(vpc *agentmodels.Vpc, rt *agentmodels.RouteTable) []string {
+	var prefixes []string
+	for _, assoc := range rt.RouteTableAssociations {
+		if assoc.AssociationType != string(cloudprovider.RouteTableAssociaToSubnet) {
+			continue
+		}
+		if prefixes == nil {
+			prefixes = []string{}
+		}
+		network, ok := vpc.Networks[assoc.AssociatedResourceId]
+		if !ok {
+			log.Warningf("route table %s(%s): associated subnet %s not found in vpc %s",
+				rt.Name, rt.Id, assoc.AssociatedResourceId, vpc.Id)
+			continue
+		}
+		prefix, err := netutils.NewIPV4Prefix(fmt.Sprintf("%s/%d", network.GuestIpStart, network.GuestIpMask))
+		if err != nil {
+			log.Warningf("route table %s(%s): subnet %s(%s): %v", rt.Name, rt.Id, network.Name, network.Id, err)
+			continue
+		}
+		prefixes = append(prefixes, prefix.String())
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+// vpcHasCustomDefaultRoute returns true if the default route of vpc logical
+// router is taken over by a route table of the whole vpc
+func vpcHasCustomDefaultRoute(vpc *agentmodels.Vpc) bool {
+	for _, rt := range vpc.RouteTables {
+		if rt.Routes == nil || routeTableSrcPrefixes(vpc, rt) != nil {
+			continue
+		}
+		for _, route := range *rt.Routes {
+			if route.Type != apis.ROUTE_ENTRY_TYPE_SYSTEM && route.Cidr == routeDefaultCidr {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type routeNexthop struct {
+	nexthop    string
+	outputPort *string
+	// the route continues from vpc ext logical router to the eip gateway
+	viaEipgw bool
+	peer     *agentmodels.Vpc
+}
+
+func resolveRouteNexthop(vpc *agentmodels.Vpc, vpcs agentmodels.Vpcs, route *apis.SRoute) (*routeNexthop, error) {
+	switch route.NextHopType {
+	case apis.Next_HOP_TYPE_INSTANCE:
+		var ips []string
+		for _, network := range vpc.Networks {
+			for _, guestnetwork := range network.Guestnetworks {
+				if guestnetwork.GuestId == route.NextHopId {
+					ips = append(ips, guestnetwork.IpAddr)
+				}
+			}
+		}
+		if len(ips) == 0 {
+			return nil, errors.Errorf("guest %s has no address in vpc", route.NextHopId)
+		}
+		sort.Strings(ips)
+		return &routeNexthop{nexthop: ips[0]}, nil
+	case apis.Next_HOP_TYPE_INTERNET:
+		if !vpcHasEipgw(vpc) {
+			return nil, errors.Errorf("vpc external access mode %q has no eip gateway", vpc.ExternalAccessMode)
+		}
+		return &routeNexthop{
+			nexthop:    apis.VpcInterExtIP2().String(),
+			outputPort: ptr(vpcR1extpName(vpc.Id)),
+			viaEipgw:   true,
+		}, nil
+	case apis.Next_HOP_TYPE_VPC:
+		peer, ok := vpcs[route.NextHopId]
+		if !ok || peer.Id == apis.DEFAULT_VPC_ID || peer.Id == vpc.Id {
+			return nil, errors.Errorf("invalid peer vpc %s", route.NextHopId)
+		}
+		_, peerIp := vpcPeerIPs(vpc.Id, peer.Id)
+		return &routeNexthop{
+			nexthop:    peerIp.String(),
+			outputPort: ptr(vpcPeerpName(vpc.Id, peer.Id)),
+			peer:       peer,
+		}, nil
+	default:
+		return nil, errors.Errorf("unsupported next hop type %q", route.NextHopType)
+	}
+}
+
+// routeTablePolicyPriority returns the priority of router policy for the
+// route of dst prefix, more specific routes take precedence
+func routeTablePolicyPriority(dst netutils.IPV4Prefix) int64 {
+	return routeTablePolicyPriorityBase + int64(dst.MaskLen)
+}
+
+// routeTablePolicyMatch returns the match of router policy rerouting the
+// traffic from src to dst
+func routeTablePolicyMatch(src string, dst netutils.IPV4Prefix) string {
+	if dst.MaskLen == 0 {
+		return fmt.Sprintf("ip4.src == %s", src)
+	}
+	return fmt.Sprintf("ip4.src == %s && ip4.dst == %s", src, dst.String())
+}
+
+type routeTableRows struct {
+	vpcRoutes      []*ovn_nb.LogicalRouterStaticRoute
+	vpcExtRoutes   []*ovn_nb.LogicalRouterStaticRoute
+	vpcPolicies    []*ovn_nb.LogicalRouterPolicy
+	vpcExtPolicies []*ovn_nb.LogicalRouterPolicy
+}
+
+// ClaimVpcRouteTables realizes route tables of the vpc on vpc logical router.
+//
+// Route tables without subnet association apply to the whole vpc and their
+// routes become dst-ip static routes.  OVN static routes match either the
+// source or the destination, so routes of route tables associated with
+// subnets become reroute policies matching both the subnet cidrs and the
+// route destination
+func (keeper *OVNNorthboundKeeper) ClaimVpcRouteTables(ctx context.Context, vpc *agentmodels.Vpc, vpcs agentmodels.Vpcs) error {
+	rtIds := make([]string, 0, len(vpc.RouteTables))
+	for rtId := range vpc.RouteTables {
+		rtIds = append(rtIds, rtId)
+	}
+	sort.Strings(rtIds)
+
+	var (
+		claimed   = map[string]string{} // route key -> route table id
+		peerAddrs = map[string]string{} // link address -> peer vpc id
+		errs      []error
+	)
+	for _, rtId := range rtIds {
+		rt := vpc.RouteTables[rtId]
+		if rt.Routes == nil {
+			continue
+		}
+		var (
+			ocVersion   = fmt.Sprintf("%s.%d", rt.UpdatedAt, rt.UpdateVersion)
+			srcPrefixes = routeTableSrcPrefixes(vpc, rt)
+			rows        routeTableRows
+		)
+		for _, route := range *rt.Routes {
+			if route.Type == apis.ROUTE_ENTRY_TYPE_SYSTEM {
+				continue
+			}
+			dst, err := netutils.NewIPV4Prefix(route.Cidr)
+			if err != nil {
+				log.Warningf("route table %s(%s): route %s: %v", rt.Name, rt.Id, route.Cidr, err)
+				continue
+			}
+			nh, err := resolveRouteNexthop(vpc, vpcs, route)
+			if err != nil {
+				log.Warningf("route table %s(%s): route %s: %v", rt.Name, rt.Id, route.Cidr, err)
+				continue
+			}
+			if nh.peer != nil {
+				addr, _ := vpcPeerIPs(vpc.Id, nh.peer.Id)
+				if peerId, ok := peerAddrs[addr.String()]; ok && peerId != nh.peer.Id {
+					log.Errorf("route table %s(%s): route %s: link address of peer vpc %s conflicts with peer vpc %s",
+						rt.Name, rt.Id, route.Cidr, nh.peer.Id, peerId)
+					continue
+				}
+				peerAddrs[addr.String()] = nh.peer.Id
+				keeper.ClaimVpcPeer(ctx, vpc, nh.peer)
+			}
+			if srcPrefixes == nil {
+				key := "dst-ip/" + dst.String()
+				if id, ok := claimed[key]; ok {
+					log.Warningf("route table %s(%s): route %s conflicts with route table %s", rt.Name, rt.Id, dst.String(), id)
+					continue
+				}
+				claimed[key] = rt.Id
+				ocRef := fmt.Sprintf("rt/%s/dst-ip/%s", rt.Id, dst.String())
+				rows.vpcRoutes = append(rows.vpcRoutes, &ovn_nb.LogicalRouterStaticRoute{
+					Policy:     ptr("dst-ip"),
+					IpPrefix:   dst.String(),
+					Nexthop:    nh.nexthop,
+					OutputPort: nh.outputPort,
+					ExternalIds: map[string]string{
+						externalKeyOcRef: ocRef,
+					},
+				})
+				if nh.viaEipgw {
+					rows.vpcExtRoutes = append(rows.vpcExtRoutes, &ovn_nb.LogicalRouterStaticRoute{
+						Policy:     ptr("dst-ip"),
+						IpPrefix:   dst.String(),
+						Nexthop:    apis.VpcEipGatewayIP3().String(),
+						OutputPort: ptr(vpcRepName(vpc.Id)),
+						ExternalIds: map[string]string{
+							externalKeyOcRef: ocRef,
+						},
+					})
+				}
+				continue
+			}
+			for _, src := range srcPrefixes {
+				key := "policy/" + src + "/" + dst.String()
+				if id, ok := claimed[key]; ok {
+					log.Warningf("route table %s(%s): route %s from %s conflicts with route table %s",
+						rt.Name, rt.Id, dst.String(), src, id)
+					continue
+				}
+				claimed[key] = rt.Id
+				var (
+					ocRef    = fmt.Sprintf("rt/%s/policy/%s/%s", rt.Id, src, dst.String())
+					match    = routeTablePolicyMatch(src, dst)
+					priority = routeTablePolicyPriority(dst)
+				)
+				rows.vpcPolicies = append(rows.vpcPolicies, &ovn_nb.LogicalRouterPolicy{
+					Priority: priority,
+					Match:    match,
+					Action:   "reroute",
+					Nexthop:  ptr(nh.nexthop),
+					ExternalIds: map[string]string{
+						externalKeyOcRef: ocRef,
+					},
+				})
+				if nh.viaEipgw {
+					rows.vpcExtPolicies = append(rows.vpcExtPolicies, &ovn_nb.LogicalRouterPolicy{
+						Priority: priority,
+						Match:    match,
+						Action:   "reroute",
+						Nexthop:  ptr(apis.VpcEipGatewayIP3().String()),
+						ExternalIds: map[string]string{
+							externalKeyOcRef: ocRef,
+						},
+					})
+				}
+			}
+		}
+		if err := keeper.claimRouteTableRows(ctx, vpc, ocVersion, &rows); err != nil {
+			errs = append(errs, errors.Wrapf(err, "route table %s(%s)", rt.Name, rt.Id))
+		}
+	}
+	return errors.NewAggregate(errs)
+}
+
+func (keeper *OVNNorthboundKeeper) claimRouteTableRows(ctx context.Context, vpc *agentmodels.Vpc, ocVersion string, rows *routeTableRows) error {
+	var irows []types.IRow
+	for _, route := range rows.vpcRoutes {
+		irows = append(irows, route)
+	}
+	for _, route := range rows.vpcExtRoutes {
+		irows = append(irows, route)
+	}
+	policies := append(append([]*ovn_nb.LogicalRouterPolicy{}, rows.vpcPolicies...), rows.vpcExtPolicies...)
+	if len(irows) == 0 && len(policies) == 0 {
+		return nil
+	}
+	routesFound, args := cmp(&keeper.DB, ocVersion, irows...)
+	policiesFound, policyArgs := keeper.cmpPolicies(ocVersion, policies...)
+	if routesFound && policiesFound {
+		return nil
+	}
+	args = append(args, policyArgs...)
+	if !routesFound {
+		for i, route := range rows.vpcRoutes {
+			ref := fmt.Sprintf("rtRoute%d", i)
+			args = append(args, ovnCreateArgs(route, ref)...)
+			args = append(args, "--", "add", "Logical_Router", vpcLrName(vpc.Id), "static_routes", "@"+ref)
+		}
+		for i, route := range rows.vpcExtRoutes {
+			ref := fmt.Sprintf("rtExtRoute%d", i)
+			args = append(args, ovnCreateArgs(route, ref)...)
+			args = append(args, "--", "add", "Logical_Router", vpcExtLrName(vpc.Id), "static_routes", "@"+ref)
+		}
+	}
+	if !policiesFound {
+		for i, policy := range rows.vpcPolicies {
+			ref := fmt.Sprintf("rtPolicy%d", i)
+			args = append(args, ovnCreateArgs(policy, ref)...)
+			args = append(args, "--", "add", "Logical_Router", vpcLrName(vpc.Id), "policies", "@"+ref)
+		}
+		for i, policy := range rows.vpcExtPolicies {
+			ref := fmt.Sprintf("rtExtPolicy%d", i)
+			args = append(args, ovnCreateArgs(policy, ref)...)
+			args = append(args, "--", "add", "Logical_Router", vpcExtLrName(vpc.Id), "policies", "@"+ref)
+		}
+	}
+	keeper.cli.Must(ctx, "ClaimVpcRouteTables", args)
+	return nil
+}
+
+// ClaimVpcPeer connects logical routers of the two vpcs with a pair of peer
+// router ports.  The link is claimed once even if both vpcs route to each
+// other
+func (keeper *OVNNorthboundKeeper) ClaimVpcPeer(ctx context.Context, vpc, peer *agentmodels.Vpc) error {
+	a, b := vpc.Id, peer.Id
+	if a > b {
+		a, b = b, a
+	}
+	if keeper.vpcPeers == nil {
+		keeper.vpcPeers = map[string]struct{}{}
+	}
+	if _, ok := keeper.vpcPeers[a+"/"+b]; ok {
+		return nil
+	}
+	keeper.vpcPeers[a+"/"+b] = struct{}{}
+
+	var (
+		ocVersion  = fmt.Sprintf("%s.%d", vpc.UpdatedAt, vpc.UpdateVersion)
+		ip, peerIp = vpcPeerIPs(vpc.Id, peer.Id)
+	)
+	vpcPeerp := &ovn_nb.LogicalRouterPort{
+		Name:     vpcPeerpName(vpc.Id, peer.Id),
+		Mac:      mac.HashVpcPeerMac(vpc.Id, peer.Id),
+		Networks: []string{fmt.Sprintf("%s/%d", ip, apis.VpcPeerMask)},
+		Peer:     ptr(vpcPeerpName(peer.Id, vpc.Id)),
+	}
+	peerVpcPeerp := &ovn_nb.LogicalRouterPort{
+		Name:     vpcPeerpName(peer.Id, vpc.Id),
+		Mac:      mac.HashVpcPeerMac(peer.Id, vpc.Id),
+		Networks: []string{fmt.Sprintf("%s/%d", peerIp, apis.VpcPeerMask)},
+		Peer:     ptr(vpcPeerpName(vpc.Id, peer.Id)),
+	}
+	allFound, args := cmp(&keeper.DB, ocVersion, vpcPeerp, peerVpcPeerp)
+	if allFound {
+		return nil
+	}
+	args = append(args, ovnCreateArgs(vpcPeerp, "vpcPeerp")...)
+	args = append(args, ovnCreateArgs(peerVpcPeerp, "peerVpcPeerp")...)
+	args = append(args, "--", "add", "Logical_Router", vpcLrName(vpc.Id), "ports", "@vpcPeerp")
+	args = append(args, "--", "add", "Logical_Router", vpcLrName(peer.Id), "ports", "@peerVpcPeerp")
+	return keeper.cli.Must(ctx, "ClaimVpcPeer", args)
+}
+
+func (keeper *OVNNorthboundKeeper) lrPolicyRemoveArgs(uuid string) []string {
+	var args []string
+	for _, lr := range keeper.DB.LogicalRouter.FindLogicalRouterPolicyReferrer_policies(uuid) {
+		args = append(args, "--", "--if-exists", "remove", "Logical_Router", lr.Name, "policies", uuid)
+	}
+	return args
+}
+
+// cmpPolicies is cmp for router policies, the policies found are removed
+// from their routers if not all of them are found
+func (keeper *OVNNorthboundKeeper) cmpPolicies(ocver string, rows ...*ovn_nb.LogicalRouterPolicy) (bool, []string) {
+	rowsFound := make([]*ovn_nb.LogicalRouterPolicy, 0, len(rows))
+	for _, row := range rows {
+		if rowFound := keeper.DB.LogicalRouterPolicy.FindOneMatchNonZeros(row); rowFound != nil {
+			rowsFound = append(rowsFound, rowFound)
+		}
+	}
+	for _, rowFound := range rowsFound {
+		rowFound.SetExternalId(externalKeyOcVersion, ocver)
+	}
+	if len(rowsFound) == len(rows) {
+		return true, nil
+	}
+	var args []string
+	for _, rowFound := range rowsFound {
+		args = append(args, keeper.lrPolicyRemoveArgs(rowFound.Uuid)...)
+	}
+	return false, args
+}
@@ -0,0 +1,490 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovn
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"yunion.io/x/pkg/util/netutils"
+
+	apis "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+	"yunion.io/x/onecloud/pkg/vpcagent/ovn/mac"
+	"yunion.io/x/onecloud/pkg/vpcagent/ovn/schema/ovn_nb"
+)
+
+func newTestVpc(id string, mode string) *agentmodels.Vpc {
+	vpc := &agentmodels.Vpc{
+		Networks:      agentmodels.Networks{},
+		RouteTables:   agentmodels.RouteTables{},
+		NatGateways:   agentmodels.NatGateways{},
+		Loadbalancers: agentmodels.Loadbalancers{},
+	}
+	vpc.Id = id
+	vpc.ExternalAccessMode = mode
+	return vpc
+}
+
+func newTestNetwork(vpc *agentmodels.Vpc, id string, ipStart string, mask int8) *agentmodels.Network {
+	network := &agentmodels.Network{
+		Vpc:           vpc,
+		Guestnetworks: agentmodels.Guestnetworks{},
+	}
+	network.Id = id
+	network.GuestIpStart = ipStart
+	network.GuestIpMask = mask
+	vpc.Networks[id] = network
+	return network
+}
+
+func newTestGuestnetwork(network *agentmodels.Network, guestId string, ifname string, ip string) *agentmodels.Guestnetwork {
+	gn := &agentmodels.Guestnetwork{
+		Network: network,
+	}
+	gn.GuestId = guestId
+	gn.NetworkId = network.Id
+	gn.Ifname = ifname
+	gn.IpAddr = ip
+	network.Guestnetworks[guestId+"/"+ifname] = gn
+	return gn
+}
+
+func newTestRouteTable(vpc *agentmodels.Vpc, id string, subnetIds []string, routes ...*apis.SRoute) *agentmodels.RouteTable {
+	rt := &agentmodels.RouteTable{
+		Vpc:                    vpc,
+		RouteTableAssociations: agentmodels.RouteTableAssociations{},
+	}
+	rt.Id = id
+	rt.UpdateVersion = 1
+	rtRoutes := apis.SRoutes(routes)
+	rt.Routes = &rtRoutes
+	for _, subnetId := range subnetIds {
+		assoc := &agentmodels.RouteTableAssociation{
+			RouteTable: rt,
+		}
+		assoc.Id = id + "/" + subnetId
+		assoc.AssociationType = string(cloudprovider.RouteTableAssociaToSubnet)
+		assoc.AssociatedResourceId = subnetId
+		rt.RouteTableAssociations[assoc.Id] = assoc
+	}
+	vpc.RouteTables[id] = rt
+	return rt
+}
+
+func vpcPeerMac(vpcId, peerVpcId string) string {
+	return mac.HashVpcPeerMac(vpcId, peerVpcId)
+}
+
+func vpcPeerNetwork(vpcId, peerVpcId string) string {
+	ip, _ := vpcPeerIPs(vpcId, peerVpcId)
+	return fmt.Sprintf("%s/%d", ip, apis.VpcPeerMask)
+}
+
+func TestVpcPeerIPs(t *testing.T) {
+	ip0, peerIp0 := vpcPeerIPs("vpc0", "vpc1")
+	ip1, peerIp1 := vpcPeerIPs("vpc1", "vpc0")
+	if ip0 != peerIp1 || peerIp0 != ip1 {
+		t.Errorf("vpc0: %s, %s, vpc1: %s, %s", ip0, peerIp0, ip1, peerIp1)
+	}
+	cidr := apis.VpcPeerCidr()
+	if !cidr.Contains(ip0) || !cidr.Contains(peerIp0) {
+		t.Errorf("%s, %s not in %s", ip0, peerIp0, cidr.String())
+	}
+	if uint32(ip0)>>2 != uint32(peerIp0)>>2 {
+		t.Errorf("%s and %s not in the same /30", ip0, peerIp0)
+	}
+}
+
+func TestRouteTablePolicyMatch(t *testing.T) {
+	cases := []struct {
+		src      string
+		dst      string
+		match    string
+		priority int64
+	}{
+		{
+			src:      "192.168.0.0/24",
+			dst:      "0.0.0.0/0",
+			match:    "ip4.src == 192.168.0.0/24",
+			priority: 1000,
+		},
+		{
+			src:      "192.168.0.0/24",
+			dst:      "10.0.0.0/8",
+			match:    "ip4.src == 192.168.0.0/24 && ip4.dst == 10.0.0.0/8",
+			priority: 1008,
+		},
+		{
+			src:      "192.168.0.0/24",
+			dst:      "10.0.0.1/32",
+			match:    "ip4.src == 192.168.0.0/24 && ip4.dst == 10.0.0.1",
+			priority: 1032,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.dst, func(t *testing.T) {
+			dst, err := netutils.NewIPV4Prefix(c.dst)
+			if err != nil {
+				t.Fatalf("prefix %s: %v", c.dst, err)
+			}
+			if got := routeTablePolicyMatch(c.src, dst); got != c.match {
+				t.Errorf("match: got %q, want %q", got, c.match)
+			}
+			if got := routeTablePolicyPriority(dst); got != c.priority {
+				t.Errorf("priority: got %d, want %d", got, c.priority)
+			}
+		})
+	}
+}
+
+func TestVpcHasCustomDefaultRoute(t *testing.T) {
+	defaultRoute := &apis.SRoute{
+		Type:        apis.ROUTE_ENTRY_TYPE_CUSTOM,
+		Cidr:        routeDefaultCidr,
+		NextHopType: apis.Next_HOP_TYPE_INTERNET,
+	}
+	systemDefaultRoute := &apis.SRoute{
+		Type:        apis.ROUTE_ENTRY_TYPE_SYSTEM,
+		Cidr:        routeDefaultCidr,
+		NextHopType: apis.Next_HOP_TYPE_INTERNET,
+	}
+	cases := []struct {
+		name      string
+		subnetIds []string
+		route     *apis.SRoute
+		want      bool
+	}{
+		{
+			name:  "vpc default route",
+			route: defaultRoute,
+			want:  true,
+		},
+		{
+			name:  "vpc system default route",
+			route: systemDefaultRoute,
+		},
+		{
+			name:      "subnet default route",
+			subnetIds: []string{"net0"},
+			route:     defaultRoute,
+		},
+		{
+			name:      "subnet not in vpc",
+			subnetIds: []string{"net1"},
+			route:     defaultRoute,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			vpc := newTestVpc("vpc0", apis.VPC_EXTERNAL_ACCESS_MODE_EIP)
+			newTestNetwork(vpc, "net0", "192.168.0.1", 24)
+			newTestRouteTable(vpc, "rt0", c.subnetIds, c.route)
+			if got := vpcHasCustomDefaultRoute(vpc); got != c.want {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestClaimVpcRouteTables(t *testing.T) {
+	var (
+		instanceRoute = &apis.SRoute{
+			Type:        apis.ROUTE_ENTRY_TYPE_CUSTOM,
+			Cidr:        "10.0.0.0/8",
+			NextHopType: apis.Next_HOP_TYPE_INSTANCE,
+			NextHopId:   "guest0",
+		}
+		internetRoute = &apis.SRoute{
+			Type:        apis.ROUTE_ENTRY_TYPE_CUSTOM,
+			Cidr:        "0.0.0.0/0",
+			NextHopType: apis.Next_HOP_TYPE_INTERNET,
+		}
+		vpcRoute = &apis.SRoute{
+			Type:        apis.ROUTE_ENTRY_TYPE_CUSTOM,
+			Cidr:        "172.16.0.0/16",
+			NextHopType: apis.Next_HOP_TYPE_VPC,
+			NextHopId:   "vpc1",
+		}
+		systemRoute = &apis.SRoute{
+			Type:        apis.ROUTE_ENTRY_TYPE_SYSTEM,
+			Cidr:        "192.168.0.0/24",
+			NextHopType: apis.Next_HOP_TYPE_INSTANCE,
+			NextHopId:   "guest0",
+		}
+	)
+	newVpcs := func(mode string) (*agentmodels.Vpc, agentmodels.Vpcs) {
+		vpc := newTestVpc("vpc0", mode)
+		network := newTestNetwork(vpc, "net0", "192.168.0.1", 24)
+		newTestGuestnetwork(network, "guest0", "eth0", "192.168.0.10")
+		peer := newTestVpc("vpc1", mode)
+		return vpc, agentmodels.Vpcs{vpc.Id: vpc, peer.Id: peer}
+	}
+	peerIp, _ := vpcPeerIPs("vpc1", "vpc0")
+
+	cases := []struct {
+		name   string
+		vpcs   func() (*agentmodels.Vpc, agentmodels.Vpcs)
+		keeper func() *OVNNorthboundKeeper
+		want   [][]string
+	}{
+		{
+			name: "vpc route via instance",
+			vpcs: func() (*agentmodels.Vpc, agentmodels.Vpcs) {
+				vpc, vpcs := newVpcs(apis.VPC_EXTERNAL_ACCESS_MODE_EIP)
+				newTestRouteTable(vpc, "rt0", nil, systemRoute, instanceRoute)
+				return vpc, vpcs
+			},
+			want: [][]string{
+				{
+					"--", "--id=@rtRoute0", "create", "Logical_Router_Static_Route",
+					"external_ids:\"oc-ref\"=\"rt/rt0/dst-ip/10.0.0.0/8\"",
+					"ip_prefix=\"10.0.0.0/8\"",
+					"nexthop=\"192.168.0.10\"",
+					"policy=\"dst-ip\"",
+					"--", "add", "Logical_Router", "vpc-r/vpc0", "static_routes", "@rtRoute0",
+				},
+			},
+		},
+		{
+			name: "vpc route already realized",
+			vpcs: func() (*agentmodels.Vpc, agentmodels.Vpcs) {
+				vpc, vpcs := newVpcs(apis.VPC_EXTERNAL_ACCESS_MODE_EIP)
+				newTestRouteTable(vpc, "rt0", nil, instanceRoute)
+				return vpc, vpcs
+			},
+			keeper: func() *OVNNorthboundKeeper {
+				keeper := newTestKeeper()
+				keeper.DB.LogicalRouterStaticRoute = ovn_nb.LogicalRouterStaticRouteTable{
+					{
+						Uuid:     "route0",
+						Policy:   ptr("dst-ip"),
+						IpPrefix: "10.0.0.0/8",
+						Nexthop:  "192.168.0.10",
+						ExternalIds: map[string]string{
+							externalKeyOcRef: "rt/rt0/dst-ip/10.0.0.0/8",
+						},
+					},
+				}
+				return keeper
+			},
+		},
+		{
+			name: "vpc route changed",
+			vpcs: func() (*agentmodels.Vpc, agentmodels.Vpcs) {
+				vpc, vpcs := newVpcs(apis.VPC_EXTERNAL_ACCESS_MODE_EIP)
+				newTestRouteTable(vpc, "rt0", nil, instanceRoute)
+				return vpc, vpcs
+			},
+			keeper: func() *OVNNorthboundKeeper {
+				keeper := newTestKeeper()
+				keeper.DB.LogicalRouterStaticRoute = ovn_nb.LogicalRouterStaticRouteTable{
+					{
+						Uuid:     "route0",
+						Policy:   ptr("dst-ip"),
+						IpPrefix: "10.0.0.0/8",
+						Nexthop:  "192.168.0.11",
+						ExternalIds: map[string]string{
+							externalKeyOcRef: "rt/rt0/dst-ip/10.0.0.0/8",
+						},
+					},
+				}
+				return keeper
+			},
+			want: [][]string{
+				{
+					"--", "--id=@rtRoute0", "create", "Logical_Router_Static_Route",
+					"external_ids:\"oc-ref\"=\"rt/rt0/dst-ip/10.0.0.0/8\"",
+					"ip_prefix=\"10.0.0.0/8\"",
+					"nexthop=\"192.168.0.10\"",
+					"policy=\"dst-ip\"",
+					"--", "add", "Logical_Router", "vpc-r/vpc0", "static_routes", "@rtRoute0",
+				},
+			},
+		},
+		{
+			name: "vpc default route via internet",
+			vpcs: func() (*agentmodels.Vpc, agentmodels.Vpcs) {
+				vpc, vpcs := newVpcs(apis.VPC_EXTERNAL_ACCESS_MODE_EIP)
+				newTestRouteTable(vpc, "rt0", nil, internetRoute)
+				return vpc, vpcs
+			},
+			want: [][]string{
+				{
+					"--", "--id=@rtRoute0", "create", "Logical_Router_Static_Route",
+					"external_ids:\"oc-ref\"=\"rt/rt0/dst-ip/0.0.0.0/0\"",
+					"ip_prefix=\"0.0.0.0/0\"",
+					"nexthop=\"100.65.0.2\"",
+					"output_port=\"vpc-r1ext/vpc0\"",
+					"policy=\"dst-ip\"",
+					"--", "add", "Logical_Router", "vpc-r/vpc0", "static_routes", "@rtRoute0",
+					"--", "--id=@rtExtRoute0", "create", "Logical_Router_Static_Route",
+					"external_ids:\"oc-ref\"=\"rt/rt0/dst-ip/0.0.0.0/0\"",
+					"ip_prefix=\"0.0.0.0/0\"",
+					"nexthop=\"100.64.128.3\"",
+					"output_port=\"vpc-re/vpc0\"",
+					"policy=\"dst-ip\"",
+					"--", "add", "Logical_Router", "vpc-ext-r/vpc0", "static_routes", "@rtExtRoute0",
+				},
+			},
+		},
+		{
+			name: "internet route without eip gateway",
+			vpcs: func() (*agentmodels.Vpc, agentmodels.Vpcs) {
+				vpc, vpcs := newVpcs(apis.VPC_EXTERNAL_ACCESS_MODE_NONE)
+				newTestRouteTable(vpc, "rt0", nil, internetRoute)
+				return vpc, vpcs
+			},
+		},
+		{
+			name: "subnet route via instance",
+			vpcs: func() (*agentmodels.Vpc, agentmodels.Vpcs) {
+				vpc, vpcs := newVpcs(apis.VPC_EXTERNAL_ACCESS_MODE_EIP)
+				newTestRouteTable(vpc, "rt0", []string{"net0"}, instanceRoute)
+				return vpc, vpcs
+			},
+			want: [][]string{
+				{
+					"--", "--id=@rtPolicy0", "create", "Logical_Router_Policy",
+					"action=\"reroute\"",
+					"external_ids:\"oc-ref\"=\"rt/rt0/policy/192.168.0.0/24/10.0.0.0/8\"",
+					"match=\"ip4.src == 192.168.0.0/24 && ip4.dst == 10.0.0.0/8\"",
+					"nexthop=\"192.168.0.10\"",
+					"priority=1008",
+					"--", "add", "Logical_Router", "vpc-r/vpc0", "policies", "@rtPolicy0",
+				},
+			},
+		},
+		{
+			name: "subnet default route via internet",
+			vpcs: func() (*agentmodels.Vpc, agentmodels.Vpcs) {
+				vpc, vpcs := newVpcs(apis.VPC_EXTERNAL_ACCESS_MODE_EIP)
+				newTestRouteTable(vpc, "rt0", []string{"net0"}, internetRoute)
+				return vpc, vpcs
+			},
+			want: [][]string{
+				{
+					"--", "--id=@rtPolicy0", "create", "Logical_Router_Policy",
+					"action=\"reroute\"",
+					"external_ids:\"oc-ref\"=\"rt/rt0/policy/192.168.0.0/24/0.0.0.0/0\"",
+					"match=\"ip4.src == 192.168.0.0/24\"",
+					"nexthop=\"100.65.0.2\"",
+					"priority=1000",
+					"--", "add", "Logical_Router", "vpc-r/vpc0", "policies", "@rtPolicy0",
+					"--", "--id=@rtExtPolicy0", "create", "Logical_Router_Policy",
+					"action=\"reroute\"",
+					"external_ids:\"oc-ref\"=\"rt/rt0/policy/192.168.0.0/24/0.0.0.0/0\"",
+					"match=\"ip4.src == 192.168.0.0/24\"",
+					"nexthop=\"100.64.128.3\"",
+					"priority=1000",
+					"--", "add", "Logical_Router", "vpc-ext-r/vpc0", "policies", "@rtExtPolicy0",
+				},
+			},
+		},
+		{
+			name: "subnet policy changed",
+			vpcs: func() (*agentmodels.Vpc, agentmodels.Vpcs) {
+				vpc, vpcs := newVpcs(apis.VPC_EXTERNAL_ACCESS_MODE_EIP)
+				newTestRouteTable(vpc, "rt0", []string{"net0"}, instanceRoute)
+				return vpc, vpcs
+			},
+			keeper: func() *OVNNorthboundKeeper {
+				keeper := newTestKeeper()
+				keeper.DB.LogicalRouter = ovn_nb.LogicalRouterTable{
+					{Uuid: "lr0", Name: "vpc-r/vpc0", Policies: []string{"policy0"}},
+				}
+				keeper.DB.LogicalRouterPolicy = ovn_nb.LogicalRouterPolicyTable{
+					{
+						Uuid:     "policy0",
+						Action:   "reroute",
+						Match:    "ip4.src == 192.168.0.0/24 && ip4.dst == 10.0.0.0/8",
+						Nexthop:  ptr("192.168.0.11"),
+						Priority: 1008,
+						ExternalIds: map[string]string{
+							externalKeyOcRef: "rt/rt0/policy/192.168.0.0/24/10.0.0.0/8",
+						},
+					},
+				}
+				return keeper
+			},
+			want: [][]string{
+				{
+					"--", "--id=@rtPolicy0", "create", "Logical_Router_Policy",
+					"action=\"reroute\"",
+					"external_ids:\"oc-ref\"=\"rt/rt0/policy/192.168.0.0/24/10.0.0.0/8\"",
+					"match=\"ip4.src == 192.168.0.0/24 && ip4.dst == 10.0.0.0/8\"",
+					"nexthop=\"192.168.0.10\"",
+					"priority=1008",
+					"--", "add", "Logical_Router", "vpc-r/vpc0", "policies", "@rtPolicy0",
+				},
+			},
+		},
+		{
+			name: "route to peer vpc",
+			vpcs: func() (*agentmodels.Vpc, agentmodels.Vpcs) {
+				vpc, vpcs := newVpcs(apis.VPC_EXTERNAL_ACCESS_MODE_EIP)
+				newTestRouteTable(vpc, "rt0", nil, vpcRoute)
+				return vpc, vpcs
+			},
+			want: [][]string{
+				{
+					"--", "--id=@vpcPeerp", "create", "Logical_Router_Port",
+					"mac=\"" + vpcPeerMac("vpc0", "vpc1") + "\"",
+					"name=\"vpc-peer/vpc0/vpc1\"",
+					"networks=[\"" + vpcPeerNetwork("vpc0", "vpc1") + "\"]",
+					"peer=\"vpc-peer/vpc1/vpc0\"",
+					"--", "--id=@peerVpcPeerp", "create", "Logical_Router_Port",
+					"mac=\"" + vpcPeerMac("vpc1", "vpc0") + "\"",
+					"name=\"vpc-peer/vpc1/vpc0\"",
+					"networks=[\"" + vpcPeerNetwork("vpc1", "vpc0") + "\"]",
+					"peer=\"vpc-peer/vpc0/vpc1\"",
+					"--", "add", "Logical_Router", "vpc-r/vpc0", "ports", "@vpcPeerp",
+					"--", "add", "Logical_Router", "vpc-r/vpc1", "ports", "@peerVpcPeerp",
+				},
+				{
+					"--", "--id=@rtRoute0", "create", "Logical_Router_Static_Route",
+					"external_ids:\"oc-ref\"=\"rt/rt0/dst-ip/172.16.0.0/16\"",
+					"ip_prefix=\"172.16.0.0/16\"",
+					"nexthop=\"" + peerIp.String() + "\"",
+					"output_port=\"vpc-peer/vpc0/vpc1\"",
+					"policy=\"dst-ip\"",
+					"--", "add", "Logical_Router", "vpc-r/vpc0", "static_routes", "@rtRoute0",
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake := newFakeOvnNbCtl(t)
+			defer fake.Close()
+
+			keeper := newTestKeeper()
+			if c.keeper != nil {
+				keeper = c.keeper()
+			}
+			vpc, vpcs := c.vpcs()
+			if err := keeper.ClaimVpcRouteTables(context.Background(), vpc, vpcs); err != nil {
+				t.Fatalf("ClaimVpcRouteTables: %v", err)
+			}
+			got := fake.Calls(t)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got calls\n%q\nwant\n%q", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schema keeps the OVN northbound schema vpcagent works with, it is
+// the schema vendored by yunion.io/x/ovsdb plus the Logical_Router_Policy
+// table and the policies column of Logical_Router of later OVN releases.
+// Package ovn_nb is generated from it by ovsdb_gen of yunion.io/x/ovsdb and
+// must not be edited by hand.
+//
+// TODO: drop this package and import yunion.io/x/ovsdb/schema/ovn_nb again
+// once yunion.io/x/ovsdb is bumped to a release generating
+// Logical_Router_Policy
+//
+// ovsdb_gen is not vendored, it is run from the module cache
+//
+//go:generate go run -mod=mod yunion.io/x/ovsdb/cmd/ovsdb_gen -gen schema -schema ovn-nb.ovsschema -outdir ovn_nb
+//go:generate goimports -local yunion.io/x/ -w ovn_nb
+package schema // import "yunion.io/x/onecloud/pkg/vpcagent/ovn/schema"
@@ -19,6 +19,7 @@ type OVNNorthbound struct {
 	GatewayChassis           GatewayChassisTable
 	LoadBalancer             LoadBalancerTable
 	LogicalRouter            LogicalRouterTable
+	LogicalRouterPolicy      LogicalRouterPolicyTable
 	LogicalRouterPort        LogicalRouterPortTable
 	LogicalRouterStaticRoute LogicalRouterStaticRouteTable
 	LogicalSwitch            LogicalSwitchTable
@@ -76,6 +77,11 @@ func (db OVNNorthbound) FindOneMatchNonZeros(irow types.IRow) types.IRow {
 			return r
 		}
 		return nil
+	case *LogicalRouterPolicy:
+		if r := db.LogicalRouterPolicy.FindOneMatchNonZeros(row); r != nil {
+			return r
+		}
+		return nil
 	case *LogicalRouterPort:
 		if r := db.LogicalRouterPort.FindOneMatchNonZeros(row); r != nil {
 			return r
@@ -177,6 +183,11 @@ func (db OVNNorthbound) FindOneMatchByAnyIndex(irow types.IRow) types.IRow {
 			return r
 		}
 		return nil
+	case *LogicalRouterPolicy:
+		if r := db.LogicalRouterPolicy.OvsdbGetByAnyIndex(row); r != nil {
+			return r
+		}
+		return nil
 	case *LogicalRouterPort:
 		if r := db.LogicalRouterPort.OvsdbGetByAnyIndex(row); r != nil {
 			return r
@@ -1488,6 +1499,7 @@ type LogicalRouter struct {
 	Name         string            `json:"name"`
 	Nat          []string          `json:"nat"`
 	Options      map[string]string `json:"options"`
+	Policies     []string          `json:"policies"`
 	Ports        []string          `json:"ports"`
 	StaticRoutes []string          `json:"static_routes"`
 }
@@ -1514,6 +1526,7 @@ func (row *LogicalRouter) OvsdbCmdArgs() []string {
 	r = append(r, types.OvsdbCmdArgsString("name", row.Name)...)
 	r = append(r, types.OvsdbCmdArgsUuidMultiples("nat", row.Nat)...)
 	r = append(r, types.OvsdbCmdArgsMapStringString("options", row.Options)...)
+	r = append(r, types.OvsdbCmdArgsUuidMultiples("policies", row.Policies)...)
 	r = append(r, types.OvsdbCmdArgsUuidMultiples("ports", row.Ports)...)
 	r = append(r, types.OvsdbCmdArgsUuidMultiples("static_routes", row.StaticRoutes)...)
 	return r
@@ -1542,6 +1555,8 @@ func (row *LogicalRouter) SetColumn(name string, val interface{}) (err error) {
 		row.Nat = types.EnsureUuidMultiples(val)
 	case "options":
 		row.Options = types.EnsureMapStringString(val)
+	case "policies":
+		row.Policies = types.EnsureUuidMultiples(val)
 	case "ports":
 		row.Ports = types.EnsureUuidMultiples(val)
 	case "static_routes":
@@ -1577,6 +1592,9 @@ func (row *LogicalRouter) MatchNonZeros(row1 *LogicalRouter) bool {
 	if !types.MatchMapStringStringIfNonZero(row.Options, row1.Options) {
 		return false
 	}
+	if !types.MatchUuidMultiplesIfNonZero(row.Policies, row1.Policies) {
+		return false
+	}
 	if !types.MatchUuidMultiplesIfNonZero(row.Ports, row1.Ports) {
 		return false
 	}
@@ -1610,6 +1628,18 @@ func (tbl LogicalRouterTable) FindNATReferrer_nat(refUuid string) (r []*LogicalR
 	return r
 }
 
+func (tbl LogicalRouterTable) FindLogicalRouterPolicyReferrer_policies(refUuid string) (r []*LogicalRouter) {
+	for i := range tbl {
+		row := &tbl[i]
+		for _, val := range row.Policies {
+			if val == refUuid {
+				r = append(r, row)
+			}
+		}
+	}
+	return r
+}
+
 func (tbl LogicalRouterTable) FindLogicalRouterPortReferrer_ports(refUuid string) (r []*LogicalRouter) {
 	for i := range tbl {
 		row := &tbl[i]
@@ -1664,6 +1694,169 @@ func (row *LogicalRouter) RemoveExternalId(k string) (string, bool) {
 	return r, ok
 }
 
+type LogicalRouterPolicyTable []LogicalRouterPolicy
+
+var _ types.ITable = &LogicalRouterPolicyTable{}
+
+func (tbl LogicalRouterPolicyTable) OvsdbTableName() string {
+	return "Logical_Router_Policy"
+}
+
+func (tbl LogicalRouterPolicyTable) OvsdbIsRoot() bool {
+	return false
+}
+
+func (tbl LogicalRouterPolicyTable) Rows() []types.IRow {
+	r := make([]types.IRow, len(tbl))
+	for i := range tbl {
+		r[i] = &tbl[i]
+	}
+	return r
+}
+
+func (tbl LogicalRouterPolicyTable) NewRow() types.IRow {
+	return &LogicalRouterPolicy{}
+}
+
+func (tbl *LogicalRouterPolicyTable) AppendRow(irow types.IRow) {
+	row := irow.(*LogicalRouterPolicy)
+	*tbl = append(*tbl, *row)
+}
+
+func (tbl LogicalRouterPolicyTable) OvsdbHasIndex() bool {
+	return false
+}
+
+func (tbl LogicalRouterPolicyTable) OvsdbGetByAnyIndex(irow1 types.IRow) types.IRow {
+	return nil
+}
+
+func (tbl LogicalRouterPolicyTable) FindOneMatchNonZeros(row1 *LogicalRouterPolicy) *LogicalRouterPolicy {
+	for i := range tbl {
+		row := &tbl[i]
+		if row.MatchNonZeros(row1) {
+			return row
+		}
+	}
+	return nil
+}
+
+type LogicalRouterPolicy struct {
+	Uuid        string            `json:"_uuid"`
+	Version     string            `json:"_version"`
+	Action      string            `json:"action"`
+	ExternalIds map[string]string `json:"external_ids"`
+	Match       string            `json:"match"`
+	Nexthop     *string           `json:"nexthop"`
+	Priority    int64             `json:"priority"`
+}
+
+var _ types.IRow = &LogicalRouterPolicy{}
+
+func (row *LogicalRouterPolicy) OvsdbTableName() string {
+	return "Logical_Router_Policy"
+}
+
+func (row *LogicalRouterPolicy) OvsdbIsRoot() bool {
+	return false
+}
+
+func (row *LogicalRouterPolicy) OvsdbUuid() string {
+	return row.Uuid
+}
+
+func (row *LogicalRouterPolicy) OvsdbCmdArgs() []string {
+	r := []string{}
+	r = append(r, types.OvsdbCmdArgsString("action", row.Action)...)
+	r = append(r, types.OvsdbCmdArgsMapStringString("external_ids", row.ExternalIds)...)
+	r = append(r, types.OvsdbCmdArgsString("match", row.Match)...)
+	r = append(r, types.OvsdbCmdArgsStringOptional("nexthop", row.Nexthop)...)
+	r = append(r, types.OvsdbCmdArgsInteger("priority", row.Priority)...)
+	return r
+}
+
+func (row *LogicalRouterPolicy) SetColumn(name string, val interface{}) (err error) {
+	defer func() {
+		if panicErr := recover(); panicErr != nil {
+			err = errors.Wrapf(panicErr.(error), "%s: %#v", name, fmt.Sprintf("%#v", val))
+		}
+	}()
+	switch name {
+	case "_uuid":
+		row.Uuid = types.EnsureUuid(val)
+	case "_version":
+		row.Version = types.EnsureUuid(val)
+	case "action":
+		row.Action = types.EnsureString(val)
+	case "external_ids":
+		row.ExternalIds = types.EnsureMapStringString(val)
+	case "match":
+		row.Match = types.EnsureString(val)
+	case "nexthop":
+		row.Nexthop = types.EnsureStringOptional(val)
+	case "priority":
+		row.Priority = types.EnsureInteger(val)
+	default:
+		panic(types.ErrUnknownColumn)
+	}
+	return
+}
+
+func (row *LogicalRouterPolicy) MatchNonZeros(row1 *LogicalRouterPolicy) bool {
+	if !types.MatchUuidIfNonZero(row.Uuid, row1.Uuid) {
+		return false
+	}
+	if !types.MatchUuidIfNonZero(row.Version, row1.Version) {
+		return false
+	}
+	if !types.MatchStringIfNonZero(row.Action, row1.Action) {
+		return false
+	}
+	if !types.MatchMapStringStringIfNonZero(row.ExternalIds, row1.ExternalIds) {
+		return false
+	}
+	if !types.MatchStringIfNonZero(row.Match, row1.Match) {
+		return false
+	}
+	if !types.MatchStringOptionalIfNonZero(row.Nexthop, row1.Nexthop) {
+		return false
+	}
+	if !types.MatchIntegerIfNonZero(row.Priority, row1.Priority) {
+		return false
+	}
+	return true
+}
+
+func (row *LogicalRouterPolicy) HasExternalIds() bool {
+	return true
+}
+
+func (row *LogicalRouterPolicy) SetExternalId(k, v string) {
+	if row.ExternalIds == nil {
+		row.ExternalIds = map[string]string{}
+	}
+	row.ExternalIds[k] = v
+}
+
+func (row *LogicalRouterPolicy) GetExternalId(k string) (string, bool) {
+	if row.ExternalIds == nil {
+		return "", false
+	}
+	r, ok := row.ExternalIds[k]
+	return r, ok
+}
+
+func (row *LogicalRouterPolicy) RemoveExternalId(k string) (string, bool) {
+	if row.ExternalIds == nil {
+		return "", false
+	}
+	r, ok := row.ExternalIds[k]
+	if ok {
+		delete(row.ExternalIds, k)
+	}
+	return r, ok
+}
+
 type LogicalRouterPortTable []LogicalRouterPort
 
 var _ types.ITable = &LogicalRouterPortTable{}
@@ -5,11 +5,11 @@ import (
 	"strconv"
 	"strings"
 
-	"yunion.io/x/ovsdb/schema/ovn_nb"
 	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/util/secrules"
 
 	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+	"yunion.io/x/onecloud/pkg/vpcagent/ovn/schema/ovn_nb"
 )
 
 const (
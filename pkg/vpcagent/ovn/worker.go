@@ -170,8 +170,19 @@ func (w *Worker) run(ctx context.Context, mss *agentmodels.ModelSets) (err error
 			continue
 		}
 		ovndb.ClaimVpcGuestDnsRecords(ctx, vpc)
+		ovndb.ClaimVpcRouteTables(ctx, vpc, mss.Vpcs)
+		ovndb.ClaimVpcNatGateways(ctx, vpc)
+		ovndb.ClaimVpcLoadbalancers(ctx, vpc)
 	}
 	ovndb.ClaimDnsRecords(ctx, mss.Vpcs, mss.DnsRecords)
 	ovndb.Sweep(ctx)
+
+	{
+		ovnsbctl := ovnutil.NewOvnSbCtl(w.opts.OvnSouthDatabase)
+		s := auth.GetAdminSession(ctx, w.opts.Region, "v2")
+		if err := ReportLoadbalancerBackendHealth(ctx, s, ovnsbctl, mss.Vpcs); err != nil {
+			log.Errorf("report loadbalancer backend health: %v", err)
+		}
+	}
 	return nil
 }
@@ -23,9 +23,10 @@ import (
 	"time"
 
 	"yunion.io/x/log"
-	"yunion.io/x/ovsdb/schema/ovn_nb"
 	"yunion.io/x/ovsdb/types"
 	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/vpcagent/ovn/schema/ovn_nb"
 )
 
 const ovnNbCtlTimeout = 8 * time.Second
@@ -40,12 +41,14 @@ func (res *CmdResult) Error() string {
 }
 
 type OvnNbCtl struct {
-	db string
+	db   string
+	prog string
 }
 
 func NewOvnNbCtl(db string) *OvnNbCtl {
 	cli := &OvnNbCtl{
-		db: db,
+		db:   db,
+		prog: "ovn-nbctl",
 	}
 	return cli
 }
@@ -67,7 +70,7 @@ func (cli *OvnNbCtl) run(ctx context.Context, args []string) *CmdResult {
 	defer cancel()
 
 	args = cli.prepArgs(args)
-	cmd := exec.CommandContext(ctx, "ovn-nbctl", args...)
+	cmd := exec.CommandContext(ctx, cli.prog, args...)
 	combined, err := cmd.CombinedOutput()
 	res := &CmdResult{
 		Output: string(combined),
@@ -82,7 +85,7 @@ func (cli *OvnNbCtl) Must(ctx context.Context, msg string, args []string) *CmdRe
 		panic(cli.errWrap(res, msg, args))
 	}
 	if cli.argsHasWrite(args) {
-		log.Infof("%s:\n%s", msg, ovnNbctlArgsString(args))
+		log.Infof("%s:\n%s", msg, ovnNbctlArgsString(cli.prog, args))
 	}
 	return res
 }
@@ -94,7 +97,7 @@ func (cli *OvnNbCtl) errWrap(err error, msg string, args []string) error {
 
 func (cli *OvnNbCtl) argsString(args []string) string {
 	args = cli.prepArgs(args)
-	s := ovnNbctlArgsString(args)
+	s := ovnNbctlArgsString(cli.prog, args)
 	return s
 }
 
@@ -112,14 +115,14 @@ func (cli *OvnNbCtl) argsHasWrite(args []string) bool {
 	return false
 }
 
-func ovnNbctlArgsString(args []string) string {
+func ovnNbctlArgsString(prog string, args []string) string {
 	var (
 		s       = ""
 		indent  = ""
 		indent1 = "\t"
 		indent2 = "\t\t"
 	)
-	s += "ovn-nbctl"
+	s += prog
 	for _, arg := range args {
 		if arg == "--" {
 			indent = indent1
@@ -162,6 +165,7 @@ func OvnNbctlArgsDestroy(irows []types.IRow) []string {
 		case *ovn_nb.LogicalRouterStaticRoute:
 		case *ovn_nb.ACL:
 		case *ovn_nb.QoS:
+		case *ovn_nb.NAT:
 		default:
 			if !irow.OvsdbIsRoot() {
 				panic(irow.OvsdbTableName())
@@ -0,0 +1,50 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovnutil
+
+import (
+	"encoding/json"
+
+	"yunion.io/x/ovsdb/cli_util"
+	"yunion.io/x/ovsdb/types"
+	"yunion.io/x/pkg/errors"
+)
+
+// UnmarshalJSON is like cli_util.UnmarshalJSON except that columns unknown
+// to the compiled schema are ignored.  Newer ovn releases add columns to
+// existing tables and we should be able to work with them
+func UnmarshalJSON(data []byte, itbl types.ITable) error {
+	list := &cli_util.List{}
+	if err := json.Unmarshal(data, list); err != nil {
+		return err
+	}
+	for _, row := range list.Data {
+		irow := itbl.NewRow()
+		for colI, col := range row {
+			if colI >= len(list.Headings) {
+				return errors.Errorf("bad index into list headings (%d>=%d)", colI, len(list.Headings))
+			}
+			colName := list.Headings[colI]
+			if err := irow.SetColumn(colName, col); err != nil {
+				if errors.Cause(err) == types.ErrUnknownColumn {
+					continue
+				}
+				return err
+			}
+		}
+		itbl.AppendRow(irow)
+	}
+	return nil
+}